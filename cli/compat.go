@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/flashbots/mev-boost/server"
+)
+
+// runCompat runs the compat subcommand, which probes relays and prints their compatibility matrix
+func runCompat(args []string) error {
+	fs := flag.NewFlagSet("compat", flag.ExitOnError)
+	relays := fs.String("relays", "", "relay urls - single entry or comma-separated list (scheme://pubkey@host)")
+	probes := fs.Int("probes", 10, "number of status requests per relay, for the latency percentiles")
+	timeoutMs := fs.Int("request-timeout", defaultRelayTimeoutMs, "timeout of each request to a relay [ms]")
+	format := fs.String("format", "table", "output format: table or json")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of %s compat [flags]:\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Probes the relays and prints their compatibility matrix: supported forks, encodings, cancellations, maximum registration batch size and latency percentiles.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *relays == "" {
+		fs.Usage()
+		return fmt.Errorf("no relays specified")
+	}
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("invalid format: %s", *format)
+	}
+	if *probes < 1 {
+		return fmt.Errorf("invalid number of probes: %d", *probes)
+	}
+
+	matrix := server.ProbeRelays(context.Background(), parseRelayURLs(*relays), *probes, time.Duration(*timeoutMs)*time.Millisecond)
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(matrix)
+	}
+	return server.WriteRelayCompatibilityTable(os.Stdout, matrix)
+}
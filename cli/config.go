@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/flashbots/mev-boost/server"
+)
+
+// configFiles are the config files of mev-boost, by the name used in the config subcommand
+var configFiles = map[string]struct {
+	title string
+	model any
+}{
+	"networks": {"mev-boost networks config (-networks-config)", []networkConfig{}},
+	"proposer": {"mev-boost proposer config (-proposer-config)", server.ProposerConfig{}},
+	"headers":  {"mev-boost response headers (-response-headers)", server.ResponseHeaders{}},
+}
+
+// runConfig runs the config subcommand, which prints the JSON Schema of a config file, for validation in editors
+// and linting of config files
+func runConfig(args []string) error {
+	names := make([]string, 0, len(configFiles))
+	for name := range configFiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fs := flag.NewFlagSet("config", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of %s config schema %s:\n", os.Args[0], strings.Join(names, "|"))
+		fmt.Fprintln(fs.Output(), "Prints the JSON Schema of a config file.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	file, ok := configFiles[fs.Arg(1)]
+	if fs.NArg() != 2 || fs.Arg(0) != "schema" || !ok {
+		fs.Usage()
+		return fmt.Errorf("expected schema and one of %s", strings.Join(names, ", "))
+	}
+
+	schema, err := server.JSONSchema(file.model, file.title)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(os.Stdout, string(data))
+	return err
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/flashbots/mev-boost/server"
+)
+
+// runDecrypt runs the decrypt subcommand, which decrypts files written with -at-rest-key (eg. exports or slot traces)
+// to stdout, for analysis
+func runDecrypt(args []string) error {
+	fs := flag.NewFlagSet("decrypt", flag.ExitOnError)
+	atRestKey := fs.String("at-rest-key", defaultAtRestKey, "key the files were encrypted with: file:PATH, env:NAME or vault:URL#FIELD")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of %s decrypt [flags] files:\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Decrypts files written by mev-boost with -at-rest-key to stdout. Files written without encryption are printed as is.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("no files specified")
+	}
+	if *atRestKey == "" {
+		return fmt.Errorf("no at-rest key specified")
+	}
+	key, err := server.LoadAtRestKey(*atRestKey)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range fs.Args() {
+		data, err := os.ReadFile(resolvePath(path))
+		if err != nil {
+			return err
+		}
+		if data, err = server.DecryptAtRest(key, data); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if _, err := os.Stdout.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
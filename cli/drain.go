@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// adminRelayDrainPath is the path of the admin API endpoint draining relays
+const adminRelayDrainPath = "/mev-boost/v1/admin/relays/drain"
+
+// runDrain runs the drain subcommand, which drains a relay of a running mev-boost instance with the admin API:
+// the relay gets no more getHeader calls, and is removed once the payloads of its bids for the current slot are
+// delivered
+func runDrain(args []string) error {
+	fs := flag.NewFlagSet("drain", flag.ExitOnError)
+	addr := fs.String("addr", defaultListenAddr, "listen-address of the mev-boost instance, which must run with -admin-api")
+	undo := fs.Bool("undo", false, "put the relay back into service instead")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of %s drain [flags] relay-host:\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Drains a relay of a running mev-boost instance, to remove it without missing the payload of a bid it delivered.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected a single relay host")
+	}
+
+	method := http.MethodPost
+	if *undo {
+		method = http.MethodDelete
+	}
+	u := url.URL{Scheme: "http", Host: *addr, Path: adminRelayDrainPath, RawQuery: url.Values{"relay": {fs.Arg(0)}}.Encode()}
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", resp.Status, body)
+	}
+	_, err = os.Stdout.Write(body)
+	return err
+}
@@ -0,0 +1,26 @@
+//go:build !windows
+
+package cli
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/flashbots/mev-boost/server"
+)
+
+// handleFeatureFlagSignals reloads the feature flags file on SIGHUP
+func handleFeatureFlagSignals(features *server.FeatureFlags) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for range signals {
+			if err := features.Reload(); err != nil {
+				log.WithError(err).Error("could not reload the feature flags, keeping the previous ones")
+				continue
+			}
+			log.WithField("features", features.Active()).Warn("feature flags reloaded")
+		}
+	}()
+}
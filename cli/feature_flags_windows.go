@@ -0,0 +1,8 @@
+//go:build windows
+
+package cli
+
+import "github.com/flashbots/mev-boost/server"
+
+// handleFeatureFlagSignals does nothing, Windows has no signals to reload the feature flags. The admin API can be used.
+func handleFeatureFlagSignals(features *server.FeatureFlags) {}
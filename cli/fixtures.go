@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/flashbots/mev-boost/server"
+)
+
+// runFixtures runs the fixtures subcommand, which converts the relay responses recorded with -relay-record-dir into
+// presets for the mock relay
+func runFixtures(args []string) error {
+	fs := flag.NewFlagSet("fixtures", flag.ExitOnError)
+	dir := fs.String("dir", "", "directory of the recorded relay responses (-relay-record-dir)")
+	relay := fs.String("relay", "", "host of the relay to keep the responses of, optional")
+	since := fs.String("since", "", "keep the responses recorded from this time (RFC 3339), optional")
+	until := fs.String("until", "", "keep the responses recorded until this time (RFC 3339), optional")
+	out := fs.String("out", "", "file to write the presets to, stdout if empty")
+	atRestKey := fs.String("at-rest-key", defaultAtRestKey, "key the responses were recorded with, if encrypted: file:PATH, env:NAME or vault:URL#FIELD")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of %s fixtures [flags]:\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Converts recorded relay responses into mock relay presets, a JSON list with the responses of each relay, which mock-relay -fixtures replays.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *dir == "" {
+		fs.Usage()
+		return fmt.Errorf("no directory specified")
+	}
+
+	var sinceTime, untilTime time.Time
+	var err error
+	if *since != "" {
+		if sinceTime, err = time.Parse(time.RFC3339, *since); err != nil {
+			return fmt.Errorf("invalid since time: %w", err)
+		}
+	}
+	if *until != "" {
+		if untilTime, err = time.Parse(time.RFC3339, *until); err != nil {
+			return fmt.Errorf("invalid until time: %w", err)
+		}
+	}
+
+	var key []byte
+	if *atRestKey != "" {
+		if key, err = server.LoadAtRestKey(*atRestKey); err != nil {
+			return err
+		}
+	}
+	fixtures, err := server.LoadRelayFixtures(resolvePath(*dir), key)
+	if err != nil {
+		return err
+	}
+	presets := server.GenerateRelayFixturePresets(fixtures, *relay, sinceTime, untilTime)
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(resolvePath(*out))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(presets)
+}
@@ -0,0 +1,26 @@
+//go:build !windows
+
+package cli
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/flashbots/mev-boost/server"
+)
+
+// handleLogLevelSignals makes the logs more verbose on SIGUSR1, and less verbose on SIGUSR2
+func handleLogLevelSignals(levels *server.LogLevels) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		for sig := range signals {
+			delta := 1
+			if sig == syscall.SIGUSR2 {
+				delta = -1
+			}
+			log.Warnf("log level set to %s", levels.Step(delta))
+		}
+	}()
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package cli
+
+import "github.com/flashbots/mev-boost/server"
+
+// handleLogLevelSignals does nothing, Windows has no signals to adjust the log level. The admin API can be used.
+func handleLogLevelSignals(levels *server.LogLevels) {}
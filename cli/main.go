@@ -1,6 +1,9 @@
 package cli
 
 import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"os"
@@ -14,31 +17,231 @@ import (
 )
 
 const (
-	genesisForkVersionMainnet = "0x00000000"
-	genesisForkVersionKiln    = "0x70000069" // https://github.com/eth-clients/merge-testnets/blob/main/kiln/config.yaml#L10
-	genesisForkVersionRopsten = "0x80000069"
-	genesisForkVersionSepolia = "0x90000069"
-	genesisForkVersionGoerli  = "0x00001020"
+	genesisForkVersionMainnet = server.GenesisForkVersionMainnet
+	genesisForkVersionKiln    = server.GenesisForkVersionKiln
+	genesisForkVersionRopsten = server.GenesisForkVersionRopsten
+	genesisForkVersionSepolia = server.GenesisForkVersionSepolia
+	genesisForkVersionGoerli  = server.GenesisForkVersionGoerli
+	genesisForkVersionGnosis  = server.GenesisForkVersionGnosis
+
+	genesisTimeMainnet = 1606824023
+	genesisTimeKiln    = 1647007500
+	genesisTimeRopsten = 1653922800
+	genesisTimeSepolia = 1655733600
+	genesisTimeGoerli  = 1616508000
+	genesisTimeGnosis  = 1638993340
 )
 
 var (
 	// defaults
 	defaultLogJSON            = os.Getenv("LOG_JSON") != ""
 	defaultLogLevel           = getEnv("LOG_LEVEL", "info")
+	defaultLogFile            = getEnv("LOG_FILE", "")
 	defaultListenAddr         = getEnv("BOOST_LISTEN_ADDR", "localhost:18550")
 	defaultRelayTimeoutMs     = getEnvInt("RELAY_TIMEOUT_MS", 2000) // timeout for all the requests to the relay
 	defaultRelayCheck         = os.Getenv("RELAY_STARTUP_CHECK") != ""
+	defaultStatusCacheTTLMs   = getEnvInt("STATUS_CACHE_TTL_MS", 1000)
+	defaultStatusCacheStaleMs = getEnvInt("STATUS_CACHE_STALE_MS", 12000)
 	defaultGenesisForkVersion = getEnv("GENESIS_FORK_VERSION", "")
+	defaultGenesisTime        = getEnvInt("GENESIS_TIMESTAMP", 0)
+	defaultPartialDeadlineMs  = getEnvInt("GETHEADER_PARTIAL_DEADLINE_MS", 0)
+	defaultPayloadStaggerMs   = getEnvInt("GETPAYLOAD_STAGGER_MS", 0)
+	defaultRespDeadlineMs     = getEnvInt("GETHEADER_RESPONSE_DEADLINE_MS", 0)
+	defaultPayloadSLAMs       = getEnvInt("PAYLOAD_DELIVERY_SLA_MS", 0)
+	defaultPayloadVerifyPol   = getEnv("PAYLOAD_VERIFICATION_POLICY", string(server.PayloadVerificationStrict))
+	defaultPayloadVerifyMs    = getEnvInt("PAYLOAD_VERIFICATION_DEADLINE_MS", 0)
+	defaultVerifyPayload      = os.Getenv("VERIFY_PAYLOAD_ROOTS") != ""
+	defaultRelayMaintenance   = getEnv("RELAY_MAINTENANCE", "")
+	defaultRelayValueUnits    = getEnv("RELAY_VALUE_UNITS", "")
+	defaultRelayEscrow        = getEnv("RELAY_ESCROW_VERIFICATION", "")
+	defaultUntrustedRelays    = getEnv("UNTRUSTED_RELAYS", "")
+	defaultUntrustedMargin    = getEnvFloat("UNTRUSTED_RELAY_BID_MARGIN", 5)
+	defaultUntrustedMaxResp   = getEnvInt("UNTRUSTED_RELAY_MAX_RESPONSE_SIZE", 64<<10)
+	defaultUntrustedMaxPayld  = getEnvInt("UNTRUSTED_RELAY_MAX_PAYLOAD_SIZE", 16<<20)
+	defaultRelayTags          = getEnv("RELAY_TAGS", "")
+	defaultRelayMinBids       = getEnv("RELAY_MIN_BID", "")
+	defaultMinBid             = getEnv("MIN_BID", "")
+	defaultRelayTagPolicies   = getEnv("RELAY_TAG_POLICIES", "")
+	defaultBidPolicy          = getEnv("BID_POLICY", "")
+	defaultRelayTagEnforce    = os.Getenv("RELAY_TAG_POLICIES_ENFORCE") != ""
+	defaultRelayTransport     = getEnv("RELAY_TRANSPORT", "")
+	defaultRelayMaxIdleConns  = getEnvInt("RELAY_MAX_IDLE_CONNS", server.DefaultRelayTransport.MaxIdleConns)
+	defaultRelayIdleTimeout   = getEnvInt("RELAY_IDLE_TIMEOUT_SEC", int(server.DefaultRelayTransport.IdleConnTimeout.Seconds()))
+	defaultRelayTLSCache      = getEnvInt("RELAY_TLS_SESSION_CACHE", server.DefaultRelayTransport.TLSSessionCacheSize)
+	defaultRelayMaxRedirects  = getEnvInt("RELAY_MAX_REDIRECTS", server.DefaultRelayTransport.MaxRedirects)
+	defaultRelayCapsInterval  = getEnvInt("RELAY_CAPABILITIES_INTERVAL_SEC", 0)
+	defaultRelayChallenge     = getEnvInt("RELAY_CHALLENGE_INTERVAL_SEC", 0)
+	defaultRelayScoreHalfLife = getEnvInt("RELAY_SCORE_HALF_LIFE_SEC", 3600)
+	defaultRelayProbation     = getEnvInt("RELAY_SCORE_PROBATION_SEC", 3600)
+	defaultNetworksConfig     = getEnv("NETWORKS_CONFIG", "")
+	defaultTimeoutGetHeader   = getEnvInt("TIMEOUT_GETHEADER_MS", 0)
+	defaultTimeoutGetPayload  = getEnvInt("TIMEOUT_GETPAYLOAD_MS", 0)
+	defaultTimeoutRegVal      = getEnvInt("TIMEOUT_REGVAL_MS", 0)
+	defaultForkSchedule       = getEnv("FORK_SCHEDULE", "")
+	defaultChain              = getEnv("CHAIN", "")
+	defaultSigCacheSize       = getEnvInt("SIGNATURE_CACHE_SIZE", 1024)
+	defaultRegCacheSizeMiB    = getEnvInt("REGISTRATION_CACHE_SIZE_MIB", 64)
+	defaultDebugAPI           = os.Getenv("DEBUG_API") != ""
+	defaultAdminAPI           = os.Getenv("ADMIN_API") != ""
+	defaultAdminAPIToken      = getEnv("ADMIN_API_TOKEN", "")
+	defaultSecretsReload      = getEnvInt("SECRETS_RELOAD_INTERVAL_SEC", 60)
+	defaultDebugPublicAddr    = getEnv("DEBUG_API_PUBLIC_ADDR", "")
+	defaultDebugRedact        = getEnv("DEBUG_API_REDACT", server.DebugFieldPubkey)
+	defaultRegRateLimit       = getEnvFloat("REGISTRATION_RATE_LIMIT", 0)
+	defaultRegRateLimitPerIP  = getEnvFloat("REGISTRATION_RATE_LIMIT_PER_IP", 0)
+	defaultRegRateLimitBurst  = getEnvInt("REGISTRATION_RATE_LIMIT_BURST", 10)
+	defaultSpecStrict         = os.Getenv("SPEC_STRICT") != ""
+	defaultRelayBidRateLimit  = getEnvFloat("RELAY_BID_RATE_LIMIT", 0)
+	defaultRelayBidRateBurst  = getEnvInt("RELAY_BID_RATE_LIMIT_BURST", 5)
+	defaultRelayBudget        = getEnvFloat("RELAY_REQUEST_BUDGET", 0)
+	defaultRelayBudgetBurst   = getEnvInt("RELAY_REQUEST_BUDGET_BURST", 100)
+	defaultRelayMaxRespSize   = getEnvInt("RELAY_MAX_RESPONSE_SIZE", 1<<20)
+	defaultRelayMaxPayload    = getEnvInt("RELAY_MAX_PAYLOAD_SIZE", 32<<20)
+	defaultBeaconNodeURL      = getEnv("BEACON_NODE_URL", "")
+	defaultBidOracleURL       = getEnv("BID_ORACLE_URL", "")
+	defaultServedHeaderURL    = getEnv("SERVED_HEADER_URL", "")
+	defaultRelayMonitors      = getEnv("RELAY_MONITORS", "")
+	defaultPeers              = getEnv("PEERS", "")
+	defaultPeerSecret         = getEnv("PEER_SECRET", "")
+	defaultPeerSecretSource   = getEnv("PEER_SECRET_SOURCE", "")
+	defaultProposerMetrics    = getEnvInt("PROPOSER_METRICS_LIMIT", 0)
+	defaultExperimentCohorts  = getEnv("EXPERIMENT_COHORTS", "")
+	defaultExperimentBySlot   = os.Getenv("EXPERIMENT_BY_SLOT") != ""
+	defaultRegQueue           = os.Getenv("REGISTRATION_QUEUE") != ""
+	defaultRegQueueFile       = getEnv("REGISTRATION_QUEUE_FILE", "")
+	defaultRegQueuePacingMs   = getEnvInt("REGISTRATION_QUEUE_PACING_MS", 0)
+	defaultAtRestKey          = getEnv("AT_REST_KEY", "")
+	defaultPrefetchLeadTimeMs = getEnvInt("GETHEADER_PREFETCH_MS", 0)
+	defaultDutyCheck          = getEnv("GETHEADER_DUTY_CHECK", string(server.DutyCheckOff))
+	defaultSamplingInterval   = getEnvInt("RELAY_SAMPLING_INTERVAL_SEC", 0)
+	defaultSamplingPubkey     = getEnv("RELAY_SAMPLING_PUBKEY", "")
+	defaultExpectedValidators = getEnv("EXPECTED_VALIDATORS_FILE", "")
+	defaultProposerConfig     = getEnv("PROPOSER_CONFIG_FILE", "")
+	defaultProposerImport     = getEnv("PROPOSER_IMPORT", "")
+	defaultProposerImportTok  = getEnv("PROPOSER_IMPORT_TOKEN", "")
+	defaultProposerImportSec  = getEnvInt("PROPOSER_IMPORT_INTERVAL_SEC", 300)
+	defaultProposerConfigSec  = getEnvInt("PROPOSER_CONFIG_INTERVAL_SEC", 60)
+	defaultSlotTraceDir       = getEnv("SLOT_TRACE_DIR", "")
+	defaultRelayRecordDir     = getEnv("RELAY_RECORD_DIR", "")
+	defaultResponseHeaders    = getEnv("RESPONSE_HEADERS_FILE", "")
+	defaultExportTarget       = getEnv("EXPORT_TARGET", "")
+	defaultExportIntervalSec  = getEnvInt("EXPORT_INTERVAL_SEC", 3600)
+	defaultExportS3Region     = getEnv("AWS_REGION", "")
+	defaultExportS3Endpoint   = getEnv("EXPORT_S3_ENDPOINT", "")
+	defaultTelemetryEndpoint  = getEnv("TELEMETRY_ENDPOINT", "")
+	defaultTelemetryInterval  = getEnvInt("TELEMETRY_INTERVAL_SEC", 3600)
+	defaultTelemetryEpsilon   = getEnvFloat("TELEMETRY_EPSILON", 1)
+	defaultFeatures           = getEnv("FEATURES", "")
+	defaultFeaturesFile       = getEnv("FEATURES_FILE", "")
+	defaultJSONCodec          = getEnv("JSON_CODEC", server.JSONCodecStd)
+	defaultRelayCertWarnDays  = getEnvInt("RELAY_CERT_WARN_DAYS", 14)
+	defaultAttestationKey     = getEnv("ATTESTATION_KEY", "")
+	defaultRetention          = getEnv("RETENTION", "")
 
 	// cli flags
 	printVersion = flag.Bool("version", false, "only print version")
 	logJSON      = flag.Bool("json", defaultLogJSON, "log in JSON format instead of text")
 	logLevel     = flag.String("loglevel", defaultLogLevel, "minimum loglevel: trace, debug, info, warn/warning, error, fatal, panic")
+	logFile      = flag.String("log-file", defaultLogFile, "append the logs to this file instead of stdout, eg. when running as a Windows service")
 
 	listenAddr     = flag.String("addr", defaultListenAddr, "listen-address for mev-boost server")
 	relayURLs      = flag.String("relays", "", "relay urls - single entry or comma-separated list (scheme://pubkey@host)")
 	relayTimeoutMs = flag.Int("request-timeout", defaultRelayTimeoutMs, "timeout for requests to a relay [ms]")
 	relayCheck     = flag.Bool("relay-check", defaultRelayCheck, "check relay status on startup and on the status API call")
+	statusCacheTTL = flag.Int("status-cache-ttl", defaultStatusCacheTTLMs, "how long the relay status checked on a status API call is reused, 0 to check the relays on every call [ms]")
+	statusStaleTTL = flag.Int("status-cache-stale", defaultStatusCacheStaleMs, "how long an expired relay status is still served while the relays are checked again in the background [ms]")
+
+	sigCacheSize      = flag.Int("signature-cache-size", defaultSigCacheSize, "number of relay signature verification results to cache, 0 to disable")
+	regCacheSizeMiB   = flag.Int("registration-cache-size", defaultRegCacheSizeMiB, "total size of the encoded registration batches cached for sending them to several relays, retries and replays, 0 to disable [MiB]")
+	debugAPI          = flag.Bool("debug-api", defaultDebugAPI, "enable the debug API endpoints (eg. /mev-boost/v1/debug/bids)")
+	adminAPI          = flag.Bool("admin-api", defaultAdminAPI, "enable the admin API endpoints (eg. POST /mev-boost/v1/admin/registrations/replay)")
+	adminAPIToken     = flag.String("admin-api-token", defaultAdminAPIToken, "require the bearer token read from file:PATH, env:NAME or vault:URL#FIELD on the admin API endpoints, optional")
+	secretsReload     = flag.Int("secrets-reload-interval", defaultSecretsReload, "interval for reading the secrets from files and Vault again, to rotate them without restart, 0 to disable [s]")
+	debugPublicAddr   = flag.String("debug-api-public-addr", defaultDebugPublicAddr, "optional listen-address serving the debug API endpoints to third parties, with the -debug-api-redact fields redacted")
+	debugRedact       = flag.String("debug-api-redact", defaultDebugRedact, "fields redacted on the public debug API - comma-separated list of pubkey (hashed), block_hash, value, relays, rejections, provenance")
+	regRateLimit      = flag.Float64("registration-rate-limit", defaultRegRateLimit, "maximum rate of incoming registerValidator calls, 0 to disable [requests/s]")
+	regRateLimitPerIP = flag.Float64("registration-rate-limit-per-ip", defaultRegRateLimitPerIP, "maximum rate of incoming registerValidator calls per source IP, 0 to disable [requests/s]")
+	regRateLimitBurst = flag.Int("registration-rate-limit-burst", defaultRegRateLimitBurst, "burst size for the registerValidator rate limits")
+	specStrict        = flag.Bool("spec-strict", defaultSpecStrict, "reject consensus client and relay messages deviating from the builder spec (eg. unknown fields, missing content type), instead of only counting them")
+	relayBidRateLimit = flag.Float64("relay-bid-rate-limit", defaultRelayBidRateLimit, "maximum rate of bids processed per relay, excess bids are dropped, 0 to disable [bids/s]")
+	relayBidRateBurst = flag.Int("relay-bid-rate-limit-burst", defaultRelayBidRateBurst, "burst size for the relay bid rate limit")
+	relayBudget       = flag.Float64("relay-request-budget", defaultRelayBudget, "maximum rate of requests to all relays together, requests wait for the budget until they time out (except getPayload), 0 to disable [requests/s]")
+	relayBudgetBurst  = flag.Int("relay-request-budget-burst", defaultRelayBudgetBurst, "burst size for the relay request budget")
+	relayMaxRespSize  = flag.Int("relay-max-response-size", defaultRelayMaxRespSize, "maximum size of a relay getHeader response, larger responses are dropped, 0 to disable [bytes]")
+	relayMaxPayload   = flag.Int("relay-max-payload-size", defaultRelayMaxPayload, "maximum size of a relay getPayload response, larger responses are dropped while being received, 0 to disable [bytes]")
+	regQueue          = flag.Bool("registration-queue", defaultRegQueue, "acknowledge registerValidator calls right away and deliver the registrations to the relays asynchronously, with retries")
+	regQueueFile      = flag.String("registration-queue-file", defaultRegQueueFile, "file to persist the registration queue to, optional")
+	atRestKey         = flag.String("at-rest-key", defaultAtRestKey, "encrypt the files written by mev-boost (the registration queue, exports, slot traces and relay recordings) with AES-GCM, using the hex-encoded 32-byte key from file:PATH, env:NAME or vault:URL#FIELD (KMS keys are not supported)")
+	proposerConfig    = flag.String("proposer-config", defaultProposerConfig, "JSON file or http(s) URL with the relays per proposer pubkey and default relays, and whether getPayload may fall back to other relays (get_payload_fallback: permissive or strict), read again on SIGHUP, optional")
+	proposerConfigSec = flag.Int("proposer-config-interval", defaultProposerConfigSec, "interval for fetching the proposer config again if -proposer-config is a URL, 0 to fetch it only at startup and on SIGHUP [s]")
+	proposerImport    = flag.String("proposer-import", defaultProposerImport, "import the validators of a Web3Signer (web3signer:URL) or of a validator client's keymanager API (keymanager:URL) into the proposer config, with their fee recipients and gas limits from the keymanager API, optional")
+	proposerImportTok = flag.String("proposer-import-token", defaultProposerImportTok, "bearer token of the keymanager API of -proposer-import, read from file:PATH, env:NAME or vault:URL#FIELD")
+	proposerImportSec = flag.Int("proposer-import-interval", defaultProposerImportSec, "interval for importing the validators of -proposer-import again, 0 to import them only at startup [s]")
+	expectedValFile   = flag.String("expected-validators", defaultExpectedValidators, "file with the pubkeys of the validators expected to register, one per line, which are included in the registration coverage report even if they never registered")
+	slotTraceDir      = flag.String("slot-trace-dir", defaultSlotTraceDir, "directory to write a trace of the relay requests and processing phases of each slot to, in Chrome trace format (viewable with Perfetto), optional")
+	responseHeaders   = flag.String("response-headers", defaultResponseHeaders, "JSON file with headers to add to the responses per path prefix, eg. CORS headers for browser-based monitoring tools, optional")
+	relayRecordDir    = flag.String("relay-record-dir", defaultRelayRecordDir, "directory to record the responses of the relays to, as fixtures for the mock relay (see the fixtures subcommand), optional")
+	exportTarget      = flag.String("export-target", defaultExportTarget, "directory or S3 location (s3://bucket/prefix, with the credentials from the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN env vars) to export the bids and slot outcomes to as CSV files, optional")
+	exportIntervalSec = flag.Int("export-interval", defaultExportIntervalSec, "interval between exports to -export-target [s]")
+	exportS3Region    = flag.String("export-s3-region", defaultExportS3Region, "region of the S3 bucket of -export-target (default: us-east-1)")
+	exportS3Endpoint  = flag.String("export-s3-endpoint", defaultExportS3Endpoint, "endpoint of an S3-compatible store for -export-target (eg. MinIO), instead of AWS, optional")
+	telemetryEndpoint = flag.String("telemetry-endpoint", defaultTelemetryEndpoint, "community endpoint to submit anonymous relay statistics (bid counts, latencies, consensus clients) to, without any pubkeys, optional (off by default)")
+	telemetryInterval = flag.Int("telemetry-interval", defaultTelemetryInterval, "interval between telemetry submissions to -telemetry-endpoint [s]")
+	telemetryEpsilon  = flag.Float64("telemetry-epsilon", defaultTelemetryEpsilon, "differential privacy budget of each telemetry report, covering all its counts and latencies, 0 for no noise")
+	featureFlags      = flag.String("features", defaultFeatures, "features to enable or disable (with a - prefix) - comma-separated list of concurrent_get_payload, bid_prefetch (all enabled by default)")
+	featureFlagsFile  = flag.String("features-file", defaultFeaturesFile, "file with the features to enable or disable, in the -features format and overriding it, read again on SIGHUP or with the admin API, optional")
+	retention         = flag.String("retention", defaultRetention, "retention policies of the data stored on disk - single entry or comma-separated list (store=max_age:72h;max_size_mb:1024), with the stores slot_traces, exports (local directory) and relay_records")
+	attestationKeySrc = flag.String("attestation-key", defaultAttestationKey, "sign an attestation of each served bid with the ed25519 key whose hex-encoded 32-byte seed is read from file:PATH, env:NAME or vault:URL#FIELD, optional")
+	relayCertWarnDays = flag.Int("relay-cert-warn-days", defaultRelayCertWarnDays, "warn when the TLS certificate of a relay expires within this many days, 0 to disable")
+	jsonCodecName     = flag.String("json-codec", defaultJSONCodec, "JSON codec: std (encoding/json) or fast (encoding/json with faster encoding of getHeader responses and registration batches)")
+	regQueuePacingMs  = flag.Int("registration-queue-pacing", defaultRegQueuePacingMs, "minimum interval between registration batches delivered to a relay [ms]")
+	relayMaintenance  = flag.String("relay-maintenance", defaultRelayMaintenance, "relay maintenance windows - single entry or comma-separated list (host=start/end, RFC3339 times)")
+	relayCapsInterval = flag.Int("relay-capabilities-interval", defaultRelayCapsInterval, "interval for polling relay capabilities, 0 to disable [s]")
+	relayChallenge    = flag.Int("relay-challenge-interval", defaultRelayChallenge, "interval for challenging relays to sign a nonce with their configured pubkey, starting at startup, 0 to disable [s]")
+	relayHalfLife     = flag.Int("relay-score-half-life", defaultRelayScoreHalfLife, "age at which a relay response counts half in the reliability score of the relay, 0 to count all responses fully [s]")
+	relayProbation    = flag.Int("relay-score-probation", defaultRelayProbation, "time after its first response during which the reliability score of a relay is at most that of a relay without responses [s]")
+	relayEscrow       = flag.String("relay-escrow-verification", defaultRelayEscrow, "relays whose payloads are always verified in full against the signed header, for optimistic relaying - single entry or comma-separated list of hosts")
+	untrustedRelays   = flag.String("untrusted-relays", defaultUntrustedRelays, "relays whose responses get stricter validation, and whose bids only win when exceeding the trusted bids by -untrusted-relay-bid-margin - single entry or comma-separated list of hosts")
+	untrustedMargin   = flag.Float64("untrusted-relay-bid-margin", defaultUntrustedMargin, "how much the bid of an untrusted relay must exceed the best trusted bid to win [%]")
+	untrustedMaxResp  = flag.Int("untrusted-relay-max-response-size", defaultUntrustedMaxResp, "maximum size of an untrusted relay's getHeader response, if lower than -relay-max-response-size [bytes]")
+	untrustedMaxPayld = flag.Int("untrusted-relay-max-payload-size", defaultUntrustedMaxPayld, "maximum size of an untrusted relay's getPayload response, if lower than -relay-max-payload-size [bytes]")
+	relayTags         = flag.String("relay-tags", defaultRelayTags, "key-value tags of relays, added to their logs and metrics (mev_boost_relay_tags_info) - single entry or comma-separated list (host=region:eu;operator:x)")
+	relayTagPolicies  = flag.String("relay-tag-policies", defaultRelayTagPolicies, "relay tags of which each getHeader request requires a bid from at least one relay, violations are logged and counted - comma-separated list (eg. region=eu)")
+	relayTagEnforce   = flag.Bool("relay-tag-policies-enforce", defaultRelayTagEnforce, "serve no bid if the bids violate a relay tag policy")
+	bidPolicy         = flag.String("bid-policy", defaultBidPolicy, "expression which bids must satisfy, bids not satisfying it are dropped before signature verification (eg. 'bid.value >= eth(0.01) && relay.tag(\"region\") == \"eu\"')")
+	minBid            = flag.String("min-bid", defaultMinBid, "minimum bid value served to proposers without min_bid in the proposer config, lower bids are dropped so the consensus client builds the block locally (eg. 0.05, in units of the native token), optional")
+	relayMinBids      = flag.String("relay-min-bid", defaultRelayMinBids, "minimum bid values per relay, lower bids are dropped before signature verification - single entry or comma-separated list (host=0.01, in units of the native token)")
+	relayValueUnits   = flag.String("relay-value-units", defaultRelayValueUnits, "units of bid values for relays not reporting in wei - single entry or comma-separated list (host=gwei)")
+	relayTransport    = flag.String("relay-transport", defaultRelayTransport, "connection settings per relay, overriding the -relay-max-idle-conns, -relay-idle-timeout, -relay-tls-session-cache and -relay-max-redirects defaults - single entry or comma-separated list (host=max_idle_conns:N;idle_timeout:30s;tls_session_cache:N;max_redirects:N)")
+	relayMaxIdleConns = flag.Int("relay-max-idle-conns", defaultRelayMaxIdleConns, "maximum number of idle keep-alive connections per relay")
+	relayIdleTimeout  = flag.Int("relay-idle-timeout", defaultRelayIdleTimeout, "time after which idle connections to a relay are closed [s]")
+	relayTLSCache     = flag.Int("relay-tls-session-cache", defaultRelayTLSCache, "number of TLS sessions cached per relay for resumption")
+	relayMaxRedirects = flag.Int("relay-max-redirects", defaultRelayMaxRedirects, "maximum number of redirects followed per relay request, only from HTTPS to HTTPS on the same host, 0 to refuse redirects")
+
+	timeoutGetHeaderMs  = flag.Int("timeout-getheader", defaultTimeoutGetHeader, "deadline for handling getHeader requests, 0 to disable [ms]")
+	timeoutGetPayloadMs = flag.Int("timeout-getpayload", defaultTimeoutGetPayload, "deadline for handling getPayload requests, 0 to disable [ms]")
+	timeoutRegValMs     = flag.Int("timeout-regval", defaultTimeoutRegVal, "deadline for handling registerValidator requests, 0 to disable [ms]")
+	partialDeadlineMs   = flag.Int("getheader-partial-deadline", defaultPartialDeadlineMs, "return the best bid so far if not all relays responded to getHeader within this time, 0 to disable [ms]")
+	respDeadlineMs      = flag.Int("getheader-response-deadline", defaultRespDeadlineMs, "time into the slot after which getHeader is answered right away with the bid already served or prefetched for the slot, or no bid, instead of requesting bids, requires the genesis time, 0 to disable [ms]")
+	payloadStaggerMs    = flag.Int("getpayload-stagger", defaultPayloadStaggerMs, "delay between getPayload calls to subsequent relays, relays which delivered the bid are called first, 0 to call all at once [ms]")
+	payloadSLAMs        = flag.Int("payload-delivery-sla", defaultPayloadSLAMs, "maximum time from serving a header to receiving its payload from a relay, after which the payload is reported at risk and the relays not responding yet are counted as exceeding it, 0 to disable [ms]")
+	verifyPayloadRoots  = flag.Bool("verify-payload-roots", defaultVerifyPayload, "recompute the transactions root of getPayload responses, and reject payloads not matching the signed header")
+	payloadVerifyPolicy = flag.String("payload-verification-policy", defaultPayloadVerifyPol, "handling of optional getPayload response checks (-verify-payload-roots) expected to exceed the time left until -timeout-getpayload or -payload-verification-deadline: strict (run them anyway) or degrade (skip them, log and count them)")
+	payloadVerifyMs     = flag.Int("payload-verification-deadline", defaultPayloadVerifyMs, "time into the slot by which getPayload responses must be checked, for -payload-verification-policy degrade, requires the genesis time, 0 to disable [ms]")
+	prefetchLeadTimeMs  = flag.Int("getheader-prefetch", defaultPrefetchLeadTimeMs, "request bids this long before the slots of registered validators' proposals and serve getHeader from them, requires -beacon-node, 0 to disable [ms]")
+	dutyCheck           = flag.String("getheader-duty-check", defaultDutyCheck, "handling of getHeader calls for pubkeys without a proposal duty in the epoch, requires -beacon-node: off, reject (respond with an error) or deprioritize (request bids for one such call at a time, no bid for the others)")
+	samplingInterval    = flag.Int("relay-sampling-interval", defaultSamplingInterval, "request bids for the current slot this often with -relay-sampling-pubkey, except in slots with a getHeader call, to score the relays in between proposals, requires -beacon-node, 0 to disable [s]")
+	samplingPubkey      = flag.String("relay-sampling-pubkey", defaultSamplingPubkey, "pubkey with which the relays are sampled, its bids are never served")
+	beaconNodeURL       = flag.String("beacon-node", defaultBeaconNodeURL, "optional beacon node API url, used for prefetching bids and checking proposal duties")
+	experimentCohorts   = flag.String("experiment-cohorts", defaultExperimentCohorts, "experiment cohorts requesting bids from their own relay sets, the other proposers request bids from all relays - comma-separated list (name=percent:host|host, eg. a=25:relay1.com|relay2.com)")
+	experimentBySlot    = flag.Bool("experiment-by-slot", defaultExperimentBySlot, "assign slots instead of proposers to the experiment cohorts")
+	proposerMetrics     = flag.Int("proposer-metrics-limit", defaultProposerMetrics, "maximum number of proposers with their own per-proposer metrics (labelled by shortened pubkey), further proposers are aggregated as \"other\", 0 to disable")
+	relayMonitorURLs    = flag.String("relay-monitors", defaultRelayMonitors, "relay monitor urls to send auction transcripts (signed bids, acceptances and payload outcomes) to - single entry or comma-separated list")
+	peerURLs            = flag.String("peers", defaultPeers, "urls of peer mev-boost instances to share bid summaries with, requires -peer-secret - single entry or comma-separated list")
+	peerSecret          = flag.String("peer-secret", defaultPeerSecret, "secret shared by the peer mev-boost instances, enables receiving bid summaries from peers")
+	peerSecretSource    = flag.String("peer-secret-source", defaultPeerSecretSource, "read the peer secret from file:PATH, env:NAME or vault:URL#FIELD instead of -peer-secret")
+	servedHeaderURL     = flag.String("served-header-url", defaultServedHeaderURL, "optional url receiving a POST request with the slot and block hash of each served header, eg. to detect the validator signing a different block")
+	bidOracleURL        = flag.String("bid-oracle", defaultBidOracleURL, "optional url of a source of publicly observed bids implementing the relay data API, to compare the served bids with")
 
 	// helpers
 	useGenesisForkVersionMainnet = flag.Bool("mainnet", false, "use Mainnet")
@@ -46,13 +249,59 @@ var (
 	useGenesisForkVersionRopsten = flag.Bool("ropsten", false, "use Ropsten")
 	useGenesisForkVersionSepolia = flag.Bool("sepolia", false, "use Sepolia")
 	useGenesisForkVersionGoerli  = flag.Bool("goerli", false, "use Goerli")
+	useGenesisForkVersionGnosis  = flag.Bool("gnosis", false, "use Gnosis Chain")
+	networksConfig               = flag.String("networks-config", defaultNetworksConfig, "serve multiple networks from one process, as configured in this JSON file (replaces -relays, -addr and the network flags)")
 	useCustomGenesisForkVersion  = flag.String("genesis-fork-version", defaultGenesisForkVersion, "use a custom genesis fork version")
+	useCustomGenesisTime         = flag.Int("genesis-timestamp", defaultGenesisTime, "use a custom genesis timestamp, to verify bids are built for the requested slot (known for the network flags)")
+	chainName                    = flag.String("chain", defaultChain, "chain of the network, for its slot timing and native token: ethereum or gnosis (known for the network flags, default: ethereum)")
+	forkSchedule                 = flag.String("fork-schedule", defaultForkSchedule, "fork activation epochs used to verify relay response versions - comma-separated list (name:epoch, eg. bellatrix:144896)")
 )
 
-var log = logrus.WithField("module", "cli")
+var (
+	log       = logrus.WithField("module", "cli")
+	logLevels *server.LogLevels    // adjusted at runtime by the admin API and signals
+	features  *server.FeatureFlags // reloaded at runtime by the admin API and signals
+)
 
 // Main starts the mev-boost cli
 func Main() {
+	if len(os.Args) > 1 && os.Args[1] == "report" {
+		if err := runReport(os.Args[2:]); err != nil {
+			log.WithError(err).Fatal("could not create the report")
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "drain" {
+		if err := runDrain(os.Args[2:]); err != nil {
+			log.WithError(err).Fatal("could not drain the relay")
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "compat" {
+		if err := runCompat(os.Args[2:]); err != nil {
+			log.WithError(err).Fatal("could not probe the relays")
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "fixtures" {
+		if err := runFixtures(os.Args[2:]); err != nil {
+			log.WithError(err).Fatal("could not generate the fixtures")
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "decrypt" {
+		if err := runDecrypt(os.Args[2:]); err != nil {
+			log.WithError(err).Fatal("could not decrypt the files")
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		if err := runConfig(os.Args[2:]); err != nil {
+			log.WithError(err).Fatal("could not print the config schema")
+		}
+		return
+	}
+
 	flag.Parse()
 	logrus.SetOutput(os.Stdout)
 
@@ -61,6 +310,22 @@ func Main() {
 		return
 	}
 
+	if runAsService(run) {
+		return
+	}
+	run(nil)
+}
+
+// run starts mev-boost, and returns when stop is closed
+func run(stop <-chan struct{}) {
+	if *logFile != "" {
+		f, err := os.OpenFile(resolvePath(*logFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			log.WithError(err).Fatal("could not open the log file")
+		}
+		logrus.SetOutput(f)
+	}
+
 	if *logJSON {
 		log.Logger.SetFormatter(&logrus.JSONFormatter{})
 	} else {
@@ -78,27 +343,68 @@ func Main() {
 		}
 		logrus.SetLevel(lvl)
 	}
+	logLevels = server.NewLogLevels(log.Logger)
+	handleLogLevelSignals(logLevels)
+
+	flags, err := server.ParseFeatureFlags(*featureFlags)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid feature flags")
+	}
+	features, err = server.NewFeatureFlags(flags, resolvePath(*featureFlagsFile))
+	if err != nil {
+		log.WithError(err).Fatal("Invalid feature flags file")
+	}
+	handleFeatureFlagSignals(features)
+	log.WithField("features", features.Active()).Info("enabled features")
 
 	log.Infof("mev-boost %s", config.Version)
 
+	if *networksConfig != "" {
+		runNetworks(resolvePath(*networksConfig), stop)
+		return
+	}
+
 	genesisForkVersionHex := ""
+	genesisTime := uint64(0)
+	chain := server.ChainEthereum
 	if *useCustomGenesisForkVersion != "" {
 		genesisForkVersionHex = *useCustomGenesisForkVersion
 	} else if *useGenesisForkVersionMainnet {
 		genesisForkVersionHex = genesisForkVersionMainnet
+		genesisTime = genesisTimeMainnet
 	} else if *useGenesisForkVersionKiln {
 		genesisForkVersionHex = genesisForkVersionKiln
+		genesisTime = genesisTimeKiln
 	} else if *useGenesisForkVersionRopsten {
 		genesisForkVersionHex = genesisForkVersionRopsten
+		genesisTime = genesisTimeRopsten
 	} else if *useGenesisForkVersionSepolia {
 		genesisForkVersionHex = genesisForkVersionSepolia
+		genesisTime = genesisTimeSepolia
 	} else if *useGenesisForkVersionGoerli {
 		genesisForkVersionHex = genesisForkVersionGoerli
+		genesisTime = genesisTimeGoerli
+	} else if *useGenesisForkVersionGnosis {
+		genesisForkVersionHex = genesisForkVersionGnosis
+		genesisTime = genesisTimeGnosis
+		chain = server.ChainGnosis
 	} else {
 		flag.Usage()
-		log.Fatal("Please specify a genesis fork version (eg. -mainnet / -kiln / -ropsten / -sepolia / -goerli / -genesis-fork-version flags)")
+		log.Fatal("Please specify a genesis fork version (eg. -mainnet / -kiln / -ropsten / -sepolia / -goerli / -gnosis / -genesis-fork-version flags)")
 	}
 	log.Infof("Using genesis fork version: %s", genesisForkVersionHex)
+	if *useCustomGenesisTime > 0 {
+		genesisTime = uint64(*useCustomGenesisTime)
+	}
+	if *chainName != "" {
+		var err error
+		if chain, err = server.ParseChain(*chainName); err != nil {
+			log.WithError(err).Fatal("Invalid chain")
+		}
+	}
+	if chain.Name != server.ChainEthereum.Name {
+		log.Infof("Using chain: %s", chain.Name)
+	}
 
 	relays := parseRelayURLs(*relayURLs)
 	if len(relays) == 0 {
@@ -106,31 +412,375 @@ func Main() {
 		log.Fatal("No relays specified")
 	}
 	log.WithField("relays", relays).Infof("using %d relays", len(relays))
+	configureRelays(log, relays)
+
+	opts := newBoostServiceOpts(log)
+	opts.ListenAddr = *listenAddr
+	opts.DebugAPIPublicListenAddr = *debugPublicAddr
+	opts.Relays = relays
+	opts.GenesisForkVersionHex = genesisForkVersionHex
+	opts.GenesisTime = genesisTime
+	opts.Chain = chain
+	opts.BeaconNodeURL = *beaconNodeURL
+	opts.BidOracleURL = *bidOracleURL
+	opts.ServedHeaderURL = *servedHeaderURL
+
+	service, err := server.NewBoostService(opts)
+	if err != nil {
+		log.WithError(err).Fatal("failed creating the server")
+	}
+
+	if *relayCheck && !service.CheckRelays() {
+		log.Fatal("no relay available")
+	}
+
+	log.Println("listening on", *listenAddr)
+	if err := serve([]*server.BoostService{service}, stop); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// newBoostServiceOpts returns the service options shared by all networks, as configured by the cli flags
+func newBoostServiceOpts(log *logrus.Entry) server.BoostServiceOpts {
+	schedule, err := server.ParseForkSchedule(*forkSchedule)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid fork schedule")
+	}
+	if len(schedule) > 0 {
+		log.WithField("forkSchedule", schedule).Info("using fork schedule")
+	}
+
+	debugRedactFields, err := server.ParseDebugRedactFields(*debugRedact)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid debug API redaction")
+	}
+
+	tagPolicies, err := server.ParseRelayTagPolicies(*relayTagPolicies)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid relay tag policies")
+	}
+
+	minBidValue, err := server.ParseMinBidValue(*minBid)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid minimum bid value")
+	}
+	if minBidValue != nil {
+		log.Infof("minimum bid value: %s wei", minBidValue.String())
+	}
+
+	var policy *server.BidPolicy
+	if *bidPolicy != "" {
+		policy, err = server.ParseBidPolicy(*bidPolicy)
+		if err != nil {
+			log.WithError(err).Fatal("Invalid bid policy")
+		}
+	}
+
+	// Peer URLs are parsed like relay monitor URLs
+	peers := server.ParseRelayMonitorURLs(*peerURLs)
+	peerSecretValue := server.StaticSecret(*peerSecret)
+	if *peerSecretSource != "" {
+		peerSecretValue, err = server.LoadSecret(*peerSecretSource)
+		if err != nil {
+			log.WithError(err).Fatal("Invalid peer secret")
+		}
+	}
+	if len(peers) > 0 && peerSecretValue.Value() == "" {
+		log.Fatal("Peers require a peer secret, set -peer-secret or -peer-secret-source")
+	}
+
+	var adminToken *server.Secret
+	if *adminAPIToken != "" {
+		adminToken, err = server.LoadSecret(*adminAPIToken)
+		if err != nil {
+			log.WithError(err).Fatal("Invalid admin API token")
+		}
+	}
+
+	cohorts, err := server.ParseExperimentCohorts(*experimentCohorts)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid experiment cohorts")
+	}
+	cohortUnit := "proposers"
+	if *experimentBySlot {
+		cohortUnit = "slots"
+	}
+	for _, cohort := range cohorts {
+		log.WithField("relays", cohort.Relays).Infof("experiment cohort %s: %g%% of the %s", cohort.Name, cohort.Share*100, cohortUnit)
+	}
+
+	expectedValidators := []string{}
+	if *expectedValFile != "" {
+		expectedValidators, err = server.ReadValidatorPubkeys(resolvePath(*expectedValFile))
+		if err != nil {
+			log.WithError(err).Fatal("Invalid expected validators file")
+		}
+	}
+
+	var headers server.ResponseHeaders
+	if *responseHeaders != "" {
+		headers, err = server.LoadResponseHeaders(resolvePath(*responseHeaders))
+		if err != nil {
+			log.WithError(err).Fatal("Invalid response headers")
+		}
+	}
+
+	var proposers *server.ProposerConfig
+	if server.IsRemoteProposerConfig(*proposerConfig) {
+		proposers, err = server.FetchProposerConfig(context.Background(), *proposerConfig)
+		if err != nil {
+			log.WithError(err).Fatal("Could not fetch the proposer config")
+		}
+		log.Infof("using the relay settings of %d proposers", len(proposers.Proposers))
+	} else if *proposerConfig != "" {
+		proposers, err = server.LoadProposerConfig(resolvePath(*proposerConfig))
+		if err != nil {
+			log.WithError(err).Fatal("Invalid proposer config")
+		}
+		log.Infof("using the relay settings of %d proposers", len(proposers.Proposers))
+	}
+
+	var importer *server.ProposerImporter
+	if *proposerImport != "" {
+		var token *server.Secret
+		if *proposerImportTok != "" {
+			token, err = server.LoadSecret(*proposerImportTok)
+			if err != nil {
+				log.WithError(err).Fatal("Invalid proposer import token")
+			}
+		}
+		importer, err = server.NewProposerImporter(*proposerImport, token)
+		if err != nil {
+			log.WithError(err).Fatal("Invalid proposer import")
+		}
+	}
+
+	var key []byte
+	if *atRestKey != "" {
+		key, err = server.LoadAtRestKey(*atRestKey)
+		if err != nil {
+			log.WithError(err).Fatal("Invalid at-rest key")
+		}
+	}
+
+	retentionPolicies, err := server.ParseRetentionPolicies(*retention)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid retention policies")
+	}
+
+	var attestationKey ed25519.PrivateKey
+	if *attestationKeySrc != "" {
+		attestationKey, err = server.LoadAttestationKey(*attestationKeySrc)
+		if err != nil {
+			log.WithError(err).Fatal("Invalid attestation key")
+		}
+		log.WithField("publicKey", "0x"+hex.EncodeToString(attestationKey.Public().(ed25519.PublicKey))).Info("attesting the served bids")
+	}
 
 	relayTimeout := time.Duration(*relayTimeoutMs) * time.Millisecond
 	if relayTimeout <= 0 {
 		log.Fatal("Please specify a relay timeout greater than 0")
 	}
 
-	opts := server.BoostServiceOpts{
-		Log:                   log,
-		ListenAddr:            *listenAddr,
-		Relays:                relays,
-		GenesisForkVersionHex: genesisForkVersionHex,
-		RelayRequestTimeout:   relayTimeout,
-		RelayCheck:            *relayCheck,
+	target := *exportTarget
+	if target != "" && !strings.HasPrefix(target, "s3://") {
+		target = resolvePath(target)
+	}
+	if target != "" && *exportIntervalSec <= 0 {
+		log.Fatal("Please specify an export interval greater than 0")
+	}
+	if *telemetryEndpoint != "" && (*telemetryInterval <= 0 || *telemetryEpsilon < 0) {
+		log.Fatal("Please specify a telemetry interval greater than 0 and a telemetry epsilon of at least 0")
 	}
-	server, err := server.NewBoostService(opts)
+
+	jsonCodec, err := server.JSONCodecByName(*jsonCodecName)
 	if err != nil {
-		log.WithError(err).Fatal("failed creating the server")
+		log.WithError(err).Fatal("Invalid JSON codec")
 	}
 
-	if *relayCheck && !server.CheckRelays() {
-		log.Fatal("no relay available")
+	dutyCheckMode, err := server.ParseDutyCheckMode(*dutyCheck)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid getHeader duty check")
 	}
 
-	log.Println("listening on", *listenAddr)
-	log.Fatal(server.StartHTTPServer())
+	payloadVerification, err := server.ParsePayloadVerificationPolicy(*payloadVerifyPolicy)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid payload verification policy")
+	}
+
+	return server.BoostServiceOpts{
+		Log:                     log,
+		JSONCodec:               jsonCodec,
+		AttestationKey:          attestationKey,
+		Retention:               retentionPolicies,
+		RelayCertificateWarning: time.Duration(*relayCertWarnDays) * 24 * time.Hour,
+		RelayRequestTimeout:     relayTimeout,
+		RelayCheck:              *relayCheck,
+		StatusCacheTTL:          time.Duration(*statusCacheTTL) * time.Millisecond,
+		StatusCacheStaleTTL:     time.Duration(*statusStaleTTL) * time.Millisecond,
+		RelayMonitors:           server.ParseRelayMonitorURLs(*relayMonitorURLs),
+		Peers:                   peers,
+		PeerSecret:              peerSecretValue,
+		AdminAPIToken:           adminToken,
+		SecretsReloadInterval:   time.Duration(*secretsReload) * time.Second,
+		ProposerMetricsLimit:    *proposerMetrics,
+		ExpectedValidators:      expectedValidators,
+		ProposerConfig:          proposers,
+		ProposerImporter:        importer,
+		ProposerImportInterval:  time.Duration(*proposerImportSec) * time.Second,
+		SlotTraceDir:            resolvePath(*slotTraceDir),
+		RelayRecordDir:          resolvePath(*relayRecordDir),
+		ResponseHeaders:         headers,
+		Experiment:              server.Experiment{Cohorts: cohorts, BySlot: *experimentBySlot},
+
+		GetHeaderPartialDeadline:  time.Duration(*partialDeadlineMs) * time.Millisecond,
+		GetPayloadStagger:         time.Duration(*payloadStaggerMs) * time.Millisecond,
+		GetHeaderResponseDeadline: time.Duration(*respDeadlineMs) * time.Millisecond,
+		RelayTagPolicies:          tagPolicies,
+		RelayTagPoliciesEnforce:   *relayTagEnforce,
+		BidPolicy:                 policy,
+		MinBidValue:               minBidValue,
+		PayloadDeliverySLA:        time.Duration(*payloadSLAMs) * time.Millisecond,
+		VerifyPayloadRoots:        *verifyPayloadRoots,
+		ForkSchedule:              schedule,
+		SignatureCacheSize:        *sigCacheSize,
+		DebugAPI:                  *debugAPI,
+		DebugAPIRedactFields:      debugRedactFields,
+		AdminAPI:                  *adminAPI,
+		LogLevels:                 logLevels,
+		FeatureFlags:              features,
+
+		ProposerConfigPollInterval: time.Duration(*proposerConfigSec) * time.Second,
+
+		PayloadVerificationPolicy:   payloadVerification,
+		PayloadVerificationDeadline: time.Duration(*payloadVerifyMs) * time.Millisecond,
+
+		RegistrationRateLimit:      *regRateLimit,
+		RegistrationRateLimitPerIP: *regRateLimitPerIP,
+		RegistrationRateLimitBurst: *regRateLimitBurst,
+
+		RegistrationEncodingCacheSize: *regCacheSizeMiB << 20,
+
+		SpecStrict:             *specStrict,
+		RelayBidRateLimit:      *relayBidRateLimit,
+		RelayBidRateLimitBurst: *relayBidRateBurst,
+
+		OutboundRequestBudget:      *relayBudget,
+		OutboundRequestBudgetBurst: *relayBudgetBurst,
+		RelayMaxResponseSize:       int64(*relayMaxRespSize),
+		RelayMaxPayloadSize:        int64(*relayMaxPayload),
+
+		UntrustedRelayMaxResponseSize: int64(*untrustedMaxResp),
+		UntrustedRelayMaxPayloadSize:  int64(*untrustedMaxPayld),
+		UntrustedRelayBidMargin:       *untrustedMargin,
+
+		RegistrationQueue:       *regQueue,
+		RegistrationQueueFile:   resolvePath(*regQueueFile),
+		RegistrationQueuePacing: time.Duration(*regQueuePacingMs) * time.Millisecond,
+		AtRestKey:               key,
+
+		RelayCapabilitiesInterval: time.Duration(*relayCapsInterval) * time.Second,
+		RelayChallengeInterval:    time.Duration(*relayChallenge) * time.Second,
+		RelayScoreHalfLife:        time.Duration(*relayHalfLife) * time.Second,
+		RelayScoreProbation:       time.Duration(*relayProbation) * time.Second,
+
+		GetHeaderPrefetchLeadTime: time.Duration(*prefetchLeadTimeMs) * time.Millisecond,
+		GetHeaderDutyCheck:        dutyCheckMode,
+		RelaySamplingInterval:     time.Duration(*samplingInterval) * time.Second,
+		RelaySamplingPubkey:       *samplingPubkey,
+
+		RequestTimeouts: server.RequestTimeouts{
+			GetHeader:         time.Duration(*timeoutGetHeaderMs) * time.Millisecond,
+			GetPayload:        time.Duration(*timeoutGetPayloadMs) * time.Millisecond,
+			RegisterValidator: time.Duration(*timeoutRegValMs) * time.Millisecond,
+		},
+
+		ExportTarget:   target,
+		ExportInterval: time.Duration(*exportIntervalSec) * time.Second,
+		ExportS3: server.S3ExportOpts{
+			Region:          *exportS3Region,
+			Endpoint:        *exportS3Endpoint,
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		},
+
+		TelemetryEndpoint: *telemetryEndpoint,
+		TelemetryInterval: time.Duration(*telemetryInterval) * time.Second,
+		TelemetryEpsilon:  *telemetryEpsilon,
+	}
+}
+
+// configureRelays applies the per-relay settings from the cli flags
+func configureRelays(log *logrus.Entry, relays []server.RelayEntry) {
+	maintenanceWindows, err := server.ParseRelayMaintenanceWindows(*relayMaintenance)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid relay maintenance windows")
+	}
+	valueUnits, err := server.ParseRelayValueUnits(*relayValueUnits)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid relay value units")
+	}
+
+	defaultTransport := server.RelayTransport{
+		MaxIdleConns:        *relayMaxIdleConns,
+		IdleConnTimeout:     time.Duration(*relayIdleTimeout) * time.Second,
+		TLSSessionCacheSize: *relayTLSCache,
+		MaxRedirects:        *relayMaxRedirects,
+	}
+	transports, err := server.ParseRelayTransports(*relayTransport, defaultTransport)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid relay transport settings")
+	}
+
+	tags, err := server.ParseRelayTags(*relayTags)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid relay tags")
+	}
+	minBids, err := server.ParseRelayMinBidValues(*relayMinBids)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid relay minimum bid values")
+	}
+
+	escrowHosts := parseHosts(*relayEscrow)
+	untrustedHosts := parseHosts(*untrustedRelays)
+
+	for i, relay := range relays {
+		relays[i].EscrowVerification = escrowHosts[relay.URL.Host]
+		if relays[i].EscrowVerification {
+			log.WithField("relay", relay.String()).Info("relay uses escrow verification")
+		}
+
+		relays[i].Untrusted = untrustedHosts[relay.URL.Host]
+		if relays[i].Untrusted {
+			log.WithField("relay", relay.String()).Infof("relay is untrusted, its bids must exceed the trusted bids by %g%%", *untrustedMargin)
+		}
+
+		relays[i].Tags = tags[relay.URL.Host]
+		if len(relays[i].Tags) > 0 {
+			log.WithField("relay", relay.String()).Infof("relay tags: %s", relays[i].TagsString())
+		}
+
+		relays[i].MinBidValue = minBids[relay.URL.Host]
+		if relays[i].MinBidValue != nil {
+			log.WithField("relay", relay.String()).Infof("relay minimum bid value: %s wei", relays[i].MinBidValue.String())
+		}
+
+		relays[i].ValueUnit = valueUnits[relay.URL.Host]
+		if relays[i].ValueUnit == server.ValueUnitGwei {
+			log.WithField("relay", relay.String()).Info("relay reports bid values in gwei")
+		}
+
+		relays[i].Transport = defaultTransport
+		if transport, ok := transports[relay.URL.Host]; ok {
+			relays[i].Transport = transport
+		}
+
+		relays[i].MaintenanceWindows = maintenanceWindows[relay.URL.Host]
+		for _, w := range relays[i].MaintenanceWindows {
+			log.WithField("relay", relay.String()).Infof("relay maintenance window: %s", w.String())
+		}
+	}
 }
 
 func getEnv(key string, defaultValue string) string {
@@ -150,6 +800,16 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value, ok := os.LookupEnv(key); ok {
+		val, err := strconv.ParseFloat(value, 64)
+		if err == nil {
+			return val
+		}
+	}
+	return defaultValue
+}
+
 func parseRelayURLs(relayURLs string) []server.RelayEntry {
 	ret := []server.RelayEntry{}
 	for _, entry := range strings.Split(relayURLs, ",") {
@@ -161,3 +821,14 @@ func parseRelayURLs(relayURLs string) []server.RelayEntry {
 	}
 	return ret
 }
+
+// parseHosts parses a comma-separated list of relay hosts into a set
+func parseHosts(s string) map[string]bool {
+	hosts := make(map[string]bool)
+	for _, host := range strings.Split(s, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts[host] = true
+		}
+	}
+	return hosts
+}
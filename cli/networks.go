@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/flashbots/mev-boost/server"
+	"github.com/sirupsen/logrus"
+)
+
+// knownGenesisTimes maps network names to their genesis timestamp
+var knownGenesisTimes = map[string]uint64{
+	"mainnet": genesisTimeMainnet,
+	"kiln":    genesisTimeKiln,
+	"ropsten": genesisTimeRopsten,
+	"sepolia": genesisTimeSepolia,
+	"goerli":  genesisTimeGoerli,
+	"gnosis":  genesisTimeGnosis,
+}
+
+// networkConfig is a network served in multi-network mode, with its own listen address and relays
+type networkConfig struct {
+	Name               string   `json:"name" validate:"required" doc:"name of the network, eg. mainnet"`
+	ListenAddr         string   `json:"listen_addr" validate:"required" doc:"listen address of the network, eg. localhost:18550"`
+	GenesisForkVersion string   `json:"genesis_fork_version" validate:"pattern=^0x[0-9a-fA-F]{8}$" doc:"optional for known network names"`
+	GenesisTime        uint64   `json:"genesis_time" doc:"unix timestamp, optional for known network names"`
+	Chain              string   `json:"chain" doc:"optional, ethereum by default, gnosis for the gnosis network"`
+	Relays             []string `json:"relays" validate:"required,min=1" doc:"relay URLs, with the relay pubkey as user"`
+	BeaconNode         string   `json:"beacon_node" validate:"format=uri" doc:"optional beacon node API URL, for prefetching bids and checking proposal duties"`
+	BidOracle          string   `json:"bid_oracle" validate:"format=uri" doc:"optional bid oracle URL, to compare the served bids with"`
+	DebugAPIPublicAddr string   `json:"debug_api_public_addr" doc:"optional listen address of the redacted debug API"`
+	ProposerConfig     string   `json:"proposer_config" doc:"optional file with the relay settings per proposer"`
+	ServedHeaderURL    string   `json:"served_header_url" validate:"format=uri" doc:"optional url receiving a POST request with each header served on the network, tagged with the network name"`
+
+	chain server.ChainConfig
+}
+
+// loadNetworksConfig reads and validates the networks config file
+func loadNetworksConfig(path string) ([]networkConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	networks := []networkConfig{}
+	if err := json.Unmarshal(data, &networks); err != nil {
+		return nil, fmt.Errorf("could not parse networks config: %w", err)
+	}
+	if len(networks) == 0 {
+		return nil, fmt.Errorf("no networks configured")
+	}
+
+	names := make(map[string]bool)
+	listenAddrs := make(map[string]bool)
+	for i, network := range networks {
+		if network.Name == "" {
+			return nil, fmt.Errorf("network %d has no name", i)
+		}
+		if names[network.Name] {
+			return nil, fmt.Errorf("duplicate network name: %s", network.Name)
+		}
+		names[network.Name] = true
+
+		if network.ListenAddr == "" || listenAddrs[network.ListenAddr] {
+			return nil, fmt.Errorf("network %s needs a unique listen address", network.Name)
+		}
+		listenAddrs[network.ListenAddr] = true
+		if network.DebugAPIPublicAddr != "" {
+			if listenAddrs[network.DebugAPIPublicAddr] {
+				return nil, fmt.Errorf("network %s needs a unique public debug API address", network.Name)
+			}
+			listenAddrs[network.DebugAPIPublicAddr] = true
+		}
+
+		if len(network.Relays) == 0 {
+			return nil, fmt.Errorf("network %s has no relays", network.Name)
+		}
+		if err := server.ValidateConfig(network); err != nil {
+			return nil, fmt.Errorf("network %s: %w", network.Name, err)
+		}
+
+		if network.GenesisForkVersion == "" {
+			version, ok := server.KnownGenesisForkVersions[strings.ToLower(network.Name)]
+			if !ok {
+				return nil, fmt.Errorf("network %s needs a genesis fork version", network.Name)
+			}
+			networks[i].GenesisForkVersion = version
+		}
+		if network.GenesisTime == 0 {
+			networks[i].GenesisTime = knownGenesisTimes[strings.ToLower(network.Name)]
+		}
+
+		networks[i].chain = server.ChainEthereum
+		if network.Chain == "" && strings.EqualFold(network.Name, server.ChainGnosis.Name) {
+			networks[i].chain = server.ChainGnosis
+		} else if network.Chain != "" {
+			if networks[i].chain, err = server.ParseChain(network.Chain); err != nil {
+				return nil, fmt.Errorf("network %s: %w", network.Name, err)
+			}
+		}
+	}
+	return networks, nil
+}
+
+// networkServiceOpts returns the service options of a network: those of the flags, with the network's relays and
+// settings, and the files written by the service in their own location per network
+func networkServiceOpts(log *logrus.Entry, network networkConfig, relays []server.RelayEntry) (server.BoostServiceOpts, error) {
+	opts := newBoostServiceOpts(log)
+	opts.NetworkName = network.Name
+	opts.ListenAddr = network.ListenAddr
+	opts.Relays = relays
+	opts.GenesisForkVersionHex = network.GenesisForkVersion
+	opts.GenesisTime = network.GenesisTime
+	opts.Chain = network.chain
+	opts.BeaconNodeURL = network.BeaconNode
+	opts.BidOracleURL = network.BidOracle
+	opts.DebugAPIPublicListenAddr = network.DebugAPIPublicAddr
+	opts.ServedHeaderURL = network.ServedHeaderURL
+	opts.ProposerConfig = nil // the relays differ per network
+	if network.ProposerConfig != "" {
+		var err error
+		if opts.ProposerConfig, err = server.LoadProposerConfig(resolvePath(network.ProposerConfig)); err != nil {
+			return opts, err
+		}
+	}
+	if opts.RegistrationQueueFile != "" {
+		opts.RegistrationQueueFile += "." + network.Name // each network has its own queue
+	}
+	if opts.ExportTarget != "" {
+		opts.ExportTarget += "/" + network.Name // each network exports to its own directory or key prefix
+	}
+	if opts.SlotTraceDir != "" {
+		opts.SlotTraceDir = filepath.Join(opts.SlotTraceDir, network.Name) // the slots of the networks overlap
+	}
+	if opts.RelayRecordDir != "" {
+		opts.RelayRecordDir = filepath.Join(opts.RelayRecordDir, network.Name)
+	}
+	return opts, nil
+}
+
+// runNetworks starts one service per configured network, each with isolated relays and logs, until stop is closed
+func runNetworks(path string, stop <-chan struct{}) {
+	networks, err := loadNetworksConfig(path)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid networks config")
+	}
+
+	services := make([]*server.BoostService, len(networks))
+	for i, network := range networks {
+		log := log.WithField("network", network.Name)
+		log.Infof("Using genesis fork version: %s", network.GenesisForkVersion)
+
+		relays := parseRelayURLs(strings.Join(network.Relays, ","))
+		log.WithField("relays", relays).Infof("using %d relays", len(relays))
+		configureRelays(log, relays)
+
+		opts, err := networkServiceOpts(log, network, relays)
+		if err != nil {
+			log.WithError(err).Fatal("Invalid proposer config")
+		}
+		services[i], err = server.NewBoostService(opts)
+		if err != nil {
+			log.WithError(err).Fatal("failed creating the server")
+		}
+
+		if *relayCheck && !services[i].CheckRelays() {
+			log.Fatal("no relay available")
+		}
+	}
+
+	for _, network := range networks {
+		log.WithField("network", network.Name).Println("listening on", network.ListenAddr)
+	}
+	if err := serve(services, stop); err != nil {
+		log.Fatal(err)
+	}
+}
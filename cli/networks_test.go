@@ -0,0 +1,208 @@
+package cli
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/flashbots/mev-boost/server"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+const testRelayURL = "https://0xac6e77dfe25ecd6110b8e780608cce0dab71fdd5ebea22a16c0205200f2f8e2e3ad3b71d3499c54ad14d6c21b41a37ae@boost-relay.flashbots.net"
+
+func TestLoadNetworksConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		err      string
+		expected func(t *testing.T, networks []networkConfig)
+	}{
+		{
+			name:   "Known networks",
+			config: `[{"name": "mainnet", "listen_addr": "localhost:18550", "relays": ["` + testRelayURL + `"]}, {"name": "gnosis", "listen_addr": "localhost:18551", "relays": ["` + testRelayURL + `"]}]`,
+			expected: func(t *testing.T, networks []networkConfig) {
+				t.Helper()
+				require.Len(t, networks, 2)
+				require.Equal(t, server.KnownGenesisForkVersions["mainnet"], networks[0].GenesisForkVersion)
+				require.Equal(t, uint64(genesisTimeMainnet), networks[0].GenesisTime)
+				require.Equal(t, server.ChainEthereum, networks[0].chain)
+				require.Equal(t, server.ChainGnosis, networks[1].chain)
+			},
+		},
+		{
+			name:   "Custom network",
+			config: `[{"name": "devnet", "listen_addr": "localhost:18550", "genesis_fork_version": "0x00000001", "genesis_time": 1, "chain": "gnosis", "relays": ["` + testRelayURL + `"]}]`,
+			expected: func(t *testing.T, networks []networkConfig) {
+				t.Helper()
+				require.Equal(t, "0x00000001", networks[0].GenesisForkVersion)
+				require.Equal(t, uint64(1), networks[0].GenesisTime)
+				require.Equal(t, server.ChainGnosis, networks[0].chain)
+			},
+		},
+		{
+			name:   "Invalid JSON",
+			config: `{"name": "mainnet"}`,
+			err:    "could not parse networks config",
+		},
+		{
+			name:   "No networks",
+			config: `[]`,
+			err:    "no networks configured",
+		},
+		{
+			name:   "No name",
+			config: `[{"listen_addr": "localhost:18550", "relays": ["` + testRelayURL + `"]}]`,
+			err:    "network 0 has no name",
+		},
+		{
+			name:   "Duplicate names",
+			config: `[{"name": "mainnet", "listen_addr": "localhost:18550", "relays": ["` + testRelayURL + `"]}, {"name": "mainnet", "listen_addr": "localhost:18551", "relays": ["` + testRelayURL + `"]}]`,
+			err:    "duplicate network name: mainnet",
+		},
+		{
+			name:   "Duplicate listen addresses",
+			config: `[{"name": "mainnet", "listen_addr": "localhost:18550", "relays": ["` + testRelayURL + `"]}, {"name": "goerli", "listen_addr": "localhost:18550", "relays": ["` + testRelayURL + `"]}]`,
+			err:    "network goerli needs a unique listen address",
+		},
+		{
+			name:   "Debug API on a listen address",
+			config: `[{"name": "mainnet", "listen_addr": "localhost:18550", "relays": ["` + testRelayURL + `"]}, {"name": "goerli", "listen_addr": "localhost:18551", "debug_api_public_addr": "localhost:18550", "relays": ["` + testRelayURL + `"]}]`,
+			err:    "network goerli needs a unique public debug API address",
+		},
+		{
+			name:   "No relays",
+			config: `[{"name": "mainnet", "listen_addr": "localhost:18550", "relays": []}]`,
+			err:    "network mainnet has no relays",
+		},
+		{
+			name:   "Unknown network without genesis fork version",
+			config: `[{"name": "devnet", "listen_addr": "localhost:18550", "relays": ["` + testRelayURL + `"]}]`,
+			err:    "network devnet needs a genesis fork version",
+		},
+		{
+			name:   "Invalid genesis fork version",
+			config: `[{"name": "devnet", "listen_addr": "localhost:18550", "genesis_fork_version": "0x01", "relays": ["` + testRelayURL + `"]}]`,
+			err:    "network devnet",
+		},
+		{
+			name:   "Unknown chain",
+			config: `[{"name": "mainnet", "listen_addr": "localhost:18550", "chain": "solana", "relays": ["` + testRelayURL + `"]}]`,
+			err:    "network mainnet",
+		},
+		{
+			name:   "Invalid served header URL",
+			config: `[{"name": "mainnet", "listen_addr": "localhost:18550", "served_header_url": "not a url", "relays": ["` + testRelayURL + `"]}]`,
+			err:    "network mainnet",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "networks.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.config), 0o600))
+			networks, err := loadNetworksConfig(path)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			tt.expected(t, networks)
+		})
+	}
+}
+
+func TestNetworkServiceOpts(t *testing.T) {
+	setFlag := func(t *testing.T, name, value string) {
+		t.Helper()
+		previous := flag.Lookup(name).Value.String()
+		require.NoError(t, flag.Set(name, value))
+		t.Cleanup(func() { require.NoError(t, flag.Set(name, previous)) })
+	}
+	log := logrus.NewEntry(logrus.New())
+	relays := parseRelayURLs(testRelayURL)
+	network := networkConfig{Name: "goerli", ListenAddr: "localhost:18551", GenesisForkVersion: "0x00001020", chain: server.ChainEthereum}
+
+	tests := []struct {
+		name     string
+		flags    map[string]string
+		network  func(network networkConfig) networkConfig
+		expected func(t *testing.T, opts server.BoostServiceOpts)
+	}{
+		{
+			name: "Network settings",
+			expected: func(t *testing.T, opts server.BoostServiceOpts) {
+				t.Helper()
+				require.Equal(t, "goerli", opts.NetworkName)
+				require.Equal(t, "localhost:18551", opts.ListenAddr)
+				require.Equal(t, "0x00001020", opts.GenesisForkVersionHex)
+				require.Equal(t, relays, opts.Relays)
+				require.Empty(t, opts.RegistrationQueueFile)
+				require.Empty(t, opts.ExportTarget)
+				require.Empty(t, opts.SlotTraceDir)
+				require.Empty(t, opts.RelayRecordDir)
+				require.Nil(t, opts.ProposerConfig)
+			},
+		},
+		{
+			name: "Files per network",
+			flags: map[string]string{
+				"registration-queue-file": "queue.json",
+				"export-target":           "s3://bucket/mev-boost",
+				"slot-trace-dir":          "traces",
+				"relay-record-dir":        "records",
+			},
+			expected: func(t *testing.T, opts server.BoostServiceOpts) {
+				t.Helper()
+				require.Equal(t, "queue.json.goerli", opts.RegistrationQueueFile)
+				require.Equal(t, "s3://bucket/mev-boost/goerli", opts.ExportTarget)
+				require.Equal(t, filepath.Join("traces", "goerli"), opts.SlotTraceDir)
+				require.Equal(t, filepath.Join("records", "goerli"), opts.RelayRecordDir)
+			},
+		},
+		{
+			name:  "Served header URL of the flags",
+			flags: map[string]string{"served-header-url": "http://sidecar:8080/headers"},
+			expected: func(t *testing.T, opts server.BoostServiceOpts) {
+				t.Helper()
+				require.Empty(t, opts.ServedHeaderURL) // the headers of another network
+			},
+		},
+		{
+			name:  "Served header URL of the network",
+			flags: map[string]string{"served-header-url": "http://sidecar:8080/headers"},
+			network: func(network networkConfig) networkConfig {
+				network.ServedHeaderURL = "http://goerli-sidecar:8080/headers"
+				return network
+			},
+			expected: func(t *testing.T, opts server.BoostServiceOpts) {
+				t.Helper()
+				require.Equal(t, "http://goerli-sidecar:8080/headers", opts.ServedHeaderURL)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.flags {
+				setFlag(t, name, value)
+			}
+			network := network
+			if tt.network != nil {
+				network = tt.network(network)
+			}
+			opts, err := networkServiceOpts(log, network, relays)
+			require.NoError(t, err)
+			tt.expected(t, opts)
+		})
+	}
+
+	t.Run("Invalid proposer config", func(t *testing.T) {
+		network := network
+		network.ProposerConfig = filepath.Join(t.TempDir(), "missing.json")
+		_, err := networkServiceOpts(log, network, relays)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,26 @@
+//go:build !windows
+
+package cli
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/flashbots/mev-boost/server"
+)
+
+// handleProposerConfigSignals reloads the proposer config files of the services on SIGHUP
+func handleProposerConfigSignals(services []*server.BoostService) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for range signals {
+			for _, service := range services {
+				if err := service.ReloadProposerConfig(); err != nil {
+					log.WithError(err).Error("could not reload the proposer config, keeping the previous one")
+				}
+			}
+		}
+	}()
+}
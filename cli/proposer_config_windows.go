@@ -0,0 +1,9 @@
+//go:build windows
+
+package cli
+
+import "github.com/flashbots/mev-boost/server"
+
+// handleProposerConfigSignals does nothing, Windows has no signals to reload the proposer config. mev-boost must be
+// restarted instead.
+func handleProposerConfigSignals(services []*server.BoostService) {}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/flashbots/mev-boost/server"
+)
+
+// reportDateFormat is the format of the -from and -to dates of the report subcommand
+const reportDateFormat = "2006-01-02"
+
+// runReport runs the report subcommand, which sums up the earnings per proposer and relay from the slot outcome
+// records in mev-boost JSON logs
+func runReport(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	from := fs.String("from", "", "first day of the report, in UTC (YYYY-MM-DD), default: all records")
+	to := fs.String("to", "", "last day of the report, in UTC (YYYY-MM-DD), default: all records")
+	format := fs.String("format", "csv", "output format: csv or json")
+	chainName := fs.String("chain", "ethereum", "chain of the logs, for the earnings in units of its native token in the JSON output: ethereum or gnosis")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of %s report [flags] [log files]:\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Sums up the earnings per proposer and relay from mev-boost logs written with -json, read from stdin if no files are given.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("invalid format: %s", *format)
+	}
+	chain, err := server.ParseChain(*chainName)
+	if err != nil {
+		return err
+	}
+
+	var start, end time.Time
+	if *from != "" {
+		if start, err = time.Parse(reportDateFormat, *from); err != nil {
+			return fmt.Errorf("invalid -from date: %w", err)
+		}
+	}
+	if *to != "" {
+		if end, err = time.Parse(reportDateFormat, *to); err != nil {
+			return fmt.Errorf("invalid -to date: %w", err)
+		}
+		end = end.AddDate(0, 0, 1)
+	}
+
+	var logs io.Reader = os.Stdin
+	if fs.NArg() > 0 {
+		readers := make([]io.Reader, fs.NArg())
+		for i, path := range fs.Args() {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			readers[i] = f
+		}
+		logs = io.MultiReader(readers...)
+	}
+
+	report, err := server.NewEarningsReport(logs, start, end)
+	if err != nil {
+		return err
+	}
+	if *format == "json" {
+		report.FormatEarnings(chain)
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	return report.WriteCSV(os.Stdout)
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/flashbots/mev-boost/server"
+)
+
+const (
+	// serviceName is the name mev-boost is registered with as an operating system service
+	serviceName = "mev-boost"
+
+	// shutdownTimeout is how long pending requests may take to complete when stopping
+	shutdownTimeout = 10 * time.Second
+)
+
+// isService is set if mev-boost runs under a service manager which doesn't set a useful working directory
+var isService bool
+
+// resolvePath cleans a file path given on the command line. Relative paths are resolved against the working
+// directory, or against the directory of the executable when running as a Windows service, whose working
+// directory is the system directory.
+func resolvePath(path string) string {
+	if path == "" {
+		return ""
+	}
+	if filepath.IsAbs(path) || !isService {
+		return filepath.Clean(path)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		log.WithError(err).Warn("could not determine the executable directory, using the path as is")
+		return filepath.Clean(path)
+	}
+	return filepath.Join(filepath.Dir(executable), path)
+}
+
+// serve runs the HTTP servers of the services until one of them fails, or stop is closed
+func serve(services []*server.BoostService, stop <-chan struct{}) error {
+	handleProposerConfigSignals(services)
+
+	errCh := make(chan error, len(services))
+	for _, service := range services {
+		go func(service *server.BoostService) {
+			errCh <- service.StartHTTPServer()
+		}(service)
+	}
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-stop:
+	}
+
+	log.Info("stopping")
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	for _, service := range services {
+		if err := service.Shutdown(ctx); err != nil {
+			log.WithError(err).Warn("could not stop the server gracefully")
+		}
+	}
+	return nil
+}
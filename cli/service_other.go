@@ -0,0 +1,9 @@
+//go:build !windows
+
+package cli
+
+// runAsService runs mev-boost under the service manager of the operating system if started by it, and returns
+// whether it did. Other service managers than Windows' start mev-boost as a regular process.
+func runAsService(run func(stop <-chan struct{})) bool {
+	return false
+}
@@ -0,0 +1,58 @@
+//go:build windows
+
+package cli
+
+import (
+	"golang.org/x/sys/windows/svc"
+)
+
+// runAsService runs mev-boost under the Windows service control manager if started by it, and returns whether it did
+func runAsService(run func(stop <-chan struct{})) bool {
+	ok, err := svc.IsWindowsService()
+	if err != nil {
+		log.WithError(err).Fatal("could not determine whether running as a Windows service")
+	}
+	if !ok {
+		return false
+	}
+
+	isService = true
+	if err := svc.Run(serviceName, &windowsService{run: run}); err != nil {
+		log.WithError(err).Fatal("Windows service failed")
+	}
+	return true
+}
+
+// windowsService handles the requests of the Windows service control manager
+type windowsService struct {
+	run func(stop <-chan struct{})
+}
+
+func (s *windowsService) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		s.run(stop)
+		close(done)
+	}()
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for {
+		select {
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				close(stop)
+				<-done
+				return false, 0
+			}
+		case <-done: // stopped without request
+			return false, 1
+		}
+	}
+}
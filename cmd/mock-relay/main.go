@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/flashbots/go-boost-utils/bls"
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/sirupsen/logrus"
+
+	"github.com/flashbots/mev-boost/server"
+)
+
+var log = logrus.WithField("service", "cmd/mock-relay")
+
+func main() {
+	listenAddr := flag.String("addr", "localhost:28545", "listen-address for the mock relay")
+	secretKeyHex := flag.String("secret-key", "", "BLS secret key to sign bids with, a random key is used if empty")
+	genesisForkVersion := flag.String("genesis-fork-version", "0x00000000", "genesis fork version of the network, for the builder signing domain")
+
+	bidValue := flag.Uint64("bid-value", 12345, "value of the bids [wei]")
+	bidBlockHash := flag.String("bid-block-hash", "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7", "block hash of the bids")
+	echoRequestHashes := flag.Bool("echo-request-hashes", true, "build bids on the requested parent hash, and payloads for the requested block hash")
+	noBids := flag.Bool("no-bids", false, "respond to getHeader without a bid (204)")
+	withholdPayload := flag.Bool("withhold-payload", false, "respond to getPayload with an error")
+
+	responseDelayMs := flag.Int("delay", 0, "delay of all responses [ms]")
+	faultRate := flag.Float64("fault-rate", 0, "fraction of requests answered with an internal server error (0-1)")
+
+	fixtures := flag.String("fixtures", "", "file of presets generated by mev-boost fixtures, whose recorded responses are replayed")
+	fixtureRelay := flag.String("fixture-relay", "", "host of the relay whose preset is replayed, if the file has several")
+	flag.Parse()
+
+	secretKey, err := bls.GenerateRandomSecretKey()
+	if *secretKeyHex != "" {
+		var skBytes []byte
+		skBytes, err = hexutil.Decode(*secretKeyHex)
+		if err == nil {
+			secretKey, err = bls.SecretKeyFromBytes(skBytes)
+		}
+	}
+	if err != nil {
+		log.WithError(err).Fatal("invalid secret key")
+	}
+
+	signingDomain, err := server.ComputeDomain(types.DomainTypeAppBuilder, *genesisForkVersion, types.Root{}.String())
+	if err != nil {
+		log.WithError(err).Fatal("invalid genesis fork version")
+	}
+
+	relay := server.NewMockRelay(secretKey)
+	relay.SigningDomain = signingDomain
+	relay.BidValue = *bidValue
+	relay.BidBlockHash = *bidBlockHash
+	relay.EchoRequestHashes = *echoRequestHashes
+	relay.NoBids = *noBids
+	relay.WithholdPayload = *withholdPayload
+	relay.ResponseDelay = time.Duration(*responseDelayMs) * time.Millisecond
+	relay.FaultRate = *faultRate
+
+	pubkey := hexutil.Encode(relay.PublicKey().Compress())
+	if *fixtures != "" {
+		preset, err := loadPreset(*fixtures, *fixtureRelay)
+		if err != nil {
+			log.WithError(err).Fatal("invalid fixtures")
+		}
+		if err := relay.ReplayFixtures(preset); err != nil {
+			log.WithError(err).Fatal("invalid fixtures")
+		}
+		// The recorded responses are signed by the recorded relay
+		pubkey = preset.RelayPubkey
+		log.WithField("relay", preset.Relay).Infof("replaying %d recorded responses", len(preset.Fixtures))
+	}
+
+	log.Infof("relay url: http://%s@%s", pubkey, *listenAddr)
+	log.Fatal(http.ListenAndServe(*listenAddr, relay.Handler()))
+}
+
+// loadPreset returns the preset of a relay host from a presets file, or the only preset if relay is empty
+func loadPreset(path, relay string) (server.RelayFixturePreset, error) {
+	presets, err := server.LoadRelayFixturePresets(path)
+	if err != nil {
+		return server.RelayFixturePreset{}, err
+	}
+	for _, preset := range presets {
+		if preset.Relay == relay || (relay == "" && len(presets) == 1) {
+			return preset, nil
+		}
+	}
+	if relay == "" {
+		return server.RelayFixturePreset{}, fmt.Errorf("%d presets in %s, select one with -fixture-relay", len(presets), path)
+	}
+	return server.RelayFixturePreset{}, fmt.Errorf("no preset of %s in %s", relay, path)
+}
@@ -28,4 +28,7 @@ var (
 	ServerIdleTimeoutMs = cli.GetEnvInt("MEV_BOOST_SERVER_IDLE_TIMEOUT_MS", 0)
 
 	ServerMaxHeaderBytes = cli.GetEnvInt("MAX_HEADER_BYTES", 4000) // max header byte size for requests for dos prevention
+
+	// ServerMaxConnections is the maximum number of concurrent connections of the main listener, further connections wait to be accepted. A zero or negative value means there is no limit.
+	ServerMaxConnections = cli.GetEnvInt("MEV_BOOST_SERVER_MAX_CONNECTIONS", 256)
 )
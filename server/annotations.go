@@ -0,0 +1,217 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// maxAnnotations is the number of annotations kept, the oldest annotations are removed beyond it
+const maxAnnotations = 1000
+
+// annotation is an operator note on an incident, eg. a relay announcing degraded performance, for post-hoc analysis
+// of the slot outcomes during the incident
+type annotation struct {
+	ID        uint64     `json:"id,string"`
+	Relay     string     `json:"relay,omitempty"` // all relays if empty
+	Text      string     `json:"text"`
+	Start     time.Time  `json:"start"`
+	End       *time.Time `json:"end,omitempty"` // ongoing if nil
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// activeAt returns whether the annotated incident is ongoing at time t
+func (a annotation) activeAt(t time.Time) bool {
+	return !t.Before(a.Start) && (a.End == nil || t.Before(*a.End))
+}
+
+// annotationStore keeps the annotations, in order of creation
+type annotationStore struct {
+	mu          sync.RWMutex
+	nextID      uint64
+	annotations []annotation
+}
+
+func newAnnotationStore() *annotationStore {
+	return &annotationStore{nextID: 1}
+}
+
+// add stores an annotation, and returns it with its ID
+func (s *annotationStore) add(a annotation) annotation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a.ID = s.nextID
+	s.nextID++
+	s.annotations = append(s.annotations, a)
+	if excess := len(s.annotations) - maxAnnotations; excess > 0 {
+		s.annotations = s.annotations[excess:]
+	}
+	return a
+}
+
+// remove removes an annotation, and returns it if it existed
+func (s *annotationStore) remove(id uint64) (annotation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, a := range s.annotations {
+		if a.ID == id {
+			s.annotations = append(s.annotations[:i], s.annotations[i+1:]...)
+			return a, true
+		}
+	}
+	return annotation{}, false
+}
+
+// list returns all annotations, in order of creation
+func (s *annotationStore) list() []annotation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]annotation{}, s.annotations...)
+}
+
+// active returns the annotations of a relay active at time t, or the annotations of all relays if relay is empty
+func (s *annotationStore) active(relay string, t time.Time) []annotation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ret := []annotation{}
+	for _, a := range s.annotations {
+		if a.Relay == relay && a.activeAt(t) {
+			ret = append(ret, a)
+		}
+	}
+	return ret
+}
+
+// activeIDs returns the IDs of the annotations active at time t, which concern all relays or one of the given relays
+func (s *annotationStore) activeIDs(relays []string, t time.Time) []uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := []uint64{}
+	for _, a := range s.annotations {
+		if !a.activeAt(t) {
+			continue
+		}
+		if a.Relay == "" {
+			ids = append(ids, a.ID)
+			continue
+		}
+		for _, relay := range relays {
+			if a.Relay == relay {
+				ids = append(ids, a.ID)
+				break
+			}
+		}
+	}
+	return ids
+}
+
+// annotationsCollector exports the number of active annotations per relay
+type annotationsCollector struct {
+	store *annotationStore
+	clock Clock
+	desc  *prometheus.Desc
+}
+
+func newAnnotationsCollector(store *annotationStore, clock Clock) *annotationsCollector {
+	return &annotationsCollector{
+		store: store,
+		clock: clock,
+		desc:  prometheus.NewDesc("mev_boost_annotations_active", "Number of active operator annotations, per relay (all for annotations of all relays)", []string{"relay"}, nil),
+	}
+}
+
+func (c *annotationsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *annotationsCollector) Collect(ch chan<- prometheus.Metric) {
+	counts := make(map[string]int)
+	now := c.clock.Now()
+	for _, a := range c.store.list() {
+		if !a.activeAt(now) {
+			continue
+		}
+		relay := a.Relay
+		if relay == "" {
+			relay = "all"
+		}
+		counts[relay]++
+	}
+	for relay, count := range counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count), relay)
+	}
+}
+
+// annotationRequest is the body of a request to add an annotation
+type annotationRequest struct {
+	Relay string     `json:"relay"` // host of the relay, all relays if empty
+	Text  string     `json:"text"`
+	Start time.Time  `json:"start"` // now if zero
+	End   *time.Time `json:"end"`   // ongoing if nil
+}
+
+// handleAnnotations returns the annotations. POST adds the annotation in the body, and DELETE removes the
+// annotation given by the id query parameter.
+func (m *BoostService) handleAnnotations(w http.ResponseWriter, req *http.Request) {
+	now := m.clock.Now()
+	log := m.requestLog(req)
+	switch req.Method {
+	case http.MethodPost:
+		body := annotationRequest{}
+		if err := DecodeJSON(req.Body, &body); err != nil {
+			m.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		a := annotation{Text: strings.TrimSpace(body.Text), Start: body.Start, End: body.End, CreatedAt: now}
+		if a.Text == "" {
+			m.respondError(w, http.StatusBadRequest, "missing text")
+			return
+		}
+		if a.Start.IsZero() {
+			a.Start = now
+		}
+		if a.End != nil && !a.End.After(a.Start) {
+			m.respondError(w, http.StatusBadRequest, "end must be after start")
+			return
+		}
+		if body.Relay != "" {
+			relay := m.relayByHost(body.Relay)
+			if relay == nil {
+				m.respondError(w, http.StatusBadRequest, "unknown relay: "+body.Relay)
+				return
+			}
+			a.Relay = relay.String()
+		}
+		a = m.annotations.add(a)
+		m.dataExporter.addAnnotation(now, "added", a)
+		log.WithField("annotation", a).Info("annotation added")
+	case http.MethodDelete:
+		id, err := strconv.ParseUint(req.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			m.respondError(w, http.StatusBadRequest, "invalid id: "+req.URL.Query().Get("id"))
+			return
+		}
+		a, ok := m.annotations.remove(id)
+		if !ok {
+			m.respondError(w, http.StatusNotFound, "unknown annotation: "+strconv.FormatUint(id, 10))
+			return
+		}
+		m.dataExporter.addAnnotation(now, "removed", a)
+		log.WithField("annotation", a).Info("annotation removed")
+	}
+	m.respondOK(w, m.annotations.list())
+}
+
+// relayByHost returns the relay with the given host, or nil if there is none
+func (m *BoostService) relayByHost(host string) *RelayEntry {
+	for i := range m.relays {
+		if m.relays[i].URL.Host == host {
+			return &m.relays[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotationStore(t *testing.T) {
+	store := newAnnotationStore()
+	start := time.Unix(1000, 0)
+	end := start.Add(time.Hour)
+	a := store.add(annotation{Relay: "relay1", Text: "degraded", Start: start, End: &end})
+	b := store.add(annotation{Text: "network incident", Start: start})
+	require.Equal(t, uint64(1), a.ID)
+	require.Equal(t, uint64(2), b.ID)
+
+	require.Equal(t, []annotation{a}, store.active("relay1", start))
+	require.Equal(t, []annotation{b}, store.active("", end))
+	require.Equal(t, []uint64{1, 2}, store.activeIDs([]string{"relay1"}, start))
+	require.Equal(t, []uint64{2}, store.activeIDs([]string{"relay1"}, end))
+	require.Equal(t, []uint64{2}, store.activeIDs([]string{"relay2"}, start))
+	require.Empty(t, store.activeIDs(nil, start.Add(-time.Second)))
+
+	_, ok := store.remove(1)
+	require.True(t, ok)
+	_, ok = store.remove(1)
+	require.False(t, ok)
+	require.Equal(t, []annotation{b}, store.list())
+}
+
+func TestAnnotationsAPI(t *testing.T) {
+	backend := newTestBackend(t, 2, time.Second)
+	backend.boost.adminAPI = true
+	clock := newFakeClock(time.Unix(1_000_000, 0))
+	backend.boost.clock = clock
+	relay := backend.relays[0].RelayEntry
+
+	rr := backend.request(t, http.MethodPost, pathAdminAnnotations, map[string]any{"relay": relay.URL.Host, "text": "degraded performance", "end": clock.Now().Add(time.Hour)})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	annotations := []annotation{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &annotations))
+	require.Len(t, annotations, 1)
+	require.Equal(t, relay.String(), annotations[0].Relay)
+	require.Equal(t, clock.Now(), annotations[0].Start.Local())
+
+	for _, body := range []map[string]any{
+		{"text": ""},
+		{"text": "x", "relay": "unknown.com"},
+		{"text": "x", "end": clock.Now().Add(-time.Second)},
+	} {
+		rr = backend.request(t, http.MethodPost, pathAdminAnnotations, body)
+		require.Equal(t, http.StatusBadRequest, rr.Code, body)
+	}
+
+	// Active annotations are shown in the status API and metrics
+	rr = backend.request(t, http.MethodGet, pathMevBoostStatus, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	status := mevBoostStatusResponse{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &status))
+	require.Len(t, status.Relays[0].Annotations, 1)
+	require.Empty(t, status.Relays[1].Annotations)
+	require.NoError(t, testutil.CollectAndCompare(newAnnotationsCollector(backend.boost.annotations, clock), strings.NewReader(`
+		# HELP mev_boost_annotations_active Number of active operator annotations, per relay (all for annotations of all relays)
+		# TYPE mev_boost_annotations_active gauge
+		mev_boost_annotations_active{relay="`+relay.String()+`"} 1
+	`)))
+
+	clock.advance(time.Hour)
+	rr = backend.request(t, http.MethodGet, pathMevBoostStatus, nil)
+	status = mevBoostStatusResponse{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &status))
+	require.Empty(t, status.Relays[0].Annotations)
+
+	rr = backend.request(t, http.MethodDelete, pathAdminAnnotations+"?id=1", nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, "[]\n", rr.Body.String())
+	rr = backend.request(t, http.MethodDelete, pathAdminAnnotations+"?id=1", nil)
+	require.Equal(t, http.StatusNotFound, rr.Code, rr.Body.String())
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// AtRestKeySize is the size of the key encrypting the files written by mev-boost (AES-256)
+const AtRestKeySize = 32
+
+// atRestMagic prefixes the files encrypted at rest, to tell them from files written without encryption
+var atRestMagic = []byte("mev-boost-aes-gcm-v1\n")
+
+var errAtRestKeyRequired = errors.New("file is encrypted, but no at-rest key is configured")
+
+// LoadAtRestKey loads the key encrypting files at rest from file:PATH, env:NAME or vault:URL#FIELD, holding the
+// hex-encoded key. Keys held by a KMS are not supported yet.
+func LoadAtRestKey(source string) ([]byte, error) {
+	return loadHexKey(source, AtRestKeySize, ErrInvalidAtRestKey)
+}
+
+// loadHexKey loads a hex-encoded key of the given size from a secret source (see Secret). Invalid sources and keys
+// are reported with errInvalid.
+func loadHexKey(source string, size int, errInvalid error) ([]byte, error) {
+	encoded, err := readSecret(context.Background(), source)
+	if errors.Is(err, ErrInvalidSecret) {
+		return nil, fmt.Errorf("%w: %v", errInvalid, err)
+	} else if err != nil {
+		return nil, err
+	}
+
+	key, err := hex.DecodeString(strings.TrimPrefix(encoded, "0x"))
+	if err != nil || len(key) != size {
+		return nil, fmt.Errorf("%w: expected %d hex-encoded bytes from %s", errInvalid, size, source)
+	}
+	return key, nil
+}
+
+// sealAtRest encrypts data with AES-GCM if a key is given, and returns it as is otherwise
+func sealAtRest(key, data []byte) ([]byte, error) {
+	if key == nil {
+		return data, nil
+	}
+	aead, err := newAtRestCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	sealed := append(append([]byte{}, atRestMagic...), nonce...)
+	return aead.Seal(sealed, nonce, data, atRestMagic), nil
+}
+
+// writeFileAtRest writes a file readable only by its owner, encrypted with sealAtRest if a key is given
+func writeFileAtRest(path string, key, data []byte) error {
+	data, err := sealAtRest(key, data)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// DecryptAtRest decrypts a file written by mev-boost with an at-rest key, eg. an export or a slot trace. Files written
+// without encryption are returned as is.
+func DecryptAtRest(key, data []byte) ([]byte, error) {
+	return openAtRest(key, data)
+}
+
+// openAtRest decrypts data sealed by sealAtRest. Data written without encryption is returned as is, so that
+// files are encrypted when next written after a key was configured.
+func openAtRest(key, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, atRestMagic) {
+		return data, nil
+	}
+	if key == nil {
+		return nil, errAtRestKeyRequired
+	}
+	aead, err := newAtRestCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data = data[len(atRestMagic):]
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted data too short")
+	}
+	return aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], atRestMagic)
+}
+
+func newAtRestCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
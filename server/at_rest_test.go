@@ -0,0 +1,149 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealAtRest(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, AtRestKeySize)
+	data := []byte(`{"relay":[]}`)
+
+	sealed, err := sealAtRest(key, data)
+	require.NoError(t, err)
+	require.NotContains(t, string(sealed), string(data))
+	opened, err := openAtRest(key, sealed)
+	require.NoError(t, err)
+	require.Equal(t, data, opened)
+
+	// Tampered data, a wrong key or no key fail
+	tampered := append([]byte{}, sealed...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = openAtRest(key, tampered)
+	require.Error(t, err)
+	_, err = openAtRest(bytes.Repeat([]byte{0x43}, AtRestKeySize), sealed)
+	require.Error(t, err)
+	_, err = openAtRest(nil, sealed)
+	require.ErrorIs(t, err, errAtRestKeyRequired)
+
+	// Without a key nothing is encrypted, and unencrypted data is read as is
+	plain, err := sealAtRest(nil, data)
+	require.NoError(t, err)
+	require.Equal(t, data, plain)
+	opened, err = openAtRest(key, data)
+	require.NoError(t, err)
+	require.Equal(t, data, opened)
+}
+
+func TestLoadAtRestKey(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, AtRestKeySize)
+	path := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(path, []byte(hex.EncodeToString(key)+"\n"), 0o600))
+
+	loaded, err := LoadAtRestKey("file:" + path)
+	require.NoError(t, err)
+	require.Equal(t, key, loaded)
+
+	t.Setenv("TEST_AT_REST_KEY", "0x"+hex.EncodeToString(key))
+	loaded, err = LoadAtRestKey("env:TEST_AT_REST_KEY")
+	require.NoError(t, err)
+	require.Equal(t, key, loaded)
+
+	for _, source := range []string{"env:TEST_AT_REST_KEY_UNSET", "kms://key-id", path, "env"} {
+		_, err = LoadAtRestKey(source)
+		require.ErrorIs(t, err, ErrInvalidAtRestKey, source)
+	}
+}
+
+func TestRegistrationQueueEncrypted(t *testing.T) {
+	relay := newMockRelay(t).RelayEntry
+	path := filepath.Join(t.TempDir(), "queue.json")
+	key := bytes.Repeat([]byte{0x42}, AtRestKeySize)
+	registrations := []types.SignedValidatorRegistration{newTestRegistration(0xab, 1)}
+
+	// A queue persisted without encryption is encrypted when persisted again with a key
+	q, err := newRegistrationQueue([]RelayEntry{relay}, path, nil)
+	require.NoError(t, err)
+	q.enqueue(registrations)
+	require.NoError(t, q.persist())
+
+	q, err = newRegistrationQueue([]RelayEntry{relay}, path, key)
+	require.NoError(t, err)
+	require.Equal(t, registrations, q.next(relay.String(), 0))
+	q.dirty = true
+	require.NoError(t, q.persist())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(data, atRestMagic))
+	require.NotContains(t, string(data), registrations[0].Message.Pubkey.String())
+
+	restored, err := newRegistrationQueue([]RelayEntry{relay}, path, key)
+	require.NoError(t, err)
+	require.Equal(t, registrations, restored.next(relay.String(), 0))
+	_, err = newRegistrationQueue([]RelayEntry{relay}, path, nil)
+	require.ErrorIs(t, err, errAtRestKeyRequired)
+}
+
+func TestFilesEncryptedAtRest(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, AtRestKeySize)
+	requireSealed := func(t *testing.T, path string) []byte {
+		t.Helper()
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.True(t, bytes.HasPrefix(data, atRestMagic))
+		opened, err := DecryptAtRest(key, data)
+		require.NoError(t, err)
+		return opened
+	}
+
+	t.Run("Exports", func(t *testing.T) {
+		dir := t.TempDir()
+		exporter := newDataExporter(dirExportTarget(dir), key)
+		exporter.addOutcome(time.Unix(0, 0), &slotOutcome{Slot: 1})
+		_, err := exporter.export(context.Background(), time.Unix(0, 0))
+		require.NoError(t, err)
+		data := requireSealed(t, filepath.Join(dir, "outcomes-19700101T000000Z.csv"))
+		require.True(t, strings.HasPrefix(string(data), strings.Join(exportOutcomesHeader, ",")))
+	})
+
+	t.Run("Slot traces", func(t *testing.T) {
+		tracer, err := newSlotTracer(t.TempDir(), key)
+		require.NoError(t, err)
+		path, err := tracer.write(tracer.get(1, time.Now()))
+		require.NoError(t, err)
+		data := requireSealed(t, path)
+		require.Contains(t, string(data), "traceEvents")
+	})
+
+	t.Run("Recorded relay responses", func(t *testing.T) {
+		dir := t.TempDir()
+		recorder, err := newRelayRecorder(dir, key, testLog)
+		require.NoError(t, err)
+		recorder.record(RelayFixture{Relay: "relay", Endpoint: "get_header", RecordedAt: time.Unix(1, 0)})
+		recorder.wg.Wait()
+		files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		requireSealed(t, files[0])
+
+		fixtures, err := LoadRelayFixtures(dir, key)
+		require.NoError(t, err)
+		require.Len(t, fixtures, 1)
+		require.Equal(t, "relay", fixtures[0].Relay)
+		_, err = LoadRelayFixtures(dir, nil)
+		require.ErrorIs(t, err, errAtRestKeyRequired)
+	})
+}
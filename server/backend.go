@@ -6,4 +6,56 @@ var (
 	pathRegisterValidator = "/eth/v1/builder/validators"
 	pathGetHeader         = "/eth/v1/builder/header/{slot:[0-9]+}/{parent_hash:0x[a-fA-F0-9]+}/{pubkey:0x[a-fA-F0-9]+}"
 	pathGetPayload        = "/eth/v1/builder/blinded_blocks"
+
+	// Relay capability discovery (not part of the builder spec)
+	pathRelayCapabilities = "/relay/v1/capabilities"
+
+	// Relay pubkey challenge (not part of the builder spec)
+	pathRelayChallenge = "/relay/v1/challenge/{nonce:0x[a-fA-F0-9]+}"
+
+	// mev-boost status API
+	pathMevBoostStatus = "/mev-boost/v1/status"
+
+	// Signed attestations of the served bids
+	pathAttestations = "/mev-boost/v1/attestations/{slot:[0-9]+}"
+
+	// Debug API
+	pathDebugBids      = "/mev-boost/v1/debug/bids"
+	pathDebugRelayDiff = "/mev-boost/v1/debug/relay_diff/{slot:[0-9]+}/{parent_hash:0x[a-fA-F0-9]+}/{pubkey:0x[a-fA-F0-9]+}"
+
+	pathDebugRegistrationCoverage = "/mev-boost/v1/debug/registration_coverage"
+	pathDebugEvents               = "/mev-boost/v1/debug/events"
+
+	// Admin API
+	pathAdminReplayRegistrations = "/mev-boost/v1/admin/registrations/replay"
+	pathAdminLogLevel            = "/mev-boost/v1/admin/loglevel"
+	pathAdminRelayDrain          = "/mev-boost/v1/admin/relays/drain"
+	pathAdminAnnotations         = "/mev-boost/v1/admin/annotations"
+	pathAdminFeatures            = "/mev-boost/v1/admin/features"
+
+	// Prometheus metrics
+	pathMetrics = "/metrics"
+)
+
+// Response headers
+const (
+	// HeaderPartialResult is set on getHeader responses served before all relays responded, with the
+	// number of responding relays as value (eg. "2/3")
+	HeaderPartialResult = "X-MEV-Boost-Partial-Result"
+
+	// HeaderRequestID correlates the logs of a request across the CL, mev-boost and relays. It is accepted from
+	// the CL, returned on all responses, and forwarded to relays.
+	HeaderRequestID = "X-Request-ID"
+
+	// HeaderIdempotencyKey is set on the POST requests to relays, with the same key for retries of the same request,
+	// so relays can dedupe them. Relays honoring it return it on their responses.
+	HeaderIdempotencyKey = "Idempotency-Key"
+
+	// HeaderContentDigest is the checksum of the body of POST requests to relays, and of the getPayload responses to
+	// the CL, as in RFC 9530
+	HeaderContentDigest = "Content-Digest"
+
+	// HeaderBidAttestation is set on getHeader responses with the signed attestation of the served bid, if an
+	// attestation key is configured
+	HeaderBidAttestation = "X-MEV-Boost-Bid-Attestation"
 )
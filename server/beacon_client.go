@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SecondsPerSlot is the duration of a slot of Ethereum
+const SecondsPerSlot = 12
+
+// SlotsPerEpoch is the number of slots in an epoch of Ethereum
+const SlotsPerEpoch = 32
+
+// proposerDuty is a block proposal duty of a validator, as returned by the beacon node
+type proposerDuty struct {
+	Pubkey         string `json:"pubkey"`
+	ValidatorIndex uint64 `json:"validator_index,string"`
+	Slot           uint64 `json:"slot,string"`
+}
+
+// beaconClient is a minimal client for the beacon node API
+type beaconClient struct {
+	url        string
+	httpClient http.Client
+}
+
+func newBeaconClient(url string, timeout time.Duration) *beaconClient {
+	return &beaconClient{
+		url:        strings.TrimRight(url, "/"),
+		httpClient: http.Client{Timeout: timeout},
+	}
+}
+
+// genesisTime returns the genesis time of the chain [unix timestamp]
+func (c *beaconClient) genesisTime() (uint64, error) {
+	resp := new(struct {
+		Data struct {
+			GenesisTime uint64 `json:"genesis_time,string"`
+		} `json:"data"`
+	})
+	if _, err := SendHTTPRequest(context.Background(), c.httpClient, http.MethodGet, c.url+"/eth/v1/beacon/genesis", "", nil, resp); err != nil {
+		return 0, err
+	}
+	return resp.Data.GenesisTime, nil
+}
+
+// proposerDuties returns the block proposal duties of all validators for the given epoch
+func (c *beaconClient) proposerDuties(epoch uint64) ([]proposerDuty, error) {
+	resp := new(struct {
+		Data []proposerDuty `json:"data"`
+	})
+	url := fmt.Sprintf("%s/eth/v1/validator/duties/proposer/%d", c.url, epoch)
+	if _, err := SendHTTPRequest(context.Background(), c.httpClient, http.MethodGet, url, "", nil, resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// headExecutionBlockHash returns the execution block hash of the head block
+func (c *beaconClient) headExecutionBlockHash() (string, error) {
+	resp := new(struct {
+		Data struct {
+			Message struct {
+				Body struct {
+					ExecutionPayload struct {
+						BlockHash string `json:"block_hash"`
+					} `json:"execution_payload"`
+				} `json:"body"`
+			} `json:"message"`
+		} `json:"data"`
+	})
+	if _, err := SendHTTPRequest(context.Background(), c.httpClient, http.MethodGet, c.url+"/eth/v2/beacon/blocks/head", "", nil, resp); err != nil {
+		return "", err
+	}
+	return resp.Data.Message.Body.ExecutionPayload.BlockHash, nil
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+var errInvalidBidAttestation = errors.New("invalid bid attestation")
+
+// BidAttestation is the statement of mev-boost about the bid it served for a getHeader request, signed with its
+// attestation key, as evidence of what was presented to the consensus client
+type BidAttestation struct {
+	Slot       uint64    `json:"slot,string"`
+	ParentHash string    `json:"parent_hash"`
+	Pubkey     string    `json:"pubkey"` // proposer pubkey
+	BlockHash  string    `json:"block_hash"`
+	Value      string    `json:"value"` // in wei
+	Relays     []string  `json:"relays"`
+	ServedAt   time.Time `json:"served_at"`
+}
+
+// signedBidAttestation is a bid attestation with the ed25519 signature of its JSON encoding
+type signedBidAttestation struct {
+	Message   json.RawMessage `json:"message"`
+	Signature string          `json:"signature"`
+	PublicKey string          `json:"public_key"`
+}
+
+// LoadAttestationKey loads the ed25519 key signing bid attestations from file:PATH, env:NAME or vault:URL#FIELD, holding the
+// hex-encoded 32-byte seed of the key
+func LoadAttestationKey(source string) (ed25519.PrivateKey, error) {
+	seed, err := loadHexKey(source, ed25519.SeedSize, ErrInvalidAttestationKey)
+	if err != nil {
+		return nil, err
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// signBidAttestation signs an attestation, and returns its header value: the base64url-encoded JSON message and
+// signature, separated by a dot
+func signBidAttestation(key ed25519.PrivateKey, a BidAttestation) (signedBidAttestation, string, error) {
+	message, err := json.Marshal(a)
+	if err != nil {
+		return signedBidAttestation{}, "", err
+	}
+	signature := ed25519.Sign(key, message)
+	signed := signedBidAttestation{
+		Message:   message,
+		Signature: "0x" + hex.EncodeToString(signature),
+		PublicKey: "0x" + hex.EncodeToString(key.Public().(ed25519.PublicKey)),
+	}
+	return signed, base64.RawURLEncoding.EncodeToString(message) + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// VerifyBidAttestation verifies the value of an attestation header against the public key of the mev-boost
+// instance, and returns the attestation
+func VerifyBidAttestation(publicKey ed25519.PublicKey, header string) (*BidAttestation, error) {
+	encodedMessage, encodedSignature, found := strings.Cut(header, ".")
+	if !found {
+		return nil, fmt.Errorf("%w: missing signature", errInvalidBidAttestation)
+	}
+	message, err := base64.RawURLEncoding.DecodeString(encodedMessage)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidBidAttestation, err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidBidAttestation, err)
+	}
+	if len(publicKey) != ed25519.PublicKeySize || !ed25519.Verify(publicKey, message, signature) {
+		return nil, fmt.Errorf("%w: invalid signature", errInvalidBidAttestation)
+	}
+	a := new(BidAttestation)
+	if err := json.Unmarshal(message, a); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidBidAttestation, err)
+	}
+	return a, nil
+}
+
+// attestBid signs the attestation of a served bid, stores it with the bid and sets the attestation header
+func (m *BoostService) attestBid(w http.ResponseWriter, slot uint64, parentHash string, bid *bidResp) error {
+	if m.attestationKey == nil {
+		return nil
+	}
+	value := ""
+	if bid.valueWei != nil {
+		value = bid.valueWei.String()
+	}
+	signed, header, err := signBidAttestation(m.attestationKey, BidAttestation{
+		Slot:       slot,
+		ParentHash: parentHash,
+		Pubkey:     bid.pubkey,
+		BlockHash:  bid.blockHash,
+		Value:      value,
+		Relays:     bid.relays,
+		ServedAt:   bid.servedAt.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	bid.attestation = &signed
+	w.Header().Set(HeaderBidAttestation, header)
+	return nil
+}
+
+// handleAttestations returns the signed attestations of the bids served for a recent slot
+func (m *BoostService) handleAttestations(w http.ResponseWriter, req *http.Request) {
+	slot, err := strconv.ParseUint(mux.Vars(req)["slot"], 10, 64)
+	if err != nil {
+		m.respondError(w, http.StatusBadRequest, "invalid slot")
+		return
+	}
+
+	type servedAttestation struct {
+		servedAt    time.Time
+		attestation signedBidAttestation
+	}
+	served := []servedAttestation{}
+	m.bidsLock.Lock()
+	for key, bid := range m.bids {
+		if key.slot == slot && bid.attestation != nil {
+			served = append(served, servedAttestation{bid.servedAt, *bid.attestation})
+		}
+	}
+	m.bidsLock.Unlock()
+
+	sort.Slice(served, func(i, j int) bool { return served[i].servedAt.Before(served[j].servedAt) })
+	attestations := make([]signedBidAttestation, len(served))
+	for i := range served {
+		attestations[i] = served[i].attestation
+	}
+	m.respondOK(w, attestations)
+}
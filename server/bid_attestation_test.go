@@ -0,0 +1,86 @@
+package server
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAttestationKey(t *testing.T) {
+	seed := strings.Repeat("ab", ed25519.SeedSize)
+	t.Setenv("MEV_BOOST_TEST_ATTESTATION_KEY", seed)
+	key, err := LoadAttestationKey("env:MEV_BOOST_TEST_ATTESTATION_KEY")
+	require.NoError(t, err)
+	require.Equal(t, seed, hex.EncodeToString(key.Seed()))
+
+	for _, source := range []string{"MEV_BOOST_TEST_ATTESTATION_KEY", "kms:key", "env:MEV_BOOST_TEST_UNSET"} {
+		_, err = LoadAttestationKey(source)
+		require.ErrorIs(t, err, ErrInvalidAttestationKey, source)
+	}
+}
+
+func TestBidAttestation(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	path := fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey)
+
+	t.Run("Without attestation key", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		rr := backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Empty(t, rr.Header().Get(HeaderBidAttestation))
+		rr = backend.request(t, http.MethodGet, "/mev-boost/v1/attestations/1", nil)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("With attestation key", func(t *testing.T) {
+		backend := newTestBackend(t, 2, time.Second)
+		_, key, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		backend.boost.attestationKey = key
+		publicKey := key.Public().(ed25519.PublicKey)
+
+		rr := backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		header := rr.Header().Get(HeaderBidAttestation)
+		a, err := VerifyBidAttestation(publicKey, header)
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), a.Slot)
+		require.Equal(t, hash, a.ParentHash)
+		require.Equal(t, pubkey, a.Pubkey)
+		require.Equal(t, "12345", a.Value)
+		require.ElementsMatch(t, []string{backend.relays[0].RelayEntry.String(), backend.relays[1].RelayEntry.String()}, a.Relays)
+
+		// Tampered attestations and other keys are rejected
+		otherKey, _, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		_, err = VerifyBidAttestation(otherKey, header)
+		require.ErrorIs(t, err, errInvalidBidAttestation)
+		_, err = VerifyBidAttestation(publicKey, "x"+header)
+		require.ErrorIs(t, err, errInvalidBidAttestation)
+		_, err = VerifyBidAttestation(publicKey, strings.Split(header, ".")[0])
+		require.ErrorIs(t, err, errInvalidBidAttestation)
+
+		// The attestations of a slot are available afterwards
+		rr = backend.request(t, http.MethodGet, "/mev-boost/v1/attestations/1", nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		attestations := []signedBidAttestation{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &attestations))
+		require.Len(t, attestations, 1)
+		require.Equal(t, "0x"+hex.EncodeToString(publicKey), attestations[0].PublicKey)
+		signature, err := hex.DecodeString(strings.TrimPrefix(attestations[0].Signature, "0x"))
+		require.NoError(t, err)
+		require.True(t, ed25519.Verify(publicKey, attestations[0].Message, signature))
+
+		rr = backend.request(t, http.MethodGet, "/mev-boost/v1/attestations/2", nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Equal(t, "[]\n", rr.Body.String())
+	})
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	bidOracleWindow         = 10 // number of recent slots considered for alerting
+	bidOracleAlertThreshold = 5  // alert if the served bid was below the market in at least this many recent slots
+)
+
+// bidTrace is a bid received by a relay, as returned by the relay data API
+type bidTrace struct {
+	Slot  uint64 `json:"slot,string"`
+	Value string `json:"value"`
+}
+
+// bidOracle compares the served bids with the bids publicly observed by an external source, which implements the
+// relay data API (eg. a relay monitor, or a relay not configured in mev-boost)
+type bidOracle struct {
+	url        string
+	httpClient http.Client
+
+	mu     sync.Mutex
+	recent []bool // whether the served bid was below the market, for the recent slots
+}
+
+func newBidOracle(url string, timeout time.Duration) *bidOracle {
+	return &bidOracle{
+		url:        strings.TrimRight(url, "/"),
+		httpClient: http.Client{Timeout: timeout},
+	}
+}
+
+// maxBidValue returns the highest bid value observed for the slot, or nil if there were no bids
+func (o *bidOracle) maxBidValue(slot uint64) (*big.Int, error) {
+	traces := []bidTrace{}
+	url := fmt.Sprintf("%s/relay/v1/data/bidtraces/builder_blocks_received?slot=%d", o.url, slot)
+	if _, err := SendHTTPRequest(context.Background(), o.httpClient, http.MethodGet, url, "", nil, &traces); err != nil {
+		return nil, err
+	}
+
+	var max *big.Int
+	for _, trace := range traces {
+		value, ok := new(big.Int).SetString(trace.Value, 10)
+		if !ok || trace.Slot != slot {
+			continue
+		}
+		if max == nil || value.Cmp(max) > 0 {
+			max = value
+		}
+	}
+	return max, nil
+}
+
+// record adds the comparison result of a slot, and returns the number of recent slots the served bid was below the market
+func (o *bidOracle) record(belowMarket bool) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.recent = append(o.recent, belowMarket)
+	if len(o.recent) > bidOracleWindow {
+		o.recent = o.recent[len(o.recent)-bidOracleWindow:]
+	}
+
+	count := 0
+	for _, below := range o.recent {
+		if below {
+			count++
+		}
+	}
+	return count
+}
+
+// compareWithBidOracle compares the bid served for a slot with the highest bid observed by the oracle
+func (m *BoostService) compareWithBidOracle(o *slotOutcome) {
+	log := m.log.WithFields(logrus.Fields{
+		"method": "bidOracle",
+		"slot":   o.Slot,
+	})
+
+	marketValue, err := m.bidOracle.maxBidValue(o.Slot)
+	if err != nil {
+		log.WithError(err).Warn("could not get bids from the bid oracle")
+		return
+	}
+	if marketValue == nil {
+		log.Debug("bid oracle observed no bids")
+		return
+	}
+
+	servedValue, ok := new(big.Int).SetString(o.Value, 10)
+	if !ok {
+		servedValue = big.NewInt(0)
+	}
+
+	belowMarket := servedValue.Cmp(marketValue) < 0
+	result := "at_market"
+	if belowMarket {
+		result = "below_market"
+	}
+	m.metrics.bidOracleComparisons.WithLabelValues(result).Inc()
+	numBelowMarket := m.bidOracle.record(belowMarket)
+
+	log = log.WithFields(logrus.Fields{
+		"servedValue":    servedValue.String(),
+		"marketValue":    marketValue.String(),
+		"numBelowMarket": numBelowMarket,
+	})
+	if numBelowMarket >= bidOracleAlertThreshold {
+		log.Warnf("served bids were below the market in %d of the last %d slots, the configured relays might not see the best bids", numBelowMarket, bidOracleWindow)
+	} else if belowMarket {
+		log.Info("served bid was below the market")
+	}
+}
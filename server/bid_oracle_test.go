@@ -0,0 +1,43 @@
+package server
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBidOracleMaxBidValue(t *testing.T) {
+	oracleServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "/relay/v1/data/bidtraces/builder_blocks_received", req.URL.Path)
+		if req.URL.Query().Get("slot") == "2" {
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		fmt.Fprint(w, `[{"slot":"1","value":"100"},{"slot":"1","value":"300"},{"slot":"1","value":"200"}]`)
+	}))
+	defer oracleServer.Close()
+
+	oracle := newBidOracle(oracleServer.URL, time.Second)
+	value, err := oracle.maxBidValue(1)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(300), value)
+
+	value, err = oracle.maxBidValue(2)
+	require.NoError(t, err)
+	require.Nil(t, value)
+}
+
+func TestBidOracleRecord(t *testing.T) {
+	oracle := newBidOracle("", time.Second)
+	for i := 0; i < bidOracleWindow; i++ {
+		require.Equal(t, i+1, oracle.record(true))
+	}
+
+	// Only the recent slots are considered
+	require.Equal(t, bidOracleWindow-1, oracle.record(false))
+}
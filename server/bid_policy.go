@@ -0,0 +1,474 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Limits of bid policies. Policies have no loops, so their evaluation takes at most one step per node.
+const (
+	maxBidPolicyLength = 1000 // [characters]
+	maxBidPolicyNodes  = 200
+)
+
+var errBidPolicyDivisionByZero = errors.New("division by zero")
+
+// bidPolicyType is the type of a bid policy expression
+type bidPolicyType string
+
+const (
+	bidPolicyNumber bidPolicyType = "number"
+	bidPolicyString bidPolicyType = "string"
+	bidPolicyBool   bidPolicyType = "bool"
+)
+
+// bidPolicyEnv is a bid evaluated by a bid policy
+type bidPolicyEnv struct {
+	slot     uint64
+	proposer string
+	relay    RelayEntry
+	valueWei *big.Int
+	bid      *BuilderBid
+}
+
+// bidPolicyVars are the variables of bid policies
+var bidPolicyVars = map[string]struct {
+	typ bidPolicyType
+	get func(env *bidPolicyEnv) any
+}{
+	"bid.value":         {bidPolicyNumber, func(env *bidPolicyEnv) any { return new(big.Rat).SetInt(env.valueWei) }},
+	"bid.block_number":  {bidPolicyNumber, func(env *bidPolicyEnv) any { return ratFromUint64(env.bid.Header.BlockNumber) }},
+	"bid.gas_limit":     {bidPolicyNumber, func(env *bidPolicyEnv) any { return ratFromUint64(env.bid.Header.GasLimit) }},
+	"bid.gas_used":      {bidPolicyNumber, func(env *bidPolicyEnv) any { return ratFromUint64(env.bid.Header.GasUsed) }},
+	"bid.builder":       {bidPolicyString, func(env *bidPolicyEnv) any { return env.bid.Pubkey.String() }},
+	"bid.fee_recipient": {bidPolicyString, func(env *bidPolicyEnv) any { return strings.ToLower(env.bid.Header.FeeRecipient.String()) }},
+	"slot":              {bidPolicyNumber, func(env *bidPolicyEnv) any { return ratFromUint64(env.slot) }},
+	"proposer":          {bidPolicyString, func(env *bidPolicyEnv) any { return strings.ToLower(env.proposer) }},
+	"relay.host":        {bidPolicyString, func(env *bidPolicyEnv) any { return env.relay.URL.Host }},
+	"relay.untrusted":   {bidPolicyBool, func(env *bidPolicyEnv) any { return env.relay.Untrusted }},
+	"relay.min_bid": {bidPolicyNumber, func(env *bidPolicyEnv) any {
+		if env.relay.MinBidValue == nil {
+			return new(big.Rat)
+		}
+		return new(big.Rat).SetInt(env.relay.MinBidValue)
+	}},
+}
+
+func ratFromUint64(n uint64) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).SetUint64(n))
+}
+
+// bidPolicyNode is a node of a compiled bid policy, with the type of its value
+type bidPolicyNode struct {
+	typ  bidPolicyType
+	eval func(env *bidPolicyEnv) (any, error)
+}
+
+// BidPolicy is a boolean expression which bids must satisfy, eg.
+// `bid.value >= eth(0.05) && relay.tag("region") == "eu"`. See the README for the variables and operators.
+type BidPolicy struct {
+	expr string
+	root bidPolicyNode
+}
+
+func (p *BidPolicy) String() string {
+	return p.expr
+}
+
+// ParseBidPolicy compiles a bid policy, verifying the types of its operands
+func ParseBidPolicy(expr string) (*BidPolicy, error) {
+	if len(expr) > maxBidPolicyLength {
+		return nil, fmt.Errorf("%w: longer than %d characters", ErrInvalidBidPolicy, maxBidPolicyLength)
+	}
+	tokens, err := tokenizeBidPolicy(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBidPolicy, err)
+	}
+	p := &bidPolicyParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.peek() != "" {
+		err = fmt.Errorf("unexpected %s", p.peek())
+	}
+	if err == nil && root.typ != bidPolicyBool {
+		err = fmt.Errorf("policy is a %s, not a bool", root.typ)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBidPolicy, err)
+	}
+	return &BidPolicy{expr: expr, root: root}, nil
+}
+
+// allows evaluates the policy on a bid
+func (p *BidPolicy) allows(env *bidPolicyEnv) (bool, error) {
+	v, err := p.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+	return v.(bool), nil
+}
+
+// tokenizeBidPolicy splits an expression into numbers, quoted strings, identifiers (with dots) and operators
+func tokenizeBidPolicy(expr string) ([]string, error) {
+	tokens := []string{}
+	for i := 0; i < len(expr); {
+		c := rune(expr[i])
+		start := i
+		switch {
+		case unicode.IsSpace(c):
+			i++
+			continue
+		case unicode.IsDigit(c):
+			for i < len(expr) && (unicode.IsDigit(rune(expr[i])) || expr[i] == '.') {
+				i++
+			}
+		case unicode.IsLetter(c) || c == '_':
+			for i < len(expr) && (unicode.IsLetter(rune(expr[i])) || unicode.IsDigit(rune(expr[i])) || expr[i] == '_' || expr[i] == '.') {
+				i++
+			}
+		case c == '"':
+			for i++; i < len(expr) && expr[i] != '"'; i++ {
+				if expr[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(expr) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			i++
+		case strings.HasPrefix(expr[i:], "&&") || strings.HasPrefix(expr[i:], "||") || strings.HasPrefix(expr[i:], "==") ||
+			strings.HasPrefix(expr[i:], "!=") || strings.HasPrefix(expr[i:], "<=") || strings.HasPrefix(expr[i:], ">="):
+			i += 2
+		case strings.ContainsRune("!<>+-*/(),", c):
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character %q", c)
+		}
+		tokens = append(tokens, expr[start:i])
+	}
+	return tokens, nil
+}
+
+// bidPolicyParser is a recursive descent parser of bid policies, by increasing precedence: ||, &&, comparisons,
+// + and -, * and /, unary ! and -
+type bidPolicyParser struct {
+	tokens []string
+	pos    int
+	nodes  int
+}
+
+func (p *bidPolicyParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *bidPolicyParser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+func (p *bidPolicyParser) node(typ bidPolicyType, eval func(env *bidPolicyEnv) (any, error)) (bidPolicyNode, error) {
+	p.nodes++
+	if p.nodes > maxBidPolicyNodes {
+		return bidPolicyNode{}, fmt.Errorf("more than %d operations", maxBidPolicyNodes)
+	}
+	return bidPolicyNode{typ, eval}, nil
+}
+
+func expectType(op string, typ bidPolicyType, operands ...bidPolicyNode) error {
+	for _, operand := range operands {
+		if operand.typ != typ {
+			return fmt.Errorf("%s needs a %s, got a %s", op, typ, operand.typ)
+		}
+	}
+	return nil
+}
+
+func (p *bidPolicyParser) parseOr() (bidPolicyNode, error) {
+	return p.parseLogical("||", p.parseAnd)
+}
+
+func (p *bidPolicyParser) parseAnd() (bidPolicyNode, error) {
+	return p.parseLogical("&&", p.parseComparison)
+}
+
+// parseLogical parses a chain of || or && operators, which are short-circuiting
+func (p *bidPolicyParser) parseLogical(op string, parseOperand func() (bidPolicyNode, error)) (bidPolicyNode, error) {
+	left, err := parseOperand()
+	for err == nil && p.peek() == op {
+		p.next()
+		var right bidPolicyNode
+		if right, err = parseOperand(); err != nil {
+			break
+		}
+		if err = expectType(op, bidPolicyBool, left, right); err != nil {
+			break
+		}
+		l, r := left, right
+		left, err = p.node(bidPolicyBool, func(env *bidPolicyEnv) (any, error) {
+			v, err := l.eval(env)
+			if err != nil || v.(bool) == (op == "||") {
+				return v, err
+			}
+			return r.eval(env)
+		})
+	}
+	return left, err
+}
+
+func (p *bidPolicyParser) parseComparison() (bidPolicyNode, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return left, err
+	}
+	op := p.peek()
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=":
+	default:
+		return left, nil
+	}
+	p.next()
+	right, err := p.parseSum()
+	if err != nil {
+		return right, err
+	}
+	if left.typ != right.typ {
+		return left, fmt.Errorf("cannot compare a %s with a %s", left.typ, right.typ)
+	}
+	if left.typ != bidPolicyNumber && op != "==" && op != "!=" {
+		return left, fmt.Errorf("%s needs numbers, got a %s", op, left.typ)
+	}
+	return p.node(bidPolicyBool, func(env *bidPolicyEnv) (any, error) {
+		l, err := left.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		r, err := right.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		cmp := 0
+		if left.typ == bidPolicyNumber {
+			cmp = l.(*big.Rat).Cmp(r.(*big.Rat))
+		} else if l != r {
+			cmp = 1
+		}
+		switch op {
+		case "==":
+			return cmp == 0, nil
+		case "!=":
+			return cmp != 0, nil
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		}
+		return cmp >= 0, nil
+	})
+}
+
+func (p *bidPolicyParser) parseSum() (bidPolicyNode, error) {
+	return p.parseArithmetic("+-", p.parseProduct)
+}
+
+func (p *bidPolicyParser) parseProduct() (bidPolicyNode, error) {
+	return p.parseArithmetic("*/", p.parseUnary)
+}
+
+// parseArithmetic parses a chain of operators of the same precedence on numbers
+func (p *bidPolicyParser) parseArithmetic(ops string, parseOperand func() (bidPolicyNode, error)) (bidPolicyNode, error) {
+	left, err := parseOperand()
+	for err == nil && len(p.peek()) == 1 && strings.Contains(ops, p.peek()) {
+		op := p.next()
+		var right bidPolicyNode
+		if right, err = parseOperand(); err != nil {
+			break
+		}
+		if err = expectType(op, bidPolicyNumber, left, right); err != nil {
+			break
+		}
+		l, r := left, right
+		left, err = p.node(bidPolicyNumber, func(env *bidPolicyEnv) (any, error) {
+			lv, err := l.eval(env)
+			if err != nil {
+				return nil, err
+			}
+			rv, err := r.eval(env)
+			if err != nil {
+				return nil, err
+			}
+			a, b := lv.(*big.Rat), rv.(*big.Rat)
+			switch op {
+			case "+":
+				return new(big.Rat).Add(a, b), nil
+			case "-":
+				return new(big.Rat).Sub(a, b), nil
+			case "*":
+				return new(big.Rat).Mul(a, b), nil
+			}
+			if b.Sign() == 0 {
+				return nil, errBidPolicyDivisionByZero
+			}
+			return new(big.Rat).Quo(a, b), nil
+		})
+	}
+	return left, err
+}
+
+func (p *bidPolicyParser) parseUnary() (bidPolicyNode, error) {
+	switch op := p.peek(); op {
+	case "!", "-":
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return operand, err
+		}
+		if op == "!" {
+			if err := expectType(op, bidPolicyBool, operand); err != nil {
+				return operand, err
+			}
+			return p.node(bidPolicyBool, func(env *bidPolicyEnv) (any, error) {
+				v, err := operand.eval(env)
+				if err != nil {
+					return nil, err
+				}
+				return !v.(bool), nil
+			})
+		}
+		if err := expectType(op, bidPolicyNumber, operand); err != nil {
+			return operand, err
+		}
+		return p.node(bidPolicyNumber, func(env *bidPolicyEnv) (any, error) {
+			v, err := operand.eval(env)
+			if err != nil {
+				return nil, err
+			}
+			return new(big.Rat).Neg(v.(*big.Rat)), nil
+		})
+	}
+	return p.parsePrimary()
+}
+
+func (p *bidPolicyParser) parsePrimary() (bidPolicyNode, error) {
+	token := p.next()
+	switch {
+	case token == "":
+		return bidPolicyNode{}, fmt.Errorf("unexpected end of policy")
+	case token == "(":
+		node, err := p.parseOr()
+		if err == nil && p.next() != ")" {
+			err = fmt.Errorf("missing )")
+		}
+		return node, err
+	case token == "true" || token == "false":
+		return p.constant(bidPolicyBool, token == "true")
+	case token[0] == '"':
+		s, err := strconv.Unquote(token)
+		if err != nil {
+			return bidPolicyNode{}, fmt.Errorf("invalid string %s", token)
+		}
+		return p.constant(bidPolicyString, s)
+	case unicode.IsDigit(rune(token[0])):
+		n, ok := new(big.Rat).SetString(token)
+		if !ok {
+			return bidPolicyNode{}, fmt.Errorf("invalid number %s", token)
+		}
+		return p.constant(bidPolicyNumber, n)
+	case p.peek() == "(":
+		return p.parseCall(token)
+	}
+	v, ok := bidPolicyVars[token]
+	if !ok {
+		return bidPolicyNode{}, fmt.Errorf("unknown variable %s", token)
+	}
+	return p.node(v.typ, func(env *bidPolicyEnv) (any, error) {
+		return v.get(env), nil
+	})
+}
+
+func (p *bidPolicyParser) constant(typ bidPolicyType, v any) (bidPolicyNode, error) {
+	return p.node(typ, func(*bidPolicyEnv) (any, error) {
+		return v, nil
+	})
+}
+
+// parseCall parses a call of a function with a single argument: relay.tag(key), and eth(n) and gwei(n) converting
+// amounts to wei
+func (p *bidPolicyParser) parseCall(name string) (bidPolicyNode, error) {
+	p.next()
+	arg, err := p.parseOr()
+	if err != nil {
+		return arg, err
+	}
+	if p.next() != ")" {
+		return arg, fmt.Errorf("%s takes a single argument", name)
+	}
+
+	switch name {
+	case "relay.tag":
+		if err := expectType(name, bidPolicyString, arg); err != nil {
+			return arg, err
+		}
+		return p.node(bidPolicyString, func(env *bidPolicyEnv) (any, error) {
+			key, err := arg.eval(env)
+			if err != nil {
+				return nil, err
+			}
+			return env.relay.Tags[key.(string)], nil
+		})
+	case "eth", "gwei":
+		if err := expectType(name, bidPolicyNumber, arg); err != nil {
+			return arg, err
+		}
+		unit := new(big.Rat).SetInt(big.NewInt(1e18))
+		if name == "gwei" {
+			unit = new(big.Rat).SetInt(big.NewInt(1e9))
+		}
+		return p.node(bidPolicyNumber, func(env *bidPolicyEnv) (any, error) {
+			v, err := arg.eval(env)
+			if err != nil {
+				return nil, err
+			}
+			return new(big.Rat).Mul(v.(*big.Rat), unit), nil
+		})
+	}
+	return arg, fmt.Errorf("unknown function %s", name)
+}
+
+// bidPolicies returns the bid policies applying to the bids of a proposer
+func (m *BoostService) bidPolicies(pubkey string) []*BidPolicy {
+	policies := []*BidPolicy{}
+	if m.bidPolicy != nil {
+		policies = append(policies, m.bidPolicy)
+	}
+	if policy := m.proposerConfig.settings(pubkey).bidPolicy; policy != nil {
+		policies = append(policies, policy)
+	}
+	return policies
+}
+
+// allowedByBidPolicies returns whether a bid satisfies all the bid policies. Bids for which a policy fails to
+// evaluate are not allowed.
+func (m *BoostService) allowedByBidPolicies(log *logrus.Entry, policies []*BidPolicy, env *bidPolicyEnv) bool {
+	for _, policy := range policies {
+		allowed, err := policy.allows(env)
+		if err != nil {
+			log.WithError(err).WithField("policy", policy.String()).Warn("could not evaluate bid policy")
+		}
+		if !allowed {
+			log.WithField("policy", policy.String()).Debug("dropping bid not satisfying the bid policy")
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBidPolicy(t *testing.T) {
+	relay, err := NewRelayEntry("http://0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249@relay1.com")
+	require.NoError(t, err)
+	relay.Tags = map[string]string{"region": "eu"}
+	env := &bidPolicyEnv{
+		slot:     10,
+		proposer: "0xABCD",
+		relay:    relay,
+		valueWei: big.NewInt(2_000_000_000_000_000),
+		bid:      &BuilderBid{Header: &ExecutionPayloadHeader{ExecutionPayloadHeader: types.ExecutionPayloadHeader{BlockNumber: 5, GasLimit: 30_000_000, GasUsed: 15_000_000}}},
+	}
+
+	for expr, expected := range map[string]bool{
+		`bid.value >= eth(0.002)`:                               true,
+		`bid.value > gwei(2000000)`:                             false,
+		`bid.value >= eth(0.01) || relay.tag("region") == "eu"`: true,
+		`relay.tag("region") != "eu" && bid.value > 0`:          false,
+		`relay.tag("operator") == ""`:                           true,
+		`relay.host == "relay1.com" && !relay.untrusted`:        true,
+		`bid.gas_used * 2 == bid.gas_limit`:                     true,
+		`(slot - bid.block_number) / 5 == 1`:                    true,
+		`-bid.value < relay.min_bid`:                            true,
+		`proposer == "0xabcd"`:                                  true,
+		`true || 1 / 0 == 0`:                                    true,
+	} {
+		policy, err := ParseBidPolicy(expr)
+		require.NoError(t, err, expr)
+		require.Equal(t, expr, policy.String())
+		allowed, err := policy.allows(env)
+		require.NoError(t, err, expr)
+		require.Equal(t, expected, allowed, expr)
+	}
+
+	// Runtime errors do not allow the bid
+	policy, err := ParseBidPolicy(`bid.value / (slot - 10) > 0`)
+	require.NoError(t, err)
+	allowed, err := policy.allows(env)
+	require.ErrorIs(t, err, errBidPolicyDivisionByZero)
+	require.False(t, allowed)
+
+	for _, expr := range []string{
+		``, `bid.value`, `bid.value >`, `bid.value > "1"`, `bid.valu > 1`, `relay.tag(1) == ""`, `eth("1") > 0`,
+		`foo(1)`, `(true`, `true)`, `"abc == "abc"`, `1 < 2 < 3`, `"a" < "b"`, `!1`, `-true`, `bid.value > 1 $`,
+		strings.Repeat("true && ", maxBidPolicyNodes) + "true", strings.Repeat(" ", maxBidPolicyLength+1),
+	} {
+		_, err := ParseBidPolicy(expr)
+		require.ErrorIs(t, err, ErrInvalidBidPolicy, expr)
+	}
+}
+
+func TestBidPolicyDropsBids(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	path := fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey)
+
+	backend := newTestBackend(t, 1, time.Second)
+	relay := backend.boost.relays[0].String()
+
+	// The mock relay bids 12345 wei
+	policy, err := ParseBidPolicy(`bid.value >= 12345`)
+	require.NoError(t, err)
+	backend.boost.bidPolicy = policy
+	rr := backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	// The policies of the proposer config apply in addition
+	proposerConfig := &ProposerConfig{Default: ProposerSettings{BidPolicy: `bid.value > 12345`}}
+	require.NoError(t, proposerConfig.Default.compileBidPolicy())
+	backend.boost.proposerConfig = newProposerConfigStore(proposerConfig)
+	rr = backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.relayDroppedBids.WithLabelValues(relay, string(BidRejectionPolicy))))
+	require.Equal(t, uint64(1), backend.boost.bidRejections.snapshot()[relay][BidRejectionPolicy])
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"net/http"
+	"time"
+)
+
+// bidProvenance is the chain of custody of a served bid from one of the relays which delivered it, to trace back
+// disputed bids
+type bidProvenance struct {
+	RelayURL        string      `json:"relay_url"`
+	RelayPubkey     string      `json:"relay_pubkey"`
+	BuilderPubkey   string      `json:"builder_pubkey"`
+	ResponseHeaders http.Header `json:"response_headers"`
+	RequestedAt     time.Time   `json:"requested_at"`
+	ReceivedAt      time.Time   `json:"received_at"`
+	ValidationTime  int64       `json:"validation_time_us"` // time spent validating the bid, incl. the signature
+}
+
+// newBidProvenance records the provenance of a bid received from relay
+func newBidProvenance(relay RelayEntry, bid *GetHeaderResponse, header http.Header, requestedAt, receivedAt time.Time, validationTime time.Duration) bidProvenance {
+	return bidProvenance{
+		RelayURL:        relay.GetURI(""),
+		RelayPubkey:     relay.PublicKey.String(),
+		BuilderPubkey:   bid.Data.Message.Pubkey.String(),
+		ResponseHeaders: header.Clone(),
+		RequestedAt:     requestedAt.UTC(),
+		ReceivedAt:      receivedAt.UTC(),
+		ValidationTime:  validationTime.Microseconds(),
+	}
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBidProvenance(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	builderPubkey := pubkey
+	backend := newTestBackend(t, 3, time.Second)
+
+	// Two relays deliver the best bid, the third one a lower bid
+	for _, relay := range backend.relays[:2] {
+		relay.GetHeaderResponse = relay.MakeGetHeaderResponse(12345, "0xa38385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7", builderPubkey)
+	}
+	backend.relays[2].GetHeaderResponse = backend.relays[2].MakeGetHeaderResponse(1, "0xb38385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7", builderPubkey)
+
+	start := time.Now()
+	result := backend.boost.requestBids(context.Background(), testLog, 1, hash, pubkey, "")
+	provenance := result.bid.provenance
+	require.Len(t, provenance, 2)
+
+	relayURLs := []string{}
+	for _, p := range provenance {
+		relayURLs = append(relayURLs, p.RelayURL)
+		require.Equal(t, builderPubkey, p.BuilderPubkey)
+		require.Equal(t, "application/json", p.ResponseHeaders.Get("Content-Type"))
+		require.False(t, p.RequestedAt.Before(start.UTC()))
+		require.False(t, p.ReceivedAt.Before(p.RequestedAt))
+		require.GreaterOrEqual(t, p.ValidationTime, int64(0))
+	}
+	for _, relay := range backend.relays[:2] {
+		require.Contains(t, relayURLs, relay.RelayEntry.GetURI(""))
+	}
+	require.ElementsMatch(t, []string{backend.relays[0].RelayEntry.PublicKey.String(), backend.relays[1].RelayEntry.PublicKey.String()},
+		[]string{provenance[0].RelayPubkey, provenance[1].RelayPubkey})
+}
@@ -0,0 +1,90 @@
+package server
+
+import "sync"
+
+// BidRejectionReason is a machine-readable reason why a relay bid was not selected
+type BidRejectionReason string
+
+// Bid rejection reasons
+const (
+	BidRejectionInvalidResponse    BidRejectionReason = "invalid_response"
+	BidRejectionVersionMismatch    BidRejectionReason = "version_mismatch"
+	BidRejectionPubkeyMismatch     BidRejectionReason = "pubkey_mismatch"
+	BidRejectionInvalidSignature   BidRejectionReason = "invalid_signature"
+	BidRejectionParentHashMismatch BidRejectionReason = "parent_hash_mismatch"
+	BidRejectionTimestampMismatch  BidRejectionReason = "timestamp_mismatch"
+	BidRejectionZeroValue          BidRejectionReason = "zero_value"
+	BidRejectionLowerValue         BidRejectionReason = "lower_value"
+	BidRejectionRelayError         BidRejectionReason = "relay_error"
+	BidRejectionRateLimited        BidRejectionReason = "rate_limited"
+	BidRejectionOversizedResponse  BidRejectionReason = "oversized_response"
+	BidRejectionSpecDeviation      BidRejectionReason = "spec_deviation"
+	BidRejectionBelowMinValue      BidRejectionReason = "below_min_value"
+	BidRejectionValidationSkipped  BidRejectionReason = "validation_skipped"
+	BidRejectionPolicy             BidRejectionReason = "policy"
+	BidRejectionBelowProposerMin   BidRejectionReason = "below_proposer_min_value"
+	BidRejectionBelowMinBid        BidRejectionReason = "below_min_bid"
+)
+
+// bidRejection describes a single bid which was not selected
+type bidRejection struct {
+	Relay     string             `json:"relay"`
+	BlockHash string             `json:"block_hash,omitempty"`
+	Value     string             `json:"value,omitempty"`
+	Reason    BidRejectionReason `json:"reason"`
+	Message   string             `json:"message,omitempty"` // relay-supplied error message
+}
+
+// debugBid is a served bid, as returned by the debug bids endpoint
+type debugBid struct {
+	Slot       uint64          `json:"slot,string"`
+	Pubkey     string          `json:"pubkey"`
+	BlockHash  string          `json:"block_hash"`
+	Value      string          `json:"value"`
+	Relays     []string        `json:"relays"`
+	Rejections []bidRejection  `json:"rejections"`
+	Decision   decisionHashes  `json:"decision"`
+	Provenance []bidProvenance `json:"provenance"`
+}
+
+// debugBidsResponse is the response of the debug bids endpoint
+type debugBidsResponse struct {
+	Bids        []debugBid                               `json:"bids"`
+	Rejections  map[string]map[BidRejectionReason]uint64 `json:"rejections"`
+	RelayErrors map[string]map[string]uint64             `json:"relay_errors"`
+}
+
+// bidRejectionStats counts bid rejections per relay and reason
+type bidRejectionStats struct {
+	mu     sync.Mutex
+	counts map[string]map[BidRejectionReason]uint64
+}
+
+func newBidRejectionStats() *bidRejectionStats {
+	return &bidRejectionStats{counts: make(map[string]map[BidRejectionReason]uint64)}
+}
+
+func (s *bidRejectionStats) add(relay string, reason BidRejectionReason) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.counts[relay]; !ok {
+		s.counts[relay] = make(map[BidRejectionReason]uint64)
+	}
+	s.counts[relay][reason]++
+}
+
+// snapshot returns a copy of the counts per relay and reason
+func (s *bidRejectionStats) snapshot() map[string]map[BidRejectionReason]uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ret := make(map[string]map[BidRejectionReason]uint64, len(s.counts))
+	for relay, reasons := range s.counts {
+		ret[relay] = make(map[BidRejectionReason]uint64, len(reasons))
+		for reason, count := range reasons {
+			ret[relay][reason] = count
+		}
+	}
+	return ret
+}
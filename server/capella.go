@@ -0,0 +1,182 @@
+package server
+
+import (
+	"encoding/json"
+
+	ssz "github.com/ferranbt/fastssz"
+	"github.com/flashbots/go-boost-utils/types"
+)
+
+// Versions of the Builder API responses
+const (
+	versionBellatrix = "bellatrix"
+	versionCapella   = "capella"
+)
+
+// maxWithdrawalsPerPayload is the SSZ limit of the withdrawals of an execution payload
+const maxWithdrawalsPerPayload = 16
+
+// The Builder API types of go-boost-utils are those of Bellatrix. The types below extend them with the withdrawals of
+// Capella, which are omitted from Bellatrix messages, so that the same types carry the messages of both forks.
+
+// Withdrawal is a withdrawal of the consensus layer, processed in an execution payload since Capella
+type Withdrawal struct {
+	Index          uint64        `json:"index,string"`
+	ValidatorIndex uint64        `json:"validator_index,string"`
+	Address        types.Address `json:"address"`
+	Amount         uint64        `json:"amount,string"` // in gwei
+}
+
+// HashTreeRootWith hashes the withdrawal with a hasher
+func (w *Withdrawal) HashTreeRootWith(hh ssz.HashWalker) error {
+	if w == nil {
+		return errNilWithdrawal
+	}
+	indx := hh.Index()
+	hh.PutUint64(w.Index)
+	hh.PutUint64(w.ValidatorIndex)
+	hh.PutBytes(w.Address[:])
+	hh.PutUint64(w.Amount)
+	hh.Merkleize(indx)
+	return nil
+}
+
+// Withdrawals are the withdrawals of an execution payload
+type Withdrawals []*Withdrawal
+
+// HashTreeRoot returns the withdrawals root of the header of the payload
+func (w Withdrawals) HashTreeRoot() (types.Root, error) {
+	if len(w) > maxWithdrawalsPerPayload {
+		return types.Root{}, ssz.ErrIncorrectListSize
+	}
+	hh := ssz.DefaultHasherPool.Get()
+	defer ssz.DefaultHasherPool.Put(hh)
+	indx := hh.Index()
+	for _, withdrawal := range w {
+		if err := withdrawal.HashTreeRootWith(hh); err != nil {
+			return types.Root{}, err
+		}
+	}
+	hh.MerkleizeWithMixin(indx, uint64(len(w)), maxWithdrawalsPerPayload)
+	root, err := hh.HashRoot()
+	return types.Root(root), err
+}
+
+// ExecutionPayloadHeader is the header of an execution payload, with the withdrawals root since Capella
+type ExecutionPayloadHeader struct {
+	types.ExecutionPayloadHeader
+	WithdrawalsRoot *types.Root `json:"withdrawals_root,omitempty"`
+}
+
+// HashTreeRoot hashes the header, as a Capella header if it has a withdrawals root
+func (h *ExecutionPayloadHeader) HashTreeRoot() ([32]byte, error) {
+	hh := ssz.DefaultHasherPool.Get()
+	defer ssz.DefaultHasherPool.Put(hh)
+	if err := h.HashTreeRootWith(hh); err != nil {
+		return [32]byte{}, err
+	}
+	return hh.HashRoot()
+}
+
+// HashTreeRootWith hashes the header with a hasher
+func (h *ExecutionPayloadHeader) HashTreeRootWith(hh ssz.HashWalker) error {
+	if h.WithdrawalsRoot == nil {
+		return h.ExecutionPayloadHeader.HashTreeRootWith(hh)
+	}
+	if len(h.ExtraData) > 32 {
+		return ssz.ErrIncorrectListSize
+	}
+
+	indx := hh.Index()
+	hh.PutBytes(h.ParentHash[:])
+	hh.PutBytes(h.FeeRecipient[:])
+	hh.PutBytes(h.StateRoot[:])
+	hh.PutBytes(h.ReceiptsRoot[:])
+	hh.PutBytes(h.LogsBloom[:])
+	hh.PutBytes(h.Random[:])
+	hh.PutUint64(h.BlockNumber)
+	hh.PutUint64(h.GasLimit)
+	hh.PutUint64(h.GasUsed)
+	hh.PutUint64(h.Timestamp)
+	extraDataIndx := hh.Index()
+	hh.PutBytes(h.ExtraData)
+	hh.MerkleizeWithMixin(extraDataIndx, uint64(len(h.ExtraData)), (32+31)/32)
+	hh.PutBytes(h.BaseFeePerGas[:])
+	hh.PutBytes(h.BlockHash[:])
+	hh.PutBytes(h.TransactionsRoot[:])
+	hh.PutBytes(h.WithdrawalsRoot[:])
+	hh.Merkleize(indx)
+	return nil
+}
+
+// BuilderBid is the bid of a builder, signed by the relay
+type BuilderBid struct {
+	Header *ExecutionPayloadHeader `json:"header"`
+	Value  types.U256Str           `json:"value"`
+	Pubkey types.PublicKey         `json:"pubkey"`
+}
+
+// HashTreeRoot hashes the bid, which is the signed message of the relay
+func (b *BuilderBid) HashTreeRoot() ([32]byte, error) {
+	header := b.Header
+	if header == nil {
+		header = new(ExecutionPayloadHeader)
+	}
+	hh := ssz.DefaultHasherPool.Get()
+	defer ssz.DefaultHasherPool.Put(hh)
+	indx := hh.Index()
+	if err := header.HashTreeRootWith(hh); err != nil {
+		return [32]byte{}, err
+	}
+	hh.PutBytes(b.Value[:])
+	hh.PutBytes(b.Pubkey[:])
+	hh.Merkleize(indx)
+	return hh.HashRoot()
+}
+
+// SignedBuilderBid is a bid with the signature of the relay
+type SignedBuilderBid struct {
+	Message   *BuilderBid     `json:"message"`
+	Signature types.Signature `json:"signature"`
+}
+
+// GetHeaderResponse is the response of the getHeader request
+type GetHeaderResponse struct {
+	Version types.VersionString `json:"version"`
+	Data    *SignedBuilderBid   `json:"data"`
+}
+
+// ExecutionPayload is an execution payload, with the withdrawals since Capella
+type ExecutionPayload struct {
+	types.ExecutionPayload
+	Withdrawals *Withdrawals `json:"withdrawals,omitempty"`
+}
+
+// GetPayloadResponse is the response of the getPayload request
+type GetPayloadResponse struct {
+	Version types.VersionString `json:"version"`
+	Data    *ExecutionPayload   `json:"data"`
+}
+
+// BlindedBeaconBlockBody is the body of a blinded block, with the header of the execution payload including the
+// withdrawals root, and the BLS to execution changes since Capella, which are passed on to the relays as is
+type BlindedBeaconBlockBody struct {
+	types.BlindedBeaconBlockBody
+	ExecutionPayloadHeader *ExecutionPayloadHeader `json:"execution_payload_header"`
+	BLSToExecutionChanges  json.RawMessage         `json:"bls_to_execution_changes,omitempty"`
+}
+
+// BlindedBeaconBlock is a blinded block of the proposer
+type BlindedBeaconBlock struct {
+	Slot          uint64                  `json:"slot,string"`
+	ProposerIndex uint64                  `json:"proposer_index,string"`
+	ParentRoot    types.Root              `json:"parent_root"`
+	StateRoot     types.Root              `json:"state_root"`
+	Body          *BlindedBeaconBlockBody `json:"body"`
+}
+
+// SignedBlindedBeaconBlock is the blinded block signed by the proposer, the request of getPayload
+type SignedBlindedBeaconBlock struct {
+	Message   *BlindedBeaconBlock `json:"message"`
+	Signature types.Signature     `json:"signature"`
+}
@@ -0,0 +1,233 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithdrawalsHashTreeRoot(t *testing.T) {
+	root, err := Withdrawals{}.HashTreeRoot()
+	require.NoError(t, err)
+	require.Equal(t, "0x792930bbd5baac43bcc798ee49aa8185ef76bb3b44ba62b91d86ae569e4bb535", root.String()) // no withdrawals
+
+	otherRoot, err := Withdrawals{{Index: 1, ValidatorIndex: 2, Address: types.Address{0x03}, Amount: 4}}.HashTreeRoot()
+	require.NoError(t, err)
+	require.NotEqual(t, root, otherRoot)
+
+	_, err = make(Withdrawals, maxWithdrawalsPerPayload+1).HashTreeRoot()
+	require.Error(t, err)
+
+	_, err = Withdrawals{nil}.HashTreeRoot()
+	require.ErrorIs(t, err, errNilWithdrawal)
+}
+
+func TestExecutionPayloadHeaderCapella(t *testing.T) {
+	header := &ExecutionPayloadHeader{ExecutionPayloadHeader: types.ExecutionPayloadHeader{
+		BlockHash:   types.Hash{0x01},
+		BlockNumber: 12345,
+		ExtraData:   types.ExtraData{0x02},
+	}}
+
+	// Bellatrix headers hash and encode as in go-boost-utils
+	root, err := header.HashTreeRoot()
+	require.NoError(t, err)
+	expectedRoot, err := header.ExecutionPayloadHeader.HashTreeRoot()
+	require.NoError(t, err)
+	require.Equal(t, expectedRoot, root)
+	data, err := json.Marshal(header)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "withdrawals_root")
+
+	// Capella headers commit to the withdrawals root
+	header.WithdrawalsRoot = &types.Root{0x03}
+	capellaRoot, err := header.HashTreeRoot()
+	require.NoError(t, err)
+	require.NotEqual(t, root, capellaRoot)
+	data, err = json.Marshal(header)
+	require.NoError(t, err)
+	decoded := new(ExecutionPayloadHeader)
+	require.NoError(t, DecodeJSON(bytes.NewReader(data), decoded))
+	require.Equal(t, header, decoded)
+}
+
+func TestVerifyWithdrawalsRoot(t *testing.T) {
+	withdrawals := Withdrawals{{Index: 1, ValidatorIndex: 2, Amount: 3}}
+	withdrawalsRoot, err := withdrawals.HashTreeRoot()
+	require.NoError(t, err)
+	payload := makeTestPayload(t, 10, 200)
+	payload.Withdrawals = &withdrawals
+	header := makeTestHeader(t, payload)
+	header.WithdrawalsRoot = &withdrawalsRoot
+	require.NoError(t, verifyWithdrawalsRoot(header, payload))
+	require.NoError(t, verifyPayloadRoots(header, payload))
+
+	tampered := *payload
+	tampered.Withdrawals = &Withdrawals{{Index: 1, ValidatorIndex: 2, Amount: 4}}
+	require.ErrorIs(t, verifyWithdrawalsRoot(header, &tampered), errWithdrawalsRootMismatch)
+	require.ErrorIs(t, verifyPayloadRoots(header, &tampered), errWithdrawalsRootMismatch)
+
+	tampered.Withdrawals = nil
+	require.ErrorIs(t, verifyWithdrawalsRoot(header, &tampered), errWithdrawalsRootMismatch)
+}
+
+func TestGetHeaderCapella(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	path := fmt.Sprintf("/eth/v1/builder/header/%d/%s/%s", 10*SlotsPerEpoch, hash, pubkey)
+	withdrawalsRoot := types.Root{0x01}
+
+	newBackend := func(t *testing.T, version types.VersionString, withdrawalsRoot *types.Root) *testBackend {
+		t.Helper()
+		backend := newTestBackend(t, 1, time.Second)
+		backend.boost.forkSchedule = ForkSchedule{{Name: "bellatrix", Epoch: 0}, {Name: "capella", Epoch: 10}}
+		resp := backend.relays[0].MakeGetHeaderResponse(12345, hash, pubkey)
+		resp.Version = version
+		resp.Data.Message.Header.WithdrawalsRoot = withdrawalsRoot
+		signature, err := types.SignMessage(resp.Data.Message, types.DomainBuilder, mockRelaySecretKey)
+		require.NoError(t, err)
+		resp.Data.Signature = signature
+		backend.relays[0].GetHeaderResponse = resp
+		return backend
+	}
+
+	t.Run("Capella bid", func(t *testing.T) {
+		backend := newBackend(t, versionCapella, &withdrawalsRoot)
+		rr := backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		resp := new(GetHeaderResponse)
+		require.NoError(t, DecodeJSON(rr.Body, resp))
+		require.Equal(t, &withdrawalsRoot, resp.Data.Message.Header.WithdrawalsRoot)
+	})
+
+	t.Run("Capella bid in SSZ", func(t *testing.T) {
+		backend := newBackend(t, versionCapella, &withdrawalsRoot)
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", "application/octet-stream")
+		rr := httptest.NewRecorder()
+		backend.boost.getRouter().ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Equal(t, versionCapella, rr.Header().Get(HeaderConsensusVersion))
+
+		header := rr.Body.Bytes()[signedBuilderBidFixed+builderBidFixed:]
+		require.Len(t, header, executionPayloadHeaderFixedSize+32)
+		require.Equal(t, uint32(executionPayloadHeaderFixedSize+32), binary.LittleEndian.Uint32(header[extraDataOffsetPosition:]))
+		require.Equal(t, withdrawalsRoot[:], header[executionPayloadHeaderFixedSize:])
+	})
+
+	t.Run("Capella bid without withdrawals root", func(t *testing.T) {
+		backend := newBackend(t, versionCapella, nil)
+		rr := backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	})
+
+	t.Run("Bellatrix bid with withdrawals root", func(t *testing.T) {
+		backend := newBackend(t, versionBellatrix, &withdrawalsRoot)
+		backend.boost.forkSchedule = nil
+		rr := backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	})
+}
+
+func TestGetPayloadCapella(t *testing.T) {
+	withdrawals := Withdrawals{{Index: 1, ValidatorIndex: 2, Address: types.Address{0x03}, Amount: 4}}
+	withdrawalsRoot, err := withdrawals.HashTreeRoot()
+	require.NoError(t, err)
+	payload := makeTestPayload(t, 10, 200)
+	payload.Withdrawals = &withdrawals
+	header := makeTestHeader(t, payload)
+	header.WithdrawalsRoot = &withdrawalsRoot
+	blindedBlock := makeTestBlindedBlock(header)
+	blindedBlock.Message.Body.BLSToExecutionChanges = json.RawMessage(`[]`)
+
+	t.Run("Payload matching the withdrawals root", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		backend.relays[0].GetPayloadResponse = &GetPayloadResponse{Version: versionCapella, Data: payload}
+		rr := backend.request(t, http.MethodPost, pathGetPayload, blindedBlock)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		resp := new(GetPayloadResponse)
+		require.NoError(t, DecodeJSON(rr.Body, resp))
+		require.Equal(t, &withdrawals, resp.Data.Withdrawals)
+	})
+
+	t.Run("Payload with other withdrawals", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		tampered := *payload
+		tampered.Withdrawals = &Withdrawals{}
+		backend.relays[0].GetPayloadResponse = &GetPayloadResponse{Version: versionCapella, Data: &tampered}
+		rr := backend.request(t, http.MethodPost, pathGetPayload, blindedBlock)
+		require.Equal(t, http.StatusBadGateway, rr.Code, rr.Body.String())
+	})
+
+	t.Run("Payload without withdrawals", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		backend.relays[0].GetPayloadResponse = &GetPayloadResponse{Version: versionCapella, Data: &ExecutionPayload{ExecutionPayload: payload.ExecutionPayload}}
+		rr := backend.request(t, http.MethodPost, pathGetPayload, blindedBlock)
+		require.Equal(t, http.StatusBadGateway, rr.Code, rr.Body.String())
+	})
+
+	t.Run("Payload with a null withdrawal", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		tampered := *payload
+		tampered.Withdrawals = &Withdrawals{nil} // "withdrawals":[null]
+		backend.relays[0].GetPayloadResponse = &GetPayloadResponse{Version: versionCapella, Data: &tampered}
+		rr := backend.request(t, http.MethodPost, pathGetPayload, blindedBlock)
+		require.Equal(t, http.StatusBadGateway, rr.Code, rr.Body.String())
+	})
+}
+
+func TestDecodeBlindedBlockCapella(t *testing.T) {
+	header := &ExecutionPayloadHeader{WithdrawalsRoot: &types.Root{0x01}}
+	blindedBlock := makeTestBlindedBlock(header)
+	blindedBlock.Message.Body.BLSToExecutionChanges = json.RawMessage(`[{"message":{"validator_index":"1"}}]`)
+	body, err := json.Marshal(blindedBlock)
+	require.NoError(t, err)
+
+	// The BLS to execution changes are passed on to the relays as is
+	decoded, err := decodeBlindedBlock(httptest.NewRequest(http.MethodPost, pathGetPayload, bytes.NewReader(body)))
+	require.NoError(t, err)
+	require.Equal(t, header.WithdrawalsRoot, decoded.Message.Body.ExecutionPayloadHeader.WithdrawalsRoot)
+	forwarded, err := json.Marshal(decoded)
+	require.NoError(t, err)
+	require.JSONEq(t, string(body), string(forwarded))
+}
+
+func TestMarshalExecutionPayloadSSZCapella(t *testing.T) {
+	payload := &ExecutionPayload{
+		ExecutionPayload: types.ExecutionPayload{
+			ExtraData:    hexutil.Bytes{0x01},
+			Transactions: []hexutil.Bytes{{0x02}},
+		},
+		Withdrawals: &Withdrawals{{Index: 3, ValidatorIndex: 4, Address: types.Address{0x05}, Amount: 6}},
+	}
+	data, err := marshalExecutionPayloadSSZ(payload)
+	require.NoError(t, err)
+	fixedSize := executionPayloadFixedSize + 4
+	require.Len(t, data, fixedSize+1+5+withdrawalSize)
+	require.Equal(t, uint32(fixedSize), binary.LittleEndian.Uint32(data[extraDataOffsetPosition:]))
+	require.Equal(t, uint32(fixedSize+1), binary.LittleEndian.Uint32(data[fixedSize-8:]))
+	require.Equal(t, uint32(fixedSize+1+5), binary.LittleEndian.Uint32(data[fixedSize-4:]))
+	require.Equal(t, []byte{0x01, 4, 0, 0, 0, 0x02}, data[fixedSize:fixedSize+6])
+	require.Equal(t, byte(3), data[fixedSize+6])
+	require.Equal(t, byte(0x05), data[fixedSize+6+16])
+
+	// Null withdrawals are refused
+	payload.Withdrawals = &Withdrawals{nil}
+	_, err = marshalExecutionPayloadSSZ(payload)
+	require.ErrorIs(t, err, errNilWithdrawal)
+
+	// Bellatrix payloads have no withdrawals offset
+	payload.Withdrawals = nil
+	data, err = marshalExecutionPayloadSSZ(payload)
+	require.NoError(t, err)
+	require.Len(t, data, executionPayloadFixedSize+1+5)
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// ChainConfig are the constants of the chain served by mev-boost, which differ between Ethereum and Gnosis Chain
+type ChainConfig struct {
+	Name           string
+	SecondsPerSlot uint64
+	SlotsPerEpoch  uint64
+	TokenSymbol    string // native token of the execution layer, in which bids are paid
+	TokenDecimals  int
+}
+
+// Known chains
+var (
+	ChainEthereum = ChainConfig{Name: "ethereum", SecondsPerSlot: SecondsPerSlot, SlotsPerEpoch: SlotsPerEpoch, TokenSymbol: "ETH", TokenDecimals: 18}
+	ChainGnosis   = ChainConfig{Name: "gnosis", SecondsPerSlot: 5, SlotsPerEpoch: 16, TokenSymbol: "xDAI", TokenDecimals: 18}
+)
+
+// ParseChain returns the config of a known chain by name
+func ParseChain(name string) (ChainConfig, error) {
+	for _, chain := range []ChainConfig{ChainEthereum, ChainGnosis} {
+		if strings.EqualFold(name, chain.Name) {
+			return chain, nil
+		}
+	}
+	return ChainConfig{}, fmt.Errorf("%w: %s", ErrInvalidChain, name)
+}
+
+func (c ChainConfig) slotDuration() time.Duration {
+	return time.Duration(c.SecondsPerSlot) * time.Second
+}
+
+func (c ChainConfig) epoch(slot uint64) uint64 {
+	return slot / c.SlotsPerEpoch
+}
+
+// slotAt returns the slot at time t, for a chain started at genesisTime
+func (c ChainConfig) slotAt(genesisTime uint64, t time.Time) uint64 {
+	if t.Unix() < int64(genesisTime) {
+		return 0
+	}
+	return (uint64(t.Unix()) - genesisTime) / c.SecondsPerSlot
+}
+
+// slotStartTime returns the start time of a slot, for a chain started at genesisTime
+func (c ChainConfig) slotStartTime(genesisTime, slot uint64) time.Time {
+	return time.Unix(int64(genesisTime+slot*c.SecondsPerSlot), 0)
+}
+
+// FormatValue formats a value in wei in units of the native token, eg. "0.05 ETH"
+func (c ChainConfig) FormatValue(wei *big.Int) string {
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(c.TokenDecimals)), nil)
+	whole, frac := new(big.Int).QuoRem(wei, unit, new(big.Int))
+	s := whole.String()
+	if frac.Sign() != 0 {
+		s += "." + strings.TrimRight(fmt.Sprintf("%0*s", c.TokenDecimals, frac.String()), "0")
+	}
+	return s + " " + c.TokenSymbol
+}
+
+// forkAtSlot returns the name of the fork active at the given slot of the chain served
+func (m *BoostService) forkAtSlot(slot uint64) string {
+	return m.forkSchedule.ForkAtEpoch(m.chain.epoch(slot))
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChain(t *testing.T) {
+	chain, err := ParseChain("Gnosis")
+	require.NoError(t, err)
+	require.Equal(t, ChainGnosis, chain)
+	_, err = ParseChain("solana")
+	require.ErrorIs(t, err, ErrInvalidChain)
+}
+
+func TestGnosisSlotTiming(t *testing.T) {
+	genesisTime := uint64(1638993340)
+	require.Equal(t, uint64(0), ChainGnosis.slotAt(genesisTime, time.Unix(1638993344, 0)))
+	require.Equal(t, uint64(1), ChainGnosis.slotAt(genesisTime, time.Unix(1638993345, 0)))
+	require.Equal(t, time.Unix(1638993350, 0), ChainGnosis.slotStartTime(genesisTime, 2))
+	require.Equal(t, uint64(2), ChainGnosis.epoch(32))
+
+	// Forks are looked up by the epochs of the chain served
+	backend := newTestBackend(t, 1, time.Second)
+	backend.boost.chain = ChainGnosis
+	backend.boost.forkSchedule = ForkSchedule{{Name: "bellatrix", Epoch: 2}}
+	require.Equal(t, "", backend.boost.forkAtSlot(31))
+	require.Equal(t, "bellatrix", backend.boost.forkAtSlot(32))
+	backend.boost.chain = ChainEthereum
+	require.Equal(t, "", backend.boost.forkAtSlot(63))
+	require.Equal(t, "bellatrix", backend.boost.forkAtSlot(64))
+}
+
+func TestFormatValue(t *testing.T) {
+	value, _ := new(big.Int).SetString("1500000000000000000", 10)
+	require.Equal(t, "1.5 ETH", ChainEthereum.FormatValue(value))
+	require.Equal(t, "1.5 xDAI", ChainGnosis.FormatValue(value))
+	require.Equal(t, "0.000000000000012345 ETH", ChainEthereum.FormatValue(big.NewInt(12345)))
+	require.Equal(t, "2 ETH", ChainEthereum.FormatValue(new(big.Int).Mul(big.NewInt(2), big.NewInt(1e18))))
+	require.Equal(t, "0 ETH", ChainEthereum.FormatValue(new(big.Int)))
+}
@@ -0,0 +1,32 @@
+package server
+
+import "time"
+
+// Clock is the source of time of a BoostService: the current time for slot computation, and the waits of timeouts
+// and background jobs. Tests replace it to simulate the progression of slots. Deadlines of contexts and HTTP clients
+// still use the system clock.
+type Clock interface {
+	Now() time.Time
+
+	// After waits for d to elapse, and then sends the current time on the returned channel
+	After(d time.Duration) <-chan time.Time
+
+	// AfterFunc calls f after d, unless the returned stop function is called before. stop returns false if f
+	// was already called.
+	AfterFunc(d time.Duration, f func()) (stop func() bool)
+}
+
+// systemClock is the Clock of the system
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) func() bool {
+	return time.AfterFunc(d, f).Stop
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a Clock for tests, which only advances when told to. The functions and waits due are run by
+// advance, in order of their due time.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	at time.Time
+	f  func()
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	c.AfterFunc(d, func() { ch <- c.Now() })
+	return ch
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) func() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	timer := &fakeTimer{at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, timer)
+	return func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for i, t := range c.timers {
+			if t == timer {
+				c.timers = append(c.timers[:i], c.timers[i+1:]...)
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// advance moves the clock forward by d, and runs the timers due
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	due := []*fakeTimer{}
+	pending := []*fakeTimer{}
+	for _, timer := range c.timers {
+		if timer.at.After(c.now) {
+			pending = append(pending, timer)
+		} else {
+			due = append(due, timer)
+		}
+	}
+	c.timers = pending
+	c.mu.Unlock()
+
+	sort.SliceStable(due, func(i, j int) bool { return due[i].at.Before(due[j].at) })
+	for _, timer := range due {
+		timer.f()
+	}
+}
+
+// numTimers returns the number of pending timers, to wait for goroutines to wait for the clock
+func (c *fakeClock) numTimers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+func TestFakeClock(t *testing.T) {
+	clock := newFakeClock(time.Unix(1000, 0))
+	ch := clock.After(2 * time.Second)
+	var calls int32
+	stop := clock.AfterFunc(time.Second, func() { atomic.AddInt32(&calls, 1) })
+	stop2 := clock.AfterFunc(time.Second, func() { atomic.AddInt32(&calls, 10) })
+	require.True(t, stop2())
+
+	clock.advance(time.Second)
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	require.False(t, stop(), "already called")
+	require.Empty(t, ch)
+
+	clock.advance(time.Second)
+	require.Equal(t, time.Unix(1002, 0), <-ch)
+	require.Equal(t, 0, clock.numTimers())
+}
+
+func TestSchedulerFakeClock(t *testing.T) {
+	clock := newFakeClock(time.Unix(1000, 0))
+	metrics := newServiceMetrics(0)
+	s := newScheduler(testLog, metrics, clock)
+	var runs int32
+	s.every("test", time.Minute, 0, func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	})
+
+	for i := int32(1); i <= 3; i++ {
+		require.Eventually(t, func() bool { return clock.numTimers() == 1 }, time.Second, time.Millisecond)
+		require.Equal(t, i, atomic.LoadInt32(&runs))
+		require.Equal(t, float64(clock.Now().Unix()), testutil.ToFloat64(metrics.jobLastRun.WithLabelValues("test")))
+		clock.advance(time.Minute)
+	}
+	require.NoError(t, s.stop(context.Background()))
+}
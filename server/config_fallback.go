@@ -0,0 +1,26 @@
+package server
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Configurations changed at runtime, as labels of the config metrics
+const (
+	configFeatureFlags     = "feature_flags"
+	configProposerConfig   = "proposer_config"
+	configRelayDrain       = "relay_drain"
+	configRelayMaintenance = "relay_maintenance"
+)
+
+// newConfigReloadFailingMetric returns a gauge which is 1 while the last reload of a configuration failed, and the
+// previous configuration is kept
+func newConfigReloadFailingMetric(config string, reloadError func() error) prometheus.GaugeFunc {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name:        "mev_boost_config_reload_failing",
+		Help:        "Whether the last reload of the configuration failed, keeping the last known good configuration",
+		ConstLabels: prometheus.Labels{"config": config},
+	}, func() float64 {
+		if reloadError() != nil {
+			return 1
+		}
+		return 0
+	})
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrainLastRelay(t *testing.T) {
+	backend := newTestBackend(t, 2, time.Second)
+	backend.boost.adminAPI = true
+	drainPath := func(i int) string {
+		return pathAdminRelayDrain + "?relay=" + backend.relays[i].RelayEntry.URL.Host
+	}
+
+	rr := backend.request(t, http.MethodPost, drainPath(0), nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	rr = backend.request(t, http.MethodPost, drainPath(1), nil)
+	require.Equal(t, http.StatusConflict, rr.Code, rr.Body.String())
+	require.False(t, backend.boost.relayDrains.isDraining(backend.relays[1].RelayEntry.String()))
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.configFallbacks.WithLabelValues(configRelayDrain)))
+
+	// Draining a drained relay again is fine
+	rr = backend.request(t, http.MethodPost, drainPath(0), nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+}
+
+func TestAllRelaysInMaintenance(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	path := fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey)
+
+	backend := newTestBackend(t, 2, time.Second)
+	window := MaintenanceWindow{Start: time.Now().Add(-time.Hour), End: time.Now().Add(time.Hour)}
+	backend.boost.relays[0].MaintenanceWindows = []MaintenanceWindow{window}
+
+	// A relay in maintenance is skipped while another relay is in service
+	rr := backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, 0, backend.relays[0].GetRequestCount(path))
+
+	// Relays in maintenance are called if all of them are
+	backend.boost.relays[1].MaintenanceWindows = []MaintenanceWindow{window}
+	rr = backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, 1, backend.relays[0].GetRequestCount(path))
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.configFallbacks.WithLabelValues(configRelayMaintenance)))
+}
+
+func TestConfigReloadFailingMetric(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "features")
+	require.NoError(t, os.WriteFile(file, []byte("bid_prefetch\n"), 0o600))
+	features, err := NewFeatureFlags(nil, file)
+	require.NoError(t, err)
+	metric := newConfigReloadFailingMetric(configFeatureFlags, features.ReloadError)
+	require.Equal(t, 0.0, testutil.ToFloat64(metric))
+
+	require.NoError(t, os.WriteFile(file, []byte("unknown\n"), 0o600))
+	require.Error(t, features.Reload())
+	require.Equal(t, 1.0, testutil.ToFloat64(metric))
+
+	require.NoError(t, os.WriteFile(file, []byte("-bid_prefetch\n"), 0o600))
+	require.NoError(t, features.Reload())
+	require.Equal(t, 0.0, testutil.ToFloat64(metric))
+}
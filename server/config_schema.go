@@ -0,0 +1,290 @@
+package server
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// jsonSchemaDraft is the JSON Schema version of the generated schemas
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// configRules are the rules of the validate tag of a config field, which both JSONSchema and ValidateConfig follow.
+// The tag is a comma-separated list of: required (not the zero value), min=N (minimum of a number, length of a
+// string or entries of a list or map), enum=a|b, pattern=REGEXP, keypattern=REGEXP (of the keys of a map) and
+// format=uri. Patterns can't contain commas. The doc tag of a field is its description in the schema.
+type configRules struct {
+	required   bool
+	min        *int64
+	enum       []string
+	pattern    *regexp.Regexp
+	keyPattern *regexp.Regexp
+	format     string
+}
+
+func parseConfigRules(tag string) (configRules, error) {
+	rules := configRules{}
+	if tag == "" {
+		return rules, nil
+	}
+	for _, rule := range strings.Split(tag, ",") {
+		name, value, _ := strings.Cut(rule, "=")
+		var err error
+		switch name {
+		case "required":
+			rules.required = true
+		case "min":
+			var n int64
+			n, err = strconv.ParseInt(value, 10, 64)
+			rules.min = &n
+		case "enum":
+			rules.enum = strings.Split(value, "|")
+		case "pattern":
+			rules.pattern, err = regexp.Compile(value)
+		case "keypattern":
+			rules.keyPattern, err = regexp.Compile(value)
+		case "format":
+			rules.format = value
+		default:
+			err = fmt.Errorf("unknown rule %s", name)
+		}
+		if err != nil {
+			return rules, fmt.Errorf("invalid validate tag %q: %w", tag, err)
+		}
+	}
+	return rules, nil
+}
+
+// jsonFieldName returns the JSON name of a struct field, or "" if it isn't encoded
+func jsonFieldName(f reflect.StructField) string {
+	if !f.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	} else if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// JSONSchema returns the JSON Schema of the config type of v, following its doc and validate tags. Unknown fields
+// are not allowed by the schema, to catch misspelled fields when linting config files.
+func JSONSchema(v any, title string) (map[string]any, error) {
+	schema, err := jsonSchemaOf(reflect.TypeOf(v), configRules{})
+	if err != nil {
+		return nil, err
+	}
+	schema["$schema"] = jsonSchemaDraft
+	schema["title"] = title
+	return schema, nil
+}
+
+func jsonSchemaOf(t reflect.Type, rules configRules) (map[string]any, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	schema := map[string]any{}
+	switch t.Kind() {
+	case reflect.Bool:
+		schema["type"] = "boolean"
+	case reflect.String:
+		schema["type"] = "string"
+		if rules.min != nil {
+			schema["minLength"] = *rules.min
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		schema["type"] = "integer"
+		if t.Kind() >= reflect.Uint {
+			schema["minimum"] = 0
+		}
+		if rules.min != nil {
+			schema["minimum"] = *rules.min
+		}
+	case reflect.Float32, reflect.Float64:
+		schema["type"] = "number"
+		if rules.min != nil {
+			schema["minimum"] = *rules.min
+		}
+	case reflect.Slice, reflect.Array:
+		items, err := jsonSchemaOf(t.Elem(), configRules{})
+		if err != nil {
+			return nil, err
+		}
+		schema["type"] = "array"
+		schema["items"] = items
+		if rules.min != nil {
+			schema["minItems"] = *rules.min
+		}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s", t.Key())
+		}
+		values, err := jsonSchemaOf(t.Elem(), configRules{})
+		if err != nil {
+			return nil, err
+		}
+		schema["type"] = "object"
+		schema["additionalProperties"] = values
+		if rules.keyPattern != nil {
+			schema["propertyNames"] = map[string]any{"pattern": rules.keyPattern.String()}
+		}
+		if rules.min != nil {
+			schema["minProperties"] = *rules.min
+		}
+	case reflect.Struct:
+		properties := map[string]any{}
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := jsonFieldName(f)
+			if name == "" {
+				continue
+			}
+			fieldRules, err := parseConfigRules(f.Tag.Get("validate"))
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", t.Name(), f.Name, err)
+			}
+			property, err := jsonSchemaOf(f.Type, fieldRules)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", t.Name(), f.Name, err)
+			}
+			if doc := f.Tag.Get("doc"); doc != "" {
+				property["description"] = doc
+			}
+			properties[name] = property
+			if fieldRules.required {
+				required = append(required, name)
+			}
+		}
+		schema["type"] = "object"
+		schema["properties"] = properties
+		schema["additionalProperties"] = false
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+	default:
+		return nil, fmt.Errorf("unsupported type %s", t)
+	}
+
+	if len(rules.enum) > 0 {
+		schema["enum"] = rules.enum
+	}
+	if rules.pattern != nil {
+		schema["pattern"] = rules.pattern.String()
+	}
+	if rules.format != "" {
+		schema["format"] = rules.format
+	}
+	return schema, nil
+}
+
+// ValidateConfig verifies that a config follows the validate tags of its fields, and returns an ErrInvalidConfig
+// error naming the first field which doesn't
+func ValidateConfig(v any) error {
+	return validateConfigValue(reflect.ValueOf(v), "", configRules{})
+}
+
+func validateConfigValue(v reflect.Value, path string, rules configRules) error {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			if rules.required {
+				return fmt.Errorf("%w: %s is required", ErrInvalidConfig, path)
+			}
+			return nil
+		}
+		v = v.Elem()
+	}
+	if rules.required && v.IsZero() {
+		return fmt.Errorf("%w: %s is required", ErrInvalidConfig, path)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		s := v.String()
+		if s == "" {
+			return nil // only checked if required
+		}
+		if rules.min != nil && int64(len(s)) < *rules.min {
+			return fmt.Errorf("%w: %s must have at least %d characters", ErrInvalidConfig, path, *rules.min)
+		}
+		if len(rules.enum) > 0 && !containsString(rules.enum, s) {
+			return fmt.Errorf("%w: %s must be one of %s", ErrInvalidConfig, path, strings.Join(rules.enum, ", "))
+		}
+		if rules.pattern != nil && !rules.pattern.MatchString(s) {
+			return fmt.Errorf("%w: %s must match %s", ErrInvalidConfig, path, rules.pattern)
+		}
+		if rules.format == "uri" {
+			if u, err := url.Parse(s); err != nil || u.Scheme == "" {
+				return fmt.Errorf("%w: %s must be a URI", ErrInvalidConfig, path)
+			}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if rules.min != nil && v.Int() < *rules.min {
+			return fmt.Errorf("%w: %s must be at least %d", ErrInvalidConfig, path, *rules.min)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rules.min != nil && *rules.min > 0 && v.Uint() < uint64(*rules.min) {
+			return fmt.Errorf("%w: %s must be at least %d", ErrInvalidConfig, path, *rules.min)
+		}
+	case reflect.Slice, reflect.Array:
+		if rules.min != nil && int64(v.Len()) < *rules.min {
+			return fmt.Errorf("%w: %s must have at least %d items", ErrInvalidConfig, path, *rules.min)
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := validateConfigValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), configRules{}); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if rules.min != nil && int64(v.Len()) < *rules.min {
+			return fmt.Errorf("%w: %s must have at least %d entries", ErrInvalidConfig, path, *rules.min)
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			if rules.keyPattern != nil && !rules.keyPattern.MatchString(key) {
+				return fmt.Errorf("%w: %s key %s must match %s", ErrInvalidConfig, path, key, rules.keyPattern)
+			}
+			if err := validateConfigValue(iter.Value(), path+"."+key, configRules{}); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := jsonFieldName(f)
+			if name == "" {
+				continue
+			}
+			fieldRules, err := parseConfigRules(f.Tag.Get("validate"))
+			if err != nil {
+				return err
+			}
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			if err := validateConfigValue(v.Field(i), fieldPath, fieldRules); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
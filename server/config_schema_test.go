@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testConfigItem struct {
+	Mode string `json:"mode" validate:"enum=a|b" doc:"the mode"`
+}
+
+type testConfig struct {
+	Name    string                    `json:"name" validate:"required,min=2"`
+	Version string                    `json:"version,omitempty" validate:"pattern=^0x[0-9a-f]{2}$"`
+	URL     string                    `json:"url" validate:"format=uri"`
+	Count   int                       `json:"count" validate:"min=1"`
+	Hosts   []string                  `json:"hosts" validate:"required,min=1"`
+	Items   map[string]testConfigItem `json:"items" validate:"keypattern=^0x"`
+	Enabled *bool                     `json:"enabled"`
+	Skipped string                    `json:"-"`
+	private string
+}
+
+func TestJSONSchema(t *testing.T) {
+	schema, err := JSONSchema(testConfig{}, "test config")
+	require.NoError(t, err)
+	data, err := json.Marshal(schema)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "test config",
+		"type": "object",
+		"additionalProperties": false,
+		"required": ["name", "hosts"],
+		"properties": {
+			"name": {"type": "string", "minLength": 2},
+			"version": {"type": "string", "pattern": "^0x[0-9a-f]{2}$"},
+			"url": {"type": "string", "format": "uri"},
+			"count": {"type": "integer", "minimum": 1},
+			"hosts": {"type": "array", "items": {"type": "string"}, "minItems": 1},
+			"items": {
+				"type": "object",
+				"propertyNames": {"pattern": "^0x"},
+				"additionalProperties": {
+					"type": "object",
+					"additionalProperties": false,
+					"properties": {"mode": {"type": "string", "enum": ["a", "b"], "description": "the mode"}}
+				}
+			},
+			"enabled": {"type": "boolean"}
+		}
+	}`, string(data))
+
+	_, err = JSONSchema(struct {
+		Values map[int]string `json:"values"`
+	}{}, "")
+	require.Error(t, err)
+	_, err = JSONSchema(struct {
+		Value string `json:"value" validate:"unique"`
+	}{}, "")
+	require.Error(t, err)
+}
+
+func TestValidateConfig(t *testing.T) {
+	valid := testConfig{private: "ignored", Name: "ab", Version: "0x0a", URL: "http://localhost:5052", Count: 1, Hosts: []string{"relay.com"}, Items: map[string]testConfigItem{"0x01": {Mode: "a"}}}
+	require.NoError(t, ValidateConfig(valid))
+	require.NoError(t, ValidateConfig(&valid))
+
+	for name, modify := range map[string]func(c *testConfig){
+		"required":   func(c *testConfig) { c.Name = "" },
+		"min length": func(c *testConfig) { c.Name = "a" },
+		"pattern":    func(c *testConfig) { c.Version = "0x0A" },
+		"uri":        func(c *testConfig) { c.URL = "localhost" },
+		"minimum":    func(c *testConfig) { c.Count = 0 },
+		"min items":  func(c *testConfig) { c.Hosts = []string{} },
+		"key":        func(c *testConfig) { c.Items = map[string]testConfigItem{"01": {}} },
+		"enum":       func(c *testConfig) { c.Items = map[string]testConfigItem{"0x01": {Mode: "c"}} },
+	} {
+		c := valid
+		modify(&c)
+		err := ValidateConfig(c)
+		require.ErrorIs(t, err, ErrInvalidConfig, name)
+	}
+
+	err := ValidateConfig(testConfig{Name: "ab", Count: 1, Hosts: []string{"relay.com"}, Items: map[string]testConfigItem{"0x01": {Mode: "c"}}})
+	require.EqualError(t, err, "invalid config: items.0x01.mode must be one of a, b")
+}
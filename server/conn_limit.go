@@ -0,0 +1,118 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// connLimitListener limits the number of concurrent connections accepted from a listener. Connections in excess
+// wait to be accepted until another connection is closed, which the read and idle timeouts of the server ensure
+// for slow or stalled clients.
+type connLimitListener struct {
+	net.Listener
+	sem     chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	metrics *serviceMetrics
+	pool    *poolUsage
+}
+
+func newConnLimitListener(l net.Listener, max int, metrics *serviceMetrics) *connLimitListener {
+	return &connLimitListener{
+		Listener: l,
+		sem:      make(chan struct{}, max),
+		done:     make(chan struct{}),
+		metrics:  metrics,
+		pool:     metrics.pool(poolServerConnections, max),
+	}
+}
+
+func (l *connLimitListener) Accept() (net.Conn, error) {
+	select {
+	case l.sem <- struct{}{}:
+	default:
+		l.metrics.serverConnLimitReached.Inc()
+		start := time.Now()
+		select {
+		case l.sem <- struct{}{}:
+			l.pool.waited(time.Since(start))
+		case <-l.done:
+			return nil, net.ErrClosed
+		}
+	}
+
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		<-l.sem
+		return nil, err
+	}
+	release := l.pool.acquire()
+	return &limitedConn{Conn: conn, release: func() {
+		release()
+		<-l.sem
+	}}, nil
+}
+
+func (l *connLimitListener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return l.Listener.Close()
+}
+
+// limitedConn frees its slot of the connection limit when closed
+type limitedConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *limitedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
+
+// connStateTracker exports the number of connections of the HTTP server per state, and counts the connections
+// closed without a request, which are mostly clients timing out while sending the request headers
+type connStateTracker struct {
+	mu      sync.Mutex
+	states  map[net.Conn]http.ConnState
+	served  map[net.Conn]bool
+	metrics *serviceMetrics
+}
+
+func newConnStateTracker(metrics *serviceMetrics) *connStateTracker {
+	return &connStateTracker{
+		states:  make(map[net.Conn]http.ConnState),
+		served:  make(map[net.Conn]bool),
+		metrics: metrics,
+	}
+}
+
+// track is the ConnState hook of the HTTP server
+func (t *connStateTracker) track(conn net.Conn, state http.ConnState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if previous, ok := t.states[conn]; ok {
+		t.metrics.serverConns.WithLabelValues(previous.String()).Dec()
+	}
+	switch state {
+	case http.StateNew, http.StateIdle:
+		t.states[conn] = state
+		t.metrics.serverConns.WithLabelValues(state.String()).Inc()
+	case http.StateActive:
+		t.states[conn] = state
+		t.served[conn] = true
+		t.metrics.serverConns.WithLabelValues(state.String()).Inc()
+	case http.StateHijacked, http.StateClosed:
+		result := "no_request"
+		if t.served[conn] {
+			result = "served"
+		}
+		t.metrics.serverConnsClosed.WithLabelValues(result).Inc()
+		delete(t.states, conn)
+		delete(t.served, conn)
+	}
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnLimitListener(t *testing.T) {
+	metrics := newServiceMetrics(0)
+	l, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	listener := newConnLimitListener(l, 1, metrics)
+	defer listener.Close()
+
+	accepted := make(chan net.Conn)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				close(accepted)
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	client1, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer client1.Close()
+	conn1 := <-accepted
+
+	// The second connection waits until the first one is closed
+	client2, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer client2.Close()
+	select {
+	case <-accepted:
+		t.Fatal("connection accepted beyond the limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.serverConnLimitReached))
+
+	require.NoError(t, conn1.Close())
+	conn1.Close() // closing twice frees a single slot
+	select {
+	case conn2 := <-accepted:
+		conn2.Close()
+	case <-time.After(time.Second):
+		t.Fatal("connection not accepted after another one was closed")
+	}
+
+	// Closing the listener releases a waiting Accept
+	client3, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer client3.Close()
+	conn3 := <-accepted
+	defer conn3.Close()
+	require.NoError(t, listener.Close())
+	select {
+	case _, ok := <-accepted:
+		require.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("Accept not released by Close")
+	}
+}
+
+func TestConnStateTracker(t *testing.T) {
+	metrics := newServiceMetrics(0)
+	tracker := newConnStateTracker(metrics)
+	served, slow := &net.TCPConn{}, &net.TCPConn{}
+
+	tracker.track(served, http.StateNew)
+	tracker.track(slow, http.StateNew)
+	require.Equal(t, 2.0, testutil.ToFloat64(metrics.serverConns.WithLabelValues("new")))
+
+	tracker.track(served, http.StateActive)
+	tracker.track(served, http.StateIdle)
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.serverConns.WithLabelValues("new")))
+	require.Equal(t, 0.0, testutil.ToFloat64(metrics.serverConns.WithLabelValues("active")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.serverConns.WithLabelValues("idle")))
+
+	tracker.track(served, http.StateClosed)
+	tracker.track(slow, http.StateClosed)
+	require.Equal(t, 0.0, testutil.ToFloat64(metrics.serverConns.WithLabelValues("new")))
+	require.Equal(t, 0.0, testutil.ToFloat64(metrics.serverConns.WithLabelValues("idle")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.serverConnsClosed.WithLabelValues("served")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.serverConnsClosed.WithLabelValues("no_request")))
+	require.Len(t, tracker.states, 0)
+}
@@ -0,0 +1,241 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxExportRows is the number of rows kept per table while exports fail, the oldest rows are dropped beyond it
+const maxExportRows = 100_000
+
+// exportTimeFormat is the time format of the names of export files, which sorts chronologically
+const exportTimeFormat = "20060102T150405Z"
+
+var (
+	exportBidsHeader        = []string{"time", "slot", "pubkey", "relay", "block_hash", "value", "selected", "rejection_reason"}
+	exportOutcomesHeader    = []string{"time", "slot", "pubkey", "relays", "block_hash", "value", "num_bids", "payload_delivered", "latency_ms", "cohort", "decision_hash", "annotations"}
+	exportAnnotationsHeader = []string{"time", "action", "id", "relay", "start", "end", "text"}
+	exportTrafficHeader     = []string{"time", "relay", "endpoint", "bytes_sent", "bytes_received"}
+)
+
+// exportTarget stores the export files
+type exportTarget interface {
+	write(ctx context.Context, name string, data []byte) error
+}
+
+// dirExportTarget writes the export files to a local directory
+type dirExportTarget string
+
+func (d dirExportTarget) write(ctx context.Context, name string, data []byte) error {
+	if err := os.MkdirAll(string(d), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(string(d), name), data, 0o600)
+}
+
+// newExportTarget returns the target of the exports, an S3 URL (s3://bucket/prefix) or a local directory
+func newExportTarget(target string, s3Opts S3ExportOpts) (exportTarget, error) {
+	if strings.HasPrefix(target, "s3://") {
+		return newS3ExportTarget(strings.TrimPrefix(target, "s3://"), s3Opts)
+	}
+	return dirExportTarget(target), nil
+}
+
+// dataExporter collects the bids and slot outcomes, and periodically writes the rows collected since the last export
+// as CSV files, for offline analysis. The files are encrypted if an at-rest key is given.
+type dataExporter struct {
+	target exportTarget
+	key    []byte
+
+	mu          sync.Mutex
+	bids        [][]string
+	outcomes    [][]string
+	annotations [][]string
+	traffic     [][]string
+	dropped     int // rows dropped while exports failed
+}
+
+func newDataExporter(target exportTarget, key []byte) *dataExporter {
+	return &dataExporter{target: target, key: key}
+}
+
+// addBids records the bids of a getHeader request: the selected bid once per relay which delivered it, and the
+// rejected bids with their reason
+func (e *dataExporter) addBids(t time.Time, slot uint64, pubkey string, bid bidResp) {
+	if e == nil {
+		return
+	}
+	rows := make([][]string, 0, len(bid.relays)+len(bid.rejections))
+	for _, relay := range bid.relays {
+		value := ""
+		if bid.valueWei != nil {
+			value = bid.valueWei.String()
+		}
+		rows = append(rows, []string{formatExportTime(t), strconv.FormatUint(slot, 10), pubkey, relay, bid.blockHash, value, "true", ""})
+	}
+	for _, rejection := range bid.rejections {
+		rows = append(rows, []string{formatExportTime(t), strconv.FormatUint(slot, 10), pubkey, rejection.Relay, rejection.BlockHash, rejection.Value, "false", string(rejection.Reason)})
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.bids = e.appendRows(e.bids, rows...)
+}
+
+// addOutcome records the outcome of a slot
+func (e *dataExporter) addOutcome(t time.Time, o *slotOutcome) {
+	if e == nil {
+		return
+	}
+	row := []string{
+		formatExportTime(t), strconv.FormatUint(o.Slot, 10), o.Pubkey, o.relaysString(), o.BlockHash, o.Value,
+		strconv.Itoa(o.NumBids), strconv.FormatBool(o.PayloadDelivered), strconv.FormatInt(o.Latency.Milliseconds(), 10),
+		o.Cohort, o.Decision.Decision, formatAnnotationIDs(o.Annotations),
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.outcomes = e.appendRows(e.outcomes, row)
+}
+
+// addAnnotation records an annotation being added or removed
+func (e *dataExporter) addAnnotation(t time.Time, action string, a annotation) {
+	if e == nil {
+		return
+	}
+	end := ""
+	if a.End != nil {
+		end = formatExportTime(*a.End)
+	}
+	row := []string{formatExportTime(t), action, strconv.FormatUint(a.ID, 10), a.Relay, formatExportTime(a.Start), end, a.Text}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.annotations = e.appendRows(e.annotations, row)
+}
+
+// addTraffic records the bytes exchanged with the relays by endpoint, since the previous export
+func (e *dataExporter) addTraffic(t time.Time, traffic map[relayTrafficKey]relayTrafficBytes) {
+	if e == nil {
+		return
+	}
+	rows := make([][]string, 0, len(traffic))
+	for _, key := range sortedRelayTrafficKeys(traffic) {
+		b := traffic[key]
+		rows = append(rows, []string{formatExportTime(t), key.relay, key.endpoint, strconv.FormatInt(b.sent, 10), strconv.FormatInt(b.received, 10)})
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.traffic = e.appendRows(e.traffic, rows...)
+}
+
+// formatAnnotationIDs formats annotation IDs as a space-separated list
+func formatAnnotationIDs(ids []uint64) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = strconv.FormatUint(id, 10)
+	}
+	return strings.Join(s, " ")
+}
+
+// appendRows appends rows to a table, dropping its oldest rows beyond maxExportRows. The lock must be held.
+func (e *dataExporter) appendRows(table [][]string, rows ...[]string) [][]string {
+	table = append(table, rows...)
+	if excess := len(table) - maxExportRows; excess > 0 {
+		e.dropped += excess
+		table = table[excess:]
+	}
+	return table
+}
+
+// export writes the rows collected since the last export to the bids-TIME.csv, outcomes-TIME.csv,
+// annotations-TIME.csv and traffic-TIME.csv files, and returns the number of rows written. Rows failing to be written are kept for the next export.
+func (e *dataExporter) export(ctx context.Context, now time.Time) (int, error) {
+	e.mu.Lock()
+	bids, outcomes, annotations, traffic := e.bids, e.outcomes, e.annotations, e.traffic
+	e.bids, e.outcomes, e.annotations, e.traffic = nil, nil, nil, nil
+	e.mu.Unlock()
+
+	tables := []struct {
+		name   string
+		header []string
+		rows   *[][]string
+	}{
+		{"bids", exportBidsHeader, &bids},
+		{"outcomes", exportOutcomesHeader, &outcomes},
+		{"annotations", exportAnnotationsHeader, &annotations},
+		{"traffic", exportTrafficHeader, &traffic},
+	}
+	var exportErr error
+	numRows := 0
+	for _, table := range tables {
+		if len(*table.rows) == 0 {
+			continue
+		}
+		data, err := encodeCSV(table.header, *table.rows)
+		if err == nil {
+			data, err = sealAtRest(e.key, data)
+		}
+		if err == nil {
+			err = e.target.write(ctx, fmt.Sprintf("%s-%s.csv", table.name, now.UTC().Format(exportTimeFormat)), data)
+		}
+		if err != nil {
+			exportErr = fmt.Errorf("could not export %s: %w", table.name, err)
+			continue
+		}
+		numRows += len(*table.rows)
+		*table.rows = nil
+	}
+
+	// Keep the rows which were not written, before the rows collected meanwhile
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.bids = e.appendRows(bids, e.bids...)
+	e.outcomes = e.appendRows(outcomes, e.outcomes...)
+	e.annotations = e.appendRows(annotations, e.annotations...)
+	e.traffic = e.appendRows(traffic, e.traffic...)
+	return numRows, exportErr
+}
+
+func encodeCSV(header []string, rows [][]string) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func formatExportTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// exportData is the periodic job writing the collected bids and slot outcomes
+func (m *BoostService) exportData(ctx context.Context) error {
+	now := m.clock.Now()
+	m.dataExporter.addTraffic(now, m.relayTraffic.take())
+	numRows, err := m.dataExporter.export(ctx, now)
+	m.dataExporter.mu.Lock()
+	dropped := m.dataExporter.dropped
+	m.dataExporter.dropped = 0
+	m.dataExporter.mu.Unlock()
+	if dropped > 0 {
+		m.log.WithField("dropped", dropped).Warn("dropped export rows, exports failed for too long")
+	}
+	if numRows > 0 {
+		m.log.WithField("rows", numRows).Debug("exported bids and slot outcomes")
+	}
+	return err
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+type failingExportTarget struct{ err error }
+
+func (f failingExportTarget) write(ctx context.Context, name string, data []byte) error {
+	return f.err
+}
+
+func readExportCSV(t *testing.T, path string) [][]string {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+	return records
+}
+
+func TestDataExport(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	dir := t.TempDir()
+
+	backend := newTestBackend(t, 1, time.Second)
+	backend.boost.dataExporter = newDataExporter(dirExportTarget(dir), nil)
+	backend.relays[0].EchoRequestHashes = true
+	relay := backend.relays[0].RelayEntry.String()
+
+	rr := backend.request(t, http.MethodGet, fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey), nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	payload := types.SignedBlindedBeaconBlock{
+		Message: &types.BlindedBeaconBlock{
+			Slot: 1,
+			Body: &types.BlindedBeaconBlockBody{
+				Eth1Data:      &types.Eth1Data{},
+				SyncAggregate: &types.SyncAggregate{},
+				ExecutionPayloadHeader: &types.ExecutionPayloadHeader{
+					BlockHash: _HexToHash(hash),
+				},
+			},
+		},
+	}
+	rr = backend.request(t, http.MethodPost, pathGetPayload, payload)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	now := time.Date(2022, 9, 15, 6, 42, 0, 0, time.UTC)
+	numRows, err := backend.boost.dataExporter.export(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 2, numRows)
+
+	bids := readExportCSV(t, filepath.Join(dir, "bids-20220915T064200Z.csv"))
+	require.Equal(t, exportBidsHeader, bids[0])
+	require.Len(t, bids, 2)
+	require.Equal(t, []string{"1", pubkey, relay, hash, "12345", "true", ""}, bids[1][1:])
+
+	outcomes := readExportCSV(t, filepath.Join(dir, "outcomes-20220915T064200Z.csv"))
+	require.Equal(t, exportOutcomesHeader, outcomes[0])
+	require.Len(t, outcomes, 2)
+	require.Equal(t, []string{"1", pubkey, relay, hash, "12345", "1", "true"}, outcomes[1][1:8])
+
+	// Nothing new to export
+	numRows, err = backend.boost.dataExporter.export(context.Background(), now.Add(time.Hour))
+	require.NoError(t, err)
+	require.Zero(t, numRows)
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+}
+
+func TestDataExportFailure(t *testing.T) {
+	exporter := newDataExporter(failingExportTarget{errors.New("unavailable")}, nil)
+	exporter.addOutcome(time.Now(), &slotOutcome{Slot: 1, Annotations: []uint64{1, 2}})
+	exporter.addBids(time.Now(), 1, "0xab", bidResp{rejections: []bidRejection{{Relay: "relay", Reason: BidRejectionZeroValue}}})
+	exporter.addAnnotation(time.Now(), "added", annotation{ID: 1, Text: "degraded", Start: time.Now()})
+
+	// Rows are kept for the next export
+	_, err := exporter.export(context.Background(), time.Now())
+	require.Error(t, err)
+	require.Len(t, exporter.bids, 1)
+	require.Len(t, exporter.outcomes, 1)
+	require.Len(t, exporter.annotations, 1)
+	require.Equal(t, string(BidRejectionZeroValue), exporter.bids[0][7])
+	require.Equal(t, "1 2", exporter.outcomes[0][11])
+	require.Equal(t, []string{"added", "1"}, exporter.annotations[0][1:3])
+
+	dir := t.TempDir()
+	exporter.target = dirExportTarget(dir)
+	numRows, err := exporter.export(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 3, numRows)
+	require.Empty(t, exporter.bids)
+	require.Empty(t, exporter.annotations)
+}
+
+func TestDataExportRowLimit(t *testing.T) {
+	exporter := newDataExporter(failingExportTarget{}, nil)
+	for i := 0; i < maxExportRows+10; i++ {
+		exporter.addOutcome(time.Now(), &slotOutcome{Slot: uint64(i)})
+	}
+	require.Len(t, exporter.outcomes, maxExportRows)
+	require.Equal(t, 10, exporter.dropped)
+	require.Equal(t, "10", exporter.outcomes[0][1])
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Fields of the debug endpoints which can be redacted on the public debug listener
+const (
+	DebugFieldPubkey     = "pubkey"
+	DebugFieldBlockHash  = "block_hash"
+	DebugFieldValue      = "value"
+	DebugFieldRelays     = "relays"
+	DebugFieldRejections = "rejections"
+	DebugFieldProvenance = "provenance"
+)
+
+var debugFields = []string{DebugFieldPubkey, DebugFieldBlockHash, DebugFieldValue, DebugFieldRelays, DebugFieldRejections, DebugFieldProvenance}
+
+// ParseDebugRedactFields parses a comma-separated list of debug fields to redact
+func ParseDebugRedactFields(s string) ([]string, error) {
+	fields := []string{}
+	for _, field := range strings.Split(s, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+		known := false
+		for _, f := range debugFields {
+			known = known || f == field
+		}
+		if !known {
+			return nil, fmt.Errorf("%w: %s (valid: %s)", errInvalidDebugField, field, strings.Join(debugFields, ", "))
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// debugRedactor redacts the debug responses served to third parties. Proposer pubkeys are replaced by a keyed
+// hash, so that the bids of a proposer can still be correlated, but the proposer can't be identified.
+// The key is random, so the hashes only stay stable for the lifetime of the process.
+type debugRedactor struct {
+	fields map[string]bool
+	key    []byte
+}
+
+func newDebugRedactor(fields []string) (*debugRedactor, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	r := &debugRedactor{fields: make(map[string]bool, len(fields)), key: key}
+	for _, field := range fields {
+		r.fields[field] = true
+	}
+	return r, nil
+}
+
+func (r *debugRedactor) hashPubkey(pubkey string) string {
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(strings.ToLower(pubkey)))
+	return "hash:0x" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// redactBids returns a copy of the bids with the redacted fields removed
+func (r *debugRedactor) redactBids(bids []debugBid) []debugBid {
+	ret := make([]debugBid, len(bids))
+	for i, bid := range bids {
+		if r.fields[DebugFieldPubkey] && bid.Pubkey != "" {
+			bid.Pubkey = r.hashPubkey(bid.Pubkey)
+		}
+		if r.fields[DebugFieldBlockHash] {
+			bid.BlockHash = ""
+		}
+		if r.fields[DebugFieldValue] {
+			bid.Value = ""
+		}
+		if r.fields[DebugFieldRelays] {
+			bid.Relays = nil
+		}
+		if r.fields[DebugFieldProvenance] || r.fields[DebugFieldRelays] {
+			bid.Provenance = nil // names the relays
+		}
+		if r.fields[DebugFieldRejections] {
+			bid.Rejections = nil
+		} else if r.fields[DebugFieldBlockHash] || r.fields[DebugFieldValue] {
+			rejections := make([]bidRejection, len(bid.Rejections))
+			for j, rejection := range bid.Rejections {
+				if r.fields[DebugFieldBlockHash] {
+					rejection.BlockHash = ""
+				}
+				if r.fields[DebugFieldValue] {
+					rejection.Value = ""
+				}
+				rejections[j] = rejection
+			}
+			bid.Rejections = rejections
+		}
+		ret[i] = bid
+	}
+	return ret
+}
+
+// getPublicDebugRouter returns the router of the public debug listener, serving the redacted debug endpoints only
+func (m *BoostService) getPublicDebugRouter() http.Handler {
+	r := mux.NewRouter()
+	r.HandleFunc(pathDebugBids, m.handlePublicDebugBids).Methods(http.MethodGet)
+	return r
+}
+
+// handlePublicDebugBids returns the debug bids with the configured fields redacted
+func (m *BoostService) handlePublicDebugBids(w http.ResponseWriter, req *http.Request) {
+	resp := m.debugBids()
+	resp.Bids = m.debugRedactor.redactBids(resp.Bids)
+	if m.debugRedactor.fields[DebugFieldRejections] {
+		resp.Rejections = nil
+		resp.RelayErrors = nil
+	}
+	m.respondOK(w, resp)
+}
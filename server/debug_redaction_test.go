@@ -0,0 +1,84 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDebugRedactFields(t *testing.T) {
+	fields, err := ParseDebugRedactFields("")
+	require.NoError(t, err)
+	require.Len(t, fields, 0)
+
+	fields, err = ParseDebugRedactFields("pubkey, Relays")
+	require.NoError(t, err)
+	require.Equal(t, []string{DebugFieldPubkey, DebugFieldRelays}, fields)
+
+	_, err = ParseDebugRedactFields("pubkey,signature")
+	require.ErrorIs(t, err, errInvalidDebugField)
+}
+
+func TestPublicDebugBids(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+
+	backend := newTestBackend(t, 2, time.Second)
+	backend.boost.debugAPI = true
+	var err error
+	backend.boost.debugRedactor, err = newDebugRedactor([]string{DebugFieldPubkey, DebugFieldRelays})
+	require.NoError(t, err)
+
+	rr := backend.request(t, http.MethodGet, fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey), nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	// Internal access sees the full data
+	rr = backend.request(t, http.MethodGet, pathDebugBids, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	resp := new(debugBidsResponse)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+	require.Len(t, resp.Bids, 1)
+	require.Equal(t, pubkey, resp.Bids[0].Pubkey)
+	require.Len(t, resp.Bids[0].Relays, 2)
+	require.Len(t, resp.Bids[0].Provenance, 2)
+
+	// The public listener only serves the redacted data
+	req, err := http.NewRequest(http.MethodGet, pathDebugBids, nil)
+	require.NoError(t, err)
+	rr = httptest.NewRecorder()
+	backend.boost.getPublicDebugRouter().ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.NotContains(t, rr.Body.String(), strings.TrimPrefix(pubkey, "0x"))
+
+	publicResp := new(debugBidsResponse)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), publicResp))
+	require.Len(t, publicResp.Bids, 1)
+	require.Equal(t, backend.boost.debugRedactor.hashPubkey(pubkey), publicResp.Bids[0].Pubkey)
+	require.Len(t, publicResp.Bids[0].Relays, 0)
+	require.Len(t, publicResp.Bids[0].Provenance, 0)
+	require.Equal(t, resp.Bids[0].BlockHash, publicResp.Bids[0].BlockHash)
+	require.Equal(t, resp.Bids[0].Value, publicResp.Bids[0].Value)
+
+	req, err = http.NewRequest(http.MethodGet, pathStatus, nil)
+	require.NoError(t, err)
+	rr = httptest.NewRecorder()
+	backend.boost.getPublicDebugRouter().ServeHTTP(rr, req)
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestDebugRedactorHashPubkey(t *testing.T) {
+	r1, err := newDebugRedactor(nil)
+	require.NoError(t, err)
+	r2, err := newDebugRedactor(nil)
+	require.NoError(t, err)
+
+	require.Equal(t, r1.hashPubkey("0xAB"), r1.hashPubkey("0xab"))
+	require.NotEqual(t, r1.hashPubkey("0xab"), r1.hashPubkey("0xac"))
+	require.NotEqual(t, r1.hashPubkey("0xab"), r2.hashPubkey("0xab"))
+}
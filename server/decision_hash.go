@@ -0,0 +1,68 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// decisionHashes identify the bid selection of a slot. Redundant mev-boost instances which received the same bids
+// have the same inputs hash, and if they are configured alike, also the same decision hash. Equal inputs with
+// different decisions point to config drift between the instances.
+type decisionHashes struct {
+	Inputs   string `json:"inputs_hash"`   // the getHeader request, and the bids received from the relays
+	Decision string `json:"decision_hash"` // the inputs, the served bid and the reasons the other bids were rejected
+}
+
+// decisionBid is a bid received for a getHeader request, and what became of it
+type decisionBid struct {
+	relay     string
+	blockHash string
+	value     string
+	outcome   string // "selected" or the rejection reason
+}
+
+// newDecisionHashes hashes the bid selection of a getHeader request. Relay errors are no bids and not part of the
+// inputs, since they depend on the instance's network conditions.
+func newDecisionHashes(slot uint64, parentHash, pubkey string, bid bidResp) decisionHashes {
+	bids := []decisionBid{}
+	for _, relay := range bid.relays {
+		bids = append(bids, decisionBid{relay: relay, blockHash: bid.blockHash, value: bid.response.Data.Message.Value.String(), outcome: "selected"})
+	}
+	for _, rejection := range bid.rejections {
+		if rejection.BlockHash == "" {
+			continue
+		}
+		bids = append(bids, decisionBid{relay: rejection.Relay, blockHash: rejection.BlockHash, value: rejection.Value, outcome: string(rejection.Reason)})
+	}
+	sort.Slice(bids, func(i, j int) bool {
+		if bids[i].relay != bids[j].relay {
+			return bids[i].relay < bids[j].relay
+		}
+		if bids[i].blockHash != bids[j].blockHash {
+			return bids[i].blockHash < bids[j].blockHash
+		}
+		return bids[i].value < bids[j].value
+	})
+
+	inputs := sha256.New()
+	fmt.Fprintf(inputs, "%d\n%s\n%s\n", slot, strings.ToLower(parentHash), strings.ToLower(pubkey))
+	for _, b := range bids {
+		fmt.Fprintf(inputs, "%s %s %s\n", b.relay, strings.ToLower(b.blockHash), b.value)
+	}
+	inputsHash := inputs.Sum(nil)
+
+	decision := sha256.New()
+	decision.Write(inputsHash)
+	fmt.Fprintf(decision, "%s\n", strings.ToLower(bid.blockHash))
+	for _, b := range bids {
+		fmt.Fprintf(decision, "%s %s %s\n", b.relay, strings.ToLower(b.blockHash), b.outcome)
+	}
+
+	return decisionHashes{
+		Inputs:   "0x" + hex.EncodeToString(inputsHash),
+		Decision: "0x" + hex.EncodeToString(decision.Sum(nil)),
+	}
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDecisionHashes(t *testing.T) {
+	bid := bidResp{
+		response:  GetHeaderResponse{Data: &SignedBuilderBid{Message: &BuilderBid{Value: types.IntToU256(20)}}},
+		blockHash: "0x02",
+		relays:    []string{"relay-a", "relay-b"},
+		rejections: []bidRejection{
+			{Relay: "relay-c", BlockHash: "0x01", Value: "10", Reason: BidRejectionLowerValue},
+			{Relay: "relay-d", Reason: BidRejectionRelayError},
+		},
+	}
+	hashes := newDecisionHashes(1, "0xAB", "0xCD", bid)
+
+	// The order of the bids and relay errors don't matter
+	reordered := bid
+	reordered.relays = []string{"relay-b", "relay-a"}
+	reordered.rejections = []bidRejection{bid.rejections[0]}
+	require.Equal(t, hashes, newDecisionHashes(1, "0xab", "0xcd", reordered))
+
+	// The same inputs with another decision
+	rejected := bid
+	rejected.rejections = []bidRejection{{Relay: "relay-c", BlockHash: "0x01", Value: "10", Reason: BidRejectionZeroValue}}
+	other := newDecisionHashes(1, "0xab", "0xcd", rejected)
+	require.Equal(t, hashes.Inputs, other.Inputs)
+	require.NotEqual(t, hashes.Decision, other.Decision)
+
+	// Other inputs
+	other = newDecisionHashes(2, "0xab", "0xcd", bid)
+	require.NotEqual(t, hashes.Inputs, other.Inputs)
+	require.NotEqual(t, hashes.Decision, other.Decision)
+}
+
+func TestDecisionHashesAcrossInstances(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	backend := newTestBackend(t, 2, time.Second)
+
+	// A second instance with the same relays
+	opts := BoostServiceOpts{
+		Log:                   testLog,
+		ListenAddr:            "localhost:12346",
+		Relays:                backend.boost.relays,
+		GenesisForkVersionHex: "0x00000000",
+		RelayRequestTimeout:   time.Second,
+	}
+	instance, err := NewBoostService(opts)
+	require.NoError(t, err)
+
+	resultA := backend.boost.requestBids(context.Background(), testLog, 1, hash, pubkey, "")
+	resultB := instance.requestBids(context.Background(), testLog, 1, hash, pubkey, "")
+	require.NotEmpty(t, resultA.bid.decision.Decision)
+	require.Equal(t, resultA.bid.decision, resultB.bid.decision)
+
+	// An instance with a drifted config makes another decision on the same inputs
+	instance.genesisTime = 1606824023
+	resultB = instance.requestBids(context.Background(), testLog, 1, hash, pubkey, "")
+	require.Equal(t, resultA.bid.decision.Inputs, resultB.bid.decision.Inputs)
+	require.NotEqual(t, resultA.bid.decision.Decision, resultB.bid.decision.Decision)
+
+	rr := backend.request(t, http.MethodGet, fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey), nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, resultA.bid.decision, backend.boost.debugBids().Bids[0].Decision)
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	errNoProposalDuty    = errors.New("no proposal duty for pubkey in epoch")
+	errDutiesUnavailable = errors.New("proposer duties unavailable")
+)
+
+// DutyCheckMode is how getHeader calls for pubkeys without a proposal duty in the epoch of the slot are handled
+type DutyCheckMode string
+
+// Duty check modes
+const (
+	DutyCheckOff          DutyCheckMode = "off"
+	DutyCheckReject       DutyCheckMode = "reject"
+	DutyCheckDeprioritize DutyCheckMode = "deprioritize"
+)
+
+// ParseDutyCheckMode parses a duty check mode: off, reject (respond with an error), or deprioritize (request bids for
+// one such call at a time, and respond with no bid to the others)
+func ParseDutyCheckMode(s string) (DutyCheckMode, error) {
+	switch mode := DutyCheckMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case DutyCheckOff, DutyCheckReject, DutyCheckDeprioritize:
+		return mode, nil
+	case "":
+		return DutyCheckOff, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrInvalidDutyCheckMode, s)
+}
+
+// proposerDutiesCache keeps the pubkeys of the proposers of recent epochs, fetched from the beacon node when first
+// needed
+type proposerDutiesCache struct {
+	retryInterval time.Duration // after a failure to fetch the duties of an epoch
+
+	mu        sync.Mutex
+	proposers map[uint64]map[string]bool // by epoch, lowercase pubkeys
+	failedAt  map[uint64]time.Time       // last failure to fetch the duties of an epoch
+	fetching  map[uint64]chan struct{}   // closed once the duties of an epoch being fetched are cached, or failed
+}
+
+func newProposerDutiesCache(retryInterval time.Duration) *proposerDutiesCache {
+	return &proposerDutiesCache{
+		retryInterval: retryInterval,
+		proposers:     make(map[uint64]map[string]bool),
+		failedAt:      make(map[uint64]time.Time),
+		fetching:      make(map[uint64]chan struct{}),
+	}
+}
+
+// isProposer returns whether pubkey has a proposal duty in epoch. Duties are fetched at most once per epoch, and not
+// again within the retry interval of a failure. The duties are fetched without holding the lock, so that calls for
+// cached epochs aren't held up by the beacon node, while calls for the epoch being fetched wait for it.
+func (c *proposerDutiesCache) isProposer(client *beaconClient, epoch uint64, pubkey string, now time.Time) (bool, error) {
+	pubkey = strings.ToLower(pubkey)
+	c.mu.Lock()
+	for {
+		if proposers, ok := c.proposers[epoch]; ok {
+			c.mu.Unlock()
+			return proposers[pubkey], nil
+		}
+		if failedAt, failed := c.failedAt[epoch]; failed && now.Sub(failedAt) < c.retryInterval {
+			c.mu.Unlock()
+			return false, errDutiesUnavailable
+		}
+		fetching, ok := c.fetching[epoch]
+		if !ok {
+			break
+		}
+		c.mu.Unlock()
+		<-fetching
+		c.mu.Lock()
+	}
+	fetched := make(chan struct{})
+	c.fetching[epoch] = fetched
+	c.mu.Unlock()
+
+	duties, err := client.proposerDuties(epoch)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.fetching, epoch)
+	defer close(fetched)
+	if err != nil {
+		c.failedAt[epoch] = now
+		return false, fmt.Errorf("%w: %v", errDutiesUnavailable, err)
+	}
+	proposers := make(map[string]bool, len(duties))
+	for _, duty := range duties {
+		proposers[strings.ToLower(duty.Pubkey)] = true
+	}
+	c.proposers[epoch] = proposers
+	delete(c.failedAt, epoch)
+
+	// Keep the previous epoch, for late calls around the epoch boundary
+	for e := range c.proposers {
+		if e+1 < epoch {
+			delete(c.proposers, e)
+		}
+	}
+	for e := range c.failedAt {
+		if e+1 < epoch {
+			delete(c.failedAt, e)
+		}
+	}
+	return proposers[pubkey], nil
+}
+
+// checkProposerDuty returns the status code with which a getHeader call is shed, or 0 if it is served, and the
+// function to call once it is served. Calls are served if the duties can't be fetched from the beacon node.
+func (m *BoostService) checkProposerDuty(log *logrus.Entry, slot uint64, pubkey string) (code int, done func()) {
+	done = func() {}
+	if m.dutyCheck == DutyCheckOff || m.dutyCheck == "" || m.beaconClient == nil {
+		return 0, done
+	}
+
+	epoch := m.chain.epoch(slot)
+	isProposer, err := m.proposerDuties.isProposer(m.beaconClient, epoch, pubkey, m.clock.Now())
+	if err != nil {
+		log.WithError(err).WithField("epoch", epoch).Warn("could not check the proposal duty of the getHeader call")
+		return 0, done
+	} else if isProposer {
+		return 0, done
+	}
+
+	log = log.WithField("epoch", epoch)
+	if m.dutyCheck == DutyCheckReject {
+		m.metrics.getHeaderWithoutDuty.WithLabelValues("rejected").Inc()
+		log.Warn("getHeader for a pubkey without proposal duty, rejected")
+		return http.StatusBadRequest, done
+	}
+
+	select {
+	case m.dutylessGetHeader <- struct{}{}:
+		m.metrics.getHeaderWithoutDuty.WithLabelValues("served").Inc()
+		log.Info("getHeader for a pubkey without proposal duty")
+		return 0, func() { <-m.dutylessGetHeader }
+	default:
+		m.metrics.getHeaderWithoutDuty.WithLabelValues("shed").Inc()
+		log.Warn("getHeader for a pubkey without proposal duty, shed while another one is served")
+		return http.StatusNoContent, done
+	}
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDutyCheckMode(t *testing.T) {
+	for s, expected := range map[string]DutyCheckMode{"": DutyCheckOff, "off": DutyCheckOff, "reject": DutyCheckReject, " Deprioritize": DutyCheckDeprioritize} {
+		mode, err := ParseDutyCheckMode(s)
+		require.NoError(t, err)
+		require.Equal(t, expected, mode)
+	}
+	_, err := ParseDutyCheckMode("drop")
+	require.ErrorIs(t, err, ErrInvalidDutyCheckMode)
+}
+
+func TestCheckProposerDuty(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	otherPubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca24a"
+	path := fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey)
+	otherPath := fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, otherPubkey)
+
+	var dutiesRequests, dutiesFail int32
+	beaconNode := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&dutiesRequests, 1)
+		if atomic.LoadInt32(&dutiesFail) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		require.Equal(t, "/eth/v1/validator/duties/proposer/0", req.URL.Path)
+		fmt.Fprintf(w, `{"data":[{"pubkey":"%s","validator_index":"3","slot":"1"}]}`, pubkey)
+	}))
+	defer beaconNode.Close()
+
+	backend := newTestBackend(t, 1, time.Second)
+	backend.boost.beaconClient = newBeaconClient(beaconNode.URL, time.Second)
+	relay := backend.relays[0]
+	relay.GetHeaderResponse = relay.MakeGetHeaderResponse(12345, hash, pubkey)
+
+	// Calls without duty are rejected, and the duties fetched once per epoch
+	backend.boost.dutyCheck = DutyCheckReject
+	rr := backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	rr = backend.request(t, http.MethodGet, otherPath, nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+	require.Contains(t, rr.Body.String(), errNoProposalDuty.Error())
+	require.Equal(t, 0, relay.GetRequestCount(otherPath))
+	require.Equal(t, int32(1), atomic.LoadInt32(&dutiesRequests))
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.getHeaderWithoutDuty.WithLabelValues("rejected")))
+
+	// One call without duty at a time is served when deprioritized
+	backend.boost.dutyCheck = DutyCheckDeprioritize
+	backend.boost.dutylessGetHeader <- struct{}{}
+	rr = backend.request(t, http.MethodGet, otherPath, nil)
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	require.Equal(t, 0, relay.GetRequestCount(otherPath))
+	rr = backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	<-backend.boost.dutylessGetHeader
+	rr = backend.request(t, http.MethodGet, otherPath, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, 1, relay.GetRequestCount(otherPath))
+	require.Len(t, backend.boost.dutylessGetHeader, 0)
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.getHeaderWithoutDuty.WithLabelValues("shed")))
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.getHeaderWithoutDuty.WithLabelValues("served")))
+
+	// Calls are served when the duties are unavailable, which are not fetched again right away
+	atomic.StoreInt32(&dutiesFail, 1)
+	backend.boost.dutyCheck = DutyCheckReject
+	otherEpochPath := fmt.Sprintf("/eth/v1/builder/header/40/%s/%s", hash, otherPubkey)
+	for i := 0; i < 2; i++ {
+		rr = backend.request(t, http.MethodGet, otherEpochPath, nil)
+		require.NotEqual(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+	}
+	require.Equal(t, 2, relay.GetRequestCount(otherEpochPath))
+	require.Equal(t, int32(2), atomic.LoadInt32(&dutiesRequests))
+}
+
+func TestProposerDutiesCacheConcurrentFetch(t *testing.T) {
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	var dutiesRequests int32
+	release := make(chan struct{})
+	beaconNode := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&dutiesRequests, 1)
+		if req.URL.Path == "/eth/v1/validator/duties/proposer/1" {
+			<-release
+		}
+		fmt.Fprintf(w, `{"data":[{"pubkey":"%s","validator_index":"3","slot":"1"}]}`, pubkey)
+	}))
+	defer beaconNode.Close()
+	client := newBeaconClient(beaconNode.URL, time.Second)
+	cache := newProposerDutiesCache(ChainEthereum.slotDuration())
+	now := time.Now()
+
+	isProposer, err := cache.isProposer(client, 0, pubkey, now)
+	require.NoError(t, err)
+	require.True(t, isProposer)
+
+	// Calls for the epoch being fetched wait for the one fetch, while calls for cached epochs don't
+	results := make(chan bool, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			isProposer, err := cache.isProposer(client, 1, pubkey, now)
+			results <- err == nil && isProposer
+		}()
+	}
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&dutiesRequests) == 2 }, time.Second, 10*time.Millisecond)
+	isProposer, err = cache.isProposer(client, 0, pubkey, now)
+	require.NoError(t, err)
+	require.True(t, isProposer)
+
+	close(release)
+	require.True(t, <-results)
+	require.True(t, <-results)
+	require.Equal(t, int32(2), atomic.LoadInt32(&dutiesRequests))
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// slotOutcomeMessage is the log message of the slot outcome records
+const slotOutcomeMessage = "slot outcome"
+
+// slotOutcomeRecord is a slot outcome record, as logged in JSON format
+type slotOutcomeRecord struct {
+	Msg              string    `json:"msg"`
+	Time             time.Time `json:"time"`
+	Slot             uint64    `json:"slot"`
+	Pubkey           string    `json:"pubkey"`
+	Relays           string    `json:"relays"`
+	Value            string    `json:"value"`
+	PayloadDelivered bool      `json:"payloadDelivered"`
+	Cohort           string    `json:"cohort"`
+}
+
+// EarningsSummary sums up the slots and delivered bid values of a proposer or relay
+type EarningsSummary struct {
+	Key       string `json:"key"` // proposer pubkey, relay or experiment cohort
+	Slots     int    `json:"slots"`
+	Delivered int    `json:"delivered"`
+	Earnings  string `json:"earnings"` // sum of the values of delivered payloads [wei]
+
+	EarningsDisplay string `json:"earnings_display,omitempty"` // in units of the native token, see FormatEarnings
+
+	earnings *big.Int
+}
+
+// EarningsReport sums up the slot outcomes in a time range per proposer, per relay and per experiment cohort.
+// A slot whose bid was delivered by multiple relays counts for each of them.
+type EarningsReport struct {
+	From      time.Time         `json:"from"`
+	To        time.Time         `json:"to"` // zero if unbounded
+	Slots     int               `json:"slots"`
+	Proposers []EarningsSummary `json:"proposers"`
+	Relays    []EarningsSummary `json:"relays"`
+	Cohorts   []EarningsSummary `json:"cohorts,omitempty"`
+}
+
+// NewEarningsReport reads the slot outcome records from mev-boost JSON logs, and sums up those logged in [from, to).
+// A zero to includes all later records. Other log lines are skipped.
+func NewEarningsReport(logs io.Reader, from, to time.Time) (*EarningsReport, error) {
+	proposers := make(map[string]*EarningsSummary)
+	relays := make(map[string]*EarningsSummary)
+	cohorts := make(map[string]*EarningsSummary)
+	report := &EarningsReport{From: from, To: to}
+
+	scanner := bufio.NewScanner(logs)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if !strings.Contains(string(line), slotOutcomeMessage) {
+			continue
+		}
+		record := slotOutcomeRecord{}
+		if err := json.Unmarshal(line, &record); err != nil || record.Msg != slotOutcomeMessage {
+			continue
+		}
+		if record.Time.Before(from) || (!to.IsZero() && !record.Time.Before(to)) {
+			continue
+		}
+
+		report.Slots++
+		value := new(big.Int)
+		if record.PayloadDelivered {
+			value.SetString(record.Value, 10)
+		}
+		addEarnings(proposers, record.Pubkey, record.PayloadDelivered, value)
+		if record.Cohort != "" {
+			addEarnings(cohorts, record.Cohort, record.PayloadDelivered, value)
+		}
+		if record.Relays == "" {
+			continue
+		}
+		for _, relay := range strings.Split(record.Relays, ",") {
+			addEarnings(relays, relay, record.PayloadDelivered, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	report.Proposers = sortedEarnings(proposers)
+	report.Relays = sortedEarnings(relays)
+	if len(cohorts) > 0 {
+		report.Cohorts = sortedEarnings(cohorts)
+	}
+	return report, nil
+}
+
+func addEarnings(summaries map[string]*EarningsSummary, key string, delivered bool, value *big.Int) {
+	summary, ok := summaries[key]
+	if !ok {
+		summary = &EarningsSummary{Key: key, earnings: new(big.Int)}
+		summaries[key] = summary
+	}
+	summary.Slots++
+	if delivered {
+		summary.Delivered++
+		summary.earnings.Add(summary.earnings, value)
+	}
+}
+
+// sortedEarnings returns the summaries ordered by earnings, highest first
+func sortedEarnings(summaries map[string]*EarningsSummary) []EarningsSummary {
+	ret := make([]EarningsSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		summary.Earnings = summary.earnings.String()
+		ret = append(ret, *summary)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if c := ret[i].earnings.Cmp(ret[j].earnings); c != 0 {
+			return c > 0
+		}
+		return ret[i].Key < ret[j].Key
+	})
+	return ret
+}
+
+// FormatEarnings sets the earnings in units of the native token of chain, for display
+func (r *EarningsReport) FormatEarnings(chain ChainConfig) {
+	for _, summaries := range [][]EarningsSummary{r.Proposers, r.Relays, r.Cohorts} {
+		for i := range summaries {
+			summaries[i].EarningsDisplay = chain.FormatValue(summaries[i].earnings)
+		}
+	}
+}
+
+// WriteCSV writes the report as CSV, with a row per proposer, relay and experiment cohort
+func (r *EarningsReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"type", "key", "slots", "delivered", "earnings_wei"}); err != nil {
+		return err
+	}
+	for _, group := range []struct {
+		name      string
+		summaries []EarningsSummary
+	}{{"proposer", r.Proposers}, {"relay", r.Relays}, {"cohort", r.Cohorts}} {
+		for _, s := range group.summaries {
+			if err := cw.Write([]string{group.name, s.Key, strconv.Itoa(s.Slots), strconv.Itoa(s.Delivered), s.Earnings}); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEarningsReport(t *testing.T) {
+	// Write slot outcome records the way the service logs them
+	logs := new(bytes.Buffer)
+	logger := logrus.New()
+	logger.SetOutput(logs)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	backend := newTestBackend(t, 1, time.Second)
+
+	day := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	for _, o := range []struct {
+		t       time.Time
+		outcome slotOutcome
+	}{
+		{day, slotOutcome{Slot: 1, Pubkey: "0xaa", Relays: []string{"relay-a", "relay-b"}, Value: "10", PayloadDelivered: true}},
+		{day, slotOutcome{Slot: 2, Pubkey: "0xaa", Relays: []string{"relay-a"}, Value: "5"}},
+		{day, slotOutcome{Slot: 3, Pubkey: "0xbb"}},
+		{day.Add(time.Hour), slotOutcome{Slot: 4, Pubkey: "0xbb", Relays: []string{"relay-b"}, Value: "30", PayloadDelivered: true, Cohort: "b"}},
+		{day.AddDate(0, 0, 1), slotOutcome{Slot: 5, Pubkey: "0xaa", Relays: []string{"relay-a"}, Value: "1", PayloadDelivered: true}},
+	} {
+		outcome := o.outcome
+		backend.boost.log = logrus.NewEntry(logger).WithTime(o.t)
+		backend.boost.emitSlotOutcome(&outcome)
+	}
+	logs.WriteString("not a json line\n")
+
+	report, err := NewEarningsReport(bytes.NewReader(logs.Bytes()), day.Truncate(24*time.Hour), day.Truncate(24*time.Hour).AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Equal(t, 4, report.Slots)
+	require.Equal(t, []EarningsSummary{
+		{Key: "0xbb", Slots: 2, Delivered: 1, Earnings: "30"},
+		{Key: "0xaa", Slots: 2, Delivered: 1, Earnings: "10"},
+	}, stripEarnings(report.Proposers))
+	require.Equal(t, []EarningsSummary{
+		{Key: "relay-b", Slots: 2, Delivered: 2, Earnings: "40"},
+		{Key: "relay-a", Slots: 2, Delivered: 1, Earnings: "10"},
+	}, stripEarnings(report.Relays))
+	require.Equal(t, []EarningsSummary{{Key: "b", Slots: 1, Delivered: 1, Earnings: "30"}}, stripEarnings(report.Cohorts))
+
+	csv := new(strings.Builder)
+	require.NoError(t, report.WriteCSV(csv))
+	require.Equal(t, `type,key,slots,delivered,earnings_wei
+proposer,0xbb,2,1,30
+proposer,0xaa,2,1,10
+relay,relay-b,2,2,40
+relay,relay-a,2,1,10
+cohort,b,1,1,30
+`, csv.String())
+
+	// Without an upper bound
+	report, err = NewEarningsReport(bytes.NewReader(logs.Bytes()), day.Truncate(24*time.Hour), time.Time{})
+	require.NoError(t, err)
+	require.Equal(t, 5, report.Slots)
+
+	// The earnings are displayed in units of the native token
+	report.FormatEarnings(ChainGnosis)
+	require.Equal(t, "0.00000000000000004 xDAI", report.Relays[0].EarningsDisplay)
+}
+
+func stripEarnings(summaries []EarningsSummary) []EarningsSummary {
+	for i := range summaries {
+		summaries[i].earnings = nil
+	}
+	return summaries
+}
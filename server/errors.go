@@ -5,4 +5,75 @@ import "fmt"
 var (
 	// ErrMissingRelayPubkey is returned if a new RelayEntry URL has no public key
 	ErrMissingRelayPubkey = fmt.Errorf("missing relay public key")
+
+	// ErrInvalidMaintenanceWindow is returned if a relay maintenance window cannot be parsed
+	ErrInvalidMaintenanceWindow = fmt.Errorf("invalid maintenance window")
+
+	// ErrInvalidForkSchedule is returned if a fork schedule cannot be parsed
+	ErrInvalidForkSchedule = fmt.Errorf("invalid fork schedule")
+
+	// ErrInvalidValueUnit is returned if a relay value unit cannot be parsed
+	ErrInvalidValueUnit = fmt.Errorf("invalid value unit")
+
+	// ErrInvalidRelayTransport is returned if relay transport settings cannot be parsed
+	ErrInvalidRelayTransport = fmt.Errorf("invalid relay transport")
+
+	// ErrInvalidExperiment is returned if experiment cohorts cannot be parsed, or use unknown relays
+	ErrInvalidExperiment = fmt.Errorf("invalid experiment")
+
+	// ErrInvalidValidatorPubkey is returned if a list of validator pubkeys contains an invalid pubkey
+	ErrInvalidValidatorPubkey = fmt.Errorf("invalid validator pubkey")
+
+	// ErrInvalidAtRestKey is returned if the key encrypting files at rest cannot be loaded
+	ErrInvalidAtRestKey = fmt.Errorf("invalid at-rest key")
+
+	// ErrInvalidChain is returned for an unknown chain name
+	ErrInvalidChain = fmt.Errorf("invalid chain")
+
+	// ErrInvalidFeatureFlag is returned for an unknown or malformed feature flag
+	ErrInvalidFeatureFlag = fmt.Errorf("invalid feature flag")
+
+	// ErrInvalidRelayTags is returned if relay tags or relay tag policies cannot be parsed
+	ErrInvalidRelayTags = fmt.Errorf("invalid relay tags")
+
+	// ErrInvalidMinBidValue is returned if relay minimum bid values cannot be parsed
+	ErrInvalidMinBidValue = fmt.Errorf("invalid minimum bid value")
+
+	// ErrInvalidProposerConfig is returned if the proposer config cannot be parsed, or uses unknown relays
+	ErrInvalidProposerConfig = fmt.Errorf("invalid proposer config")
+
+	// ErrInvalidExportTarget is returned if the target of data exports is incomplete
+	ErrInvalidExportTarget = fmt.Errorf("invalid export target")
+
+	// ErrInvalidAttestationKey is returned if the key signing bid attestations cannot be loaded
+	ErrInvalidAttestationKey = fmt.Errorf("invalid attestation key")
+
+	// ErrInvalidConfig is returned if a config doesn't follow the validate tags of its fields
+	ErrInvalidConfig = fmt.Errorf("invalid config")
+
+	// ErrInvalidRetentionPolicy is returned if retention policies cannot be parsed
+	ErrInvalidRetentionPolicy = fmt.Errorf("invalid retention policy")
+
+	// ErrInvalidDutyCheckMode is returned for an unknown duty check mode
+	ErrInvalidDutyCheckMode = fmt.Errorf("invalid duty check mode")
+
+	// ErrInvalidPayloadVerificationPolicy is returned for an unknown payload verification policy
+	ErrInvalidPayloadVerificationPolicy = fmt.Errorf("invalid payload verification policy")
+
+	// ErrInvalidJSONCodec is returned for an unknown JSON codec name
+	ErrInvalidJSONCodec = fmt.Errorf("invalid JSON codec")
+
+	// ErrInvalidSecret is returned if a secret source is invalid
+	ErrInvalidSecret = fmt.Errorf("invalid secret")
+
+	// ErrInvalidBidPolicy is returned if a bid policy cannot be compiled
+	ErrInvalidBidPolicy = fmt.Errorf("invalid bid policy")
+
+	// ErrInvalidResponseHeaders is returned if the response header rules cannot be parsed
+	ErrInvalidResponseHeaders = fmt.Errorf("invalid response headers")
+
+	// ErrInvalidExtension is returned if an extension endpoint cannot be registered
+	ErrInvalidExtension = fmt.Errorf("invalid extension")
+
+	errInvalidDebugField = fmt.Errorf("invalid debug field")
 )
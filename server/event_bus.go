@@ -0,0 +1,104 @@
+package server
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// eventObserver observes the events published to the event bus. Observers run on the goroutine of the publisher,
+// which is often on the critical path of a request, so they must not block: slow work (eg. network calls) belongs on
+// a goroutine of the observer.
+type eventObserver func(eventType string, data any)
+
+// eventBus decouples the handlers publishing events from the observers of the events, eg. metrics, stats and the
+// event stream, so that adding an observer doesn't touch the handlers
+type eventBus struct {
+	mu        sync.RWMutex
+	observers map[string][]eventObserver // by event type, "" for observers of all events
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{observers: make(map[string][]eventObserver)}
+}
+
+// subscribe registers an observer of the events of the given types, or of all events without type
+func (b *eventBus) subscribe(observer eventObserver, eventTypes ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(eventTypes) == 0 {
+		eventTypes = []string{""}
+	}
+	for _, eventType := range eventTypes {
+		b.observers[eventType] = append(b.observers[eventType], observer)
+	}
+}
+
+// publish passes an event to its observers, in the order they subscribed
+func (b *eventBus) publish(eventType string, data any) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, observer := range b.observers[eventType] {
+		observer(eventType, data)
+	}
+	for _, observer := range b.observers[""] {
+		observer(eventType, data)
+	}
+}
+
+// publishRelayError publishes a relay error event if err is an error response of the relay
+func (m *BoostService) publishRelayError(relay RelayEntry, endpoint string, err error) {
+	var relayErr *RelayError
+	if !errors.As(err, &relayErr) {
+		return
+	}
+	m.events.publish(EventRelayError, relayErrorEvent{
+		Relay:      relay.String(),
+		Endpoint:   endpoint,
+		StatusCode: relayErr.StatusCode,
+		Code:       relayErr.Code,
+		Message:    relayErr.Message,
+	})
+}
+
+// subscribeObservers subscribes the observers of the service to its events
+func (m *BoostService) subscribeObservers() {
+	m.events.subscribe(m.eventStream.publish)
+
+	m.events.subscribe(func(_ string, data any) {
+		event := data.(relayBidEvent)
+		if event.Status == "" {
+			return
+		}
+		m.relayScores.record(event.Relay, event.Status == relayBidValid || event.Status == relayBidNone, m.clock.Now())
+		m.metrics.relayBids.WithLabelValues(event.Relay, event.Status).Inc()
+		if value, err := strconv.ParseFloat(event.Value, 64); err == nil && event.Status == relayBidValid {
+			m.metrics.relayBidValue.WithLabelValues(event.Relay).Set(value)
+		}
+		if relayURL, err := url.Parse(event.Relay); err == nil {
+			m.telemetry.recordRelayBid(relayURL.Host, event.Status, time.Duration(event.LatencyMs)*time.Millisecond)
+		}
+	}, EventRelayBid)
+
+	m.events.subscribe(func(_ string, data any) {
+		event := data.(payloadFetchedEvent)
+		m.relayScores.record(event.Relay, event.Status == payloadFetchedValid, m.clock.Now())
+		m.metrics.relayPayloads.WithLabelValues(event.Relay, event.Status).Inc()
+		if event.Status != payloadFetchedError && event.delay > 0 {
+			m.metrics.relayPayloadDelay.WithLabelValues(event.Relay).Observe(event.delay.Seconds())
+		}
+	}, EventPayloadFetched)
+
+	m.events.subscribe(func(_ string, data any) {
+		for _, relay := range data.(bidSelectedEvent).Relays {
+			m.metrics.relayBidsWon.WithLabelValues(relay).Inc()
+		}
+	}, EventBidSelected)
+
+	m.events.subscribe(func(_ string, data any) {
+		event := data.(relayErrorEvent)
+		m.relayErrors.add(event.Relay, &RelayError{StatusCode: event.StatusCode, Code: event.Code, Message: event.Message})
+	}, EventRelayError)
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventBus(t *testing.T) {
+	bus := newEventBus()
+	var observed []string
+	bus.subscribe(func(eventType string, data any) {
+		observed = append(observed, "bid:"+data.(string))
+	}, EventRelayBid)
+	bus.subscribe(func(eventType string, data any) {
+		observed = append(observed, "all:"+eventType)
+	})
+
+	bus.publish(EventRelayBid, "1")
+	bus.publish(EventBidSelected, "2")
+	require.Equal(t, []string{"bid:1", "all:" + EventRelayBid, "all:" + EventBidSelected}, observed)
+}
+
+func TestEventObservers(t *testing.T) {
+	backend := newTestBackend(t, 1, time.Second)
+	relay := backend.relays[0].RelayEntry
+	var events []string
+	backend.boost.events.subscribe(func(eventType string, data any) {
+		events = append(events, eventType)
+	})
+
+	// Relay errors are counted per message
+	backend.boost.publishRelayError(relay, "get_header", &RelayError{StatusCode: 400, Code: 400, Message: "invalid slot"})
+	backend.boost.publishRelayError(relay, "get_header", errors.New("connection refused"))
+	require.Equal(t, map[string]map[string]uint64{relay.String(): {"invalid slot": 1}}, backend.boost.relayErrors.snapshot())
+	require.Equal(t, []string{EventRelayError}, events)
+
+	// Payloads fetched are scored
+	backend.boost.relayScores = newRelayScores(time.Hour, 0)
+	backend.boost.events.publish(EventPayloadFetched, payloadFetchedEvent{Relay: relay.String(), Status: payloadFetchedError})
+	score, _ := backend.boost.relayScores.get(relay.String(), backend.boost.clock.Now())
+	require.Less(t, score, relayScoreColdStart)
+}
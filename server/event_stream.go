@@ -0,0 +1,188 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Event types of the event stream
+const (
+	// EventPayloadAtRisk is published when no relay delivered the payload of a served header within the payload
+	// delivery SLA
+	EventPayloadAtRisk = "payload_at_risk"
+
+	// EventRelayBid is published for the response of each relay to a getHeader fan-out, as it arrives
+	EventRelayBid = "relay_bid"
+
+	// EventBidSelected is published when the bid of a getHeader fan-out is selected
+	EventBidSelected = "bid_selected"
+
+	// EventPayloadFetched is published for the response of each relay called for a payload, unless the call was
+	// cancelled after another relay delivered the payload
+	EventPayloadFetched = "payload_fetched"
+
+	// EventRelayError is published for each error response of a relay
+	EventRelayError = "relay_error"
+)
+
+// Statuses of relay bid events
+const (
+	relayBidValid    = "bid"
+	relayBidNone     = "no_bid"
+	relayBidRejected = "rejected"
+	relayBidError    = "error"
+)
+
+// relayBidEvent is the response of a relay to a getHeader fan-out
+type relayBidEvent struct {
+	Slot       uint64             `json:"slot,string"`
+	ParentHash string             `json:"parent_hash"`
+	Pubkey     string             `json:"pubkey"`
+	Relay      string             `json:"relay"`
+	Status     string             `json:"status"` // bid, no_bid, rejected or error
+	Rejection  BidRejectionReason `json:"rejection,omitempty"`
+	BlockHash  string             `json:"block_hash,omitempty"`
+	Value      string             `json:"value,omitempty"` // in wei
+	Best       bool               `json:"best"`            // best bid of the fan-out so far
+	LatencyMs  int64              `json:"latency_ms"`
+}
+
+// Statuses of payload fetched events
+const (
+	payloadFetchedValid   = "payload"
+	payloadFetchedInvalid = "invalid"
+	payloadFetchedError   = "error"
+)
+
+// payloadFetchedEvent is the response of a relay to a getPayload call
+type payloadFetchedEvent struct {
+	Slot      uint64 `json:"slot,string"`
+	BlockHash string `json:"block_hash"`
+	Relay     string `json:"relay"`
+	Status    string `json:"status"` // payload, invalid or error
+	LatencyMs int64  `json:"latency_ms"`
+	DelayMs   int64  `json:"delay_ms,omitempty"` // since the header was served, if it was
+
+	delay time.Duration
+}
+
+// relayErrorEvent is an error response of a relay
+type relayErrorEvent struct {
+	Relay      string `json:"relay"`
+	Endpoint   string `json:"endpoint"` // see relayEndpoint
+	StatusCode int    `json:"status_code"`
+	Code       int    `json:"code"`
+	Message    string `json:"message"`
+}
+
+// bidSelectedEvent is the bid selected from the responses of a getHeader fan-out
+type bidSelectedEvent struct {
+	Slot       uint64   `json:"slot,string"`
+	ParentHash string   `json:"parent_hash"`
+	Pubkey     string   `json:"pubkey"`
+	BlockHash  string   `json:"block_hash,omitempty"` // empty without bid
+	Value      string   `json:"value,omitempty"`      // in wei
+	Relays     []string `json:"relays"`
+	Coverage   string   `json:"coverage"` // relays responded / relays called
+	Partial    bool     `json:"partial"`
+}
+
+func newBidSelectedEvent(slot uint64, parentHash, pubkey string, bid bidResp, coverage string, partial bool) bidSelectedEvent {
+	event := bidSelectedEvent{
+		Slot:       slot,
+		ParentHash: parentHash,
+		Pubkey:     pubkey,
+		BlockHash:  bid.blockHash,
+		Relays:     bid.relays,
+		Coverage:   coverage,
+		Partial:    partial,
+	}
+	if event.Relays == nil {
+		event.Relays = []string{}
+	}
+	if bid.valueWei != nil {
+		event.Value = bid.valueWei.String()
+	}
+	return event
+}
+
+// eventBufferSize is the number of events buffered per subscriber, further events are dropped for slow subscribers
+const eventBufferSize = 64
+
+// streamEvent is an event of the event stream, sent as a server-sent event with Type as event name and Data as JSON
+type streamEvent struct {
+	Type string
+	Data any
+}
+
+// eventStream broadcasts events to the subscribers of the event stream endpoint
+type eventStream struct {
+	mu          sync.Mutex
+	subscribers map[chan streamEvent]struct{}
+}
+
+func newEventStream() *eventStream {
+	return &eventStream{subscribers: make(map[chan streamEvent]struct{})}
+}
+
+// subscribe returns a channel receiving the events published from now on, and a function to unsubscribe
+func (s *eventStream) subscribe() (<-chan streamEvent, func()) {
+	ch := make(chan streamEvent, eventBufferSize)
+	s.mu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.mu.Unlock()
+	return ch, func() {
+		s.mu.Lock()
+		delete(s.subscribers, ch)
+		s.mu.Unlock()
+	}
+}
+
+// publish sends an event to all subscribers without blocking
+func (s *eventStream) publish(eventType string, data any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subscribers {
+		select {
+		case ch <- streamEvent{Type: eventType, Data: data}:
+		default: // the subscriber is too slow
+		}
+	}
+}
+
+// handleEvents streams the events as server-sent events until the client disconnects
+func (m *BoostService) handleEvents(w http.ResponseWriter, req *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		m.respondError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	events, unsubscribe := m.eventStream.subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case event := <-events:
+			data, err := json.Marshal(event.Data)
+			if err != nil {
+				m.log.WithError(err).WithField("event", event.Type).Error("could not encode event")
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// readStreamEvent reads server-sent events until one of the given type, and returns its data
+func readStreamEvent(t *testing.T, reader *bufio.Reader, eventType string) string {
+	t.Helper()
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		if line != "event: "+eventType+"\n" {
+			continue
+		}
+		line, err = reader.ReadString('\n')
+		require.NoError(t, err)
+		return strings.TrimSuffix(strings.TrimPrefix(line, "data: "), "\n")
+	}
+}
+
+func TestGetHeaderEvents(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+
+	backend := newTestBackend(t, 3, time.Second)
+	backend.boost.debugAPI = true
+	backend.relays[0].GetHeaderResponse = backend.relays[0].MakeGetHeaderResponse(12345, hash, pubkey)
+	backend.relays[1].GetHeaderResponse = backend.relays[1].MakeGetHeaderResponse(12345, hash, pubkey)
+	backend.relays[1].GetHeaderResponse.Data.Message.Header.BlockHash = nilHash
+	backend.relays[2].ResponseDelay = 100 * time.Millisecond
+	backend.relays[2].GetHeaderResponse = backend.relays[2].MakeGetHeaderResponse(1, "0xa38385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7", pubkey)
+
+	server := httptest.NewServer(backend.boost.getRouter())
+	defer server.Close()
+	resp, err := http.Get(server.URL + pathDebugEvents)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	done := make(chan int)
+	go func() {
+		done <- backend.request(t, http.MethodGet, fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey), nil).Code
+	}()
+
+	// The relay responses are published as they arrive, before the bid is selected
+	reader := bufio.NewReader(resp.Body)
+	events := make(map[string]relayBidEvent)
+	for i := 0; i < 3; i++ {
+		event := relayBidEvent{}
+		require.NoError(t, json.Unmarshal([]byte(readStreamEvent(t, reader, EventRelayBid)), &event))
+		events[event.Relay] = event
+		if i < 2 {
+			select {
+			case <-done:
+				t.Fatal("bid selected before the slow relay responded")
+			default:
+			}
+		}
+	}
+	require.Equal(t, http.StatusOK, <-done)
+
+	fast := events[backend.relays[0].RelayEntry.String()]
+	require.Equal(t, relayBidValid, fast.Status)
+	require.Equal(t, uint64(1), fast.Slot)
+	require.Equal(t, pubkey, fast.Pubkey)
+	require.Equal(t, "12345", fast.Value)
+	require.True(t, fast.Best)
+	invalid := events[backend.relays[1].RelayEntry.String()]
+	require.Equal(t, relayBidRejected, invalid.Status)
+	require.Equal(t, BidRejectionInvalidResponse, invalid.Rejection)
+	slow := events[backend.relays[2].RelayEntry.String()]
+	require.Equal(t, relayBidValid, slow.Status)
+	require.False(t, slow.Best)
+	require.GreaterOrEqual(t, slow.LatencyMs, int64(100))
+
+	selected := bidSelectedEvent{}
+	require.NoError(t, json.Unmarshal([]byte(readStreamEvent(t, reader, EventBidSelected)), &selected))
+	require.Equal(t, "3/3", selected.Coverage)
+	require.Equal(t, "12345", selected.Value)
+	require.False(t, selected.Partial)
+	require.Len(t, selected.Relays, 1)
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ExperimentControlCohort is the cohort of the proposers or slots not assigned to an experiment cohort, which
+// request bids from all relays
+const ExperimentControlCohort = "control"
+
+// ExperimentCohort is a group of proposers or slots which request bids from its own set of relays
+type ExperimentCohort struct {
+	Name   string
+	Share  float64  // share of the proposers or slots assigned to the cohort, between 0 and 1
+	Relays []string // hosts of the relays to request bids from
+}
+
+// Experiment assigns proposers, or slots, to cohorts with different relay sets, to compare relay strategies.
+// The assignment is deterministic, so that a proposer stays in its cohort across restarts and instances.
+type Experiment struct {
+	Cohorts []ExperimentCohort
+	BySlot  bool // assign slots instead of proposers to the cohorts
+}
+
+// ParseExperimentCohorts parses a comma-separated list of NAME=PERCENT:HOST|HOST entries into experiment cohorts
+func ParseExperimentCohorts(s string) ([]ExperimentCohort, error) {
+	cohorts := []ExperimentCohort{}
+	if strings.TrimSpace(s) == "" {
+		return cohorts, nil
+	}
+
+	names := make(map[string]bool)
+	total := 0.0
+	for _, entry := range strings.Split(s, ",") {
+		name, spec, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || name == "" || name == ExperimentControlCohort || names[name] {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidExperiment, entry)
+		}
+		percent, hosts, found := strings.Cut(spec, ":")
+		if !found || hosts == "" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidExperiment, entry)
+		}
+		share, err := strconv.ParseFloat(strings.TrimSuffix(percent, "%"), 64)
+		if err != nil || share <= 0 {
+			return nil, fmt.Errorf("%w: invalid share %s", ErrInvalidExperiment, percent)
+		}
+
+		names[name] = true
+		total += share
+		cohorts = append(cohorts, ExperimentCohort{Name: name, Share: share / 100, Relays: strings.Split(hosts, "|")})
+	}
+	if total > 100 {
+		return nil, fmt.Errorf("%w: cohort shares add up to %g%%", ErrInvalidExperiment, total)
+	}
+	return cohorts, nil
+}
+
+// validate verifies that the cohorts only use the given relays
+func (e *Experiment) validate(relays []RelayEntry) error {
+	hosts := make(map[string]bool, len(relays))
+	for _, relay := range relays {
+		hosts[relay.URL.Host] = true
+	}
+	for _, cohort := range e.Cohorts {
+		for _, host := range cohort.Relays {
+			if !hosts[host] {
+				return fmt.Errorf("%w: cohort %s uses unknown relay %s", ErrInvalidExperiment, cohort.Name, host)
+			}
+		}
+	}
+	return nil
+}
+
+// cohort returns the cohort of the proposer or slot, or nil for the control cohort
+func (e *Experiment) cohort(slot uint64, pubkey string) *ExperimentCohort {
+	key := strings.ToLower(pubkey)
+	if e.BySlot {
+		key = strconv.FormatUint(slot, 10)
+	}
+	hash := sha256.Sum256([]byte(key))
+	x := float64(binary.BigEndian.Uint64(hash[:8])>>11) / (1 << 53) // uniform in [0, 1)
+
+	for i, cohort := range e.Cohorts {
+		if x < cohort.Share {
+			return &e.Cohorts[i]
+		}
+		x -= cohort.Share
+	}
+	return nil
+}
+
+// cohortRelays returns the name of the cohort of a getHeader request, and the relays to request bids from
+func (m *BoostService) cohortRelays(slot uint64, pubkey string, relays []RelayEntry) (string, []RelayEntry) {
+	if len(m.experiment.Cohorts) == 0 {
+		return "", relays
+	}
+	cohort := m.experiment.cohort(slot, pubkey)
+	if cohort == nil {
+		return ExperimentControlCohort, relays
+	}
+
+	hosts := make(map[string]bool, len(cohort.Relays))
+	for _, host := range cohort.Relays {
+		hosts[host] = true
+	}
+	ret := make([]RelayEntry, 0, len(cohort.Relays))
+	for _, relay := range relays {
+		if hosts[relay.URL.Host] {
+			ret = append(ret, relay)
+		}
+	}
+	return cohort.Name, ret
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExperimentCohorts(t *testing.T) {
+	cohorts, err := ParseExperimentCohorts("")
+	require.NoError(t, err)
+	require.Len(t, cohorts, 0)
+
+	cohorts, err = ParseExperimentCohorts("a=25%:foo.com|bar.com:9000, b=10:baz.com")
+	require.NoError(t, err)
+	require.Equal(t, []ExperimentCohort{
+		{Name: "a", Share: 0.25, Relays: []string{"foo.com", "bar.com:9000"}},
+		{Name: "b", Share: 0.1, Relays: []string{"baz.com"}},
+	}, cohorts)
+
+	for _, s := range []string{"a", "a=25", "a=x:foo.com", "a=0:foo.com", "control=10:foo.com", "a=10:foo.com,a=10:bar.com", "a=60:foo.com,b=60:bar.com"} {
+		_, err = ParseExperimentCohorts(s)
+		require.ErrorIs(t, err, ErrInvalidExperiment, s)
+	}
+}
+
+func TestExperimentCohort(t *testing.T) {
+	e := Experiment{Cohorts: []ExperimentCohort{{Name: "a", Share: 0.3}, {Name: "b", Share: 0.2}}}
+
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		cohort := e.cohort(uint64(i), fmt.Sprintf("0x%096x", i))
+		if cohort == nil {
+			counts[ExperimentControlCohort]++
+			continue
+		}
+		counts[cohort.Name]++
+	}
+	require.InDelta(t, 3000, counts["a"], 300)
+	require.InDelta(t, 2000, counts["b"], 300)
+	require.InDelta(t, 5000, counts[ExperimentControlCohort], 300)
+
+	// Proposers stay in their cohort, whatever the slot
+	require.Equal(t, e.cohort(1, "0xAB"), e.cohort(2, "0xab"))
+
+	e.BySlot = true
+	require.Equal(t, e.cohort(1, "0xab"), e.cohort(1, "0xcd"))
+}
+
+func TestGetHeaderExperimentCohort(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	path := fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey)
+
+	backend := newTestBackend(t, 2, time.Second)
+	host := backend.relays[1].RelayEntry.URL.Host
+	backend.boost.experiment = Experiment{Cohorts: []ExperimentCohort{{Name: "b", Share: 1, Relays: []string{host}}}}
+	require.NoError(t, backend.boost.experiment.validate(backend.boost.relays))
+
+	rr := backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, 0, backend.relays[0].GetRequestCount(path))
+	require.Equal(t, 1, backend.relays[1].GetRequestCount(path))
+
+	outcome := backend.boost.slotOutcomes.payloadServed(1, false, time.Now())
+	require.NotNil(t, outcome)
+	require.Equal(t, "b", outcome.Cohort)
+	backend.boost.metrics.observeSlotOutcome(outcome)
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.cohortSlots.WithLabelValues("b", "missed")))
+
+	invalid := Experiment{Cohorts: []ExperimentCohort{{Name: "c", Share: 1, Relays: []string{"unknown:1234"}}}}
+	require.ErrorIs(t, invalid.validate(backend.boost.relays), ErrInvalidExperiment)
+}
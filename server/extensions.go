@@ -0,0 +1,140 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// pathExtensions is the prefix of the endpoints of extensions
+const pathExtensions = "/ext/"
+
+// defaultExtensionTimeout is the deadline of the requests to extension endpoints without their own
+const defaultExtensionTimeout = 10 * time.Second
+
+var extensionNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Extension is an additional endpoint mounted by a downstream extension, eg. custom proposer tooling, at
+// /ext/<Name><Path>. Its handler is isolated from the Builder API: panics are recovered and answered with an internal
+// server error, and requests have their own deadline.
+type Extension struct {
+	Name    string   // lowercase letters, digits, - and _
+	Path    string   // path below /ext/<Name>, with mux variables like /{id}, empty for /ext/<Name> itself
+	Methods []string // all methods if empty
+	Handler http.HandlerFunc
+	Timeout time.Duration // defaultExtensionTimeout if 0, no deadline if negative
+	Admin   bool          // part of the admin API: only mounted with -admin-api, and requires its token if set
+}
+
+func (e Extension) path() string {
+	return pathExtensions + e.Name + e.Path
+}
+
+func (e Extension) validate() error {
+	switch {
+	case !extensionNameRegex.MatchString(e.Name):
+		return fmt.Errorf("%w: invalid name %q", ErrInvalidExtension, e.Name)
+	case e.Path != "" && !strings.HasPrefix(e.Path, "/"):
+		return fmt.Errorf("%w: path %q of %s must start with /", ErrInvalidExtension, e.Path, e.Name)
+	case e.Handler == nil:
+		return fmt.Errorf("%w: no handler for %s", ErrInvalidExtension, e.path())
+	}
+	return nil
+}
+
+// RegisterExtension mounts the endpoint of an extension, which must be done before StartHTTPServer. An endpoint can
+// only be registered once per method.
+func (m *BoostService) RegisterExtension(ext Extension) error {
+	if err := ext.validate(); err != nil {
+		return err
+	}
+	m.srvLock.Lock()
+	defer m.srvLock.Unlock()
+	m.extensionsLock.Lock()
+	defer m.extensionsLock.Unlock()
+	if m.srv != nil {
+		return fmt.Errorf("%w: %s registered after the server started", ErrInvalidExtension, ext.path())
+	}
+	for _, registered := range m.extensions {
+		if registered.path() == ext.path() && methodsOverlap(registered.Methods, ext.Methods) {
+			return fmt.Errorf("%w: %s is already registered", ErrInvalidExtension, ext.path())
+		}
+	}
+	m.extensions = append(m.extensions, ext)
+	return nil
+}
+
+func methodsOverlap(a, b []string) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return true
+	}
+	for _, methodA := range a {
+		for _, methodB := range b {
+			if strings.EqualFold(methodA, methodB) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// registerExtensions adds the endpoints of the extensions to the router. StartHTTPServer builds the router holding
+// srvLock, so only extensionsLock is taken here.
+func (m *BoostService) registerExtensions(r *mux.Router) {
+	m.extensionsLock.Lock()
+	extensions := m.extensions
+	m.extensionsLock.Unlock()
+
+	for _, ext := range extensions {
+		if ext.Admin && !m.adminAPI {
+			continue
+		}
+		timeout := ext.Timeout
+		if timeout == 0 {
+			timeout = defaultExtensionTimeout
+		}
+		// Recovering within the deadline keeps the stack of the panic, which withTimeout would panic again with
+		handler := m.withTimeout(timeout, m.withRecovery(ext.Name, ext.Handler))
+		if ext.Admin {
+			handler = m.withAdminAuth(handler)
+		}
+		route := r.HandleFunc(ext.path(), m.withRequestMetrics("ext_"+ext.Name, handler))
+		if len(ext.Methods) > 0 {
+			route.Methods(ext.Methods...)
+		}
+	}
+}
+
+// withRecovery recovers from panics in the handler of an extension, which are logged with their stack and answered
+// with an internal server error if nothing was written yet
+func (m *BoostService) withRecovery(extension string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w}
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if err, ok := p.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(p) // aborts the response on purpose
+			}
+			m.metrics.extensionPanics.WithLabelValues(extension).Inc()
+			m.requestLog(req).WithFields(logrus.Fields{
+				"extension": extension,
+				"panic":     fmt.Sprint(p),
+				"stack":     string(debug.Stack()),
+			}).Error("extension handler panicked")
+			if rec.code == 0 {
+				m.respondError(rec, http.StatusInternalServerError, "extension handler failed")
+			}
+		}()
+		handler(rec, req)
+	}
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterExtension(t *testing.T) {
+	backend := newTestBackend(t, 1, time.Second)
+	handler := func(w http.ResponseWriter, req *http.Request) {}
+
+	require.ErrorIs(t, backend.boost.RegisterExtension(Extension{Name: "Tools", Handler: handler}), ErrInvalidExtension)
+	require.ErrorIs(t, backend.boost.RegisterExtension(Extension{Name: "tools", Path: "keys", Handler: handler}), ErrInvalidExtension)
+	require.ErrorIs(t, backend.boost.RegisterExtension(Extension{Name: "tools"}), ErrInvalidExtension)
+
+	require.NoError(t, backend.boost.RegisterExtension(Extension{Name: "tools", Path: "/keys", Methods: []string{http.MethodGet}, Handler: handler}))
+	require.NoError(t, backend.boost.RegisterExtension(Extension{Name: "tools", Path: "/keys", Methods: []string{http.MethodPost}, Handler: handler}))
+	require.ErrorIs(t, backend.boost.RegisterExtension(Extension{Name: "tools", Path: "/keys", Handler: handler}), ErrInvalidExtension)
+}
+
+func TestExtensionEndpoints(t *testing.T) {
+	backend := newTestBackend(t, 1, time.Second)
+	require.NoError(t, backend.boost.RegisterExtension(Extension{
+		Name:    "tools",
+		Path:    "/keys/{id}",
+		Methods: []string{http.MethodGet},
+		Handler: func(w http.ResponseWriter, req *http.Request) {
+			backend.boost.respondOK(w, mux.Vars(req)["id"])
+		},
+	}))
+	require.NoError(t, backend.boost.RegisterExtension(Extension{
+		Name:    "tools",
+		Path:    "/panic",
+		Handler: func(w http.ResponseWriter, req *http.Request) { panic("boom") },
+	}))
+	require.NoError(t, backend.boost.RegisterExtension(Extension{
+		Name:    "slow",
+		Timeout: 10 * time.Millisecond,
+		Handler: func(w http.ResponseWriter, req *http.Request) { <-req.Context().Done() },
+	}))
+	require.NoError(t, backend.boost.RegisterExtension(Extension{
+		Name:    "admin",
+		Admin:   true,
+		Handler: func(w http.ResponseWriter, req *http.Request) {},
+	}))
+
+	rr := backend.request(t, http.MethodGet, "/ext/tools/keys/1", nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.JSONEq(t, `"1"`, rr.Body.String())
+	rr = backend.request(t, http.MethodPost, "/ext/tools/keys/1", nil)
+	require.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+
+	// Panics and timeouts of extensions are isolated
+	rr = backend.request(t, http.MethodGet, "/ext/tools/panic", nil)
+	require.Equal(t, http.StatusInternalServerError, rr.Code)
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.extensionPanics.WithLabelValues("tools")))
+	rr = backend.request(t, http.MethodGet, "/ext/slow", nil)
+	require.Equal(t, http.StatusGatewayTimeout, rr.Code)
+	rr = backend.request(t, http.MethodGet, pathStatus, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.requests.WithLabelValues("ext_tools", fmt.Sprint(http.StatusInternalServerError))))
+
+	// Admin extensions are only mounted with the admin API
+	rr = backend.request(t, http.MethodGet, "/ext/admin", nil)
+	require.Equal(t, http.StatusNotFound, rr.Code)
+	backend.boost.adminAPI = true
+	rr = backend.request(t, http.MethodGet, "/ext/admin", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+}
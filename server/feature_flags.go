@@ -0,0 +1,176 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Feature is a risky behavior which can be toggled per deployment at runtime, without rebuilding
+type Feature string
+
+const (
+	// FeatureConcurrentGetPayload calls the relays for a payload in parallel (staggered by GetPayloadStagger).
+	// Disabled, they are called one after another until one delivers it, so fewer relays see the signed block.
+	FeatureConcurrentGetPayload Feature = "concurrent_get_payload"
+
+	// FeatureBidPrefetch requests bids ahead of the slots of registered validators' proposals, and serves getHeader
+	// from them. Prefetching also requires BeaconNodeURL and GetHeaderPrefetchLeadTime.
+	FeatureBidPrefetch Feature = "bid_prefetch"
+
+	// FeatureGetHeaderCoalescing shares the relay fan-out in flight with identical getHeader calls for the same slot,
+	// parent hash and proposer, and the bids of the requests to a relay in flight with the calls of equivalent
+	// proposers. Disabled, each getHeader call requests the bids from the relays.
+	FeatureGetHeaderCoalescing Feature = "getheader_coalescing"
+)
+
+// defaultFeatures are the known features, with their state if not configured
+var defaultFeatures = map[Feature]bool{
+	FeatureConcurrentGetPayload: true,
+	FeatureBidPrefetch:          true,
+	FeatureGetHeaderCoalescing:  true,
+}
+
+// FeatureFlags are the states of the features, from a list given at startup overridden by an optional file, which
+// is read again on Reload
+type FeatureFlags struct {
+	mu        sync.RWMutex
+	flags     map[Feature]bool // given at startup
+	file      string
+	enabled   map[Feature]bool
+	reloadErr error // of the last reload, while the previous flags are kept
+}
+
+// ParseFeatureFlags parses a comma-separated list of features, each enabled by its name or NAME=true, and disabled
+// by -NAME or NAME=false
+func ParseFeatureFlags(s string) (map[Feature]bool, error) {
+	flags := make(map[Feature]bool)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(entry, "=")
+		enabled := true
+		if found {
+			var err error
+			if enabled, err = strconv.ParseBool(strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidFeatureFlag, entry)
+			}
+		} else if strings.HasPrefix(name, "-") {
+			name, enabled = name[1:], false
+		}
+
+		feature := Feature(strings.ToLower(strings.TrimSpace(name)))
+		if _, ok := defaultFeatures[feature]; !ok {
+			return nil, fmt.Errorf("%w: unknown feature %s", ErrInvalidFeatureFlag, name)
+		}
+		flags[feature] = enabled
+	}
+	return flags, nil
+}
+
+// NewFeatureFlags returns the feature flags given by flags, overridden by the flags in file if set. The file has
+// the format of ParseFeatureFlags, with one or more entries per line. Empty lines and lines starting with # are
+// skipped.
+func NewFeatureFlags(flags map[Feature]bool, file string) (*FeatureFlags, error) {
+	f := &FeatureFlags{flags: flags, file: file}
+	if err := f.Reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Reload reads the file of the feature flags again. On error, the previous flags are kept.
+func (f *FeatureFlags) Reload() error {
+	enabled, err := f.load()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reloadErr = err
+	if err != nil {
+		return err
+	}
+	f.enabled = enabled
+	return nil
+}
+
+// ReloadError returns the error of the last reload, or nil if it succeeded. Nil flags have no error.
+func (f *FeatureFlags) ReloadError() error {
+	if f == nil {
+		return nil
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.reloadErr
+}
+
+// load returns the states of the features, from the flags given at startup and the file
+func (f *FeatureFlags) load() (map[Feature]bool, error) {
+	enabled := make(map[Feature]bool, len(defaultFeatures))
+	for feature, state := range defaultFeatures {
+		enabled[feature] = state
+	}
+	for feature, state := range f.flags {
+		enabled[feature] = state
+	}
+
+	if f.file != "" {
+		data, err := os.ReadFile(f.file)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			flags, err := ParseFeatureFlags(line)
+			if err != nil {
+				return nil, err
+			}
+			for feature, state := range flags {
+				enabled[feature] = state
+			}
+		}
+	}
+	return enabled, nil
+}
+
+// Enabled returns whether a feature is enabled. Nil flags have the default states.
+func (f *FeatureFlags) Enabled(feature Feature) bool {
+	if f == nil {
+		return defaultFeatures[feature]
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.enabled[feature]
+}
+
+// Active returns the enabled features, sorted
+func (f *FeatureFlags) Active() []Feature {
+	active := []Feature{}
+	for feature := range defaultFeatures {
+		if f.Enabled(feature) {
+			active = append(active, feature)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i] < active[j] })
+	return active
+}
+
+// handleFeatureFlags returns the active features, and on POST reloads the feature flags file first
+func (m *BoostService) handleFeatureFlags(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodPost {
+		if err := m.features.Reload(); err != nil {
+			m.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		m.requestLog(req).WithField("features", m.features.Active()).Warn("feature flags reloaded")
+	}
+	m.respondOK(w, m.features.Active())
+}
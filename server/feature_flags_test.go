@@ -0,0 +1,88 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFeatureFlags(t *testing.T) {
+	flags, err := ParseFeatureFlags(" bid_prefetch=false, -concurrent_get_payload ")
+	require.NoError(t, err)
+	require.Equal(t, map[Feature]bool{FeatureBidPrefetch: false, FeatureConcurrentGetPayload: false}, flags)
+
+	flags, err = ParseFeatureFlags("BID_PREFETCH")
+	require.NoError(t, err)
+	require.Equal(t, map[Feature]bool{FeatureBidPrefetch: true}, flags)
+
+	for _, s := range []string{"ssz", "bid_prefetch=maybe", "-"} {
+		_, err = ParseFeatureFlags(s)
+		require.ErrorIs(t, err, ErrInvalidFeatureFlag, s)
+	}
+}
+
+func TestFeatureFlagsReload(t *testing.T) {
+	var nilFlags *FeatureFlags
+	require.Equal(t, []Feature{FeatureBidPrefetch, FeatureConcurrentGetPayload, FeatureGetHeaderCoalescing}, nilFlags.Active())
+
+	file := filepath.Join(t.TempDir(), "features")
+	require.NoError(t, os.WriteFile(file, []byte("# overrides\nbid_prefetch\n"), 0o600))
+	features, err := NewFeatureFlags(map[Feature]bool{FeatureBidPrefetch: false, FeatureConcurrentGetPayload: false, FeatureGetHeaderCoalescing: false}, file)
+	require.NoError(t, err)
+	require.Equal(t, []Feature{FeatureBidPrefetch}, features.Active())
+
+	// The file overrides the flags given at startup, and invalid files keep the previous flags
+	require.NoError(t, os.WriteFile(file, []byte("-bid_prefetch\n"), 0o600))
+	require.NoError(t, features.Reload())
+	require.Empty(t, features.Active())
+	require.NoError(t, os.WriteFile(file, []byte("concurrent_get_payload\nssz\n"), 0o600))
+	require.ErrorIs(t, features.Reload(), ErrInvalidFeatureFlag)
+	require.Empty(t, features.Active())
+}
+
+func TestSequentialGetPayload(t *testing.T) {
+	payload := types.SignedBlindedBeaconBlock{
+		Message: &types.BlindedBeaconBlock{
+			Slot: 1,
+			Body: &types.BlindedBeaconBlockBody{
+				Eth1Data:      &types.Eth1Data{},
+				SyncAggregate: &types.SyncAggregate{},
+				ExecutionPayloadHeader: &types.ExecutionPayloadHeader{
+					BlockHash: _HexToHash("0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab1"),
+				},
+			},
+		},
+	}
+
+	features, err := NewFeatureFlags(map[Feature]bool{FeatureConcurrentGetPayload: false}, "")
+	require.NoError(t, err)
+	backend := newTestBackend(t, 2, time.Second)
+	backend.boost.features = features
+
+	// The second relay is not called once the first delivered the payload
+	rr := backend.request(t, http.MethodPost, pathGetPayload, payload)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, 1, backend.relays[0].GetRequestCount(pathGetPayload))
+	require.Equal(t, 0, backend.relays[1].GetRequestCount(pathGetPayload))
+
+	// It is called if the first fails
+	backend.relays[0].overrideHandleGetPayload(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	rr = backend.request(t, http.MethodPost, pathGetPayload, payload)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, 2, backend.relays[0].GetRequestCount(pathGetPayload))
+	require.Equal(t, 1, backend.relays[1].GetRequestCount(pathGetPayload))
+
+	rr = backend.request(t, http.MethodGet, pathMevBoostStatus, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	status := mevBoostStatusResponse{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &status))
+	require.Equal(t, []Feature{FeatureBidPrefetch, FeatureGetHeaderCoalescing}, status.Features)
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Fork is a consensus fork with its activation epoch
+type Fork struct {
+	Name  string
+	Epoch uint64
+}
+
+// ForkSchedule is a list of forks, ordered by activation epoch
+type ForkSchedule []Fork
+
+// ParseForkSchedule parses a comma-separated list of NAME:EPOCH entries (eg. bellatrix:144896,capella:194048)
+func ParseForkSchedule(s string) (ForkSchedule, error) {
+	ret := ForkSchedule{}
+	if strings.TrimSpace(s) == "" {
+		return ret, nil
+	}
+
+	for _, entry := range strings.Split(s, ",") {
+		name, epochStr, found := strings.Cut(strings.TrimSpace(entry), ":")
+		if !found || name == "" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidForkSchedule, entry)
+		}
+
+		epoch, err := strconv.ParseUint(epochStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidForkSchedule, err.Error())
+		}
+		ret = append(ret, Fork{Name: strings.ToLower(name), Epoch: epoch})
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].Epoch < ret[j].Epoch })
+	return ret, nil
+}
+
+// ForkAtEpoch returns the name of the fork active at the given epoch, or an empty string if no fork is scheduled at
+// or before that epoch
+func (s ForkSchedule) ForkAtEpoch(epoch uint64) string {
+	name := ""
+	for _, fork := range s {
+		if fork.Epoch > epoch {
+			break
+		}
+		name = fork.Name
+	}
+	return name
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseForkSchedule(t *testing.T) {
+	schedule, err := ParseForkSchedule("")
+	require.NoError(t, err)
+	require.Len(t, schedule, 0)
+
+	schedule, err = ParseForkSchedule("capella:10, Bellatrix:0")
+	require.NoError(t, err)
+	require.Equal(t, ForkSchedule{{Name: "bellatrix", Epoch: 0}, {Name: "capella", Epoch: 10}}, schedule)
+
+	_, err = ParseForkSchedule("bellatrix")
+	require.True(t, errors.Is(err, ErrInvalidForkSchedule), err)
+
+	_, err = ParseForkSchedule("bellatrix:abc")
+	require.True(t, errors.Is(err, ErrInvalidForkSchedule), err)
+}
+
+func TestForkAtEpoch(t *testing.T) {
+	schedule := ForkSchedule{{Name: "bellatrix", Epoch: 2}, {Name: "capella", Epoch: 10}}
+
+	testCases := []struct {
+		epoch    uint64
+		expected string
+	}{
+		{0, ""},
+		{1, ""},
+		{2, "bellatrix"},
+		{9, "bellatrix"}, // last bellatrix epoch
+		{10, "capella"},  // first capella epoch
+		{100, "capella"},
+	}
+
+	for _, tt := range testCases {
+		require.Equal(t, tt.expected, schedule.ForkAtEpoch(tt.epoch), "epoch %d", tt.epoch)
+	}
+
+	require.Equal(t, "", ForkSchedule{}.ForkAtEpoch(1))
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+)
+
+// The fuzz targets feed malformed requests of the consensus client through the router. Their seeds run with the
+// test suite, `make fuzz` fuzzes each of them for a while.
+
+const (
+	fuzzParentHash = "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	fuzzPubkey     = "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+)
+
+// fuzzRequest serves a request with the given body, and fails unless the response has one of the given codes
+func fuzzRequest(t *testing.T, backend *testBackend, method, path string, body []byte, codes ...int) {
+	t.Helper()
+	req, err := http.NewRequest(method, path, bytes.NewReader(body))
+	if err != nil {
+		t.Skip(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	backend.boost.getRouter().ServeHTTP(rr, req)
+	for _, code := range codes {
+		if rr.Code == code {
+			return
+		}
+	}
+	t.Fatalf("unexpected status %d for %s %s: %s", rr.Code, method, path, rr.Body.String())
+}
+
+func FuzzGetHeader(f *testing.F) {
+	f.Add("1", fuzzParentHash, fuzzPubkey)
+	f.Add("18446744073709551616", fuzzParentHash, fuzzPubkey)
+	f.Add("-1", fuzzParentHash, fuzzPubkey)
+	f.Add("1", fuzzParentHash[:65]+"z", fuzzPubkey)
+	f.Add("1", fuzzParentHash, "0x"+fuzzPubkey[4:])
+	f.Add("1", "", "")
+	f.Add("1", fuzzParentHash, fuzzPubkey+"/extra")
+
+	backend := newTestBackend(f, 1, time.Second)
+	f.Fuzz(func(t *testing.T, slot, parentHash, pubkey string) {
+		path := "/eth/v1/builder/header/" + url.PathEscape(slot) + "/" + url.PathEscape(parentHash) + "/" + url.PathEscape(pubkey)
+		// Paths which aren't clean, eg. with empty segments, are redirected by the router
+		fuzzRequest(t, backend, http.MethodGet, path, nil, http.StatusOK, http.StatusNoContent, http.StatusMovedPermanently, http.StatusBadRequest, http.StatusNotFound)
+	})
+}
+
+func FuzzRegisterValidator(f *testing.F) {
+	seed, err := json.Marshal([]types.SignedValidatorRegistration{payloadRegisterValidator})
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(seed)
+	f.Add([]byte(`[]`))
+	f.Add([]byte(`null`))
+	f.Add([]byte(`[null]`))
+	f.Add([]byte(`[{}]`))
+	f.Add([]byte(`[{"message":null,"signature":"0x"}]`))
+	f.Add([]byte(`[{"message":{"gas_limit":"-1"}}]`))
+	f.Add([]byte(`{`))
+
+	backend := newTestBackend(f, 1, time.Second)
+	f.Fuzz(func(t *testing.T, body []byte) {
+		fuzzRequest(t, backend, http.MethodPost, pathRegisterValidator, body, http.StatusOK, http.StatusBadRequest, http.StatusTooManyRequests, http.StatusBadGateway)
+	})
+}
+
+func FuzzGetPayload(f *testing.F) {
+	seed, err := json.Marshal(types.SignedBlindedBeaconBlock{
+		Message: &types.BlindedBeaconBlock{
+			Slot: 1,
+			Body: &types.BlindedBeaconBlockBody{
+				Eth1Data:               &types.Eth1Data{},
+				SyncAggregate:          &types.SyncAggregate{},
+				ExecutionPayloadHeader: &types.ExecutionPayloadHeader{BlockHash: _HexToHash(fuzzParentHash)},
+			},
+		},
+	})
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(seed)
+	f.Add([]byte(`null`))
+	f.Add([]byte(`{}`))
+	f.Add([]byte(`{"message":null}`))
+	f.Add([]byte(`{"message":{"body":null}}`))
+	f.Add([]byte(`{"message":{"slot":"18446744073709551615","body":{"execution_payload_header":{}}}}`))
+	f.Add([]byte(`[]`))
+
+	backend := newTestBackend(f, 1, time.Second)
+	f.Fuzz(func(t *testing.T, body []byte) {
+		fuzzRequest(t, backend, http.MethodPost, pathGetPayload, body, http.StatusOK, http.StatusBadRequest, http.StatusBadGateway)
+	})
+}
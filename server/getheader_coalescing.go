@@ -0,0 +1,205 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// relayHeaderKey identifies the getHeader requests to a relay whose response can be shared
+type relayHeaderKey struct {
+	relay      string
+	slot       uint64
+	parentHash string
+	proposer   string // of equivalent proposers, see relayHeaderProposer
+}
+
+// relayHeaderCall is a getHeader request to a relay in flight, whose response is shared with equivalent requests
+type relayHeaderCall struct {
+	done   chan struct{}
+	pubkey string // of the proposer the request was made for
+	code   int
+	header http.Header
+	resp   *GetHeaderResponse
+	err    error
+	ctxErr error // of the call which made the request, once it returned
+}
+
+// hasBid returns whether the relay responded with a bid
+func (c *relayHeaderCall) hasBid() bool {
+	return c.err == nil && c.code == http.StatusOK && c.resp != nil && c.resp.Data != nil
+}
+
+// relayHeaderCalls are the getHeader requests to relays in flight
+type relayHeaderCalls struct {
+	mu    sync.Mutex
+	calls map[relayHeaderKey]*relayHeaderCall
+}
+
+func newRelayHeaderCalls() *relayHeaderCalls {
+	return &relayHeaderCalls{calls: make(map[relayHeaderKey]*relayHeaderCall)}
+}
+
+// do calls fn with the request for pubkey made with ctx, or waits for the equivalent call in flight and returns its
+// results, with whether they are shared. Only one of equivalent proposers is the proposer of the slot, so the call in
+// flight for another proposer is only shared if the relay responded with a bid; otherwise fn is called for pubkey.
+// The cancellation of the call in flight is not shared either.
+func (c *relayHeaderCalls) do(ctx context.Context, key relayHeaderKey, pubkey string, fn func() (int, http.Header, *GetHeaderResponse, error)) (*relayHeaderCall, bool) {
+	c.mu.Lock()
+	for {
+		call, ok := c.calls[key]
+		if !ok {
+			break
+		}
+		c.mu.Unlock()
+		<-call.done
+		if call.ctxErr == nil && (call.pubkey == pubkey || call.hasBid()) {
+			return call, true
+		}
+		c.mu.Lock()
+	}
+	call := &relayHeaderCall{done: make(chan struct{}), pubkey: pubkey}
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	call.code, call.header, call.resp, call.err = fn()
+	call.ctxErr = ctx.Err()
+	c.mu.Lock()
+	delete(c.calls, key)
+	c.mu.Unlock()
+	close(call.done)
+	return call, false
+}
+
+// getHeaderKey identifies the getHeader calls whose relay fan-out can be shared
+type getHeaderKey struct {
+	slot       uint64
+	parentHash string
+	pubkey     string
+}
+
+// getHeaderCall is a relay fan-out in flight, whose result is shared with identical getHeader calls
+type getHeaderCall struct {
+	done   chan struct{}
+	result getHeaderResult
+	ctxErr error // of the call which made the fan-out, once it returned
+}
+
+// getHeaderCalls are the relay fan-outs in flight
+type getHeaderCalls struct {
+	mu    sync.Mutex
+	calls map[getHeaderKey]*getHeaderCall
+}
+
+func newGetHeaderCalls() *getHeaderCalls {
+	return &getHeaderCalls{calls: make(map[getHeaderKey]*getHeaderCall)}
+}
+
+// do calls fn with the fan-out made with ctx, or waits for the identical call in flight and returns its result, with
+// whether it is shared. Waiting stops when waitCtx is done, with no bid. If the call in flight was cancelled but ctx
+// wasn't, fn is called again rather than returning the result of the cancelled fan-out.
+func (c *getHeaderCalls) do(ctx, waitCtx context.Context, key getHeaderKey, fn func() getHeaderResult) (getHeaderResult, bool) {
+	c.mu.Lock()
+	for {
+		call, ok := c.calls[key]
+		if !ok {
+			break
+		}
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+		case <-waitCtx.Done():
+			return getHeaderResult{}, true
+		}
+		if call.ctxErr == nil {
+			return call.result, true
+		}
+		c.mu.Lock()
+	}
+	call := &getHeaderCall{done: make(chan struct{})}
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	call.result = fn()
+	call.ctxErr = ctx.Err()
+	c.mu.Lock()
+	delete(c.calls, key)
+	c.mu.Unlock()
+	close(call.done)
+	return call.result, false
+}
+
+// requestBidsCoalesced is requestBids, except that with FeatureGetHeaderCoalescing, identical calls in flight (eg.
+// from redundant consensus clients of the same proposer, retries, or a prefetch) share a single relay fan-out, and
+// get the same validated bid. The fan-out is made with ctx, and calls wait for the identical call in flight until
+// waitCtx is done, eg. with the getHeader request.
+func (m *BoostService) requestBidsCoalesced(ctx, waitCtx context.Context, log *logrus.Entry, slot uint64, parentHashHex, pubkey string, ua UserAgent) getHeaderResult {
+	if !m.features.Enabled(FeatureGetHeaderCoalescing) {
+		return m.requestBids(ctx, log, slot, parentHashHex, pubkey, ua)
+	}
+
+	key := getHeaderKey{slot: slot, parentHash: strings.ToLower(parentHashHex), pubkey: strings.ToLower(pubkey)}
+	result, shared := m.getHeaderCalls.do(ctx, waitCtx, key, func() getHeaderResult {
+		return m.requestBids(ctx, log, slot, parentHashHex, pubkey, ua)
+	})
+	if shared {
+		m.metrics.getHeaderCoalesced.Inc()
+		log.Debug("shared the relay fan-out of an identical getHeader call")
+	}
+	return result
+}
+
+// relayHeaderProposer identifies the proposers whose getHeader requests to a relay get equivalent bids: those
+// registered with the same fee recipient and gas limit, which is what the builders build the block for. Proposers
+// without a registration with this instance are only equivalent to themselves.
+func (m *BoostService) relayHeaderProposer(pubkey string) string {
+	pubkey = strings.ToLower(pubkey)
+	m.registrationsLock.Lock()
+	defer m.registrationsLock.Unlock()
+	registration, ok := m.registrations[pubkey]
+	if !ok || registration.Message == nil {
+		return pubkey
+	}
+	return fmt.Sprintf("%s/%d", strings.ToLower(registration.Message.FeeRecipient.String()), registration.Message.GasLimit)
+}
+
+// getRelayHeader requests a bid from a relay into dst. With FeatureGetHeaderCoalescing, the requests in flight to the
+// relay for the same slot and parent hash are shared between equivalent proposers (eg. validators of the same node
+// with the same fee recipient and gas limit) if the relay responds with a bid, which only applies to the relays the
+// proposers have in common. Each call validates the shared bid against its own proposer's settings (minimum bid, bid
+// policies, ...).
+func (m *BoostService) getRelayHeader(ctx context.Context, relay RelayEntry, slot uint64, parentHashHex, pubkey string, ua UserAgent, dst *GetHeaderResponse) (int, http.Header, error) {
+	url := relay.GetURI(fmt.Sprintf("/eth/v1/builder/header/%d/%s/%s", slot, parentHashHex, pubkey))
+	if !m.features.Enabled(FeatureGetHeaderCoalescing) {
+		return sendHTTPRequest(ctx, m.relayClient(relay), http.MethodGet, url, ua, nil, dst, m.relayResponseOpts(relay))
+	}
+
+	key := relayHeaderKey{relay: relay.String(), slot: slot, parentHash: strings.ToLower(parentHashHex), proposer: m.relayHeaderProposer(pubkey)}
+	call, shared := m.relayHeaderCalls.do(ctx, key, strings.ToLower(pubkey), func() (int, http.Header, *GetHeaderResponse, error) {
+		resp := new(GetHeaderResponse)
+		code, header, err := sendHTTPRequest(ctx, m.relayClient(relay), http.MethodGet, url, ua, nil, resp, m.relayResponseOpts(relay))
+		return code, header, resp, err
+	})
+	if !shared {
+		*dst = *call.resp
+		return call.code, call.header, call.err
+	}
+
+	// Each request gets its own copy of the response, as the bids are processed independently
+	m.metrics.relayHeadersShared.WithLabelValues(relay.String()).Inc()
+	if call.err == nil && call.code != http.StatusNoContent {
+		data, err := json.Marshal(call.resp)
+		if err != nil {
+			return 0, nil, err
+		}
+		if err := json.Unmarshal(data, dst); err != nil {
+			return 0, nil, err
+		}
+	}
+	return call.code, call.header.Clone(), call.err
+}
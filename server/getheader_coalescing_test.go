@@ -0,0 +1,200 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetHeaderCoalescing(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	otherPubkey := "0xac6e77dfe25ecd6110b8e780608cce0dab71fdd5ebea22a16c0205200f2f8e2e3ad3b71d3499c54ad14d6c21b41a37ae"
+	path := fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey)
+	otherPath := fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, otherPubkey)
+
+	register := func(backend *testBackend, pubkey string, feeRecipient types.Address, gasLimit uint64) {
+		backend.boost.registrations[pubkey] = types.SignedValidatorRegistration{
+			Message: &types.RegisterValidatorRequestMessage{Pubkey: _HexToPubkey(pubkey), FeeRecipient: feeRecipient, GasLimit: gasLimit},
+		}
+	}
+
+	getHeaders := func(t *testing.T, backend *testBackend, paths ...string) []int {
+		t.Helper()
+		codes := make([]int, len(paths))
+		var wg sync.WaitGroup
+		for i, path := range paths {
+			wg.Add(1)
+			go func(i int, path string) {
+				defer wg.Done()
+				codes[i] = backend.request(t, http.MethodGet, path, nil).Code
+			}(i, path)
+		}
+		wg.Wait()
+		return codes
+	}
+
+	t.Run("Identical requests share the relay fan-out", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		relay := backend.relays[0]
+		relay.ResponseDelay = 100 * time.Millisecond
+		relay.GetHeaderResponse = relay.MakeGetHeaderResponse(12345, hash, pubkey)
+
+		codes := getHeaders(t, backend, path, path)
+		require.Equal(t, []int{http.StatusOK, http.StatusOK}, codes)
+		require.Equal(t, 1, relay.GetRequestCount(path))
+		require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.getHeaderCoalesced))
+	})
+
+	t.Run("Identical requests share the relay request", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		relay := backend.relays[0]
+		relay.ResponseDelay = 100 * time.Millisecond
+		relay.GetHeaderResponse = relay.MakeGetHeaderResponse(12345, hash, pubkey)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				code, _, err := backend.boost.getRelayHeader(context.Background(), relay.RelayEntry, 1, hash, pubkey, "", new(GetHeaderResponse))
+				require.NoError(t, err)
+				require.Equal(t, http.StatusOK, code)
+			}()
+		}
+		wg.Wait()
+		require.Equal(t, 1, relay.GetRequestCount(path))
+		require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.relayHeadersShared.WithLabelValues(relay.RelayEntry.String())))
+	})
+
+	t.Run("Requests for different proposers are not shared", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		relay := backend.relays[0]
+		relay.ResponseDelay = 100 * time.Millisecond
+		register(backend, pubkey, types.Address{0x01}, 30000000)
+		register(backend, otherPubkey, types.Address{0x01}, 36000000)
+
+		getHeaders(t, backend, path, otherPath)
+		require.Equal(t, 1, relay.GetRequestCount(path))
+		require.Equal(t, 1, relay.GetRequestCount(otherPath))
+		require.Equal(t, 0.0, testutil.ToFloat64(backend.boost.metrics.relayHeadersShared.WithLabelValues(relay.RelayEntry.String())))
+	})
+
+	t.Run("Requests for equivalent proposers are shared", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		relay := backend.relays[0]
+		relay.ResponseDelay = 100 * time.Millisecond
+		relay.GetHeaderResponse = relay.MakeGetHeaderResponse(12345, hash, pubkey)
+		register(backend, pubkey, types.Address{0x01}, 30000000)
+		register(backend, otherPubkey, types.Address{0x01}, 30000000)
+
+		// The shared bid is validated against the settings of each proposer
+		backend.boost.proposerConfig = newProposerConfigStore(&ProposerConfig{
+			Proposers: map[string]ProposerSettings{otherPubkey: {minBid: big.NewInt(12346)}},
+		})
+		codes := getHeaders(t, backend, path, otherPath)
+		require.Equal(t, []int{http.StatusOK, http.StatusNoContent}, codes)
+		require.Equal(t, 1, relay.GetRequestCount(path)+relay.GetRequestCount(otherPath))
+		require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.relayHeadersShared.WithLabelValues(relay.RelayEntry.String())))
+	})
+
+	t.Run("Requests for equivalent proposers without a bid are not shared", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		relay := backend.relays[0]
+		relay.ResponseDelay = 100 * time.Millisecond
+		bid := relay.MakeGetHeaderResponse(12345, hash, pubkey)
+		relay.overrideHandleGetHeader(func(w http.ResponseWriter, req *http.Request) {
+			// The relay only has a bid for the proposer of the slot
+			if strings.Contains(req.URL.Path, otherPubkey) {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			require.NoError(t, json.NewEncoder(w).Encode(bid))
+		})
+		register(backend, pubkey, types.Address{0x01}, 30000000)
+		register(backend, otherPubkey, types.Address{0x01}, 30000000)
+
+		done := make(chan int)
+		go func() {
+			code, _, _ := backend.boost.getRelayHeader(context.Background(), relay.RelayEntry, 1, hash, otherPubkey, "", new(GetHeaderResponse))
+			done <- code
+		}()
+		require.Eventually(t, func() bool { return relay.GetRequestCount(otherPath) == 1 }, time.Second, 5*time.Millisecond)
+
+		code, _, err := backend.boost.getRelayHeader(context.Background(), relay.RelayEntry, 1, hash, pubkey, "", new(GetHeaderResponse))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, http.StatusNoContent, <-done)
+		require.Equal(t, 1, relay.GetRequestCount(path))
+		require.Equal(t, 0.0, testutil.ToFloat64(backend.boost.metrics.relayHeadersShared.WithLabelValues(relay.RelayEntry.String())))
+	})
+
+	t.Run("Waiting stops with the getHeader request", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		relay := backend.relays[0]
+		relay.ResponseDelay = 300 * time.Millisecond
+		relay.GetHeaderResponse = relay.MakeGetHeaderResponse(12345, hash, pubkey)
+
+		done := make(chan int)
+		go func() {
+			done <- backend.request(t, http.MethodGet, path, nil).Code
+		}()
+		require.Eventually(t, func() bool { return relay.GetRequestCount(path) == 1 }, time.Second, 5*time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		req := httptest.NewRequest(http.MethodGet, path, nil).WithContext(ctx)
+		rr := httptest.NewRecorder()
+		start := time.Now()
+		backend.boost.getRouter().ServeHTTP(rr, req)
+		require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+		require.Less(t, time.Since(start), relay.ResponseDelay)
+
+		// The fan-out is not cancelled with the request which waited for it
+		require.Equal(t, http.StatusOK, <-done)
+		require.Equal(t, 1, relay.GetRequestCount(path))
+	})
+
+	t.Run("Cancellation of the call in flight is not shared", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		relay := backend.relays[0]
+		relay.ResponseDelay = 100 * time.Millisecond
+		relay.GetHeaderResponse = relay.MakeGetHeaderResponse(12345, hash, pubkey)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		go backend.boost.getRelayHeader(ctx, relay.RelayEntry, 1, hash, pubkey, "", new(GetHeaderResponse))
+		require.Eventually(t, func() bool { return relay.GetRequestCount(path) == 1 }, time.Second, 5*time.Millisecond)
+
+		code, _, err := backend.boost.getRelayHeader(context.Background(), relay.RelayEntry, 1, hash, pubkey, "", new(GetHeaderResponse))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, 2, relay.GetRequestCount(path))
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		features, err := NewFeatureFlags(map[Feature]bool{FeatureGetHeaderCoalescing: false}, "")
+		require.NoError(t, err)
+		backend.boost.features = features
+		relay := backend.relays[0]
+		relay.ResponseDelay = 100 * time.Millisecond
+		relay.GetHeaderResponse = relay.MakeGetHeaderResponse(12345, hash, pubkey)
+
+		codes := getHeaders(t, backend, path, path)
+		require.Equal(t, []int{http.StatusOK, http.StatusOK}, codes)
+		require.Equal(t, 2, relay.GetRequestCount(path))
+	})
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"strings"
+	"time"
+)
+
+// pastGetHeaderDeadline returns whether a getHeader call at time t is past the response deadline of the slot, after
+// which no bids are requested from the relays
+func (m *BoostService) pastGetHeaderDeadline(slot uint64, t time.Time) bool {
+	if m.getHeaderRespDeadline <= 0 || m.genesisTime == 0 {
+		return false
+	}
+	return !t.Before(m.chain.slotStartTime(m.genesisTime, slot).Add(m.getHeaderRespDeadline))
+}
+
+// servedBid returns the most valuable bid already served for the getHeader request, if any
+func (m *BoostService) servedBid(slot uint64, parentHash, pubkey string) (getHeaderResult, bool) {
+	m.bidsLock.Lock()
+	defer m.bidsLock.Unlock()
+
+	var result getHeaderResult
+	found := false
+	for key, bid := range m.bids {
+		if key.slot != slot || !strings.EqualFold(bid.pubkey, pubkey) ||
+			!strings.EqualFold(bid.response.Data.Message.Header.ParentHash.String(), parentHash) {
+			continue
+		}
+		if !found || bid.valueWei.Cmp(result.bid.valueWei) > 0 {
+			result = getHeaderResult{bid: bid, numBids: len(bid.relays)}
+			for _, rejection := range bid.rejections {
+				if rejection.Reason == BidRejectionLowerValue { // valid bids which were outbid
+					result.numBids++
+				}
+			}
+			found = true
+		}
+	}
+	result.cohort, _ = m.cohortRelays(slot, pubkey, nil)
+	return result, found
+}
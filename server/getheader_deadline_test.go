@@ -0,0 +1,63 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPastGetHeaderDeadline(t *testing.T) {
+	backend := newTestBackend(t, 1, time.Second)
+	start := ChainEthereum.slotStartTime(1000, 2)
+	require.False(t, backend.boost.pastGetHeaderDeadline(2, start.Add(time.Hour)))
+
+	backend.boost.getHeaderRespDeadline = 3 * time.Second
+	require.False(t, backend.boost.pastGetHeaderDeadline(2, start.Add(time.Hour))) // unknown genesis time
+	backend.boost.genesisTime = 1000
+	require.False(t, backend.boost.pastGetHeaderDeadline(2, start.Add(2*time.Second)))
+	require.True(t, backend.boost.pastGetHeaderDeadline(2, start.Add(3*time.Second)))
+}
+
+func TestGetHeaderAfterDeadline(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	path := fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey)
+	path2 := fmt.Sprintf("/eth/v1/builder/header/2/%s/%s", hash, pubkey)
+
+	backend := newTestBackend(t, 1, time.Second)
+	backend.boost.getHeaderRespDeadline = time.Second
+	backend.boost.genesisTime = 1606824023
+	clock := newFakeClock(ChainEthereum.slotStartTime(1606824023, 1).Add(500 * time.Millisecond))
+	backend.boost.clock = clock
+	relay := backend.relays[0]
+	relay.GetHeaderResponse = relay.MakeGetHeaderResponse(12345, hash, pubkey)
+	relay.GetHeaderResponse.Data.Message.Header.Timestamp = 1606824023 + SecondsPerSlot // slot 1
+	signature, err := types.SignMessage(relay.GetHeaderResponse.Data.Message, types.DomainBuilder, mockRelaySecretKey)
+	require.NoError(t, err)
+	relay.GetHeaderResponse.Data.Signature = signature
+
+	// Bids are requested before the deadline
+	rr := backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, 1, relay.GetRequestCount(path))
+
+	// CL retries after the deadline get the bid already served
+	clock.advance(time.Second)
+	rr = backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Contains(t, rr.Body.String(), `"value":"12345"`)
+	require.Equal(t, 1, relay.GetRequestCount(path))
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.lateGetHeader.WithLabelValues("true")))
+
+	// No bid was served for the next slot yet, and no bids are requested after its deadline
+	clock.advance(ChainEthereum.slotDuration())
+	rr = backend.request(t, http.MethodGet, path2, nil)
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	require.Equal(t, 0, relay.GetRequestCount(path2))
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.lateGetHeader.WithLabelValues("false")))
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"sync"
+)
+
+// setIdempotencyHeaders sets the checksum of the request body, and the idempotency key, which is derived from the
+// method, path and body so that retries of a request have the same key
+func setIdempotencyHeaders(req *http.Request, body []byte) {
+	digest := sha256.Sum256(body)
+	req.Header.Set(HeaderContentDigest, contentDigest(digest))
+
+	key := sha256.New()
+	key.Write([]byte(req.Method + " " + req.URL.Path + "\n"))
+	key.Write(digest[:])
+	req.Header.Set(HeaderIdempotencyKey, hex.EncodeToString(key.Sum(nil)[:16]))
+}
+
+// contentDigest returns the value of the Content-Digest header of a body with the given SHA-256
+func contentDigest(digest [sha256.Size]byte) string {
+	return "sha-256=:" + base64.StdEncoding.EncodeToString(digest[:]) + ":"
+}
+
+// idempotencySupport records which relays honor idempotency keys, by relay URL
+type idempotencySupport struct {
+	mu        sync.RWMutex
+	supported map[string]bool
+}
+
+func newIdempotencySupport() *idempotencySupport {
+	return &idempotencySupport{supported: make(map[string]bool)}
+}
+
+// get returns whether a relay honored the idempotency key of its last response to a POST request
+func (s *idempotencySupport) get(relay string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.supported[relay]
+}
+
+// recordIdempotency records whether a relay honors idempotency keys, from the header of its response to a POST
+// request. Failed requests without a response are not recorded.
+func (m *BoostService) recordIdempotency(relay RelayEntry, header http.Header) {
+	if header == nil {
+		return
+	}
+	supported := header.Get(HeaderIdempotencyKey) != ""
+
+	m.idempotency.mu.Lock()
+	m.idempotency.supported[relay.String()] = supported
+	m.idempotency.mu.Unlock()
+
+	value := 0.0
+	if supported {
+		value = 1
+	}
+	m.metrics.relayIdempotency.WithLabelValues(relay.String()).Set(value)
+}
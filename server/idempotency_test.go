@@ -0,0 +1,71 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetIdempotencyHeaders(t *testing.T) {
+	newRequest := func(path string, body []byte) *http.Request {
+		req, err := http.NewRequest(http.MethodPost, "http://relay"+path, bytes.NewReader(body))
+		require.NoError(t, err)
+		setIdempotencyHeaders(req, body)
+		return req
+	}
+	req := newRequest(pathRegisterValidator, []byte("[]"))
+	require.Equal(t, "sha-256=:T1PNoYwrqgwDVLtfmj7L5e0Sq02OEbqHPC8RFhICuUU=:", req.Header.Get(HeaderContentDigest))
+	require.Len(t, req.Header.Get(HeaderIdempotencyKey), 32)
+
+	// Retries have the same key, other bodies and paths another
+	require.Equal(t, req.Header.Get(HeaderIdempotencyKey), newRequest(pathRegisterValidator, []byte("[]")).Header.Get(HeaderIdempotencyKey))
+	require.NotEqual(t, req.Header.Get(HeaderIdempotencyKey), newRequest(pathRegisterValidator, []byte("[{}]")).Header.Get(HeaderIdempotencyKey))
+	require.NotEqual(t, req.Header.Get(HeaderIdempotencyKey), newRequest(pathGetPayload, []byte("[]")).Header.Get(HeaderIdempotencyKey))
+}
+
+func TestRelayIdempotencySupport(t *testing.T) {
+	backend := newTestBackend(t, 2, time.Second)
+	var mu sync.Mutex
+	keys := []string{}
+	backend.relays[0].overrideHandleRegisterValidator(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		keys = append(keys, req.Header.Get(HeaderIdempotencyKey))
+		mu.Unlock()
+		require.NotEmpty(t, req.Header.Get(HeaderContentDigest))
+		w.Header().Set(HeaderIdempotencyKey, req.Header.Get(HeaderIdempotencyKey))
+		w.WriteHeader(http.StatusOK)
+	})
+
+	payload := []types.SignedValidatorRegistration{payloadRegisterValidator}
+	for i := 0; i < 2; i++ {
+		rr := backend.request(t, http.MethodPost, pathRegisterValidator, payload)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	}
+	// The response does not wait for all relays
+	relay0, relay1 := backend.relays[0].RelayEntry.String(), backend.relays[1].RelayEntry.String()
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(keys) == 2 && testutil.CollectAndCount(backend.boost.metrics.relayIdempotency) == 2
+	}, time.Second, time.Millisecond)
+	require.Equal(t, keys[0], keys[1])
+
+	require.True(t, backend.boost.idempotency.get(relay0))
+	require.False(t, backend.boost.idempotency.get(relay1))
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.relayIdempotency.WithLabelValues(relay0)))
+	require.Equal(t, 0.0, testutil.ToFloat64(backend.boost.metrics.relayIdempotency.WithLabelValues(relay1)))
+
+	rr := backend.request(t, http.MethodGet, pathMevBoostStatus, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	status := mevBoostStatusResponse{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &status))
+	require.True(t, status.Relays[0].Idempotency)
+	require.False(t, status.Relays[1].Idempotency)
+}
@@ -0,0 +1,213 @@
+package server
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/flashbots/go-boost-utils/types"
+)
+
+// JSONCodec encodes and decodes the JSON messages exchanged with the consensus client and the relays
+type JSONCodec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSON codec names
+const (
+	JSONCodecStd  = "std"
+	JSONCodecFast = "fast"
+)
+
+// JSONCodecByName returns the JSON codec with the given name: std (encoding/json), or fast (encoding/json with
+// hand-written encoders for the getHeader responses and registration batches)
+func JSONCodecByName(name string) (JSONCodec, error) {
+	switch name {
+	case JSONCodecStd, "":
+		return stdJSONCodec{}, nil
+	case JSONCodecFast:
+		return fastJSONCodec{}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrInvalidJSONCodec, name)
+}
+
+// stdJSONCodec is encoding/json
+type stdJSONCodec struct{}
+
+func (stdJSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (stdJSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// fastJSONCodec encodes the messages of the hot paths without reflection, with the same output as encoding/json.
+// Other messages, and all decoding, use encoding/json.
+type fastJSONCodec struct{}
+
+func (fastJSONCodec) Marshal(v any) ([]byte, error) {
+	switch v := v.(type) {
+	case GetHeaderResponse:
+		return appendGetHeaderResponse(make([]byte, 0, 2048), &v), nil
+	case *GetHeaderResponse:
+		if v == nil {
+			return []byte("null"), nil
+		}
+		return appendGetHeaderResponse(make([]byte, 0, 2048), v), nil
+	case []types.SignedValidatorRegistration:
+		return appendRegistrations(make([]byte, 0, 400*len(v)+2), v), nil
+	}
+	return json.Marshal(v)
+}
+
+func (fastJSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// marshalJSON encodes v with codec, or with encoding/json if codec is nil
+func marshalJSON(codec JSONCodec, v any) ([]byte, error) {
+	if codec == nil {
+		return json.Marshal(v)
+	}
+	return codec.Marshal(v)
+}
+
+// unmarshalJSON decodes data with codec, or with encoding/json if codec is nil
+func unmarshalJSON(codec JSONCodec, data []byte, v any) error {
+	if codec == nil {
+		return json.Unmarshal(data, v)
+	}
+	return codec.Unmarshal(data, v)
+}
+
+func appendGetHeaderResponse(b []byte, r *GetHeaderResponse) []byte {
+	version, _ := json.Marshal(string(r.Version))
+	b = append(b, `{"version":`...)
+	b = append(b, version...)
+	b = append(b, `,"data":`...)
+	if r.Data == nil {
+		return append(b, "null}"...)
+	}
+	b = append(b, `{"message":`...)
+	if bid := r.Data.Message; bid == nil {
+		b = append(b, "null"...)
+	} else {
+		b = append(b, `{"header":`...)
+		b = appendExecutionPayloadHeader(b, bid.Header)
+		b = appendField(b, "value")
+		b = appendU256(b, &bid.Value)
+		b = appendField(b, "pubkey")
+		b = appendHex(b, bid.Pubkey[:])
+		b = append(b, '}')
+	}
+	b = appendField(b, "signature")
+	b = appendHex(b, r.Data.Signature[:])
+	return append(b, "}}"...)
+}
+
+func appendExecutionPayloadHeader(b []byte, h *ExecutionPayloadHeader) []byte {
+	if h == nil {
+		return append(b, "null"...)
+	}
+	b = append(b, `{"parent_hash":`...)
+	b = appendHex(b, h.ParentHash[:])
+	b = appendField(b, "fee_recipient")
+	b = appendHex(b, h.FeeRecipient[:])
+	b = appendField(b, "state_root")
+	b = appendHex(b, h.StateRoot[:])
+	b = appendField(b, "receipts_root")
+	b = appendHex(b, h.ReceiptsRoot[:])
+	b = appendField(b, "logs_bloom")
+	b = appendHex(b, h.LogsBloom[:])
+	b = appendField(b, "prev_randao")
+	b = appendHex(b, h.Random[:])
+	b = appendField(b, "block_number")
+	b = appendUint64String(b, h.BlockNumber)
+	b = appendField(b, "gas_limit")
+	b = appendUint64String(b, h.GasLimit)
+	b = appendField(b, "gas_used")
+	b = appendUint64String(b, h.GasUsed)
+	b = appendField(b, "timestamp")
+	b = appendUint64String(b, h.Timestamp)
+	b = appendField(b, "extra_data")
+	b = appendHex(b, h.ExtraData)
+	b = appendField(b, "base_fee_per_gas")
+	b = appendU256(b, &h.BaseFeePerGas)
+	b = appendField(b, "block_hash")
+	b = appendHex(b, h.BlockHash[:])
+	b = appendField(b, "transactions_root")
+	b = appendHex(b, h.TransactionsRoot[:])
+	if h.WithdrawalsRoot != nil {
+		b = appendField(b, "withdrawals_root")
+		b = appendHex(b, h.WithdrawalsRoot[:])
+	}
+	return append(b, '}')
+}
+
+func appendRegistrations(b []byte, registrations []types.SignedValidatorRegistration) []byte {
+	if registrations == nil {
+		return append(b, "null"...)
+	}
+	b = append(b, '[')
+	for i := range registrations {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = append(b, `{"message":`...)
+		if m := registrations[i].Message; m == nil {
+			b = append(b, "null"...)
+		} else {
+			b = append(b, `{"fee_recipient":`...)
+			b = appendHex(b, m.FeeRecipient[:])
+			b = appendField(b, "gas_limit")
+			b = appendUint64String(b, m.GasLimit)
+			b = appendField(b, "timestamp")
+			b = appendUint64String(b, m.Timestamp)
+			b = appendField(b, "pubkey")
+			b = appendHex(b, m.Pubkey[:])
+			b = append(b, '}')
+		}
+		b = appendField(b, "signature")
+		b = appendHex(b, registrations[i].Signature[:])
+		b = append(b, '}')
+	}
+	return append(b, ']')
+}
+
+// appendField appends the separator and name of a field following another
+func appendField(b []byte, name string) []byte {
+	b = append(b, `,"`...)
+	b = append(b, name...)
+	return append(b, `":`...)
+}
+
+// appendHex appends data as a quoted 0x-prefixed hex string
+func appendHex(b []byte, data []byte) []byte {
+	b = append(b, `"0x`...)
+	n := len(b)
+	b = append(b, make([]byte, hex.EncodedLen(len(data)))...)
+	hex.Encode(b[n:], data)
+	return append(b, '"')
+}
+
+// appendUint64String appends n as a quoted decimal string
+func appendUint64String(b []byte, n uint64) []byte {
+	b = append(b, '"')
+	b = strconv.AppendUint(b, n, 10)
+	return append(b, '"')
+}
+
+// appendU256 appends a little-endian 256-bit number as a quoted decimal string
+func appendU256(b []byte, n *types.U256Str) []byte {
+	var be [32]byte
+	for i := range n {
+		be[len(be)-1-i] = n[i]
+	}
+	b = append(b, '"')
+	b = new(big.Int).SetBytes(be[:]).Append(b, 10)
+	return append(b, '"')
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+// testGetHeaderResponse returns a getHeader response with all fields set
+func testGetHeaderResponse(t testing.TB) *GetHeaderResponse {
+	t.Helper()
+	value, ok := new(big.Int).SetString("123456789012345678901234567890", 10)
+	require.True(t, ok)
+	bid := &BuilderBid{
+		Header: &ExecutionPayloadHeader{ExecutionPayloadHeader: types.ExecutionPayloadHeader{
+			ParentHash:       types.Hash{1},
+			FeeRecipient:     types.Address{2},
+			StateRoot:        types.Root{3},
+			ReceiptsRoot:     types.Root{4},
+			LogsBloom:        types.Bloom{5},
+			Random:           types.Hash{6},
+			BlockNumber:      7,
+			GasLimit:         30_000_000,
+			GasUsed:          9,
+			Timestamp:        1_606_824_023,
+			ExtraData:        types.ExtraData("<builder>"),
+			BaseFeePerGas:    types.IntToU256(7),
+			BlockHash:        types.Hash{0xab},
+			TransactionsRoot: types.Root{0xcd},
+		}},
+		Pubkey: types.PublicKey{0xef},
+	}
+	require.NoError(t, bid.Value.FromBig(value))
+	return &GetHeaderResponse{
+		Version: "bellatrix",
+		Data:    &SignedBuilderBid{Message: bid, Signature: types.Signature{0x12}},
+	}
+}
+
+// testRegistrations returns n registrations with all fields set
+func testRegistrations(n int) []types.SignedValidatorRegistration {
+	registrations := make([]types.SignedValidatorRegistration, n)
+	for i := range registrations {
+		registrations[i] = types.SignedValidatorRegistration{
+			Message: &types.RegisterValidatorRequestMessage{
+				FeeRecipient: types.Address{byte(i)},
+				GasLimit:     30_000_000,
+				Timestamp:    uint64(1_606_824_023 + i),
+				Pubkey:       types.PublicKey{byte(i), byte(i >> 8)},
+			},
+			Signature: types.Signature{byte(i)},
+		}
+	}
+	return registrations
+}
+
+func TestJSONCodecByName(t *testing.T) {
+	codec, err := JSONCodecByName("")
+	require.NoError(t, err)
+	require.Equal(t, stdJSONCodec{}, codec)
+	codec, err = JSONCodecByName(JSONCodecFast)
+	require.NoError(t, err)
+	require.Equal(t, fastJSONCodec{}, codec)
+	_, err = JSONCodecByName("jsoniter")
+	require.ErrorIs(t, err, ErrInvalidJSONCodec)
+}
+
+func TestFastJSONCodec(t *testing.T) {
+	response := testGetHeaderResponse(t)
+	emptyResponse := testGetHeaderResponse(t)
+	emptyResponse.Data.Message.Header = nil
+	capellaResponse := testGetHeaderResponse(t)
+	capellaResponse.Version = versionCapella
+	capellaResponse.Data.Message.Header.WithdrawalsRoot = &types.Root{0xef}
+	registrations := testRegistrations(3)
+	registrations[1].Message = nil
+	var nilResponse *GetHeaderResponse
+
+	// The fast paths have the same output as encoding/json
+	for _, v := range []any{
+		response,
+		*response,
+		emptyResponse,
+		capellaResponse,
+		&GetHeaderResponse{Version: "<bellatrix>", Data: &SignedBuilderBid{}},
+		GetHeaderResponse{},
+		nilResponse,
+		registrations,
+		[]types.SignedValidatorRegistration{},
+		[]types.SignedValidatorRegistration(nil),
+		httpErrorResp{Code: 400, Message: "invalid"},
+	} {
+		expected, err := json.Marshal(v)
+		require.NoError(t, err)
+		actual, err := fastJSONCodec{}.Marshal(v)
+		require.NoError(t, err)
+		require.Equal(t, string(expected), string(actual))
+	}
+}
+
+func TestFastJSONCodecService(t *testing.T) {
+	backend := newTestBackend(t, 1, time.Second)
+	backend.boost.jsonCodec = fastJSONCodec{}
+	relay := backend.relays[0]
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	relay.GetHeaderResponse = relay.MakeGetHeaderResponse(12345, hash, pubkey)
+	path := fmt.Sprintf("/eth/v1/builder/header/%d/%s/%s", 1, hash, pubkey)
+	rr := backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	expected, err := json.Marshal(relay.GetHeaderResponse)
+	require.NoError(t, err)
+	require.Equal(t, string(expected)+"\n", rr.Body.String())
+
+	rr = backend.request(t, http.MethodPost, pathRegisterValidator, testRegistrations(2))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+}
+
+func BenchmarkJSONCodecs(b *testing.B) {
+	response := testGetHeaderResponse(b)
+	for _, name := range []string{JSONCodecStd, JSONCodecFast} {
+		codec, err := JSONCodecByName(name)
+		require.NoError(b, err)
+		b.Run(name+"/getHeader response", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := codec.Marshal(*response); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		for _, n := range []int{100, 1000} {
+			registrations := testRegistrations(n)
+			b.Run(fmt.Sprintf("%s/%d registrations", name, n), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, err := codec.Marshal(registrations); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogModuleRelay is the module of the log records of requests to the relays. Other modules are "cli", "service"
+// (the handlers of the builder API) and "scheduler".
+const LogModuleRelay = "relay"
+
+// LogLevels adjusts the log level at runtime, globally and per module (the "module" field of log records). The
+// logger is set to the most verbose of the levels, and the records of less verbose modules are dropped by its
+// formatter.
+type LogLevels struct {
+	mu      sync.RWMutex
+	logger  *logrus.Logger
+	level   logrus.Level
+	modules map[string]logrus.Level
+}
+
+// logLevelsResponse is the response of the admin endpoint for the log levels
+type logLevelsResponse struct {
+	Level   string            `json:"level"`
+	Modules map[string]string `json:"modules"`
+}
+
+// NewLogLevels installs runtime log levels on logger, starting from its current level. The formatter of the logger
+// must be set before.
+func NewLogLevels(logger *logrus.Logger) *LogLevels {
+	l := &LogLevels{
+		logger:  logger,
+		level:   logger.GetLevel(),
+		modules: make(map[string]logrus.Level),
+	}
+	logger.SetFormatter(&moduleLevelFormatter{levels: l, next: logger.Formatter})
+	return l
+}
+
+// SetLevel sets the log level of a module, or the global level if module is empty
+func (l *LogLevels) SetLevel(module string, level logrus.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if module == "" {
+		l.level = level
+	} else {
+		l.modules[module] = level
+	}
+	l.updateLogger()
+}
+
+// ResetLevel makes a module log at the global level again
+func (l *LogLevels) ResetLevel(module string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.modules, module)
+	l.updateLogger()
+}
+
+// Step raises (delta > 0) or lowers (delta < 0) the verbosity of the global level, and returns the new level
+func (l *LogLevels) Step(delta int) logrus.Level {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	level := int(l.level) + delta
+	if level < int(logrus.ErrorLevel) {
+		level = int(logrus.ErrorLevel)
+	} else if level > int(logrus.TraceLevel) {
+		level = int(logrus.TraceLevel)
+	}
+	l.level = logrus.Level(level)
+	l.updateLogger()
+	return l.level
+}
+
+func (l *LogLevels) updateLogger() {
+	max := l.level
+	for _, level := range l.modules {
+		if level > max {
+			max = level
+		}
+	}
+	l.logger.SetLevel(max)
+}
+
+func (l *LogLevels) enabled(module string, level logrus.Level) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if moduleLevel, ok := l.modules[module]; ok {
+		return level <= moduleLevel
+	}
+	return level <= l.level
+}
+
+func (l *LogLevels) response() logLevelsResponse {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	resp := logLevelsResponse{Level: l.level.String(), Modules: make(map[string]string, len(l.modules))}
+	for module, level := range l.modules {
+		resp.Modules[module] = level.String()
+	}
+	return resp
+}
+
+// moduleLevelFormatter drops the log records below the level of their module
+type moduleLevelFormatter struct {
+	levels *LogLevels
+	next   logrus.Formatter
+}
+
+func (f *moduleLevelFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	module, _ := entry.Data["module"].(string)
+	if !f.levels.enabled(module, entry.Level) {
+		return nil, nil
+	}
+	return f.next.Format(entry)
+}
+
+// relayLog returns the logger for a request to the relay at url
+func relayLog(log *logrus.Entry, relay RelayEntry, url string) *logrus.Entry {
+	log = log.WithFields(logrus.Fields{"module": LogModuleRelay, "url": url})
+	if len(relay.Tags) > 0 {
+		log = log.WithField("relayTags", relay.TagsString())
+	}
+	return log
+}
+
+// handleLogLevel returns the log levels, and on PUT sets the level given by the level query parameter, globally or
+// for the module given by the module query parameter. DELETE resets the level of a module to the global level.
+func (m *BoostService) handleLogLevel(w http.ResponseWriter, req *http.Request) {
+	module := req.URL.Query().Get("module")
+	switch req.Method {
+	case http.MethodPut:
+		level, err := logrus.ParseLevel(req.URL.Query().Get("level"))
+		if err != nil {
+			m.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		m.logLevels.SetLevel(module, level)
+		m.requestLog(req).Warnf("log level of module %q set to %s", module, level)
+	case http.MethodDelete:
+		if module == "" {
+			m.respondError(w, http.StatusBadRequest, "missing module")
+			return
+		}
+		m.logLevels.ResetLevel(module)
+		m.requestLog(req).Warnf("log level of module %q reset", module)
+	}
+	m.respondOK(w, m.logLevels.response())
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogLevels(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := logrus.New()
+	logger.SetOutput(buf)
+	logger.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+	logger.SetLevel(logrus.InfoLevel)
+	levels := NewLogLevels(logger)
+
+	service := logger.WithField("module", "service")
+	relay := logger.WithField("module", LogModuleRelay)
+	logged := func(log *logrus.Entry, msg string) bool {
+		buf.Reset()
+		log.Debug(msg)
+		return bytes.Contains(buf.Bytes(), []byte(msg))
+	}
+
+	require.False(t, logged(service, "service debug"))
+	require.False(t, logged(relay, "relay debug"))
+
+	// A module can log more verbosely than the others
+	levels.SetLevel(LogModuleRelay, logrus.DebugLevel)
+	require.Equal(t, logrus.DebugLevel, logger.GetLevel())
+	require.False(t, logged(service, "service debug"))
+	require.True(t, logged(relay, "relay debug"))
+
+	levels.ResetLevel(LogModuleRelay)
+	require.Equal(t, logrus.InfoLevel, logger.GetLevel())
+	require.False(t, logged(relay, "relay debug"))
+
+	// Stepping the global level is bounded
+	require.Equal(t, logrus.DebugLevel, levels.Step(1))
+	require.True(t, logged(service, "service debug"))
+	require.Equal(t, logrus.TraceLevel, levels.Step(2))
+	require.Equal(t, logrus.ErrorLevel, levels.Step(-10))
+	buf.Reset()
+	service.Warn("service warning")
+	require.Empty(t, buf.String())
+}
+
+func TestHandleLogLevel(t *testing.T) {
+	backend := newTestBackend(t, 1, time.Second)
+	backend.boost.adminAPI = true
+	logger := logrus.New()
+	logger.SetLevel(logrus.InfoLevel)
+	backend.boost.logLevels = NewLogLevels(logger)
+
+	levels := func(body *bytes.Buffer) logLevelsResponse {
+		resp := logLevelsResponse{}
+		require.NoError(t, json.Unmarshal(body.Bytes(), &resp))
+		return resp
+	}
+
+	rr := backend.request(t, http.MethodPut, pathAdminLogLevel+"?level=debug&module=relay", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, logLevelsResponse{Level: "info", Modules: map[string]string{"relay": "debug"}}, levels(rr.Body))
+	require.Equal(t, logrus.DebugLevel, logger.GetLevel())
+
+	rr = backend.request(t, http.MethodPut, pathAdminLogLevel+"?level=warning", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "warning", levels(rr.Body).Level)
+
+	rr = backend.request(t, http.MethodPut, pathAdminLogLevel+"?level=verbose", nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = backend.request(t, http.MethodDelete, pathAdminLogLevel+"?module=relay", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, logLevelsResponse{Level: "warning", Modules: map[string]string{}}, levels(rr.Body))
+	require.Equal(t, logrus.WarnLevel, logger.GetLevel())
+
+	rr = backend.request(t, http.MethodGet, pathAdminLogLevel, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "warning", levels(rr.Body).Level)
+}
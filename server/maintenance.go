@@ -0,0 +1,69 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaintenanceWindow is a time range during which a relay is expected to be unavailable
+type MaintenanceWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Contains returns whether t is within the maintenance window
+func (w MaintenanceWindow) Contains(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+func (w MaintenanceWindow) String() string {
+	return fmt.Sprintf("%s/%s", w.Start.Format(time.RFC3339), w.End.Format(time.RFC3339))
+}
+
+// ParseMaintenanceWindow parses a window in the format START/END, with both times in RFC3339 format
+// (eg. 2022-09-01T10:00:00Z/2022-09-01T12:00:00Z)
+func ParseMaintenanceWindow(s string) (w MaintenanceWindow, err error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 {
+		return w, fmt.Errorf("%w: %s", ErrInvalidMaintenanceWindow, s)
+	}
+
+	w.Start, err = time.Parse(time.RFC3339, strings.TrimSpace(parts[0]))
+	if err != nil {
+		return w, fmt.Errorf("%w: %s", ErrInvalidMaintenanceWindow, err.Error())
+	}
+
+	w.End, err = time.Parse(time.RFC3339, strings.TrimSpace(parts[1]))
+	if err != nil {
+		return w, fmt.Errorf("%w: %s", ErrInvalidMaintenanceWindow, err.Error())
+	}
+
+	if !w.End.After(w.Start) {
+		return w, fmt.Errorf("%w: end must be after start", ErrInvalidMaintenanceWindow)
+	}
+	return w, nil
+}
+
+// ParseRelayMaintenanceWindows parses a comma-separated list of HOST=START/END entries into
+// maintenance windows per relay host
+func ParseRelayMaintenanceWindows(s string) (map[string][]MaintenanceWindow, error) {
+	ret := make(map[string][]MaintenanceWindow)
+	if strings.TrimSpace(s) == "" {
+		return ret, nil
+	}
+
+	for _, entry := range strings.Split(s, ",") {
+		host, window, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || host == "" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidMaintenanceWindow, entry)
+		}
+
+		w, err := ParseMaintenanceWindow(window)
+		if err != nil {
+			return nil, err
+		}
+		ret[host] = append(ret[host], w)
+	}
+	return ret, nil
+}
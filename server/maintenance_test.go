@@ -0,0 +1,80 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMaintenanceWindow(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+
+		expectedErr   error
+		expectedStart string
+		expectedEnd   string
+	}{
+		{
+			name:          "Valid window",
+			input:         "2022-09-01T10:00:00Z/2022-09-01T12:00:00Z",
+			expectedStart: "2022-09-01T10:00:00Z",
+			expectedEnd:   "2022-09-01T12:00:00Z",
+		},
+		{
+			name:        "Missing end",
+			input:       "2022-09-01T10:00:00Z",
+			expectedErr: ErrInvalidMaintenanceWindow,
+		},
+		{
+			name:        "Invalid time format",
+			input:       "2022-09-01 10:00/2022-09-01 12:00",
+			expectedErr: ErrInvalidMaintenanceWindow,
+		},
+		{
+			name:        "End before start",
+			input:       "2022-09-01T12:00:00Z/2022-09-01T10:00:00Z",
+			expectedErr: ErrInvalidMaintenanceWindow,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := ParseMaintenanceWindow(tt.input)
+			if tt.expectedErr != nil {
+				require.True(t, errors.Is(err, tt.expectedErr), err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedStart, w.Start.Format(time.RFC3339))
+			require.Equal(t, tt.expectedEnd, w.End.Format(time.RFC3339))
+		})
+	}
+}
+
+func TestParseRelayMaintenanceWindows(t *testing.T) {
+	windows, err := ParseRelayMaintenanceWindows("")
+	require.NoError(t, err)
+	require.Len(t, windows, 0)
+
+	windows, err = ParseRelayMaintenanceWindows("foo.com=2022-09-01T10:00:00Z/2022-09-01T12:00:00Z, foo.com=2022-09-02T10:00:00Z/2022-09-02T12:00:00Z,bar.com:9000=2022-09-01T10:00:00Z/2022-09-01T11:00:00Z")
+	require.NoError(t, err)
+	require.Len(t, windows["foo.com"], 2)
+	require.Len(t, windows["bar.com:9000"], 1)
+
+	_, err = ParseRelayMaintenanceWindows("2022-09-01T10:00:00Z/2022-09-01T12:00:00Z")
+	require.True(t, errors.Is(err, ErrInvalidMaintenanceWindow), err)
+}
+
+func TestRelayEntryInMaintenance(t *testing.T) {
+	w, err := ParseMaintenanceWindow("2022-09-01T10:00:00Z/2022-09-01T12:00:00Z")
+	require.NoError(t, err)
+	relay := RelayEntry{MaintenanceWindows: []MaintenanceWindow{w}}
+
+	require.False(t, relay.InMaintenance(w.Start.Add(-time.Second)))
+	require.True(t, relay.InMaintenance(w.Start))
+	require.True(t, relay.InMaintenance(w.Start.Add(time.Hour)))
+	require.False(t, relay.InMaintenance(w.End))
+}
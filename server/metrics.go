@@ -0,0 +1,463 @@
+package server
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// proposerLabelLength is the length of the shortened proposer pubkeys used as metric labels, including the 0x prefix
+	proposerLabelLength = 10
+
+	// proposerLabelOther aggregates the proposers in excess of the cardinality limit
+	proposerLabelOther = "other"
+)
+
+// serviceMetrics are the Prometheus metrics of a BoostService. Each service has its own registry, so that
+// services of multiple networks in one process don't share metrics.
+type serviceMetrics struct {
+	registry *prometheus.Registry
+
+	slotOutcome  *prometheus.GaugeVec
+	slots        *prometheus.CounterVec
+	slotLatency  prometheus.Histogram
+	slotNumBids  prometheus.Histogram
+	slotBidValue prometheus.Gauge
+
+	registrationQueueDepth *prometheus.GaugeVec
+	bidOracleComparisons   *prometheus.CounterVec
+	relayDroppedBids       *prometheus.CounterVec
+	specDeviations         *prometheus.CounterVec
+
+	jobRuns     *prometheus.CounterVec
+	jobDuration *prometheus.HistogramVec
+	jobLastRun  *prometheus.GaugeVec
+	workers     *prometheus.GaugeVec
+
+	proposerSlots    *prometheus.CounterVec
+	proposerBidValue *prometheus.CounterVec
+
+	serverConns            *prometheus.GaugeVec
+	serverConnsClosed      *prometheus.CounterVec
+	serverConnLimitReached prometheus.Counter
+
+	cohortSlots    *prometheus.CounterVec
+	cohortBidValue *prometheus.CounterVec
+	cohortNumBids  *prometheus.HistogramVec
+
+	statusCache *prometheus.CounterVec
+
+	relayPayloadDelay            *prometheus.HistogramVec
+	relayPayloadSLAExceeded      *prometheus.CounterVec
+	payloadsAtRisk               prometheus.Counter
+	servedHeaderNotifyErrors     prometheus.Counter
+	lateGetHeader                *prometheus.CounterVec
+	partialGetHeader             *prometheus.CounterVec
+	getHeaderWithoutDuty         *prometheus.CounterVec
+	outboundBudgetThrottled      *prometheus.CounterVec
+	relayHeadersShared           *prometheus.CounterVec
+	getHeaderCoalesced           prometheus.Counter
+	relayTagPolicyViolations     *prometheus.CounterVec
+	configFallbacks              *prometheus.CounterVec
+	peerBidComparisons           *prometheus.CounterVec
+	peerRelayBidMismatches       *prometheus.CounterVec
+	peerGossipErrors             *prometheus.CounterVec
+	registrationWrongNetwork     *prometheus.CounterVec
+	registrationConfigMismatches *prometheus.CounterVec
+	relayIdempotency             *prometheus.GaugeVec
+	relayPubkeyVerified          *prometheus.GaugeVec
+	registrationsDeferred        *prometheus.CounterVec
+	relayBytesSent               *prometheus.CounterVec
+	relayBytesReceived           *prometheus.CounterVec
+	storageBytes                 *prometheus.GaugeVec
+	storagePrunedFiles           *prometheus.CounterVec
+	poolInUse                    *prometheus.GaugeVec
+	poolCapacity                 *prometheus.GaugeVec
+	poolWaits                    *prometheus.CounterVec
+	poolWaitTime                 *prometheus.HistogramVec
+
+	requests             *prometheus.CounterVec
+	requestDuration      *prometheus.HistogramVec
+	relayRequests        *prometheus.CounterVec
+	relayRequestDuration *prometheus.HistogramVec
+	relayBids            *prometheus.CounterVec
+	relayBidValue        *prometheus.GaugeVec
+	relayBidsWon         *prometheus.CounterVec
+	relaySamples         *prometheus.CounterVec
+	relayPayloads        *prometheus.CounterVec
+	relayErrors          *prometheus.CounterVec
+	payloadChecksSkipped *prometheus.CounterVec
+	extensionPanics      *prometheus.CounterVec
+
+	proposersLock sync.Mutex
+	proposerLimit int               // maximum number of proposers with their own labels, 0 disables per-proposer metrics
+	proposers     map[string]string // label per proposer pubkey
+}
+
+func newServiceMetrics(proposerLimit int) *serviceMetrics {
+	m := &serviceMetrics{
+		registry:      prometheus.NewRegistry(),
+		proposerLimit: proposerLimit,
+		proposers:     make(map[string]string),
+		slotOutcome: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "mev_boost_slot_outcome_info",
+			Help: "Outcome of the latest proposer slot handled",
+		}, []string{"slot", "pubkey", "relays", "block_hash", "value", "num_bids", "payload_delivered", "decision_hash"}),
+		slots: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_slots_total",
+			Help: "Number of proposer slots handled",
+		}, []string{"payload_delivered"}),
+		slotLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "mev_boost_slot_latency_seconds",
+			Help:    "Time from the first getHeader call to the getPayload response of a slot",
+			Buckets: []float64{0.1, 0.25, 0.5, 1, 2, 3, 4, 6, 8, 12},
+		}),
+		slotNumBids: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "mev_boost_slot_bids",
+			Help:    "Number of valid bids received per slot",
+			Buckets: []float64{0, 1, 2, 3, 5, 8, 13},
+		}),
+		slotBidValue: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "mev_boost_slot_bid_value_wei",
+			Help: "Value of the bid served for the latest proposer slot",
+		}),
+		registrationQueueDepth: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "mev_boost_registration_queue_depth",
+			Help: "Number of validator registrations queued for delivery to a relay",
+		}, []string{"relay"}),
+		bidOracleComparisons: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_bid_oracle_comparisons_total",
+			Help: "Comparisons of the served bid with the highest bid observed by the bid oracle",
+		}, []string{"result"}),
+		relayDroppedBids: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_relay_dropped_bids_total",
+			Help: "Number of bids dropped without validation, for exceeding the relay rate limit or response size, a value below the relay minimum, or losing to a validated bid without time left to validate it",
+		}, []string{"relay", "reason"}),
+		specDeviations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_spec_deviations_total",
+			Help: "Number of messages deviating from the builder spec, per peer (relay or consensus_client) and deviation",
+		}, []string{"peer", "deviation"}),
+		jobRuns: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_job_runs_total",
+			Help: "Number of runs of periodic background jobs, by result",
+		}, []string{"job", "result"}),
+		jobDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "mev_boost_job_duration_seconds",
+			Help:    "Duration of the runs of periodic background jobs",
+			Buckets: []float64{0.001, 0.01, 0.1, 0.5, 1, 5, 30},
+		}, []string{"job"}),
+		jobLastRun: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "mev_boost_job_last_run_timestamp_seconds",
+			Help: "Time of the last run of periodic background jobs",
+		}, []string{"job"}),
+		workers: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "mev_boost_workers",
+			Help: "Number of running background workers",
+		}, []string{"worker"}),
+		proposerSlots: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_proposer_slots_total",
+			Help: "Number of slots per proposer, by result: delivered, missed (payload not delivered) or no_bid",
+		}, []string{"proposer", "result"}),
+		proposerBidValue: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_proposer_delivered_value_wei_total",
+			Help: "Sum of the values of the bids delivered per proposer",
+		}, []string{"proposer"}),
+		serverConns: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "mev_boost_server_connections",
+			Help: "Number of open connections of the main listener, by state: new (no request yet), active or idle",
+		}, []string{"state"}),
+		serverConnsClosed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_server_connections_closed_total",
+			Help: "Number of closed connections of the main listener, by result: served, or no_request for connections closed before a request was read (eg. read header timeout)",
+		}, []string{"result"}),
+		serverConnLimitReached: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "mev_boost_server_connection_limit_reached_total",
+			Help: "Number of connections which had to wait for the maximum number of concurrent connections to drop",
+		}),
+		cohortSlots: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_cohort_slots_total",
+			Help: "Number of slots per experiment cohort, by result: delivered, missed (payload not delivered) or no_bid",
+		}, []string{"cohort", "result"}),
+		cohortBidValue: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_cohort_delivered_value_wei_total",
+			Help: "Sum of the values of the bids delivered per experiment cohort",
+		}, []string{"cohort"}),
+		cohortNumBids: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "mev_boost_cohort_slot_bids",
+			Help:    "Number of valid bids received per slot, per experiment cohort",
+			Buckets: []float64{0, 1, 2, 3, 5, 8, 13},
+		}, []string{"cohort"}),
+		statusCache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_status_cache_total",
+			Help: "Number of status calls by cache result: hit, stale (served while revalidating) or miss (relays checked)",
+		}, []string{"result"}),
+		relayPayloadDelay: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "mev_boost_relay_payload_delay_seconds",
+			Help:    "Time from serving a header to receiving its payload from a relay",
+			Buckets: []float64{0.1, 0.25, 0.5, 1, 2, 3, 4, 6, 8, 12},
+		}, []string{"relay"}),
+		relayPayloadSLAExceeded: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_relay_payload_sla_exceeded_total",
+			Help: "Number of payloads a relay did not deliver within the payload delivery SLA after the header was served",
+		}, []string{"relay"}),
+		payloadsAtRisk: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "mev_boost_payloads_at_risk_total",
+			Help: "Number of payloads no relay delivered within the payload delivery SLA after the header was served",
+		}),
+		servedHeaderNotifyErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "mev_boost_served_header_notify_errors_total",
+			Help: "Number of served headers which could not be posted to the served header endpoint",
+		}),
+		lateGetHeader: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_getheader_after_deadline_total",
+			Help: "Number of getHeader calls after the response deadline, answered without requesting bids, by whether a cached bid was served",
+		}, []string{"cached"}),
+		partialGetHeader: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_getheader_partial_total",
+			Help: "Number of getHeader calls answered at the partial deadline before all relays responded, by whether a bid was served",
+		}, []string{"served"}),
+		getHeaderWithoutDuty: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_getheader_without_duty_total",
+			Help: "Number of getHeader calls for pubkeys without a proposal duty in the epoch, by whether they were served, shed or rejected",
+		}, []string{"action"}),
+		outboundBudgetThrottled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_outbound_budget_throttled_total",
+			Help: "Number of requests to relays delayed for lack of outbound request budget, and of those dropped as they timed out while waiting, by endpoint",
+		}, []string{"endpoint", "outcome"}),
+		relayHeadersShared: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_relay_getheader_shared_total",
+			Help: "Number of getHeader calls which shared the bid of a request to a relay in flight for the same slot, parent hash and an equivalent proposer, instead of requesting the relay again",
+		}, []string{"relay"}),
+		getHeaderCoalesced: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "mev_boost_getheader_coalesced_total",
+			Help: "Number of getHeader calls which shared the relay fan-out in flight of an identical call for the same slot, parent hash and proposer",
+		}),
+		relayTagPolicyViolations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_relay_tag_policy_violations_total",
+			Help: "Number of getHeader requests whose bids violated a relay tag policy",
+		}, []string{"policy"}),
+		configFallbacks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_config_fallbacks_total",
+			Help: "Number of configuration changes not applied because they would leave no usable relay, keeping the last known good configuration",
+		}, []string{"config"}),
+		peerBidComparisons: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_peer_bid_comparisons_total",
+			Help: "Comparisons of the served bid with the best bid of a peer instance for the same getHeader request",
+		}, []string{"result"}),
+		peerRelayBidMismatches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_peer_relay_bid_mismatches_total",
+			Help: "Number of getHeader requests for which a relay delivered a different bid to a peer instance",
+		}, []string{"relay"}),
+		peerGossipErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_peer_gossip_errors_total",
+			Help: "Number of bid summaries which could not be sent to a peer instance",
+		}, []string{"peer"}),
+		registrationConfigMismatches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_registration_config_mismatches_total",
+			Help: "Number of validator registrations whose fee recipient or gas limit differ from the proposer config, by field",
+		}, []string{"field"}),
+		registrationWrongNetwork: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_registration_wrong_network_total",
+			Help: "Number of rejected validator registrations signed for another network than served, by the network they are signed for (unknown if none)",
+		}, []string{"network"}),
+		relayIdempotency: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "mev_boost_relay_idempotency_supported",
+			Help: "Whether the relay honored the idempotency key of its last response to a registerValidator or getPayload request",
+		}, []string{"relay"}),
+		relayPubkeyVerified: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "mev_boost_relay_pubkey_verified",
+			Help: "Whether the relay signed the last pubkey challenge with its configured pubkey (0 on mismatch)",
+		}, []string{"relay"}),
+		registrationsDeferred: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_registrations_deferred_total",
+			Help: "Number of registerValidator calls not awaited for a flaky relay, whose registrations were queued for it instead",
+		}, []string{"relay"}),
+		relayBytesSent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_relay_bytes_sent_total",
+			Help: "Bytes of the HTTP requests sent to the relay, by endpoint",
+		}, []string{"relay", "endpoint"}),
+		relayBytesReceived: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_relay_bytes_received_total",
+			Help: "Bytes of the HTTP responses received from the relay, by endpoint (with decompressed bodies)",
+		}, []string{"relay", "endpoint"}),
+		storageBytes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "mev_boost_storage_bytes",
+			Help: "Size of the files of the store on disk, after pruning",
+		}, []string{"store"}),
+		storagePrunedFiles: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_storage_pruned_files_total",
+			Help: "Number of files of the store removed by its retention policy",
+		}, []string{"store"}),
+		poolInUse: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "mev_boost_pool_in_use",
+			Help: "Number of slots in use of an internal pool, queue or semaphore",
+		}, []string{"pool"}),
+		poolCapacity: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "mev_boost_pool_capacity",
+			Help: "Number of slots of an internal pool, queue or semaphore, unset if unbounded",
+		}, []string{"pool"}),
+		poolWaits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_pool_waits_total",
+			Help: "Number of acquisitions of a saturated pool which waited for a free slot",
+		}, []string{"pool"}),
+		poolWaitTime: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "mev_boost_pool_wait_seconds",
+			Help:    "Time acquisitions of a saturated pool waited for a free slot",
+			Buckets: []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2, 5},
+		}, []string{"pool"}),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_requests_total",
+			Help: "Number of Builder API calls of the consensus client, by endpoint and status code",
+		}, []string{"endpoint", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "mev_boost_request_duration_seconds",
+			Help:    "Time to respond to the Builder API calls of the consensus client, by endpoint",
+			Buckets: []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2, 4},
+		}, []string{"endpoint"}),
+		relayRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_relay_requests_total",
+			Help: "Number of requests to the relay, by endpoint and result (success, status, timeout, cancelled or error)",
+		}, []string{"relay", "endpoint", "result"}),
+		relayRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "mev_boost_relay_request_duration_seconds",
+			Help:    "Time until the response headers of the relay arrived, or the request failed, by endpoint",
+			Buckets: []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2, 4},
+		}, []string{"relay", "endpoint"}),
+		relayBids: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_relay_bids_total",
+			Help: "Number of getHeader responses of the relay, by status (bid, no_bid, rejected or error)",
+		}, []string{"relay", "status"}),
+		relayBidValue: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "mev_boost_relay_bid_value_wei",
+			Help: "Value of the latest valid bid of the relay",
+		}, []string{"relay"}),
+		relayBidsWon: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_relay_bids_won_total",
+			Help: "Number of getHeader calls served with the bid of the relay, counted for each relay which offered the served bid",
+		}, []string{"relay"}),
+		relaySamples: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_relay_samples_total",
+			Help: "Number of sampling getHeader responses of the relay, by status (bid, no_bid, rejected or error)",
+		}, []string{"relay", "status"}),
+		relayPayloads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_relay_payloads_total",
+			Help: "Number of getPayload responses of the relay, by status (payload, invalid or error)",
+		}, []string{"relay", "status"}),
+		relayErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_relay_errors_total",
+			Help: "Number of error responses of the relay, by relay-supplied message, with the messages beyond the limit per relay counted as other",
+		}, []string{"relay", "message"}),
+		payloadChecksSkipped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_payload_checks_skipped_total",
+			Help: "Number of optional getPayload response checks skipped as they risked exceeding the slot deadline, by check",
+		}, []string{"check"}),
+		extensionPanics: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mev_boost_extension_panics_total",
+			Help: "Number of panics recovered in the handlers of extension endpoints, by extension",
+		}, []string{"extension"}),
+	}
+	m.registry.MustRegister(m.slotOutcome, m.slots, m.slotLatency, m.slotNumBids, m.slotBidValue, m.registrationQueueDepth,
+		m.bidOracleComparisons, m.relayDroppedBids, m.specDeviations, m.jobRuns, m.jobDuration, m.jobLastRun, m.workers,
+		m.serverConns, m.serverConnsClosed, m.serverConnLimitReached, m.cohortSlots, m.cohortBidValue, m.cohortNumBids,
+		m.statusCache, m.relayPayloadDelay, m.relayPayloadSLAExceeded, m.payloadsAtRisk, m.servedHeaderNotifyErrors,
+		m.lateGetHeader, m.partialGetHeader, m.getHeaderWithoutDuty, m.outboundBudgetThrottled, m.relayHeadersShared, m.getHeaderCoalesced, m.relayTagPolicyViolations, m.configFallbacks, m.peerBidComparisons, m.peerRelayBidMismatches,
+		m.peerGossipErrors, m.registrationConfigMismatches, m.registrationWrongNetwork, m.relayIdempotency, m.relayPubkeyVerified,
+		m.registrationsDeferred, m.relayBytesSent, m.relayBytesReceived,
+		m.storageBytes, m.storagePrunedFiles, m.poolInUse, m.poolCapacity, m.poolWaits, m.poolWaitTime,
+		m.requests, m.requestDuration, m.relayRequests, m.relayRequestDuration, m.relayBids, m.relayBidValue, m.relayBidsWon,
+		m.relaySamples, m.relayPayloads, m.relayErrors, m.payloadChecksSkipped, m.extensionPanics)
+	if proposerLimit > 0 {
+		m.registry.MustRegister(m.proposerSlots, m.proposerBidValue)
+	}
+	return m
+}
+
+// observeSlotOutcome records the outcome of a slot. The info metric only holds the latest slot, to bound its cardinality.
+func (m *serviceMetrics) observeSlotOutcome(o *slotOutcome) {
+	delivered := strconv.FormatBool(o.PayloadDelivered)
+	m.slotOutcome.Reset()
+	m.slotOutcome.With(prometheus.Labels{
+		"slot":              strconv.FormatUint(o.Slot, 10),
+		"pubkey":            o.Pubkey,
+		"relays":            o.relaysString(),
+		"block_hash":        o.BlockHash,
+		"value":             o.Value,
+		"num_bids":          strconv.Itoa(o.NumBids),
+		"payload_delivered": delivered,
+		"decision_hash":     o.Decision.Decision,
+	}).Set(1)
+
+	m.slots.WithLabelValues(delivered).Inc()
+	m.slotNumBids.Observe(float64(o.NumBids))
+	if o.PayloadDelivered {
+		m.slotLatency.Observe(o.Latency.Seconds())
+	}
+	if value, err := strconv.ParseFloat(o.Value, 64); err == nil {
+		m.slotBidValue.Set(value)
+	}
+
+	if m.proposerLimit > 0 {
+		m.observeProposer(o)
+	}
+	if o.Cohort != "" {
+		m.observeCohort(o)
+	}
+}
+
+// observeCohort records the outcome of a slot for its experiment cohort
+func (m *serviceMetrics) observeCohort(o *slotOutcome) {
+	m.cohortNumBids.WithLabelValues(o.Cohort).Observe(float64(o.NumBids))
+	switch {
+	case o.BlockHash == "":
+		m.cohortSlots.WithLabelValues(o.Cohort, "no_bid").Inc()
+	case !o.PayloadDelivered:
+		m.cohortSlots.WithLabelValues(o.Cohort, "missed").Inc()
+	default:
+		m.cohortSlots.WithLabelValues(o.Cohort, "delivered").Inc()
+		if value, err := strconv.ParseFloat(o.Value, 64); err == nil {
+			m.cohortBidValue.WithLabelValues(o.Cohort).Add(value)
+		}
+	}
+}
+
+// observeProposer records the outcome of a slot for its proposer
+func (m *serviceMetrics) observeProposer(o *slotOutcome) {
+	proposer := m.proposerLabel(o.Pubkey)
+	switch {
+	case o.BlockHash == "":
+		m.proposerSlots.WithLabelValues(proposer, "no_bid").Inc()
+	case !o.PayloadDelivered:
+		m.proposerSlots.WithLabelValues(proposer, "missed").Inc()
+	default:
+		m.proposerSlots.WithLabelValues(proposer, "delivered").Inc()
+		if value, err := strconv.ParseFloat(o.Value, 64); err == nil {
+			m.proposerBidValue.WithLabelValues(proposer).Add(value)
+		}
+	}
+}
+
+// proposerLabel returns the shortened pubkey of a proposer, or "other" once the cardinality limit is reached.
+// Proposers keep the label they were first given.
+func (m *serviceMetrics) proposerLabel(pubkey string) string {
+	pubkey = strings.ToLower(pubkey)
+
+	m.proposersLock.Lock()
+	defer m.proposersLock.Unlock()
+
+	if label, ok := m.proposers[pubkey]; ok {
+		return label
+	}
+	if len(m.proposers) >= m.proposerLimit {
+		return proposerLabelOther
+	}
+
+	label := pubkey
+	if len(label) > proposerLabelLength {
+		label = label[:proposerLabelLength]
+	}
+	m.proposers[pubkey] = label
+	return label
+}
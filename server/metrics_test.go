@@ -0,0 +1,36 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProposerLabel(t *testing.T) {
+	m := newServiceMetrics(2)
+	require.Equal(t, "0x8a1d7b8d", m.proposerLabel("0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"))
+	require.Equal(t, "0xab", m.proposerLabel("0xAB"))
+	require.Equal(t, proposerLabelOther, m.proposerLabel("0xcd"))
+
+	// Known proposers keep their label
+	require.Equal(t, "0xab", m.proposerLabel("0xab"))
+}
+
+func TestObserveProposer(t *testing.T) {
+	m := newServiceMetrics(1)
+	m.observeSlotOutcome(&slotOutcome{Pubkey: "0xab", BlockHash: "0x01", Value: "10", PayloadDelivered: true})
+	m.observeSlotOutcome(&slotOutcome{Pubkey: "0xab", BlockHash: "0x02", Value: "20", PayloadDelivered: true})
+	m.observeSlotOutcome(&slotOutcome{Pubkey: "0xab", BlockHash: "0x03", Value: "40"})
+	m.observeSlotOutcome(&slotOutcome{Pubkey: "0xcd"})
+
+	require.Equal(t, float64(2), testutil.ToFloat64(m.proposerSlots.WithLabelValues("0xab", "delivered")))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.proposerSlots.WithLabelValues("0xab", "missed")))
+	require.Equal(t, float64(30), testutil.ToFloat64(m.proposerBidValue.WithLabelValues("0xab")))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.proposerSlots.WithLabelValues(proposerLabelOther, "no_bid")))
+
+	// Per-proposer metrics are disabled without limit
+	m = newServiceMetrics(0)
+	m.observeSlotOutcome(&slotOutcome{Pubkey: "0xab", BlockHash: "0x01", Value: "10", PayloadDelivered: true})
+	require.Equal(t, 0, testutil.CollectAndCount(m.proposerSlots))
+}
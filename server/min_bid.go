@@ -0,0 +1,74 @@
+package server
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ParseRelayMinBidValues parses a comma-separated list of HOST=VALUE entries into the minimum bid values per relay
+// host, in wei. Values are in units of the native token (eg. relay.example.com=0.01 for 0.01 ETH), which has 18
+// decimals on all supported chains.
+func ParseRelayMinBidValues(s string) (map[string]*big.Int, error) {
+	ret := make(map[string]*big.Int)
+	if strings.TrimSpace(s) == "" {
+		return ret, nil
+	}
+
+	for _, entry := range strings.Split(s, ",") {
+		host, value, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || host == "" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidMinBidValue, entry)
+		}
+		wei, err := parseTokenAmount(value, ChainEthereum.TokenDecimals)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidMinBidValue, entry, err)
+		}
+		ret[host] = wei
+	}
+	return ret, nil
+}
+
+// ParseMinBidValue parses a minimum bid value in units of the native token (eg. 0.05) into wei, nil if empty
+func ParseMinBidValue(s string) (*big.Int, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	wei, err := parseTokenAmount(s, ChainEthereum.TokenDecimals)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMinBidValue, err)
+	}
+	return wei, nil
+}
+
+// parseTokenAmount parses a non-negative decimal amount of a token into its smallest unit
+func parseTokenAmount(s string, decimals int) (*big.Int, error) {
+	amount, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok || amount.Sign() < 0 {
+		return nil, fmt.Errorf("not a non-negative number: %s", s)
+	}
+	amount.Mul(amount, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+	if !amount.IsInt() {
+		return nil, fmt.Errorf("more than %d decimals: %s", decimals, s)
+	}
+	return amount.Num(), nil
+}
+
+// proposerMinBid returns the minimum bid value of a proposer: the min_bid of the proposer config if any, or else the
+// service-wide minimum bid value. Nil if there is none.
+func (m *BoostService) proposerMinBid(pubkey string) (*big.Int, BidRejectionReason) {
+	if minBid := m.proposerConfig.settings(pubkey).minBid; minBid != nil {
+		return minBid, BidRejectionBelowProposerMin
+	}
+	return m.minBidValue, BidRejectionBelowMinBid
+}
+
+// isBelowMinBid returns whether a bid was rejected for being below the minimum bid value of the proposer
+func isBelowMinBid(rejection bidRejection) bool {
+	return rejection.Reason == BidRejectionBelowProposerMin || rejection.Reason == BidRejectionBelowMinBid
+}
+
+// isBelowMinBidValue returns whether a bid value in wei is below the minimum bid value of the relay, if any
+func isBelowMinBidValue(relay RelayEntry, valueWei *big.Int) bool {
+	return relay.MinBidValue != nil && valueWei.Cmp(relay.MinBidValue) < 0
+}
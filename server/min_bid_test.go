@@ -0,0 +1,81 @@
+package server
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRelayMinBidValues(t *testing.T) {
+	values, err := ParseRelayMinBidValues("relay1.com=0.01, relay2.com=2")
+	require.NoError(t, err)
+	require.Equal(t, map[string]*big.Int{
+		"relay1.com": big.NewInt(10_000_000_000_000_000),
+		"relay2.com": new(big.Int).Mul(big.NewInt(2), big.NewInt(1_000_000_000_000_000_000)),
+	}, values)
+
+	values, err = ParseRelayMinBidValues("")
+	require.NoError(t, err)
+	require.Empty(t, values)
+
+	for _, s := range []string{"relay1.com", "relay1.com=x", "relay1.com=-1", "relay1.com=0.0000000000000000001"} {
+		_, err = ParseRelayMinBidValues(s)
+		require.ErrorIs(t, err, ErrInvalidMinBidValue, s)
+	}
+}
+
+func TestRelayMinBidValue(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	path := fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey)
+
+	backend := newTestBackend(t, 1, time.Second)
+	relay := backend.boost.relays[0].String()
+
+	// The mock relay bids 12345 wei
+	backend.boost.relays[0].MinBidValue = big.NewInt(12345)
+	rr := backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	backend.boost.relays[0].MinBidValue = big.NewInt(12346)
+	rr = backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.relayDroppedBids.WithLabelValues(relay, string(BidRejectionBelowMinValue))))
+	require.Equal(t, uint64(1), backend.boost.bidRejections.snapshot()[relay][BidRejectionBelowMinValue])
+}
+
+func TestMinBidValue(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	path := fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey)
+
+	value, err := ParseMinBidValue("0.05")
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(50_000_000_000_000_000), value)
+	value, err = ParseMinBidValue("")
+	require.NoError(t, err)
+	require.Nil(t, value)
+	_, err = ParseMinBidValue("-1")
+	require.ErrorIs(t, err, ErrInvalidMinBidValue)
+
+	backend := newTestBackend(t, 1, time.Second)
+	relay := backend.boost.relays[0].String()
+
+	// The mock relay bids 12345 wei, below the minimum the consensus client builds the block locally
+	backend.boost.minBidValue = big.NewInt(12346)
+	rr := backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.relayDroppedBids.WithLabelValues(relay, string(BidRejectionBelowMinBid))))
+
+	// The min_bid of the proposer config takes precedence
+	backend.boost.proposerConfig = newProposerConfigStore(&ProposerConfig{
+		Proposers: map[string]ProposerSettings{pubkey: {minBid: big.NewInt(12345)}},
+	})
+	rr = backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"encoding/json"
 	"fmt"
+	"math/rand"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -25,17 +26,15 @@ var (
 	mockRelayPublicKey    = bls.PublicKeyFromSecretKey(mockRelaySecretKey)
 )
 
-// mockRelay is used to fake a relay's behavior.
+// MockRelay is used to fake a relay's behavior.
 // You can override each of its handler by setting the instance's HandlerOverride_METHOD_TO_OVERRIDE to your own
 // handler.
-type mockRelay struct {
-	// Used to panic if impossible error happens
-	t *testing.T
-
-	// KeyPair used to sign messages
-	secretKey  *bls.SecretKey
-	publicKey  *bls.PublicKey
-	RelayEntry RelayEntry
+type MockRelay struct {
+	// KeyPair used to sign messages, and the domain to sign them for
+	secretKey     *bls.SecretKey
+	publicKey     *bls.PublicKey
+	RelayEntry    RelayEntry
+	SigningDomain types.Domain
 
 	// Used to count each Request made to the relay, either if it fails or not, for each method
 	mu           sync.Mutex
@@ -47,21 +46,48 @@ type mockRelay struct {
 	handlerOverrideGetPayload        func(w http.ResponseWriter, req *http.Request)
 
 	// Default responses placeholders, used if overrider does not exist
-	GetHeaderResponse  *types.GetHeaderResponse
-	GetPayloadResponse *types.GetPayloadResponse
+	GetHeaderResponse  *GetHeaderResponse
+	GetPayloadResponse *GetPayloadResponse
+	Capabilities       *RelayCapabilities // capabilities endpoint returns 404 if nil
+
+	// Default response settings
+	BidValue          uint64 // value of the default getHeader response
+	BidBlockHash      string // block hash of the default getHeader and getPayload responses
+	EchoRequestHashes bool   // use the parent hash of getHeader requests, and the block hash of getPayload requests
+	NoBids            bool   // respond to getHeader with 204 (no bid)
+	WithholdPayload   bool   // respond to getPayload with an error
+
+	// Fault injection
+	FaultRate float64 // fraction of requests answered with an internal server error
+
+	// Recorded responses, served instead of the default ones (see ReplayFixtures)
+	fixtures *fixtureReplay
 
 	// Server section
 	Server        *httptest.Server
 	ResponseDelay time.Duration
 }
 
+// NewMockRelay creates a mocked relay signing its responses with the given secret key, which can be served with
+// Handler (eg. as a standalone relay for integration environments)
+func NewMockRelay(secretKey *bls.SecretKey) *MockRelay {
+	return &MockRelay{
+		secretKey:     secretKey,
+		publicKey:     bls.PublicKeyFromSecretKey(secretKey),
+		SigningDomain: types.DomainBuilder,
+		requestCount:  make(map[string]int),
+		BidValue:      12345,
+		BidBlockHash:  "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7",
+	}
+}
+
 // newMockRelay creates a mocked relay which implements the backend.BoostBackend interface
 // A secret key must be provided to sign default and custom response messages
-func newMockRelay(t *testing.T) *mockRelay {
-	relay := &mockRelay{t: t, secretKey: mockRelaySecretKey, publicKey: mockRelayPublicKey, requestCount: make(map[string]int)}
+func newMockRelay(t testing.TB) *MockRelay {
+	relay := NewMockRelay(mockRelaySecretKey)
 
 	// Initialize server
-	relay.Server = httptest.NewServer(relay.getRouter())
+	relay.Server = httptest.NewServer(relay.Handler())
 
 	// Create the RelayEntry with correct pubkey
 	url, err := url.Parse(relay.Server.URL)
@@ -72,8 +98,19 @@ func newMockRelay(t *testing.T) *mockRelay {
 	return relay
 }
 
-// newTestMiddleware creates a middleware which increases the Request counter and creates a fake delay for the response
-func (m *mockRelay) newTestMiddleware(next http.Handler) http.Handler {
+// PublicKey returns the public key of the relay
+func (m *MockRelay) PublicKey() *bls.PublicKey {
+	return m.publicKey
+}
+
+// Handler returns the HTTP handler of the relay
+func (m *MockRelay) Handler() http.Handler {
+	return m.getRouter()
+}
+
+// newTestMiddleware creates a middleware which increases the Request counter, creates a fake delay for the response,
+// and injects faults
+func (m *MockRelay) newTestMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
 			// Request counter
@@ -87,13 +124,29 @@ func (m *mockRelay) newTestMiddleware(next http.Handler) http.Handler {
 				time.Sleep(m.ResponseDelay)
 			}
 
+			// Injected fault
+			if m.FaultRate > 0 && rand.Float64() < m.FaultRate {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprintf(w, `{"code":500,"message":"injected fault"}`)
+				return
+			}
+
+			// Recorded response
+			m.mu.Lock()
+			fixtures := m.fixtures
+			m.mu.Unlock()
+			if fixtures != nil && fixtures.serve(w, r) {
+				return
+			}
+
 			next.ServeHTTP(w, r)
 		},
 	)
 }
 
 // getRouter registers all methods from the backend, apply the test middleware a,nd return the configured router
-func (m *mockRelay) getRouter() http.Handler {
+func (m *MockRelay) getRouter() http.Handler {
 	// Create router.
 	r := mux.NewRouter()
 
@@ -103,33 +156,35 @@ func (m *mockRelay) getRouter() http.Handler {
 	r.HandleFunc(pathRegisterValidator, m.handleRegisterValidator).Methods(http.MethodPost)
 	r.HandleFunc(pathGetHeader, m.handleGetHeader).Methods(http.MethodGet)
 	r.HandleFunc(pathGetPayload, m.handleGetPayload).Methods(http.MethodPost)
+	r.HandleFunc(pathRelayCapabilities, m.handleCapabilities).Methods(http.MethodGet)
+	r.HandleFunc(pathRelayChallenge, m.handleChallenge).Methods(http.MethodGet)
 
 	return m.newTestMiddleware(r)
 }
 
 // GetRequestCount returns the number of Request made to a specific URL
-func (m *mockRelay) GetRequestCount(path string) int {
+func (m *MockRelay) GetRequestCount(path string) int {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	return m.requestCount[path]
 }
 
 // By default, handleRoot returns the relay's status
-func (m *mockRelay) handleRoot(w http.ResponseWriter, req *http.Request) {
+func (m *MockRelay) handleRoot(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintf(w, `{}`)
 }
 
 // By default, handleStatus returns the relay's status as http.StatusOK
-func (m *mockRelay) handleStatus(w http.ResponseWriter, req *http.Request) {
+func (m *MockRelay) handleStatus(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintf(w, `{}`)
 }
 
 // By default, handleRegisterValidator returns a default types.SignedValidatorRegistration
-func (m *mockRelay) handleRegisterValidator(w http.ResponseWriter, req *http.Request) {
+func (m *MockRelay) handleRegisterValidator(w http.ResponseWriter, req *http.Request) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	if m.handlerOverrideRegisterValidator != nil {
@@ -149,24 +204,30 @@ func (m *mockRelay) handleRegisterValidator(w http.ResponseWriter, req *http.Req
 
 // MakeGetHeaderResponse is used to create the default or can be used to create a custom response to the getHeader
 // method
-func (m *mockRelay) MakeGetHeaderResponse(value uint64, hash, publicKey string) *types.GetHeaderResponse {
+func (m *MockRelay) MakeGetHeaderResponse(value uint64, hash, publicKey string) *GetHeaderResponse {
+	return m.makeGetHeaderResponse(value, hash, "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7", publicKey)
+}
+
+func (m *MockRelay) makeGetHeaderResponse(value uint64, hash, parentHash, publicKey string) *GetHeaderResponse {
 	// Fill the payload with custom values.
-	message := &types.BuilderBid{
-		Header: &types.ExecutionPayloadHeader{
+	message := &BuilderBid{
+		Header: &ExecutionPayloadHeader{ExecutionPayloadHeader: types.ExecutionPayloadHeader{
 			BlockHash:  _HexToHash(hash),
-			ParentHash: _HexToHash("0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"),
-		},
+			ParentHash: _HexToHash(parentHash),
+		}},
 		Value:  types.IntToU256(value),
 		Pubkey: _HexToPubkey(publicKey),
 	}
 
-	// Sign the message.
-	signature, err := types.SignMessage(message, types.DomainBuilder, m.secretKey)
-	require.NoError(m.t, err)
+	// Sign the message. This can only fail with an invalid secret key.
+	signature, err := types.SignMessage(message, m.SigningDomain, m.secretKey)
+	if err != nil {
+		panic(err)
+	}
 
-	return &types.GetHeaderResponse{
-		Version: "bellatrix",
-		Data: &types.SignedBuilderBid{
+	return &GetHeaderResponse{
+		Version: versionBellatrix,
+		Data: &SignedBuilderBid{
 			Message:   message,
 			Signature: signature,
 		},
@@ -174,7 +235,7 @@ func (m *mockRelay) MakeGetHeaderResponse(value uint64, hash, publicKey string)
 }
 
 // handleGetHeader handles incoming requests to server.pathGetHeader
-func (m *mockRelay) handleGetHeader(w http.ResponseWriter, req *http.Request) {
+func (m *MockRelay) handleGetHeader(w http.ResponseWriter, req *http.Request) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	// Try to override default behavior is custom handler is specified.
@@ -183,18 +244,23 @@ func (m *mockRelay) handleGetHeader(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if m.NoBids {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
 	// By default, everything will be ok.
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
 	// Build the default response.
-	response := m.MakeGetHeaderResponse(
-		12345,
-		"0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7",
-		"0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249",
-	)
-	if m.GetHeaderResponse != nil {
-		response = m.GetHeaderResponse
+	response := m.GetHeaderResponse
+	if response == nil {
+		parentHash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+		if m.EchoRequestHashes {
+			parentHash = mux.Vars(req)["parent_hash"]
+		}
+		response = m.makeGetHeaderResponse(m.BidValue, m.BidBlockHash, parentHash, hexutil.Encode(m.publicKey.Compress()))
 	}
 
 	if err := json.NewEncoder(w).Encode(response); err != nil {
@@ -205,20 +271,20 @@ func (m *mockRelay) handleGetHeader(w http.ResponseWriter, req *http.Request) {
 
 // MakeGetPayloadResponse is used to create the default or can be used to create a custom response to the getPayload
 // method
-func (m *mockRelay) MakeGetPayloadResponse(parentHash, blockHash, feeRecipient string, blockNumber uint64) *types.GetPayloadResponse {
-	return &types.GetPayloadResponse{
-		Version: "bellatrix",
-		Data: &types.ExecutionPayload{
+func (m *MockRelay) MakeGetPayloadResponse(parentHash, blockHash, feeRecipient string, blockNumber uint64) *GetPayloadResponse {
+	return &GetPayloadResponse{
+		Version: versionBellatrix,
+		Data: &ExecutionPayload{ExecutionPayload: types.ExecutionPayload{
 			ParentHash:   _HexToHash(parentHash),
 			BlockHash:    _HexToHash(blockHash),
 			BlockNumber:  blockNumber,
 			FeeRecipient: _HexToAddress(feeRecipient),
-		},
+		}},
 	}
 }
 
 // handleGetPayload handles incoming requests to server.pathGetPayload
-func (m *mockRelay) handleGetPayload(w http.ResponseWriter, req *http.Request) {
+func (m *MockRelay) handleGetPayload(w http.ResponseWriter, req *http.Request) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	// Try to override default behavior is custom handler is specified.
@@ -227,6 +293,13 @@ func (m *mockRelay) handleGetPayload(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if m.WithholdPayload {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, `{"code":500,"message":"payload withheld"}`)
+		return
+	}
+
 	// By default, everything will be ok.
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -240,17 +313,83 @@ func (m *mockRelay) handleGetPayload(w http.ResponseWriter, req *http.Request) {
 	)
 	if m.GetPayloadResponse != nil {
 		response = m.GetPayloadResponse
+	} else if m.EchoRequestHashes {
+		payload := new(SignedBlindedBeaconBlock)
+		if err := DecodeJSON(req.Body, payload); err == nil && payload.Message != nil && payload.Message.Body != nil && payload.Message.Body.ExecutionPayloadHeader != nil {
+			header := payload.Message.Body.ExecutionPayloadHeader
+			response = m.MakeGetPayloadResponse(header.ParentHash.String(), header.BlockHash.String(), header.FeeRecipient.String(), header.BlockNumber)
+		}
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// handleCapabilities handles incoming requests to server.pathRelayCapabilities
+func (m *MockRelay) handleCapabilities(w http.ResponseWriter, req *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Capabilities == nil {
+		http.NotFound(w, req)
+		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(m.Capabilities); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// handleChallenge handles incoming requests to server.pathRelayChallenge, signing the nonce with the relay key
+func (m *MockRelay) handleChallenge(w http.ResponseWriter, req *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	message := new(RelayChallenge)
+	if err := message.Nonce.UnmarshalText([]byte(mux.Vars(req)["nonce"])); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	signature, err := types.SignMessage(message, m.SigningDomain, m.secretKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	response := SignedRelayChallenge{Message: message, Signature: signature}
+	if err := response.Pubkey.FromSlice(m.publicKey.Compress()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
 
-func (m *mockRelay) overrideHandleRegisterValidator(method func(w http.ResponseWriter, req *http.Request)) {
+func (m *MockRelay) overrideHandleRegisterValidator(method func(w http.ResponseWriter, req *http.Request)) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	m.handlerOverrideRegisterValidator = method
 }
+
+func (m *MockRelay) overrideHandleGetHeader(method func(w http.ResponseWriter, req *http.Request)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.handlerOverrideGetHeader = method
+}
+
+func (m *MockRelay) overrideHandleGetPayload(method func(w http.ResponseWriter, req *http.Request)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.handlerOverrideGetPayload = method
+}
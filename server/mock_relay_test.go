@@ -2,10 +2,12 @@ package server
 
 import (
 	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/flashbots/go-boost-utils/types"
 	"github.com/stretchr/testify/require"
 )
 
@@ -18,4 +20,32 @@ func Test_mockRelay(t *testing.T) {
 		relay.getRouter().ServeHTTP(rr, req)
 		require.Equal(t, http.StatusBadRequest, rr.Code)
 	})
+
+	t.Run("standalone relay", func(t *testing.T) {
+		relay := NewMockRelay(mockRelaySecretKey)
+		relay.EchoRequestHashes = true
+		parentHash := "0xa28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+		req, err := http.NewRequest(http.MethodGet, "/eth/v1/builder/header/1/"+parentHash+"/0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		relay.Handler().ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		resp := new(types.GetHeaderResponse)
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		require.Equal(t, parentHash, resp.Data.Message.Header.ParentHash.String())
+		ok, err := types.VerifySignature(resp.Data.Message, types.DomainBuilder, resp.Data.Message.Pubkey[:], resp.Data.Signature[:])
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		relay.NoBids = true
+		rr = httptest.NewRecorder()
+		relay.Handler().ServeHTTP(rr, req)
+		require.Equal(t, http.StatusNoContent, rr.Code)
+
+		relay.FaultRate = 1
+		rr = httptest.NewRecorder()
+		relay.Handler().ServeHTTP(rr, req)
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
 }
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var errOutboundBudgetExhausted = errors.New("outbound request budget exhausted")
+
+// outboundBudget limits the requests to all relays together, shared by all handlers and background tasks
+type outboundBudget struct {
+	mu     sync.Mutex
+	bucket *tokenBucket
+	clock  Clock
+}
+
+// newOutboundBudget returns a budget of rate requests per second with bursts of up to burst requests, or nil for a
+// rate of 0
+func newOutboundBudget(rate float64, burst int, clock Clock) *outboundBudget {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &outboundBudget{bucket: newTokenBucket(rate, burst, clock.Now()), clock: clock}
+}
+
+// tryTake takes a token if one is available, and otherwise returns how long until one is
+func (b *outboundBudget) tryTake() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	wait := b.bucket.wait(b.clock.Now())
+	if wait == 0 {
+		b.bucket.take()
+	}
+	return wait
+}
+
+// capacity returns the burst of the budget, or 0 without budget
+func (b *outboundBudget) capacity() int {
+	if b == nil {
+		return 0
+	}
+	return int(b.bucket.burst)
+}
+
+// budgetRelayClient is a RelayClient whose requests wait for the outbound request budget, until their context is
+// done. getPayload requests never wait, as a missing payload misses the slot, but take a token if available.
+type budgetRelayClient struct {
+	client   RelayClient
+	budget   *outboundBudget
+	throttle func(endpoint, outcome string)
+	pool     *poolUsage
+}
+
+func (c *budgetRelayClient) Do(req *http.Request) (*http.Response, error) {
+	endpoint := relayEndpoint(req.URL.Path)
+	wait := c.budget.tryTake()
+	if wait > 0 && endpoint != "get_payload" {
+		c.throttle(endpoint, "delayed")
+		start := c.budget.clock.Now()
+		defer func() { c.pool.waited(c.budget.clock.Now().Sub(start)) }()
+		for wait > 0 {
+			select {
+			case <-req.Context().Done():
+				c.throttle(endpoint, "dropped")
+				return nil, fmt.Errorf("%w: %v", errOutboundBudgetExhausted, req.Context().Err())
+			case <-c.budget.clock.After(wait):
+			}
+			wait = c.budget.tryTake()
+		}
+	}
+	return c.client.Do(req)
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboundBudget(t *testing.T) {
+	require.Nil(t, newOutboundBudget(0, 10, systemClock{}))
+
+	backend := newTestBackend(t, 1, time.Second)
+	clock := newFakeClock(time.Now())
+	backend.boost.clock = clock
+	backend.boost.outboundBudget = newOutboundBudget(1, 2, clock)
+	relay := backend.relays[0].RelayEntry
+	client := backend.boost.relayClient(relay)
+
+	send := func(ctx context.Context, path string) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, relay.GetURI(path), nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+	pendingTimers := func() int {
+		clock.mu.Lock()
+		defer clock.mu.Unlock()
+		return len(clock.timers)
+	}
+
+	// Bursts are sent right away
+	require.NoError(t, send(context.Background(), pathStatus))
+	require.NoError(t, send(context.Background(), pathStatus))
+
+	// Further requests wait for the budget, except getPayload
+	done := make(chan error)
+	go func() { done <- send(context.Background(), pathStatus) }()
+	require.Eventually(t, func() bool { return pendingTimers() == 1 }, time.Second, time.Millisecond)
+	require.NoError(t, send(context.Background(), pathGetPayload))
+	select {
+	case <-done:
+		t.Fatal("request sent without budget")
+	default:
+	}
+	clock.advance(time.Second)
+	require.NoError(t, <-done)
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.outboundBudgetThrottled.WithLabelValues("status", "delayed")))
+
+	// Requests timing out while waiting are dropped
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { done <- send(ctx, pathRegisterValidator) }()
+	require.Eventually(t, func() bool { return pendingTimers() == 1 }, time.Second, time.Millisecond)
+	cancel()
+	err := <-done
+	require.ErrorIs(t, err, errOutboundBudgetExhausted)
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.outboundBudgetThrottled.WithLabelValues("register_validator", "dropped")))
+	require.Equal(t, 3, backend.relays[0].GetRequestCount(pathStatus))
+
+	// Which doesn't count against the relay
+	history := newRegistrationHistory()
+	history.record(relay.String(), err, time.Second)
+	require.Empty(t, history.relays)
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// PayloadVerificationPolicy is how optional checks of getPayload responses are handled when they risk exceeding the
+// time left to return the payload
+type PayloadVerificationPolicy string
+
+// Payload verification policies
+const (
+	// PayloadVerificationStrict runs all checks, even if the payload is returned too late for the slot
+	PayloadVerificationStrict PayloadVerificationPolicy = "strict"
+
+	// PayloadVerificationDegrade skips the optional checks whose expected duration exceeds the time left, and
+	// returns the payload unchecked
+	PayloadVerificationDegrade PayloadVerificationPolicy = "degrade"
+)
+
+// ParsePayloadVerificationPolicy parses a payload verification policy: strict or degrade
+func ParsePayloadVerificationPolicy(s string) (PayloadVerificationPolicy, error) {
+	switch policy := PayloadVerificationPolicy(strings.ToLower(strings.TrimSpace(s))); policy {
+	case PayloadVerificationStrict, PayloadVerificationDegrade:
+		return policy, nil
+	case "":
+		return PayloadVerificationStrict, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrInvalidPayloadVerificationPolicy, s)
+}
+
+// Optional checks of getPayload responses
+const (
+	// payloadCheckRoots recomputes the transactions root of payloads of trusted relays (-verify-payload-roots). The
+	// payloads of untrusted relays are always checked.
+	payloadCheckRoots = "roots"
+)
+
+// payloadCheckCosts keeps the moving average of the duration of each optional payload check
+type payloadCheckCosts map[string]*validationCost
+
+func newPayloadCheckCosts() payloadCheckCosts {
+	return payloadCheckCosts{payloadCheckRoots: &validationCost{}}
+}
+
+// payloadDeadline returns the time by which the payload of a getPayload request must be returned: the deadline of the
+// request, or PayloadVerificationDeadline into the slot if earlier. Zero without deadline.
+func (m *BoostService) payloadDeadline(ctx context.Context, slot uint64) time.Time {
+	deadline, _ := ctx.Deadline()
+	if m.payloadCheckDeadline > 0 && m.genesisTime > 0 {
+		slotDeadline := m.chain.slotStartTime(m.genesisTime, slot).Add(m.payloadCheckDeadline)
+		if deadline.IsZero() || slotDeadline.Before(deadline) {
+			deadline = slotDeadline
+		}
+	}
+	return deadline
+}
+
+// skipPayloadCheck returns whether an optional payload check is skipped under the degrade policy, as its expected
+// duration exceeds the time left until deadline. Skipped checks are logged and counted.
+func (m *BoostService) skipPayloadCheck(log *logrus.Entry, check string, deadline time.Time) bool {
+	if m.payloadCheckPolicy != PayloadVerificationDegrade || deadline.IsZero() {
+		return false
+	}
+	cost := m.payloadCheckCosts[check].get()
+	left := deadline.Sub(m.clock.Now())
+	if left > 0 && left >= cost {
+		return false
+	}
+	m.metrics.payloadChecksSkipped.WithLabelValues(check).Inc()
+	log.WithFields(logrus.Fields{
+		"check":        check,
+		"expectedCost": cost.String(),
+		"timeLeft":     left.String(),
+	}).Warn("skipping payload check, which risks exceeding the slot deadline")
+	return true
+}
+
+// runPayloadCheck runs an optional payload check, and adds its duration to the moving average of the check
+func (m *BoostService) runPayloadCheck(check string, run func() error) error {
+	start := m.clock.Now()
+	err := run()
+	m.payloadCheckCosts[check].add(m.clock.Now().Sub(start))
+	return err
+}
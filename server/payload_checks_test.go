@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePayloadVerificationPolicy(t *testing.T) {
+	for s, expected := range map[string]PayloadVerificationPolicy{"": PayloadVerificationStrict, "strict": PayloadVerificationStrict, " Degrade": PayloadVerificationDegrade} {
+		policy, err := ParsePayloadVerificationPolicy(s)
+		require.NoError(t, err)
+		require.Equal(t, expected, policy)
+	}
+	_, err := ParsePayloadVerificationPolicy("skip")
+	require.ErrorIs(t, err, ErrInvalidPayloadVerificationPolicy)
+}
+
+func TestSkipPayloadCheck(t *testing.T) {
+	backend := newTestBackend(t, 1, time.Second)
+	clock := newFakeClock(time.Unix(1663000000, 0))
+	backend.boost.clock = clock
+	backend.boost.genesisTime = 1663000000 - 12
+	backend.boost.payloadCheckDeadline = 4 * time.Second
+	backend.boost.payloadCheckCosts[payloadCheckRoots].add(100 * time.Millisecond)
+
+	// The slot deadline applies if earlier than the deadline of the request
+	ctx, cancel := context.WithDeadline(context.Background(), clock.Now().Add(10*time.Second))
+	defer cancel()
+	deadline := backend.boost.payloadDeadline(ctx, 1)
+	require.Equal(t, clock.Now().Add(4*time.Second), deadline)
+	require.Equal(t, clock.Now().Add(10*time.Second), backend.boost.payloadDeadline(ctx, 2))
+
+	// Checks run in strict mode, and with enough time left
+	clock.advance(3950 * time.Millisecond)
+	require.False(t, backend.boost.skipPayloadCheck(backend.boost.log, payloadCheckRoots, deadline))
+	backend.boost.payloadCheckPolicy = PayloadVerificationDegrade
+	require.True(t, backend.boost.skipPayloadCheck(backend.boost.log, payloadCheckRoots, deadline))
+	require.False(t, backend.boost.skipPayloadCheck(backend.boost.log, payloadCheckRoots, deadline.Add(time.Second)))
+	require.False(t, backend.boost.skipPayloadCheck(backend.boost.log, payloadCheckRoots, time.Time{}))
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.payloadChecksSkipped.WithLabelValues(payloadCheckRoots)))
+}
+
+func TestGetPayloadSkipChecks(t *testing.T) {
+	backend := newTestBackend(t, 1, time.Second)
+	backend.boost.verifyPayloadRoots = true
+	backend.boost.payloadCheckPolicy = PayloadVerificationDegrade
+	backend.boost.payloadCheckDeadline = 4 * time.Second
+	backend.boost.genesisTime = 1 // the deadline of slot 1 is long past
+
+	payload := makeTestPayload(t, 10, 200)
+	backend.relays[0].GetPayloadResponse = &GetPayloadResponse{Version: "bellatrix", Data: payload}
+	header := makeTestHeader(t, payload)
+	header.TransactionsRoot = types.Root{0x01}
+	blindedBlock := makeTestBlindedBlock(header)
+
+	// The optional check is skipped, so the payload is returned unchecked
+	rr := backend.request(t, http.MethodPost, pathGetPayload, blindedBlock)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.payloadChecksSkipped.WithLabelValues(payloadCheckRoots)))
+
+	// The payloads of untrusted relays are always checked
+	backend.boost.relays[0].Untrusted = true
+	rr = backend.request(t, http.MethodPost, pathGetPayload, blindedBlock)
+	require.Equal(t, http.StatusBadGateway, rr.Code, rr.Body.String())
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// payloadAtRiskEvent is published when no relay delivered the payload of a served header within the payload
+// delivery SLA
+type payloadAtRiskEvent struct {
+	Slot           uint64     `json:"slot,string"`
+	BlockHash      string     `json:"block_hash"`
+	Pubkey         string     `json:"pubkey"`
+	Relays         []string   `json:"relays"` // relays called for the payload which did not respond yet
+	HeaderServedAt time.Time  `json:"header_served_at"`
+	Deadline       *time.Time `json:"deadline,omitempty"` // end of the slot, if the genesis time is known
+}
+
+// slotDeadline returns the end of a slot, after which its payload is of no use, or nil if the genesis time is unknown
+func (m *BoostService) slotDeadline(slot uint64) *time.Time {
+	if m.genesisTime == 0 {
+		return nil
+	}
+	deadline := m.chain.slotStartTime(m.genesisTime, slot+1)
+	return &deadline
+}
+
+// payloadAtRisk alerts that the payload of a served bid was not delivered within the payload delivery SLA, and
+// counts an SLA violation for each relay which did not respond yet
+func (m *BoostService) payloadAtRisk(log *logrus.Entry, slot uint64, bid bidResp, pendingRelays []string) {
+	sort.Strings(pendingRelays)
+	event := payloadAtRiskEvent{
+		Slot:           slot,
+		BlockHash:      bid.blockHash,
+		Pubkey:         bid.pubkey,
+		Relays:         pendingRelays,
+		HeaderServedAt: bid.servedAt,
+		Deadline:       m.slotDeadline(slot),
+	}
+	for _, relay := range pendingRelays {
+		m.metrics.relayPayloadSLAExceeded.WithLabelValues(relay).Inc()
+	}
+	m.metrics.payloadsAtRisk.Inc()
+
+	log = log.WithFields(logrus.Fields{
+		"pendingRelays": pendingRelays,
+		"sla":           m.payloadDeliverySLA,
+	})
+	if event.Deadline != nil {
+		log = log.WithField("deadline", *event.Deadline)
+	}
+	log.Warn("payload at risk: not delivered within the payload delivery SLA")
+	m.events.publish(EventPayloadAtRisk, event)
+}
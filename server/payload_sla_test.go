@@ -0,0 +1,86 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventStream(t *testing.T) {
+	stream := newEventStream()
+	events, unsubscribe := stream.subscribe()
+
+	stream.publish(EventPayloadAtRisk, 1)
+	require.Equal(t, streamEvent{Type: EventPayloadAtRisk, Data: 1}, <-events)
+
+	// Events are dropped for slow subscribers, without blocking
+	for i := 0; i < eventBufferSize+1; i++ {
+		stream.publish(EventPayloadAtRisk, i)
+	}
+	require.Len(t, events, eventBufferSize)
+
+	unsubscribe()
+	require.Empty(t, stream.subscribers)
+}
+
+func TestPayloadAtRisk(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	payload := types.SignedBlindedBeaconBlock{
+		Message: &types.BlindedBeaconBlock{
+			Slot: 1,
+			Body: &types.BlindedBeaconBlockBody{
+				Eth1Data:      &types.Eth1Data{},
+				SyncAggregate: &types.SyncAggregate{},
+				ExecutionPayloadHeader: &types.ExecutionPayloadHeader{
+					BlockHash: _HexToHash(hash),
+				},
+			},
+		},
+	}
+
+	backend := newTestBackend(t, 1, time.Second)
+	backend.boost.debugAPI = true
+	backend.boost.payloadDeliverySLA = 50 * time.Millisecond
+	relay := backend.relays[0]
+	relay.EchoRequestHashes = true
+
+	// Subscribe to the event stream endpoint
+	server := httptest.NewServer(backend.boost.getRouter())
+	defer server.Close()
+	resp, err := http.Get(server.URL + pathDebugEvents)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	rr := backend.request(t, http.MethodGet, fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey), nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	// The payload is delivered late, after it was reported at risk
+	relay.ResponseDelay = 150 * time.Millisecond
+	rr = backend.request(t, http.MethodPost, pathGetPayload, payload)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.payloadsAtRisk))
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.relayPayloadSLAExceeded.WithLabelValues(relay.RelayEntry.String())))
+	require.Equal(t, 1, testutil.CollectAndCount(backend.boost.metrics.relayPayloadDelay))
+
+	// The event follows those of the getHeader fan-out
+	reader := bufio.NewReader(resp.Body)
+	data := readStreamEvent(t, reader, EventPayloadAtRisk)
+	require.True(t, strings.HasPrefix(data, `{"slot":"1","block_hash":"`+hash+`","pubkey":"`+pubkey+`","relays":["`+relay.RelayEntry.String()+`"]`), data)
+
+	// No alert once the payload is delivered within the SLA
+	relay.ResponseDelay = 0
+	backend.boost.payloadDeliverySLA = time.Second
+	rr = backend.request(t, http.MethodPost, pathGetPayload, payload)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.payloadsAtRisk))
+}
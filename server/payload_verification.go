@@ -0,0 +1,88 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/flashbots/go-boost-utils/types"
+)
+
+// verifyPayloadRoots recomputes the transactions and withdrawals roots of a payload delivered by a relay, and
+// verifies that they match the blinded header signed by the proposer, as do all other header fields. This protects
+// the proposer from relays delivering another payload than the one committed to.
+func verifyPayloadRoots(header *ExecutionPayloadHeader, payload *ExecutionPayload) error {
+	if err := verifyWithdrawalsRoot(header, payload); err != nil {
+		return err
+	}
+	payloadHeader, err := payloadToHeader(payload)
+	if err != nil {
+		return err
+	}
+	if payloadHeader.TransactionsRoot != header.TransactionsRoot {
+		return fmt.Errorf("%w: header %s, payload %s", errTransactionsRootMismatch, header.TransactionsRoot.String(), payloadHeader.TransactionsRoot.String())
+	}
+
+	headerRoot, err := header.HashTreeRoot()
+	if err != nil {
+		return err
+	}
+	payloadHeaderRoot, err := payloadHeader.HashTreeRoot()
+	if err != nil {
+		return err
+	}
+	if headerRoot != payloadHeaderRoot {
+		return fmt.Errorf("%w: header root %#x, payload header root %#x", errPayloadHeaderMismatch, headerRoot, payloadHeaderRoot)
+	}
+	return nil
+}
+
+// verifyWithdrawalsRoot verifies that the withdrawals of a payload match the withdrawals root of the blinded header
+// signed by the proposer. Unlike the transactions, there are few withdrawals to hash, so they are always verified.
+func verifyWithdrawalsRoot(header *ExecutionPayloadHeader, payload *ExecutionPayload) error {
+	if header.WithdrawalsRoot == nil && payload.Withdrawals == nil {
+		return nil // Bellatrix
+	}
+	if header.WithdrawalsRoot == nil || payload.Withdrawals == nil {
+		return fmt.Errorf("%w: withdrawals in header %t, in payload %t", errWithdrawalsRootMismatch, header.WithdrawalsRoot != nil, payload.Withdrawals != nil)
+	}
+	root, err := payload.Withdrawals.HashTreeRoot()
+	if err != nil {
+		return err
+	}
+	if root != *header.WithdrawalsRoot {
+		return fmt.Errorf("%w: header %s, payload %s", errWithdrawalsRootMismatch, header.WithdrawalsRoot.String(), root.String())
+	}
+	return nil
+}
+
+// payloadToHeader is types.PayloadToPayloadHeader, hashing the transactions root in parallel, and the withdrawals
+// root of Capella payloads
+func payloadToHeader(payload *ExecutionPayload) (*ExecutionPayloadHeader, error) {
+	txRoot, err := transactionsRoot(payload.Transactions)
+	if err != nil {
+		return nil, err
+	}
+	header := &ExecutionPayloadHeader{ExecutionPayloadHeader: types.ExecutionPayloadHeader{
+		ParentHash:       payload.ParentHash,
+		FeeRecipient:     payload.FeeRecipient,
+		StateRoot:        payload.StateRoot,
+		ReceiptsRoot:     payload.ReceiptsRoot,
+		LogsBloom:        payload.LogsBloom,
+		Random:           payload.Random,
+		BlockNumber:      payload.BlockNumber,
+		GasLimit:         payload.GasLimit,
+		GasUsed:          payload.GasUsed,
+		Timestamp:        payload.Timestamp,
+		ExtraData:        types.ExtraData(payload.ExtraData),
+		BaseFeePerGas:    payload.BaseFeePerGas,
+		BlockHash:        payload.BlockHash,
+		TransactionsRoot: txRoot,
+	}}
+	if payload.Withdrawals != nil {
+		withdrawalsRoot, err := payload.Withdrawals.HashTreeRoot()
+		if err != nil {
+			return nil, err
+		}
+		header.WithdrawalsRoot = &withdrawalsRoot
+	}
+	return header, nil
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+// makeTestPayload returns a payload with numTxs random transactions of txSize bytes
+func makeTestPayload(tb testing.TB, numTxs, txSize int) *ExecutionPayload {
+	tb.Helper()
+	payload := &ExecutionPayload{ExecutionPayload: types.ExecutionPayload{
+		BlockHash:     _HexToHash("0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab1"),
+		BlockNumber:   12345,
+		BaseFeePerGas: types.IntToU256(7),
+		Transactions:  make([]hexutil.Bytes, numTxs),
+	}}
+	for i := range payload.Transactions {
+		payload.Transactions[i] = make([]byte, txSize)
+		_, err := rand.Read(payload.Transactions[i])
+		require.NoError(tb, err)
+	}
+	return payload
+}
+
+// makeTestHeader returns the header of a payload, as computed by go-boost-utils
+func makeTestHeader(tb testing.TB, payload *ExecutionPayload) *ExecutionPayloadHeader {
+	tb.Helper()
+	header, err := types.PayloadToPayloadHeader(&payload.ExecutionPayload)
+	require.NoError(tb, err)
+	return &ExecutionPayloadHeader{ExecutionPayloadHeader: *header}
+}
+
+// makeTestBlindedBlock returns a blinded block of slot 1 with the given header
+func makeTestBlindedBlock(header *ExecutionPayloadHeader) *SignedBlindedBeaconBlock {
+	return &SignedBlindedBeaconBlock{
+		Message: &BlindedBeaconBlock{
+			Slot: 1,
+			Body: &BlindedBeaconBlockBody{
+				BlindedBeaconBlockBody: types.BlindedBeaconBlockBody{
+					Eth1Data:      &types.Eth1Data{},
+					SyncAggregate: &types.SyncAggregate{},
+				},
+				ExecutionPayloadHeader: header,
+			},
+		},
+	}
+}
+
+func TestVerifyPayloadRoots(t *testing.T) {
+	payload := makeTestPayload(t, 10, 200)
+	header := makeTestHeader(t, payload)
+	require.NoError(t, verifyPayloadRoots(header, payload))
+
+	tampered := *payload
+	tampered.Transactions = payload.Transactions[1:]
+	require.ErrorIs(t, verifyPayloadRoots(header, &tampered), errTransactionsRootMismatch)
+
+	tampered = *payload
+	tampered.GasUsed = 1
+	require.ErrorIs(t, verifyPayloadRoots(header, &tampered), errPayloadHeaderMismatch)
+}
+
+func TestGetPayloadVerifyRoots(t *testing.T) {
+	backend := newTestBackend(t, 1, time.Second)
+	backend.boost.verifyPayloadRoots = true
+
+	payload := makeTestPayload(t, 10, 200)
+	backend.relays[0].GetPayloadResponse = &GetPayloadResponse{Version: "bellatrix", Data: payload}
+	header := makeTestHeader(t, payload)
+
+	rr := backend.request(t, http.MethodPost, pathGetPayload, makeTestBlindedBlock(header))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	// The relay delivers a payload with other transactions than the signed header
+	tampered := *header
+	tampered.TransactionsRoot = types.Root{0x01}
+	rr = backend.request(t, http.MethodPost, pathGetPayload, makeTestBlindedBlock(&tampered))
+	require.Equal(t, http.StatusBadGateway, rr.Code, rr.Body.String())
+}
+
+func TestPayloadEscrowVerification(t *testing.T) {
+	// Escrow verification does not depend on -verify-payload-roots, nor on the bid having been served by this
+	// instance: the payload is verified against the header signed by the proposer
+	backend := newTestBackend(t, 1, time.Second)
+	backend.boost.relays[0].EscrowVerification = true
+
+	payload := makeTestPayload(t, 10, 200)
+	backend.relays[0].GetPayloadResponse = &GetPayloadResponse{Version: "bellatrix", Data: payload}
+	header := makeTestHeader(t, payload)
+
+	rr := backend.request(t, http.MethodPost, pathGetPayload, makeTestBlindedBlock(header))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	// The relay changes the content of the payload after the header was signed
+	tampered := *header
+	tampered.TransactionsRoot = types.Root{0x01}
+	rr = backend.request(t, http.MethodPost, pathGetPayload, makeTestBlindedBlock(&tampered))
+	require.Equal(t, http.StatusBadGateway, rr.Code, rr.Body.String())
+}
+
+func BenchmarkTransactionsRoot(b *testing.B) {
+	for _, numTxs := range []int{100, 500, 2000} {
+		payload := makeTestPayload(b, numTxs, 300)
+		b.Run(fmt.Sprintf("%d txs", numTxs), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := transactionsRoot(payload.Transactions); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkVerifyPayloadRoots(b *testing.B) {
+	for _, numTxs := range []int{100, 500, 2000} {
+		payload := makeTestPayload(b, numTxs, 300)
+		header := makeTestHeader(b, payload)
+		b.Run(fmt.Sprintf("%d txs", numTxs), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := verifyPayloadRoots(header, payload); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// pathPeerBids is the endpoint receiving the bid summaries of peer instances
+	pathPeerBids = "/mev-boost/v1/peer/bids"
+
+	// HeaderPeerSecret is the header with the secret shared by peer instances
+	HeaderPeerSecret = "X-MEV-Boost-Peer-Secret"
+)
+
+// peerBid is a validated bid of a relay, as shared with peers
+type peerBid struct {
+	Relay     string `json:"relay"`
+	BlockHash string `json:"block_hash"`
+	Value     string `json:"value"`
+}
+
+// peerBidSummary summarizes the validated bids of a getHeader request and the served bid, without the payloads
+type peerBidSummary struct {
+	Slot            uint64    `json:"slot,string"`
+	ParentHash      string    `json:"parent_hash"`
+	Pubkey          string    `json:"pubkey"`
+	ServedBlockHash string    `json:"served_block_hash,omitempty"`
+	ServedValue     string    `json:"served_value,omitempty"` // [wei]
+	Bids            []peerBid `json:"bids"`
+}
+
+// newPeerBidSummary returns the summary of the bids of a getHeader request
+func newPeerBidSummary(slot uint64, parentHash, pubkey string, bid bidResp) peerBidSummary {
+	summary := peerBidSummary{Slot: slot, ParentHash: parentHash, Pubkey: pubkey, Bids: []peerBid{}}
+	if bid.blockHash != "" && bid.valueWei != nil {
+		summary.ServedBlockHash = bid.blockHash
+		summary.ServedValue = bid.valueWei.String()
+		for _, relay := range bid.relays {
+			summary.Bids = append(summary.Bids, peerBid{Relay: relay, BlockHash: bid.blockHash, Value: bid.valueWei.String()})
+		}
+	}
+	for _, rejection := range bid.rejections {
+		if rejection.Reason == BidRejectionLowerValue { // valid bids which were outbid
+			summary.Bids = append(summary.Bids, peerBid{Relay: rejection.Relay, BlockHash: rejection.BlockHash, Value: rejection.Value})
+		}
+	}
+	return summary
+}
+
+// maxValue returns the highest value of the bids, or nil without bids
+func (s peerBidSummary) maxValue() *big.Int {
+	var max *big.Int
+	for _, bid := range s.Bids {
+		if value, ok := new(big.Int).SetString(bid.Value, 10); ok && (max == nil || value.Cmp(max) > 0) {
+			max = value
+		}
+	}
+	return max
+}
+
+// peerSlotBids are the bid summaries of a getHeader request, of this instance and its peers
+type peerSlotBids struct {
+	local *peerBidSummary
+	peers map[string]peerBidSummary
+	t     time.Time
+}
+
+// peering shares bid summaries with peer instances run by the same operator
+type peering struct {
+	urls       []string
+	secret     *Secret
+	httpClient http.Client
+
+	mu   sync.Mutex
+	bids map[prefetchKey]*peerSlotBids
+}
+
+func newPeering(urls []string, secret *Secret, timeout time.Duration) *peering {
+	if secret.Value() == "" {
+		return nil
+	}
+	return &peering{
+		urls:       urls,
+		secret:     secret,
+		httpClient: http.Client{Timeout: timeout},
+		bids:       make(map[prefetchKey]*peerSlotBids),
+	}
+}
+
+// slotBids returns the bid summaries of a getHeader request, added if needed. The caller must hold the lock.
+func (p *peering) slotBids(s peerBidSummary, t time.Time) *peerSlotBids {
+	key := newPrefetchKey(s.Slot, s.ParentHash, s.Pubkey)
+	bids, ok := p.bids[key]
+	if !ok {
+		bids = &peerSlotBids{peers: make(map[string]peerBidSummary), t: t}
+		p.bids[key] = bids
+	}
+	return bids
+}
+
+// setLocal stores the summary of this instance, and returns the summaries received from peers so far
+func (p *peering) setLocal(s peerBidSummary, t time.Time) map[string]peerBidSummary {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	bids := p.slotBids(s, t)
+	bids.local = &s
+	peers := make(map[string]peerBidSummary, len(bids.peers))
+	for peer, summary := range bids.peers {
+		peers[peer] = summary
+	}
+	return peers
+}
+
+// addPeer stores the summary of a peer, and returns the summary of this instance if there is one yet
+func (p *peering) addPeer(peer string, s peerBidSummary, t time.Time) *peerBidSummary {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	bids := p.slotBids(s, t)
+	bids.peers[peer] = s
+	return bids.local
+}
+
+// prune removes the summaries of requests older than maxAge
+func (p *peering) prune(now time.Time, maxAge time.Duration) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, bids := range p.bids {
+		if now.Sub(bids.t) > maxAge {
+			delete(p.bids, key)
+		}
+	}
+}
+
+// send posts a bid summary to a peer
+func (p *peering) send(ctx context.Context, url string, s peerBidSummary) error {
+	body, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url+pathPeerBids, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderPeerSecret, p.secret.Value())
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("peer responded with status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// gossipBids shares the bids of a getHeader request with the peers, and cross-checks them with the bids the peers
+// shared already
+func (m *BoostService) gossipBids(slot uint64, parentHash, pubkey string, bid bidResp) {
+	summary := newPeerBidSummary(slot, parentHash, pubkey, bid)
+	for peer, peerSummary := range m.peering.setLocal(summary, m.clock.Now()) {
+		m.comparePeerBids(peer, summary, peerSummary)
+	}
+
+	for _, url := range m.peering.urls {
+		go func(url string) {
+			log := m.log.WithFields(logrus.Fields{
+				"method": "peerBids",
+				"peer":   url,
+				"slot":   slot,
+			})
+			if err := m.peering.send(context.Background(), url, summary); err != nil {
+				m.metrics.peerGossipErrors.WithLabelValues(url).Inc()
+				log.WithError(err).Warn("could not send bids to peer")
+				return
+			}
+			log.Debug("sent bids to peer")
+		}(url)
+	}
+}
+
+// handlePeerBids receives the bid summary of a peer, and cross-checks it with the bids of this instance
+func (m *BoostService) handlePeerBids(w http.ResponseWriter, req *http.Request) {
+	if subtle.ConstantTimeCompare([]byte(req.Header.Get(HeaderPeerSecret)), []byte(m.peering.secret.Value())) != 1 {
+		m.respondError(w, http.StatusUnauthorized, "invalid peer secret")
+		return
+	}
+
+	summary := peerBidSummary{}
+	if err := DecodeJSON(req.Body, &summary); err != nil {
+		m.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if summary.Slot == 0 || summary.ParentHash == "" || summary.Pubkey == "" {
+		m.respondError(w, http.StatusBadRequest, "missing slot, parent_hash or pubkey")
+		return
+	}
+
+	peer, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		peer = req.RemoteAddr
+	}
+	if local := m.peering.addPeer(peer, summary, m.clock.Now()); local != nil {
+		m.comparePeerBids(peer, *local, summary)
+	}
+	m.respondOK(w, nilResponse)
+}
+
+// comparePeerBids compares the served bid with the best bid of a peer for the same getHeader request, and the bids
+// of the relays which delivered bids to both instances
+func (m *BoostService) comparePeerBids(peer string, local, remote peerBidSummary) {
+	log := m.log.WithFields(logrus.Fields{
+		"method": "peerBids",
+		"peer":   peer,
+		"slot":   local.Slot,
+	})
+
+	servedValue, ok := new(big.Int).SetString(local.ServedValue, 10)
+	if !ok {
+		servedValue = big.NewInt(0)
+	}
+	if peerValue := remote.maxValue(); peerValue != nil {
+		result := "at_peer"
+		if servedValue.Cmp(peerValue) < 0 {
+			result = "below_peer"
+			log.WithFields(logrus.Fields{
+				"servedValue": servedValue.String(),
+				"peerValue":   peerValue.String(),
+			}).Info("peer received a better bid")
+		}
+		m.metrics.peerBidComparisons.WithLabelValues(result).Inc()
+	}
+
+	localBids := make(map[string]peerBid, len(local.Bids))
+	for _, bid := range local.Bids {
+		localBids[bid.Relay] = bid
+	}
+	for _, remoteBid := range remote.Bids {
+		localBid, ok := localBids[remoteBid.Relay]
+		if !ok || localBid.BlockHash == remoteBid.BlockHash {
+			continue
+		}
+		m.metrics.peerRelayBidMismatches.WithLabelValues(remoteBid.Relay).Inc()
+		log.WithFields(logrus.Fields{
+			"relay":         remoteBid.Relay,
+			"blockHash":     localBid.BlockHash,
+			"value":         localBid.Value,
+			"peerBlockHash": remoteBid.BlockHash,
+			"peerValue":     remoteBid.Value,
+		}).Info("relay delivered different bids to the peer")
+	}
+}
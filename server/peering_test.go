@@ -0,0 +1,82 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerBidSummary(t *testing.T) {
+	backend := newTestBackend(t, 2, time.Second)
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	backend.relays[1].GetHeaderResponse = backend.relays[1].MakeGetHeaderResponse(1, "0xb38385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7", pubkey)
+
+	result := backend.boost.requestBids(context.Background(), testLog, 1, hash, pubkey, "")
+	summary := newPeerBidSummary(1, hash, pubkey, result.bid)
+	require.Equal(t, "12345", summary.ServedValue)
+	require.ElementsMatch(t, []peerBid{
+		{Relay: backend.relays[0].RelayEntry.String(), BlockHash: hash, Value: "12345"},
+		{Relay: backend.relays[1].RelayEntry.String(), BlockHash: "0xb38385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7", Value: "1"},
+	}, summary.Bids)
+	require.Equal(t, "12345", summary.maxValue().String())
+
+	require.Nil(t, newPeerBidSummary(1, hash, pubkey, bidResp{}).maxValue())
+}
+
+func TestComparePeerBids(t *testing.T) {
+	backend := newTestBackend(t, 1, time.Second)
+	local := peerBidSummary{Slot: 1, ServedValue: "10", Bids: []peerBid{{Relay: "relay1", BlockHash: "0xa", Value: "10"}}}
+
+	backend.boost.comparePeerBids("peer", local, peerBidSummary{Slot: 1, Bids: []peerBid{{Relay: "relay1", BlockHash: "0xa", Value: "10"}}})
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.peerBidComparisons.WithLabelValues("at_peer")))
+	require.Equal(t, 0.0, testutil.ToFloat64(backend.boost.metrics.peerRelayBidMismatches.WithLabelValues("relay1")))
+
+	backend.boost.comparePeerBids("peer", local, peerBidSummary{Slot: 1, Bids: []peerBid{{Relay: "relay1", BlockHash: "0xb", Value: "20"}}})
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.peerBidComparisons.WithLabelValues("below_peer")))
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.peerRelayBidMismatches.WithLabelValues("relay1")))
+}
+
+func TestPeerBidGossip(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	path := fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey)
+
+	// The peer served a bid first, and receives the bids of the other instance later
+	peer := newTestBackend(t, 1, time.Second)
+	peer.boost.peering = newPeering(nil, StaticSecret("secret"), time.Second)
+	peer.relays[0].GetHeaderResponse = peer.relays[0].MakeGetHeaderResponse(1, hash, pubkey)
+	rr := peer.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	server := httptest.NewServer(peer.boost.getRouter())
+	defer server.Close()
+
+	backend := newTestBackend(t, 1, time.Second)
+	backend.boost.peering = newPeering([]string{server.URL}, StaticSecret("secret"), time.Second)
+	rr = backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(peer.boost.metrics.peerBidComparisons.WithLabelValues("below_peer")) == 1
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, 0.0, testutil.ToFloat64(backend.boost.metrics.peerGossipErrors.WithLabelValues(server.URL)))
+
+	// Peers must know the secret
+	resp, err := http.Post(server.URL+pathPeerBids, "application/json", bytes.NewReader([]byte(`{"slot":"1"}`)))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	backend.boost.peering = newPeering([]string{server.URL}, StaticSecret("wrong"), time.Second)
+	rr = backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(backend.boost.metrics.peerGossipErrors.WithLabelValues(server.URL)) == 1
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"net"
+	"time"
+)
+
+// platform is the integration with the service manager of the operating system: the listener it passes to the
+// process, and the readiness and liveness notifications it expects
+type platform interface {
+	// listener returns the listener passed by the service manager, or nil to listen on the configured address
+	listener() (net.Listener, error)
+	notifyReady() error
+	// watchdogInterval returns the interval at which notifyWatchdog must be called, or 0 if not needed
+	watchdogInterval() time.Duration
+	notifyWatchdog() error
+}
+
+// systemdPlatform supports systemd socket activation, readiness notification and watchdog
+type systemdPlatform struct{}
+
+func (systemdPlatform) listener() (net.Listener, error) { return systemdListener() }
+func (systemdPlatform) notifyReady() error              { return sdNotify("READY=1") }
+func (systemdPlatform) watchdogInterval() time.Duration { return sdWatchdogInterval() }
+func (systemdPlatform) notifyWatchdog() error           { return sdNotify("WATCHDOG=1") }
+
+// nopPlatform is used where the service manager doesn't pass sockets or expect notifications, such as the Windows
+// service control manager, which is handled by the cli
+type nopPlatform struct{}
+
+func (nopPlatform) listener() (net.Listener, error) { return nil, nil }
+func (nopPlatform) notifyReady() error              { return nil }
+func (nopPlatform) watchdogInterval() time.Duration { return 0 }
+func (nopPlatform) notifyWatchdog() error           { return nil }
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testPlatform passes a listener to the service, and records the notifications
+type testPlatform struct {
+	l        net.Listener
+	ready    chan struct{}
+	watchdog chan struct{}
+}
+
+func (p *testPlatform) listener() (net.Listener, error) { return p.l, nil }
+func (p *testPlatform) watchdogInterval() time.Duration { return 10 * time.Millisecond }
+
+func (p *testPlatform) notifyReady() error {
+	close(p.ready)
+	return nil
+}
+
+func (p *testPlatform) notifyWatchdog() error {
+	select {
+	case p.watchdog <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func TestStartHTTPServerPlatform(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	backend := newTestBackend(t, 1, time.Second)
+	p := &testPlatform{l: l, ready: make(chan struct{}), watchdog: make(chan struct{}, 1)}
+	backend.boost.platform = p
+	backend.boost.listenAddr = "invalid address, the listener of the platform is used"
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- backend.boost.StartHTTPServer()
+	}()
+
+	select {
+	case <-p.ready:
+	case err := <-errCh:
+		t.Fatalf("server failed to start: %v", err)
+	case <-time.After(time.Second):
+		t.Fatal("no readiness notification")
+	}
+
+	resp, err := http.Get("http://" + l.Addr().String() + pathStatus)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	select {
+	case <-p.watchdog:
+	case <-time.After(time.Second):
+		t.Fatal("no watchdog notification")
+	}
+
+	require.Equal(t, errServerAlreadyRunning, backend.boost.StartHTTPServer())
+	require.NoError(t, backend.boost.Shutdown(context.Background()))
+	require.NoError(t, <-errCh)
+}
+
+func TestNopPlatform(t *testing.T) {
+	p := nopPlatform{}
+	l, err := p.listener()
+	require.NoError(t, err)
+	require.Nil(t, l)
+	require.NoError(t, p.notifyReady())
+	require.Equal(t, time.Duration(0), p.watchdogInterval())
+}
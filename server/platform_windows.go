@@ -0,0 +1,7 @@
+//go:build windows
+
+package server
+
+func defaultPlatform() platform {
+	return nopPlatform{}
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Names of the instrumented pools, queues and semaphores
+const (
+	poolServerConnections = "server_connections" // connections of the HTTP server, bounded by the connection limit
+	poolBidValidations    = "bid_validations"    // bid signature validations in progress, bounded by the CPUs
+	poolRelayRequests     = "relay_requests"     // requests to relays in flight, unbounded
+	poolOutboundBudget    = "outbound_budget"    // tokens of the outbound request budget, bounded by its burst
+)
+
+// poolUsage exports the use of an internal pool, queue or semaphore, and the waits for a free slot when it is
+// saturated. Its utilization is mev_boost_pool_in_use / mev_boost_pool_capacity.
+type poolUsage struct {
+	inUse    prometheus.Gauge
+	waits    prometheus.Counter
+	waitTime prometheus.Observer
+}
+
+// pool returns the usage metrics of a pool with the given capacity, 0 if unbounded
+func (m *serviceMetrics) pool(name string, capacity int) *poolUsage {
+	if capacity > 0 {
+		m.poolCapacity.WithLabelValues(name).Set(float64(capacity))
+	}
+	return &poolUsage{
+		inUse:    m.poolInUse.WithLabelValues(name),
+		waits:    m.poolWaits.WithLabelValues(name),
+		waitTime: m.poolWaitTime.WithLabelValues(name),
+	}
+}
+
+// acquire marks a slot of the pool as used, and returns the function releasing it
+func (p *poolUsage) acquire() func() {
+	p.inUse.Inc()
+	return p.inUse.Dec
+}
+
+// waited records a wait for a free slot of the saturated pool
+func (p *poolUsage) waited(d time.Duration) {
+	p.waits.Inc()
+	p.waitTime.Observe(d.Seconds())
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"net/http"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolUsage(t *testing.T) {
+	metrics := newServiceMetrics(0)
+	pool := metrics.pool("test", 2)
+	require.Equal(t, 2.0, testutil.ToFloat64(metrics.poolCapacity.WithLabelValues("test")))
+
+	release := pool.acquire()
+	release2 := pool.acquire()
+	require.Equal(t, 2.0, testutil.ToFloat64(metrics.poolInUse.WithLabelValues("test")))
+	release()
+	release2()
+	require.Equal(t, 0.0, testutil.ToFloat64(metrics.poolInUse.WithLabelValues("test")))
+
+	pool.waited(10 * time.Millisecond)
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.poolWaits.WithLabelValues("test")))
+
+	// Unbounded pools have no capacity
+	metrics.pool("unbounded", 0)
+	require.Equal(t, 1, testutil.CollectAndCount(metrics.poolCapacity))
+}
+
+func TestRelayRequestsPool(t *testing.T) {
+	backend := newTestBackend(t, 1, time.Second)
+	require.Equal(t, float64(runtime.GOMAXPROCS(0)), testutil.ToFloat64(backend.boost.metrics.poolCapacity.WithLabelValues(poolBidValidations)))
+
+	// Requests are in flight until their response body is closed
+	relay := backend.relays[0].RelayEntry
+	req, err := http.NewRequest(http.MethodGet, relay.GetURI(pathStatus), nil)
+	require.NoError(t, err)
+	resp, err := backend.boost.relayClient(relay).Do(req)
+	require.NoError(t, err)
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.poolInUse.WithLabelValues(poolRelayRequests)))
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, 0.0, testutil.ToFloat64(backend.boost.metrics.poolInUse.WithLabelValues(poolRelayRequests)))
+}
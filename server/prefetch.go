@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// prefetchKey identifies the getHeader request a bid was prefetched for
+type prefetchKey struct {
+	slot       uint64
+	parentHash string
+	pubkey     string
+}
+
+// prefetchedBidsStore keeps the bids prefetched ahead of the CL's getHeader calls
+type prefetchedBidsStore struct {
+	mu   sync.Mutex
+	bids map[prefetchKey]getHeaderResult
+}
+
+func newPrefetchedBidsStore() *prefetchedBidsStore {
+	return &prefetchedBidsStore{bids: make(map[prefetchKey]getHeaderResult)}
+}
+
+func newPrefetchKey(slot uint64, parentHash, pubkey string) prefetchKey {
+	return prefetchKey{slot: slot, parentHash: strings.ToLower(parentHash), pubkey: strings.ToLower(pubkey)}
+}
+
+func (s *prefetchedBidsStore) get(slot uint64, parentHash, pubkey string) (getHeaderResult, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result, ok := s.bids[newPrefetchKey(slot, parentHash, pubkey)]
+	return result, ok
+}
+
+func (s *prefetchedBidsStore) set(slot uint64, parentHash, pubkey string, result getHeaderResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bids[newPrefetchKey(slot, parentHash, pubkey)] = result
+}
+
+// prune removes the bids received more than maxAge before now
+func (s *prefetchedBidsStore) prune(now time.Time, maxAge time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, result := range s.bids {
+		if now.Sub(result.bid.t) > maxAge {
+			delete(s.bids, k)
+		}
+	}
+}
+
+// isRegisteredValidator returns whether a validator registration was received for the pubkey
+func (m *BoostService) isRegisteredValidator(pubkey string) bool {
+	m.registrationsLock.Lock()
+	defer m.registrationsLock.Unlock()
+	_, ok := m.registrations[strings.ToLower(pubkey)]
+	return ok
+}
+
+// startPrefetchWorker requests bids ahead of the slots in which registered validators propose, so that the CL's
+// getHeader call can be served from the prefetched bid
+func (m *BoostService) startPrefetchWorker(ctx context.Context) {
+	log := m.log.WithField("method", "prefetch")
+
+	var genesisTime uint64
+	for {
+		var err error
+		genesisTime, err = m.beaconClient.genesisTime()
+		if err == nil {
+			break
+		}
+		log.WithError(err).Warn("could not get the genesis time from the beacon node")
+		if !m.scheduler.sleep(ctx, m.chain.slotDuration()) {
+			return
+		}
+	}
+
+	var duties []proposerDuty
+	dutiesEpoch := uint64(0)
+	lastSlot := uint64(0)
+	for {
+		slot := m.chain.slotAt(genesisTime, m.clock.Now()) + 1
+		if slot <= lastSlot {
+			slot = lastSlot + 1
+		}
+		lastSlot = slot
+
+		// Proposer duties are fetched once per epoch
+		if epoch := m.chain.epoch(slot); duties == nil || epoch != dutiesEpoch {
+			var err error
+			duties, err = m.beaconClient.proposerDuties(epoch)
+			if err != nil {
+				log.WithError(err).WithField("epoch", epoch).Warn("could not get proposer duties from the beacon node")
+				if !m.scheduler.sleep(ctx, m.chain.slotStartTime(genesisTime, slot).Sub(m.clock.Now())) {
+					return
+				}
+				continue
+			}
+			dutiesEpoch = epoch
+		}
+
+		if !m.scheduler.sleep(ctx, m.chain.slotStartTime(genesisTime, slot).Add(-m.prefetchLeadTime).Sub(m.clock.Now())) {
+			return
+		}
+		for _, duty := range duties {
+			if duty.Slot == slot && m.isRegisteredValidator(duty.Pubkey) && m.features.Enabled(FeatureBidPrefetch) {
+				m.prefetchBids(duty)
+			}
+		}
+	}
+}
+
+// prefetchBids requests bids for the proposal duty on top of the current head, and stores the best one
+func (m *BoostService) prefetchBids(duty proposerDuty) {
+	log := m.log.WithFields(logrus.Fields{
+		"method": "prefetch",
+		"slot":   duty.Slot,
+		"pubkey": duty.Pubkey,
+	})
+
+	parentHash, err := m.beaconClient.headExecutionBlockHash()
+	if err != nil {
+		log.WithError(err).Warn("could not get the head block from the beacon node")
+		return
+	}
+	log = log.WithField("parentHash", parentHash)
+
+	result := m.requestBidsCoalesced(context.Background(), context.Background(), log, duty.Slot, parentHash, duty.Pubkey, "")
+	if result.bid.blockHash == "" {
+		log.Debug("no bid prefetched")
+		return
+	}
+
+	m.prefetchedBids.set(duty.Slot, parentHash, duty.Pubkey, result)
+	log.WithFields(logrus.Fields{
+		"blockHash": result.bid.blockHash,
+		"value":     result.bid.response.Data.Message.Value.String(),
+		"partial":   result.isPartial,
+	}).Info("prefetched bid")
+}
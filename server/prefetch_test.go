@@ -0,0 +1,89 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlotTiming(t *testing.T) {
+	genesisTime := uint64(1606824023)
+	require.Equal(t, uint64(0), ChainEthereum.slotAt(genesisTime, time.Unix(1606824000, 0)))
+	require.Equal(t, uint64(0), ChainEthereum.slotAt(genesisTime, time.Unix(1606824023, 0)))
+	require.Equal(t, uint64(1), ChainEthereum.slotAt(genesisTime, time.Unix(1606824035, 0)))
+	require.Equal(t, time.Unix(1606824047, 0), ChainEthereum.slotStartTime(genesisTime, 2))
+}
+
+func TestPrefetchedBidsStore(t *testing.T) {
+	store := newPrefetchedBidsStore()
+	now := time.Now()
+	store.set(1, "0xAB", "0xCD", getHeaderResult{bid: bidResp{t: now, blockHash: "0x01"}})
+
+	result, ok := store.get(1, "0xab", "0xcd")
+	require.True(t, ok)
+	require.Equal(t, "0x01", result.bid.blockHash)
+
+	_, ok = store.get(2, "0xab", "0xcd")
+	require.False(t, ok)
+
+	store.prune(now.Add(time.Minute), 3*time.Minute)
+	_, ok = store.get(1, "0xab", "0xcd")
+	require.True(t, ok)
+
+	store.prune(now.Add(4*time.Minute), 3*time.Minute)
+	_, ok = store.get(1, "0xab", "0xcd")
+	require.False(t, ok)
+}
+
+func TestPrefetchBids(t *testing.T) {
+	parentHash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	path := fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", parentHash, pubkey)
+
+	beaconNode := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "/eth/v2/beacon/blocks/head", req.URL.Path)
+		fmt.Fprintf(w, `{"data":{"message":{"body":{"execution_payload":{"block_hash":"%s"}}}}}`, parentHash)
+	}))
+	defer beaconNode.Close()
+
+	backend := newTestBackend(t, 1, time.Second)
+	backend.boost.beaconClient = newBeaconClient(beaconNode.URL, time.Second)
+
+	// Register the validator
+	rr := backend.request(t, http.MethodPost, pathRegisterValidator, []types.SignedValidatorRegistration{{
+		Message: &types.RegisterValidatorRequestMessage{Pubkey: _HexToPubkey(pubkey)},
+	}})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.True(t, backend.boost.isRegisteredValidator(pubkey))
+
+	backend.boost.prefetchBids(proposerDuty{Pubkey: pubkey, Slot: 1})
+	require.Equal(t, 1, backend.relays[0].GetRequestCount(path))
+
+	// getHeader is served from the prefetched bid, without calling the relay again
+	rr = backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, 1, backend.relays[0].GetRequestCount(path))
+
+	// Other requests are not affected
+	otherPath := fmt.Sprintf("/eth/v1/builder/header/2/%s/%s", parentHash, pubkey)
+	rr = backend.request(t, http.MethodGet, otherPath, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, 1, backend.relays[0].GetRequestCount(otherPath))
+}
+
+func TestBeaconClientProposerDuties(t *testing.T) {
+	beaconNode := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "/eth/v1/validator/duties/proposer/2", req.URL.Path)
+		fmt.Fprint(w, `{"data":[{"pubkey":"0x01","validator_index":"3","slot":"64"}]}`)
+	}))
+	defer beaconNode.Close()
+
+	duties, err := newBeaconClient(beaconNode.URL+"/", time.Second).proposerDuties(2)
+	require.NoError(t, err)
+	require.Equal(t, []proposerDuty{{Pubkey: "0x01", ValidatorIndex: 3, Slot: 64}}, duties)
+}
@@ -0,0 +1,222 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// GetPayloadFallback controls whether getPayload may call relays outside the proposer's relays
+type GetPayloadFallback string
+
+// getPayload fallback modes
+const (
+	// GetPayloadFallbackPermissive calls all relays for the payload if the relays of the proposer fail, the default
+	GetPayloadFallbackPermissive GetPayloadFallback = "permissive"
+
+	// GetPayloadFallbackStrict only calls the relays of the proposer for the payload, even at the risk of missing
+	// the slot, for proposers requiring strict relay isolation
+	GetPayloadFallbackStrict GetPayloadFallback = "strict"
+)
+
+// ProposerSettings are the relay settings of a proposer
+type ProposerSettings struct {
+	Relays             []string           `json:"relays,omitempty" doc:"hosts of the relays to use, all relays if empty"`
+	GetPayloadFallback GetPayloadFallback `json:"get_payload_fallback,omitempty" validate:"enum=permissive|strict" doc:"whether getPayload may call other relays if the relays of the proposer fail (permissive), or not (strict)"`
+	BidPolicy          string             `json:"bid_policy,omitempty" doc:"expression which the bids of the proposer must satisfy, eg. bid.value >= eth(0.01)"`
+	FeeRecipient       string             `json:"fee_recipient,omitempty" validate:"pattern=^0x[0-9a-fA-F]{40}$" doc:"fee recipient which the registrations of the proposer are expected to have"`
+	GasLimit           uint64             `json:"gas_limit,omitempty" doc:"gas limit which the registrations of the proposer are expected to have"`
+	MinBid             string             `json:"min_bid,omitempty" validate:"pattern=^[0-9]+(\\.[0-9]+)?$" doc:"minimum bid value of the proposer in units of the native token, eg. 0.05, lower bids are dropped so the proposer builds the block locally"`
+	Builder            *BuilderSettings   `json:"builder,omitempty" doc:"builder settings of a Teku proposer config, whose gas_limit is used if gas_limit is unset"`
+
+	bidPolicy *BidPolicy // compiled BidPolicy
+	minBid    *big.Int   // MinBid in wei
+}
+
+// BuilderSettings are the builder settings of a proposer in the Teku proposer config format, so Teku proposer configs
+// can be used as is
+type BuilderSettings struct {
+	Enabled  bool   `json:"enabled,omitempty" doc:"whether the proposer uses the builder network, not used by mev-boost"`
+	GasLimit string `json:"gas_limit,omitempty" validate:"pattern=^[0-9]+$" doc:"gas limit which the registrations of the proposer are expected to have, as a decimal string"`
+}
+
+// ProposerConfig are the relay settings per proposer pubkey, and the default settings of the other proposers.
+// Unset fields of a proposer's settings are taken from the default settings.
+type ProposerConfig struct {
+	Proposers map[string]ProposerSettings `json:"proposer_config" validate:"keypattern=^0x[0-9a-fA-F]+$" doc:"relay settings per proposer pubkey"`
+	Default   ProposerSettings            `json:"default_config" doc:"relay settings of the other proposers, and of the unset fields of a proposer's settings"`
+
+	path string // file the config was loaded from, to reload it
+	url  string // URL the config was fetched from, to poll it
+
+	// Validators of the remote config, for conditional requests
+	etag         string
+	lastModified string
+}
+
+// LoadProposerConfig reads a proposer config from a JSON file
+func LoadProposerConfig(path string) (*ProposerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config, err := parseProposerConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	config.path = path
+	return config, nil
+}
+
+// parseProposerConfig parses and validates a proposer config in JSON
+func parseProposerConfig(data []byte) (*ProposerConfig, error) {
+	config := new(ProposerConfig)
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProposerConfig, err)
+	}
+	if err := ValidateConfig(config); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProposerConfig, err)
+	}
+
+	// Pubkeys are matched case-insensitively
+	proposers := make(map[string]ProposerSettings, len(config.Proposers))
+	for pubkey, settings := range config.Proposers {
+		if err := settings.compile(); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidProposerConfig, pubkey, err)
+		}
+		proposers[strings.ToLower(pubkey)] = settings
+	}
+	if err := config.Default.compile(); err != nil {
+		return nil, fmt.Errorf("%w: default_config: %v", ErrInvalidProposerConfig, err)
+	}
+	config.Proposers = proposers
+	return config, nil
+}
+
+// compile takes the gas limit from the Teku builder settings if not set, and compiles the minimum bid and the bid
+// policy
+func (s *ProposerSettings) compile() (err error) {
+	if s.GasLimit == 0 && s.Builder != nil && s.Builder.GasLimit != "" {
+		if s.GasLimit, err = strconv.ParseUint(s.Builder.GasLimit, 10, 64); err != nil {
+			return fmt.Errorf("invalid builder gas_limit: %w", err)
+		}
+	}
+	if s.MinBid != "" {
+		if s.minBid, err = parseTokenAmount(s.MinBid, ChainEthereum.TokenDecimals); err != nil {
+			return fmt.Errorf("invalid min_bid: %w", err)
+		}
+	}
+	return s.compileBidPolicy()
+}
+
+// compileBidPolicy compiles the bid policy of the settings, if any
+func (s *ProposerSettings) compileBidPolicy() (err error) {
+	if s.BidPolicy != "" {
+		s.bidPolicy, err = ParseBidPolicy(s.BidPolicy)
+	}
+	return err
+}
+
+// validate verifies that the settings only use the given relays and known fallback modes
+func (c *ProposerConfig) validate(relays []RelayEntry) error {
+	if c == nil {
+		return nil
+	}
+	hosts := make(map[string]bool, len(relays))
+	for _, relay := range relays {
+		hosts[relay.URL.Host] = true
+	}
+
+	all := map[string]ProposerSettings{"default_config": c.Default}
+	for pubkey, settings := range c.Proposers {
+		all[pubkey] = settings
+	}
+	for name, settings := range all {
+		switch settings.GetPayloadFallback {
+		case "", GetPayloadFallbackPermissive, GetPayloadFallbackStrict:
+		default:
+			return fmt.Errorf("%w: %s: unknown get_payload_fallback %s", ErrInvalidProposerConfig, name, settings.GetPayloadFallback)
+		}
+		for _, host := range settings.Relays {
+			if !hosts[host] {
+				return fmt.Errorf("%w: %s uses unknown relay %s", ErrInvalidProposerConfig, name, host)
+			}
+		}
+	}
+	return nil
+}
+
+// settings returns the settings of a proposer, completed with the default settings
+func (c *ProposerConfig) settings(pubkey string) ProposerSettings {
+	if c == nil {
+		return ProposerSettings{GetPayloadFallback: GetPayloadFallbackPermissive}
+	}
+	settings := c.Proposers[strings.ToLower(pubkey)]
+	if len(settings.Relays) == 0 {
+		settings.Relays = c.Default.Relays
+	}
+	if settings.GetPayloadFallback == "" {
+		settings.GetPayloadFallback = c.Default.GetPayloadFallback
+	}
+	if settings.bidPolicy == nil {
+		settings.BidPolicy, settings.bidPolicy = c.Default.BidPolicy, c.Default.bidPolicy
+	}
+	if settings.FeeRecipient == "" {
+		settings.FeeRecipient = c.Default.FeeRecipient
+	}
+	if settings.GasLimit == 0 {
+		settings.GasLimit = c.Default.GasLimit
+	}
+	if settings.minBid == nil {
+		settings.MinBid, settings.minBid = c.Default.MinBid, c.Default.minBid
+	}
+	if settings.GetPayloadFallback == "" {
+		settings.GetPayloadFallback = GetPayloadFallbackPermissive
+	}
+	return settings
+}
+
+// proposerRelays returns the relays of a proposer, out of the given relays
+func (m *BoostService) proposerRelays(pubkey string, relays []RelayEntry) []RelayEntry {
+	settings := m.proposerConfig.settings(pubkey)
+	if len(settings.Relays) == 0 {
+		return relays
+	}
+
+	hosts := make(map[string]bool, len(settings.Relays))
+	for _, host := range settings.Relays {
+		hosts[host] = true
+	}
+	ret := make([]RelayEntry, 0, len(settings.Relays))
+	for _, relay := range relays {
+		if hosts[relay.URL.Host] {
+			ret = append(ret, relay)
+		}
+	}
+	return ret
+}
+
+// ReloadProposerConfig reads the proposer config file again, so the relay settings of proposers can change without
+// restart, or fetches a remote config again. If the file is invalid, the previous config is kept. The imported
+// proposers are kept either way.
+func (m *BoostService) ReloadProposerConfig() error {
+	path := m.proposerConfig.path()
+	if path == "" {
+		return m.PollProposerConfig(context.Background())
+	}
+	config, err := LoadProposerConfig(path)
+	if err == nil {
+		err = config.validate(m.relays)
+	}
+	if err != nil {
+		m.proposerConfig.setReloadError(err)
+		return err
+	}
+	m.proposerConfig.setBase(config)
+	m.log.WithField("numProposers", len(config.Proposers)).Info("proposer config reloaded")
+	return nil
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// proposerConfigFetchTimeout is the timeout of the requests fetching a remote proposer config
+	proposerConfigFetchTimeout = 10 * time.Second
+
+	// maxProposerConfigSize is the largest remote proposer config accepted
+	maxProposerConfigSize = 32 << 20
+)
+
+var proposerConfigClient = &http.Client{Timeout: proposerConfigFetchTimeout}
+
+// IsRemoteProposerConfig returns whether a proposer config source is a URL rather than a file
+func IsRemoteProposerConfig(source string) bool {
+	u, err := url.Parse(source)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
+
+// FetchProposerConfig fetches a proposer config in JSON from a URL, eg. a Teku proposer config served by the
+// infrastructure of the operator, which the service then polls with PollProposerConfig
+func FetchProposerConfig(ctx context.Context, url string) (*ProposerConfig, error) {
+	return fetchProposerConfig(ctx, url, nil)
+}
+
+// fetchProposerConfig fetches a proposer config from a URL. With the previous config, the request is conditional, and
+// nil is returned if the config did not change.
+func fetchProposerConfig(ctx context.Context, url string, previous *ProposerConfig) (*ProposerConfig, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if previous != nil && previous.url == url {
+		if previous.etag != "" {
+			req.Header.Set("If-None-Match", previous.etag)
+		}
+		if previous.lastModified != "" {
+			req.Header.Set("If-Modified-Since", previous.lastModified)
+		}
+	}
+
+	resp, err := proposerConfigClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotModified && previous != nil:
+		return nil, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%s responded with status code %d", url, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxProposerConfigSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxProposerConfigSize {
+		return nil, fmt.Errorf("%w: larger than %d MiB", ErrInvalidProposerConfig, maxProposerConfigSize>>20)
+	}
+	config, err := parseProposerConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	config.url = url
+	config.etag = resp.Header.Get("ETag")
+	config.lastModified = resp.Header.Get("Last-Modified")
+	return config, nil
+}
+
+// PollProposerConfig fetches the remote proposer config again, if it was fetched from a URL. If it is unreachable or
+// invalid, the last known good config is kept. The imported proposers are kept either way.
+func (m *BoostService) PollProposerConfig(ctx context.Context) error {
+	previous := m.proposerConfig.configured()
+	if previous == nil || previous.url == "" {
+		return nil
+	}
+	log := m.log.WithField("url", previous.url)
+	config, err := fetchProposerConfig(ctx, previous.url, previous)
+	if err == nil && config != nil {
+		err = config.validate(m.relays)
+	}
+	if err != nil {
+		m.proposerConfig.setReloadError(err)
+		log.WithError(err).Warn("could not fetch the proposer config, keeping the last known good one")
+		return err
+	}
+	if config == nil {
+		m.proposerConfig.setReloadError(nil)
+		log.Debug("proposer config not modified")
+		return nil
+	}
+	m.proposerConfig.setBase(config)
+	log.WithField("numProposers", len(config.Proposers)).Info("proposer config fetched")
+	return nil
+}
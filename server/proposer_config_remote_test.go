@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRemoteProposerConfig(t *testing.T) {
+	require.True(t, IsRemoteProposerConfig("https://config.example.com/proposers.json"))
+	require.True(t, IsRemoteProposerConfig("http://localhost:8080/proposers"))
+	require.False(t, IsRemoteProposerConfig("proposers.json"))
+	require.False(t, IsRemoteProposerConfig("/etc/mev-boost/proposers.json"))
+	require.False(t, IsRemoteProposerConfig(""))
+}
+
+func TestPollProposerConfig(t *testing.T) {
+	backend := newTestBackend(t, 2, time.Second)
+	own, other := backend.relays[0].RelayEntry.URL.Host, backend.relays[1].RelayEntry.URL.Host
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+
+	// A Teku proposer config, served with an ETag
+	var mu sync.Mutex
+	relay, version, status, notModified := own, 1, http.StatusOK, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		etag := fmt.Sprintf(`"v%d"`, version)
+		if req.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"proposer_config": {"%s": {"fee_recipient": "0xabcf8e0d4e9587369b2301d0790347320302cc09", "relays": ["%s"], "builder": {"enabled": true, "gas_limit": "30000000"}}}}`, pubkey, relay)
+	}))
+	defer server.Close()
+	setRemote := func(r string, v, s int) {
+		mu.Lock()
+		defer mu.Unlock()
+		relay, version, status = r, v, s
+	}
+
+	config, err := FetchProposerConfig(context.Background(), server.URL)
+	require.NoError(t, err)
+	require.Equal(t, uint64(30000000), config.settings(pubkey).GasLimit)
+	backend.boost.proposerConfig = newProposerConfigStore(config)
+
+	// Unchanged configs are not downloaded again
+	require.NoError(t, backend.boost.PollProposerConfig(context.Background()))
+	require.Equal(t, 1, notModified)
+	require.Equal(t, []string{own}, backend.boost.proposerConfig.settings(pubkey).Relays)
+
+	setRemote(other, 2, http.StatusOK)
+	require.NoError(t, backend.boost.PollProposerConfig(context.Background()))
+	require.Equal(t, []string{other}, backend.boost.proposerConfig.settings(pubkey).Relays)
+
+	// The last known good config is kept if the remote fails or serves an invalid config
+	setRemote(own, 3, http.StatusServiceUnavailable)
+	require.Error(t, backend.boost.PollProposerConfig(context.Background()))
+	require.Error(t, backend.boost.proposerConfig.ReloadError())
+	require.Equal(t, []string{other}, backend.boost.proposerConfig.settings(pubkey).Relays)
+
+	setRemote("unknown.relay.com", 4, http.StatusOK)
+	require.ErrorIs(t, backend.boost.ReloadProposerConfig(), ErrInvalidProposerConfig)
+	require.Equal(t, []string{other}, backend.boost.proposerConfig.settings(pubkey).Relays)
+
+	setRemote(own, 5, http.StatusOK)
+	require.NoError(t, backend.boost.ReloadProposerConfig())
+	require.NoError(t, backend.boost.proposerConfig.ReloadError())
+	require.Equal(t, []string{own}, backend.boost.proposerConfig.settings(pubkey).Relays)
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadProposerConfig(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "proposers.json")
+	require.NoError(t, os.WriteFile(file, []byte(`{
+		"proposer_config": {"0xABCD": {"relays": ["relay1.com"], "get_payload_fallback": "strict"}, "0xef": {}},
+		"default_config": {"relays": ["relay2.com"]}
+	}`), 0o600))
+	config, err := LoadProposerConfig(file)
+	require.NoError(t, err)
+
+	require.Equal(t, ProposerSettings{Relays: []string{"relay1.com"}, GetPayloadFallback: GetPayloadFallbackStrict}, config.settings("0xabcd"))
+	require.Equal(t, ProposerSettings{Relays: []string{"relay2.com"}, GetPayloadFallback: GetPayloadFallbackPermissive}, config.settings("0xef"))
+	require.Equal(t, ProposerSettings{Relays: []string{"relay2.com"}, GetPayloadFallback: GetPayloadFallbackPermissive}, config.settings("0x12"))
+
+	// Bid policies are taken from the default settings if unset
+	require.NoError(t, os.WriteFile(file, []byte(`{
+		"proposer_config": {"0xabcd": {"bid_policy": "bid.value > eth(1)"}, "0xef": {}},
+		"default_config": {"bid_policy": "bid.value > 0"}
+	}`), 0o600))
+	policies, err := LoadProposerConfig(file)
+	require.NoError(t, err)
+	require.Equal(t, "bid.value > eth(1)", policies.settings("0xABCD").bidPolicy.String())
+	require.Equal(t, "bid.value > 0", policies.settings("0xef").bidPolicy.String())
+
+	var nilConfig *ProposerConfig
+	require.Equal(t, ProposerSettings{GetPayloadFallback: GetPayloadFallbackPermissive}, nilConfig.settings("0x12"))
+	require.NoError(t, nilConfig.validate(nil))
+
+	relay1, err := NewRelayEntry("http://0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249@relay1.com")
+	require.NoError(t, err)
+	require.ErrorIs(t, config.validate([]RelayEntry{relay1}), ErrInvalidProposerConfig)
+	config.Default.Relays = nil
+	require.NoError(t, config.validate([]RelayEntry{relay1}))
+	config.Default.GetPayloadFallback = "sometimes"
+	require.ErrorIs(t, config.validate([]RelayEntry{relay1}), ErrInvalidProposerConfig)
+
+	require.NoError(t, os.WriteFile(file, []byte(`[]`), 0o600))
+	_, err = LoadProposerConfig(file)
+	require.ErrorIs(t, err, ErrInvalidProposerConfig)
+
+	// Pubkeys, fallback modes, bid policies and minimum bids are validated when loading
+	for _, data := range []string{
+		`{"proposer_config": {"abcd": {}}}`,
+		`{"default_config": {"get_payload_fallback": "sometimes"}}`,
+		`{"proposer_config": {"0xabcd": {"bid_policy": "bid.value >"}}}`,
+		`{"default_config": {"bid_policy": "bid.value"}}`,
+		`{"default_config": {"min_bid": "-1"}}`,
+		`{"default_config": {"min_bid": "0.0000000000000000001"}}`,
+	} {
+		require.NoError(t, os.WriteFile(file, []byte(data), 0o600))
+		_, err = LoadProposerConfig(file)
+		require.ErrorIs(t, err, ErrInvalidProposerConfig, data)
+	}
+}
+
+func TestProposerGetPayloadFallback(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	payload := types.SignedBlindedBeaconBlock{
+		Message: &types.BlindedBeaconBlock{
+			Slot: 1,
+			Body: &types.BlindedBeaconBlockBody{
+				Eth1Data:      &types.Eth1Data{},
+				SyncAggregate: &types.SyncAggregate{},
+				ExecutionPayloadHeader: &types.ExecutionPayloadHeader{
+					BlockHash: _HexToHash(hash),
+				},
+			},
+		},
+	}
+
+	backend := newTestBackend(t, 2, time.Second)
+	own, other := backend.relays[0], backend.relays[1]
+	other.EchoRequestHashes = true
+	own.overrideHandleGetPayload(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	proposerConfig := &ProposerConfig{
+		Proposers: map[string]ProposerSettings{pubkey: {Relays: []string{own.RelayEntry.URL.Host}}},
+	}
+	backend.boost.proposerConfig = newProposerConfigStore(proposerConfig)
+
+	// Only the proposer's relay is called for bids
+	headerPath := fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey)
+	rr := backend.request(t, http.MethodGet, headerPath, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, 1, own.GetRequestCount(headerPath))
+	require.Equal(t, 0, other.GetRequestCount(headerPath))
+
+	// The other relay delivers the payload if the proposer's relay fails, unless the proposer is strict
+	rr = backend.request(t, http.MethodPost, pathGetPayload, payload)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, 1, other.GetRequestCount(pathGetPayload))
+
+	proposerConfig.Proposers[pubkey] = ProposerSettings{Relays: []string{own.RelayEntry.URL.Host}, GetPayloadFallback: GetPayloadFallbackStrict}
+	rr = backend.request(t, http.MethodPost, pathGetPayload, payload)
+	require.Equal(t, http.StatusBadGateway, rr.Code, rr.Body.String())
+	require.Equal(t, 1, other.GetRequestCount(pathGetPayload))
+	require.Equal(t, 2, own.GetRequestCount(pathGetPayload))
+}
+
+func TestProposerMinBid(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	path := fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey)
+	file := filepath.Join(t.TempDir(), "proposers.json")
+	require.NoError(t, os.WriteFile(file, []byte(fmt.Sprintf(`{
+		"proposer_config": {"%s": {"min_bid": "0.00000000000002"}},
+		"default_config": {"min_bid": "1"}
+	}`, pubkey)), 0o600))
+	config, err := LoadProposerConfig(file)
+	require.NoError(t, err)
+	require.Equal(t, "20000", config.settings(pubkey).minBid.String())
+	require.Equal(t, "1000000000000000000", config.settings("0x12").minBid.String())
+
+	backend := newTestBackend(t, 2, time.Second)
+	backend.boost.proposerConfig = newProposerConfigStore(config)
+	backend.relays[0].GetHeaderResponse = backend.relays[0].MakeGetHeaderResponse(12345, hash, pubkey)
+	backend.relays[1].GetHeaderResponse = backend.relays[1].MakeGetHeaderResponse(30000, hash, pubkey)
+
+	// The bids below the minimum of the proposer are dropped
+	rr := backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	resp := new(types.GetHeaderResponse)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+	require.Equal(t, types.IntToU256(30000), resp.Data.Message.Value)
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.relayDroppedBids.WithLabelValues(backend.relays[0].RelayEntry.String(), string(BidRejectionBelowProposerMin))))
+
+	// Without bids above the minimum, the proposer builds the block locally
+	backend.relays[1].GetHeaderResponse = backend.relays[1].MakeGetHeaderResponse(100, hash, pubkey)
+	rr = backend.request(t, http.MethodGet, fmt.Sprintf("/eth/v1/builder/header/2/%s/%s", hash, pubkey), nil)
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+}
+
+func TestReloadProposerConfig(t *testing.T) {
+	backend := newTestBackend(t, 2, time.Second)
+	own, other := backend.relays[0].RelayEntry.URL.Host, backend.relays[1].RelayEntry.URL.Host
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	file := filepath.Join(t.TempDir(), "proposers.json")
+	writeConfig := func(relay string) {
+		require.NoError(t, os.WriteFile(file, []byte(fmt.Sprintf(`{"proposer_config": {"%s": {"relays": ["%s"]}}}`, pubkey, relay)), 0o600))
+	}
+	writeConfig(own)
+	config, err := LoadProposerConfig(file)
+	require.NoError(t, err)
+	backend.boost.proposerConfig = newProposerConfigStore(config)
+	backend.boost.proposerConfig.setImported(map[string]ProposerSettings{importPubkey2: {GasLimit: 30000000}})
+
+	// The relays change, and the imported proposers are kept
+	writeConfig(other)
+	require.NoError(t, backend.boost.ReloadProposerConfig())
+	require.Equal(t, []string{other}, backend.boost.proposerConfig.settings(pubkey).Relays)
+	require.Equal(t, uint64(30000000), backend.boost.proposerConfig.settings(importPubkey2).GasLimit)
+
+	// Invalid configs are not applied
+	writeConfig("unknown.relay.com")
+	require.ErrorIs(t, backend.boost.ReloadProposerConfig(), ErrInvalidProposerConfig)
+	require.Error(t, backend.boost.proposerConfig.ReloadError())
+	require.Equal(t, []string{other}, backend.boost.proposerConfig.settings(pubkey).Relays)
+
+	writeConfig(own)
+	require.NoError(t, backend.boost.ReloadProposerConfig())
+	require.NoError(t, backend.boost.proposerConfig.ReloadError())
+	require.Equal(t, []string{own}, backend.boost.proposerConfig.settings(pubkey).Relays)
+
+	// Configs not loaded from a file have nothing to reload
+	backend.boost.proposerConfig = newProposerConfigStore(nil)
+	require.NoError(t, backend.boost.ReloadProposerConfig())
+}
@@ -0,0 +1,325 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/sirupsen/logrus"
+)
+
+// Kinds of proposer import sources
+const (
+	// ProposerImportWeb3Signer imports the pubkeys of the keys held by a Web3Signer
+	ProposerImportWeb3Signer = "web3signer"
+
+	// ProposerImportKeymanager imports the pubkeys, fee recipients and gas limits of the validators of a validator
+	// client, from its keymanager API
+	ProposerImportKeymanager = "keymanager"
+)
+
+const (
+	// configProposerImport is the name of the imported proposers in the config reload metric
+	configProposerImport = "proposer_import"
+
+	// proposerImportTimeout is the timeout of each request of an import
+	proposerImportTimeout = 10 * time.Second
+)
+
+// ProposerImporter imports the validators managed by a Web3Signer or a validator client (keymanager API) as proposer
+// config entries, so the proposer config follows the keys actually in use
+type ProposerImporter struct {
+	kind   string
+	url    string
+	token  *Secret // bearer token of the keymanager API
+	client *http.Client
+}
+
+// NewProposerImporter returns the importer of a source given as web3signer:URL or keymanager:URL. The keymanager API
+// requires its bearer token.
+func NewProposerImporter(source string, token *Secret) (*ProposerImporter, error) {
+	kind, url, found := strings.Cut(source, ":")
+	if !found || (kind != ProposerImportWeb3Signer && kind != ProposerImportKeymanager) {
+		return nil, fmt.Errorf("%w: %s is neither web3signer:URL nor keymanager:URL", ErrInvalidProposerConfig, source)
+	}
+	if kind == ProposerImportKeymanager && token.Value() == "" {
+		return nil, fmt.Errorf("%w: the keymanager API requires a token", ErrInvalidProposerConfig)
+	}
+	return &ProposerImporter{
+		kind:   kind,
+		url:    strings.TrimSuffix(url, "/"),
+		token:  token,
+		client: &http.Client{Timeout: proposerImportTimeout},
+	}, nil
+}
+
+// String returns the source of the importer
+func (i *ProposerImporter) String() string {
+	return i.kind + ":" + i.url
+}
+
+// get requests a path of the source into dst
+func (i *ProposerImporter) get(ctx context.Context, path string, dst any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, i.url+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if token := i.token.Value(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with status code %d", path, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return fmt.Errorf("could not decode %s: %w", path, err)
+	}
+	return nil
+}
+
+// Import returns the settings of the managed validators by lowercase pubkey
+func (i *ProposerImporter) Import(ctx context.Context) (map[string]ProposerSettings, error) {
+	var pubkeys []string
+	switch i.kind {
+	case ProposerImportWeb3Signer:
+		if err := i.get(ctx, "/api/v1/eth2/publicKeys", &pubkeys); err != nil {
+			return nil, err
+		}
+	case ProposerImportKeymanager:
+		// Validators with local keystores, and with keys held by a remote signer
+		for _, path := range []string{"/eth/v1/keystores", "/eth/v1/remotekeys"} {
+			var keys struct {
+				Data []struct {
+					Pubkey string `json:"pubkey"`
+					// validating_pubkey in /eth/v1/keystores
+					ValidatingPubkey string `json:"validating_pubkey"`
+				} `json:"data"`
+			}
+			if err := i.get(ctx, path, &keys); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			for _, key := range keys.Data {
+				if key.ValidatingPubkey != "" {
+					pubkeys = append(pubkeys, key.ValidatingPubkey)
+				} else {
+					pubkeys = append(pubkeys, key.Pubkey)
+				}
+			}
+		}
+	}
+
+	proposers := make(map[string]ProposerSettings, len(pubkeys))
+	for _, pubkey := range pubkeys {
+		pubkey = strings.ToLower(pubkey)
+		if err := new(types.PublicKey).UnmarshalText([]byte(pubkey)); err != nil {
+			return nil, fmt.Errorf("invalid pubkey %s: %w", pubkey, err)
+		}
+		settings := ProposerSettings{}
+		if i.kind == ProposerImportKeymanager {
+			var feeRecipient struct {
+				Data struct {
+					EthAddress string `json:"ethaddress"`
+				} `json:"data"`
+			}
+			if err := i.get(ctx, "/eth/v1/validator/"+pubkey+"/feerecipient", &feeRecipient); err != nil {
+				return nil, fmt.Errorf("fee recipient of %s: %w", pubkey, err)
+			}
+			var gasLimit struct {
+				Data struct {
+					GasLimit string `json:"gas_limit"`
+				} `json:"data"`
+			}
+			if err := i.get(ctx, "/eth/v1/validator/"+pubkey+"/gas_limit", &gasLimit); err != nil {
+				return nil, fmt.Errorf("gas limit of %s: %w", pubkey, err)
+			}
+			settings.FeeRecipient = strings.ToLower(feeRecipient.Data.EthAddress)
+			settings.GasLimit, _ = strconv.ParseUint(gasLimit.Data.GasLimit, 10, 64)
+		}
+		proposers[pubkey] = settings
+	}
+	return proposers, nil
+}
+
+// withImported returns the config with entries for the imported proposers. The entries of the config take
+// precedence, and are only completed with the imported fee recipient and gas limit.
+func (c *ProposerConfig) withImported(imported map[string]ProposerSettings) *ProposerConfig {
+	merged := &ProposerConfig{Proposers: make(map[string]ProposerSettings, len(imported))}
+	if c != nil {
+		merged.Default = c.Default
+	}
+	for pubkey, settings := range imported {
+		merged.Proposers[pubkey] = settings
+	}
+	if c == nil {
+		return merged
+	}
+	for pubkey, settings := range c.Proposers {
+		if importedSettings, ok := imported[pubkey]; ok {
+			if settings.FeeRecipient == "" {
+				settings.FeeRecipient = importedSettings.FeeRecipient
+			}
+			if settings.GasLimit == 0 {
+				settings.GasLimit = importedSettings.GasLimit
+			}
+		}
+		merged.Proposers[pubkey] = settings
+	}
+	return merged
+}
+
+// proposerConfigStore holds the proposer config in use: the configured one, with the imported proposers if any.
+// Requests read the settings of a proposer once, so replacing the config doesn't affect the requests in flight.
+type proposerConfigStore struct {
+	mu        sync.RWMutex
+	base      *ProposerConfig
+	imported  map[string]ProposerSettings
+	config    *ProposerConfig
+	importErr error
+	reloadErr error
+}
+
+func newProposerConfigStore(base *ProposerConfig) *proposerConfigStore {
+	return &proposerConfigStore{base: base, config: base}
+}
+
+// settings returns the settings of a proposer, see ProposerConfig.settings
+func (s *proposerConfigStore) settings(pubkey string) ProposerSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config.settings(pubkey)
+}
+
+// setImported replaces the imported proposers
+func (s *proposerConfigStore) setImported(imported map[string]ProposerSettings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.imported = imported
+	s.config = s.base.withImported(imported)
+	s.importErr = nil
+}
+
+// setBase replaces the configured proposer config, keeping the imported proposers
+func (s *proposerConfigStore) setBase(base *ProposerConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.base = base
+	s.reloadErr = nil
+	if s.imported != nil {
+		s.config = base.withImported(s.imported)
+	} else {
+		s.config = base
+	}
+}
+
+// ImportError returns the error of the last import, nil if it succeeded
+func (s *proposerConfigStore) ImportError() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.importErr
+}
+
+func (s *proposerConfigStore) setImportError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.importErr = err
+}
+
+// ReloadError returns the error of the last reload of the configured proposer config, nil if it succeeded
+func (s *proposerConfigStore) ReloadError() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.reloadErr
+}
+
+func (s *proposerConfigStore) setReloadError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reloadErr = err
+}
+
+// path returns the file of the configured proposer config, empty if it wasn't loaded from a file
+func (s *proposerConfigStore) path() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.base == nil {
+		return ""
+	}
+	return s.base.path
+}
+
+// url returns the URL of the configured proposer config, empty if it wasn't fetched from a URL
+func (s *proposerConfigStore) url() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.base == nil {
+		return ""
+	}
+	return s.base.url
+}
+
+// configured returns the configured proposer config, without the imported proposers
+func (s *proposerConfigStore) configured() *ProposerConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.base
+}
+
+// importProposers imports the managed validators into the proposer config. If the import fails, the previously
+// imported proposers are kept.
+func (m *BoostService) importProposers(ctx context.Context) error {
+	imported, err := m.proposerImporter.Import(ctx)
+	log := m.log.WithField("source", m.proposerImporter.String())
+	if err != nil {
+		m.proposerConfig.setImportError(err)
+		log.WithError(err).Warn("could not import the proposers, keeping the previous ones")
+		return err
+	}
+
+	m.proposerConfig.setImported(imported)
+	log.WithField("numProposers", len(imported)).Debug("imported proposers")
+	return nil
+}
+
+// checkRegistrationSettings counts and logs the registrations whose fee recipient or gas limit differ from the
+// proposer config, eg. a validator client configured differently than expected
+func (m *BoostService) checkRegistrationSettings(log *logrus.Entry, registrations []types.SignedValidatorRegistration) {
+	mismatches := make(map[string][]string) // pubkeys per field
+	for _, registration := range registrations {
+		if registration.Message == nil {
+			continue
+		}
+		pubkey := registration.Message.Pubkey.String()
+		settings := m.proposerConfig.settings(pubkey)
+		if settings.FeeRecipient != "" && !strings.EqualFold(settings.FeeRecipient, registration.Message.FeeRecipient.String()) {
+			mismatches["fee_recipient"] = append(mismatches["fee_recipient"], pubkey)
+		}
+		if settings.GasLimit != 0 && settings.GasLimit != registration.Message.GasLimit {
+			mismatches["gas_limit"] = append(mismatches["gas_limit"], pubkey)
+		}
+	}
+
+	fields := make([]string, 0, len(mismatches))
+	for field, pubkeys := range mismatches {
+		fields = append(fields, field)
+		m.metrics.registrationConfigMismatches.WithLabelValues(field).Add(float64(len(pubkeys)))
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		log.WithFields(logrus.Fields{
+			"field":   field,
+			"pubkeys": strings.Join(mismatches[field], ", "),
+		}).Warn("registrations differ from the proposer config")
+	}
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	importPubkey1 = "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	importPubkey2 = "0xb5d6eb1ba2bd2d3f4d0de4c35e6e3d5e8a4ad14e3b8a6ab2f42bd68b4d2ff8ea1c0f6a1ac8f4a3b73ebd6ae8e4f3c2a1"
+)
+
+func TestNewProposerImporter(t *testing.T) {
+	_, err := NewProposerImporter("web3signer:http://localhost:9000", nil)
+	require.NoError(t, err)
+	_, err = NewProposerImporter("keymanager:http://localhost:7500", nil)
+	require.ErrorIs(t, err, ErrInvalidProposerConfig)
+	_, err = NewProposerImporter("keymanager:http://localhost:7500", StaticSecret("token"))
+	require.NoError(t, err)
+	_, err = NewProposerImporter("http://localhost:9000", nil)
+	require.ErrorIs(t, err, ErrInvalidProposerConfig)
+}
+
+func TestProposerImport(t *testing.T) {
+	t.Run("Web3Signer", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			require.Equal(t, "/api/v1/eth2/publicKeys", req.URL.Path)
+			_ = json.NewEncoder(w).Encode([]string{importPubkey1})
+		}))
+		defer srv.Close()
+
+		importer, err := NewProposerImporter("web3signer:"+srv.URL, nil)
+		require.NoError(t, err)
+		proposers, err := importer.Import(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, map[string]ProposerSettings{importPubkey1: {}}, proposers)
+	})
+
+	t.Run("Keymanager", func(t *testing.T) {
+		responses := map[string]string{
+			"/eth/v1/keystores":  `{"data":[{"validating_pubkey":"` + importPubkey1 + `"}]}`,
+			"/eth/v1/remotekeys": `{"data":[{"pubkey":"` + importPubkey2 + `"}]}`,
+			"/eth/v1/validator/" + importPubkey1 + "/feerecipient": `{"data":{"ethaddress":"0xAbcF8e0d4e9587369b2301D0790347320302cc09"}}`,
+			"/eth/v1/validator/" + importPubkey1 + "/gas_limit":    `{"data":{"gas_limit":"30000000"}}`,
+			"/eth/v1/validator/" + importPubkey2 + "/feerecipient": `{"data":{"ethaddress":"0x0000000000000000000000000000000000000001"}}`,
+			"/eth/v1/validator/" + importPubkey2 + "/gas_limit":    `{"data":{"gas_limit":"25000000"}}`,
+		}
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(responses[req.URL.Path]))
+		}))
+		defer srv.Close()
+
+		importer, err := NewProposerImporter("keymanager:"+srv.URL, StaticSecret("token"))
+		require.NoError(t, err)
+		proposers, err := importer.Import(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, map[string]ProposerSettings{
+			importPubkey1: {FeeRecipient: "0xabcf8e0d4e9587369b2301d0790347320302cc09", GasLimit: 30000000},
+			importPubkey2: {FeeRecipient: "0x0000000000000000000000000000000000000001", GasLimit: 25000000},
+		}, proposers)
+
+		importer, err = NewProposerImporter("keymanager:"+srv.URL, StaticSecret("wrong"))
+		require.NoError(t, err)
+		_, err = importer.Import(context.Background())
+		require.Error(t, err)
+	})
+}
+
+func TestProposerConfigWithImported(t *testing.T) {
+	config := &ProposerConfig{
+		Proposers: map[string]ProposerSettings{importPubkey1: {Relays: []string{"relay1.example.com"}, GasLimit: 36000000}},
+		Default:   ProposerSettings{GetPayloadFallback: GetPayloadFallbackStrict},
+	}
+	store := newProposerConfigStore(config)
+	store.setImported(map[string]ProposerSettings{
+		importPubkey1: {FeeRecipient: "0x0000000000000000000000000000000000000001", GasLimit: 30000000},
+		importPubkey2: {FeeRecipient: "0x0000000000000000000000000000000000000002"},
+	})
+
+	// The entries of the config take precedence, and are completed with the imported settings
+	require.Equal(t, ProposerSettings{
+		Relays:             []string{"relay1.example.com"},
+		FeeRecipient:       "0x0000000000000000000000000000000000000001",
+		GasLimit:           36000000,
+		GetPayloadFallback: GetPayloadFallbackStrict,
+	}, store.settings(importPubkey1))
+	require.Equal(t, "0x0000000000000000000000000000000000000002", store.settings(importPubkey2).FeeRecipient)
+	require.Equal(t, GetPayloadFallbackStrict, store.settings(importPubkey2).GetPayloadFallback)
+
+	// Imports replace the previously imported proposers, but not the configured ones
+	store.setImported(nil)
+	require.Empty(t, store.settings(importPubkey2).FeeRecipient)
+	require.Equal(t, []string{"relay1.example.com"}, store.settings(importPubkey1).Relays)
+}
+
+func TestImportProposers(t *testing.T) {
+	pubkeys := []string{importPubkey1}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if pubkeys == nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(pubkeys)
+	}))
+	defer srv.Close()
+
+	backend := newTestBackend(t, 1, time.Second)
+	importer, err := NewProposerImporter("web3signer:"+srv.URL, nil)
+	require.NoError(t, err)
+	backend.boost.proposerImporter = importer
+	backend.boost.proposerConfig = newProposerConfigStore(&ProposerConfig{Default: ProposerSettings{GasLimit: 30000000}})
+
+	require.NoError(t, backend.boost.importProposers(context.Background()))
+	require.Equal(t, uint64(30000000), backend.boost.proposerConfig.settings(importPubkey1).GasLimit)
+
+	// A failed import keeps the previously imported proposers
+	pubkeys = nil
+	require.Error(t, backend.boost.importProposers(context.Background()))
+	require.Error(t, backend.boost.proposerConfig.ImportError())
+	_, imported := backend.boost.proposerConfig.config.Proposers[importPubkey1]
+	require.True(t, imported)
+}
+
+func TestRegistrationConfigMismatches(t *testing.T) {
+	backend := newTestBackend(t, 1, time.Second)
+	backend.boost.proposerConfig = newProposerConfigStore(&ProposerConfig{
+		Default: ProposerSettings{FeeRecipient: "0x0000000000000000000000000000000000000001", GasLimit: 30000000},
+	})
+
+	var pubkey types.PublicKey
+	require.NoError(t, pubkey.UnmarshalText([]byte(importPubkey1)))
+	var feeRecipient types.Address
+	require.NoError(t, feeRecipient.UnmarshalText([]byte("0x0000000000000000000000000000000000000002")))
+	registrations := []types.SignedValidatorRegistration{{
+		Message: &types.RegisterValidatorRequestMessage{Pubkey: pubkey, FeeRecipient: feeRecipient, GasLimit: 30000000},
+	}}
+
+	backend.boost.checkRegistrationSettings(backend.boost.log, registrations)
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.registrationConfigMismatches.WithLabelValues("fee_recipient")))
+	require.Equal(t, 0.0, testutil.ToFloat64(backend.boost.metrics.registrationConfigMismatches.WithLabelValues("gas_limit")))
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// tokenBucket allows bursts of up to burst requests, refilled at rate requests per second
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int, now time.Time) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: now}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed*b.rate)
+	}
+	b.last = now
+}
+
+// wait returns how long until a token is available, 0 if one is available now
+func (b *tokenBucket) wait(now time.Time) time.Duration {
+	b.refill(now)
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+func (b *tokenBucket) take() {
+	b.tokens--
+}
+
+// rateLimiter limits requests globally and per source IP. A rate of 0 disables the respective limit.
+type rateLimiter struct {
+	mu sync.Mutex
+
+	ratePerIP float64
+	burst     int
+	global    *tokenBucket
+	perIP     map[string]*tokenBucket
+}
+
+func newRateLimiter(rate, ratePerIP float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	l := &rateLimiter{
+		ratePerIP: ratePerIP,
+		burst:     burst,
+		perIP:     make(map[string]*tokenBucket),
+	}
+	if rate > 0 {
+		l.global = newTokenBucket(rate, burst, time.Time{}) // full until the first request
+	}
+	return l
+}
+
+// allow returns whether a request from ip is allowed at time now, and otherwise how long to wait before retrying
+func (l *rateLimiter) allow(ip string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var ipBucket *tokenBucket
+	if l.ratePerIP > 0 {
+		ipBucket = l.perIP[ip]
+		if ipBucket == nil {
+			ipBucket = newTokenBucket(l.ratePerIP, l.burst, now)
+			l.perIP[ip] = ipBucket
+		}
+	}
+
+	// A token is only taken if the request is allowed by both the global and the per-IP limit
+	var retryAfter time.Duration
+	if l.global != nil {
+		retryAfter = l.global.wait(now)
+	}
+	if ipBucket != nil {
+		if wait := ipBucket.wait(now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+	if retryAfter > 0 {
+		return false, retryAfter
+	}
+
+	if l.global != nil {
+		l.global.take()
+	}
+	if ipBucket != nil {
+		ipBucket.take()
+	}
+	return true, 0
+}
+
+// prune removes the per-IP buckets which are full again, to bound memory usage
+func (l *rateLimiter) prune(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for ip, bucket := range l.perIP {
+		bucket.refill(now)
+		if bucket.tokens >= bucket.burst {
+			delete(l.perIP, ip)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Global limit", func(t *testing.T) {
+		l := newRateLimiter(1, 0, 2)
+		ok, _ := l.allow("1.1.1.1", now)
+		require.True(t, ok)
+		ok, _ = l.allow("2.2.2.2", now)
+		require.True(t, ok)
+
+		ok, retryAfter := l.allow("3.3.3.3", now)
+		require.False(t, ok)
+		require.Equal(t, time.Second, retryAfter)
+
+		ok, _ = l.allow("3.3.3.3", now.Add(time.Second))
+		require.True(t, ok)
+	})
+
+	t.Run("Per-IP limit", func(t *testing.T) {
+		l := newRateLimiter(0, 0.5, 1)
+		ok, _ := l.allow("1.1.1.1", now)
+		require.True(t, ok)
+
+		ok, retryAfter := l.allow("1.1.1.1", now)
+		require.False(t, ok)
+		require.Equal(t, 2*time.Second, retryAfter)
+
+		// Other IPs are not affected
+		ok, _ = l.allow("2.2.2.2", now)
+		require.True(t, ok)
+	})
+
+	t.Run("Rejected requests don't use global tokens", func(t *testing.T) {
+		l := newRateLimiter(1, 1, 1)
+		ok, _ := l.allow("1.1.1.1", now)
+		require.True(t, ok)
+		ok, _ = l.allow("1.1.1.1", now.Add(500*time.Millisecond))
+		require.False(t, ok)
+		ok, _ = l.allow("2.2.2.2", now.Add(time.Second))
+		require.True(t, ok)
+	})
+
+	t.Run("Prune full buckets", func(t *testing.T) {
+		l := newRateLimiter(0, 1, 1)
+		l.allow("1.1.1.1", now)
+		l.prune(now)
+		require.Len(t, l.perIP, 1)
+		l.prune(now.Add(time.Second))
+		require.Len(t, l.perIP, 0)
+	})
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+)
+
+// defaultCoverageEpochs is the window of the registration coverage report if not given in the request
+const defaultCoverageEpochs = 3
+
+// registrationCoverage tracks when the registration of each validator was last delivered to each relay. Validators
+// not covered by a relay won't receive bids from it once their registration expires at the relay.
+type registrationCoverage struct {
+	mu        sync.Mutex
+	delivered map[string]map[string]time.Time // per pubkey and relay
+}
+
+// registrationCoverageResponse is the response of the registration coverage endpoint
+type registrationCoverageResponse struct {
+	Epochs        uint64                      `json:"epochs,string"`
+	Since         time.Time                   `json:"since"`
+	NumValidators int                         `json:"num_validators"`
+	Relays        []relayRegistrationCoverage `json:"relays"`
+}
+
+// relayRegistrationCoverage is the registration coverage of a relay
+type relayRegistrationCoverage struct {
+	Relay      string   `json:"relay"`
+	NumCovered int      `json:"num_covered"`
+	Coverage   float64  `json:"coverage"` // share of the validators covered, between 0 and 1
+	Missing    []string `json:"missing"`  // pubkeys of the validators not covered
+}
+
+func newRegistrationCoverage() *registrationCoverage {
+	return &registrationCoverage{delivered: make(map[string]map[string]time.Time)}
+}
+
+// record notes the registrations as delivered to relay at t
+func (c *registrationCoverage) record(relay string, registrations []types.SignedValidatorRegistration, t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, registration := range registrations {
+		if registration.Message == nil {
+			continue
+		}
+		pubkey := strings.ToLower(registration.Message.Pubkey.String())
+		if _, ok := c.delivered[pubkey]; !ok {
+			c.delivered[pubkey] = make(map[string]time.Time)
+		}
+		c.delivered[pubkey][relay] = t
+	}
+}
+
+// report returns the coverage of the validators with the given pubkeys by the relays since a time
+func (c *registrationCoverage) report(pubkeys []string, relays []RelayEntry, since time.Time) []relayRegistrationCoverage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ret := make([]relayRegistrationCoverage, len(relays))
+	for i, relay := range relays {
+		coverage := relayRegistrationCoverage{Relay: relay.String(), Missing: []string{}}
+		for _, pubkey := range pubkeys {
+			if t, ok := c.delivered[pubkey][relay.String()]; ok && !t.Before(since) {
+				coverage.NumCovered++
+			} else {
+				coverage.Missing = append(coverage.Missing, pubkey)
+			}
+		}
+		if len(pubkeys) > 0 {
+			coverage.Coverage = float64(coverage.NumCovered) / float64(len(pubkeys))
+		}
+		ret[i] = coverage
+	}
+	return ret
+}
+
+// ReadValidatorPubkeys reads a file with one validator pubkey per line. Empty lines and lines starting with # are
+// skipped.
+func ReadValidatorPubkeys(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pubkeys := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var pubkey types.PublicKey
+		if err := pubkey.UnmarshalText([]byte(line)); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidValidatorPubkey, line)
+		}
+		pubkeys = append(pubkeys, strings.ToLower(pubkey.String()))
+	}
+	return pubkeys, scanner.Err()
+}
+
+// coverageValidators returns the pubkeys of the expected validators and of the validators which registered with
+// this instance, sorted
+func (m *BoostService) coverageValidators() []string {
+	known := make(map[string]bool, len(m.expectedValidators))
+	for _, pubkey := range m.expectedValidators {
+		known[pubkey] = true
+	}
+	m.registrationsLock.Lock()
+	for pubkey := range m.registrations {
+		known[pubkey] = true
+	}
+	m.registrationsLock.Unlock()
+
+	pubkeys := make([]string, 0, len(known))
+	for pubkey := range known {
+		pubkeys = append(pubkeys, pubkey)
+	}
+	sort.Strings(pubkeys)
+	return pubkeys
+}
+
+// handleDebugRegistrationCoverage reports which validators had their registrations delivered to which relays within
+// the last epochs, given by the epochs query parameter
+func (m *BoostService) handleDebugRegistrationCoverage(w http.ResponseWriter, req *http.Request) {
+	epochs := uint64(defaultCoverageEpochs)
+	if s := req.URL.Query().Get("epochs"); s != "" {
+		var err error
+		if epochs, err = strconv.ParseUint(s, 10, 64); err != nil || epochs == 0 {
+			m.respondError(w, http.StatusBadRequest, "invalid epochs: "+s)
+			return
+		}
+	}
+
+	since := m.clock.Now().Add(-time.Duration(epochs*m.chain.SlotsPerEpoch) * m.chain.slotDuration()).UTC()
+	pubkeys := m.coverageValidators()
+	m.respondOK(w, registrationCoverageResponse{
+		Epochs:        epochs,
+		Since:         since,
+		NumValidators: len(pubkeys),
+		Relays:        m.registrationCoverage.report(pubkeys, m.relays, since),
+	})
+}
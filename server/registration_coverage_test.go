@@ -0,0 +1,58 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistrationCoverage(t *testing.T) {
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	expected := "0xa1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca2490"
+	backend := newTestBackend(t, 2, time.Second)
+	backend.boost.debugAPI = true
+	backend.boost.expectedValidators = []string{expected}
+	backend.relays[1].overrideHandleRegisterValidator(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	rr := backend.request(t, http.MethodPost, pathRegisterValidator, []types.SignedValidatorRegistration{payloadRegisterValidator})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	rr = backend.request(t, http.MethodGet, pathDebugRegistrationCoverage+"?epochs=1", nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	resp := new(registrationCoverageResponse)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+	require.Equal(t, uint64(1), resp.Epochs)
+	require.Equal(t, 2, resp.NumValidators)
+	require.Equal(t, []relayRegistrationCoverage{
+		{Relay: backend.relays[0].RelayEntry.String(), NumCovered: 1, Coverage: 0.5, Missing: []string{expected}},
+		{Relay: backend.relays[1].RelayEntry.String(), NumCovered: 0, Coverage: 0, Missing: []string{pubkey, expected}},
+	}, resp.Relays)
+
+	// Deliveries before the window don't count
+	report := backend.boost.registrationCoverage.report([]string{pubkey}, backend.boost.relays[:1], time.Now().Add(time.Minute))
+	require.Equal(t, []string{pubkey}, report[0].Missing)
+
+	rr = backend.request(t, http.MethodGet, pathDebugRegistrationCoverage+"?epochs=0", nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestReadValidatorPubkeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "validators.txt")
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	require.NoError(t, os.WriteFile(path, []byte("# validators\n\n"+pubkey+"\n"), 0o600))
+	pubkeys, err := ReadValidatorPubkeys(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{pubkey}, pubkeys)
+
+	require.NoError(t, os.WriteFile(path, []byte(pubkey+"\n0x1234\n"), 0o600))
+	_, err = ReadValidatorPubkeys(path)
+	require.ErrorIs(t, err, ErrInvalidValidatorPubkey)
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/flashbots/go-boost-utils/types"
+)
+
+// Genesis fork versions of known networks
+const (
+	GenesisForkVersionMainnet = "0x00000000"
+	GenesisForkVersionKiln    = "0x70000069" // https://github.com/eth-clients/merge-testnets/blob/main/kiln/config.yaml#L10
+	GenesisForkVersionRopsten = "0x80000069"
+	GenesisForkVersionSepolia = "0x90000069"
+	GenesisForkVersionGoerli  = "0x00001020"
+	GenesisForkVersionGnosis  = "0x00000064"
+)
+
+// KnownGenesisForkVersions maps network names to their genesis fork version
+var KnownGenesisForkVersions = map[string]string{
+	"mainnet": GenesisForkVersionMainnet,
+	"kiln":    GenesisForkVersionKiln,
+	"ropsten": GenesisForkVersionRopsten,
+	"sepolia": GenesisForkVersionSepolia,
+	"goerli":  GenesisForkVersionGoerli,
+	"gnosis":  GenesisForkVersionGnosis,
+}
+
+// registrationNetwork returns the known network for whose builder domain the registration is signed, or an empty
+// string if the signature is invalid for all known networks
+func registrationNetwork(registration types.SignedValidatorRegistration) string {
+	names := make([]string, 0, len(KnownGenesisForkVersions))
+	for name := range KnownGenesisForkVersions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		domain, err := ComputeDomain(types.DomainTypeAppBuilder, KnownGenesisForkVersions[name], types.Root{}.String())
+		if err != nil {
+			continue
+		}
+		if ok, err := verifySignature(registration.Message, domain, registration.Message.Pubkey, registration.Signature); err == nil && ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// diagnoseRegistrations verifies the signature of registrations the relays did not accept, and returns a message
+// explaining the misconfiguration if they are not signed for the network served, or an empty string otherwise. Only
+// the first registration is verified, as all registrations of a validator client are signed for the same network.
+func (m *BoostService) diagnoseRegistrations(registrations []types.SignedValidatorRegistration) string {
+	for _, registration := range registrations {
+		if registration.Message == nil {
+			continue
+		}
+		if ok, err := verifySignature(registration.Message, m.builderSigningDomain, registration.Message.Pubkey, registration.Signature); err == nil && ok {
+			return ""
+		}
+
+		network := registrationNetwork(registration)
+		if network == "" {
+			m.metrics.registrationWrongNetwork.WithLabelValues("unknown").Inc()
+			return "validator registrations have invalid signatures for all known networks, check the genesis fork version of the validator client"
+		}
+		m.metrics.registrationWrongNetwork.WithLabelValues(network).Inc()
+		return fmt.Sprintf("validator registrations are signed for %s, a different network than served by mev-boost, check the network of the validator client and mev-boost", network)
+	}
+	return ""
+}
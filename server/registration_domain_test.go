@@ -0,0 +1,58 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/bls"
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// signedRegistration returns a validator registration signed for the network of the genesis fork version
+func signedRegistration(t *testing.T, genesisForkVersion string) types.SignedValidatorRegistration {
+	t.Helper()
+	sk, pk, err := bls.GenerateNewKeypair()
+	require.NoError(t, err)
+	message := &types.RegisterValidatorRequestMessage{
+		FeeRecipient: _HexToAddress("0xdb65fEd33dc262Fe09D9a2Ba8F80b329BA25f941"),
+		Timestamp:    1234356,
+		GasLimit:     278234191203,
+	}
+	require.NoError(t, message.Pubkey.FromSlice(pk.Compress()))
+	domain, err := ComputeDomain(types.DomainTypeAppBuilder, genesisForkVersion, types.Root{}.String())
+	require.NoError(t, err)
+	signature, err := types.SignMessage(message, domain, sk)
+	require.NoError(t, err)
+	return types.SignedValidatorRegistration{Message: message, Signature: signature}
+}
+
+func TestRegistrationNetwork(t *testing.T) {
+	require.Equal(t, "goerli", registrationNetwork(signedRegistration(t, GenesisForkVersionGoerli)))
+	require.Equal(t, "mainnet", registrationNetwork(signedRegistration(t, GenesisForkVersionMainnet)))
+	require.Equal(t, "", registrationNetwork(signedRegistration(t, "0x12345678")))
+}
+
+func TestDiagnoseRejectedRegistrations(t *testing.T) {
+	backend := newTestBackend(t, 1, time.Second)
+	backend.relays[0].overrideHandleRegisterValidator(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, `{"code":400,"message":"invalid signature"}`, http.StatusBadRequest)
+	})
+
+	// The backend serves mainnet
+	rr := backend.request(t, http.MethodPost, pathRegisterValidator, []types.SignedValidatorRegistration{signedRegistration(t, GenesisForkVersionSepolia)})
+	require.Equal(t, http.StatusBadGateway, rr.Code)
+	require.Contains(t, rr.Body.String(), "signed for sepolia")
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.registrationWrongNetwork.WithLabelValues("sepolia")))
+
+	rr = backend.request(t, http.MethodPost, pathRegisterValidator, []types.SignedValidatorRegistration{signedRegistration(t, "0x12345678")})
+	require.Equal(t, http.StatusBadGateway, rr.Code)
+	require.Contains(t, rr.Body.String(), "invalid signatures for all known networks")
+
+	// Registrations for the network served are rejected for other reasons
+	rr = backend.request(t, http.MethodPost, pathRegisterValidator, []types.SignedValidatorRegistration{signedRegistration(t, GenesisForkVersionMainnet)})
+	require.Equal(t, http.StatusBadGateway, rr.Code)
+	require.NotContains(t, rr.Body.String(), "network")
+}
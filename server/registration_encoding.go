@@ -0,0 +1,145 @@
+package server
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+	"sync/atomic"
+
+	"github.com/flashbots/go-boost-utils/types"
+)
+
+// registrationEncodingJSON is the encoding of registration batches for all relays. Relays accepting other encodings
+// get their own cache entries.
+const registrationEncodingJSON = "json"
+
+// encodedPayload is a request body which sendHTTPRequest sends as is, instead of marshaling it
+type encodedPayload []byte
+
+// regEncodingKey identifies the encoded body of a registration batch
+type regEncodingKey struct {
+	encoding string
+	digest   [32]byte // of the registrations, see registrationsDigest
+}
+
+type regEncodingEntry struct {
+	key  regEncodingKey
+	body encodedPayload
+}
+
+// registrationEncodingCache keeps the encoded bodies of recent registration batches in an LRU bounded by their total
+// size, so the same batch sent to several relays, retried, or replayed is not encoded again. Hashing the fixed-size
+// fields of the registrations is much cheaper than encoding them.
+type registrationEncodingCache struct {
+	mu       sync.Mutex
+	maxBytes int
+	bytes    int
+	entries  map[regEncodingKey]*list.Element
+	lru      *list.List
+
+	hits   uint64
+	misses uint64
+}
+
+// newRegistrationEncodingCache creates a cache holding up to maxBytes of encoded batches. A size of 0 disables
+// caching.
+func newRegistrationEncodingCache(maxBytes int) *registrationEncodingCache {
+	return &registrationEncodingCache{
+		maxBytes: maxBytes,
+		entries:  make(map[regEncodingKey]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// registrationsDigest returns the hash of all fields of the registrations, in order
+func registrationsDigest(registrations []types.SignedValidatorRegistration) [32]byte {
+	h := sha256.New()
+	var buf [8]byte
+	for _, registration := range registrations {
+		if registration.Message == nil {
+			h.Write([]byte{0})
+			continue
+		}
+		h.Write([]byte{1})
+		h.Write(registration.Message.FeeRecipient[:])
+		binary.LittleEndian.PutUint64(buf[:], registration.Message.GasLimit)
+		h.Write(buf[:])
+		binary.LittleEndian.PutUint64(buf[:], registration.Message.Timestamp)
+		h.Write(buf[:])
+		h.Write(registration.Message.Pubkey[:])
+		h.Write(registration.Signature[:])
+	}
+	var digest [32]byte
+	copy(digest[:], h.Sum(nil))
+	return digest
+}
+
+// encode returns the body of a registration batch in an encoding, from the cache or encoded with encode
+func (c *registrationEncodingCache) encode(encoding string, registrations []types.SignedValidatorRegistration, encode func() ([]byte, error)) (encodedPayload, error) {
+	if c.maxBytes <= 0 {
+		return encode()
+	}
+
+	key := regEncodingKey{encoding: encoding, digest: registrationsDigest(registrations)}
+	if body, found := c.get(key); found {
+		atomic.AddUint64(&c.hits, 1)
+		return body, nil
+	}
+	atomic.AddUint64(&c.misses, 1)
+
+	body, err := encode()
+	if err != nil {
+		return nil, err
+	}
+	c.add(key, body)
+	return body, nil
+}
+
+func (c *registrationEncodingCache) get(key regEncodingKey) (encodedPayload, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, found := c.entries[key]
+	if !found {
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return el.Value.(*regEncodingEntry).body, true
+}
+
+// add caches a body, evicting the least recently used ones beyond the size limit. Bodies larger than the limit are
+// not cached.
+func (c *registrationEncodingCache) add(key regEncodingKey, body encodedPayload) {
+	if len(body) > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, found := c.entries[key]; found {
+		c.lru.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&regEncodingEntry{key: key, body: body})
+	c.bytes += len(body)
+	for c.bytes > c.maxBytes {
+		oldest := c.lru.Back()
+		entry := oldest.Value.(*regEncodingEntry)
+		c.lru.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.bytes -= len(entry.body)
+	}
+}
+
+// stats returns the number of cache hits and misses
+func (c *registrationEncodingCache) stats() (hits, misses uint64) {
+	return atomic.LoadUint64(&c.hits), atomic.LoadUint64(&c.misses)
+}
+
+// encodeRegistrations returns the body of a registration batch for a relay
+func (m *BoostService) encodeRegistrations(registrations []types.SignedValidatorRegistration) (encodedPayload, error) {
+	return m.registrationEncodings.encode(registrationEncodingJSON, registrations, func() ([]byte, error) {
+		return marshalJSON(m.jsonCodec, registrations)
+	})
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistrationsDigest(t *testing.T) {
+	registrations := []types.SignedValidatorRegistration{payloadRegisterValidator}
+	digest := registrationsDigest(registrations)
+	require.Equal(t, digest, registrationsDigest([]types.SignedValidatorRegistration{payloadRegisterValidator}))
+
+	message := *payloadRegisterValidator.Message
+	message.GasLimit++
+	require.NotEqual(t, digest, registrationsDigest([]types.SignedValidatorRegistration{{Message: &message, Signature: payloadRegisterValidator.Signature}}))
+	require.NotEqual(t, digest, registrationsDigest(append(registrations, payloadRegisterValidator)))
+	require.NotEqual(t, digest, registrationsDigest([]types.SignedValidatorRegistration{{}}))
+}
+
+func TestRegistrationEncodingCache(t *testing.T) {
+	registrations := []types.SignedValidatorRegistration{payloadRegisterValidator}
+	encodings := 0
+	encode := func() ([]byte, error) {
+		encodings++
+		return json.Marshal(registrations)
+	}
+	expected, err := json.Marshal(registrations)
+	require.NoError(t, err)
+
+	t.Run("Memoizes bodies per encoding", func(t *testing.T) {
+		cache := newRegistrationEncodingCache(10 * len(expected))
+		for i := 0; i < 2; i++ {
+			body, err := cache.encode(registrationEncodingJSON, registrations, encode)
+			require.NoError(t, err)
+			require.Equal(t, expected, []byte(body))
+		}
+		_, err := cache.encode("other", registrations, encode)
+		require.NoError(t, err)
+
+		hits, misses := cache.stats()
+		require.Equal(t, uint64(1), hits)
+		require.Equal(t, uint64(2), misses)
+	})
+
+	t.Run("Evicts least recently used beyond the size", func(t *testing.T) {
+		cache := newRegistrationEncodingCache(len(expected))
+		_, err := cache.encode(registrationEncodingJSON, registrations, encode)
+		require.NoError(t, err)
+		_, err = cache.encode("other", registrations, encode)
+		require.NoError(t, err)
+		require.Equal(t, 1, cache.lru.Len())
+		require.Equal(t, len(expected), cache.bytes)
+
+		// Bodies larger than the cache are not cached
+		cache = newRegistrationEncodingCache(len(expected) - 1)
+		_, err = cache.encode(registrationEncodingJSON, registrations, encode)
+		require.NoError(t, err)
+		require.Equal(t, 0, cache.lru.Len())
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		encodings = 0
+		cache := newRegistrationEncodingCache(0)
+		for i := 0; i < 2; i++ {
+			_, err := cache.encode(registrationEncodingJSON, registrations, encode)
+			require.NoError(t, err)
+		}
+		require.Equal(t, 2, encodings)
+	})
+}
+
+func TestRegistrationsEncodedOnce(t *testing.T) {
+	backend := newTestBackend(t, 2, time.Second)
+	backend.boost.registrationEncodings = newRegistrationEncodingCache(1 << 20)
+
+	payload := []types.SignedValidatorRegistration{payloadRegisterValidator}
+	for _, relay := range backend.boost.relays {
+		require.NoError(t, backend.boost.sendRegistrations(context.Background(), relay, payload, ""))
+	}
+	hits, misses := backend.boost.registrationEncodings.stats()
+	require.Equal(t, uint64(1), hits)
+	require.Equal(t, uint64(1), misses)
+	require.Equal(t, 1, backend.relays[1].GetRequestCount(pathRegisterValidator))
+}
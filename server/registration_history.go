@@ -0,0 +1,104 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// registrationHistoryWeight is the weight of a new outcome in the moving averages of the registration history
+	registrationHistoryWeight = 8
+
+	// A relay is flaky once its registration success rate is below registrationFlakySuccessRate, after at least
+	// registrationFlakyMinAttempts attempts
+	registrationFlakySuccessRate = 0.5
+	registrationFlakyMinAttempts = 3
+)
+
+// relayRegistrationHistory is the moving average of the outcomes of the registerValidator calls to a relay
+type relayRegistrationHistory struct {
+	attempts    int
+	successRate float64
+	latency     time.Duration
+}
+
+// registrationHistory keeps the registration success history of the relays, by relay URL, to order the
+// registration fan-out
+type registrationHistory struct {
+	mu     sync.Mutex
+	relays map[string]*relayRegistrationHistory
+}
+
+func newRegistrationHistory() *registrationHistory {
+	return &registrationHistory{relays: make(map[string]*relayRegistrationHistory)}
+}
+
+// record adds the outcome of a registerValidator call to a relay. Registrations rejected by the relay, or not sent
+// for lack of outbound request budget, are not recorded, as they are not a failure of the relay.
+func (h *registrationHistory) record(relay string, err error, latency time.Duration) {
+	var relayErr *RelayError
+	if errors.As(err, &relayErr) && relayErr.StatusCode >= 400 && relayErr.StatusCode < 500 && relayErr.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+	if errors.Is(err, errOutboundBudgetExhausted) {
+		return
+	}
+	success := 0.0
+	if err == nil {
+		success = 1
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r, ok := h.relays[relay]
+	if !ok {
+		h.relays[relay] = &relayRegistrationHistory{attempts: 1, successRate: success, latency: latency}
+		return
+	}
+	r.attempts++
+	r.successRate += (success - r.successRate) / registrationHistoryWeight
+	r.latency += (latency - r.latency) / registrationHistoryWeight
+}
+
+// order returns the relays by decreasing success rate and then increasing latency, and splits off the flaky relays.
+// Relays without history come first. If all relays are flaky, none is split off.
+func (h *registrationHistory) order(relays []RelayEntry) (ordered, flaky []RelayEntry) {
+	h.mu.Lock()
+	histories := make([]relayRegistrationHistory, len(relays))
+	for i, relay := range relays {
+		if r, ok := h.relays[relay.String()]; ok {
+			histories[i] = *r
+		} else {
+			histories[i] = relayRegistrationHistory{successRate: 1}
+		}
+	}
+	h.mu.Unlock()
+
+	indexes := make([]int, len(relays))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		a, b := histories[indexes[i]], histories[indexes[j]]
+		if a.successRate != b.successRate {
+			return a.successRate > b.successRate
+		}
+		return a.latency < b.latency
+	})
+
+	for _, i := range indexes {
+		r := histories[i]
+		if r.attempts >= registrationFlakyMinAttempts && r.successRate < registrationFlakySuccessRate {
+			flaky = append(flaky, relays[i])
+		} else {
+			ordered = append(ordered, relays[i])
+		}
+	}
+	if len(ordered) == 0 {
+		return flaky, nil
+	}
+	return ordered, flaky
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistrationHistoryOrder(t *testing.T) {
+	relays := make([]RelayEntry, 3)
+	for i := range relays {
+		u, err := url.Parse(fmt.Sprintf("https://relay%d.com", i+1))
+		require.NoError(t, err)
+		relays[i] = RelayEntry{URL: u}
+	}
+	h := newRegistrationHistory()
+	ordered, flaky := h.order(relays)
+	require.Equal(t, relays, ordered)
+	require.Empty(t, flaky)
+
+	// Relays which rejected registrations are not flaky
+	for i := 0; i < registrationFlakyMinAttempts; i++ {
+		h.record(relays[0].String(), errors.New("timeout"), time.Second)
+		h.record(relays[1].String(), nil, 2*time.Second)
+		h.record(relays[2].String(), &RelayError{StatusCode: http.StatusBadRequest}, time.Second)
+	}
+	ordered, flaky = h.order(relays)
+	require.Equal(t, []RelayEntry{relays[2], relays[1]}, ordered)
+	require.Equal(t, []RelayEntry{relays[0]}, flaky)
+
+	// and a flaky relay recovers with successes
+	for i := 0; i < registrationHistoryWeight; i++ {
+		h.record(relays[0].String(), nil, time.Second)
+	}
+	ordered, flaky = h.order(relays)
+	require.Equal(t, []RelayEntry{relays[2], relays[1], relays[0]}, ordered)
+	require.Empty(t, flaky)
+}
+
+func TestRegisterValidatorFlakyRelay(t *testing.T) {
+	backend := newTestBackend(t, 2, time.Second)
+	flakyRelay := backend.boost.relays[0].String()
+	backend.relays[0].overrideHandleRegisterValidator(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	payload := []types.SignedValidatorRegistration{payloadRegisterValidator}
+
+	for i := 1; i <= registrationFlakyMinAttempts; i++ {
+		rr := backend.request(t, http.MethodPost, pathRegisterValidator, payload)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Eventually(t, func() bool {
+			return backend.relays[0].GetRequestCount(pathRegisterValidator) == i
+		}, time.Second, time.Millisecond)
+	}
+	require.Equal(t, 0, backend.boost.registrationQueue.depth(flakyRelay))
+
+	// The flaky relay is no longer called, but gets the registrations with the queue
+	require.Eventually(t, func() bool {
+		_, flaky := backend.boost.registrationHistory.order(backend.boost.relays)
+		return len(flaky) == 1
+	}, time.Second, time.Millisecond)
+	rr := backend.request(t, http.MethodPost, pathRegisterValidator, payload)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, registrationFlakyMinAttempts, backend.relays[0].GetRequestCount(pathRegisterValidator))
+	require.Equal(t, registrationFlakyMinAttempts+1, backend.relays[1].GetRequestCount(pathRegisterValidator))
+	require.Equal(t, 1, backend.boost.registrationQueue.depth(flakyRelay))
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.registrationsDeferred.WithLabelValues(flakyRelay)))
+}
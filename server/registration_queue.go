@@ -0,0 +1,274 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	registrationQueueMinBackoff      = 1 * time.Second
+	registrationQueueMaxBackoff      = 1 * time.Minute
+	registrationQueuePersistInterval = 5 * time.Second
+)
+
+// registrationQueue holds the validator registrations which are not yet delivered to each relay. Only the latest
+// registration per validator is kept, which bounds the queue by the number of validators.
+type registrationQueue struct {
+	path string // file the queue is persisted to, optional
+	key  []byte // key encrypting the file, optional
+
+	mu      sync.Mutex
+	pending map[string]map[string]types.SignedValidatorRegistration // per relay and pubkey
+	notify  map[string]chan struct{}                                // per relay, signalled on enqueue
+	dirty   bool                                                    // changed since last persisted
+}
+
+// newRegistrationQueue creates a queue for the relays, restoring the registrations persisted at path if any. The
+// file is encrypted with key, if given.
+func newRegistrationQueue(relays []RelayEntry, path string, key []byte) (*registrationQueue, error) {
+	q := &registrationQueue{
+		path:    path,
+		key:     key,
+		pending: make(map[string]map[string]types.SignedValidatorRegistration),
+		notify:  make(map[string]chan struct{}),
+	}
+	for _, relay := range relays {
+		q.pending[relay.String()] = make(map[string]types.SignedValidatorRegistration)
+		q.notify[relay.String()] = make(chan struct{}, 1)
+	}
+
+	if path == "" {
+		return q, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if data, err = openAtRest(key, data); err != nil {
+		return nil, err
+	}
+
+	persisted := make(map[string][]types.SignedValidatorRegistration)
+	if err := json.Unmarshal(data, &persisted); err != nil {
+		return nil, err
+	}
+	for relay, registrations := range persisted {
+		if _, ok := q.pending[relay]; !ok { // relay no longer configured
+			continue
+		}
+		q.add(relay, registrations)
+	}
+	return q, nil
+}
+
+func (q *registrationQueue) add(relay string, registrations []types.SignedValidatorRegistration) {
+	for _, registration := range registrations {
+		if registration.Message == nil {
+			continue
+		}
+		pubkey := strings.ToLower(registration.Message.Pubkey.String())
+		if existing, ok := q.pending[relay][pubkey]; ok && existing.Message.Timestamp > registration.Message.Timestamp {
+			continue
+		}
+		q.pending[relay][pubkey] = registration
+	}
+}
+
+// enqueue adds the registrations for the given relays, or for all relays if none are given
+func (q *registrationQueue) enqueue(registrations []types.SignedValidatorRegistration, relays ...string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	selected := make(map[string]bool, len(relays))
+	for _, relay := range relays {
+		selected[relay] = true
+	}
+
+	for relay := range q.pending {
+		if len(relays) > 0 && !selected[relay] {
+			continue
+		}
+		q.add(relay, registrations)
+		select {
+		case q.notify[relay] <- struct{}{}:
+		default:
+		}
+	}
+	q.dirty = true
+}
+
+// next returns up to max pending registrations for the relay (all if max is 0), ordered by pubkey
+func (q *registrationQueue) next(relay string, max int) []types.SignedValidatorRegistration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pubkeys := make([]string, 0, len(q.pending[relay]))
+	for pubkey := range q.pending[relay] {
+		pubkeys = append(pubkeys, pubkey)
+	}
+	sort.Strings(pubkeys)
+	if max > 0 && len(pubkeys) > max {
+		pubkeys = pubkeys[:max]
+	}
+
+	registrations := make([]types.SignedValidatorRegistration, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		registrations[i] = q.pending[relay][pubkey]
+	}
+	return registrations
+}
+
+// done removes the delivered registrations for the relay, unless they were replaced by newer ones in the meantime
+func (q *registrationQueue) done(relay string, registrations []types.SignedValidatorRegistration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, registration := range registrations {
+		pubkey := strings.ToLower(registration.Message.Pubkey.String())
+		if pending, ok := q.pending[relay][pubkey]; ok && pending.Message.Timestamp == registration.Message.Timestamp {
+			delete(q.pending[relay], pubkey)
+		}
+	}
+	q.dirty = true
+}
+
+// depth returns the number of pending registrations for the relay
+func (q *registrationQueue) depth(relay string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending[relay])
+}
+
+// persist writes the queue to its file if it changed since it was last persisted
+func (q *registrationQueue) persist() error {
+	q.mu.Lock()
+	if q.path == "" || !q.dirty {
+		q.mu.Unlock()
+		return nil
+	}
+	persisted := make(map[string][]types.SignedValidatorRegistration, len(q.pending))
+	for relay, registrations := range q.pending {
+		for _, registration := range registrations {
+			persisted[relay] = append(persisted[relay], registration)
+		}
+	}
+	q.dirty = false
+	q.mu.Unlock()
+
+	data, err := json.Marshal(persisted)
+	if err != nil {
+		return err
+	}
+	if data, err = sealAtRest(q.key, data); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so that the queue file is never partially written
+	tmpPath := q.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, q.path)
+}
+
+// startRegistrationQueueTasks starts a worker per relay draining the queue, and the job persisting it
+func (m *BoostService) startRegistrationQueueTasks() {
+	for _, relay := range m.relays {
+		relay := relay
+		m.scheduler.run("registration_queue", func(ctx context.Context) {
+			m.startRegistrationQueueWorker(ctx, relay)
+		})
+	}
+
+	m.scheduler.every("registration_queue_persist", registrationQueuePersistInterval, schedulerJitter, func(ctx context.Context) error {
+		return m.registrationQueue.persist()
+	})
+}
+
+// startRegistrationQueueWorker delivers the queued registrations to a relay in batches, with a pause of
+// registrationQueuePacing between batches and an exponential backoff on errors
+func (m *BoostService) startRegistrationQueueWorker(ctx context.Context, relay RelayEntry) {
+	log := m.log.WithFields(logrus.Fields{
+		"method": "registrationQueue",
+		"relay":  relay.String(),
+	})
+	url := relay.GetURI(pathRegisterValidator)
+	backoff := time.Duration(0)
+
+	for {
+		depth := m.registrationQueue.depth(relay.String())
+		m.metrics.registrationQueueDepth.WithLabelValues(relay.String()).Set(float64(depth))
+		if depth == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-m.registrationQueue.notify[relay.String()]:
+			}
+			continue
+		}
+
+		batch := m.registrationQueue.next(relay.String(), m.relayCapabilities.get(relay.String()).maxRegistrationBatchSize())
+		if m.relayDrains.isRemoved(relay.String(), m.clock.Now()) {
+			log.WithField("numRegistrations", len(batch)).Debug("dropping queued registrations for drained relay")
+			m.registrationQueue.done(relay.String(), batch)
+			continue
+		}
+		body, err := m.encodeRegistrations(batch)
+		if err != nil {
+			log.WithError(err).WithField("numRegistrations", len(batch)).Error("could not encode queued registrations, dropping them")
+			m.registrationQueue.done(relay.String(), batch)
+			continue
+		}
+		start := m.clock.Now()
+		code, header, err := sendHTTPRequest(context.Background(), m.relayClient(relay), http.MethodPost, url, "", body, nil, responseOpts{codec: m.jsonCodec})
+		m.recordIdempotency(relay, header)
+		m.registrationHistory.record(relay.String(), err, m.clock.Now().Sub(start))
+		if err != nil && code >= 400 && code < 500 && code != http.StatusTooManyRequests {
+			// The relay rejected the registrations, which retrying would not change
+			m.publishRelayError(relay, relayEndpoint(pathRegisterValidator), err)
+			log.WithError(err).WithField("numRegistrations", len(batch)).Error("relay rejected queued registrations, dropping them")
+			if diagnosis := m.diagnoseRegistrations(batch); diagnosis != "" {
+				log.Error(diagnosis)
+			}
+			m.registrationQueue.done(relay.String(), batch)
+			continue
+		} else if err != nil {
+			m.publishRelayError(relay, relayEndpoint(pathRegisterValidator), err)
+			backoff *= 2
+			if backoff < registrationQueueMinBackoff {
+				backoff = registrationQueueMinBackoff
+			} else if backoff > registrationQueueMaxBackoff {
+				backoff = registrationQueueMaxBackoff
+			}
+			if relay.InMaintenance(m.clock.Now()) {
+				log.WithError(err).Debug("error delivering queued registrations to relay in maintenance")
+			} else {
+				log.WithError(err).WithField("backoff", backoff.String()).Warn("error delivering queued registrations to relay")
+			}
+			if !m.scheduler.sleep(ctx, backoff) {
+				return
+			}
+			continue
+		}
+
+		backoff = 0
+		m.registrationQueue.done(relay.String(), batch)
+		m.registrationCoverage.record(relay.String(), batch, m.clock.Now())
+		log.WithField("numRegistrations", len(batch)).Debug("delivered queued registrations to relay")
+		if !m.scheduler.sleep(ctx, m.registrationQueuePacing) {
+			return
+		}
+	}
+}
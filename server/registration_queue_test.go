@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRegistration(pubkey byte, timestamp uint64) types.SignedValidatorRegistration {
+	return types.SignedValidatorRegistration{
+		Message: &types.RegisterValidatorRequestMessage{Pubkey: types.PublicKey{pubkey}, Timestamp: timestamp},
+	}
+}
+
+func TestRegistrationQueue(t *testing.T) {
+	relay := newMockRelay(t).RelayEntry
+	path := filepath.Join(t.TempDir(), "queue.json")
+	q, err := newRegistrationQueue([]RelayEntry{relay}, path, nil)
+	require.NoError(t, err)
+
+	// Only the latest registration per validator is kept
+	q.enqueue([]types.SignedValidatorRegistration{newTestRegistration(1, 2), newTestRegistration(2, 1)})
+	q.enqueue([]types.SignedValidatorRegistration{newTestRegistration(1, 1), newTestRegistration(3, 1)})
+	require.Equal(t, 3, q.depth(relay.String()))
+
+	batch := q.next(relay.String(), 2)
+	require.Len(t, batch, 2)
+	require.Equal(t, uint64(2), batch[0].Message.Timestamp)
+
+	// A registration replaced while being delivered stays queued
+	q.enqueue([]types.SignedValidatorRegistration{newTestRegistration(2, 3)})
+	q.done(relay.String(), batch)
+	require.Equal(t, 2, q.depth(relay.String()))
+
+	// The queue is restored from its file
+	require.NoError(t, q.persist())
+	restored, err := newRegistrationQueue([]RelayEntry{relay}, path, nil)
+	require.NoError(t, err)
+	require.Equal(t, q.next(relay.String(), 0), restored.next(relay.String(), 0))
+}
+
+func TestRegisterValidatorQueue(t *testing.T) {
+	backend := newTestBackend(t, 1, time.Second)
+	queue, err := newRegistrationQueue(backend.boost.relays, "", nil)
+	require.NoError(t, err)
+	backend.boost.registrationQueue = queue
+	backend.boost.queueRegistrations = true
+
+	// Registrations are acknowledged without calling the relay
+	backend.relays[0].ResponseDelay = 100 * time.Millisecond
+	rr := backend.request(t, http.MethodPost, pathRegisterValidator, []types.SignedValidatorRegistration{newTestRegistration(1, 1)})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, 0, backend.relays[0].GetRequestCount(pathRegisterValidator))
+	require.Equal(t, 1, queue.depth(backend.boost.relays[0].String()))
+
+	// and delivered by the worker
+	go backend.boost.startRegistrationQueueWorker(context.Background(), backend.boost.relays[0])
+	require.Eventually(t, func() bool {
+		return queue.depth(backend.boost.relays[0].String()) == 0
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, 1, backend.relays[0].GetRequestCount(pathRegisterValidator))
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/sirupsen/logrus"
+)
+
+// replayRegistrationsResponse is the response of the admin endpoint replaying the validator registrations
+type replayRegistrationsResponse struct {
+	NumRegistrations int               `json:"num_registrations"`
+	Relays           map[string]string `json:"relays"` // result per relay host: "ok", "queued" or the error
+}
+
+// cachedRegistrations returns the latest registration of each validator which registered with this instance,
+// ordered by pubkey
+func (m *BoostService) cachedRegistrations() []types.SignedValidatorRegistration {
+	m.registrationsLock.Lock()
+	defer m.registrationsLock.Unlock()
+
+	pubkeys := make([]string, 0, len(m.registrations))
+	for pubkey := range m.registrations {
+		pubkeys = append(pubkeys, pubkey)
+	}
+	sort.Strings(pubkeys)
+
+	registrations := make([]types.SignedValidatorRegistration, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		registrations[i] = m.registrations[pubkey]
+	}
+	return registrations
+}
+
+// handleReplayRegistrations sends the cached validator registrations to all relays, or to the relay given by host
+// in the relay query parameter. This restores the registrations of relays which lost them, or were added later.
+func (m *BoostService) handleReplayRegistrations(w http.ResponseWriter, req *http.Request) {
+	relays := m.relays
+	if host := req.URL.Query().Get("relay"); host != "" {
+		relays = nil
+		for _, relay := range m.relays {
+			if relay.URL.Host == host {
+				relays = append(relays, relay)
+			}
+		}
+		if len(relays) == 0 {
+			m.respondError(w, http.StatusBadRequest, "unknown relay: "+host)
+			return
+		}
+	}
+
+	registrations := m.cachedRegistrations()
+	log := m.requestLog(req).WithFields(logrus.Fields{
+		"method":           "replayRegistrations",
+		"numRegistrations": len(registrations),
+		"numRelays":        len(relays),
+	})
+	resp := replayRegistrationsResponse{NumRegistrations: len(registrations), Relays: make(map[string]string, len(relays))}
+	if len(registrations) == 0 {
+		m.respondOK(w, resp)
+		return
+	}
+
+	// Queue mode delivers the registrations asynchronously, with retries
+	if m.queueRegistrations {
+		queueRelays := make([]string, len(relays))
+		for i, relay := range relays {
+			queueRelays[i] = relay.String()
+			resp.Relays[relay.URL.Host] = "queued"
+		}
+		m.registrationQueue.enqueue(registrations, queueRelays...)
+		log.Info("queued the replay of validator registrations")
+		m.respondOK(w, resp)
+		return
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, relay := range relays {
+		wg.Add(1)
+		go func(relay RelayEntry) {
+			defer wg.Done()
+			result := "ok"
+			if err := m.sendRegistrations(relayContext(req), relay, registrations, ""); err != nil {
+				log.WithError(err).WithField("relay", relay.String()).Warn("could not replay validator registrations to relay")
+				result = err.Error()
+			}
+			mu.Lock()
+			resp.Relays[relay.URL.Host] = result
+			mu.Unlock()
+		}(relay)
+	}
+	wg.Wait()
+
+	log.Info("replayed validator registrations")
+	m.respondOK(w, resp)
+}
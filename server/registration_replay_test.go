@@ -0,0 +1,74 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayRegistrations(t *testing.T) {
+	registrations := []types.SignedValidatorRegistration{newTestRegistration(1, 1), newTestRegistration(2, 1)}
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		rr := backend.request(t, http.MethodPost, pathAdminReplayRegistrations, nil)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Replays to all relays", func(t *testing.T) {
+		backend := newTestBackend(t, 2, time.Second)
+		backend.boost.adminAPI = true
+		rr := backend.request(t, http.MethodPost, pathRegisterValidator, registrations)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		backend.relays[1].overrideHandleRegisterValidator(func(w http.ResponseWriter, req *http.Request) {
+			http.Error(w, `{"code":500,"message":"internal error"}`, http.StatusInternalServerError)
+		})
+		rr = backend.request(t, http.MethodPost, pathAdminReplayRegistrations, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Equal(t, 2, backend.relays[0].GetRequestCount(pathRegisterValidator))
+
+		resp := new(replayRegistrationsResponse)
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		require.Equal(t, 2, resp.NumRegistrations)
+		require.Equal(t, "ok", resp.Relays[backend.relays[0].RelayEntry.URL.Host])
+		require.Contains(t, resp.Relays[backend.relays[1].RelayEntry.URL.Host], "internal error")
+	})
+
+	t.Run("Replays to the specified relay", func(t *testing.T) {
+		backend := newTestBackend(t, 2, time.Second)
+		backend.boost.adminAPI = true
+		rr := backend.request(t, http.MethodPost, pathRegisterValidator, registrations)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		rr = backend.request(t, http.MethodPost, pathAdminReplayRegistrations+"?relay="+backend.relays[1].RelayEntry.URL.Host, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Equal(t, 1, backend.relays[0].GetRequestCount(pathRegisterValidator))
+		require.Equal(t, 2, backend.relays[1].GetRequestCount(pathRegisterValidator))
+
+		rr = backend.request(t, http.MethodPost, pathAdminReplayRegistrations+"?relay=unknown:1234", nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+	})
+
+	t.Run("Queues the replay in queue mode", func(t *testing.T) {
+		backend := newTestBackend(t, 2, time.Second)
+		backend.boost.adminAPI = true
+		queue, err := newRegistrationQueue(backend.boost.relays, "", nil)
+		require.NoError(t, err)
+		backend.boost.registrationQueue = queue
+		backend.boost.queueRegistrations = true
+		rr := backend.request(t, http.MethodPost, pathRegisterValidator, registrations)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		queue.done(backend.boost.relays[0].String(), registrations)
+		queue.done(backend.boost.relays[1].String(), registrations)
+
+		rr = backend.request(t, http.MethodPost, pathAdminReplayRegistrations+"?relay="+backend.relays[0].RelayEntry.URL.Host, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Equal(t, 2, queue.depth(backend.boost.relays[0].String()))
+		require.Equal(t, 0, queue.depth(backend.boost.relays[1].String()))
+	})
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+)
+
+// RelayCapabilities are the features a relay announces on its capabilities endpoint
+type RelayCapabilities struct {
+	Forks                    []string `json:"forks"`
+	SSZ                      bool     `json:"ssz"`
+	Cancellations            bool     `json:"cancellations"`
+	MaxRegistrationBatchSize int      `json:"max_registration_batch_size"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SupportsFork returns whether the relay supports the given fork. Relays which don't announce forks are
+// assumed to support all of them.
+func (c *RelayCapabilities) SupportsFork(fork string) bool {
+	if c == nil || len(c.Forks) == 0 || fork == "" {
+		return true
+	}
+	for _, f := range c.Forks {
+		if f == fork {
+			return true
+		}
+	}
+	return false
+}
+
+// maxRegistrationBatchSize returns the maximum number of registrations per request, or 0 if unknown
+func (c *RelayCapabilities) maxRegistrationBatchSize() int {
+	if c == nil {
+		return 0
+	}
+	return c.MaxRegistrationBatchSize
+}
+
+// relayCapabilitiesStore caches the discovered capabilities per relay
+type relayCapabilitiesStore struct {
+	mu   sync.RWMutex
+	caps map[string]*RelayCapabilities
+}
+
+func newRelayCapabilitiesStore() *relayCapabilitiesStore {
+	return &relayCapabilitiesStore{caps: make(map[string]*RelayCapabilities)}
+}
+
+// get returns the capabilities of a relay, or nil if they are unknown
+func (s *relayCapabilitiesStore) get(relay string) *RelayCapabilities {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.caps[relay]
+}
+
+func (s *relayCapabilitiesStore) set(relay string, caps *RelayCapabilities) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.caps[relay] = caps
+}
+
+// fetchRelayCapabilities requests the capabilities of a single relay
+func (m *BoostService) fetchRelayCapabilities(relay RelayEntry) (*RelayCapabilities, error) {
+	caps := new(RelayCapabilities)
+	code, _, err := sendHTTPRequest(context.Background(), m.relayClient(relay), http.MethodGet, relay.GetURI(pathRelayCapabilities), "", nil, caps, responseOpts{})
+	if err != nil {
+		return nil, err
+	}
+	if code == http.StatusNoContent {
+		return nil, nil
+	}
+	caps.UpdatedAt = m.clock.Now()
+	return caps, nil
+}
+
+// updateRelayCapabilities polls the capabilities of all relays. Relays which don't provide capabilities keep
+// the last known ones.
+func (m *BoostService) updateRelayCapabilities() {
+	var wg sync.WaitGroup
+	for _, relay := range m.liveRelays(m.clock.Now()) {
+		wg.Add(1)
+		go func(relay RelayEntry) {
+			defer wg.Done()
+			log := m.log.WithField("relay", relay.String())
+
+			caps, err := m.fetchRelayCapabilities(relay)
+			if err != nil {
+				log.WithError(err).Debug("failed to fetch relay capabilities")
+				return
+			}
+			if caps != nil {
+				log.WithField("capabilities", caps).Debug("updated relay capabilities")
+				m.relayCapabilities.set(relay.String(), caps)
+			}
+		}(relay)
+	}
+	wg.Wait()
+}
+
+// chunkRegistrations splits registrations into batches of at most size entries. A size of 0 means no limit.
+func chunkRegistrations(registrations []types.SignedValidatorRegistration, size int) [][]types.SignedValidatorRegistration {
+	if size <= 0 || len(registrations) <= size {
+		return [][]types.SignedValidatorRegistration{registrations}
+	}
+
+	chunks := make([][]types.SignedValidatorRegistration, 0, (len(registrations)+size-1)/size)
+	for size < len(registrations) {
+		registrations, chunks = registrations[size:], append(chunks, registrations[0:size])
+	}
+	return append(chunks, registrations)
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkRegistrations(t *testing.T) {
+	registrations := make([]types.SignedValidatorRegistration, 5)
+
+	require.Len(t, chunkRegistrations(registrations, 0), 1)
+	require.Len(t, chunkRegistrations(registrations, 5), 1)
+
+	chunks := chunkRegistrations(registrations, 2)
+	require.Len(t, chunks, 3)
+	require.Len(t, chunks[0], 2)
+	require.Len(t, chunks[1], 2)
+	require.Len(t, chunks[2], 1)
+}
+
+func TestRelayCapabilitiesSupportsFork(t *testing.T) {
+	var unknown *RelayCapabilities
+	require.True(t, unknown.SupportsFork("capella"))
+	require.True(t, (&RelayCapabilities{}).SupportsFork("capella"))
+
+	caps := &RelayCapabilities{Forks: []string{"bellatrix"}}
+	require.True(t, caps.SupportsFork("bellatrix"))
+	require.False(t, caps.SupportsFork("capella"))
+	require.True(t, caps.SupportsFork(""))
+}
+
+func TestUpdateRelayCapabilities(t *testing.T) {
+	backend := newTestBackend(t, 2, time.Second)
+	backend.relays[0].Capabilities = &RelayCapabilities{Forks: []string{"bellatrix"}, MaxRegistrationBatchSize: 1}
+
+	backend.boost.updateRelayCapabilities()
+	caps := backend.boost.relayCapabilities.get(backend.relays[0].RelayEntry.String())
+	require.NotNil(t, caps)
+	require.Equal(t, []string{"bellatrix"}, caps.Forks)
+	require.Equal(t, 1, caps.MaxRegistrationBatchSize)
+	require.Nil(t, backend.boost.relayCapabilities.get(backend.relays[1].RelayEntry.String()))
+
+	t.Run("Registrations are batched", func(t *testing.T) {
+		payload := []types.SignedValidatorRegistration{payloadRegisterValidator, payloadRegisterValidator}
+		rr := backend.request(t, http.MethodPost, pathRegisterValidator, payload)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Eventually(t, func() bool {
+			return backend.relays[0].GetRequestCount(pathRegisterValidator) == 2
+		}, time.Second, 10*time.Millisecond)
+		require.Equal(t, 1, backend.relays[1].GetRequestCount(pathRegisterValidator))
+	})
+
+	t.Run("Relays without support for the fork are skipped", func(t *testing.T) {
+		backend.boost.forkSchedule = ForkSchedule{{Name: "capella", Epoch: 0}}
+		hash := _HexToHash("0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7")
+		path := fmt.Sprintf("/eth/v1/builder/header/%d/%s/%s", 1, hash.String(), backend.relays[0].RelayEntry.PublicKey.String())
+		backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, 0, backend.relays[0].GetRequestCount(path))
+		require.Equal(t, 1, backend.relays[1].GetRequestCount(path))
+
+		// The coverage is of the relays called
+		result := backend.boost.requestBids(context.Background(), backend.boost.log, 1, hash.String(), backend.relays[0].RelayEntry.PublicKey.String(), "")
+		require.Equal(t, "1/1", result.coverage)
+	})
+
+	t.Run("Capabilities in status API", func(t *testing.T) {
+		rr := backend.request(t, http.MethodGet, pathMevBoostStatus, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		resp := new(mevBoostStatusResponse)
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		require.Len(t, resp.Relays, 2)
+		require.NotNil(t, resp.Relays[0].Capabilities)
+		require.Equal(t, []string{"bellatrix"}, resp.Relays[0].Capabilities.Forks)
+		require.Nil(t, resp.Relays[1].Capabilities)
+	})
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"crypto/tls"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// relayCertificateWarnInterval is the minimum interval between warnings about the expiring certificate of a relay
+const relayCertificateWarnInterval = time.Hour
+
+// relayCertificate is the expiry of the TLS certificate chain last presented by a relay
+type relayCertificate struct {
+	notAfter time.Time // earliest expiry of the chain
+	warnedAt time.Time
+}
+
+// relayCertificates keeps the expiry of the TLS certificates of the relays, by relay URL
+type relayCertificates struct {
+	mu    sync.Mutex
+	certs map[string]*relayCertificate
+}
+
+func newRelayCertificates() *relayCertificates {
+	return &relayCertificates{certs: make(map[string]*relayCertificate)}
+}
+
+// expiry returns the expiry of the certificate of a relay, or nil if no TLS connection was made to the relay yet
+func (c *relayCertificates) expiry(relay string) *time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cert, ok := c.certs[relay]; ok {
+		notAfter := cert.notAfter
+		return &notAfter
+	}
+	return nil
+}
+
+// record stores the expiry of the certificate chain of a TLS connection to a relay, and returns whether a warning is
+// due because it expires within warnBefore
+func (c *relayCertificates) record(relay string, state *tls.ConnectionState, now time.Time, warnBefore time.Duration) (notAfter time.Time, warn bool) {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return time.Time{}, false
+	}
+	for _, cert := range state.PeerCertificates {
+		if notAfter.IsZero() || cert.NotAfter.Before(notAfter) {
+			notAfter = cert.NotAfter
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cert, ok := c.certs[relay]
+	if !ok {
+		cert = &relayCertificate{}
+		c.certs[relay] = cert
+	}
+	cert.notAfter = notAfter
+	if warnBefore <= 0 || now.Add(warnBefore).Before(notAfter) || now.Sub(cert.warnedAt) < relayCertificateWarnInterval {
+		return notAfter, false
+	}
+	cert.warnedAt = now
+	return notAfter, true
+}
+
+// onRelayTLS returns the callback recording the certificates of the TLS connections to a relay
+func (m *BoostService) onRelayTLS(relay RelayEntry) func(*tls.ConnectionState) {
+	return func(state *tls.ConnectionState) {
+		m.recordRelayCertificate(relay, state)
+	}
+}
+
+// recordRelayCertificate records the certificate expiry of a TLS connection to a relay, and warns if it expires soon
+func (m *BoostService) recordRelayCertificate(relay RelayEntry, state *tls.ConnectionState) {
+	now := m.clock.Now()
+	notAfter, warn := m.relayCertificates.record(relay.String(), state, now, m.relayCertificateWarning)
+	if warn {
+		m.log.WithFields(logrus.Fields{
+			"relay":    relay.String(),
+			"notAfter": notAfter.UTC().Format(time.RFC3339),
+			"daysLeft": int(notAfter.Sub(now).Hours() / 24),
+		}).Warn("TLS certificate of relay expires soon")
+	}
+}
+
+// relayCertificatesCollector exports the days until the certificate of each relay expires
+type relayCertificatesCollector struct {
+	certs *relayCertificates
+	clock Clock
+	desc  *prometheus.Desc
+}
+
+func newRelayCertificatesCollector(certs *relayCertificates, clock Clock) *relayCertificatesCollector {
+	return &relayCertificatesCollector{
+		certs: certs,
+		clock: clock,
+		desc:  prometheus.NewDesc("mev_boost_relay_tls_cert_expiry_days", "Days until the TLS certificate chain last presented by the relay expires, negative if expired", []string{"relay"}, nil),
+	}
+}
+
+func (c *relayCertificatesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *relayCertificatesCollector) Collect(ch chan<- prometheus.Metric) {
+	now := c.clock.Now()
+	c.certs.mu.Lock()
+	defer c.certs.mu.Unlock()
+	for relay, cert := range c.certs.certs {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, cert.notAfter.Sub(now).Hours()/24, relay)
+	}
+}
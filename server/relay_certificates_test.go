@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayCertificates(t *testing.T) {
+	certs := newRelayCertificates()
+	now := time.Unix(1_000_000, 0)
+	state := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{
+		{NotAfter: now.Add(10 * 24 * time.Hour)},
+		{NotAfter: now.Add(5 * 24 * time.Hour)}, // intermediate expiring first
+	}}
+
+	notAfter, warn := certs.record("relay", state, now, 7*24*time.Hour)
+	require.Equal(t, now.Add(5*24*time.Hour), notAfter)
+	require.True(t, warn)
+	require.Equal(t, notAfter, *certs.expiry("relay"))
+
+	// Warnings are rate limited
+	_, warn = certs.record("relay", state, now.Add(time.Minute), 7*24*time.Hour)
+	require.False(t, warn)
+	_, warn = certs.record("relay", state, now.Add(relayCertificateWarnInterval), 7*24*time.Hour)
+	require.True(t, warn)
+	_, warn = certs.record("relay", state, now, 0)
+	require.False(t, warn)
+
+	// Connections without TLS are not recorded
+	_, warn = certs.record("other", nil, now, 7*24*time.Hour)
+	require.False(t, warn)
+	require.Nil(t, certs.expiry("other"))
+}
+
+func TestRelayCertificateOnTLS(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	var state *tls.ConnectionState
+	_, _, err := sendHTTPRequest(context.Background(), ts.Client(), http.MethodGet, ts.URL, "", nil, nil, responseOpts{onTLS: func(s *tls.ConnectionState) {
+		state = s
+	}})
+	require.NoError(t, err)
+	require.NotNil(t, state)
+	require.Equal(t, ts.Certificate().NotAfter, state.PeerCertificates[0].NotAfter)
+}
+
+func TestRelayCertificateStatus(t *testing.T) {
+	backend := newTestBackend(t, 2, time.Second)
+	clock := newFakeClock(time.Unix(1_000_000, 0))
+	backend.boost.clock = clock
+	relay := backend.relays[0].RelayEntry
+	notAfter := clock.Now().Add(36 * time.Hour)
+	backend.boost.recordRelayCertificate(relay, &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{NotAfter: notAfter}}})
+
+	rr := backend.request(t, http.MethodGet, pathMevBoostStatus, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	status := mevBoostStatusResponse{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &status))
+	require.Equal(t, notAfter, status.Relays[0].CertificateExpiry.Local())
+	require.Nil(t, status.Relays[1].CertificateExpiry)
+
+	require.NoError(t, testutil.CollectAndCompare(newRelayCertificatesCollector(backend.boost.relayCertificates, clock), strings.NewReader(`
+		# HELP mev_boost_relay_tls_cert_expiry_days Days until the TLS certificate chain last presented by the relay expires, negative if expired
+		# TYPE mev_boost_relay_tls_cert_expiry_days gauge
+		mev_boost_relay_tls_cert_expiry_days{relay="`+relay.String()+`"} 1.5
+	`)))
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/sirupsen/logrus"
+)
+
+var errRelayChallengeNonce = errors.New("relay signed another nonce")
+
+// RelayChallenge is the message a relay signs with its builder API key on the challenge endpoint, proving that it
+// holds the key of its configured pubkey
+type RelayChallenge struct {
+	Nonce types.Hash `json:"nonce"`
+}
+
+// HashTreeRoot is the SSZ root of the challenge, a container with a single Bytes32 field, which is the field itself
+func (c *RelayChallenge) HashTreeRoot() ([32]byte, error) {
+	return c.Nonce, nil
+}
+
+// SignedRelayChallenge is the response of the challenge endpoint, with the pubkey the relay signs with
+type SignedRelayChallenge struct {
+	Message   *RelayChallenge `json:"message"`
+	Pubkey    types.PublicKey `json:"pubkey"`
+	Signature types.Signature `json:"signature"`
+}
+
+// relayChallenges keeps whether each relay proved to hold the key of its configured pubkey, by relay URL. Relays
+// which don't support the challenge, or didn't respond yet, have no entry.
+type relayChallenges struct {
+	mu       sync.Mutex
+	verified map[string]bool
+}
+
+func newRelayChallenges() *relayChallenges {
+	return &relayChallenges{verified: make(map[string]bool)}
+}
+
+// get returns whether the pubkey of a relay was verified, or nil if unknown
+func (c *relayChallenges) get(relay string) *bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if verified, ok := c.verified[relay]; ok {
+		return &verified
+	}
+	return nil
+}
+
+func (c *relayChallenges) set(relay string, verified bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.verified[relay] = verified
+}
+
+// challengeRelay requests a relay to sign a random nonce, and returns the signed challenge, or nil if the relay
+// doesn't support the challenge endpoint
+func (m *BoostService) challengeRelay(ctx context.Context, relay RelayEntry) (*SignedRelayChallenge, error) {
+	challenge := &RelayChallenge{}
+	if _, err := rand.Read(challenge.Nonce[:]); err != nil {
+		return nil, err
+	}
+
+	signed := new(SignedRelayChallenge)
+	url := relay.GetURI(strings.Replace(pathRelayChallenge, "{nonce:0x[a-fA-F0-9]+}", challenge.Nonce.String(), 1))
+	code, _, err := sendHTTPRequest(ctx, m.relayClient(relay), http.MethodGet, url, "", nil, signed, responseOpts{})
+	if code == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if signed.Message == nil || signed.Message.Nonce != challenge.Nonce {
+		return nil, errRelayChallengeNonce
+	}
+	return signed, nil
+}
+
+// verifyRelayPubkeys challenges all relays to sign a nonce, and alerts if a relay doesn't sign it with the key of its
+// configured pubkey, which would get all its bids rejected
+func (m *BoostService) verifyRelayPubkeys(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, relay := range m.liveRelays(m.clock.Now()) {
+		wg.Add(1)
+		go func(relay RelayEntry) {
+			defer wg.Done()
+			log := m.log.WithField("relay", relay.String())
+
+			signed, err := m.challengeRelay(ctx, relay)
+			if err != nil {
+				log.WithError(err).Warn("failed to challenge relay")
+				return
+			}
+			if signed == nil {
+				log.Debug("relay does not support the pubkey challenge")
+				return
+			}
+
+			verified, err := verifySignature(signed.Message, m.builderSigningDomain, relay.PublicKey, signed.Signature)
+			if err != nil {
+				log.WithError(err).Debug("failed to verify the relay challenge signature")
+			}
+			m.relayChallenges.set(relay.String(), verified)
+			value := 0.0
+			if verified {
+				value = 1
+			}
+			m.metrics.relayPubkeyVerified.WithLabelValues(relay.String()).Set(value)
+
+			if !verified {
+				log.WithFields(logrus.Fields{
+					"configuredPubkey": relay.PublicKey.String(),
+					"relayPubkey":      signed.Pubkey.String(),
+				}).Error("relay did not sign the challenge with its configured pubkey, its bids will be rejected")
+			}
+		}(relay)
+	}
+	wg.Wait()
+	return nil
+}
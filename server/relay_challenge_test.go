@@ -0,0 +1,28 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/bls"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyRelayPubkeys(t *testing.T) {
+	backend := newTestBackend(t, 2, time.Second)
+	relay0, relay1 := backend.boost.relays[0].String(), backend.boost.relays[1].String()
+	require.Nil(t, backend.boost.relayChallenges.get(relay0))
+
+	// The second relay is configured with another pubkey than it signs with
+	_, pubkey, err := bls.GenerateNewKeypair()
+	require.NoError(t, err)
+	require.NoError(t, backend.boost.relays[1].PublicKey.FromSlice(pubkey.Compress()))
+
+	require.NoError(t, backend.boost.verifyRelayPubkeys(context.Background()))
+	require.True(t, *backend.boost.relayChallenges.get(relay0))
+	require.False(t, *backend.boost.relayChallenges.get(relay1))
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.relayPubkeyVerified.WithLabelValues(relay0)))
+	require.Equal(t, 0.0, testutil.ToFloat64(backend.boost.metrics.relayPubkeyVerified.WithLabelValues(relay1)))
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// RelayCompatibility is the compatibility matrix row of a relay: the features it announces on its capabilities
+// endpoint, and the latency of its status endpoint in a short probe
+type RelayCompatibility struct {
+	Relay     string `json:"relay"`
+	Reachable bool   `json:"reachable"`
+	Error     string `json:"error,omitempty"`
+
+	// Capabilities is whether the relay announces its capabilities. If not, the other features are unknown.
+	Capabilities             bool     `json:"capabilities"`
+	Forks                    []string `json:"forks"` // all forks if empty
+	Encodings                []string `json:"encodings"`
+	Cancellations            bool     `json:"cancellations"`
+	MaxRegistrationBatchSize int      `json:"max_registration_batch_size"` // 0 if unlimited
+
+	Probes       int     `json:"probes"`
+	FailedProbes int     `json:"failed_probes"`
+	LatencyP50Ms float64 `json:"latency_p50_ms"`
+	LatencyP90Ms float64 `json:"latency_p90_ms"`
+	LatencyP99Ms float64 `json:"latency_p99_ms"`
+}
+
+// ProbeRelays probes the compatibility of the relays concurrently, with probes status requests per relay and a
+// timeout per request
+func ProbeRelays(ctx context.Context, relays []RelayEntry, probes int, timeout time.Duration) []RelayCompatibility {
+	ret := make([]RelayCompatibility, len(relays))
+	var wg sync.WaitGroup
+	for i, relay := range relays {
+		wg.Add(1)
+		go func(i int, relay RelayEntry) {
+			defer wg.Done()
+			ret[i] = probeRelay(ctx, newRelayClient(timeout, relay.Transport), relay, probes, systemClock{})
+		}(i, relay)
+	}
+	wg.Wait()
+	return ret
+}
+
+// probeRelay fetches the capabilities of a relay, and measures the latency of probes sequential status requests
+func probeRelay(ctx context.Context, client RelayClient, relay RelayEntry, probes int, clock Clock) RelayCompatibility {
+	c := RelayCompatibility{Relay: relay.URL.Host, Forks: []string{}, Encodings: []string{"json"}}
+
+	latencies := make([]time.Duration, 0, probes)
+	for i := 0; i < probes; i++ {
+		start := clock.Now()
+		_, _, err := sendHTTPRequest(ctx, client, http.MethodGet, relay.GetURI(pathStatus), "", nil, nil, responseOpts{})
+		if err != nil {
+			c.FailedProbes++
+			c.Error = err.Error()
+			continue
+		}
+		latencies = append(latencies, clock.Now().Sub(start))
+	}
+	c.Probes = probes
+	c.Reachable = len(latencies) > 0
+	c.LatencyP50Ms = latencyPercentileMs(latencies, 50)
+	c.LatencyP90Ms = latencyPercentileMs(latencies, 90)
+	c.LatencyP99Ms = latencyPercentileMs(latencies, 99)
+	if !c.Reachable {
+		return c
+	}
+
+	caps := new(RelayCapabilities)
+	code, _, err := sendHTTPRequest(ctx, client, http.MethodGet, relay.GetURI(pathRelayCapabilities), "", nil, caps, responseOpts{})
+	if err != nil {
+		// Relays without capabilities endpoint are common, and not an error
+		var relayErr *RelayError
+		if !errors.As(err, &relayErr) || relayErr.StatusCode != http.StatusNotFound {
+			c.Error = fmt.Sprintf("capabilities: %v", err)
+		}
+		return c
+	}
+	if code == http.StatusNoContent {
+		return c
+	}
+	c.Capabilities = true
+	if caps.Forks != nil {
+		c.Forks = caps.Forks
+	}
+	if caps.SSZ {
+		c.Encodings = append(c.Encodings, "ssz")
+	}
+	c.Cancellations = caps.Cancellations
+	c.MaxRegistrationBatchSize = caps.MaxRegistrationBatchSize
+	return c
+}
+
+// latencyPercentileMs returns the nearest-rank percentile p of the latencies [ms], or 0 without latencies
+func latencyPercentileMs(latencies []time.Duration, p float64) float64 {
+	if len(latencies) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration{}, latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return float64(sorted[rank-1].Microseconds()) / 1000
+}
+
+// WriteRelayCompatibilityTable writes the compatibility matrix as a text table
+func WriteRelayCompatibilityTable(w io.Writer, matrix []RelayCompatibility) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RELAY\tREACHABLE\tFORKS\tENCODINGS\tCANCELLATIONS\tMAX BATCH\tP50 MS\tP90 MS\tP99 MS\tFAILED\tERROR")
+	for _, c := range matrix {
+		forks, cancellations, maxBatch := "?", "?", "?"
+		if c.Capabilities {
+			forks = "all"
+			if len(c.Forks) > 0 {
+				forks = strings.Join(c.Forks, ",")
+			}
+			cancellations = yesNo(c.Cancellations)
+			maxBatch = "unlimited"
+			if c.MaxRegistrationBatchSize > 0 {
+				maxBatch = fmt.Sprint(c.MaxRegistrationBatchSize)
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%.1f\t%.1f\t%.1f\t%d/%d\t%s\n", c.Relay, yesNo(c.Reachable), forks,
+			strings.Join(c.Encodings, ","), cancellations, maxBatch, c.LatencyP50Ms, c.LatencyP90Ms, c.LatencyP99Ms,
+			c.FailedProbes, c.Probes, c.Error)
+	}
+	return tw.Flush()
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
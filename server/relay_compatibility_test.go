@@ -0,0 +1,64 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbeRelays(t *testing.T) {
+	backend := newTestBackend(t, 2, time.Second)
+	backend.relays[0].Capabilities = &RelayCapabilities{Forks: []string{"bellatrix", "capella"}, SSZ: true, Cancellations: true, MaxRegistrationBatchSize: 100}
+	unreachable := RelayEntry{URL: &url.URL{Scheme: "http", Host: "127.0.0.1:1"}}
+	relays := []RelayEntry{backend.relays[0].RelayEntry, backend.relays[1].RelayEntry, unreachable}
+
+	matrix := ProbeRelays(context.Background(), relays, 3, time.Second)
+	require.Len(t, matrix, 3)
+
+	require.Equal(t, backend.relays[0].RelayEntry.URL.Host, matrix[0].Relay)
+	require.True(t, matrix[0].Reachable)
+	require.True(t, matrix[0].Capabilities)
+	require.Equal(t, []string{"bellatrix", "capella"}, matrix[0].Forks)
+	require.Equal(t, []string{"json", "ssz"}, matrix[0].Encodings)
+	require.True(t, matrix[0].Cancellations)
+	require.Equal(t, 100, matrix[0].MaxRegistrationBatchSize)
+	require.Equal(t, 3, matrix[0].Probes)
+	require.Zero(t, matrix[0].FailedProbes)
+	require.Equal(t, 3, backend.relays[0].GetRequestCount(pathStatus))
+
+	// Relays without capabilities endpoint
+	require.True(t, matrix[1].Reachable)
+	require.False(t, matrix[1].Capabilities)
+	require.Empty(t, matrix[1].Error)
+	require.Equal(t, []string{"json"}, matrix[1].Encodings)
+
+	require.False(t, matrix[2].Reachable)
+	require.Equal(t, 3, matrix[2].FailedProbes)
+	require.NotEmpty(t, matrix[2].Error)
+
+	var table bytes.Buffer
+	require.NoError(t, WriteRelayCompatibilityTable(&table, matrix))
+	lines := strings.Split(strings.TrimSpace(table.String()), "\n")
+	require.Len(t, lines, 4)
+	require.Regexp(t, `^RELAY\s+REACHABLE\s+FORKS`, lines[0])
+	require.Regexp(t, `yes\s+bellatrix,capella\s+json,ssz\s+yes\s+100\s`, lines[1])
+	require.Regexp(t, `yes\s+\?\s+json\s+\?\s+\?\s`, lines[2])
+	require.Contains(t, lines[3], "3/3")
+}
+
+func TestLatencyPercentile(t *testing.T) {
+	require.Zero(t, latencyPercentileMs(nil, 50))
+	latencies := []time.Duration{}
+	for i := 10; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	require.Equal(t, 5.0, latencyPercentileMs(latencies, 50))
+	require.Equal(t, 9.0, latencyPercentileMs(latencies, 90))
+	require.Equal(t, 10.0, latencyPercentileMs(latencies, 99))
+	require.Equal(t, 1.0, latencyPercentileMs(latencies, 0))
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// relayDiffFields are the header fields compared by the relay diff, with their value in a bid
+var relayDiffFields = []struct {
+	name  string
+	value func(resp *GetHeaderResponse) string
+}{
+	{"version", func(resp *GetHeaderResponse) string { return string(resp.Version) }},
+	{"value", func(resp *GetHeaderResponse) string { return resp.Data.Message.Value.String() }},
+	{"pubkey", func(resp *GetHeaderResponse) string { return resp.Data.Message.Pubkey.String() }},
+	{"block_hash", func(resp *GetHeaderResponse) string { return resp.Data.Message.Header.BlockHash.String() }},
+	{"parent_hash", func(resp *GetHeaderResponse) string { return resp.Data.Message.Header.ParentHash.String() }},
+	{"block_number", func(resp *GetHeaderResponse) string {
+		return strconv.FormatUint(resp.Data.Message.Header.BlockNumber, 10)
+	}},
+	{"timestamp", func(resp *GetHeaderResponse) string {
+		return strconv.FormatUint(resp.Data.Message.Header.Timestamp, 10)
+	}},
+	{"gas_limit", func(resp *GetHeaderResponse) string {
+		return strconv.FormatUint(resp.Data.Message.Header.GasLimit, 10)
+	}},
+	{"gas_used", func(resp *GetHeaderResponse) string {
+		return strconv.FormatUint(resp.Data.Message.Header.GasUsed, 10)
+	}},
+	{"base_fee_per_gas", func(resp *GetHeaderResponse) string {
+		return resp.Data.Message.Header.BaseFeePerGas.String()
+	}},
+	{"fee_recipient", func(resp *GetHeaderResponse) string {
+		return resp.Data.Message.Header.FeeRecipient.String()
+	}},
+	{"transactions_root", func(resp *GetHeaderResponse) string {
+		return resp.Data.Message.Header.TransactionsRoot.String()
+	}},
+	{"state_root", func(resp *GetHeaderResponse) string { return resp.Data.Message.Header.StateRoot.String() }},
+	{"extra_data", func(resp *GetHeaderResponse) string { return resp.Data.Message.Header.ExtraData.String() }},
+	{"withdrawals_root", func(resp *GetHeaderResponse) string {
+		if root := resp.Data.Message.Header.WithdrawalsRoot; root != nil {
+			return root.String()
+		}
+		return ""
+	}},
+}
+
+// relayDiffBid is the outcome of the getHeader request to one of the compared relays
+type relayDiffBid struct {
+	Relay     string             `json:"relay"`
+	Error     string             `json:"error,omitempty"`     // the request failed, or the relay had no bid
+	Rejection BidRejectionReason `json:"rejection,omitempty"` // the reason mev-boost would reject the bid
+	Valid     bool               `json:"valid"`
+}
+
+// relayDiffField compares a header field of the bids of both relays. A is empty if relay A has no bid, and B alike.
+type relayDiffField struct {
+	Field string `json:"field"`
+	A     string `json:"a"`
+	B     string `json:"b"`
+	Equal bool   `json:"equal"`
+}
+
+type relayDiffResponse struct {
+	Slot   uint64           `json:"slot,string"`
+	Relays [2]relayDiffBid  `json:"relays"`
+	Fields []relayDiffField `json:"fields"`
+}
+
+// handleDebugRelayDiff requests the header of a slot from the two relays given by host in the relays query parameter,
+// and compares their bids field by field. It helps to find out why the bids of a relay are rejected.
+func (m *BoostService) handleDebugRelayDiff(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	slot, err := strconv.ParseUint(vars["slot"], 10, 64)
+	if err != nil {
+		m.respondError(w, http.StatusBadRequest, errInvalidSlot.Error())
+		return
+	}
+	parentHashHex := vars["parent_hash"]
+	if len(parentHashHex) != 66 {
+		m.respondError(w, http.StatusBadRequest, errInvalidHash.Error())
+		return
+	}
+	pubkey := vars["pubkey"]
+	if len(pubkey) != 98 {
+		m.respondError(w, http.StatusBadRequest, errInvalidPubkey.Error())
+		return
+	}
+
+	hosts := strings.Split(req.URL.Query().Get("relays"), ",")
+	if len(hosts) != 2 {
+		m.respondError(w, http.StatusBadRequest, "relays must be two comma-separated relay hosts")
+		return
+	}
+	relays := make([]RelayEntry, 2)
+	for i, host := range hosts {
+		host = strings.TrimSpace(host)
+		found := false
+		for _, relay := range m.relays {
+			if relay.URL.Host == host {
+				relays[i], found = relay, true
+				break
+			}
+		}
+		if !found {
+			m.respondError(w, http.StatusBadRequest, "unknown relay: "+host)
+			return
+		}
+	}
+
+	log := m.requestLog(req).WithFields(logrus.Fields{
+		"method":     "debugRelayDiff",
+		"slot":       slot,
+		"parentHash": parentHashHex,
+		"pubkey":     pubkey,
+	})
+	ua := UserAgent(req.Header.Get("User-Agent"))
+
+	resp := relayDiffResponse{Slot: slot}
+	bids := make([]*GetHeaderResponse, 2)
+	var wg sync.WaitGroup
+	for i, relay := range relays {
+		wg.Add(1)
+		go func(i int, relay RelayEntry) {
+			defer wg.Done()
+			resp.Relays[i], bids[i] = m.requestDiffBid(req.Context(), log, relay, slot, parentHashHex, pubkey, ua)
+		}(i, relay)
+	}
+	wg.Wait()
+
+	for _, field := range relayDiffFields {
+		diff := relayDiffField{Field: field.name}
+		if bids[0] != nil {
+			diff.A = field.value(bids[0])
+		}
+		if bids[1] != nil {
+			diff.B = field.value(bids[1])
+		}
+		diff.Equal = diff.A == diff.B
+		resp.Fields = append(resp.Fields, diff)
+	}
+	m.respondOK(w, resp)
+}
+
+// requestDiffBid requests the header from a relay, and validates the bid like getHeader does. The bid is nil if
+// the relay has none.
+func (m *BoostService) requestDiffBid(ctx context.Context, log *logrus.Entry, relay RelayEntry, slot uint64, parentHashHex, pubkey string, ua UserAgent) (relayDiffBid, *GetHeaderResponse) {
+	result := relayDiffBid{Relay: relay.String()}
+	url := relay.GetURI(fmt.Sprintf("/eth/v1/builder/header/%d/%s/%s", slot, parentHashHex, pubkey))
+	log = relayLog(log, relay, url)
+
+	responsePayload := new(GetHeaderResponse)
+	code, _, err := sendHTTPRequest(ctx, m.relayClient(relay), http.MethodGet, url, ua, nil, responsePayload, m.relayResponseOpts(relay))
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	if code == http.StatusNoContent || responsePayload.Data == nil || responsePayload.Data.Message == nil || responsePayload.Data.Message.Header == nil {
+		result.Error = "no bid"
+		return result, nil
+	}
+
+	result.Rejection = m.validateBid(log, relay, slot, parentHashHex, responsePayload)
+	result.Valid = result.Rejection == ""
+	return result, responsePayload
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugRelayDiff(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	path := fmt.Sprintf("/mev-boost/v1/debug/relay_diff/1/%s/%s", hash, pubkey)
+	relayPath := fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey)
+
+	backend := newTestBackend(t, 2, time.Second)
+	backend.boost.debugAPI = true
+	hostA := backend.relays[0].RelayEntry.URL.Host
+	hostB := backend.relays[1].RelayEntry.URL.Host
+
+	// The second relay serves a bid for another parent hash
+	backend.relays[1].GetHeaderResponse = backend.relays[1].makeGetHeaderResponse(
+		12346,
+		"0xa38385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7",
+		"0x1111111111111111111111111111111111111111111111111111111111111111",
+		backend.relays[1].RelayEntry.PublicKey.String(),
+	)
+
+	rr := backend.request(t, http.MethodGet, path+"?relays="+hostA+","+hostB, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, 1, backend.relays[0].GetRequestCount(relayPath))
+	require.Equal(t, 1, backend.relays[1].GetRequestCount(relayPath))
+
+	resp := new(relayDiffResponse)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+	require.Equal(t, uint64(1), resp.Slot)
+	require.True(t, resp.Relays[0].Valid)
+	require.False(t, resp.Relays[1].Valid)
+	require.Equal(t, BidRejectionParentHashMismatch, resp.Relays[1].Rejection)
+
+	fields := make(map[string]relayDiffField)
+	for _, field := range resp.Fields {
+		fields[field.Field] = field
+	}
+	require.Len(t, fields, len(relayDiffFields))
+	require.Equal(t, relayDiffField{Field: "value", A: "12345", B: "12346"}, fields["value"])
+	require.False(t, fields["parent_hash"].Equal)
+	require.True(t, fields["gas_limit"].Equal)
+
+	// A relay without a bid
+	backend.relays[1].overrideHandleGetHeader(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	rr = backend.request(t, http.MethodGet, path+"?relays="+hostA+","+hostB, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	resp = new(relayDiffResponse)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+	require.Equal(t, "no bid", resp.Relays[1].Error)
+	for _, field := range resp.Fields {
+		require.Empty(t, field.B)
+	}
+
+	rr = backend.request(t, http.MethodGet, path+"?relays="+hostA, nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = backend.request(t, http.MethodGet, path+"?relays="+hostA+",unknown:1234", nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
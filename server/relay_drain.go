@@ -0,0 +1,146 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// relayDrain is a relay being taken out of service. A draining relay gets no getHeader calls, but is still called
+// for the payloads of the bids it delivered until RemoveAt, after which it is not called at all.
+type relayDrain struct {
+	Relay     string    `json:"relay"`
+	DrainedAt time.Time `json:"drained_at"`
+	RemoveAt  time.Time `json:"remove_at"`
+	Removed   bool      `json:"removed"`
+}
+
+// relayDrainStore keeps the drained relays, by relay URL
+type relayDrainStore struct {
+	mu     sync.RWMutex
+	drains map[string]relayDrain
+}
+
+func newRelayDrainStore() *relayDrainStore {
+	return &relayDrainStore{drains: make(map[string]relayDrain)}
+}
+
+// drain starts draining a relay, and returns the drain. Draining a relay again keeps the earlier removal time.
+func (s *relayDrainStore) drain(relay string, now, removeAt time.Time) relayDrain {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if drain, ok := s.drains[relay]; ok {
+		return drain
+	}
+	drain := relayDrain{Relay: relay, DrainedAt: now, RemoveAt: removeAt}
+	s.drains[relay] = drain
+	return drain
+}
+
+// undrain puts a drained relay back into service, and returns whether it was drained
+func (s *relayDrainStore) undrain(relay string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.drains[relay]
+	delete(s.drains, relay)
+	return ok
+}
+
+// isDraining returns whether a relay is drained, including after its removal
+func (s *relayDrainStore) isDraining(relay string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.drains[relay]
+	return ok
+}
+
+// isRemoved returns whether a drained relay is removed at time t
+func (s *relayDrainStore) isRemoved(relay string, t time.Time) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	drain, ok := s.drains[relay]
+	return ok && !t.Before(drain.RemoveAt)
+}
+
+// list returns the drained relays, ordered by relay URL
+func (s *relayDrainStore) list(now time.Time) []relayDrain {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	drains := make([]relayDrain, 0, len(s.drains))
+	for _, drain := range s.drains {
+		drain.Removed = !now.Before(drain.RemoveAt)
+		drains = append(drains, drain)
+	}
+	sort.Slice(drains, func(i, j int) bool { return drains[i].Relay < drains[j].Relay })
+	return drains
+}
+
+// liveRelays returns the relays which are not removed by a drain at time t
+func (m *BoostService) liveRelays(t time.Time) []RelayEntry {
+	relays := make([]RelayEntry, 0, len(m.relays))
+	for _, relay := range m.relays {
+		if !m.relayDrains.isRemoved(relay.String(), t) {
+			relays = append(relays, relay)
+		}
+	}
+	return relays
+}
+
+// isLastLiveRelay returns whether all relays other than relay are drained
+func (m *BoostService) isLastLiveRelay(relay RelayEntry) bool {
+	for _, r := range m.relays {
+		if r.String() != relay.String() && !m.relayDrains.isDraining(r.String()) {
+			return false
+		}
+	}
+	return true
+}
+
+// drainRemoveTime returns when a relay drained at now is removed: at the end of the current slot, once its bids for
+// the slot had their payloads requested
+func (m *BoostService) drainRemoveTime(now time.Time) time.Time {
+	if m.genesisTime == 0 {
+		return now.Add(m.chain.slotDuration())
+	}
+	return m.chain.slotStartTime(m.genesisTime, m.chain.slotAt(m.genesisTime, now)+1)
+}
+
+// handleRelayDrain returns the drained relays. POST drains the relay given by host in the relay query parameter,
+// and DELETE puts it back into service.
+func (m *BoostService) handleRelayDrain(w http.ResponseWriter, req *http.Request) {
+	now := m.clock.Now()
+	if req.Method == http.MethodGet {
+		m.respondOK(w, m.relayDrains.list(now))
+		return
+	}
+
+	host := req.URL.Query().Get("relay")
+	relay := m.relayByHost(host)
+	if relay == nil {
+		m.respondError(w, http.StatusBadRequest, "unknown relay: "+host)
+		return
+	}
+
+	log := m.requestLog(req).WithField("relay", relay.String())
+	if req.Method == http.MethodDelete {
+		if m.relayDrains.undrain(relay.String()) {
+			log.Warn("relay put back into service")
+		}
+	} else if !m.relayDrains.isDraining(relay.String()) && m.isLastLiveRelay(*relay) {
+		// Draining the last relay would make getHeader fail for sure
+		m.metrics.configFallbacks.WithLabelValues(configRelayDrain).Inc()
+		log.Error("refusing to drain the last relay in service")
+		m.respondError(w, http.StatusConflict, "cannot drain the last relay in service: "+host)
+		return
+	} else {
+		drain := m.relayDrains.drain(relay.String(), now, m.drainRemoveTime(now))
+		log.WithFields(logrus.Fields{
+			"drainedAt": drain.DrainedAt,
+			"removeAt":  drain.RemoveAt,
+		}).Warn("draining relay")
+	}
+	m.respondOK(w, m.relayDrains.list(now))
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayDrainStore(t *testing.T) {
+	store := newRelayDrainStore()
+	now := time.Now()
+	removeAt := now.Add(time.Second)
+
+	require.Equal(t, removeAt, store.drain("relay", now, removeAt).RemoveAt)
+	require.Equal(t, removeAt, store.drain("relay", now, now.Add(time.Minute)).RemoveAt) // draining again keeps the removal time
+	require.True(t, store.isDraining("relay"))
+	require.False(t, store.isRemoved("relay", now))
+	require.True(t, store.isRemoved("relay", removeAt))
+	require.False(t, store.isDraining("other"))
+	require.Equal(t, []relayDrain{{Relay: "relay", DrainedAt: now, RemoveAt: removeAt, Removed: true}}, store.list(removeAt))
+
+	require.True(t, store.undrain("relay"))
+	require.False(t, store.undrain("relay"))
+	require.False(t, store.isRemoved("relay", removeAt))
+}
+
+func TestDrainRemoveTime(t *testing.T) {
+	backend := newTestBackend(t, 1, time.Second)
+	now := time.Unix(1000+5*SecondsPerSlot+3, 0)
+	require.Equal(t, now.Add(SecondsPerSlot*time.Second), backend.boost.drainRemoveTime(now))
+
+	backend.boost.genesisTime = 1000
+	require.Equal(t, time.Unix(1000+6*SecondsPerSlot, 0), backend.boost.drainRemoveTime(now))
+}
+
+func TestRelayDrain(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	headerPath := fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey)
+	payload := types.SignedBlindedBeaconBlock{
+		Message: &types.BlindedBeaconBlock{
+			Slot: 1,
+			Body: &types.BlindedBeaconBlockBody{
+				Eth1Data:      &types.Eth1Data{},
+				SyncAggregate: &types.SyncAggregate{},
+				ExecutionPayloadHeader: &types.ExecutionPayloadHeader{
+					BlockHash: _HexToHash(hash),
+				},
+			},
+		},
+	}
+
+	backend := newTestBackend(t, 2, time.Second)
+	backend.boost.adminAPI = true
+	backend.relays[0].EchoRequestHashes = true
+	backend.relays[1].EchoRequestHashes = true
+	drained := backend.relays[0]
+	drainPath := pathAdminRelayDrain + "?relay=" + drained.RelayEntry.URL.Host
+
+	rr := backend.request(t, http.MethodPost, pathAdminRelayDrain+"?relay=unknown", nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Both relays deliver the bid, before one of them is drained
+	rr = backend.request(t, http.MethodGet, headerPath, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	rr = backend.request(t, http.MethodPost, drainPath, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	drains := []relayDrain{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &drains))
+	require.Len(t, drains, 1)
+	require.Equal(t, drained.RelayEntry.String(), drains[0].Relay)
+	require.False(t, drains[0].Removed)
+
+	// The drained relay is still called for the payload of its bid, but gets no more getHeader calls
+	rr = backend.request(t, http.MethodPost, pathGetPayload, payload)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, 1, drained.GetRequestCount(pathGetPayload))
+
+	rr = backend.request(t, http.MethodGet, headerPath, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, 1, drained.GetRequestCount(headerPath))
+	require.Equal(t, 2, backend.relays[1].GetRequestCount(headerPath))
+
+	// Once removed, the drained relay is not called at all
+	backend.boost.relayDrains.drains[drained.RelayEntry.String()] = relayDrain{Relay: drained.RelayEntry.String(), RemoveAt: time.Now()}
+	rr = backend.request(t, http.MethodPost, pathGetPayload, payload)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, 1, drained.GetRequestCount(pathGetPayload))
+	rr = backend.request(t, http.MethodPost, pathRegisterValidator, []types.SignedValidatorRegistration{payloadRegisterValidator})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, 0, drained.GetRequestCount(pathRegisterValidator))
+
+	// The relay can be put back into service
+	rr = backend.request(t, http.MethodDelete, drainPath, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, "[]\n", rr.Body.String())
+	rr = backend.request(t, http.MethodGet, headerPath, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, 2, drained.GetRequestCount(headerPath))
+}
@@ -1,8 +1,10 @@
 package server
 
 import (
+	"math/big"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/flashbots/go-boost-utils/types"
 )
@@ -11,6 +13,31 @@ import (
 type RelayEntry struct {
 	PublicKey types.PublicKey
 	URL       *url.URL
+
+	// MaintenanceWindows during which the relay is excluded from bid selection and its errors are not alarmed
+	MaintenanceWindows []MaintenanceWindow
+
+	// ValueUnit is the unit the relay reports bid values in, wei if empty
+	ValueUnit ValueUnit
+
+	// EscrowVerification holds the relay to the header it signed in its bid, for optimistic relaying: its
+	// payloads are always verified in full against the header signed by the proposer
+	EscrowVerification bool
+
+	// Transport tunes the HTTP connections to the relay, the defaults are used if zero
+	Transport RelayTransport
+
+	// Untrusted relays get stricter validation (spec compliance, payload verification, lower size limits, no
+	// cached signature verifications), and their bids only win when exceeding the trusted bids by a margin
+	Untrusted bool
+
+	// Tags are arbitrary key-value pairs (eg. region=eu), added to the relay's logs and metrics and used by the
+	// relay tag policies
+	Tags map[string]string
+
+	// MinBidValue, if set, is the minimum value of the relay's bids [wei]. Lower bids are dropped before signature
+	// verification.
+	MinBidValue *big.Int
 }
 
 func (r *RelayEntry) String() string {
@@ -25,6 +52,16 @@ func (r *RelayEntry) GetURI(path string) string {
 	return u2.String()
 }
 
+// InMaintenance returns whether t is within one of the relay's maintenance windows
+func (r *RelayEntry) InMaintenance(t time.Time) bool {
+	for _, w := range r.MaintenanceWindows {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
 // NewRelayEntry creates a new instance based on an input string
 // relayURL can be IP@PORT, PUBKEY@IP:PORT, https://IP, etc.
 func NewRelayEntry(relayURL string) (entry RelayEntry, err error) {
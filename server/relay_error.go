@@ -0,0 +1,121 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	maxRelayErrorMessageLength = 200 // relay error messages are truncated to this length
+	maxRelayErrorMessages      = 20  // distinct messages counted per relay, further messages are counted as "other"
+	relayErrorMessageOther     = "other"
+)
+
+// RelayError is an error response of a relay. Relays respond with {"code":...,"message":...} per the builder spec.
+type RelayError struct {
+	StatusCode int
+	Code       int
+	Message    string
+}
+
+func (e *RelayError) Error() string {
+	return fmt.Sprintf("HTTP error response: %d / %s", e.StatusCode, e.Message)
+}
+
+// parseRelayError parses an error response body, using the raw body as message if it is not a spec error response
+func parseRelayError(statusCode int, body []byte) *RelayError {
+	relayErr := &RelayError{StatusCode: statusCode, Code: statusCode}
+
+	resp := new(httpErrorResp)
+	if err := json.Unmarshal(body, resp); err == nil && resp.Message != "" {
+		relayErr.Message = resp.Message
+		if resp.Code != 0 {
+			relayErr.Code = resp.Code
+		}
+	} else {
+		relayErr.Message = strings.TrimSpace(string(body))
+	}
+
+	if len(relayErr.Message) > maxRelayErrorMessageLength {
+		relayErr.Message = relayErr.Message[:maxRelayErrorMessageLength]
+	}
+	return relayErr
+}
+
+// relayErrorMessage returns the relay-supplied message of err, or an empty string if it is not a relay error response
+func relayErrorMessage(err error) string {
+	var relayErr *RelayError
+	if errors.As(err, &relayErr) {
+		return relayErr.Message
+	}
+	return ""
+}
+
+// relayErrorStats counts relay error responses per relay and message, for a bounded number of messages per relay,
+// which bounds the cardinality of the message label of the metric too
+type relayErrorStats struct {
+	mu     sync.Mutex
+	counts map[string]map[string]uint64
+	metric *prometheus.CounterVec
+}
+
+func newRelayErrorStats(metric *prometheus.CounterVec) *relayErrorStats {
+	return &relayErrorStats{counts: make(map[string]map[string]uint64), metric: metric}
+}
+
+// add counts err if it is a relay error response
+func (s *relayErrorStats) add(relay string, err error) {
+	var relayErr *RelayError
+	if !errors.As(err, &relayErr) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.counts[relay]; !ok {
+		s.counts[relay] = make(map[string]uint64)
+	}
+	message := relayErr.Message
+	if _, ok := s.counts[relay][message]; !ok && len(s.counts[relay]) >= maxRelayErrorMessages {
+		message = relayErrorMessageOther
+	}
+	s.counts[relay][message]++
+	s.metric.WithLabelValues(relay, message).Inc()
+}
+
+// snapshot returns a copy of the counts per relay and message
+func (s *relayErrorStats) snapshot() map[string]map[string]uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ret := make(map[string]map[string]uint64, len(s.counts))
+	for relay, messages := range s.counts {
+		ret[relay] = make(map[string]uint64, len(messages))
+		for message, count := range messages {
+			ret[relay][message] = count
+		}
+	}
+	return ret
+}
+
+// noSuccessfulRelayResponseMessage returns the error message for a request which no relay answered successfully,
+// including the messages supplied by the relays, if any
+func noSuccessfulRelayResponseMessage(relayMessages map[string]string) string {
+	if len(relayMessages) == 0 {
+		return errNoSuccessfulRelayResponse.Error()
+	}
+
+	messages := make([]string, 0, len(relayMessages))
+	for relay, message := range relayMessages {
+		messages = append(messages, fmt.Sprintf("%s: %s", relay, message))
+	}
+	sort.Strings(messages)
+	return fmt.Sprintf("%s (%s)", errNoSuccessfulRelayResponse.Error(), strings.Join(messages, "; "))
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRelayError(t *testing.T) {
+	relayErr := parseRelayError(http.StatusBadRequest, []byte(`{"code":400,"message":"invalid signature"}`))
+	require.Equal(t, &RelayError{StatusCode: 400, Code: 400, Message: "invalid signature"}, relayErr)
+	require.Equal(t, "HTTP error response: 400 / invalid signature", relayErr.Error())
+
+	relayErr = parseRelayError(http.StatusBadGateway, []byte("bad gateway\n"))
+	require.Equal(t, &RelayError{StatusCode: 502, Code: 502, Message: "bad gateway"}, relayErr)
+
+	relayErr = parseRelayError(http.StatusInternalServerError, []byte(strings.Repeat("x", 1000)))
+	require.Len(t, relayErr.Message, maxRelayErrorMessageLength)
+}
+
+func TestRelayErrorStats(t *testing.T) {
+	metrics := newServiceMetrics(0)
+	stats := newRelayErrorStats(metrics.relayErrors)
+	stats.add("relay", errors.New("network error"))
+	require.Empty(t, stats.snapshot())
+
+	for i := 0; i < maxRelayErrorMessages+5; i++ {
+		stats.add("relay", fmt.Errorf("wrapped: %w", &RelayError{StatusCode: 400, Message: fmt.Sprintf("message %d", i)}))
+	}
+	counts := stats.snapshot()["relay"]
+	require.Len(t, counts, maxRelayErrorMessages+1)
+	require.Equal(t, uint64(1), counts["message 0"])
+	require.Equal(t, uint64(5), counts[relayErrorMessageOther])
+
+	// The metric has the same bounded messages
+	require.Equal(t, maxRelayErrorMessages+1, testutil.CollectAndCount(metrics.relayErrors))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.relayErrors.WithLabelValues("relay", "message 0")))
+	require.Equal(t, 5.0, testutil.ToFloat64(metrics.relayErrors.WithLabelValues("relay", relayErrorMessageOther)))
+}
+
+func TestRegisterValidatorRelayErrorMessages(t *testing.T) {
+	backend := newTestBackend(t, 2, time.Second)
+	for _, relay := range backend.relays {
+		relay.overrideHandleRegisterValidator(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":400,"message":"unknown validator"}`))
+		})
+	}
+
+	rr := backend.request(t, http.MethodPost, pathRegisterValidator, []types.SignedValidatorRegistration{})
+	require.Equal(t, http.StatusBadGateway, rr.Code)
+	require.Contains(t, rr.Body.String(), "no successful relay response (")
+	require.Contains(t, rr.Body.String(), backend.relays[0].RelayEntry.URL.Host+": unknown validator")
+	require.Contains(t, rr.Body.String(), backend.relays[1].RelayEntry.URL.Host+": unknown validator")
+	require.Equal(t, uint64(1), backend.boost.relayErrors.snapshot()[backend.relays[0].RelayEntry.String()]["unknown validator"])
+}
@@ -0,0 +1,275 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxRecordedBodySize is the largest response body recorded, larger bodies are recorded without body
+const maxRecordedBodySize = 64 << 20
+
+// RelayFixture is a recorded relay response. It is sanitized of anything but the response itself: the request is
+// only kept as method and path, without query, headers or credentials, and the response as status code, content type
+// and body.
+type RelayFixture struct {
+	Relay       string          `json:"relay"` // host
+	RelayPubkey string          `json:"relay_pubkey"`
+	Endpoint    string          `json:"endpoint"` // see relayEndpoint
+	Method      string          `json:"method"`
+	Path        string          `json:"path"`
+	StatusCode  int             `json:"status_code,omitempty"` // 0 if the request failed
+	ContentType string          `json:"content_type,omitempty"`
+	Body        json.RawMessage `json:"body,omitempty"`     // JSON body
+	RawBody     string          `json:"raw_body,omitempty"` // other body
+	Truncated   bool            `json:"truncated,omitempty"`
+	Error       string          `json:"error,omitempty"` // error of a failed request, eg. a timeout
+	RecordedAt  time.Time       `json:"recorded_at"`
+	LatencyMs   int64           `json:"latency_ms"`
+}
+
+// RelayFixturePreset are the recorded responses of a relay, which a MockRelay replays with ReplayFixtures
+type RelayFixturePreset struct {
+	Relay       string         `json:"relay"`
+	RelayPubkey string         `json:"relay_pubkey"`
+	Fixtures    []RelayFixture `json:"fixtures"`
+}
+
+// relayRecorder writes the responses of the relays to a directory, a file per response
+type relayRecorder struct {
+	dir string
+	key []byte // encrypting the recorded responses at rest, if set
+	log *logrus.Entry
+	wg  sync.WaitGroup // writes in progress
+}
+
+func newRelayRecorder(dir string, key []byte, log *logrus.Entry) (*relayRecorder, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &relayRecorder{dir: dir, key: key, log: log.WithField("dir", dir)}, nil
+}
+
+// record writes a fixture in the background, to keep disk writes off the request path
+func (r *relayRecorder) record(fixture RelayFixture) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		data, err := json.Marshal(fixture)
+		if err != nil {
+			r.log.WithError(err).Warn("could not encode the relay response")
+			return
+		}
+		name := fmt.Sprintf("%d-%s-%s.json", fixture.RecordedAt.UnixNano(), strings.ReplaceAll(fixture.Relay, ":", "_"), fixture.Endpoint)
+		if err := writeFileAtRest(filepath.Join(r.dir, name), r.key, data); err != nil {
+			r.log.WithError(err).Warn("could not record the relay response")
+		}
+	}()
+}
+
+// fixtureRelayClient is a RelayClient recording the responses of a relay
+type fixtureRelayClient struct {
+	client   RelayClient
+	relay    RelayEntry
+	clock    Clock
+	recorder *relayRecorder
+}
+
+func (c *fixtureRelayClient) Do(req *http.Request) (*http.Response, error) {
+	start := c.clock.Now()
+	resp, err := c.client.Do(req)
+	fixture := RelayFixture{
+		Relay:       c.relay.URL.Host,
+		RelayPubkey: c.relay.PublicKey.String(),
+		Endpoint:    relayEndpoint(req.URL.Path),
+		Method:      req.Method,
+		Path:        req.URL.Path,
+		RecordedAt:  start,
+		LatencyMs:   c.clock.Now().Sub(start).Milliseconds(),
+	}
+	if err != nil {
+		fixture.Error = err.Error()
+		c.recorder.record(fixture)
+		return resp, err
+	}
+
+	// Record the body up to the size limit, and pass it on followed by the rest
+	body, readErr := io.ReadAll(io.LimitReader(resp.Body, maxRecordedBodySize+1))
+	resp.Body = &struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+
+	fixture.StatusCode = resp.StatusCode
+	fixture.ContentType = resp.Header.Get("Content-Type")
+	switch {
+	case readErr != nil:
+		fixture.Error = readErr.Error()
+	case len(body) > maxRecordedBodySize:
+		fixture.Truncated = true
+	case json.Valid(body):
+		fixture.Body = body
+	default:
+		fixture.RawBody = string(body)
+	}
+	c.recorder.record(fixture)
+	return resp, nil
+}
+
+// LoadRelayFixtures reads the responses recorded in a directory, ordered by time, decrypting them with the at-rest key
+// they were recorded with, if any
+func LoadRelayFixtures(dir string, key []byte) ([]RelayFixture, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	fixtures := make([]RelayFixture, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			data, err = openAtRest(key, data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		var fixture RelayFixture
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		fixtures = append(fixtures, fixture)
+	}
+	sort.SliceStable(fixtures, func(i, j int) bool { return fixtures[i].RecordedAt.Before(fixtures[j].RecordedAt) })
+	return fixtures, nil
+}
+
+// GenerateRelayFixturePresets groups recorded responses into a preset per relay, keeping the responses of the relay
+// host if not empty, recorded between since and until if not zero
+func GenerateRelayFixturePresets(fixtures []RelayFixture, relay string, since, until time.Time) []RelayFixturePreset {
+	presets := []RelayFixturePreset{}
+	index := make(map[string]int) // preset index by relay
+	for _, fixture := range fixtures {
+		if (relay != "" && fixture.Relay != relay) || (!since.IsZero() && fixture.RecordedAt.Before(since)) || (!until.IsZero() && fixture.RecordedAt.After(until)) {
+			continue
+		}
+		i, ok := index[fixture.Relay]
+		if !ok {
+			i = len(presets)
+			index[fixture.Relay] = i
+			presets = append(presets, RelayFixturePreset{Relay: fixture.Relay, RelayPubkey: fixture.RelayPubkey})
+		}
+		presets[i].Fixtures = append(presets[i].Fixtures, fixture)
+	}
+	return presets
+}
+
+// LoadRelayFixturePresets reads the presets generated by GenerateRelayFixturePresets from a JSON file
+func LoadRelayFixturePresets(path string) ([]RelayFixturePreset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var presets []RelayFixturePreset
+	if err := json.Unmarshal(data, &presets); err != nil {
+		return nil, err
+	}
+	return presets, nil
+}
+
+// fixtureReplay serves the recorded responses of a relay. A request is answered with the next response recorded for
+// its method and path, or else for its method and endpoint, in the recorded order. Once all are served, the last one
+// is served again.
+type fixtureReplay struct {
+	mu       sync.Mutex
+	fixtures []RelayFixture
+	served   []bool
+}
+
+// next returns the response for a request, or false if none was recorded for its endpoint
+func (r *fixtureReplay) next(method, path string) (RelayFixture, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	endpoint := relayEndpoint(path)
+	match, last := -1, -1
+	for i, fixture := range r.fixtures {
+		if fixture.Method != method || fixture.Endpoint != endpoint {
+			continue
+		}
+		last = i
+		if r.served[i] {
+			continue
+		}
+		if fixture.Path == path {
+			match = i
+			break
+		}
+		if match < 0 {
+			match = i
+		}
+	}
+	if match < 0 {
+		match = last
+	}
+	if match < 0 {
+		return RelayFixture{}, false
+	}
+	r.served[match] = true
+	return r.fixtures[match], true
+}
+
+// serve writes the recorded response for a request, and returns false if none was recorded
+func (r *fixtureReplay) serve(w http.ResponseWriter, req *http.Request) bool {
+	fixture, ok := r.next(req.Method, req.URL.Path)
+	if !ok {
+		return false
+	}
+	if fixture.StatusCode == 0 {
+		// The request failed, eg. timed out: close the connection without response
+		if hijacker, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hijacker.Hijack(); err == nil {
+				conn.Close()
+				return true
+			}
+		}
+		panic(http.ErrAbortHandler)
+	}
+	if fixture.ContentType != "" {
+		w.Header().Set("Content-Type", fixture.ContentType)
+	}
+	w.WriteHeader(fixture.StatusCode)
+	if len(fixture.Body) > 0 {
+		_, _ = w.Write(fixture.Body)
+	} else {
+		_, _ = io.WriteString(w, fixture.RawBody)
+	}
+	return true
+}
+
+// ReplayFixtures makes the relay answer with the recorded responses of a preset, for the endpoints they cover. The
+// responses are signed by the recorded relay, so the relay must be configured with its pubkey.
+func (m *MockRelay) ReplayFixtures(preset RelayFixturePreset) error {
+	for _, fixture := range preset.Fixtures {
+		if fixture.Truncated {
+			return fmt.Errorf("%w: the %s response recorded at %s has no body", errInvalidFixture, fixture.Endpoint, fixture.RecordedAt.Format(time.RFC3339))
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fixtures = &fixtureReplay{fixtures: preset.Fixtures, served: make([]bool, len(preset.Fixtures))}
+	return nil
+}
+
+var errInvalidFixture = errors.New("invalid relay fixture")
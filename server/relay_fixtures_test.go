@@ -0,0 +1,87 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayFixtures(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	path := fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey)
+
+	// Record the responses of a relay
+	dir := t.TempDir()
+	backend := newTestBackend(t, 1, time.Second)
+	recorder, err := newRelayRecorder(dir, nil, testLog)
+	require.NoError(t, err)
+	backend.boost.relayRecorder = recorder
+	backend.relays[0].GetHeaderResponse = backend.relays[0].MakeGetHeaderResponse(12345, hash, pubkey)
+	rr := backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	recorder.wg.Wait()
+
+	fixtures, err := LoadRelayFixtures(dir, nil)
+	require.NoError(t, err)
+	require.Len(t, fixtures, 1)
+	require.Equal(t, "get_header", fixtures[0].Endpoint)
+	require.Equal(t, http.StatusOK, fixtures[0].StatusCode)
+	require.Equal(t, backend.relays[0].RelayEntry.URL.Host, fixtures[0].Relay)
+
+	presets := GenerateRelayFixturePresets(fixtures, "", time.Time{}, time.Time{})
+	require.Len(t, presets, 1)
+	require.Empty(t, GenerateRelayFixturePresets(fixtures, "", fixtures[0].RecordedAt.Add(time.Second), time.Time{}))
+	require.Empty(t, GenerateRelayFixturePresets(fixtures, "other:80", time.Time{}, time.Time{}))
+
+	// Replay them in another relay, whose bids would be worth less
+	data, err := json.Marshal(presets)
+	require.NoError(t, err)
+	presets = nil
+	require.NoError(t, json.Unmarshal(data, &presets))
+	replay := newTestBackend(t, 1, time.Second)
+	replay.relays[0].GetHeaderResponse = replay.relays[0].MakeGetHeaderResponse(1, hash, pubkey)
+	require.NoError(t, replay.relays[0].ReplayFixtures(presets[0]))
+
+	for i := 0; i < 2; i++ {
+		rr = replay.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		resp := new(types.GetHeaderResponse)
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		require.Equal(t, types.IntToU256(12345), resp.Data.Message.Value)
+	}
+
+	// Endpoints without recorded responses are served by default
+	rr = replay.request(t, http.MethodGet, pathStatus, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+}
+
+func TestFixtureReplayOrder(t *testing.T) {
+	replay := &fixtureReplay{
+		fixtures: []RelayFixture{
+			{Method: http.MethodGet, Endpoint: "get_header", Path: "/eth/v1/builder/header/1/a/b", StatusCode: 200},
+			{Method: http.MethodGet, Endpoint: "get_header", Path: "/eth/v1/builder/header/2/a/b", StatusCode: 204},
+			{Method: http.MethodGet, Endpoint: "get_header", Path: "/eth/v1/builder/header/3/a/b", StatusCode: 500},
+		},
+		served: make([]bool, 3),
+	}
+
+	// The same path first, then the next of the endpoint, then the last again
+	fixture, ok := replay.next(http.MethodGet, "/eth/v1/builder/header/2/a/b")
+	require.True(t, ok)
+	require.Equal(t, 204, fixture.StatusCode)
+	fixture, _ = replay.next(http.MethodGet, "/eth/v1/builder/header/9/a/b")
+	require.Equal(t, 200, fixture.StatusCode)
+	fixture, _ = replay.next(http.MethodGet, "/eth/v1/builder/header/9/a/b")
+	require.Equal(t, 500, fixture.StatusCode)
+	fixture, _ = replay.next(http.MethodGet, "/eth/v1/builder/header/1/a/b")
+	require.Equal(t, 500, fixture.StatusCode)
+
+	_, ok = replay.next(http.MethodPost, pathGetPayload)
+	require.False(t, ok)
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// pathRelayMonitorTranscript is the relay monitor endpoint receiving auction transcripts
+const pathRelayMonitorTranscript = "/monitor/v1/transcript"
+
+// auctionTranscript is the evidence of an auction sent to relay monitors: the signed bid of the relay, the signed
+// blinded block of the proposer accepting it, and the outcome of the payload request. Relay monitors use it to detect
+// faults such as unserved payloads.
+type auctionTranscript struct {
+	Bid        *SignedBuilderBid         `json:"bid"`
+	Acceptance *SignedBlindedBeaconBlock `json:"acceptance"`
+	Outcome    auctionOutcome            `json:"outcome"`
+}
+
+// auctionOutcome is the outcome of the payload request of the proposer. A payload which was not delivered is unserved.
+type auctionOutcome struct {
+	Delivered   bool     `json:"delivered"`
+	DeliveredBy string   `json:"delivered_by,omitempty"` // relay which delivered the payload
+	Relays      []string `json:"relays"`                 // relays which provided the bid
+}
+
+// ParseRelayMonitorURLs parses a comma-separated list of relay monitor URLs
+func ParseRelayMonitorURLs(s string) []string {
+	urls := []string{}
+	for _, url := range strings.Split(s, ",") {
+		if url = strings.TrimRight(strings.TrimSpace(url), "/"); url != "" {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// sendAuctionTranscript sends the transcript of an auction to the relay monitors
+func (m *BoostService) sendAuctionTranscript(bid *SignedBuilderBid, acceptance *SignedBlindedBeaconBlock, outcome auctionOutcome) {
+	transcript := auctionTranscript{Bid: bid, Acceptance: acceptance, Outcome: outcome}
+	for _, monitor := range m.relayMonitors {
+		go func(monitor string) {
+			log := m.log.WithFields(logrus.Fields{
+				"method":    "relayMonitor",
+				"monitor":   monitor,
+				"slot":      acceptance.Message.Slot,
+				"delivered": outcome.Delivered,
+			})
+			if _, err := SendHTTPRequest(context.Background(), m.httpClient, http.MethodPost, monitor+pathRelayMonitorTranscript, "", transcript, nil); err != nil {
+				log.WithError(err).Warn("could not send auction transcript to relay monitor")
+				return
+			}
+			log.Debug("sent auction transcript to relay monitor")
+		}(monitor)
+	}
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRelayMonitorURLs(t *testing.T) {
+	require.Equal(t, []string{}, ParseRelayMonitorURLs(""))
+	require.Equal(t, []string{"http://a", "https://b:8080"}, ParseRelayMonitorURLs("http://a/, https://b:8080"))
+}
+
+func TestSendAuctionTranscript(t *testing.T) {
+	transcripts := make(chan auctionTranscript, 1)
+	monitor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		require.Equal(t, pathRelayMonitorTranscript, req.URL.Path)
+		transcript := auctionTranscript{}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&transcript))
+		transcripts <- transcript
+	}))
+	defer monitor.Close()
+
+	backend := newTestBackend(t, 1, time.Second)
+	backend.boost.relayMonitors = []string{monitor.URL}
+
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	rr := backend.request(t, http.MethodGet, fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey), nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	payload := types.SignedBlindedBeaconBlock{
+		Message: &types.BlindedBeaconBlock{
+			Slot: 1,
+			Body: &types.BlindedBeaconBlockBody{
+				Eth1Data:               &types.Eth1Data{},
+				SyncAggregate:          &types.SyncAggregate{},
+				ExecutionPayloadHeader: &types.ExecutionPayloadHeader{BlockHash: _HexToHash(hash)},
+			},
+		},
+	}
+	rr = backend.request(t, http.MethodPost, pathGetPayload, payload)
+	require.Equal(t, http.StatusBadGateway, rr.Code, rr.Body.String()) // the mock relay delivers a payload for another block hash
+
+	select {
+	case transcript := <-transcripts:
+		require.Equal(t, hash, transcript.Bid.Message.Header.BlockHash.String())
+		require.Equal(t, uint64(1), transcript.Acceptance.Message.Slot)
+		require.Equal(t, auctionOutcome{Delivered: false, Relays: []string{backend.relays[0].RelayEntry.String()}}, transcript.Outcome)
+	case <-time.After(time.Second):
+		t.Fatal("no transcript received")
+	}
+
+	// The outcome of a delivered payload names the relay which delivered it
+	backend.relays[0].EchoRequestHashes = true
+	rr = backend.request(t, http.MethodPost, pathGetPayload, payload)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	select {
+	case transcript := <-transcripts:
+		require.True(t, transcript.Outcome.Delivered)
+		require.Equal(t, backend.relays[0].RelayEntry.String(), transcript.Outcome.DeliveredBy)
+	case <-time.After(time.Second):
+		t.Fatal("no transcript received")
+	}
+}
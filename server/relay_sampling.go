@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/sirupsen/logrus"
+)
+
+// relaySampler requests bids for the current slot with a pubkey whose bids are never served, to measure the liveness
+// and bid availability of the relays between the proposals of the connected validators
+type relaySampler struct {
+	pubkey string
+
+	mu           sync.Mutex
+	genesisTime  uint64
+	proposalSlot uint64 // latest slot with a getHeader call of the CL
+	sampledSlot  uint64
+}
+
+func newRelaySampler(pubkey string) (*relaySampler, error) {
+	var pk types.PublicKey
+	if err := pk.UnmarshalText([]byte(pubkey)); err != nil {
+		return nil, fmt.Errorf("%w: sampling pubkey %s", ErrInvalidValidatorPubkey, pubkey)
+	}
+	return &relaySampler{pubkey: strings.ToLower(pk.String())}, nil
+}
+
+// proposal records a getHeader call of the CL for a slot, which is then not sampled
+func (s *relaySampler) proposal(slot uint64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if slot > s.proposalSlot {
+		s.proposalSlot = slot
+	}
+}
+
+// claim returns whether the slot is to be sampled: once, and only if no getHeader call was made for it
+func (s *relaySampler) claim(slot uint64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if slot == s.proposalSlot || slot == s.sampledSlot {
+		return false
+	}
+	s.sampledSlot = slot
+	return true
+}
+
+// samplingGenesisTime returns the configured genesis time, or else the one of the beacon node, fetched once
+func (m *BoostService) samplingGenesisTime() (uint64, error) {
+	if m.genesisTime > 0 {
+		return m.genesisTime, nil
+	}
+	m.relaySampler.mu.Lock()
+	defer m.relaySampler.mu.Unlock()
+	if m.relaySampler.genesisTime == 0 {
+		genesisTime, err := m.beaconClient.genesisTime()
+		if err != nil {
+			return 0, fmt.Errorf("could not get the genesis time from the beacon node: %w", err)
+		}
+		m.relaySampler.genesisTime = genesisTime
+	}
+	return m.relaySampler.genesisTime, nil
+}
+
+// sampleRelays requests a bid for the current slot from each active relay with the sampling pubkey, unless the
+// slot has a proposal. The responses count towards the relay scores like those of the proposals.
+func (m *BoostService) sampleRelays(ctx context.Context) error {
+	genesisTime, err := m.samplingGenesisTime()
+	if err != nil {
+		return err
+	}
+	slot := m.chain.slotAt(genesisTime, m.clock.Now())
+	if !m.relaySampler.claim(slot) {
+		return nil
+	}
+	parentHash, err := m.beaconClient.headExecutionBlockHash()
+	if err != nil {
+		return fmt.Errorf("could not get the head block from the beacon node: %w", err)
+	}
+
+	if m.requestTimeouts.GetHeader > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.requestTimeouts.GetHeader)
+		defer cancel()
+	}
+	log := m.log.WithFields(logrus.Fields{
+		"method":     "sampleRelays",
+		"slot":       slot,
+		"parentHash": parentHash,
+	})
+	var wg sync.WaitGroup
+	for _, relay := range m.activeRelays(m.clock.Now()) {
+		wg.Add(1)
+		go func(relay RelayEntry) {
+			defer wg.Done()
+			status := m.sampleRelay(ctx, log.WithField("relay", relay.String()), relay, slot, parentHash)
+			m.relayScores.record(relay.String(), status == relayBidValid || status == relayBidNone, m.clock.Now())
+			m.metrics.relaySamples.WithLabelValues(relay.String(), status).Inc()
+		}(relay)
+	}
+	wg.Wait()
+	return nil
+}
+
+// sampleRelay requests a bid from a relay with the sampling pubkey, and returns the status of its response
+func (m *BoostService) sampleRelay(ctx context.Context, log *logrus.Entry, relay RelayEntry, slot uint64, parentHash string) string {
+	url := relay.GetURI(fmt.Sprintf("/eth/v1/builder/header/%d/%s/%s", slot, parentHash, m.relaySampler.pubkey))
+	resp := new(GetHeaderResponse)
+	code, _, err := sendHTTPRequest(ctx, m.relayClient(relay), http.MethodGet, url, "", nil, resp, m.relayResponseOpts(relay))
+	switch {
+	case err != nil:
+		log.WithError(err).Debug("sampling request to relay failed")
+		return relayBidError
+	case code == http.StatusNoContent:
+		return relayBidNone
+	case resp.Data == nil || resp.Data.Message == nil || resp.Data.Message.Header == nil || resp.Data.Message.Header.BlockHash == nilHash:
+		log.Debug("invalid sampled bid")
+		return relayBidRejected
+	}
+	if reason := m.validateBid(log, relay, slot, parentHash, resp); reason != "" {
+		log.WithField("reason", reason).Debug("invalid sampled bid")
+		return relayBidRejected
+	}
+	return relayBidValid
+}
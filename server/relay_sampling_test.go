@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleRelays(t *testing.T) {
+	parentHash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+
+	genesisTime := uint64(time.Now().Unix()) - 10*ChainEthereum.SecondsPerSlot
+	beaconNode := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/eth/v1/beacon/genesis" {
+			fmt.Fprintf(w, `{"data":{"genesis_time":"%d"}}`, genesisTime)
+			return
+		}
+		require.Equal(t, "/eth/v2/beacon/blocks/head", req.URL.Path)
+		fmt.Fprintf(w, `{"data":{"message":{"body":{"execution_payload":{"block_hash":"%s"}}}}}`, parentHash)
+	}))
+	defer beaconNode.Close()
+
+	_, err := newRelaySampler("0x1234")
+	require.ErrorIs(t, err, ErrInvalidValidatorPubkey)
+
+	backend := newTestBackend(t, 2, time.Second)
+	backend.boost.beaconClient = newBeaconClient(beaconNode.URL, time.Second)
+	backend.boost.relaySampler, err = newRelaySampler(pubkey)
+	require.NoError(t, err)
+	slot := ChainEthereum.slotAt(genesisTime, backend.boost.clock.Now())
+	path := fmt.Sprintf("/eth/v1/builder/header/%d/%s/%s", slot, parentHash, pubkey)
+	backend.relays[1].overrideHandleGetHeader(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	relay0, relay1 := backend.relays[0].RelayEntry.String(), backend.relays[1].RelayEntry.String()
+
+	// The responses of the relays are counted, and scored
+	require.NoError(t, backend.boost.sampleRelays(context.Background()))
+	require.Equal(t, 1, backend.relays[0].GetRequestCount(path))
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.relaySamples.WithLabelValues(relay0, relayBidValid)))
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.relaySamples.WithLabelValues(relay1, relayBidError)))
+	score0, _ := backend.boost.relayScores.get(relay0, backend.boost.clock.Now())
+	score1, _ := backend.boost.relayScores.get(relay1, backend.boost.clock.Now())
+	require.Greater(t, score0, score1)
+
+	// Each slot is sampled once
+	require.NoError(t, backend.boost.sampleRelays(context.Background()))
+	require.Equal(t, 1, backend.relays[0].GetRequestCount(path))
+
+	// Slots with a proposal are not sampled
+	backend.boost.relaySampler.sampledSlot = 0
+	backend.boost.relaySampler.proposal(slot)
+	require.NoError(t, backend.boost.sampleRelays(context.Background()))
+	require.Equal(t, 1, backend.relays[0].GetRequestCount(path))
+}
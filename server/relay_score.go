@@ -0,0 +1,132 @@
+package server
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// relayScoreColdStart is the score of relays without responses, which their score tends to as their responses
+	// decay, and the highest score of relays on probation
+	relayScoreColdStart = 0.5
+
+	// relayScorePriorWeight is the weight of the cold start score in the score of a relay, in responses
+	relayScorePriorWeight = 10
+)
+
+// relayScore is the reliability of a relay, as its responses decayed by their age
+type relayScore struct {
+	successes float64
+	responses float64
+	updated   time.Time
+	firstSeen time.Time
+}
+
+// decay ages the responses to time t
+func (s *relayScore) decay(t time.Time, halfLife time.Duration) {
+	if halfLife > 0 && t.After(s.updated) {
+		factor := math.Pow(0.5, float64(t.Sub(s.updated))/float64(halfLife))
+		s.successes *= factor
+		s.responses *= factor
+	}
+	s.updated = t
+}
+
+// relayScores keeps the reliability score of each relay, the share of its getHeader and getPayload responses which
+// were valid. Responses lose half their weight every halfLife, so that past incidents don't penalize a relay forever,
+// and the score of relays with few recent responses tends to the cold start score. Relays are on probation for the
+// probation period after their first response, during which their score can't exceed the cold start score, so that
+// a new relay doesn't rank above established ones on its first few responses.
+type relayScores struct {
+	halfLife  time.Duration // 0 disables the decay
+	probation time.Duration
+
+	mu     sync.Mutex
+	scores map[string]*relayScore // by relay URL
+}
+
+func newRelayScores(halfLife, probation time.Duration) *relayScores {
+	return &relayScores{
+		halfLife:  halfLife,
+		probation: probation,
+		scores:    make(map[string]*relayScore),
+	}
+}
+
+// record records a response of a relay at time t, valid or not
+func (s *relayScores) record(relay string, valid bool, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	score, ok := s.scores[relay]
+	if !ok {
+		score = &relayScore{updated: t, firstSeen: t}
+		s.scores[relay] = score
+	}
+	score.decay(t, s.halfLife)
+	score.responses++
+	if valid {
+		score.successes++
+	}
+}
+
+// get returns the score of a relay at time t, between 0 and 1, and whether the relay is on probation
+func (s *relayScores) get(relay string, t time.Time) (score float64, probation bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	relayScore, ok := s.scores[relay]
+	if !ok {
+		return relayScoreColdStart, s.probation > 0
+	}
+	relayScore.decay(t, s.halfLife)
+	score = (relayScore.successes + relayScoreColdStart*relayScorePriorWeight) / (relayScore.responses + relayScorePriorWeight)
+	probation = t.Sub(relayScore.firstSeen) < s.probation
+	if probation && score > relayScoreColdStart {
+		score = relayScoreColdStart
+	}
+	return score, probation
+}
+
+// sort orders relays by decreasing score at time t, keeping the order of relays with the same score
+func (s *relayScores) sort(relays []RelayEntry, t time.Time) {
+	scores := make(map[string]float64, len(relays))
+	for _, relay := range relays {
+		scores[relay.String()], _ = s.get(relay.String(), t)
+	}
+	sort.SliceStable(relays, func(i, j int) bool {
+		return scores[relays[i].String()] > scores[relays[j].String()]
+	})
+}
+
+// relayScoresCollector exports the reliability score of each relay
+type relayScoresCollector struct {
+	scores *relayScores
+	relays []RelayEntry
+	clock  Clock
+	desc   *prometheus.Desc
+}
+
+func newRelayScoresCollector(scores *relayScores, relays []RelayEntry, clock Clock) *relayScoresCollector {
+	return &relayScoresCollector{
+		scores: scores,
+		relays: relays,
+		clock:  clock,
+		desc:   prometheus.NewDesc("mev_boost_relay_score", "Reliability score of the relay, the time-decayed share of its valid responses (0.5 without responses)", []string{"relay", "probation"}, nil),
+	}
+}
+
+func (c *relayScoresCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *relayScoresCollector) Collect(ch chan<- prometheus.Metric) {
+	now := c.clock.Now()
+	for _, relay := range c.relays {
+		score, probation := c.scores.get(relay.String(), now)
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, score, relay.String(), strconv.FormatBool(probation))
+	}
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayScores(t *testing.T) {
+	start := time.Unix(1663000000, 0)
+	scores := newRelayScores(time.Hour, 10*time.Minute)
+
+	// Relays without responses have the cold start score
+	score, probation := scores.get("relay1", start)
+	require.Equal(t, relayScoreColdStart, score)
+	require.True(t, probation)
+
+	// A new relay can't exceed the cold start score on probation, but falls below it on failures
+	for i := 0; i < 10; i++ {
+		scores.record("relay1", true, start)
+		scores.record("relay2", false, start)
+	}
+	score, probation = scores.get("relay1", start.Add(time.Minute))
+	require.Equal(t, relayScoreColdStart, score)
+	require.True(t, probation)
+	score, _ = scores.get("relay2", start.Add(time.Minute))
+	require.Less(t, score, relayScoreColdStart)
+
+	score, probation = scores.get("relay1", start.Add(10*time.Minute))
+	require.False(t, probation)
+	require.InDelta(t, 0.74, score, 0.01)
+
+	// Failures are forgotten as they decay
+	failing, _ := scores.get("relay2", start.Add(10*time.Minute))
+	recovered, _ := scores.get("relay2", start.Add(10*time.Hour))
+	require.InDelta(t, 0.26, failing, 0.01)
+	require.InDelta(t, relayScoreColdStart, recovered, 0.01)
+}
+
+func TestRelayScoresSort(t *testing.T) {
+	backend := newTestBackend(t, 3, time.Second)
+	backend.boost.relayScores = newRelayScores(time.Hour, 0)
+	relays := backend.boost.relays
+	now := backend.boost.clock.Now()
+	backend.boost.relayScores.record(relays[0].String(), false, now)
+	backend.boost.relayScores.record(relays[2].String(), true, now)
+
+	bid := bidResp{relays: []string{relays[0].String(), relays[1].String()}}
+	ordered := backend.boost.getPayloadRelays(bid)
+	require.Equal(t, []RelayEntry{relays[1], relays[0], relays[2]}, ordered)
+
+	require.Equal(t, 3, testutil.CollectAndCount(newRelayScoresCollector(backend.boost.relayScores, relays, backend.boost.clock)))
+}
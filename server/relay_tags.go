@@ -0,0 +1,145 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// relayTagKeyRegexp matches the tag keys which are valid metric label names
+var relayTagKeyRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// ParseRelayTags parses a comma-separated list of HOST=KEY:VALUE;KEY:VALUE entries into the tags per relay host,
+// eg. relay.example.com=region:eu;operator:example. Keys must be valid metric label names.
+func ParseRelayTags(s string) (map[string]map[string]string, error) {
+	ret := make(map[string]map[string]string)
+	if strings.TrimSpace(s) == "" {
+		return ret, nil
+	}
+
+	for _, entry := range strings.Split(s, ",") {
+		host, tags, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || host == "" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRelayTags, entry)
+		}
+
+		ret[host] = make(map[string]string)
+		for _, tag := range strings.Split(tags, ";") {
+			key, value, found := strings.Cut(strings.TrimSpace(tag), ":")
+			if !found || value == "" || !relayTagKeyRegexp.MatchString(key) || key == "relay" {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidRelayTags, tag)
+			}
+			ret[host][key] = value
+		}
+	}
+	return ret, nil
+}
+
+// TagsString returns the tags of the relay as a sorted, comma-separated list of key=value pairs
+func (r *RelayEntry) TagsString() string {
+	tags := make([]string, 0, len(r.Tags))
+	for key, value := range r.Tags {
+		tags = append(tags, key+"="+value)
+	}
+	sort.Strings(tags)
+	return strings.Join(tags, ",")
+}
+
+// RelayTagPolicy requires at least one valid bid from a relay with the tag for each getHeader request
+type RelayTagPolicy struct {
+	Key   string
+	Value string
+}
+
+func (p RelayTagPolicy) String() string {
+	return p.Key + "=" + p.Value
+}
+
+// ParseRelayTagPolicies parses a comma-separated list of KEY=VALUE tags, each requiring a bid from a relay with the
+// tag, eg. region=eu
+func ParseRelayTagPolicies(s string) ([]RelayTagPolicy, error) {
+	ret := []RelayTagPolicy{}
+	if strings.TrimSpace(s) == "" {
+		return ret, nil
+	}
+
+	for _, entry := range strings.Split(s, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || value == "" || !relayTagKeyRegexp.MatchString(key) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRelayTags, entry)
+		}
+		ret = append(ret, RelayTagPolicy{Key: key, Value: value})
+	}
+	return ret, nil
+}
+
+// violatedTagPolicies returns the relay tag policies which the valid bids of a getHeader request don't satisfy, if
+// there is any bid
+func (m *BoostService) violatedTagPolicies(bid bidResp) []RelayTagPolicy {
+	if len(m.relayTagPolicies) == 0 || bid.blockHash == "" {
+		return nil
+	}
+
+	// Relays with a valid bid: those which delivered the selected bid, and those which were outbid
+	bidRelays := make(map[string]bool, len(bid.relays))
+	for _, relay := range bid.relays {
+		bidRelays[relay] = true
+	}
+	for _, rejection := range bid.rejections {
+		if rejection.Reason == BidRejectionLowerValue {
+			bidRelays[rejection.Relay] = true
+		}
+	}
+
+	violated := []RelayTagPolicy{}
+	for _, policy := range m.relayTagPolicies {
+		satisfied := false
+		for _, relay := range m.relays {
+			if bidRelays[relay.String()] && relay.Tags[policy.Key] == policy.Value {
+				satisfied = true
+				break
+			}
+		}
+		if !satisfied {
+			violated = append(violated, policy)
+		}
+	}
+	return violated
+}
+
+// newRelayTagsMetric returns an info metric with the tags of each relay as labels, to slice the per-relay metrics
+// by tag with a join on the relay label. It is nil if no relay has tags.
+func newRelayTagsMetric(relays []RelayEntry) *prometheus.GaugeVec {
+	keys := make(map[string]bool)
+	for _, relay := range relays {
+		for key := range relay.Tags {
+			keys[key] = true
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	labels := []string{"relay"}
+	for key := range keys {
+		labels = append(labels, key)
+	}
+	sort.Strings(labels[1:])
+
+	metric := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mev_boost_relay_tags_info",
+		Help: "Tags of the relay as labels, always 1",
+	}, labels)
+	for _, relay := range relays {
+		values := make([]string, len(labels))
+		values[0] = relay.String()
+		for i, key := range labels[1:] {
+			values[i+1] = relay.Tags[key]
+		}
+		metric.WithLabelValues(values...).Set(1)
+	}
+	return metric
+}
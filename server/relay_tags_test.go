@@ -0,0 +1,82 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRelayTags(t *testing.T) {
+	tags, err := ParseRelayTags("relay1.com=region:eu;operator:x, relay2.com=region:us")
+	require.NoError(t, err)
+	require.Equal(t, map[string]map[string]string{
+		"relay1.com": {"region": "eu", "operator": "x"},
+		"relay2.com": {"region": "us"},
+	}, tags)
+
+	relay := RelayEntry{Tags: tags["relay1.com"]}
+	require.Equal(t, "operator=x,region=eu", relay.TagsString())
+
+	tags, err = ParseRelayTags("")
+	require.NoError(t, err)
+	require.Empty(t, tags)
+
+	for _, s := range []string{"relay1.com", "relay1.com=region", "relay1.com=region:", "relay1.com=re-gion:eu", "relay1.com=relay:x"} {
+		_, err = ParseRelayTags(s)
+		require.ErrorIs(t, err, ErrInvalidRelayTags, s)
+	}
+}
+
+func TestParseRelayTagPolicies(t *testing.T) {
+	policies, err := ParseRelayTagPolicies("region=eu, type=filtering")
+	require.NoError(t, err)
+	require.Equal(t, []RelayTagPolicy{{"region", "eu"}, {"type", "filtering"}}, policies)
+	require.Equal(t, "region=eu", policies[0].String())
+
+	_, err = ParseRelayTagPolicies("region")
+	require.ErrorIs(t, err, ErrInvalidRelayTags)
+}
+
+func TestRelayTagPolicies(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	path := fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey)
+
+	backend := newTestBackend(t, 2, time.Second)
+	backend.boost.relays[0].Tags = map[string]string{"region": "eu"}
+	backend.boost.relays[1].Tags = map[string]string{"region": "us"}
+	backend.relays[0].GetHeaderResponse = backend.relays[0].MakeGetHeaderResponse(100, "0xa18385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7", pubkey)
+	backend.relays[1].GetHeaderResponse = backend.relays[1].MakeGetHeaderResponse(200, "0xa28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7", pubkey)
+
+	// The outbid relay in the EU satisfies the policy
+	backend.boost.relayTagPolicies = []RelayTagPolicy{{"region", "eu"}}
+	backend.boost.relayTagPoliciesEnforce = true
+	rr := backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	// No bid from Asia, which is only reported unless enforced
+	backend.boost.relayTagPolicies = []RelayTagPolicy{{"region", "eu"}, {"region", "asia"}}
+	backend.boost.relayTagPoliciesEnforce = false
+	rr = backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	backend.boost.relayTagPoliciesEnforce = true
+	rr = backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	require.Equal(t, 2.0, testutil.ToFloat64(backend.boost.metrics.relayTagPolicyViolations.WithLabelValues("region=asia")))
+	require.Equal(t, 0.0, testutil.ToFloat64(backend.boost.metrics.relayTagPolicyViolations.WithLabelValues("region=eu")))
+}
+
+func TestRelayTagsMetric(t *testing.T) {
+	relays := []RelayEntry{newMockRelay(t).RelayEntry, newMockRelay(t).RelayEntry}
+	require.Nil(t, newRelayTagsMetric(relays))
+
+	relays[0].Tags = map[string]string{"region": "eu", "operator": "x"}
+	relays[1].Tags = map[string]string{"region": "us"}
+	metric := newRelayTagsMetric(relays)
+	require.Equal(t, 1.0, testutil.ToFloat64(metric.WithLabelValues(relays[0].String(), "x", "eu")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metric.WithLabelValues(relays[1].String(), "", "us")))
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// relayEndpoint returns the name of the relay endpoint of a request path, for accounting
+func relayEndpoint(path string) string {
+	switch {
+	case path == pathRegisterValidator:
+		return "register_validator"
+	case strings.HasPrefix(path, "/eth/v1/builder/header/"):
+		return "get_header"
+	case path == pathGetPayload:
+		return "get_payload"
+	case path == pathStatus:
+		return "status"
+	case path == pathRelayCapabilities:
+		return "capabilities"
+	case strings.HasPrefix(path, "/relay/v1/challenge/"):
+		return "challenge"
+	}
+	return "other"
+}
+
+type relayTrafficKey struct {
+	relay    string
+	endpoint string
+}
+
+type relayTrafficBytes struct {
+	sent     int64
+	received int64
+}
+
+// relayTraffic accumulates the bytes exchanged with the relays since it was last taken, for exports
+type relayTraffic struct {
+	mu    sync.Mutex
+	bytes map[relayTrafficKey]*relayTrafficBytes
+}
+
+func newRelayTraffic() *relayTraffic {
+	return &relayTraffic{bytes: make(map[relayTrafficKey]*relayTrafficBytes)}
+}
+
+func (t *relayTraffic) add(relay, endpoint string, sent, received int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := relayTrafficKey{relay, endpoint}
+	b, ok := t.bytes[key]
+	if !ok {
+		b = &relayTrafficBytes{}
+		t.bytes[key] = b
+	}
+	b.sent += sent
+	b.received += received
+}
+
+// take returns the accumulated bytes by relay and endpoint, and resets them
+func (t *relayTraffic) take() map[relayTrafficKey]relayTrafficBytes {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ret := make(map[relayTrafficKey]relayTrafficBytes, len(t.bytes))
+	for key, b := range t.bytes {
+		ret[key] = *b
+	}
+	t.bytes = make(map[relayTrafficKey]*relayTrafficBytes)
+	return ret
+}
+
+// sortedRelayTrafficKeys returns the keys of the traffic by relay and endpoint
+func sortedRelayTrafficKeys(traffic map[relayTrafficKey]relayTrafficBytes) []relayTrafficKey {
+	keys := make([]relayTrafficKey, 0, len(traffic))
+	for key := range traffic {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].relay != keys[j].relay {
+			return keys[i].relay < keys[j].relay
+		}
+		return keys[i].endpoint < keys[j].endpoint
+	})
+	return keys
+}
+
+// recordRelayTraffic accounts bytes exchanged with a relay
+func (m *BoostService) recordRelayTraffic(relay, endpoint string, sent, received int64) {
+	if sent > 0 {
+		m.metrics.relayBytesSent.WithLabelValues(relay, endpoint).Add(float64(sent))
+	}
+	if received > 0 {
+		m.metrics.relayBytesReceived.WithLabelValues(relay, endpoint).Add(float64(received))
+	}
+	m.relayTraffic.add(relay, endpoint, sent, received)
+}
+
+// trafficRelayClient is a RelayClient accounting the bytes of the requests and responses. The sizes are those of the
+// HTTP messages, without TLS and TCP overhead, and of the decompressed response bodies.
+type trafficRelayClient struct {
+	client   RelayClient
+	record   func(endpoint string, sent, received int64)
+	inFlight *poolUsage // requests in flight until their response body is closed
+}
+
+func (c *trafficRelayClient) Do(req *http.Request) (*http.Response, error) {
+	endpoint := relayEndpoint(req.URL.Path)
+	c.record(endpoint, requestSize(req), 0)
+	release := c.inFlight.acquire()
+	resp, err := c.client.Do(req)
+	if err != nil {
+		release()
+		return resp, err
+	}
+	c.record(endpoint, 0, responseHeaderSize(resp))
+	resp.Body = &countingBody{ReadCloser: resp.Body, done: func(n int64) {
+		release()
+		c.record(endpoint, 0, n)
+	}}
+	return resp, nil
+}
+
+// requestSize returns the size of an HTTP/1.1 request with its body
+func requestSize(req *http.Request) int64 {
+	size := len(req.Method) + 1 + len(req.URL.RequestURI()) + len(" HTTP/1.1\r\n") + len("Host: \r\n") + len(req.URL.Host)
+	size += headerSize(req.Header) + 2
+	if req.ContentLength > 0 {
+		return int64(size) + req.ContentLength
+	}
+	return int64(size)
+}
+
+// responseHeaderSize returns the size of the status line and headers of an HTTP/1.1 response
+func responseHeaderSize(resp *http.Response) int64 {
+	return int64(len(resp.Proto) + 1 + len(resp.Status) + 2 + headerSize(resp.Header) + 2)
+}
+
+func headerSize(header http.Header) int {
+	size := 0
+	for key, values := range header {
+		for _, value := range values {
+			size += len(key) + len(": \r\n") + len(value)
+		}
+	}
+	return size
+}
+
+// countingBody counts the bytes read from a response body, and reports them when it is closed
+type countingBody struct {
+	io.ReadCloser
+	n    int64
+	done func(n int64)
+	once sync.Once
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	b.once.Do(func() { b.done(b.n) })
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayEndpoint(t *testing.T) {
+	require.Equal(t, "register_validator", relayEndpoint(pathRegisterValidator))
+	require.Equal(t, "get_header", relayEndpoint("/eth/v1/builder/header/1/0x01/0x02"))
+	require.Equal(t, "get_payload", relayEndpoint(pathGetPayload))
+	require.Equal(t, "status", relayEndpoint(pathStatus))
+	require.Equal(t, "capabilities", relayEndpoint(pathRelayCapabilities))
+	require.Equal(t, "challenge", relayEndpoint("/relay/v1/challenge/0x01"))
+	require.Equal(t, "other", relayEndpoint("/relay/v1/data/bidtraces"))
+}
+
+func TestRelayTraffic(t *testing.T) {
+	dir := t.TempDir()
+	backend := newTestBackend(t, 1, time.Second)
+	backend.boost.dataExporter = newDataExporter(dirExportTarget(dir), nil)
+	clock := newFakeClock(time.Date(2022, 9, 15, 6, 42, 0, 0, time.UTC))
+	backend.boost.clock = clock
+	relay := backend.relays[0].RelayEntry.String()
+
+	payload := []types.SignedValidatorRegistration{payloadRegisterValidator}
+	body, err := backend.boost.jsonCodec.Marshal(payload)
+	require.NoError(t, err)
+	rr := backend.request(t, http.MethodPost, pathRegisterValidator, payload)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	// The request line, headers and body are accounted
+	sent := testutil.ToFloat64(backend.boost.metrics.relayBytesSent.WithLabelValues(relay, "register_validator"))
+	received := testutil.ToFloat64(backend.boost.metrics.relayBytesReceived.WithLabelValues(relay, "register_validator"))
+	require.Greater(t, sent, float64(len(body)+len("POST "+pathRegisterValidator+" HTTP/1.1\r\n")))
+	require.Greater(t, received, float64(len("HTTP/1.1 200 OK\r\n")))
+	require.Zero(t, testutil.ToFloat64(backend.boost.metrics.relayBytesSent.WithLabelValues(relay, "get_header")))
+
+	// and exported as the traffic since the previous export
+	require.NoError(t, backend.boost.exportData(context.Background()))
+	traffic := readExportCSV(t, filepath.Join(dir, "traffic-20220915T064200Z.csv"))
+	require.Equal(t, [][]string{
+		exportTrafficHeader,
+		{"2022-09-15T06:42:00Z", relay, "register_validator", strconv.Itoa(int(sent)), strconv.Itoa(int(received))},
+	}, traffic)
+	require.Empty(t, backend.boost.relayTraffic.take())
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RelayTransport tunes the HTTP connections to a relay
+type RelayTransport struct {
+	// MaxIdleConns is the maximum number of idle (keep-alive) connections kept to the relay
+	MaxIdleConns int
+
+	// IdleConnTimeout is how long an idle connection is kept before closing it
+	IdleConnTimeout time.Duration
+
+	// TLSSessionCacheSize is the number of TLS sessions cached for resumption
+	TLSSessionCacheSize int
+
+	// MaxRedirects is the maximum number of redirects followed, if they stay on HTTPS and on the host of the relay.
+	// 0 refuses all redirects.
+	MaxRedirects int
+}
+
+// DefaultRelayTransport keeps enough connections open for registration bursts to many relays, which otherwise
+// open a new connection per request and exhaust the ephemeral ports of busy hosts
+var DefaultRelayTransport = RelayTransport{
+	MaxIdleConns:        100,
+	IdleConnTimeout:     90 * time.Second,
+	TLSSessionCacheSize: 64,
+	MaxRedirects:        3,
+}
+
+// ParseRelayTransports parses a comma-separated list of HOST=KEY:VALUE;KEY:VALUE entries into transports per relay
+// host. Keys are max_idle_conns, idle_timeout (a duration like 30s), tls_session_cache and max_redirects. Unset keys
+// are taken from defaults.
+func ParseRelayTransports(s string, defaults RelayTransport) (map[string]RelayTransport, error) {
+	ret := make(map[string]RelayTransport)
+	if strings.TrimSpace(s) == "" {
+		return ret, nil
+	}
+
+	for _, entry := range strings.Split(s, ",") {
+		host, settings, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || host == "" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRelayTransport, entry)
+		}
+
+		transport := defaults
+		for _, setting := range strings.Split(settings, ";") {
+			key, value, found := strings.Cut(strings.TrimSpace(setting), ":")
+			if !found {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidRelayTransport, setting)
+			}
+
+			var err error
+			switch strings.ToLower(key) {
+			case "max_idle_conns":
+				transport.MaxIdleConns, err = strconv.Atoi(value)
+			case "idle_timeout":
+				transport.IdleConnTimeout, err = time.ParseDuration(value)
+			case "tls_session_cache":
+				transport.TLSSessionCacheSize, err = strconv.Atoi(value)
+			case "max_redirects":
+				transport.MaxRedirects, err = strconv.Atoi(value)
+			default:
+				return nil, fmt.Errorf("%w: unknown setting %s", ErrInvalidRelayTransport, key)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrInvalidRelayTransport, setting, err)
+			}
+		}
+		ret[host] = transport
+	}
+	return ret, nil
+}
+
+// RelayClient sends the HTTP requests to the relays. The default implementation is an *http.Client per relay with
+// its own connection pool. Other implementations, eg. instrumented clients, can be set in BoostServiceOpts.
+type RelayClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// newRelayClient returns an HTTP client with its own connection pool to a relay. A zero transport uses the
+// default settings.
+func newRelayClient(timeout time.Duration, t RelayTransport) *http.Client {
+	if t == (RelayTransport{}) {
+		t = DefaultRelayTransport
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = t.MaxIdleConns
+	transport.MaxIdleConnsPerHost = t.MaxIdleConns
+	transport.IdleConnTimeout = t.IdleConnTimeout
+	transport.TLSClientConfig = &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ClientSessionCache: tls.NewLRUClientSessionCache(t.TLSSessionCacheSize),
+	}
+
+	return &http.Client{
+		Timeout:       timeout,
+		Transport:     transport,
+		CheckRedirect: relayRedirectPolicy(t.MaxRedirects),
+	}
+}
+
+// relayRedirectPolicy follows up to maxRedirects redirects from HTTPS to HTTPS on the host of the relay, eg. to a
+// regional endpoint behind the same name. Redirects to other hosts are refused, since the relay is identified by
+// its host.
+func relayRedirectPolicy(maxRedirects int) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if maxRedirects <= 0 {
+			return http.ErrUseLastResponse
+		}
+		if len(via) > maxRedirects {
+			return fmt.Errorf("%w: more than %d redirects", errRelayRedirect, maxRedirects)
+		}
+		if via[len(via)-1].URL.Scheme != "https" || req.URL.Scheme != "https" {
+			return fmt.Errorf("%w: redirect from %s to %s is not HTTPS", errRelayRedirect, via[len(via)-1].URL.Redacted(), req.URL.Redacted())
+		}
+		if req.URL.Hostname() != via[0].URL.Hostname() {
+			return fmt.Errorf("%w: redirect to another host %s", errRelayRedirect, req.URL.Hostname())
+		}
+		return nil
+	}
+}
+
+// relayClient returns the client for requests to a relay, which accounts the bytes exchanged with the relay, the
+// requests in flight, and the results and latency of the requests
+func (m *BoostService) relayClient(relay RelayEntry) RelayClient {
+	var client RelayClient = &m.httpClient
+	if m.customRelayClient != nil {
+		client = m.customRelayClient
+	} else if relayClient, ok := m.relayClients[relay.String()]; ok {
+		client = relayClient
+	}
+	if m.relayRecorder != nil {
+		client = &fixtureRelayClient{client: client, relay: relay, clock: m.clock, recorder: m.relayRecorder}
+	}
+	client = &trafficRelayClient{client: client, inFlight: m.relayRequestsPool, record: func(endpoint string, sent, received int64) {
+		m.recordRelayTraffic(relay.String(), endpoint, sent, received)
+	}}
+	client = &metricsRelayClient{client: client, clock: m.clock, observe: func(endpoint, result string, latency time.Duration) {
+		m.observeRelayRequest(relay.String(), endpoint, result, latency)
+	}}
+	if m.outboundBudget != nil {
+		client = &budgetRelayClient{client: client, budget: m.outboundBudget, pool: m.outboundBudgetPool, throttle: func(endpoint, outcome string) {
+			m.metrics.outboundBudgetThrottled.WithLabelValues(endpoint, outcome).Inc()
+		}}
+	}
+	return client
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRelayTransports(t *testing.T) {
+	transports, err := ParseRelayTransports("", DefaultRelayTransport)
+	require.NoError(t, err)
+	require.Len(t, transports, 0)
+
+	transports, err = ParseRelayTransports("foo.com=max_idle_conns:200;idle_timeout:30s, bar.com:9000=tls_session_cache:8;max_redirects:0", DefaultRelayTransport)
+	require.NoError(t, err)
+	require.Equal(t, map[string]RelayTransport{
+		"foo.com":      {MaxIdleConns: 200, IdleConnTimeout: 30 * time.Second, TLSSessionCacheSize: 64, MaxRedirects: 3},
+		"bar.com:9000": {MaxIdleConns: 100, IdleConnTimeout: 90 * time.Second, TLSSessionCacheSize: 8, MaxRedirects: 0},
+	}, transports)
+
+	for _, s := range []string{"foo.com", "foo.com=max_idle_conns", "foo.com=max_idle_conns:many", "foo.com=idle_timeout:30", "foo.com=keepalive:1s"} {
+		_, err = ParseRelayTransports(s, DefaultRelayTransport)
+		require.ErrorIs(t, err, ErrInvalidRelayTransport, s)
+	}
+}
+
+func TestRelayClient(t *testing.T) {
+	backend := newTestBackend(t, 2, time.Second)
+	relay := backend.relays[0].RelayEntry
+
+	// Each relay has its own connection pool, with the default settings
+	client := backend.boost.relayClient(relay).(*metricsRelayClient).client.(*trafficRelayClient).client.(*http.Client)
+	transport := client.Transport.(*http.Transport)
+	require.Equal(t, DefaultRelayTransport.MaxIdleConns, transport.MaxIdleConnsPerHost)
+	require.Equal(t, DefaultRelayTransport.IdleConnTimeout, transport.IdleConnTimeout)
+	require.NotNil(t, transport.TLSClientConfig.ClientSessionCache)
+	require.Equal(t, time.Second, client.Timeout)
+	require.NotSame(t, transport, backend.boost.relayClient(backend.relays[1].RelayEntry).(*metricsRelayClient).client.(*trafficRelayClient).client.(*http.Client).Transport)
+
+	client = newRelayClient(time.Second, RelayTransport{MaxIdleConns: 5, IdleConnTimeout: time.Minute})
+	transport = client.Transport.(*http.Transport)
+	require.Equal(t, 5, transport.MaxIdleConns)
+	require.Equal(t, 5, transport.MaxIdleConnsPerHost)
+	require.Equal(t, time.Minute, transport.IdleConnTimeout)
+}
+
+func TestRelayRedirectPolicy(t *testing.T) {
+	var serverURL *url.URL
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/regional":
+			http.Redirect(w, req, "/eth/v1/builder/status", http.StatusTemporaryRedirect)
+		case "/loop":
+			http.Redirect(w, req, "/loop", http.StatusTemporaryRedirect)
+		case "/other-host":
+			http.Redirect(w, req, "https://127.0.0.2:"+serverURL.Port()+"/eth/v1/builder/status", http.StatusTemporaryRedirect)
+		case "/plain-http":
+			http.Redirect(w, req, "http://"+serverURL.Host+"/eth/v1/builder/status", http.StatusTemporaryRedirect)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer ts.Close()
+	serverURL, _ = url.Parse(ts.URL)
+
+	client := newRelayClient(time.Second, DefaultRelayTransport)
+	client.Transport.(*http.Transport).TLSClientConfig.RootCAs = ts.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	send := func(path string) error {
+		_, _, err := sendHTTPRequest(context.Background(), client, http.MethodGet, ts.URL+path, "", nil, nil, responseOpts{})
+		return err
+	}
+
+	require.NoError(t, send("/regional"))
+	for _, path := range []string{"/loop", "/other-host", "/plain-http"} {
+		require.ErrorIs(t, send(path), errRelayRedirect, path)
+	}
+
+	// Redirects are refused without hops
+	client = newRelayClient(time.Second, RelayTransport{MaxIdleConns: 1, MaxRedirects: 0})
+	client.Transport.(*http.Transport).TLSClientConfig.RootCAs = ts.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	require.Error(t, send("/regional"))
+}
+
+// recordingRelayClient is a custom relay client recording the requests it sends
+type recordingRelayClient struct {
+	mu   sync.Mutex
+	urls []string
+}
+
+func (c *recordingRelayClient) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.urls = append(c.urls, req.URL.String())
+	c.mu.Unlock()
+	return http.DefaultClient.Do(req)
+}
+
+func TestCustomRelayClient(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	backend := newTestBackend(t, 2, time.Second)
+	client := &recordingRelayClient{}
+	backend.boost.customRelayClient = client
+
+	path := fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey)
+	rr := backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.ElementsMatch(t, []string{backend.relays[0].RelayEntry.GetURI(path), backend.relays[1].RelayEntry.GetURI(path)}, client.urls)
+}
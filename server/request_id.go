@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/flashbots/go-utils/httplogger"
+	"github.com/sirupsen/logrus"
+)
+
+// maxRequestIDLength is the maximum length of a request ID accepted from the CL
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// isValidRequestID returns whether a request ID provided by the CL is safe to log and forward
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		isAlnum := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+		if !isAlnum && c != '-' && c != '_' && c != '.' && c != ':' {
+			return false
+		}
+	}
+	return true
+}
+
+func contextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// relayContext returns a background context carrying the request ID of req, for relay requests which may outlive it
+func relayContext(req *http.Request) context.Context {
+	return contextWithRequestID(context.Background(), requestIDFromContext(req.Context()))
+}
+
+// requestLog returns the logger for handling req, which logs its request ID
+func (m *BoostService) requestLog(req *http.Request) *logrus.Entry {
+	if id := requestIDFromContext(req.Context()); id != "" {
+		return m.log.WithField("requestID", id)
+	}
+	return m.log
+}
+
+// withRequestID assigns each request the request ID provided by the CL, or a new one. The ID is returned in the
+// response header, logged with the request, and forwarded to the relays.
+func (m *BoostService) withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		id := req.Header.Get(HeaderRequestID)
+		if !isValidRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(HeaderRequestID, id)
+		req = req.WithContext(contextWithRequestID(req.Context(), id))
+		httplogger.LoggingMiddlewareLogrus(m.log.WithField("requestID", id), next).ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsValidRequestID(t *testing.T) {
+	require.True(t, isValidRequestID("abc-123_A.b:c"))
+	require.False(t, isValidRequestID(""))
+	require.False(t, isValidRequestID("abc 123"))
+	require.False(t, isValidRequestID("abc\n123"))
+	require.False(t, isValidRequestID(strings.Repeat("a", maxRequestIDLength+1)))
+}
+
+func TestRequestID(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	path := fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey)
+
+	backend := newTestBackend(t, 1, time.Second)
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+	backend.boost.log = logrus.NewEntry(logger)
+
+	relayRequestIDs := make(chan string, 1)
+	backend.relays[0].overrideHandleGetHeader(func(w http.ResponseWriter, req *http.Request) {
+		relayRequestIDs <- req.Header.Get(HeaderRequestID)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	request := func(id string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		if id != "" {
+			req.Header.Set(HeaderRequestID, id)
+		}
+		rr := httptest.NewRecorder()
+		backend.boost.getRouter().ServeHTTP(rr, req)
+		return rr
+	}
+
+	// The request ID of the CL is returned, forwarded to the relays, and logged
+	rr := request("cl-request-1")
+	require.Equal(t, http.StatusNoContent, rr.Code)
+	require.Equal(t, "cl-request-1", rr.Header().Get(HeaderRequestID))
+	require.Equal(t, "cl-request-1", <-relayRequestIDs)
+
+	messages := []string{}
+	for _, entry := range hook.AllEntries() {
+		if entry.Data["requestID"] == "cl-request-1" {
+			messages = append(messages, entry.Message)
+		}
+	}
+	require.Contains(t, messages, "getHeader")
+	require.Contains(t, messages, fmt.Sprintf("http: GET %s 204", path))
+
+	// A new request ID is assigned if the CL provides none, or an invalid one
+	rr = request("")
+	id := rr.Header().Get(HeaderRequestID)
+	require.Len(t, id, 32)
+	require.Equal(t, id, <-relayRequestIDs)
+
+	rr = request("not valid")
+	require.Len(t, rr.Header().Get(HeaderRequestID), 32)
+	<-relayRequestIDs
+
+	// Error responses carry the request ID too
+	rr = backend.request(t, http.MethodGet, "/eth/v1/builder/header/1/0x01/0x02", nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	require.NotEmpty(t, rr.Header().Get(HeaderRequestID))
+}
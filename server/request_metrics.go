@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Results of relay requests in mev_boost_relay_requests_total
+const (
+	relayRequestSuccess   = "success"   // 2xx response
+	relayRequestStatus    = "status"    // non-2xx response
+	relayRequestTimeout   = "timeout"   // no response within the timeout
+	relayRequestCancelled = "cancelled" // no longer needed, eg. after another relay delivered the payload
+	relayRequestError     = "error"     // connection or protocol error
+)
+
+// statusRecorder records the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if r.code == 0 {
+		r.code = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.code == 0 {
+		r.code = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// withRequestMetrics counts the calls of a Builder API handler by status code, and observes their duration
+func (m *BoostService) withRequestMetrics(endpoint string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		start := m.clock.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		handler(rec, req)
+		if rec.code == 0 {
+			rec.code = http.StatusOK
+		}
+		m.metrics.requests.WithLabelValues(endpoint, strconv.Itoa(rec.code)).Inc()
+		m.metrics.requestDuration.WithLabelValues(endpoint).Observe(m.clock.Now().Sub(start).Seconds())
+	}
+}
+
+// relayRequestResult returns the result of a relay request for mev_boost_relay_requests_total
+func relayRequestResult(resp *http.Response, err error) string {
+	var netErr net.Error
+	switch {
+	case err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return relayRequestSuccess
+	case err == nil:
+		return relayRequestStatus
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return relayRequestTimeout
+	case errors.Is(err, context.Canceled):
+		return relayRequestCancelled
+	}
+	return relayRequestError
+}
+
+// metricsRelayClient is a RelayClient counting the requests to a relay by endpoint and result, and observing the time
+// until the response headers arrive
+type metricsRelayClient struct {
+	client  RelayClient
+	clock   Clock
+	observe func(endpoint, result string, latency time.Duration)
+}
+
+func (c *metricsRelayClient) Do(req *http.Request) (*http.Response, error) {
+	start := c.clock.Now()
+	resp, err := c.client.Do(req)
+	c.observe(relayEndpoint(req.URL.Path), relayRequestResult(resp, err), c.clock.Now().Sub(start))
+	return resp, err
+}
+
+// observeRelayRequest records a request to a relay in the relay request metrics
+func (m *BoostService) observeRelayRequest(relay, endpoint, result string, latency time.Duration) {
+	m.metrics.relayRequests.WithLabelValues(relay, endpoint, result).Inc()
+	if result != relayRequestCancelled {
+		m.metrics.relayRequestDuration.WithLabelValues(relay, endpoint).Observe(latency.Seconds())
+	}
+}
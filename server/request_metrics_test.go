@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestMetrics(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	path := fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey)
+
+	backend := newTestBackend(t, 2, time.Second)
+	winner, failing := backend.relays[0].RelayEntry.String(), backend.relays[1].RelayEntry.String()
+	backend.relays[0].GetHeaderResponse = backend.relays[0].MakeGetHeaderResponse(12345, hash, pubkey)
+	backend.relays[1].overrideHandleGetHeader(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	rr := backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	metrics := backend.boost.metrics
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.requests.WithLabelValues("get_header", "200")))
+	require.Equal(t, 1, testutil.CollectAndCount(metrics.requestDuration))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.relayRequests.WithLabelValues(winner, "get_header", relayRequestSuccess)))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.relayRequests.WithLabelValues(failing, "get_header", relayRequestStatus)))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.relayBids.WithLabelValues(winner, relayBidValid)))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.relayBids.WithLabelValues(failing, relayBidError)))
+	require.Equal(t, 12345.0, testutil.ToFloat64(metrics.relayBidValue.WithLabelValues(winner)))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.relayBidsWon.WithLabelValues(winner)))
+	require.Equal(t, 0.0, testutil.ToFloat64(metrics.relayBidsWon.WithLabelValues(failing)))
+}
+
+func TestRelayRequestResult(t *testing.T) {
+	require.Equal(t, relayRequestSuccess, relayRequestResult(&http.Response{StatusCode: http.StatusNoContent}, nil))
+	require.Equal(t, relayRequestStatus, relayRequestResult(&http.Response{StatusCode: http.StatusBadRequest}, nil))
+	require.Equal(t, relayRequestTimeout, relayRequestResult(nil, fmt.Errorf("get header: %w", context.DeadlineExceeded)))
+	require.Equal(t, relayRequestCancelled, relayRequestResult(nil, context.Canceled))
+	require.Equal(t, relayRequestError, relayRequestResult(nil, errors.New("connection refused")))
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// responseStats describes a streamed response body
+type responseStats struct {
+	Size   int64  // [bytes]
+	Digest string // SHA-256 of the body
+}
+
+// responseBodyReader hashes a response body as it is read, and fails once more than maxSize bytes are read, if
+// maxSize is set
+type responseBodyReader struct {
+	r       io.Reader
+	maxSize int64
+	size    int64
+	hash    hash.Hash
+}
+
+func newResponseBodyReader(r io.Reader, maxSize int64) *responseBodyReader {
+	return &responseBodyReader{r: r, maxSize: maxSize, hash: sha256.New()}
+}
+
+func (b *responseBodyReader) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	b.size += int64(n)
+	b.hash.Write(p[:n])
+	if b.maxSize > 0 && b.size > b.maxSize {
+		return n, fmt.Errorf("%w: more than %d bytes", errResponseTooLarge, b.maxSize)
+	}
+	return n, err
+}
+
+func (b *responseBodyReader) stats() responseStats {
+	return responseStats{Size: b.size, Digest: hexutil.Encode(b.hash.Sum(nil))}
+}
+
+// decodeResponseStream decodes a JSON response body into dst as it is read, instead of buffering it, which keeps
+// the peak memory of large payloads down. Bodies announced or turning out larger than opts.maxSize are rejected
+// without reading them further.
+//
+// Unknown fields can't be counted without buffering the body, so they are only checked if opts.rejectUnknownFields
+// is set, rejecting the response on the first one.
+func decodeResponseStream(resp *http.Response, dst any, opts responseOpts) (responseStats, error) {
+	if opts.maxSize > 0 && resp.ContentLength > opts.maxSize {
+		return responseStats{Size: resp.ContentLength}, fmt.Errorf("%w: %d bytes announced, more than %d", errResponseTooLarge, resp.ContentLength, opts.maxSize)
+	}
+	if opts.specDeviation != nil && !isJSONContentType(resp.Header.Get("Content-Type")) {
+		if err := opts.specDeviation(specDeviationContentType); err != nil {
+			return responseStats{}, err
+		}
+	}
+
+	body := newResponseBodyReader(resp.Body, opts.maxSize)
+	decoder := json.NewDecoder(body)
+	if opts.rejectUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	err := decoder.Decode(dst)
+	if err != nil && opts.rejectUnknownFields && opts.specDeviation != nil && strings.HasPrefix(err.Error(), "json: unknown field") {
+		if err := opts.specDeviation(specDeviationUnknownField); err != nil {
+			return body.stats(), err
+		}
+	}
+	if err != nil {
+		return body.stats(), fmt.Errorf("could not decode response: %w", err)
+	}
+
+	// Hash the rest of the body, which is whitespace for well-formed responses
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return body.stats(), err
+	}
+	return body.stats(), nil
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeResponseStream(t *testing.T) {
+	body := `{"version":"bellatrix","data":{"block_number":"12"},"extra":1}`
+	response := func(contentLength int64) *http.Response {
+		return &http.Response{
+			Header:        http.Header{"Content-Type": []string{"application/json"}},
+			Body:          io.NopCloser(strings.NewReader(body + "\n")),
+			ContentLength: contentLength,
+		}
+	}
+
+	// The body is decoded and hashed as it is read, unknown fields are accepted
+	dst := new(types.GetPayloadResponse)
+	stats, err := decodeResponseStream(response(-1), dst, responseOpts{maxSize: 1000})
+	require.NoError(t, err)
+	require.Equal(t, uint64(12), dst.Data.BlockNumber)
+	digest := sha256.Sum256([]byte(body + "\n"))
+	require.Equal(t, responseStats{Size: int64(len(body) + 1), Digest: hexutil.Encode(digest[:])}, stats)
+
+	// Oversized bodies are rejected as announced, or once the limit is reached
+	_, err = decodeResponseStream(response(1001), new(types.GetPayloadResponse), responseOpts{maxSize: 1000})
+	require.ErrorIs(t, err, errResponseTooLarge)
+	_, err = decodeResponseStream(response(-1), new(types.GetPayloadResponse), responseOpts{maxSize: 20})
+	require.ErrorIs(t, err, errResponseTooLarge)
+
+	// Unknown fields are rejected in strict mode
+	deviations := []specDeviation{}
+	opts := responseOpts{
+		rejectUnknownFields: true,
+		specDeviation: func(deviation specDeviation) error {
+			deviations = append(deviations, deviation)
+			return errSpecDeviation
+		},
+	}
+	_, err = decodeResponseStream(response(-1), new(types.GetPayloadResponse), opts)
+	require.ErrorIs(t, err, errSpecDeviation)
+	require.Equal(t, []specDeviation{specDeviationUnknownField}, deviations)
+}
+
+func TestGetPayloadSizeGuard(t *testing.T) {
+	payload := types.SignedBlindedBeaconBlock{
+		Message: &types.BlindedBeaconBlock{
+			Slot: 1,
+			Body: &types.BlindedBeaconBlockBody{
+				Eth1Data:      &types.Eth1Data{},
+				SyncAggregate: &types.SyncAggregate{},
+				ExecutionPayloadHeader: &types.ExecutionPayloadHeader{
+					BlockHash: _HexToHash("0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab1"),
+				},
+			},
+		},
+	}
+
+	backend := newTestBackend(t, 1, time.Second)
+	response := backend.relays[0].MakeGetPayloadResponse(
+		"0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7",
+		"0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab1",
+		"0xdb65fEd33dc262Fe09D9a2Ba8F80b329BA25f941",
+		12345,
+	)
+	response.Data.Transactions = makeTestPayload(t, 100, 1000).Transactions
+	encoded, err := json.Marshal(response)
+	require.NoError(t, err)
+	backend.relays[0].overrideHandleGetPayload(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.Copy(w, bytes.NewReader(encoded)) // chunked, without content length
+	})
+
+	backend.boost.relayMaxPayloadSize = int64(len(encoded))
+	rr := backend.request(t, http.MethodPost, pathGetPayload, payload)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	backend.boost.relayMaxPayloadSize = int64(len(encoded)) - 1
+	rr = backend.request(t, http.MethodPost, pathGetPayload, payload)
+	require.Equal(t, http.StatusBadGateway, rr.Code, rr.Body.String())
+}
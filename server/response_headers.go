@@ -0,0 +1,88 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ResponseHeaderRule adds headers to the responses of the endpoints under a path prefix, eg. CORS headers for browser
+// based monitoring tools, or cache control
+type ResponseHeaderRule struct {
+	Path    string            `json:"path" validate:"required,pattern=^/" doc:"path prefix of the endpoints, eg. /eth/v1/builder/header/ for getHeader, or / for all endpoints"`
+	Headers map[string]string `json:"headers" validate:"required,keypattern=^[A-Za-z0-9-]+$" doc:"headers added to the responses, by name"`
+}
+
+// ResponseHeaders are the rules adding headers to responses. The rules of all the prefixes of the path of a request
+// apply, in order, so later rules override the headers of earlier ones.
+type ResponseHeaders []ResponseHeaderRule
+
+// responseHeadersReserved are the headers which can't be set by rules, as they belong to the HTTP framing
+var responseHeadersReserved = map[string]bool{
+	"Connection":        true,
+	"Content-Length":    true,
+	"Content-Type":      true,
+	"Transfer-Encoding": true,
+}
+
+// LoadResponseHeaders reads the response header rules from a JSON file
+func LoadResponseHeaders(path string) (ResponseHeaders, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules ResponseHeaders
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponseHeaders, err)
+	}
+	if err := ValidateConfig(rules); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponseHeaders, err)
+	}
+	for _, rule := range rules {
+		for name := range rule.Headers {
+			if responseHeadersReserved[http.CanonicalHeaderKey(name)] {
+				return nil, fmt.Errorf("%w: %s can't be set", ErrInvalidResponseHeaders, name)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// headers returns the headers added to the response of a request path, nil if none
+func (h ResponseHeaders) headers(path string) http.Header {
+	var ret http.Header
+	for _, rule := range h {
+		if !strings.HasPrefix(path, rule.Path) {
+			continue
+		}
+		if ret == nil {
+			ret = make(http.Header)
+		}
+		for name, value := range rule.Headers {
+			ret.Set(name, value)
+		}
+	}
+	return ret
+}
+
+// withResponseHeaders adds the configured headers to the responses. The headers set by the handlers take precedence.
+// CORS preflight requests to endpoints with configured headers are answered with the headers, without calling the
+// handlers.
+func (m *BoostService) withResponseHeaders(next http.Handler) http.Handler {
+	if len(m.responseHeaders) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		headers := m.responseHeaders.headers(req.URL.Path)
+		for name, values := range headers {
+			w.Header()[name] = values
+		}
+		if headers != nil && req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != "" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadResponseHeaders(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "headers.json")
+	load := func(config string) (ResponseHeaders, error) {
+		require.NoError(t, os.WriteFile(file, []byte(config), 0o600))
+		return LoadResponseHeaders(file)
+	}
+
+	rules, err := load(`[{"path": "/", "headers": {"Access-Control-Allow-Origin": "*"}}]`)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+
+	_, err = load(`[{"path": "eth", "headers": {"Cache-Control": "no-store"}}]`)
+	require.ErrorIs(t, err, ErrInvalidResponseHeaders)
+	_, err = load(`[{"path": "/", "headers": {"content-length": "0"}}]`)
+	require.ErrorIs(t, err, ErrInvalidResponseHeaders)
+	_, err = load(`[{"path": "/", "headers": {"X Bad": "1"}}]`)
+	require.ErrorIs(t, err, ErrInvalidResponseHeaders)
+}
+
+func TestResponseHeaders(t *testing.T) {
+	backend := newTestBackend(t, 1, time.Second)
+	backend.boost.responseHeaders = ResponseHeaders{
+		{Path: "/", Headers: map[string]string{"Access-Control-Allow-Origin": "*", "Cache-Control": "no-cache"}},
+		{Path: "/eth/v1/builder/header/", Headers: map[string]string{"Cache-Control": "no-store"}},
+		{Path: pathStatus, Headers: map[string]string{HeaderRequestID: "overridden"}},
+	}
+
+	rr := backend.request(t, http.MethodGet, pathStatus, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+	require.Equal(t, "no-cache", rr.Header().Get("Cache-Control"))
+	require.NotEqual(t, "overridden", rr.Header().Get(HeaderRequestID), "headers of mev-boost take precedence")
+
+	rr = backend.request(t, http.MethodGet, "/eth/v1/builder/header/1/0x00/0x00", nil)
+	require.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+
+	// CORS preflight requests are answered with the headers
+	req := httptest.NewRequest(http.MethodOptions, pathStatus, nil)
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	rr = httptest.NewRecorder()
+	backend.boost.getRouter().ServeHTTP(rr, req)
+	require.Equal(t, http.StatusNoContent, rr.Code)
+	require.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+}
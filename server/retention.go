@@ -0,0 +1,141 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// retentionInterval is the interval between runs of the pruning of the stored data
+const retentionInterval = 10 * time.Minute
+
+// Stores of data written to disk, which retention policies apply to
+const (
+	StoreSlotTraces   = "slot_traces"
+	StoreExports      = "exports"
+	StoreRelayRecords = "relay_records"
+)
+
+// RetentionPolicy limits the data kept in a store. Files older than MaxAge are removed, and then the oldest files
+// until the store is no larger than MaxSize. Zero values are no limit.
+type RetentionPolicy struct {
+	MaxAge  time.Duration
+	MaxSize int64 // [bytes]
+}
+
+// ParseRetentionPolicies parses a comma-separated list of STORE=KEY:VALUE;KEY:VALUE entries into policies per
+// store. Stores are slot_traces, exports and relay_records, and keys are max_age (a duration like 72h) and max_size_mb.
+func ParseRetentionPolicies(s string) (map[string]RetentionPolicy, error) {
+	ret := make(map[string]RetentionPolicy)
+	if strings.TrimSpace(s) == "" {
+		return ret, nil
+	}
+
+	for _, entry := range strings.Split(s, ",") {
+		store, settings, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || (store != StoreSlotTraces && store != StoreExports && store != StoreRelayRecords) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRetentionPolicy, entry)
+		}
+
+		policy := RetentionPolicy{}
+		for _, setting := range strings.Split(settings, ";") {
+			key, value, found := strings.Cut(strings.TrimSpace(setting), ":")
+			if !found {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidRetentionPolicy, setting)
+			}
+
+			var err error
+			switch strings.ToLower(key) {
+			case "max_age":
+				policy.MaxAge, err = time.ParseDuration(value)
+			case "max_size_mb":
+				var mb int64
+				mb, err = strconv.ParseInt(value, 10, 64)
+				policy.MaxSize = mb << 20
+			default:
+				return nil, fmt.Errorf("%w: unknown setting %s", ErrInvalidRetentionPolicy, key)
+			}
+			if err != nil || policy.MaxAge < 0 || policy.MaxSize < 0 {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidRetentionPolicy, setting)
+			}
+		}
+		ret[store] = policy
+	}
+	return ret, nil
+}
+
+// retentionStore is a directory of stored data with its retention policy
+type retentionStore struct {
+	name   string
+	dir    string
+	policy RetentionPolicy
+}
+
+// pruneDir applies a retention policy to the files of dir, and returns the size of the remaining files and the
+// number of files removed. Subdirectories are left alone.
+func pruneDir(dir string, policy RetentionPolicy, now time.Time) (size int64, removed int, err error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return 0, 0, nil
+	} else if err != nil {
+		return 0, 0, err
+	}
+
+	files := make([]os.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue // removed meanwhile
+		}
+		files = append(files, info)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ModTime().Before(files[j].ModTime()) })
+
+	for _, f := range files {
+		size += f.Size()
+	}
+	for _, f := range files {
+		expired := policy.MaxAge > 0 && now.Sub(f.ModTime()) > policy.MaxAge
+		oversized := policy.MaxSize > 0 && size > policy.MaxSize
+		if !expired && !oversized {
+			break
+		}
+		if err := os.Remove(filepath.Join(dir, f.Name())); err != nil && !os.IsNotExist(err) {
+			return size, removed, err
+		}
+		size -= f.Size()
+		removed++
+	}
+	return size, removed, nil
+}
+
+// pruneStorage is the periodic job applying the retention policies to the stores, and reporting their usage
+func (m *BoostService) pruneStorage(ctx context.Context) error {
+	var pruneErr error
+	for _, store := range m.retentionStores {
+		size, removed, err := pruneDir(store.dir, store.policy, m.clock.Now())
+		m.metrics.storageBytes.WithLabelValues(store.name).Set(float64(size))
+		m.metrics.storagePrunedFiles.WithLabelValues(store.name).Add(float64(removed))
+		if removed > 0 {
+			m.log.WithFields(logrus.Fields{
+				"store":   store.name,
+				"removed": removed,
+				"size":    size,
+			}).Debug("pruned stored data")
+		}
+		if err != nil {
+			pruneErr = fmt.Errorf("could not prune %s: %w", store.name, err)
+		}
+	}
+	return pruneErr
+}
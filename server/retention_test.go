@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetentionPolicies(t *testing.T) {
+	policies, err := ParseRetentionPolicies("")
+	require.NoError(t, err)
+	require.Empty(t, policies)
+
+	policies, err = ParseRetentionPolicies("slot_traces=max_age:72h;max_size_mb:100, exports=max_size_mb:1")
+	require.NoError(t, err)
+	require.Equal(t, map[string]RetentionPolicy{
+		StoreSlotTraces: {MaxAge: 72 * time.Hour, MaxSize: 100 << 20},
+		StoreExports:    {MaxSize: 1 << 20},
+	}, policies)
+
+	for _, s := range []string{"slot_traces", "logs=max_age:1h", "exports=max_age", "exports=max_age:3d", "exports=max_size_mb:-1", "exports=max_files:3"} {
+		_, err = ParseRetentionPolicies(s)
+		require.ErrorIs(t, err, ErrInvalidRetentionPolicy, s)
+	}
+}
+
+// writeAgedFile writes a file of size bytes, last modified at modTime
+func writeAgedFile(t *testing.T, path string, size int, modTime time.Time) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, make([]byte, size), 0o644))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestPruneDir(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	writeAgedFile(t, filepath.Join(dir, "slot-1.json"), 100, now.Add(-3*time.Hour))
+	writeAgedFile(t, filepath.Join(dir, "slot-2.json"), 100, now.Add(-2*time.Hour))
+	writeAgedFile(t, filepath.Join(dir, "slot-3.json"), 100, now.Add(-time.Hour))
+	writeAgedFile(t, filepath.Join(dir, "slot-4.json"), 100, now)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "subdir"), 0o755))
+
+	// Without limits, nothing is removed
+	size, removed, err := pruneDir(dir, RetentionPolicy{}, now)
+	require.NoError(t, err)
+	require.Equal(t, int64(400), size)
+	require.Zero(t, removed)
+
+	// By age, and then the oldest files by size
+	size, removed, err = pruneDir(dir, RetentionPolicy{MaxAge: 150 * time.Minute}, now)
+	require.NoError(t, err)
+	require.Equal(t, int64(300), size)
+	require.Equal(t, 1, removed)
+	size, removed, err = pruneDir(dir, RetentionPolicy{MaxAge: 150 * time.Minute, MaxSize: 150}, now)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), size)
+	require.Equal(t, 2, removed)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, "slot-4.json", entries[0].Name())
+	require.Equal(t, "subdir", entries[1].Name())
+
+	// Missing directories are empty
+	size, _, err = pruneDir(filepath.Join(dir, "missing"), RetentionPolicy{MaxSize: 1}, now)
+	require.NoError(t, err)
+	require.Zero(t, size)
+}
+
+func TestPruneStorage(t *testing.T) {
+	dir := t.TempDir()
+	backend := newTestBackend(t, 1, time.Second)
+	clock := newFakeClock(time.Now())
+	backend.boost.clock = clock
+	backend.boost.retentionStores = []retentionStore{{StoreSlotTraces, dir, RetentionPolicy{MaxAge: time.Hour}}}
+	writeAgedFile(t, filepath.Join(dir, "slot-1.json"), 10, clock.Now().Add(-2*time.Hour))
+	writeAgedFile(t, filepath.Join(dir, "slot-2.json"), 20, clock.Now())
+
+	require.NoError(t, backend.boost.pruneStorage(context.Background()))
+	require.Equal(t, 20.0, testutil.ToFloat64(backend.boost.metrics.storageBytes.WithLabelValues(StoreSlotTraces)))
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.storagePrunedFiles.WithLabelValues(StoreSlotTraces)))
+
+	clock.advance(2 * time.Hour)
+	require.NoError(t, backend.boost.pruneStorage(context.Background()))
+	require.Zero(t, testutil.ToFloat64(backend.boost.metrics.storageBytes.WithLabelValues(StoreSlotTraces)))
+	require.Equal(t, 2.0, testutil.ToFloat64(backend.boost.metrics.storagePrunedFiles.WithLabelValues(StoreSlotTraces)))
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3ExportOpts configures exports to S3 or an S3-compatible object store
+type S3ExportOpts struct {
+	Region   string // defaults to us-east-1
+	Endpoint string // custom endpoint of an S3-compatible store (eg. MinIO), addressed path-style
+
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // for temporary credentials
+}
+
+// s3ExportTarget uploads the export files to an S3 bucket, signing the requests with AWS Signature Version 4
+type s3ExportTarget struct {
+	bucket     string
+	prefix     string
+	opts       S3ExportOpts
+	httpClient http.Client
+}
+
+// newS3ExportTarget returns a target uploading to a bucket, with an optional key prefix, given as bucket/prefix
+func newS3ExportTarget(location string, opts S3ExportOpts) (*s3ExportTarget, error) {
+	bucket, prefix, _ := strings.Cut(location, "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("%w: missing S3 bucket", ErrInvalidExportTarget)
+	}
+	if opts.AccessKeyID == "" || opts.SecretAccessKey == "" {
+		return nil, fmt.Errorf("%w: missing S3 credentials", ErrInvalidExportTarget)
+	}
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &s3ExportTarget{
+		bucket:     bucket,
+		prefix:     prefix,
+		opts:       opts,
+		httpClient: http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// objectURL returns the URL of an object, virtual-hosted style on AWS or path-style on a custom endpoint
+func (s *s3ExportTarget) objectURL(key string) string {
+	if s.opts.Endpoint != "" {
+		return fmt.Sprintf("%s/%s/%s", strings.TrimRight(s.opts.Endpoint, "/"), s.bucket, key)
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.bucket, s.opts.Region, key)
+}
+
+func (s *s3ExportTarget) write(ctx context.Context, name string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(s.prefix+name), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/csv")
+	signS3Request(req, sha256Hex(data), s.opts, time.Now()) // S3 checks signatures against the system time
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("HTTP error response: %d %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// signS3Request adds the AWS Signature Version 4 authorization to a request, signing the host and all its headers
+func signS3Request(req *http.Request, payloadHash string, opts S3ExportOpts, t time.Time) {
+	amzDate := t.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if opts.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", opts.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	canonicalHeaders := new(strings.Builder)
+	for _, name := range names {
+		fmt.Fprintf(canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		awsURIEncode(req.URL.Path),
+		req.URL.RawQuery, // the queries of the export requests are empty, which needs no canonicalization
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, opts.Region)
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+opts.SecretAccessKey), date)
+	key = hmacSHA256(key, opts.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		opts.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// awsURIEncode percent-encodes a path as required by AWS signatures, keeping only unreserved characters and slashes
+func awsURIEncode(path string) string {
+	b := new(strings.Builder)
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
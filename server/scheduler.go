@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// schedulerJitter is the default jitter of periodic jobs, as a fraction of their interval. It spreads the load of
+// jobs with the same interval, and of multiple instances started at the same time.
+const schedulerJitter = 0.1
+
+// scheduler runs the background work of a service: periodic jobs, and long-running workers. All of it is stopped
+// by stop, which waits for the running jobs to return.
+type scheduler struct {
+	log     *logrus.Entry
+	metrics *serviceMetrics
+	clock   Clock
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newScheduler(log *logrus.Entry, metrics *serviceMetrics, clock Clock) *scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &scheduler{
+		log:     log.WithField("module", "scheduler"),
+		metrics: metrics,
+		clock:   clock,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// every runs job right away, and then every interval, varied randomly by up to jitter times the interval.
+// Runs and failures are counted per job.
+func (s *scheduler) every(name string, interval time.Duration, jitter float64, job func(ctx context.Context) error) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		log := s.log.WithField("job", name)
+		for {
+			s.runJob(log, name, job)
+
+			wait := interval + time.Duration((rand.Float64()*2-1)*jitter*float64(interval))
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-s.clock.After(wait):
+			}
+		}
+	}()
+}
+
+func (s *scheduler) runJob(log *logrus.Entry, name string, job func(ctx context.Context) error) {
+	start := s.clock.Now()
+	err := job(s.ctx)
+	s.metrics.jobDuration.WithLabelValues(name).Observe(s.clock.Now().Sub(start).Seconds())
+	s.metrics.jobLastRun.WithLabelValues(name).Set(float64(s.clock.Now().UnixNano()) / 1e9)
+	if err != nil {
+		s.metrics.jobRuns.WithLabelValues(name, "error").Inc()
+		log.WithError(err).Warn("job failed")
+		return
+	}
+	s.metrics.jobRuns.WithLabelValues(name, "success").Inc()
+}
+
+// run runs a long-running worker, which must return when ctx is done
+func (s *scheduler) run(name string, worker func(ctx context.Context)) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.metrics.workers.WithLabelValues(name).Inc()
+		defer s.metrics.workers.WithLabelValues(name).Dec()
+		worker(s.ctx)
+	}()
+}
+
+// stop stops the jobs and workers, and waits until they returned or ctx is done
+func (s *scheduler) stop(ctx context.Context) error {
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sleep waits for d, and returns false if ctx is done before
+func (s *scheduler) sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-s.clock.After(d):
+		return true
+	}
+}
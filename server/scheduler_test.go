@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler(t *testing.T) {
+	metrics := newServiceMetrics(0)
+	s := newScheduler(testLog, metrics, systemClock{})
+
+	var runs, failures int32
+	s.every("test", 10*time.Millisecond, schedulerJitter, func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	})
+	s.every("failing", 10*time.Millisecond, 0, func(ctx context.Context) error {
+		atomic.AddInt32(&failures, 1)
+		return errors.New("failed")
+	})
+
+	stopped := make(chan struct{})
+	s.run("worker", func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
+	})
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&runs) >= 3 && atomic.LoadInt32(&failures) >= 3
+	}, time.Second, 5*time.Millisecond)
+	require.Equal(t, float64(1), testutil.ToFloat64(metrics.workers.WithLabelValues("worker")))
+
+	require.NoError(t, s.stop(context.Background()))
+	<-stopped
+	require.Equal(t, float64(0), testutil.ToFloat64(metrics.workers.WithLabelValues("worker")))
+
+	// Jobs don't run after stop
+	numRuns := atomic.LoadInt32(&runs)
+	require.Equal(t, float64(numRuns), testutil.ToFloat64(metrics.jobRuns.WithLabelValues("test", "success")))
+	require.Equal(t, float64(atomic.LoadInt32(&failures)), testutil.ToFloat64(metrics.jobRuns.WithLabelValues("failing", "error")))
+	time.Sleep(30 * time.Millisecond)
+	require.Equal(t, numRuns, atomic.LoadInt32(&runs))
+}
+
+func TestSchedulerStopTimeout(t *testing.T) {
+	s := newScheduler(testLog, newServiceMetrics(0), systemClock{})
+	s.run("stuck", func(ctx context.Context) {
+		time.Sleep(time.Second)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, s.stop(ctx), context.DeadlineExceeded)
+}
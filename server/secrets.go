@@ -0,0 +1,186 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// vaultTimeout is the timeout of the requests reading secrets from Vault
+const vaultTimeout = 10 * time.Second
+
+// Secret is a secret read from a source: file:PATH, env:NAME, or vault:URL#FIELD for a field of a HashiCorp Vault
+// KV secret (v1 or v2), read with the token of the VAULT_TOKEN env var, eg.
+// vault:https://vault:8200/v1/secret/data/mev-boost#peer_secret. Secrets from files and Vault are read again by
+// reload, so they can be rotated without restart.
+type Secret struct {
+	source string
+
+	mu    sync.RWMutex
+	value string
+}
+
+// LoadSecret reads a secret from its source. Secrets are trimmed of surrounding whitespace.
+func LoadSecret(source string) (*Secret, error) {
+	value, err := readSecret(context.Background(), source)
+	if err != nil {
+		return nil, err
+	}
+	return &Secret{source: source, value: value}, nil
+}
+
+// StaticSecret returns a secret with a fixed value, eg. given on the command line
+func StaticSecret(value string) *Secret {
+	return &Secret{value: value}
+}
+
+// Value returns the current value of the secret, empty for a nil secret
+func (s *Secret) Value() string {
+	if s == nil {
+		return ""
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.value
+}
+
+// String returns the source of the secret, never its value
+func (s *Secret) String() string {
+	if s == nil || s.source == "" {
+		return "static"
+	}
+	return s.source
+}
+
+// reloadable returns whether the secret can change, ie. is read from a file or Vault
+func (s *Secret) reloadable() bool {
+	return s != nil && (strings.HasPrefix(s.source, "file:") || strings.HasPrefix(s.source, "vault:"))
+}
+
+// reload reads the secret again from its source, and returns whether it changed. The previous value is kept if the
+// source cannot be read.
+func (s *Secret) reload(ctx context.Context) (changed bool, err error) {
+	if !s.reloadable() {
+		return false, nil
+	}
+	value, err := readSecret(ctx, s.source)
+	if err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed, s.value = s.value != value, value
+	return changed, nil
+}
+
+// readSecret reads a secret from file:PATH, env:NAME or vault:URL#FIELD
+func readSecret(ctx context.Context, source string) (string, error) {
+	kind, location, found := strings.Cut(source, ":")
+	if !found {
+		return "", fmt.Errorf("%w: %s is neither file:PATH, env:NAME nor vault:URL#FIELD", ErrInvalidSecret, source)
+	}
+
+	switch kind {
+	case "file":
+		data, err := os.ReadFile(location)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(data)), nil
+	case "env":
+		value, ok := os.LookupEnv(location)
+		if !ok {
+			return "", fmt.Errorf("%w: env var %s is not set", ErrInvalidSecret, location)
+		}
+		return strings.TrimSpace(value), nil
+	case "vault":
+		return readVaultSecret(ctx, location)
+	}
+	return "", fmt.Errorf("%w: unsupported secret source %s", ErrInvalidSecret, kind)
+}
+
+// readVaultSecret reads a field of a Vault KV secret, given by URL#FIELD
+func readVaultSecret(ctx context.Context, location string) (string, error) {
+	url, field, found := strings.Cut(location, "#")
+	if !found || field == "" {
+		return "", fmt.Errorf("%w: missing #FIELD in vault:%s", ErrInvalidSecret, location)
+	}
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		return "", fmt.Errorf("%w: VAULT_TOKEN is not set", ErrInvalidSecret)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, vaultTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidSecret, err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault responded with status code %d", resp.StatusCode)
+	}
+
+	// KV v2 nests the fields of the secret in data.data, KV v1 has them in data
+	var secret struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", fmt.Errorf("could not decode vault response: %w", err)
+	}
+	fields := secret.Data
+	var nested map[string]json.RawMessage
+	if raw, ok := fields["data"]; ok && json.Unmarshal(raw, &nested) == nil {
+		fields = nested
+	}
+	var value string
+	if raw, ok := fields[field]; !ok || json.Unmarshal(raw, &value) != nil {
+		return "", fmt.Errorf("%w: vault secret has no string field %s", ErrInvalidSecret, field)
+	}
+	return strings.TrimSpace(value), nil
+}
+
+// withAdminAuth requires the admin API token as bearer token, if one is configured
+func (m *BoostService) withAdminAuth(handler http.HandlerFunc) http.HandlerFunc {
+	if m.adminAPIToken == nil {
+		return handler
+	}
+	return func(w http.ResponseWriter, req *http.Request) {
+		auth := req.Header.Get("Authorization")
+		token := strings.TrimPrefix(auth, "Bearer ")
+		expected := m.adminAPIToken.Value()
+		if token == auth || expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			m.respondError(w, http.StatusUnauthorized, "invalid admin API token")
+			return
+		}
+		handler(w, req)
+	}
+}
+
+// reloadSecrets reads the reloadable secrets again, so rotated secrets are used without restart
+func (m *BoostService) reloadSecrets(ctx context.Context) error {
+	var failed error
+	for _, secret := range m.secrets {
+		changed, err := secret.reload(ctx)
+		if err != nil {
+			m.log.WithError(err).WithField("source", secret.String()).Warn("could not reload secret, keeping the previous value")
+			failed = fmt.Errorf("could not reload %s: %w", secret, err)
+			continue
+		}
+		if changed {
+			m.log.WithField("source", secret.String()).Info("secret rotated")
+		}
+	}
+	return failed
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSecret(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(path, []byte("first\n"), 0o600))
+
+	secret, err := LoadSecret("file:" + path)
+	require.NoError(t, err)
+	require.Equal(t, "first", secret.Value())
+	require.Equal(t, "file:"+path, secret.String())
+
+	// Rotated secrets are read on reload, and the previous value is kept if the file can't be read
+	require.NoError(t, os.WriteFile(path, []byte("second"), 0o600))
+	changed, err := secret.reload(context.Background())
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, "second", secret.Value())
+	require.NoError(t, os.Remove(path))
+	_, err = secret.reload(context.Background())
+	require.Error(t, err)
+	require.Equal(t, "second", secret.Value())
+
+	t.Setenv("TEST_SECRET", "from-env")
+	secret, err = LoadSecret("env:TEST_SECRET")
+	require.NoError(t, err)
+	require.Equal(t, "from-env", secret.Value())
+	require.False(t, secret.reloadable())
+
+	for _, source := range []string{"env:TEST_SECRET_UNSET", "kms://key-id", "secret", "vault:http://localhost"} {
+		_, err = LoadSecret(source)
+		require.ErrorIs(t, err, ErrInvalidSecret, source)
+	}
+
+	var unset *Secret
+	require.Empty(t, unset.Value())
+	require.Equal(t, "static", StaticSecret("value").String())
+}
+
+func TestLoadVaultSecret(t *testing.T) {
+	value := "first"
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		// KV v2 response
+		_, _ = w.Write([]byte(`{"data": {"data": {"peer_secret": "` + value + `"}, "metadata": {"version": 1}}}`))
+	}))
+	defer vault.Close()
+
+	_, err := LoadSecret("vault:" + vault.URL + "/v1/secret/data/mev-boost#peer_secret")
+	require.ErrorIs(t, err, ErrInvalidSecret)
+
+	t.Setenv("VAULT_TOKEN", "token")
+	secret, err := LoadSecret("vault:" + vault.URL + "/v1/secret/data/mev-boost#peer_secret")
+	require.NoError(t, err)
+	require.Equal(t, "first", secret.Value())
+
+	value = "second"
+	changed, err := secret.reload(context.Background())
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, "second", secret.Value())
+
+	_, err = LoadSecret("vault:" + vault.URL + "/v1/secret/data/mev-boost#missing")
+	require.ErrorIs(t, err, ErrInvalidSecret)
+}
+
+func TestAdminAPIToken(t *testing.T) {
+	backend := newTestBackend(t, 1, time.Second)
+	backend.boost.adminAPI = true
+	backend.boost.adminAPIToken = StaticSecret("token")
+
+	request := func(auth string) int {
+		req, err := http.NewRequest(http.MethodGet, pathAdminAnnotations, nil)
+		require.NoError(t, err)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rr := httptest.NewRecorder()
+		backend.boost.getRouter().ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	require.Equal(t, http.StatusUnauthorized, request(""))
+	require.Equal(t, http.StatusUnauthorized, request("token"))
+	require.Equal(t, http.StatusUnauthorized, request("Bearer wrong"))
+	require.Equal(t, http.StatusOK, request("Bearer token"))
+
+	// The builder API is not authenticated
+	require.Equal(t, http.StatusOK, backend.request(t, http.MethodGet, pathStatus, nil).Code)
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// servedHeader is the header served to the CL for a slot, which the block the validator signs must match
+type servedHeader struct {
+	Slot       uint64    `json:"slot,string"`
+	ParentHash string    `json:"parent_hash"`
+	Pubkey     string    `json:"pubkey"`
+	BlockHash  string    `json:"block_hash"`
+	Value      string    `json:"value"` // in wei
+	Relays     []string  `json:"relays"`
+	ServedAt   time.Time `json:"served_at"`
+	Network    string    `json:"network,omitempty"` // in multi-network mode
+}
+
+// servedHeaderNotifier posts the served headers to an external endpoint, eg. a beacon node sidecar or a log
+// collector, so that external systems can detect the validator signing a different block than the one served
+type servedHeaderNotifier struct {
+	url        string
+	network    string
+	httpClient http.Client
+}
+
+func newServedHeaderNotifier(url, network string, timeout time.Duration) *servedHeaderNotifier {
+	return &servedHeaderNotifier{
+		url:        strings.TrimRight(url, "/"),
+		network:    network,
+		httpClient: http.Client{Timeout: timeout},
+	}
+}
+
+// notifyServedHeader posts the served header in the background, off the getHeader response path
+func (m *BoostService) notifyServedHeader(slot uint64, parentHash string, bid bidResp) {
+	header := servedHeader{
+		Slot:       slot,
+		ParentHash: parentHash,
+		Pubkey:     bid.pubkey,
+		BlockHash:  bid.blockHash,
+		Relays:     bid.relays,
+		ServedAt:   bid.servedAt,
+		Network:    m.servedHeaderNotifier.network,
+	}
+	if bid.valueWei != nil {
+		header.Value = bid.valueWei.String()
+	}
+	go func() {
+		log := m.log.WithFields(logrus.Fields{
+			"method":    "notifyServedHeader",
+			"slot":      slot,
+			"blockHash": bid.blockHash,
+		})
+		if _, err := SendHTTPRequest(context.Background(), m.servedHeaderNotifier.httpClient, http.MethodPost, m.servedHeaderNotifier.url, "", header, nil); err != nil {
+			m.metrics.servedHeaderNotifyErrors.Inc()
+			log.WithError(err).Warn("could not notify the served header")
+			return
+		}
+		log.Debug("notified the served header")
+	}()
+}
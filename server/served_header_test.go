@@ -0,0 +1,53 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifyServedHeader(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+
+	notified := make(chan servedHeader, 1)
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		require.Equal(t, http.MethodPost, req.Method)
+		header := servedHeader{}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&header))
+		notified <- header
+	}))
+	defer endpoint.Close()
+
+	backend := newTestBackend(t, 1, time.Second)
+	backend.boost.servedHeaderNotifier = newServedHeaderNotifier(endpoint.URL, "mainnet", time.Second)
+	rr := backend.request(t, http.MethodGet, fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey), nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	select {
+	case header := <-notified:
+		require.Equal(t, uint64(1), header.Slot)
+		require.Equal(t, hash, header.ParentHash)
+		require.Equal(t, pubkey, header.Pubkey)
+		require.Equal(t, hash, header.BlockHash) // the mock relay bids on the requested hash
+		require.Equal(t, "12345", header.Value)
+		require.Equal(t, []string{backend.relays[0].RelayEntry.String()}, header.Relays)
+		require.Equal(t, "mainnet", header.Network)
+	case <-time.After(time.Second):
+		t.Fatal("served header not notified")
+	}
+
+	// Failures are counted
+	endpoint.Close()
+	rr = backend.request(t, http.MethodGet, fmt.Sprintf("/eth/v1/builder/header/2/%s/%s", hash, pubkey), nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(backend.boost.metrics.servedHeaderNotifyErrors) == 1
+	}, time.Second, 10*time.Millisecond)
+}
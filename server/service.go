@@ -2,20 +2,29 @@ package server
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"math/big"
+	"net"
 	"net/http"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/flashbots/go-boost-utils/types"
 	"github.com/flashbots/go-utils/httplogger"
 	"github.com/flashbots/mev-boost/config"
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 )
 
@@ -26,6 +35,17 @@ var (
 	errNoSuccessfulRelayResponse = errors.New("no successful relay response")
 
 	errServerAlreadyRunning = errors.New("server already running")
+	errTooManyRequests      = errors.New("too many requests")
+	errRequestTimeout       = errors.New("request timeout")
+	errResponseTooLarge     = errors.New("response too large")
+	errSpecDeviation        = errors.New("builder spec deviation")
+	errRelayRedirect        = errors.New("relay redirect refused")
+
+	errTransactionsRootMismatch = errors.New("transactions root mismatch")
+	errPayloadHeaderMismatch    = errors.New("payload does not match the header")
+	errWithdrawalsRootMismatch  = errors.New("withdrawals root mismatch")
+	errNilWithdrawal            = errors.New("null withdrawal")
+	errUnsupportedFork          = errors.New("unsupported fork")
 )
 
 var nilHash = types.Hash{}
@@ -36,26 +56,429 @@ type httpErrorResp struct {
 	Message string `json:"message"`
 }
 
+type relayStatus struct {
+	URL           string             `json:"url"`
+	InMaintenance bool               `json:"in_maintenance"`
+	Draining      bool               `json:"draining"`
+	Untrusted     bool               `json:"untrusted"`
+	Tags          map[string]string  `json:"tags,omitempty"`
+	Capabilities  *RelayCapabilities `json:"capabilities"`
+	Annotations   []annotation       `json:"annotations,omitempty"` // active annotations of the relay
+	Idempotency   bool               `json:"idempotency"`           // whether the relay honors idempotency keys
+
+	// PubkeyVerified is whether the relay signed the last pubkey challenge with its configured pubkey, unset if unknown
+	PubkeyVerified *bool `json:"pubkey_verified,omitempty"`
+
+	// CertificateExpiry is the expiry of the TLS certificate chain last presented by the relay
+	CertificateExpiry *time.Time `json:"certificate_expiry,omitempty"`
+
+	// Score is the reliability score of the relay, limited to the cold start score while it is on probation
+	Score     float64 `json:"score"`
+	Probation bool    `json:"probation"`
+}
+
+type mevBoostStatusResponse struct {
+	Version  string        `json:"version"`
+	Features []Feature     `json:"features"`
+	Relays   []relayStatus `json:"relays"`
+
+	// Annotations are the active annotations of all relays
+	Annotations []annotation `json:"annotations,omitempty"`
+
+	// Telemetry shows whether anonymous telemetry is submitted, and the last report
+	Telemetry telemetryStatus `json:"telemetry"`
+}
+
 // BoostServiceOpts provides all available options for use with NewBoostService
 type BoostServiceOpts struct {
-	Log                   *logrus.Entry
+	Log       *logrus.Entry
+	Clock     Clock     // the system clock if nil
+	JSONCodec JSONCodec // encoding/json if nil
+
+	// RelayCertificateWarning is the remaining validity of a relay TLS certificate below which a warning is logged,
+	// 0 to disable the warnings
+	RelayCertificateWarning time.Duration
+
+	// AttestationKey signs the attestations of the served bids, which are not made if nil
+	AttestationKey ed25519.PrivateKey
+
+	// Retention limits the data kept in the stores on disk (slot traces, local exports), per store
+	Retention             map[string]RetentionPolicy
 	ListenAddr            string
 	Relays                []RelayEntry
 	GenesisForkVersionHex string
 	RelayRequestTimeout   time.Duration
 	RelayCheck            bool
+
+	// RelayClient, if set, sends the requests to all relays instead of the default HTTP clients, which makes the
+	// relay transport settings and RelayRequestTimeout ineffective
+	RelayClient RelayClient
+
+	// StatusCacheTTL is how long the result of checking the relays serves the status endpoint, 0 to check the relays
+	// on every call. For StatusCacheStaleTTL after that, the stale result is served while the relays are checked
+	// again in the background.
+	StatusCacheTTL      time.Duration
+	StatusCacheStaleTTL time.Duration
+
+	// GetHeaderPartialDeadline, if set, is the maximum time handleGetHeader waits for relays before
+	// returning the best bid received so far (flagged as a partial result). Zero means wait for all relays.
+	GetHeaderPartialDeadline time.Duration
+
+	// GenesisTime is the genesis timestamp of the network, used to verify that bids are built for the requested
+	// slot. 0 disables the check.
+	GenesisTime uint64
+
+	// Chain is the chain served, Ethereum if not set
+	Chain ChainConfig
+
+	// ForkSchedule is used to verify that relay responses use the version of the fork active at the
+	// requested slot. If empty, any version is accepted.
+	ForkSchedule ForkSchedule
+
+	// SignatureCacheSize is the number of relay signature verification results to memoize (0 to disable)
+	SignatureCacheSize int
+
+	// RegistrationEncodingCacheSize is the total size of the encoded registration batches to keep for sending them to
+	// other relays, retries and replays (0 to disable) [bytes]
+	RegistrationEncodingCacheSize int
+
+	// DebugAPI enables the debug endpoints (eg. recent bids with rejection reasons)
+	DebugAPI bool
+
+	// AdminAPI enables the admin endpoints (eg. replaying the validator registrations to the relays)
+	AdminAPI bool
+
+	// DebugAPIPublicListenAddr, if set, serves the debug endpoints on a separate listener for third parties, with
+	// the DebugAPIRedactFields redacted. Proposer pubkeys are hashed rather than removed.
+	DebugAPIPublicListenAddr string
+	DebugAPIRedactFields     []string
+
+	// RegistrationRateLimit and RegistrationRateLimitPerIP limit incoming registerValidator calls globally
+	// and per source IP [requests per second], allowing bursts of RegistrationRateLimitBurst. 0 disables a limit.
+	RegistrationRateLimit      float64
+	RegistrationRateLimitPerIP float64
+	RegistrationRateLimitBurst int
+
+	// SpecStrict rejects messages of the consensus client and relays deviating from the builder spec (eg. unknown
+	// fields or missing content type) instead of accepting them. Deviations are counted per peer either way.
+	SpecStrict bool
+
+	// RelayBidRateLimit limits the bids processed per relay [bids per second], allowing bursts of
+	// RelayBidRateLimitBurst. Excess bids are dropped before validation. 0 disables the limit.
+	RelayBidRateLimit      float64
+	RelayBidRateLimitBurst int
+
+	// OutboundRequestBudget limits the requests to all relays together [requests per second], allowing bursts of
+	// OutboundRequestBudgetBurst. Requests wait for the budget until they time out, except getPayload. 0 disables
+	// the limit.
+	OutboundRequestBudget      float64
+	OutboundRequestBudgetBurst int
+
+	// RelayMaxResponseSize is the maximum size of a getHeader response body [bytes], 0 for no limit
+	RelayMaxResponseSize int64
+
+	// RelayMaxPayloadSize is the maximum size of a getPayload response body [bytes], 0 for no limit
+	RelayMaxPayloadSize int64
+
+	// UntrustedRelayMaxResponseSize and UntrustedRelayMaxPayloadSize are the lower size limits of the getHeader
+	// and getPayload responses of untrusted relays [bytes], 0 to apply the limits of all relays
+	UntrustedRelayMaxResponseSize int64
+	UntrustedRelayMaxPayloadSize  int64
+
+	// UntrustedRelayBidMargin is how much the bid of an untrusted relay must exceed the best trusted bid to win [%]
+	UntrustedRelayBidMargin float64
+
+	// RelayCapabilitiesInterval is the interval at which relay capabilities are polled (0 to disable)
+	RelayCapabilitiesInterval time.Duration
+
+	// RelayChallengeInterval is the interval at which relays are challenged to sign a nonce with their configured
+	// pubkey, starting at startup (0 to disable)
+	RelayChallengeInterval time.Duration
+
+	// RelayScoreHalfLife is the age at which a response counts half in the reliability score of a relay, which orders
+	// the getPayload calls (0 to count all responses fully). Relays are on probation for RelayScoreProbation after
+	// their first response, during which their score is at most that of a relay without responses.
+	RelayScoreHalfLife  time.Duration
+	RelayScoreProbation time.Duration
+
+	// PayloadDeliverySLA is the maximum time from serving a header to receiving its payload from a relay. Once it
+	// passed without payload, the relays which did not respond yet are counted as exceeding it, and a payload at
+	// risk event is published. 0 disables the alerts, the time is measured either way.
+	PayloadDeliverySLA time.Duration
+
+	// RelayTagPolicies each require a valid bid from a relay with the tag for every getHeader request. Violations are
+	// logged and counted, and with RelayTagPoliciesEnforce no bid is served.
+	RelayTagPolicies        []RelayTagPolicy
+	RelayTagPoliciesEnforce bool
+
+	// GetHeaderResponseDeadline is the time into the slot after which getHeader calls are answered right away with
+	// the bid already served or prefetched for the slot, or no bid, instead of requesting bids from the relays.
+	// Requires the genesis time, 0 disables it.
+	GetHeaderResponseDeadline time.Duration
+
+	// GetPayloadStagger is the delay between the getPayload calls to subsequent relays. Relays which delivered the
+	// bid are called first, and the first valid payload cancels the remaining calls. 0 calls all relays at once.
+	GetPayloadStagger time.Duration
+
+	// VerifyPayloadRoots recomputes the transactions root of getPayload responses, and rejects payloads which don't
+	// match the signed blinded header
+	VerifyPayloadRoots bool
+
+	// PayloadVerificationPolicy is how optional checks of getPayload responses (eg. VerifyPayloadRoots) are handled
+	// when their expected duration exceeds the time left until the deadline of the getPayload request, or
+	// PayloadVerificationDeadline into the slot (requires the genesis time, 0 disables it)
+	PayloadVerificationPolicy   PayloadVerificationPolicy
+	PayloadVerificationDeadline time.Duration
+
+	// RequestTimeouts are the per-endpoint deadlines for handling requests from the CL
+	RequestTimeouts RequestTimeouts
+
+	// BeaconNodeURL is the optional beacon node API, used for prefetching bids for upcoming proposals
+	BeaconNodeURL string
+
+	// RegistrationQueue enables acknowledging registerValidator calls right away, and delivering the registrations
+	// to the relays asynchronously with retries. The queue is persisted to RegistrationQueueFile if set.
+	RegistrationQueue       bool
+	RegistrationQueueFile   string
+	RegistrationQueuePacing time.Duration // minimum interval between batches delivered to a relay
+
+	// AtRestKey, if set, encrypts the files written by the service (the registration queue, the exports, the slot
+	// traces and the recorded relay responses) with AES-GCM
+	AtRestKey []byte
+
+	// BidOracleURL is an optional source of publicly observed bids implementing the relay data API, to compare the
+	// served bids with
+	BidOracleURL string
+
+	// ServedHeaderURL, if set, receives a POST request with the slot and block hash of each served header, so that
+	// external systems can detect the validator signing a different block
+	ServedHeaderURL string
+
+	// NetworkName is the name of the network served in multi-network mode, which tags the served headers posted to
+	// ServedHeaderURL
+	NetworkName string
+
+	// ProposerMetricsLimit is the maximum number of proposers with their own per-proposer metrics, labelled by
+	// shortened pubkey. Further proposers are aggregated as "other". 0 disables per-proposer metrics.
+	ProposerMetricsLimit int
+
+	// Experiment assigns proposers or slots to cohorts requesting bids from different relay sets
+	Experiment Experiment
+
+	// ProposerConfig, if set, restricts proposers to their own relays, and controls whether getPayload may fall
+	// back to other relays. A config loaded from a file can be reloaded with ReloadProposerConfig, and a config
+	// fetched with FetchProposerConfig is polled every ProposerConfigPollInterval if set.
+	ProposerConfig             *ProposerConfig
+	ProposerConfigPollInterval time.Duration
+
+	// ProposerImporter, if set, imports the validators managed by a Web3Signer or validator client into the proposer
+	// config every ProposerImportInterval, starting at startup
+	ProposerImporter       *ProposerImporter
+	ProposerImportInterval time.Duration
+
+	// BidPolicy, if set, is an expression which bids must satisfy, in addition to the bid policies of the proposer
+	// config
+	BidPolicy *BidPolicy
+
+	// LogLevels, if set, allows adjusting the log levels at runtime with the admin API
+	LogLevels *LogLevels
+
+	// FeatureFlags toggle risky behaviors at runtime, with the default states if not set
+	FeatureFlags *FeatureFlags
+
+	// SlotTraceDir, if set, is the directory to which a trace of the relay requests and processing phases of each
+	// slot is written, in the Chrome trace event format
+	SlotTraceDir string
+
+	// MinBidValue, if set, is the minimum value of the bids served to proposers without min_bid in the proposer
+	// config [wei]. Lower bids are dropped, so the proposer builds the block locally if no bid is above it.
+	MinBidValue *big.Int
+
+	// ResponseHeaders are added to the responses of the endpoints they apply to
+	ResponseHeaders ResponseHeaders
+
+	// RelayRecordDir, if set, is the directory to which the responses of the relays are recorded, as fixtures which
+	// the mock relay can replay
+	RelayRecordDir string
+
+	// ExpectedValidators are the pubkeys of validators expected to register, which are included in the registration
+	// coverage report even if they never registered with this instance
+	ExpectedValidators []string
+
+	// RelayMonitors are the URLs of relay monitors, to which the transcripts of auctions, with their payload outcome,
+	// are sent
+	RelayMonitors []string
+
+	// Peers are the URLs of peer mev-boost instances run by the same operator, with which summaries of the validated
+	// bids are shared. Peering is enabled by PeerSecret, which authenticates the peers.
+	Peers      []string
+	PeerSecret *Secret
+
+	// AdminAPIToken, if set, is required as bearer token by the admin API endpoints
+	AdminAPIToken *Secret
+
+	// SecretsReloadInterval is the interval at which the secrets read from files and Vault are read again, so they
+	// can be rotated without restart (0 to disable)
+	SecretsReloadInterval time.Duration
+
+	// ExportTarget, if set, is the local directory or S3 location (s3://bucket/prefix) to which the bids and slot
+	// outcomes are exported as CSV files every ExportInterval, for offline analysis
+	ExportTarget   string
+	ExportInterval time.Duration
+	ExportS3       S3ExportOpts
+
+	// TelemetryEndpoint, if set, is the community endpoint to which anonymous statistics of the relays are submitted
+	// every TelemetryInterval. Each report is TelemetryEpsilon-differentially private for a single getHeader call (0
+	// for no noise), see TelemetryReport.
+	TelemetryEndpoint string
+	TelemetryInterval time.Duration
+	TelemetryEpsilon  float64
+
+	// GetHeaderPrefetchLeadTime is how long before the start of a slot in which a registered validator proposes
+	// bids are requested from the relays. Requires BeaconNodeURL, 0 disables prefetching.
+	GetHeaderPrefetchLeadTime time.Duration
+
+	// GetHeaderDutyCheck is how getHeader calls for pubkeys without a proposal duty in the epoch of the slot are
+	// handled. Requires BeaconNodeURL.
+	GetHeaderDutyCheck DutyCheckMode
+
+	// RelaySamplingInterval is how often bids are requested for the current slot with RelaySamplingPubkey, unless
+	// the slot has a proposal, to measure the liveness and bid availability of the relays in between proposals. The
+	// sampled bids are never served. Requires BeaconNodeURL, 0 disables sampling.
+	RelaySamplingInterval time.Duration
+	RelaySamplingPubkey   string
 }
 
 // BoostService - the mev-boost service
 type BoostService struct {
-	listenAddr string
-	relays     []RelayEntry
-	log        *logrus.Entry
-	srv        *http.Server
-	relayCheck bool
+	listenAddr  string
+	relays      []RelayEntry
+	relayDrains *relayDrainStore
+	annotations *annotationStore
+	idempotency *idempotencySupport
+
+	relayCertificates       *relayCertificates
+	relayCertificateWarning time.Duration
+	relayTraffic            *relayTraffic
+	attestationKey          ed25519.PrivateKey
+	retentionStores         []retentionStore
+	log                     *logrus.Entry
+	clock                   Clock
+	jsonCodec               JSONCodec
+	srvLock                 sync.Mutex
+	srv                     *http.Server
+	extensionsLock          sync.Mutex
+	extensions              []Extension
+	platform                platform
+	scheduler               *scheduler
+	relayCheck              bool
+
+	statusCache *statusCache
+
+	getHeaderPartialDeadline time.Duration
+	getPayloadStagger        time.Duration
+	getHeaderRespDeadline    time.Duration
+	relayTagPolicies         []RelayTagPolicy
+	relayTagPoliciesEnforce  bool
+	validationCost           *validationCost
+	payloadDeliverySLA       time.Duration
+	verifyPayloadRoots       bool
+	payloadCheckPolicy       PayloadVerificationPolicy
+	payloadCheckDeadline     time.Duration
+	payloadCheckCosts        payloadCheckCosts
+	experiment               Experiment
+	proposerConfig           *proposerConfigStore
+	proposerImporter         *ProposerImporter
+	proposerImportInterval   time.Duration
+	proposerConfigPoll       time.Duration
+	bidPolicy                *BidPolicy
+
+	genesisTime     uint64
+	chain           ChainConfig
+	forkSchedule    ForkSchedule
+	sigCache        *signatureCache
+	bidRejections   *bidRejectionStats
+	relayErrors     *relayErrorStats
+	slotOutcomes    *slotOutcomeTracker
+	slotTracer      *slotTracer
+	relayRecorder   *relayRecorder
+	events          *eventBus
+	eventStream     *eventStream // observer of all events
+	metrics         *serviceMetrics
+	debugAPI        bool
+	adminAPI        bool
+	adminAPIToken   *Secret
+	logLevels       *LogLevels
+	features        *FeatureFlags
+	debugPublicAddr string
+	debugRedactor   *debugRedactor
+	responseHeaders ResponseHeaders
+	minBidValue     *big.Int
+
+	registrationEncodings   *registrationEncodingCache
+	secrets                 []*Secret // reloaded every secretsReloadInterval
+	secretsReloadInterval   time.Duration
+	registrationRateLimiter *rateLimiter
+	relayBidRateLimiter     *rateLimiter // per relay
+	outboundBudget          *outboundBudget
+	outboundBudgetPool      *poolUsage
+	relayRequestsPool       *poolUsage
+	bidValidationsPool      *poolUsage
+	relayHeaderCalls        *relayHeaderCalls
+	getHeaderCalls          *getHeaderCalls
+	relayMaxResponseSize    int64
+	relayMaxPayloadSize     int64
+	specStrict              bool
+
+	untrustedRelayMaxResponseSize int64
+	untrustedRelayMaxPayloadSize  int64
+	untrustedRelayBidMargin       float64
+
+	registrationQueue       *registrationQueue
+	registrationQueuePacing time.Duration
+	queueRegistrations      bool // acknowledge registrations right away, and deliver them with the queue
+	registrationHistory     *registrationHistory
+
+	relayCapabilities         *relayCapabilitiesStore
+	relayCapabilitiesInterval time.Duration
+	relayChallenges           *relayChallenges
+	relayChallengeInterval    time.Duration
+	relayScores               *relayScores
+
+	requestTimeouts RequestTimeouts
+
+	bidOracle     *bidOracle
+	relayMonitors []string
+	peering       *peering
+
+	servedHeaderNotifier *servedHeaderNotifier
+
+	dataExporter   *dataExporter
+	exportInterval time.Duration
+
+	telemetry         *telemetry
+	telemetryInterval time.Duration
+
+	beaconClient     *beaconClient
+	prefetchLeadTime time.Duration
+	prefetchedBids   *prefetchedBidsStore
+	relaySampler     *relaySampler
+	samplingInterval time.Duration
+
+	dutyCheck         DutyCheckMode
+	proposerDuties    *proposerDutiesCache
+	dutylessGetHeader chan struct{} // getHeader calls without proposal duty being served, with DutyCheckDeprioritize
+
+	registrationsLock sync.Mutex
+	registrations     map[string]types.SignedValidatorRegistration // latest registration per pubkey
+
+	registrationCoverage *registrationCoverage
+	expectedValidators   []string
 
 	builderSigningDomain types.Domain
 	httpClient           http.Client
+	relayClients         map[string]*http.Client // per relay, each with its own connection pool
+	customRelayClient    RelayClient             // replaces the relay clients if set
 
 	bidsLock sync.Mutex
 	bids     map[bidRespKey]bidResp // keeping track of bids, to log the originating relay on withholding
@@ -72,12 +495,249 @@ func NewBoostService(opts BoostServiceOpts) (*BoostService, error) {
 		return nil, err
 	}
 
-	return &BoostService{
-		listenAddr: opts.ListenAddr,
-		relays:     opts.Relays,
-		log:        opts.Log.WithField("module", "service"),
-		relayCheck: opts.RelayCheck,
-		bids:       make(map[bidRespKey]bidResp),
+	// Without queue mode, the queue only delivers the registrations deferred for flaky relays, and is not persisted
+	queueFile := ""
+	if opts.RegistrationQueue {
+		queueFile = opts.RegistrationQueueFile
+	}
+	queue, err := newRegistrationQueue(opts.Relays, queueFile, opts.AtRestKey)
+	if err != nil {
+		return nil, fmt.Errorf("could not load the registration queue: %w", err)
+	}
+
+	redactor, err := newDebugRedactor(opts.DebugAPIRedactFields)
+	if err != nil {
+		return nil, err
+	}
+
+	var oracle *bidOracle
+	if opts.BidOracleURL != "" {
+		oracle = newBidOracle(opts.BidOracleURL, opts.RelayRequestTimeout)
+	}
+
+	var notifier *servedHeaderNotifier
+	if opts.ServedHeaderURL != "" {
+		notifier = newServedHeaderNotifier(opts.ServedHeaderURL, opts.NetworkName, opts.RelayRequestTimeout)
+	}
+
+	var beacon *beaconClient
+	if opts.BeaconNodeURL != "" {
+		beacon = newBeaconClient(opts.BeaconNodeURL, opts.RelayRequestTimeout)
+	}
+
+	var sampler *relaySampler
+	if beacon != nil && opts.RelaySamplingInterval > 0 {
+		sampler, err = newRelaySampler(opts.RelaySamplingPubkey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tracer, err := newSlotTracer(opts.SlotTraceDir, opts.AtRestKey)
+	if err != nil {
+		return nil, fmt.Errorf("could not create the slot trace directory: %w", err)
+	}
+
+	var exporter *dataExporter
+	if opts.ExportTarget != "" {
+		target, err := newExportTarget(opts.ExportTarget, opts.ExportS3)
+		if err != nil {
+			return nil, err
+		}
+		exporter = newDataExporter(target, opts.AtRestKey)
+	}
+
+	retentionStores := []retentionStore{}
+	if opts.SlotTraceDir != "" {
+		retentionStores = append(retentionStores, retentionStore{StoreSlotTraces, opts.SlotTraceDir, opts.Retention[StoreSlotTraces]})
+	}
+	if opts.ExportTarget != "" && !strings.HasPrefix(opts.ExportTarget, "s3://") {
+		retentionStores = append(retentionStores, retentionStore{StoreExports, opts.ExportTarget, opts.Retention[StoreExports]})
+	}
+	if opts.RelayRecordDir != "" {
+		retentionStores = append(retentionStores, retentionStore{StoreRelayRecords, opts.RelayRecordDir, opts.Retention[StoreRelayRecords]})
+	}
+
+	relayClients := make(map[string]*http.Client, len(opts.Relays))
+	for _, relay := range opts.Relays {
+		relayClients[relay.String()] = newRelayClient(opts.RelayRequestTimeout, relay.Transport)
+	}
+
+	log := opts.Log.WithField("module", "service")
+	recorder, err := newRelayRecorder(opts.RelayRecordDir, opts.AtRestKey, log)
+	if err != nil {
+		return nil, fmt.Errorf("could not create the relay record directory: %w", err)
+	}
+	if err := opts.Experiment.validate(opts.Relays); err != nil {
+		return nil, err
+	}
+	if err := opts.ProposerConfig.validate(opts.Relays); err != nil {
+		return nil, err
+	}
+
+	if opts.GetHeaderResponseDeadline > 0 && opts.GenesisTime == 0 {
+		log.Warn("the getHeader response deadline requires the genesis time, ignoring it")
+	}
+
+	chain := opts.Chain
+	if chain.SecondsPerSlot == 0 || chain.SlotsPerEpoch == 0 {
+		chain = ChainEthereum
+	}
+
+	clock := opts.Clock
+	if clock == nil {
+		clock = systemClock{}
+	}
+	jsonCodec := opts.JSONCodec
+	if jsonCodec == nil {
+		jsonCodec = stdJSONCodec{}
+	}
+
+	metrics := newServiceMetrics(opts.ProposerMetricsLimit)
+	if opts.FeatureFlags != nil {
+		metrics.registry.MustRegister(newConfigReloadFailingMetric(configFeatureFlags, opts.FeatureFlags.ReloadError))
+	}
+	if relayTags := newRelayTagsMetric(opts.Relays); relayTags != nil {
+		metrics.registry.MustRegister(relayTags)
+	}
+	annotations := newAnnotationStore()
+	metrics.registry.MustRegister(newAnnotationsCollector(annotations, clock))
+	outboundBudget := newOutboundBudget(opts.OutboundRequestBudget, opts.OutboundRequestBudgetBurst, clock)
+	relayCertificates := newRelayCertificates()
+	metrics.registry.MustRegister(newRelayCertificatesCollector(relayCertificates, clock))
+	validationCost := new(validationCost)
+	metrics.registry.MustRegister(newValidationCostMetric(validationCost))
+	sigCache := newSignatureCache(opts.SignatureCacheSize)
+	metrics.registry.MustRegister(newSignatureCacheMetrics(sigCache)...)
+	relayScores := newRelayScores(opts.RelayScoreHalfLife, opts.RelayScoreProbation)
+	proposerConfig := newProposerConfigStore(opts.ProposerConfig)
+	if proposerConfig.path() != "" || proposerConfig.url() != "" {
+		metrics.registry.MustRegister(newConfigReloadFailingMetric(configProposerConfig, proposerConfig.ReloadError))
+	}
+	var proposerImportToken *Secret
+	if opts.ProposerImporter != nil {
+		proposerImportToken = opts.ProposerImporter.token
+		metrics.registry.MustRegister(newConfigReloadFailingMetric(configProposerImport, proposerConfig.ImportError))
+	}
+	metrics.registry.MustRegister(newRelayScoresCollector(relayScores, opts.Relays, clock))
+	m := &BoostService{
+		listenAddr:  opts.ListenAddr,
+		relays:      opts.Relays,
+		relayDrains: newRelayDrainStore(),
+		annotations: annotations,
+		idempotency: newIdempotencySupport(),
+
+		relayCertificates:       relayCertificates,
+		relayCertificateWarning: opts.RelayCertificateWarning,
+		relayTraffic:            newRelayTraffic(),
+		attestationKey:          opts.AttestationKey,
+		retentionStores:         retentionStores,
+		platform:                defaultPlatform(),
+		scheduler:               newScheduler(log, metrics, clock),
+		log:                     log,
+		clock:                   clock,
+		jsonCodec:               jsonCodec,
+		relayCheck:              opts.RelayCheck,
+		bids:                    make(map[bidRespKey]bidResp),
+
+		statusCache: newStatusCache(opts.StatusCacheTTL, opts.StatusCacheStaleTTL),
+
+		getHeaderPartialDeadline: opts.GetHeaderPartialDeadline,
+		getPayloadStagger:        opts.GetPayloadStagger,
+		getHeaderRespDeadline:    opts.GetHeaderResponseDeadline,
+		relayTagPolicies:         opts.RelayTagPolicies,
+		relayTagPoliciesEnforce:  opts.RelayTagPoliciesEnforce,
+		validationCost:           validationCost,
+		payloadDeliverySLA:       opts.PayloadDeliverySLA,
+		verifyPayloadRoots:       opts.VerifyPayloadRoots,
+		payloadCheckPolicy:       opts.PayloadVerificationPolicy,
+		payloadCheckDeadline:     opts.PayloadVerificationDeadline,
+		payloadCheckCosts:        newPayloadCheckCosts(),
+		experiment:               opts.Experiment,
+		proposerConfig:           proposerConfig,
+		proposerImporter:         opts.ProposerImporter,
+		proposerImportInterval:   opts.ProposerImportInterval,
+		proposerConfigPoll:       opts.ProposerConfigPollInterval,
+		bidPolicy:                opts.BidPolicy,
+		genesisTime:              opts.GenesisTime,
+		chain:                    chain,
+		forkSchedule:             opts.ForkSchedule,
+		sigCache:                 sigCache,
+		registrationEncodings:    newRegistrationEncodingCache(opts.RegistrationEncodingCacheSize),
+		bidRejections:            newBidRejectionStats(),
+		relayErrors:              newRelayErrorStats(metrics.relayErrors),
+		slotOutcomes:             newSlotOutcomeTracker(),
+		slotTracer:               tracer,
+		relayRecorder:            recorder,
+		responseHeaders:          opts.ResponseHeaders,
+		minBidValue:              opts.MinBidValue,
+		events:                   newEventBus(),
+		eventStream:              newEventStream(),
+		metrics:                  metrics,
+		debugAPI:                 opts.DebugAPI,
+		adminAPI:                 opts.AdminAPI,
+		adminAPIToken:            opts.AdminAPIToken,
+		secrets:                  []*Secret{opts.PeerSecret, opts.AdminAPIToken, proposerImportToken},
+		secretsReloadInterval:    opts.SecretsReloadInterval,
+		logLevels:                opts.LogLevels,
+		features:                 opts.FeatureFlags,
+		debugPublicAddr:          opts.DebugAPIPublicListenAddr,
+		debugRedactor:            redactor,
+
+		registrationRateLimiter: newRateLimiter(opts.RegistrationRateLimit, opts.RegistrationRateLimitPerIP, opts.RegistrationRateLimitBurst),
+		relayBidRateLimiter:     newRateLimiter(0, opts.RelayBidRateLimit, opts.RelayBidRateLimitBurst),
+		outboundBudget:          outboundBudget,
+		outboundBudgetPool:      metrics.pool(poolOutboundBudget, outboundBudget.capacity()),
+		relayRequestsPool:       metrics.pool(poolRelayRequests, 0),
+		bidValidationsPool:      metrics.pool(poolBidValidations, runtime.GOMAXPROCS(0)),
+		relayHeaderCalls:        newRelayHeaderCalls(),
+		getHeaderCalls:          newGetHeaderCalls(),
+		relayMaxResponseSize:    opts.RelayMaxResponseSize,
+		relayMaxPayloadSize:     opts.RelayMaxPayloadSize,
+		specStrict:              opts.SpecStrict,
+
+		untrustedRelayMaxResponseSize: opts.UntrustedRelayMaxResponseSize,
+		untrustedRelayMaxPayloadSize:  opts.UntrustedRelayMaxPayloadSize,
+		untrustedRelayBidMargin:       opts.UntrustedRelayBidMargin,
+
+		registrationQueue:       queue,
+		registrationQueuePacing: opts.RegistrationQueuePacing,
+		queueRegistrations:      opts.RegistrationQueue,
+		registrationHistory:     newRegistrationHistory(),
+
+		relayCapabilities:         newRelayCapabilitiesStore(),
+		relayCapabilitiesInterval: opts.RelayCapabilitiesInterval,
+		relayChallenges:           newRelayChallenges(),
+		relayChallengeInterval:    opts.RelayChallengeInterval,
+		relayScores:               relayScores,
+
+		requestTimeouts: opts.RequestTimeouts,
+
+		bidOracle:     oracle,
+		relayMonitors: opts.RelayMonitors,
+		peering:       newPeering(opts.Peers, opts.PeerSecret, opts.RelayRequestTimeout),
+
+		servedHeaderNotifier: notifier,
+
+		dataExporter:   exporter,
+		exportInterval: opts.ExportInterval,
+
+		telemetry:         newTelemetry(opts.TelemetryEndpoint, opts.TelemetryEpsilon, opts.GenesisForkVersionHex, opts.Relays, clock.Now()),
+		telemetryInterval: opts.TelemetryInterval,
+
+		beaconClient:     beacon,
+		prefetchLeadTime: opts.GetHeaderPrefetchLeadTime,
+		prefetchedBids:   newPrefetchedBidsStore(),
+		relaySampler:     sampler,
+		samplingInterval: opts.RelaySamplingInterval,
+
+		dutyCheck:         opts.GetHeaderDutyCheck,
+		proposerDuties:    newProposerDutiesCache(chain.slotDuration()),
+		dutylessGetHeader: make(chan struct{}, 1),
+		registrations:     make(map[string]types.SignedValidatorRegistration),
+
+		registrationCoverage: newRegistrationCoverage(),
+		expectedValidators:   opts.ExpectedValidators,
 
 		builderSigningDomain: builderSigningDomain,
 		httpClient: http.Client{
@@ -86,7 +746,11 @@ func NewBoostService(opts BoostServiceOpts) (*BoostService, error) {
 				return http.ErrUseLastResponse
 			},
 		},
-	}, nil
+		relayClients:      relayClients,
+		customRelayClient: opts.RelayClient,
+	}
+	m.subscribeObservers()
+	return m, nil
 }
 
 func (m *BoostService) respondError(w http.ResponseWriter, code int, message string) {
@@ -100,12 +764,48 @@ func (m *BoostService) respondError(w http.ResponseWriter, code int, message str
 }
 
 func (m *BoostService) respondOK(w http.ResponseWriter, response any) {
+	body, err := marshalJSON(m.jsonCodec, response)
+	if err != nil {
+		m.log.WithField("response", response).WithError(err).Error("Couldn't write OK response")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		m.log.WithField("response", response).WithError(err).Error("Couldn't write OK response")
+	if _, err := w.Write(append(body, '\n')); err != nil {
+		m.log.WithError(err).Debug("Couldn't write OK response")
+	}
+}
+
+// respondPayload writes a getPayload response, in SSZ or JSON, with the checksum of its body, which is logged so that
+// a payload corrupted on its way to the CL can be told apart from one delivered corrupted by the relay
+func (m *BoostService) respondPayload(w http.ResponseWriter, log *logrus.Entry, response *GetPayloadResponse, inSSZ bool) {
+	var body []byte
+	var err error
+	if inSSZ {
+		body, err = marshalExecutionPayloadSSZ(response.Data)
+		w.Header().Set("Content-Type", mediaTypeSSZ)
+		w.Header().Set(HeaderConsensusVersion, string(response.Version))
+	} else {
+		body, err = marshalJSON(m.jsonCodec, response)
+		body = append(body, '\n')
+		w.Header().Set("Content-Type", "application/json")
+	}
+	if err != nil {
+		log.WithError(err).Error("Couldn't write OK response")
 		http.Error(w, "", http.StatusInternalServerError)
+		return
 	}
+	digest := sha256.Sum256(body)
+	w.Header().Set(HeaderContentDigest, contentDigest(digest))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		log.WithError(err).Debug("Couldn't write OK response")
+	}
+	log.WithFields(logrus.Fields{
+		"payloadSize":   len(body),
+		"payloadDigest": hexutil.Encode(digest[:]),
+	}).Info("delivered payload to consensus client")
 }
 
 func (m *BoostService) getRouter() http.Handler {
@@ -113,23 +813,59 @@ func (m *BoostService) getRouter() http.Handler {
 	r.HandleFunc("/", m.handleRoot)
 
 	r.HandleFunc(pathStatus, m.handleStatus).Methods(http.MethodGet)
-	r.HandleFunc(pathRegisterValidator, m.handleRegisterValidator).Methods(http.MethodPost)
-	r.HandleFunc(pathGetHeader, m.handleGetHeader).Methods(http.MethodGet)
-	r.HandleFunc(pathGetPayload, m.handleGetPayload).Methods(http.MethodPost)
+	r.HandleFunc(pathRegisterValidator, m.withRequestMetrics("register_validator", m.withTimeout(m.requestTimeouts.RegisterValidator, m.handleRegisterValidator))).Methods(http.MethodPost)
+	r.HandleFunc(pathGetHeader, m.withRequestMetrics("get_header", m.withTimeout(m.requestTimeouts.GetHeader, m.handleGetHeader))).Methods(http.MethodGet)
+	r.HandleFunc(pathGetPayload, m.withRequestMetrics("get_payload", m.withTimeout(m.requestTimeouts.GetPayload, m.handleGetPayload))).Methods(http.MethodPost)
+	r.HandleFunc(pathMevBoostStatus, m.handleMevBoostStatus).Methods(http.MethodGet)
+	r.Handle(pathMetrics, promhttp.HandlerFor(m.metrics.registry, promhttp.HandlerOpts{})).Methods(http.MethodGet)
+	if m.attestationKey != nil {
+		r.HandleFunc(pathAttestations, m.handleAttestations).Methods(http.MethodGet)
+	}
+	if m.peering != nil {
+		r.HandleFunc(pathPeerBids, m.handlePeerBids).Methods(http.MethodPost)
+	}
+
+	if m.debugAPI {
+		r.HandleFunc(pathDebugBids, m.handleDebugBids).Methods(http.MethodGet)
+		r.HandleFunc(pathDebugRelayDiff, m.handleDebugRelayDiff).Methods(http.MethodGet)
+		r.HandleFunc(pathDebugRegistrationCoverage, m.handleDebugRegistrationCoverage).Methods(http.MethodGet)
+	}
+	if m.adminAPI {
+		r.HandleFunc(pathAdminReplayRegistrations, m.withAdminAuth(m.handleReplayRegistrations)).Methods(http.MethodPost)
+		r.HandleFunc(pathAdminRelayDrain, m.withAdminAuth(m.handleRelayDrain)).Methods(http.MethodGet, http.MethodPost, http.MethodDelete)
+		r.HandleFunc(pathAdminAnnotations, m.withAdminAuth(m.handleAnnotations)).Methods(http.MethodGet, http.MethodPost, http.MethodDelete)
+		if m.logLevels != nil {
+			r.HandleFunc(pathAdminLogLevel, m.withAdminAuth(m.handleLogLevel)).Methods(http.MethodGet, http.MethodPut, http.MethodDelete)
+		}
+		if m.features != nil {
+			r.HandleFunc(pathAdminFeatures, m.withAdminAuth(m.handleFeatureFlags)).Methods(http.MethodGet, http.MethodPost)
+		}
+	}
+	m.registerExtensions(r)
 
 	r.Use(mux.CORSMethodMiddleware(r))
-	loggedRouter := httplogger.LoggingMiddlewareLogrus(m.log, r)
-	return loggedRouter
+	handler := m.withRequestID(r)
+	if !m.debugAPI {
+		return m.withResponseHeaders(handler)
+	}
+
+	// The event stream bypasses the request logger, whose response writer can't be flushed
+	return m.withResponseHeaders(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == pathDebugEvents && req.Method == http.MethodGet {
+			m.handleEvents(w, req)
+			return
+		}
+		handler.ServeHTTP(w, req)
+	}))
 }
 
 // StartHTTPServer starts the HTTP server for this boost service instance
 func (m *BoostService) StartHTTPServer() error {
+	m.srvLock.Lock()
 	if m.srv != nil {
+		m.srvLock.Unlock()
 		return errServerAlreadyRunning
 	}
-
-	go m.startBidCacheCleanupTask()
-
 	m.srv = &http.Server{
 		Addr:    m.listenAddr,
 		Handler: m.getRouter(),
@@ -140,58 +876,218 @@ func (m *BoostService) StartHTTPServer() error {
 		IdleTimeout:       time.Duration(config.ServerIdleTimeoutMs) * time.Millisecond,
 
 		MaxHeaderBytes: config.ServerMaxHeaderBytes,
+		ConnState:      newConnStateTracker(m.metrics).track,
+	}
+	srv := m.srv
+	m.srvLock.Unlock()
+
+	m.scheduler.every("cleanup", time.Minute, schedulerJitter, m.cleanup)
+	if m.relayCapabilitiesInterval > 0 {
+		m.scheduler.every("relay_capabilities", m.relayCapabilitiesInterval, schedulerJitter, func(ctx context.Context) error {
+			m.updateRelayCapabilities()
+			return nil
+		})
+	}
+	if m.relayChallengeInterval > 0 {
+		m.scheduler.every("relay_challenge", m.relayChallengeInterval, schedulerJitter, m.verifyRelayPubkeys)
+	}
+	if m.secretsReloadInterval > 0 {
+		m.scheduler.every("secrets_reload", m.secretsReloadInterval, schedulerJitter, m.reloadSecrets)
+	}
+	if m.proposerConfigPoll > 0 && m.proposerConfig.url() != "" {
+		m.scheduler.every("proposer_config_poll", m.proposerConfigPoll, schedulerJitter, m.PollProposerConfig)
+	}
+	if m.proposerImporter != nil {
+		if m.proposerImportInterval > 0 {
+			m.scheduler.every("proposer_import", m.proposerImportInterval, schedulerJitter, m.importProposers)
+		} else {
+			m.scheduler.run("proposer_import", func(ctx context.Context) {
+				_ = m.importProposers(ctx) // logged by importProposers
+			})
+		}
+	}
+	m.startRegistrationQueueTasks()
+	if m.beaconClient != nil && m.prefetchLeadTime > 0 {
+		m.scheduler.run("prefetch", m.startPrefetchWorker)
+	}
+	if m.relaySampler != nil {
+		m.scheduler.every("relay_sampling", m.samplingInterval, schedulerJitter, m.sampleRelays)
+	}
+	if m.dataExporter != nil && m.exportInterval > 0 {
+		m.scheduler.every("data_export", m.exportInterval, schedulerJitter, m.exportData)
+	}
+	if m.telemetry != nil && m.telemetryInterval > 0 {
+		m.scheduler.every("telemetry", m.telemetryInterval, schedulerJitter, m.submitTelemetry)
+	}
+	if len(m.retentionStores) > 0 {
+		m.scheduler.every("retention", retentionInterval, schedulerJitter, m.pruneStorage)
+	}
+	if m.debugPublicAddr != "" {
+		go m.startPublicDebugServer()
+	}
+
+	// Use the socket passed by the service manager if any (eg. systemd socket activation), which allows binding
+	// privileged ports without root
+	listener, err := m.platform.listener()
+	if err != nil {
+		return err
+	}
+	if listener != nil {
+		m.log.WithField("addr", listener.Addr().String()).Info("using the listener passed by the service manager")
+	} else {
+		listener, err = net.Listen("tcp", m.listenAddr)
+		if err != nil {
+			return err
+		}
+	}
+	if config.ServerMaxConnections > 0 {
+		listener = newConnLimitListener(listener, config.ServerMaxConnections, m.metrics)
+	}
+
+	// Notify the service manager about readiness, and start the watchdog if enabled
+	if err := m.platform.notifyReady(); err != nil {
+		m.log.WithError(err).Warn("failed to notify the service manager about readiness")
+	}
+	if interval := m.platform.watchdogInterval(); interval > 0 {
+		// No jitter, the service manager expects notifications within a deadline
+		m.scheduler.every("watchdog", interval, 0, func(ctx context.Context) error {
+			return m.platform.notifyWatchdog()
+		})
 	}
 
-	err := m.srv.ListenAndServe()
+	err = srv.Serve(listener)
 	if err == http.ErrServerClosed {
 		return nil
 	}
 	return err
 }
 
-func (m *BoostService) startBidCacheCleanupTask() {
-	for {
-		time.Sleep(1 * time.Minute)
-		m.bidsLock.Lock()
-		for k, bidResp := range m.bids {
-			if time.Since(bidResp.t) > 3*time.Minute {
-				delete(m.bids, k)
-			}
+// Shutdown gracefully stops the HTTP server started by StartHTTPServer and the background jobs, waiting for
+// pending requests and running jobs until ctx is done
+func (m *BoostService) Shutdown(ctx context.Context) error {
+	m.srvLock.Lock()
+	srv := m.srv
+	m.srvLock.Unlock()
+
+	if srv != nil {
+		if err := srv.Shutdown(ctx); err != nil {
+			return err
+		}
+	}
+	if err := m.scheduler.stop(ctx); err != nil {
+		return err
+	}
+
+	// Export the rows collected since the last export
+	if m.dataExporter != nil {
+		return m.exportData(ctx)
+	}
+	return nil
+}
+
+// startPublicDebugServer serves the redacted debug endpoints on the public debug listener
+func (m *BoostService) startPublicDebugServer() {
+	srv := &http.Server{
+		Addr:    m.debugPublicAddr,
+		Handler: httplogger.LoggingMiddlewareLogrus(m.log, m.getPublicDebugRouter()),
+
+		ReadTimeout:       time.Duration(config.ServerReadTimeoutMs) * time.Millisecond,
+		ReadHeaderTimeout: time.Duration(config.ServerReadHeaderTimeoutMs) * time.Millisecond,
+		WriteTimeout:      time.Duration(config.ServerWriteTimeoutMs) * time.Millisecond,
+		IdleTimeout:       time.Duration(config.ServerIdleTimeoutMs) * time.Millisecond,
+
+		MaxHeaderBytes: config.ServerMaxHeaderBytes,
+	}
+
+	m.log.WithField("addr", m.debugPublicAddr).Info("serving the public debug API")
+	if err := srv.ListenAndServe(); err != nil {
+		m.log.WithError(err).Error("public debug API server failed")
+	}
+}
+
+// cleanup removes the expired bids and per-slot state
+func (m *BoostService) cleanup(ctx context.Context) error {
+	m.bidsLock.Lock()
+	for k, bidResp := range m.bids {
+		if m.clock.Now().Sub(bidResp.t) > 3*time.Minute {
+			delete(m.bids, k)
 		}
-		m.bidsLock.Unlock()
 	}
+	m.bidsLock.Unlock()
+
+	m.prefetchedBids.prune(m.clock.Now(), 3*time.Minute)
+	m.slotTracer.prune(m.clock.Now(), 3*time.Minute)
+	m.peering.prune(m.clock.Now(), 3*time.Minute)
+	for _, outcome := range m.slotOutcomes.expire(m.clock.Now(), time.Minute) {
+		m.emitSlotOutcome(outcome)
+	}
+	m.registrationRateLimiter.prune(m.clock.Now())
+
+	hits, misses := m.registrationEncodings.stats()
+	m.log.WithFields(logrus.Fields{
+		"hits":   hits,
+		"misses": misses,
+	}).Debug("registration encoding cache stats")
+	return nil
 }
 
 func (m *BoostService) handleRoot(w http.ResponseWriter, req *http.Request) {
 	m.respondOK(w, nilResponse)
 }
 
-// handleStatus sends calls to the status endpoint of every relay.
-// It returns OK if at least one returned OK, and returns error otherwise.
+// handleStatus returns OK if at least one relay is available, and an error otherwise. The result of checking the
+// relays is cached, if enabled.
 func (m *BoostService) handleStatus(w http.ResponseWriter, req *http.Request) {
 	if !m.relayCheck {
 		m.respondOK(w, nilResponse)
 		return
 	}
 
-	// If relayCheck is enabled, make sure at least 1 relay returns success
+	ua := UserAgent(req.Header.Get("User-Agent"))
+	log := m.requestLog(req)
+	ok, result, revalidate := m.statusCache.get(m.clock.Now())
+	m.metrics.statusCache.WithLabelValues(result).Inc()
+	if revalidate {
+		go func() {
+			start := m.clock.Now()
+			m.statusCache.set(m.checkRelayStatus(relayContext(req), log, ua), start)
+		}()
+	}
+	if result == statusCacheMiss {
+		start := m.clock.Now()
+		ok = m.checkRelayStatus(relayContext(req), log, ua)
+		m.statusCache.set(ok, start)
+	}
+
+	if ok {
+		m.respondOK(w, nilResponse)
+	} else {
+		m.respondError(w, http.StatusServiceUnavailable, "all relays are unavailable")
+	}
+}
+
+// checkRelayStatus sends calls to the status endpoint of every relay, and returns whether at least one returned OK
+func (m *BoostService) checkRelayStatus(ctx context.Context, log *logrus.Entry, ua UserAgent) bool {
 	var wg sync.WaitGroup
 	var numSuccessRequestsToRelay uint32
-	ua := UserAgent(req.Header.Get("User-Agent"))
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	for _, r := range m.relays {
+	for _, r := range m.liveRelays(m.clock.Now()) {
 		wg.Add(1)
 
 		go func(relay RelayEntry) {
 			defer wg.Done()
 			url := relay.GetURI(pathStatus)
-			log := m.log.WithField("url", url)
+			log := relayLog(log, relay, url)
 			log.Debug("Checking relay status")
 
-			_, err := SendHTTPRequest(ctx, m.httpClient, http.MethodGet, url, ua, nil, nil)
+			_, _, err := sendHTTPRequest(ctx, m.relayClient(relay), http.MethodGet, url, ua, nil, nil, responseOpts{onTLS: m.onRelayTLS(relay)})
 			if err != nil && ctx.Err() != context.Canceled {
+				if relay.InMaintenance(m.clock.Now()) {
+					log.WithError(err).Debug("failed to retrieve status of relay in maintenance")
+					return
+				}
 				log.WithError(err).Error("failed to retrieve relay status")
 				return
 			}
@@ -204,48 +1100,104 @@ func (m *BoostService) handleStatus(w http.ResponseWriter, req *http.Request) {
 
 	// At the end, wait for every routine and return status according to relay's ones.
 	wg.Wait()
-
-	if numSuccessRequestsToRelay > 0 {
-		m.respondOK(w, nilResponse)
-	} else {
-		m.respondError(w, http.StatusServiceUnavailable, "all relays are unavailable")
-	}
+	return numSuccessRequestsToRelay > 0
 }
 
 // handleRegisterValidator - returns 200 if at least one relay returns 200, else 502
 func (m *BoostService) handleRegisterValidator(w http.ResponseWriter, req *http.Request) {
-	log := m.log.WithField("method", "registerValidator")
+	log := m.requestLog(req).WithField("method", "registerValidator")
 	log.Debug("registerValidator")
 
-	payload := []types.SignedValidatorRegistration{}
-	if err := DecodeJSON(req.Body, &payload); err != nil {
+	sourceIP, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		sourceIP = req.RemoteAddr
+	}
+	if ok, retryAfter := m.registrationRateLimiter.allow(sourceIP, m.clock.Now()); !ok {
+		log.WithField("sourceIP", sourceIP).Warn("registerValidator rate limit exceeded")
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		m.respondError(w, http.StatusTooManyRequests, errTooManyRequests.Error())
+		return
+	}
+
+	if err := m.checkRequestSpec(req); err != nil {
+		m.respondError(w, http.StatusUnsupportedMediaType, err.Error())
+		return
+	}
+
+	payload, err := decodeRegistrations(req)
+	if err != nil {
 		m.respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	// Remember the registrations, to know which validators are served by this instance
+	m.registrationsLock.Lock()
+	for _, registration := range payload {
+		if registration.Message != nil {
+			m.registrations[strings.ToLower(registration.Message.Pubkey.String())] = registration
+		}
+	}
+	m.registrationsLock.Unlock()
+	m.checkRegistrationSettings(log, payload)
+
+	// Acknowledge right away in queue mode, the registrations are delivered to the relays asynchronously
+	if m.queueRegistrations {
+		m.registrationQueue.enqueue(payload)
+		log.WithField("numRegistrations", len(payload)).Debug("queued registrations")
+		m.respondOK(w, nilResponse)
+		return
+	}
+
 	ua := UserAgent(req.Header.Get("User-Agent"))
 	log = log.WithFields(logrus.Fields{
 		"numRegistrations": len(payload),
 		"ua":               ua,
 	})
 
-	relayRespCh := make(chan error, len(m.relays))
+	// Flaky relays are not awaited, so that they cannot delay the response. Their registrations are delivered with
+	// the queue instead.
+	relays, flaky := m.registrationHistory.order(m.liveRelays(m.clock.Now()))
+	if len(flaky) > 0 {
+		flakyRelays := make([]string, len(flaky))
+		for i, relay := range flaky {
+			flakyRelays[i] = relay.String()
+			m.metrics.registrationsDeferred.WithLabelValues(relay.String()).Inc()
+		}
+		m.registrationQueue.enqueue(payload, flakyRelays...)
+		log.WithField("relays", flakyRelays).Debug("queued registrations for flaky relays")
+	}
 
-	for _, relay := range m.relays {
+	relayRespCh := make(chan error, len(relays))
+	var relayMessagesLock sync.Mutex
+	relayMessages := make(map[string]string) // error messages supplied by the relays
+
+	for _, relay := range relays {
 		go func(relay RelayEntry) {
 			url := relay.GetURI(pathRegisterValidator)
-			log := log.WithField("url", url)
-
-			_, err := SendHTTPRequest(context.Background(), m.httpClient, http.MethodPost, url, ua, payload, nil)
+			log := relayLog(log, relay, url)
+
+			start := m.clock.Now()
+			err := m.sendRegistrations(relayContext(req), relay, payload, ua)
+			m.registrationHistory.record(relay.String(), err, m.clock.Now().Sub(start))
+			m.publishRelayError(relay, relayEndpoint(pathRegisterValidator), err)
+			if message := relayErrorMessage(err); message != "" {
+				relayMessagesLock.Lock()
+				relayMessages[relay.URL.Host] = message
+				relayMessagesLock.Unlock()
+			}
 			relayRespCh <- err
 			if err != nil {
+				if relay.InMaintenance(m.clock.Now()) {
+					log.WithError(err).Debug("error calling registerValidator on relay in maintenance")
+					return
+				}
 				log.WithError(err).Warn("error calling registerValidator on relay")
 				return
 			}
 		}(relay)
 	}
 
-	for i := 0; i < len(m.relays); i++ {
+	for i := 0; i < len(relays); i++ {
 		respErr := <-relayRespCh
 		if respErr == nil {
 			m.respondOK(w, nilResponse)
@@ -253,16 +1205,44 @@ func (m *BoostService) handleRegisterValidator(w http.ResponseWriter, req *http.
 		}
 	}
 
-	m.respondError(w, http.StatusBadGateway, errNoSuccessfulRelayResponse.Error())
+	relayMessagesLock.Lock()
+	defer relayMessagesLock.Unlock()
+	message := noSuccessfulRelayResponseMessage(relayMessages)
+
+	// Relays rejecting the signatures of registrations for another network is a common misconfiguration
+	if diagnosis := m.diagnoseRegistrations(payload); diagnosis != "" {
+		log.Error(diagnosis)
+		message = fmt.Sprintf("%s: %s", message, diagnosis)
+	}
+	m.respondError(w, http.StatusBadGateway, message)
+}
+
+// sendRegistrations sends validator registrations to a relay, split into batches if the relay limits the batch size
+func (m *BoostService) sendRegistrations(ctx context.Context, relay RelayEntry, registrations []types.SignedValidatorRegistration, ua UserAgent) error {
+	url := relay.GetURI(pathRegisterValidator)
+	for _, batch := range chunkRegistrations(registrations, m.relayCapabilities.get(relay.String()).maxRegistrationBatchSize()) {
+		body, err := m.encodeRegistrations(batch)
+		if err != nil {
+			return err
+		}
+		_, header, err := sendHTTPRequest(ctx, m.relayClient(relay), http.MethodPost, url, ua, body, nil, responseOpts{codec: m.jsonCodec})
+		m.recordIdempotency(relay, header)
+		if err != nil {
+			return err
+		}
+		m.registrationCoverage.record(relay.String(), batch, m.clock.Now())
+	}
+	return nil
 }
 
 // handleGetHeader requests bids from the relays
 func (m *BoostService) handleGetHeader(w http.ResponseWriter, req *http.Request) {
+	start := m.clock.Now()
 	vars := mux.Vars(req)
 	slot := vars["slot"]
 	parentHashHex := vars["parent_hash"]
 	pubkey := vars["pubkey"]
-	log := m.log.WithFields(logrus.Fields{
+	log := m.requestLog(req).WithFields(logrus.Fields{
 		"method":     "getHeader",
 		"slot":       slot,
 		"parentHash": parentHashHex,
@@ -286,39 +1266,307 @@ func (m *BoostService) handleGetHeader(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	var mu sync.Mutex
-	relays := make(map[string][]string) // relays per blockHash
-	result := bidResp{}
+	// Keep the capacity for the actual proposers of the epoch
+	code, done := m.checkProposerDuty(log, _slot, pubkey)
+	defer done()
+	if code == http.StatusBadRequest {
+		m.respondError(w, code, errNoProposalDuty.Error())
+		return
+	} else if code != 0 {
+		w.WriteHeader(code)
+		return
+	}
 
 	ua := UserAgent(req.Header.Get("User-Agent"))
+	m.telemetry.recordClient(ua)
+	m.relaySampler.proposal(_slot)
+	trace := m.slotTracer.get(_slot, start)
+	defer func() {
+		trace.span(slotTraceMainThread, "getHeader", slotTraceCatHandler, start, m.clock.Now(), nil)
+	}()
+
+	// Serve the bid prefetched ahead of the request if available, which takes the relays off the critical path
+	result, ok := m.prefetchedBids.get(_slot, parentHashHex, pubkey)
+	if ok && m.features.Enabled(FeatureBidPrefetch) {
+		log.Debug("serving prefetched bid")
+		trace.instant(slotTraceMainThread, "prefetched bid served", slotTraceCatSelection, m.clock.Now(), nil)
+	} else if m.pastGetHeaderDeadline(_slot, start) {
+		// Requesting bids this late only adds to the risk of missing the slot
+		result, ok = m.servedBid(_slot, parentHashHex, pubkey)
+		m.metrics.lateGetHeader.WithLabelValues(strconv.FormatBool(ok)).Inc()
+		log.WithField("cached", ok).Warn("getHeader after the response deadline, not requesting bids")
+	} else {
+		result = m.requestBidsCoalesced(relayContext(req), req.Context(), log, _slot, parentHashHex, pubkey, ua)
+	}
+	if violated := m.violatedTagPolicies(result.bid); len(violated) > 0 {
+		for _, policy := range violated {
+			m.metrics.relayTagPolicyViolations.WithLabelValues(policy.String()).Inc()
+		}
+		log.WithFields(logrus.Fields{
+			"violatedPolicies": violated,
+			"enforced":         m.relayTagPoliciesEnforce,
+		}).Warn("bids violate relay tag policies")
+		if m.relayTagPoliciesEnforce {
+			result.bid = bidResp{}
+		}
+	}
+	bestBid := result.bid
+	m.slotOutcomes.headerServed(_slot, pubkey, result, start)
+	m.dataExporter.addBids(m.clock.Now(), _slot, pubkey, bestBid)
+	if m.peering != nil {
+		m.gossipBids(_slot, parentHashHex, pubkey, bestBid)
+	}
+
+	if result.isPartial {
+		m.metrics.partialGetHeader.WithLabelValues(strconv.FormatBool(bestBid.blockHash != "")).Inc()
+		w.Header().Set(HeaderPartialResult, result.coverage)
+		log.WithField("coverage", result.coverage).Warn("partial deadline reached, not all relays responded")
+	}
+
+	if bestBid.blockHash == "" {
+		numBelowMin := 0
+		for _, rejection := range bestBid.rejections {
+			if isBelowMinBid(rejection) {
+				numBelowMin++
+			}
+		}
+		if numBelowMin > 0 {
+			// No Content makes the consensus client fall back to local block production
+			log.WithField("numBelowMin", numBelowMin).Info("no bid above the minimum bid value, falling back to local block production")
+		} else {
+			log.Info("no bid received")
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// Log result
+	log.WithFields(logrus.Fields{
+		"blockHash":   bestBid.blockHash,
+		"blockNumber": bestBid.response.Data.Message.Header.BlockNumber,
+		"txRoot":      bestBid.response.Data.Message.Header.TransactionsRoot.String(),
+		"value":       bestBid.response.Data.Message.Value.String(),
+		"relays":      strings.Join(bestBid.relays, ", "),
+		"partial":     result.isPartial,
+		"numRejected": len(bestBid.rejections),
+	}).Info("best bid")
 
-	// Call the relays
+	// Remember the bid, for future logging in case of withholding
+	bestBid.pubkey = pubkey
+	bestBid.servedAt = m.clock.Now()
+	bidKey := bidRespKey{slot: _slot, blockHash: bestBid.blockHash}
+	if err := m.attestBid(w, _slot, parentHashHex, &bestBid); err != nil {
+		log.WithError(err).Error("could not attest the served bid")
+	}
+	m.bidsLock.Lock()
+	m.bids[bidKey] = bestBid
+	m.bidsLock.Unlock()
+
+	// Return the bid, in SSZ if the CL prefers it
+	if acceptsSSZ(req) {
+		body, err := marshalSignedBuilderBidSSZ(bestBid.response.Data)
+		if err != nil {
+			log.WithError(err).Error("could not encode the bid in SSZ")
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		m.respondSSZ(w, body, bestBid.response.Version)
+	} else {
+		m.respondOK(w, bestBid.response)
+	}
+	if m.servedHeaderNotifier != nil {
+		m.notifyServedHeader(_slot, parentHashHex, bestBid)
+	}
+}
+
+// validateBid verifies a bid of a relay for the getHeader request, and returns the reason to reject it, or an empty
+// reason if it is valid
+func (m *BoostService) validateBid(log *logrus.Entry, relay RelayEntry, slot uint64, parentHashHex string, responsePayload *GetHeaderResponse) BidRejectionReason {
+	// Bids must be for the fork active at the requested slot, which matters at fork boundaries
+	expectedVersion := m.forkAtSlot(slot)
+
+	if responsePayload.Version == "" {
+		if err := m.recordRelaySpecDeviation(relay, specDeviationMissingVersion); err != nil {
+			log.WithError(err).Warn("rejecting relay response deviating from the builder spec")
+			return BidRejectionSpecDeviation
+		}
+	}
+	if expectedVersion != "" && string(responsePayload.Version) != expectedVersion {
+		log.Errorf("bid version mismatch. expected: %s - got: %s", expectedVersion, responsePayload.Version)
+		return BidRejectionVersionMismatch
+	}
+
+	// Capella headers commit to the withdrawals of the payload, and Bellatrix headers have no withdrawals
+	hasWithdrawalsRoot := responsePayload.Data.Message.Header.WithdrawalsRoot != nil
+	if (responsePayload.Version == versionCapella && !hasWithdrawalsRoot) || (responsePayload.Version == versionBellatrix && hasWithdrawalsRoot) {
+		log.WithField("hasWithdrawalsRoot", hasWithdrawalsRoot).Errorf("bid header does not match its version %s", responsePayload.Version)
+		return BidRejectionVersionMismatch
+	}
+
+	if relay.PublicKey != responsePayload.Data.Message.Pubkey {
+		log.Errorf("bid pubkey mismatch. expected: %s - got: %s", relay.PublicKey.String(), responsePayload.Data.Message.Pubkey.String())
+		return BidRejectionPubkeyMismatch
+	}
+
+	// Verify the relay signature in the relay response
+	verify := m.sigCache.verify
+	if relay.Untrusted {
+		verify = verifySignature // no cached results for untrusted relays
+	}
+	ok, err := verify(responsePayload.Data.Message, m.builderSigningDomain, relay.PublicKey, responsePayload.Data.Signature)
+	if err != nil {
+		log.WithError(err).Error("error verifying relay signature")
+		return BidRejectionInvalidSignature
+	}
+	if !ok {
+		log.Error("failed to verify relay signature")
+		return BidRejectionInvalidSignature
+	}
+
+	// Verify response coherence with proposer's input data
+	responseParentHash := responsePayload.Data.Message.Header.ParentHash.String()
+	if responseParentHash != parentHashHex {
+		log.WithFields(logrus.Fields{
+			"originalParentHash": parentHashHex,
+			"responseParentHash": responseParentHash,
+		}).Error("proposer and relay parent hashes are not the same")
+		return BidRejectionParentHashMismatch
+	}
+
+	// Verify the bid is built for the requested slot
+	if m.genesisTime > 0 {
+		expectedTimestamp := m.genesisTime + slot*m.chain.SecondsPerSlot
+		if responsePayload.Data.Message.Header.Timestamp != expectedTimestamp {
+			log.WithFields(logrus.Fields{
+				"expectedTimestamp": expectedTimestamp,
+				"timestamp":         responsePayload.Data.Message.Header.Timestamp,
+			}).Error("bid timestamp does not match the requested slot")
+			return BidRejectionTimestampMismatch
+		}
+	}
+
+	isZeroValue := responsePayload.Data.Message.Value.String() == "0"
+	isEmptyListTxRoot := responsePayload.Data.Message.Header.TransactionsRoot.String() == "0x7ffe241ea60187fdb0187bfa22de35d1f9bed7ab061d9401fd47e34a54fbede1"
+	if isZeroValue || isEmptyListTxRoot {
+		log.Warn("ignoring bid with 0 value")
+		return BidRejectionZeroValue
+	}
+	return ""
+}
+
+// requestBids requests bids from the relays, and returns the most profitable valid bid
+func (m *BoostService) requestBids(ctx context.Context, log *logrus.Entry, slot uint64, parentHashHex, pubkey string, ua UserAgent) getHeaderResult {
+	// Bids must be for the fork active at the requested slot, which matters at fork boundaries
+	expectedVersion := m.forkAtSlot(slot)
+
+	var mu sync.Mutex
+	relays := make(map[string][]string) // relays per blockHash
+	best := bidResp{}
+	var bestValue *big.Int // value of the best bid in the bid selection, reduced by the margin for untrusted relays
+	rejections := []bidRejection{}
+	validBids := []bidRejection{}                  // valid bids, which are rejected for lower value if not selected
+	provenance := make(map[string][]bidProvenance) // provenance per blockHash
+	trace := m.slotTracer.get(slot, m.clock.Now())
+	start := m.clock.Now()
+	budget := m.newValidationBudget(start)
+	policies := m.bidPolicies(pubkey)
+	minBid, minBidReason := m.proposerMinBid(pubkey)
+
+	// Call the proposer's relays of the request's experiment cohort, except those in a maintenance window
+	cohort, activeRelays := m.cohortRelays(slot, pubkey, m.proposerRelays(pubkey, m.activeRelays(m.clock.Now())))
 	var wg sync.WaitGroup
-	for _, relay := range m.relays {
+	var numRelaysResponded uint32
+	numRelaysCalled := 0 // relays with support for the fork, which the coverage is of
+	for _, relay := range activeRelays {
+		if !m.relayCapabilities.get(relay.String()).SupportsFork(expectedVersion) {
+			log.WithField("relay", relay.String()).Debugf("skipping relay without support for %s", expectedVersion)
+			continue
+		}
+
+		numRelaysCalled++
 		wg.Add(1)
 		go func(relay RelayEntry) {
 			defer wg.Done()
-			path := fmt.Sprintf("/eth/v1/builder/header/%s/%s/%s", slot, parentHashHex, pubkey)
+			defer atomic.AddUint32(&numRelaysResponded, 1)
+			path := fmt.Sprintf("/eth/v1/builder/header/%d/%s/%s", slot, parentHashHex, pubkey)
 			url := relay.GetURI(path)
-			log := log.WithField("url", url)
-			responsePayload := new(types.GetHeaderResponse)
-			code, err := SendHTTPRequest(context.Background(), m.httpClient, http.MethodGet, url, ua, nil, responsePayload)
+			log := relayLog(log, relay, url)
+			responsePayload := new(GetHeaderResponse)
+			requestedAt := m.clock.Now()
+
+			// Publish the outcome of the relay's response as it arrives, eg. for live monitoring of the fan-out
+			event := relayBidEvent{Slot: slot, ParentHash: parentHashHex, Pubkey: pubkey, Relay: relay.String()}
+			defer func() {
+				m.events.publish(EventRelayBid, event)
+			}()
+			addRejection := func(rejection bidRejection) {
+				mu.Lock()
+				rejections = append(rejections, rejection)
+				mu.Unlock()
+				if event.Status == "" {
+					event.Status = relayBidRejected
+				}
+				event.Rejection = rejection.Reason
+			}
+
+			code, respHeader, err := m.getRelayHeader(ctx, relay, slot, parentHashHex, pubkey, ua, responsePayload)
+			receivedAt := m.clock.Now()
+			event.LatencyMs = receivedAt.Sub(requestedAt).Milliseconds()
+			trace.span(relay.String(), "getHeader", slotTraceCatRelay, requestedAt, receivedAt, relaySpanArgs(code, err))
+			if errors.Is(err, errResponseTooLarge) {
+				log.WithError(err).Warn("dropping oversized response from relay")
+				m.dropBid(relay, BidRejectionOversizedResponse)
+				addRejection(bidRejection{Relay: relay.String(), Reason: BidRejectionOversizedResponse})
+				return
+			}
+			if errors.Is(err, errSpecDeviation) {
+				log.WithError(err).Warn("rejecting relay response deviating from the builder spec")
+				m.bidRejections.add(relay.String(), BidRejectionSpecDeviation)
+				addRejection(bidRejection{Relay: relay.String(), Reason: BidRejectionSpecDeviation, Message: err.Error()})
+				return
+			}
 			if err != nil {
 				log.WithError(err).Warn("error making request to relay")
+				event.Status = relayBidError
+				m.publishRelayError(relay, relayEndpoint(path), err)
+				if message := relayErrorMessage(err); message != "" {
+					m.bidRejections.add(relay.String(), BidRejectionRelayError)
+					addRejection(bidRejection{Relay: relay.String(), Reason: BidRejectionRelayError, Message: message})
+				}
 				return
 			}
 
 			if code == http.StatusNoContent {
 				log.Debug("no-content response")
+				event.Status = relayBidNone
+				return
+			}
+
+			// Drop bids in excess of the relay's rate limit, before spending time on validation
+			if ok, _ := m.relayBidRateLimiter.allow(relay.String(), m.clock.Now()); !ok {
+				log.Warn("dropping bid in excess of the relay rate limit")
+				m.dropBid(relay, BidRejectionRateLimited)
+				addRejection(bidRejection{Relay: relay.String(), Reason: BidRejectionRateLimited})
 				return
 			}
 
 			// Skip if invalid payload
 			if responsePayload.Data == nil || responsePayload.Data.Message == nil || responsePayload.Data.Message.Header == nil || responsePayload.Data.Message.Header.BlockHash == nilHash {
+				m.bidRejections.add(relay.String(), BidRejectionInvalidResponse)
+				addRejection(bidRejection{Relay: relay.String(), Reason: BidRejectionInvalidResponse})
 				return
 			}
 
 			blockHash := responsePayload.Data.Message.Header.BlockHash.String()
+			rejectBid := func(reason BidRejectionReason) {
+				m.bidRejections.add(relay.String(), reason)
+				addRejection(bidRejection{
+					Relay:     relay.String(),
+					BlockHash: blockHash,
+					Value:     responsePayload.Data.Message.Value.String(),
+					Reason:    reason,
+				})
+			}
 			log = log.WithFields(logrus.Fields{
 				"blockNumber": responsePayload.Data.Message.Header.BlockNumber,
 				"blockHash":   blockHash,
@@ -326,41 +1574,76 @@ func (m *BoostService) handleGetHeader(w http.ResponseWriter, req *http.Request)
 				"value":       responsePayload.Data.Message.Value.String(),
 			})
 
-			if relay.PublicKey != responsePayload.Data.Message.Pubkey {
-				log.Errorf("bid pubkey mismatch. expected: %s - got: %s", relay.PublicKey.String(), responsePayload.Data.Message.Pubkey.String())
+			// Normalize the value to wei for comparison, as some relays report values in gwei
+			valueWei := normalizeBidValue(&responsePayload.Data.Message.Value, relay.ValueUnit)
+			value := m.effectiveBidValue(valueWei, relay)
+			event.BlockHash = blockHash
+			event.Value = valueWei.String()
+
+			// Drop bids below the relay's minimum value, before spending time on signature verification
+			if isBelowMinBidValue(relay, valueWei) {
+				log.Debug("dropping bid below the relay's minimum value")
+				m.metrics.relayDroppedBids.WithLabelValues(relay.String(), string(BidRejectionBelowMinValue)).Inc()
+				rejectBid(BidRejectionBelowMinValue)
 				return
 			}
 
-			// Verify the relay signature in the relay response
-			ok, err := types.VerifySignature(responsePayload.Data.Message, m.builderSigningDomain, relay.PublicKey[:], responsePayload.Data.Signature[:])
-			if err != nil {
-				log.WithError(err).Error("error verifying relay signature")
+			// Drop bids below the proposer's minimum value, for which the proposer rather builds the block locally
+			if minBid != nil && valueWei.Cmp(minBid) < 0 {
+				log.Debug("dropping bid below the proposer's minimum value")
+				m.metrics.relayDroppedBids.WithLabelValues(relay.String(), string(minBidReason)).Inc()
+				rejectBid(minBidReason)
 				return
 			}
-			if !ok {
-				log.Error("failed to verify relay signature")
+
+			// Drop bids not satisfying the bid policies, which are cheaper to evaluate than the signature
+			if !m.allowedByBidPolicies(log, policies, &bidPolicyEnv{slot: slot, proposer: pubkey, relay: relay, valueWei: valueWei, bid: responsePayload.Data.Message}) {
+				m.metrics.relayDroppedBids.WithLabelValues(relay.String(), string(BidRejectionPolicy)).Inc()
+				rejectBid(BidRejectionPolicy)
 				return
 			}
 
-			// Verify response coherence with proposer's input data
-			responseParentHash := responsePayload.Data.Message.Header.ParentHash.String()
-			if responseParentHash != parentHashHex {
-				log.WithFields(logrus.Fields{
-					"originalParentHash": parentHashHex,
-					"responseParentHash": responseParentHash,
-				}).Error("proposer and relay parent hashes are not the same")
-				return
+			// Skip validating bids losing to the best bid validated so far if the time left is insufficient to
+			// validate them all, so the bids which can still win are validated in time
+			if budget.exhausted(m.clock.Now()) {
+				mu.Lock()
+				skip := canSkipValidation(value, bestValue, blockHash, best.blockHash)
+				mu.Unlock()
+				if skip {
+					log.Debug("skipping validation of a losing bid, out of validation budget")
+					m.metrics.relayDroppedBids.WithLabelValues(relay.String(), string(BidRejectionValidationSkipped)).Inc()
+					rejectBid(BidRejectionValidationSkipped)
+					return
+				}
 			}
 
-			isZeroValue := responsePayload.Data.Message.Value.String() == "0"
-			isEmptyListTxRoot := responsePayload.Data.Message.Header.TransactionsRoot.String() == "0x7ffe241ea60187fdb0187bfa22de35d1f9bed7ab061d9401fd47e34a54fbede1"
-			if isZeroValue || isEmptyListTxRoot {
-				log.Warn("ignoring bid with 0 value")
+			validationDone := budget.start()
+			releaseValidation := m.bidValidationsPool.acquire()
+			validationStart := m.clock.Now()
+			reason := m.validateBid(log, relay, slot, parentHashHex, responsePayload)
+			validationTime := m.clock.Now().Sub(validationStart)
+			releaseValidation()
+			validationDone(validationTime)
+			trace.span(relay.String(), "validateBid", slotTraceCatValidation, validationStart, validationStart.Add(validationTime), map[string]any{"blockHash": blockHash, "rejection": string(reason)})
+			if reason != "" {
+				rejectBid(reason)
 				return
 			}
 
+			if isImplausibleBidValue(valueWei) {
+				log.WithField("valueWei", valueWei.String()).Error("implausibly low bid value, the relay might report values in gwei. check the relay value unit configuration")
+			}
+
 			mu.Lock()
 			defer mu.Unlock()
+			event.Status = relayBidValid
+
+			validBids = append(validBids, bidRejection{
+				Relay:     relay.String(),
+				BlockHash: blockHash,
+				Value:     responsePayload.Data.Message.Value.String(),
+				Reason:    BidRejectionLowerValue,
+			})
 
 			// Remember which relays delivered which bids (multiple relays might deliver the top bid)
 			if _, ok := relays[blockHash]; !ok {
@@ -368,14 +1651,16 @@ func (m *BoostService) handleGetHeader(w http.ResponseWriter, req *http.Request)
 			} else {
 				relays[blockHash] = append(relays[blockHash], relay.String())
 			}
+			provenance[blockHash] = append(provenance[blockHash], newBidProvenance(relay, responsePayload, respHeader, requestedAt, receivedAt, validationTime))
 
-			// Compare the bid with already known top bid (if any)
-			if result.response.Data != nil {
-				valueDiff := responsePayload.Data.Message.Value.Cmp(&result.response.Data.Message.Value)
+			// Compare the bid with already known top bid (if any). A bid delivered by trusted and untrusted relays
+			// keeps the higher value of the trusted relays.
+			if best.response.Data != nil {
+				valueDiff := value.Cmp(bestValue)
 				if valueDiff == -1 { // current bid is less profitable than already known one
 					return
 				} else if valueDiff == 0 { // current bid is equally profitable as already known one. Use hash as tiebreaker
-					previousBidBlockHash := result.response.Data.Message.Header.BlockHash.String()
+					previousBidBlockHash := best.response.Data.Message.Header.BlockHash.String()
 					if blockHash >= previousBidBlockHash {
 						return
 					}
@@ -384,79 +1669,225 @@ func (m *BoostService) handleGetHeader(w http.ResponseWriter, req *http.Request)
 
 			// Use this relay's response as mev-boost response because it's most profitable
 			log.Debug("received a good bid")
-			result.response = *responsePayload
-			result.blockHash = blockHash
-			result.t = time.Now()
+			best.response = *responsePayload
+			best.blockHash = blockHash
+			best.valueWei = valueWei
+			best.t = m.clock.Now()
+			bestValue = value
+			event.Best = true
 		}(relay)
 	}
 
-	// Wait for all requests to complete...
-	wg.Wait()
+	// Wait for all requests to complete, or until the partial deadline is reached
+	isPartial := !m.waitForRelays(&wg)
+
+	// Copy the best bid, as relays still in flight after the partial deadline may update it
+	mu.Lock()
+	bestBid := best
+	bestBid.relays = relays[bestBid.blockHash]
+	bestBid.provenance = provenance[bestBid.blockHash]
+	bestBid.rejections = append([]bidRejection{}, rejections...)
+	for _, bid := range validBids {
+		if bid.BlockHash != bestBid.blockHash {
+			m.bidRejections.add(bid.Relay, BidRejectionLowerValue)
+			bestBid.rejections = append(bestBid.rejections, bid)
+		}
+	}
+	bestBid.decision = newDecisionHashes(slot, parentHashHex, pubkey, bestBid)
+	coverage := fmt.Sprintf("%d/%d", atomic.LoadUint32(&numRelaysResponded), numRelaysCalled)
+	numBids := len(validBids)
+	mu.Unlock()
+
+	m.events.publish(EventBidSelected, newBidSelectedEvent(slot, parentHashHex, pubkey, bestBid, coverage, isPartial))
+	trace.span(slotTraceMainThread, "requestBids", slotTraceCatRelay, start, m.clock.Now(), map[string]any{"coverage": coverage, "partial": isPartial})
+	trace.instant(slotTraceMainThread, "bid selected", slotTraceCatSelection, m.clock.Now(), map[string]any{
+		"blockHash":   bestBid.blockHash,
+		"relays":      bestBid.relays,
+		"numBids":     numBids,
+		"numRejected": len(bestBid.rejections),
+	})
 
-	if result.blockHash == "" {
-		log.Info("no bid received")
-		w.WriteHeader(http.StatusNoContent)
-		return
+	return getHeaderResult{bid: bestBid, isPartial: isPartial, coverage: coverage, numBids: numBids, cohort: cohort}
+}
+
+// activeRelays returns the relays which are not in a maintenance window at time t, nor drained
+func (m *BoostService) activeRelays(t time.Time) []RelayEntry {
+	relays := make([]RelayEntry, 0, len(m.relays))
+	inMaintenance := []RelayEntry{}
+	for _, relay := range m.relays {
+		if m.relayDrains.isDraining(relay.String()) {
+			m.log.WithField("relay", relay.String()).Debug("skipping drained relay")
+			continue
+		}
+		if relay.InMaintenance(t) {
+			m.log.WithField("relay", relay.String()).Debug("skipping relay in maintenance")
+			inMaintenance = append(inMaintenance, relay)
+			continue
+		}
+		relays = append(relays, relay)
 	}
 
-	// Log result
-	result.relays = relays[result.blockHash]
-	log.WithFields(logrus.Fields{
-		"blockHash":   result.blockHash,
-		"blockNumber": result.response.Data.Message.Header.BlockNumber,
-		"txRoot":      result.response.Data.Message.Header.TransactionsRoot.String(),
-		"value":       result.response.Data.Message.Value.String(),
-		"relays":      strings.Join(result.relays, ", "),
-	}).Info("best bid")
+	// Maintenance windows covering all relays would make getHeader fail for sure, rather call the relays anyway
+	if len(relays) == 0 && len(inMaintenance) > 0 {
+		m.metrics.configFallbacks.WithLabelValues(configRelayMaintenance).Inc()
+		m.log.Error("all relays are in maintenance, ignoring the maintenance windows")
+		return inMaintenance
+	}
+	return relays
+}
 
-	// Remember the bid, for future logging in case of withholding
-	bidKey := bidRespKey{slot: _slot, blockHash: result.blockHash}
-	m.bidsLock.Lock()
-	m.bids[bidKey] = result
-	m.bidsLock.Unlock()
+// waitForRelays waits for all relay requests to complete. If a partial deadline is configured, it returns
+// false when the deadline is reached before every relay responded.
+func (m *BoostService) waitForRelays(wg *sync.WaitGroup) bool {
+	if m.getHeaderPartialDeadline <= 0 {
+		wg.Wait()
+		return true
+	}
 
-	// Return the bid
-	m.respondOK(w, result.response)
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-m.clock.After(m.getHeaderPartialDeadline):
+		return false
+	}
 }
 
 func (m *BoostService) handleGetPayload(w http.ResponseWriter, req *http.Request) {
-	log := m.log.WithField("method", "getPayload")
+	start := m.clock.Now()
+	log := m.requestLog(req).WithField("method", "getPayload")
 	log.Debug("getPayload")
 
-	payload := new(types.SignedBlindedBeaconBlock)
-	if err := DecodeJSON(req.Body, &payload); err != nil {
+	if err := m.checkRequestSpec(req); err != nil {
+		m.respondError(w, http.StatusUnsupportedMediaType, err.Error())
+		return
+	}
+
+	payload, err := decodeBlindedBlock(req)
+	if err != nil {
 		m.respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	if payload.Message == nil || payload.Message.Body == nil || payload.Message.Body.ExecutionPayloadHeader == nil {
+	if payload == nil || payload.Message == nil || payload.Message.Body == nil || payload.Message.Body.ExecutionPayloadHeader == nil {
 		m.respondError(w, http.StatusBadRequest, "missing parts of the payload")
 		return
 	}
 
 	log = log.WithField("blockHash", payload.Message.Body.ExecutionPayloadHeader.BlockHash.String())
+	expectedVersion := m.forkAtSlot(payload.Message.Slot)
+
+	// Get the original bid, which is unknown if getHeader was not served by this instance
+	bidKey := bidRespKey{slot: payload.Message.Slot, blockHash: payload.Message.Body.ExecutionPayloadHeader.BlockHash.String()}
+	m.bidsLock.Lock()
+	originalBid := m.bids[bidKey]
+	m.bidsLock.Unlock()
+	trace := m.slotTracer.get(payload.Message.Slot, start)
+	deadline := m.payloadDeadline(req.Context(), payload.Message.Slot)
+
 	var wg sync.WaitGroup
 	var mu sync.Mutex
-	result := new(types.GetPayloadResponse)
+	result := new(GetPayloadResponse)
+	deliveredBy := ""
+	relayMessages := make(map[string]string) // error messages supplied by the relays
 	ua := UserAgent(req.Header.Get("User-Agent"))
 
 	// Prepare the request context, which will be cancelled after the first successful response from a relay
-	requestCtx, requestCtxCancel := context.WithCancel(context.Background())
+	requestCtx, requestCtxCancel := context.WithCancel(relayContext(req))
 	defer requestCtxCancel()
 
-	for _, relay := range m.relays {
+	// Alert if no payload is received within the delivery SLA, counting the relays which did not respond yet
+	pendingRelays := make(map[string]bool) // relays called which did not respond yet
+	if m.payloadDeliverySLA > 0 && !originalBid.servedAt.IsZero() {
+		stopSLATimer := m.clock.AfterFunc(originalBid.servedAt.Add(m.payloadDeliverySLA).Sub(m.clock.Now()), func() {
+			mu.Lock()
+			defer mu.Unlock()
+			if result.Data == nil {
+				relays := make([]string, 0, len(pendingRelays))
+				for relay := range pendingRelays {
+					relays = append(relays, relay)
+				}
+				m.payloadAtRisk(log, payload.Message.Slot, originalBid, relays)
+			}
+		})
+		defer stopSLATimer()
+	}
+
+	// Call the relays which delivered the bid first, each after a stagger if configured, or one after another if
+	// concurrent calls are disabled
+	concurrent := m.features.Enabled(FeatureConcurrentGetPayload)
+	for i, relay := range m.getPayloadRelays(originalBid) {
 		wg.Add(1)
-		go func(relay RelayEntry) {
+		go func(relay RelayEntry, stagger time.Duration) {
 			defer wg.Done()
 			url := relay.GetURI(pathGetPayload)
-			log := log.WithField("url", url)
+			log := relayLog(log, relay, url)
+
+			if stagger > 0 {
+				select {
+				case <-m.clock.After(stagger):
+				case <-requestCtx.Done(): // another relay already delivered the payload
+					return
+				}
+			}
 			log.Debug("calling getPayload")
 
-			responsePayload := new(types.GetPayloadResponse)
-			_, err := SendHTTPRequest(requestCtx, m.httpClient, http.MethodPost, url, ua, payload, responsePayload)
+			mu.Lock()
+			pendingRelays[relay.String()] = true
+			mu.Unlock()
+
+			responsePayload := new(GetPayloadResponse)
+			stats := responseStats{}
+			requestedAt := m.clock.Now()
+			code, header, err := sendHTTPRequest(requestCtx, m.relayClient(relay), http.MethodPost, url, ua, payload, responsePayload, m.relayPayloadResponseOpts(relay, &stats))
+			respondedAt := m.clock.Now()
+			m.recordIdempotency(relay, header)
+			trace.span(relay.String(), "getPayload", slotTraceCatRelay, requestedAt, respondedAt, relaySpanArgs(code, err))
 
+			mu.Lock()
+			delete(pendingRelays, relay.String())
+			mu.Unlock()
+
+			// Publish the outcome of the response, unless the call was cancelled after another relay delivered the
+			// payload
+			event := payloadFetchedEvent{
+				Slot:      payload.Message.Slot,
+				BlockHash: payload.Message.Body.ExecutionPayloadHeader.BlockHash.String(),
+				Relay:     relay.String(),
+				Status:    payloadFetchedInvalid,
+				LatencyMs: respondedAt.Sub(requestedAt).Milliseconds(),
+			}
+			if err != nil {
+				event.Status = payloadFetchedError
+			}
+			if !originalBid.servedAt.IsZero() {
+				event.delay = respondedAt.Sub(originalBid.servedAt)
+				event.DelayMs = event.delay.Milliseconds()
+			}
+			defer func() {
+				if !errors.Is(err, context.Canceled) {
+					m.events.publish(EventPayloadFetched, event)
+				}
+			}()
+			log = log.WithFields(logrus.Fields{"responseSize": stats.Size, "responseDigest": stats.Digest})
+
+			if errors.Is(err, errResponseTooLarge) {
+				log.WithError(err).Error("dropping oversized payload from relay")
+				return
+			}
 			if err != nil {
 				log.WithError(err).Error("error making request to relay")
+				m.publishRelayError(relay, relayEndpoint(pathGetPayload), err)
+				if message := relayErrorMessage(err); message != "" {
+					mu.Lock()
+					relayMessages[relay.URL.Host] = message
+					mu.Unlock()
+				}
 				return
 			}
 
@@ -465,6 +1896,18 @@ func (m *BoostService) handleGetPayload(w http.ResponseWriter, req *http.Request
 				return
 			}
 
+			if responsePayload.Version == "" {
+				if err := m.recordRelaySpecDeviation(relay, specDeviationMissingVersion); err != nil {
+					log.WithError(err).Error("rejecting relay response deviating from the builder spec")
+					return
+				}
+			}
+
+			if expectedVersion != "" && string(responsePayload.Version) != expectedVersion {
+				log.Errorf("payload version mismatch. expected: %s - got: %s", expectedVersion, responsePayload.Version)
+				return
+			}
+
 			// Ensure the response blockhash matches the request
 			if payload.Message.Body.ExecutionPayloadHeader.BlockHash != responsePayload.Data.BlockHash {
 				log.WithFields(logrus.Fields{
@@ -473,6 +1916,29 @@ func (m *BoostService) handleGetPayload(w http.ResponseWriter, req *http.Request
 				return
 			}
 
+			// Ensure the withdrawals of the payload match the signed header, which is cheap with few withdrawals
+			if err := verifyWithdrawalsRoot(payload.Message.Body.ExecutionPayloadHeader, responsePayload.Data); err != nil {
+				log.WithError(err).Error("payload withdrawals do not match the signed header")
+				return
+			}
+
+			// Ensure the payload matches the signed header, which costs a few ms of hashing for large payloads. The
+			// check is optional for trusted relays, and may be skipped close to the deadline, except for relays in
+			// escrow verification mode.
+			if relay.Untrusted || relay.EscrowVerification || (m.verifyPayloadRoots && !m.skipPayloadCheck(log, payloadCheckRoots, deadline)) {
+				verificationStart := m.clock.Now()
+				err := m.runPayloadCheck(payloadCheckRoots, func() error {
+					return verifyPayloadRoots(payload.Message.Body.ExecutionPayloadHeader, responsePayload.Data)
+				})
+				trace.span(relay.String(), "verifyPayloadRoots", slotTraceCatValidation, verificationStart, m.clock.Now(), map[string]any{"valid": err == nil})
+				if err != nil {
+					log.WithError(err).Error("payload does not match the signed header")
+					return
+				}
+			}
+
+			event.Status = payloadFetchedValid
+
 			// Lock before accessing the shared payload
 			mu.Lock()
 			defer mu.Unlock()
@@ -484,25 +1950,141 @@ func (m *BoostService) handleGetPayload(w http.ResponseWriter, req *http.Request
 			// Received successful response. Now cancel other requests and return immediately
 			requestCtxCancel()
 			*result = *responsePayload
+			deliveredBy = relay.String()
 			log.Info("received payload from relay")
-		}(relay)
+		}(relay, time.Duration(i)*m.getPayloadStagger)
+
+		if !concurrent {
+			wg.Wait()
+			if requestCtx.Err() != nil { // the relay delivered the payload
+				break
+			}
+		}
 	}
 
 	// Wait for all requests to complete...
 	wg.Wait()
 
+	// Emit the summary of the slot
+	delivered := result.Data != nil && result.Data.BlockHash != nilHash
+	trace.span(slotTraceMainThread, "getPayload", slotTraceCatHandler, start, m.clock.Now(), map[string]any{"delivered": delivered})
+	if outcome := m.slotOutcomes.payloadServed(payload.Message.Slot, delivered, m.clock.Now()); outcome != nil {
+		m.emitSlotOutcome(outcome)
+	}
+
+	// Contribute the auction to relay monitors, as evidence in case the payload was not delivered
+	if len(m.relayMonitors) > 0 && originalBid.response.Data != nil {
+		m.sendAuctionTranscript(originalBid.response.Data, payload, auctionOutcome{Delivered: delivered, DeliveredBy: deliveredBy, Relays: originalBid.relays})
+	}
+
 	// If no payload has been received from relay, log loudly about withholding!
-	if result.Data == nil || result.Data.BlockHash == nilHash {
-		bidKey := bidRespKey{slot: payload.Message.Slot, blockHash: payload.Message.Body.ExecutionPayloadHeader.BlockHash.String()}
-		m.bidsLock.Lock()
-		originalResp := m.bids[bidKey]
-		m.bidsLock.Unlock()
-		log.WithField("relays", strings.Join(originalResp.relays, ", ")).Errorf("no payload received from relay -- withholding or network error --")
-		m.respondError(w, http.StatusBadGateway, errNoSuccessfulRelayResponse.Error())
+	if !delivered {
+		log.WithField("relays", strings.Join(originalBid.relays, ", ")).Errorf("no payload received from relay -- withholding or network error --")
+		m.respondError(w, http.StatusBadGateway, noSuccessfulRelayResponseMessage(relayMessages))
 		return
 	}
 
-	m.respondOK(w, result)
+	m.respondPayload(w, log.WithFields(logrus.Fields{"slot": payload.Message.Slot, "relay": deliveredBy}), result, acceptsSSZ(req))
+}
+
+// dropBid records a bid from a relay which was dropped without validation
+func (m *BoostService) dropBid(relay RelayEntry, reason BidRejectionReason) {
+	m.bidRejections.add(relay.String(), reason)
+	m.metrics.relayDroppedBids.WithLabelValues(relay.String(), string(reason)).Inc()
+}
+
+// getPayloadRelays returns the relays to call for the payload of a bid: the relays which delivered the bid first,
+// followed by the other relays, each by decreasing reliability score. Drained relays are only called for the bids they delivered, until their removal.
+func (m *BoostService) getPayloadRelays(bid bidResp) []RelayEntry {
+	delivered := make(map[string]bool, len(bid.relays))
+	for _, relay := range bid.relays {
+		delivered[relay] = true
+	}
+
+	now := m.clock.Now()
+	liveRelays := m.liveRelays(now)
+	relays := make([]RelayEntry, 0, len(liveRelays))
+	var fallbackRelays []RelayEntry
+	for _, relay := range liveRelays {
+		if delivered[relay.String()] {
+			relays = append(relays, relay)
+		} else if !m.relayDrains.isDraining(relay.String()) {
+			fallbackRelays = append(fallbackRelays, relay)
+		}
+	}
+	m.relayScores.sort(relays, now)
+	m.relayScores.sort(fallbackRelays, now)
+	relays = append(relays, fallbackRelays...)
+
+	// Proposers requiring strict relay isolation only get their payloads from their own relays
+	if m.proposerConfig.settings(bid.pubkey).GetPayloadFallback == GetPayloadFallbackStrict {
+		return m.proposerRelays(bid.pubkey, relays)
+	}
+	return relays
+}
+
+// handleMevBoostStatus returns the status of mev-boost and its relays
+func (m *BoostService) handleMevBoostStatus(w http.ResponseWriter, req *http.Request) {
+	now := m.clock.Now()
+	resp := mevBoostStatusResponse{
+		Version:  config.Version,
+		Features: m.features.Active(),
+		Relays:   make([]relayStatus, 0, len(m.relays)),
+
+		Annotations: m.annotations.active("", now),
+		Telemetry:   m.telemetry.status(),
+	}
+	for _, relay := range m.relays {
+		score, probation := m.relayScores.get(relay.String(), now)
+		resp.Relays = append(resp.Relays, relayStatus{
+			URL:           relay.String(),
+			InMaintenance: relay.InMaintenance(now),
+			Draining:      m.relayDrains.isDraining(relay.String()),
+			Untrusted:     relay.Untrusted,
+			Tags:          relay.Tags,
+			Capabilities:  m.relayCapabilities.get(relay.String()),
+			Annotations:   m.annotations.active(relay.String(), now),
+			Idempotency:   m.idempotency.get(relay.String()),
+
+			CertificateExpiry: m.relayCertificates.expiry(relay.String()),
+			PubkeyVerified:    m.relayChallenges.get(relay.String()),
+
+			Score:     score,
+			Probation: probation,
+		})
+	}
+	m.respondOK(w, resp)
+}
+
+// handleDebugBids returns the recently served bids with the rejected bids of each slot, and the number of
+// rejections per relay and reason
+func (m *BoostService) handleDebugBids(w http.ResponseWriter, req *http.Request) {
+	m.respondOK(w, m.debugBids())
+}
+
+func (m *BoostService) debugBids() debugBidsResponse {
+	m.bidsLock.Lock()
+	bids := make([]debugBid, 0, len(m.bids))
+	for key, bid := range m.bids {
+		bids = append(bids, debugBid{
+			Slot:       key.slot,
+			Pubkey:     bid.pubkey,
+			BlockHash:  bid.blockHash,
+			Value:      bid.response.Data.Message.Value.String(),
+			Relays:     bid.relays,
+			Rejections: bid.rejections,
+			Decision:   bid.decision,
+			Provenance: bid.provenance,
+		})
+	}
+	m.bidsLock.Unlock()
+
+	sort.Slice(bids, func(i, j int) bool { return bids[i].Slot > bids[j].Slot })
+	return debugBidsResponse{
+		Bids:        bids,
+		Rejections:  m.bidRejections.snapshot(),
+		RelayErrors: m.relayErrors.snapshot(),
+	}
 }
 
 // CheckRelays sends a request to each one of the relays previously registered to get their status
@@ -511,8 +2093,12 @@ func (m *BoostService) CheckRelays() bool {
 		m.log.WithField("relay", relay.String()).Info("Checking relay")
 
 		url := relay.GetURI(pathStatus)
-		_, err := SendHTTPRequest(context.Background(), m.httpClient, http.MethodGet, url, "", nil, nil)
+		_, _, err := sendHTTPRequest(context.Background(), m.relayClient(relay), http.MethodGet, url, "", nil, nil, responseOpts{onTLS: m.onRelayTLS(relay)})
 		if err != nil {
+			if relay.InMaintenance(m.clock.Now()) {
+				m.log.WithError(err).WithField("relay", relay.String()).Warn("relay check failed, ignoring relay in maintenance")
+				continue
+			}
 			m.log.WithError(err).WithField("relay", relay.String()).Error("relay check failed")
 			return false
 		}
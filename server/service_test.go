@@ -3,6 +3,8 @@ package server
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"math"
@@ -14,18 +16,19 @@ import (
 	"time"
 
 	"github.com/flashbots/go-boost-utils/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 )
 
 type testBackend struct {
 	boost  *BoostService
-	relays []*mockRelay
+	relays []*MockRelay
 }
 
 // newTestBackend creates a new backend, initializes mock relays, registers them and return the instance
-func newTestBackend(t *testing.T, numRelays int, relayTimeout time.Duration) *testBackend {
+func newTestBackend(t testing.TB, numRelays int, relayTimeout time.Duration) *testBackend {
 	backend := testBackend{
-		relays: make([]*mockRelay, numRelays),
+		relays: make([]*MockRelay, numRelays),
 	}
 
 	relayEntries := make([]RelayEntry, numRelays)
@@ -70,7 +73,14 @@ func (be *testBackend) request(t *testing.T, method string, path string, payload
 
 func TestNewBoostServiceErrors(t *testing.T) {
 	t.Run("errors when no relays", func(t *testing.T) {
-		_, err := NewBoostService(BoostServiceOpts{testLog, ":123", []RelayEntry{}, "0x00000000", time.Second, true})
+		_, err := NewBoostService(BoostServiceOpts{
+			Log:                   testLog,
+			ListenAddr:            ":123",
+			Relays:                []RelayEntry{},
+			GenesisForkVersionHex: "0x00000000",
+			RelayRequestTimeout:   time.Second,
+			RelayCheck:            true,
+		})
 		require.Error(t, err)
 	})
 }
@@ -218,6 +228,20 @@ func TestRegisterValidator(t *testing.T) {
 		require.Equal(t, 3, backend.relays[1].GetRequestCount(path))
 	})
 
+	t.Run("Rate limited", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		backend.boost.registrationRateLimiter = newRateLimiter(0, 0.5, 1)
+
+		rr := backend.request(t, http.MethodPost, path, payload)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		rr = backend.request(t, http.MethodPost, path, payload)
+		require.Equal(t, http.StatusTooManyRequests, rr.Code)
+		require.Equal(t, `{"code":429,"message":"too many requests"}`+"\n", rr.Body.String())
+		require.Equal(t, "2", rr.Header().Get("Retry-After"))
+		require.Equal(t, 1, backend.relays[0].GetRequestCount(path))
+	})
+
 	t.Run("mev-boost relay timeout works with slow relay", func(t *testing.T) {
 		backend := newTestBackend(t, 1, 5*time.Millisecond) // 10ms max
 		rr := backend.request(t, http.MethodPost, path, payload)
@@ -356,6 +380,83 @@ func TestGetHeader(t *testing.T) {
 		require.Equal(t, "0xa18385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7", resp.Data.Message.Header.BlockHash.String())
 	})
 
+	t.Run("Compare values normalized to wei", func(t *testing.T) {
+		backend := newTestBackend(t, 2, time.Second)
+		backend.boost.relays[0].ValueUnit = ValueUnitGwei
+
+		// 2 gwei reported in gwei beats 1.5 gwei reported in wei
+		backend.relays[0].GetHeaderResponse = backend.relays[0].MakeGetHeaderResponse(
+			2,
+			"0xa18385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7",
+			"0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249",
+		)
+		backend.relays[1].GetHeaderResponse = backend.relays[1].MakeGetHeaderResponse(
+			1_500_000_000,
+			"0xa28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7",
+			"0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249",
+		)
+
+		rr := backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		resp := new(types.GetHeaderResponse)
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		require.Equal(t, "0xa18385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7", resp.Data.Message.Header.BlockHash.String())
+	})
+
+	t.Run("Bid timestamp must match the requested slot", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		backend.boost.genesisTime = 1606824023
+
+		// The default mock response has no timestamp
+		rr := backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+		require.Equal(t, uint64(1), backend.boost.bidRejections.snapshot()[backend.relays[0].RelayEntry.String()][BidRejectionTimestampMismatch])
+
+		resp := backend.relays[0].MakeGetHeaderResponse(
+			12345,
+			"0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7",
+			"0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249",
+		)
+		resp.Data.Message.Header.Timestamp = 1606824023 + SecondsPerSlot // slot 1
+		signature, err := types.SignMessage(resp.Data.Message, types.DomainBuilder, mockRelaySecretKey)
+		require.NoError(t, err)
+		resp.Data.Signature = signature
+		backend.relays[0].GetHeaderResponse = resp
+
+		rr = backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	})
+
+	t.Run("Bids in excess of the relay rate limit are dropped", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		backend.boost.relayBidRateLimiter = newRateLimiter(0, 0.001, 1)
+		relay := backend.relays[0].RelayEntry.String()
+
+		rr := backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		rr = backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+		require.Equal(t, 2, backend.relays[0].GetRequestCount(path))
+		require.Equal(t, uint64(1), backend.boost.bidRejections.snapshot()[relay][BidRejectionRateLimited])
+		require.Equal(t, float64(1), testutil.ToFloat64(backend.boost.metrics.relayDroppedBids.WithLabelValues(relay, string(BidRejectionRateLimited))))
+	})
+
+	t.Run("Oversized relay responses are dropped", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		backend.boost.relayMaxResponseSize = 100
+		relay := backend.relays[0].RelayEntry.String()
+
+		rr := backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+		require.Equal(t, uint64(1), backend.boost.bidRejections.snapshot()[relay][BidRejectionOversizedResponse])
+		require.Equal(t, float64(1), testutil.ToFloat64(backend.boost.metrics.relayDroppedBids.WithLabelValues(relay, string(BidRejectionOversizedResponse))))
+
+		backend.boost.relayMaxResponseSize = 1 << 20
+		rr = backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	})
+
 	t.Run("Invalid relay public key", func(t *testing.T) {
 		backend := newTestBackend(t, 1, time.Second)
 
@@ -395,6 +496,76 @@ func TestGetHeader(t *testing.T) {
 		require.Equal(t, http.StatusNoContent, rr.Code)
 	})
 
+	t.Run("Partial result after deadline", func(t *testing.T) {
+		backend := newTestBackend(t, 2, time.Second)
+		backend.boost.getHeaderPartialDeadline = 50 * time.Millisecond
+		backend.relays[1].ResponseDelay = 200 * time.Millisecond
+
+		rr := backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Equal(t, "1/2", rr.Header().Get(HeaderPartialResult))
+		require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.partialGetHeader.WithLabelValues("true")))
+
+		// Without slow relays, the result is not partial
+		backend = newTestBackend(t, 2, time.Second)
+		backend.boost.getHeaderPartialDeadline = 50 * time.Millisecond
+		rr = backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Equal(t, "", rr.Header().Get(HeaderPartialResult))
+	})
+
+	t.Run("Request deadline exceeded", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		backend.boost.requestTimeouts.GetHeader = 20 * time.Millisecond
+		backend.relays[0].ResponseDelay = 100 * time.Millisecond
+
+		rr := backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusGatewayTimeout, rr.Code)
+		require.Equal(t, `{"code":504,"message":"request timeout"}`+"\n", rr.Body.String())
+	})
+
+	t.Run("Relay in maintenance is skipped", func(t *testing.T) {
+		backend := newTestBackend(t, 2, time.Second)
+		backend.boost.relays[1].MaintenanceWindows = []MaintenanceWindow{{Start: time.Now().Add(-time.Hour), End: time.Now().Add(time.Hour)}}
+
+		rr := backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Equal(t, 1, backend.relays[0].GetRequestCount(path))
+		require.Equal(t, 0, backend.relays[1].GetRequestCount(path))
+	})
+
+	t.Run("Fork boundary slots", func(t *testing.T) {
+		lastBellatrixSlot := uint64(10*SlotsPerEpoch - 1)
+		firstCapellaSlot := uint64(10 * SlotsPerEpoch)
+
+		backend := newTestBackend(t, 1, time.Second)
+		backend.boost.forkSchedule = ForkSchedule{{Name: "bellatrix", Epoch: 0}, {Name: "capella", Epoch: 10}}
+
+		// A bellatrix bid is accepted for the last bellatrix slot, and rejected for the first capella slot
+		rr := backend.request(t, http.MethodGet, getPath(lastBellatrixSlot, hash, pubkey), nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		rr = backend.request(t, http.MethodGet, getPath(firstCapellaSlot, hash, pubkey), nil)
+		require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+
+		// A capella bid is rejected for the last bellatrix slot, and accepted for the first capella slot.
+		// The builder signing domain is the same across forks.
+		resp := backend.relays[0].MakeGetHeaderResponse(
+			12345,
+			"0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7",
+			"0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249",
+		)
+		resp.Version = "capella"
+		resp.Data.Message.Header.WithdrawalsRoot = &types.Root{0x01}
+		signature, err := types.SignMessage(resp.Data.Message, types.DomainBuilder, mockRelaySecretKey)
+		require.NoError(t, err)
+		resp.Data.Signature = signature
+		backend.relays[0].GetHeaderResponse = resp
+		rr = backend.request(t, http.MethodGet, getPath(lastBellatrixSlot, hash, pubkey), nil)
+		require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+		rr = backend.request(t, http.MethodGet, getPath(firstCapellaSlot, hash, pubkey), nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	})
+
 	t.Run("Invalid slot number", func(t *testing.T) {
 		// Number larger than uint64 creates parsing error
 		slot := fmt.Sprintf("%d0", uint64(math.MaxUint64))
@@ -437,6 +608,58 @@ func TestGetHeader(t *testing.T) {
 	})
 }
 
+func TestDebugBids(t *testing.T) {
+	hash := _HexToHash("0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7")
+	pubkey := _HexToPubkey(
+		"0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249")
+	path := fmt.Sprintf("/eth/v1/builder/header/%d/%s/%s", 1, hash.String(), pubkey.String())
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		rr := backend.request(t, http.MethodGet, pathDebugBids, nil)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Rejection reasons per relay", func(t *testing.T) {
+		backend := newTestBackend(t, 3, time.Second)
+		backend.boost.debugAPI = true
+
+		backend.relays[0].GetHeaderResponse = backend.relays[0].MakeGetHeaderResponse(
+			12345,
+			"0xa18385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7",
+			"0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249",
+		)
+		backend.relays[1].GetHeaderResponse = backend.relays[1].MakeGetHeaderResponse(
+			12347,
+			"0xa28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7",
+			"0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249",
+		)
+		backend.relays[2].GetHeaderResponse = backend.relays[2].MakeGetHeaderResponse(
+			12349,
+			"0xa38385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7",
+			"0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249",
+		)
+		backend.relays[2].GetHeaderResponse.Data.Signature = types.Signature{}
+
+		rr := backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		rr = backend.request(t, http.MethodGet, pathDebugBids, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		resp := new(debugBidsResponse)
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+
+		require.Len(t, resp.Bids, 1)
+		require.Equal(t, uint64(1), resp.Bids[0].Slot)
+		require.Equal(t, "12347", resp.Bids[0].Value)
+		require.Len(t, resp.Bids[0].Rejections, 2)
+
+		require.Equal(t, uint64(1), resp.Rejections[backend.relays[0].RelayEntry.String()][BidRejectionLowerValue])
+		require.Equal(t, uint64(1), resp.Rejections[backend.relays[2].RelayEntry.String()][BidRejectionInvalidSignature])
+		require.Len(t, resp.Rejections[backend.relays[1].RelayEntry.String()], 0)
+	})
+}
+
 func TestGetPayload(t *testing.T) {
 	path := "/eth/v1/builder/blinded_blocks"
 
@@ -469,15 +692,19 @@ func TestGetPayload(t *testing.T) {
 		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
 		require.Equal(t, 1, backend.relays[0].GetRequestCount(path))
 
-		resp := new(types.GetPayloadResponse)
+		resp := new(GetPayloadResponse)
 		err := json.Unmarshal(rr.Body.Bytes(), resp)
 		require.NoError(t, err)
 		require.Equal(t, payload.Message.Body.ExecutionPayloadHeader.BlockHash, resp.Data.BlockHash)
+
+		// The checksum covers the exact bytes delivered
+		digest := sha256.Sum256(rr.Body.Bytes())
+		require.Equal(t, "sha-256=:"+base64.StdEncoding.EncodeToString(digest[:])+":", rr.Header().Get(HeaderContentDigest))
 	})
 
 	t.Run("Bad response from relays", func(t *testing.T) {
 		backend := newTestBackend(t, 2, time.Second)
-		resp := new(types.GetPayloadResponse)
+		resp := new(GetPayloadResponse)
 
 		// 1/2 failing responses are okay
 		backend.relays[0].GetPayloadResponse = resp
@@ -495,6 +722,80 @@ func TestGetPayload(t *testing.T) {
 		require.Equal(t, `{"code":502,"message":"no successful relay response"}`+"\n", rr.Body.String())
 		require.Equal(t, http.StatusBadGateway, rr.Code, rr.Body.String())
 	})
+
+	t.Run("Staggered calls start with the relay which delivered the bid", func(t *testing.T) {
+		backend := newTestBackend(t, 2, time.Second)
+		backend.boost.getPayloadStagger = 500 * time.Millisecond
+		bidKey := bidRespKey{slot: 1, blockHash: payload.Message.Body.ExecutionPayloadHeader.BlockHash.String()}
+		backend.boost.bids[bidKey] = bidResp{relays: []string{backend.relays[1].RelayEntry.String()}}
+
+		rr := backend.request(t, http.MethodPost, path, payload)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Equal(t, 1, backend.relays[1].GetRequestCount(path))
+
+		// The valid payload cancels the staggered call to the other relay
+		time.Sleep(600 * time.Millisecond)
+		require.Equal(t, 0, backend.relays[0].GetRequestCount(path))
+	})
+
+	t.Run("Staggered calls fall back to the next relay", func(t *testing.T) {
+		backend := newTestBackend(t, 2, time.Second)
+		backend.boost.getPayloadStagger = 50 * time.Millisecond
+		backend.relays[0].GetPayloadResponse = new(GetPayloadResponse)
+
+		rr := backend.request(t, http.MethodPost, path, payload)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Equal(t, 1, backend.relays[0].GetRequestCount(path))
+		require.Equal(t, 1, backend.relays[1].GetRequestCount(path))
+	})
+}
+
+func TestGetPayloadForkBoundary(t *testing.T) {
+	path := "/eth/v1/builder/blinded_blocks"
+	schedule := ForkSchedule{{Name: "bellatrix", Epoch: 0}, {Name: "capella", Epoch: 10}}
+
+	makePayload := func(slot uint64, withdrawalsRoot *types.Root) *SignedBlindedBeaconBlock {
+		payload := makeTestBlindedBlock(&ExecutionPayloadHeader{
+			ExecutionPayloadHeader: types.ExecutionPayloadHeader{
+				BlockHash: _HexToHash("0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab1"),
+			},
+			WithdrawalsRoot: withdrawalsRoot,
+		})
+		payload.Message.Slot = slot
+		return payload
+	}
+
+	t.Run("Last bellatrix slot", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		backend.boost.forkSchedule = schedule
+		rr := backend.request(t, http.MethodPost, path, makePayload(10*SlotsPerEpoch-1, nil))
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	})
+
+	t.Run("First capella slot", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		backend.boost.forkSchedule = schedule
+
+		withdrawals := Withdrawals{{Index: 1, ValidatorIndex: 2, Amount: 3}}
+		withdrawalsRoot, err := withdrawals.HashTreeRoot()
+		require.NoError(t, err)
+
+		// The default mock response is a bellatrix payload, which is invalid for a capella slot
+		rr := backend.request(t, http.MethodPost, path, makePayload(10*SlotsPerEpoch, &withdrawalsRoot))
+		require.Equal(t, http.StatusBadGateway, rr.Code, rr.Body.String())
+
+		resp := backend.relays[0].MakeGetPayloadResponse(
+			"0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7",
+			"0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab1",
+			"0xdb65fEd33dc262Fe09D9a2Ba8F80b329BA25f941",
+			12345,
+		)
+		resp.Version = "capella"
+		resp.Data.Withdrawals = &withdrawals
+		backend.relays[0].GetPayloadResponse = resp
+		rr = backend.request(t, http.MethodPost, path, makePayload(10*SlotsPerEpoch, &withdrawalsRoot))
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	})
 }
 
 func TestCheckRelays(t *testing.T) {
@@ -512,6 +813,15 @@ func TestCheckRelays(t *testing.T) {
 		require.Equal(t, false, status)
 	})
 
+	t.Run("Relay in maintenance is ignored", func(t *testing.T) {
+		backend := newTestBackend(t, 2, time.Second)
+		backend.relays[1].Server.Close()
+		backend.boost.relays[1].MaintenanceWindows = []MaintenanceWindow{{Start: time.Now().Add(-time.Hour), End: time.Now().Add(time.Hour)}}
+
+		status := backend.boost.CheckRelays()
+		require.Equal(t, true, status)
+	})
+
 	t.Run("Should not follow redirects", func(t *testing.T) {
 		backend := newTestBackend(t, 1, time.Second)
 		redirectAddress := backend.relays[0].Server.URL
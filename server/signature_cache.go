@@ -0,0 +1,134 @@
+package server
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+
+	"github.com/flashbots/go-boost-utils/bls"
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// sigCacheKey identifies a signature verification
+type sigCacheKey struct {
+	pubkey      types.PublicKey
+	signingRoot [32]byte
+	signature   types.Signature
+}
+
+type sigCacheEntry struct {
+	key sigCacheKey
+	ok  bool
+}
+
+// signatureCache memoizes signature verification results in a bounded LRU, so identical bids (eg. on CL
+// retries or for multiple proposers) are not verified repeatedly
+type signatureCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[sigCacheKey]*list.Element
+	lru     *list.List
+
+	hits   uint64
+	misses uint64
+}
+
+// newSignatureCache creates a cache holding up to size results. A size of 0 disables caching.
+func newSignatureCache(size int) *signatureCache {
+	return &signatureCache{
+		size:    size,
+		entries: make(map[sigCacheKey]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// verify checks the signature of obj, using a cached result if the same verification was done before
+func (c *signatureCache) verify(obj types.HashTreeRoot, domain types.Domain, pubkey types.PublicKey, signature types.Signature) (bool, error) {
+	signingRoot, err := types.ComputeSigningRoot(obj, domain)
+	if err != nil {
+		return false, err
+	}
+	key := sigCacheKey{pubkey: pubkey, signingRoot: signingRoot, signature: signature}
+
+	if ok, found := c.get(key); found {
+		atomic.AddUint64(&c.hits, 1)
+		return ok, nil
+	}
+	atomic.AddUint64(&c.misses, 1)
+
+	ok, err := bls.VerifySignatureBytes(signingRoot[:], signature[:], pubkey[:])
+	if err != nil {
+		return false, err
+	}
+	c.add(key, ok)
+	return ok, nil
+}
+
+func (c *signatureCache) get(key sigCacheKey) (ok, found bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, found := c.entries[key]
+	if !found {
+		return false, false
+	}
+	c.lru.MoveToFront(el)
+	return el.Value.(*sigCacheEntry).ok, true
+}
+
+func (c *signatureCache) add(key sigCacheKey, ok bool) {
+	if c.size <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, found := c.entries[key]; found {
+		c.lru.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&sigCacheEntry{key: key, ok: ok})
+	if c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*sigCacheEntry).key)
+	}
+}
+
+// stats returns the number of cache hits and misses
+func (c *signatureCache) stats() (hits, misses uint64) {
+	return atomic.LoadUint64(&c.hits), atomic.LoadUint64(&c.misses)
+}
+
+// newSignatureCacheMetrics returns the counters of the signature cache hits and misses
+func newSignatureCacheMetrics(cache *signatureCache) []prometheus.Collector {
+	opts := func(result string) prometheus.CounterOpts {
+		return prometheus.CounterOpts{
+			Name:        "mev_boost_signature_cache_total",
+			Help:        "Number of bid signature verifications by cache result: hit (cached result) or miss (verified)",
+			ConstLabels: prometheus.Labels{"result": result},
+		}
+	}
+	return []prometheus.Collector{
+		prometheus.NewCounterFunc(opts("hit"), func() float64 {
+			hits, _ := cache.stats()
+			return float64(hits)
+		}),
+		prometheus.NewCounterFunc(opts("miss"), func() float64 {
+			_, misses := cache.stats()
+			return float64(misses)
+		}),
+	}
+}
+
+// verifySignature checks the signature of obj without using the cache
+func verifySignature(obj types.HashTreeRoot, domain types.Domain, pubkey types.PublicKey, signature types.Signature) (bool, error) {
+	signingRoot, err := types.ComputeSigningRoot(obj, domain)
+	if err != nil {
+		return false, err
+	}
+	return bls.VerifySignatureBytes(signingRoot[:], signature[:], pubkey[:])
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignatureCache(t *testing.T) {
+	relay := newMockRelay(t)
+	bid := relay.MakeGetHeaderResponse(
+		12345,
+		"0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7",
+		"0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249",
+	)
+	pubkey := relay.RelayEntry.PublicKey
+
+	t.Run("Memoizes results", func(t *testing.T) {
+		cache := newSignatureCache(10)
+		ok, err := cache.verify(bid.Data.Message, types.DomainBuilder, pubkey, bid.Data.Signature)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		ok, err = cache.verify(bid.Data.Message, types.DomainBuilder, pubkey, bid.Data.Signature)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		// Malformed signatures are not cached
+		ok, err = cache.verify(bid.Data.Message, types.DomainBuilder, pubkey, types.Signature{})
+		require.Error(t, err)
+		require.False(t, ok)
+
+		hits, misses := cache.stats()
+		require.Equal(t, uint64(1), hits)
+		require.Equal(t, uint64(2), misses)
+
+		metrics := newSignatureCacheMetrics(cache)
+		require.Equal(t, 1.0, testutil.ToFloat64(metrics[0]))
+		require.Equal(t, 2.0, testutil.ToFloat64(metrics[1]))
+	})
+
+	t.Run("Evicts least recently used", func(t *testing.T) {
+		cache := newSignatureCache(1)
+		otherBid := relay.MakeGetHeaderResponse(
+			12346,
+			"0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7",
+			"0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249",
+		)
+
+		_, err := cache.verify(bid.Data.Message, types.DomainBuilder, pubkey, bid.Data.Signature)
+		require.NoError(t, err)
+		_, err = cache.verify(otherBid.Data.Message, types.DomainBuilder, pubkey, otherBid.Data.Signature)
+		require.NoError(t, err)
+		_, err = cache.verify(bid.Data.Message, types.DomainBuilder, pubkey, bid.Data.Signature)
+		require.NoError(t, err)
+
+		hits, misses := cache.stats()
+		require.Equal(t, uint64(0), hits)
+		require.Equal(t, uint64(3), misses)
+		require.Equal(t, 1, cache.lru.Len())
+	})
+
+	t.Run("Metrics of the service", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		count, err := testutil.GatherAndCount(backend.boost.metrics.registry, "mev_boost_signature_cache_total")
+		require.NoError(t, err)
+		require.Equal(t, 2, count) // hit and miss
+	})
+
+	t.Run("Disabled cache", func(t *testing.T) {
+		cache := newSignatureCache(0)
+		for i := 0; i < 2; i++ {
+			ok, err := cache.verify(bid.Data.Message, types.DomainBuilder, pubkey, bid.Data.Signature)
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+
+		hits, misses := cache.stats()
+		require.Equal(t, uint64(0), hits)
+		require.Equal(t, uint64(2), misses)
+	})
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// slotOutcome summarizes a proposer slot handled by mev-boost, from getHeader to getPayload
+type slotOutcome struct {
+	Slot             uint64
+	Pubkey           string
+	NumBids          int
+	Relays           []string // relays which delivered the served bid
+	Annotations      []uint64 // IDs of the operator annotations active for the relays when the outcome was emitted
+	BlockHash        string
+	Value            string // value of the served bid [wei]
+	PayloadDelivered bool
+	Latency          time.Duration // from the first getHeader call to the getPayload response
+	Decision         decisionHashes
+	Cohort           string // experiment cohort, if any
+
+	start time.Time
+}
+
+func (o *slotOutcome) relaysString() string {
+	return strings.Join(o.Relays, ",")
+}
+
+// slotOutcomeTracker collects the outcome of slots until the payload is delivered or the slot expires
+type slotOutcomeTracker struct {
+	mu       sync.Mutex
+	outcomes map[uint64]*slotOutcome
+}
+
+func newSlotOutcomeTracker() *slotOutcomeTracker {
+	return &slotOutcomeTracker{outcomes: make(map[uint64]*slotOutcome)}
+}
+
+// headerServed records the bid served for a slot. Repeated getHeader calls update the bid, but keep the start time.
+func (t *slotOutcomeTracker) headerServed(slot uint64, pubkey string, result getHeaderResult, start time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	outcome, ok := t.outcomes[slot]
+	if !ok {
+		outcome = &slotOutcome{Slot: slot, start: start}
+		t.outcomes[slot] = outcome
+	}
+	outcome.Pubkey = pubkey
+	outcome.NumBids = result.numBids
+	outcome.Relays = result.bid.relays
+	outcome.BlockHash = result.bid.blockHash
+	outcome.Decision = result.bid.decision
+	outcome.Cohort = result.cohort
+	outcome.Value = ""
+	if result.bid.valueWei != nil {
+		outcome.Value = result.bid.valueWei.String()
+	}
+}
+
+// payloadServed completes the outcome of a slot, and returns it. It returns nil if no header was served for the slot.
+func (t *slotOutcomeTracker) payloadServed(slot uint64, delivered bool, now time.Time) *slotOutcome {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	outcome, ok := t.outcomes[slot]
+	if !ok {
+		return nil
+	}
+	delete(t.outcomes, slot)
+	outcome.PayloadDelivered = delivered
+	outcome.Latency = now.Sub(outcome.start)
+	return outcome
+}
+
+// expire removes and returns the outcomes of slots which started more than maxAge before now without getPayload call
+func (t *slotOutcomeTracker) expire(now time.Time, maxAge time.Duration) []*slotOutcome {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	expired := []*slotOutcome{}
+	for slot, outcome := range t.outcomes {
+		if now.Sub(outcome.start) > maxAge {
+			delete(t.outcomes, slot)
+			expired = append(expired, outcome)
+		}
+	}
+	return expired
+}
+
+// emitSlotOutcome logs a single summary record of the slot, and exports it as metrics
+func (m *BoostService) emitSlotOutcome(o *slotOutcome) {
+	log := m.log
+	if o.Cohort != "" {
+		log = log.WithField("cohort", o.Cohort)
+	}
+	if o.Annotations = m.annotations.activeIDs(o.Relays, m.clock.Now()); len(o.Annotations) > 0 {
+		log = log.WithField("annotations", formatAnnotationIDs(o.Annotations))
+	}
+	log.WithFields(logrus.Fields{
+		"slot":             o.Slot,
+		"pubkey":           o.Pubkey,
+		"numBids":          o.NumBids,
+		"relays":           o.relaysString(),
+		"blockHash":        o.BlockHash,
+		"value":            o.Value,
+		"payloadDelivered": o.PayloadDelivered,
+		"latencyMs":        o.Latency.Milliseconds(),
+		"inputsHash":       o.Decision.Inputs,
+		"decisionHash":     o.Decision.Decision,
+	}).Info("slot outcome")
+	m.metrics.observeSlotOutcome(o)
+	m.dataExporter.addOutcome(m.clock.Now(), o)
+
+	if trace := m.slotTracer.remove(o.Slot); trace != nil {
+		go m.writeSlotTrace(trace)
+	}
+
+	if m.bidOracle != nil {
+		go m.compareWithBidOracle(o)
+	}
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlotOutcomeTracker(t *testing.T) {
+	tracker := newSlotOutcomeTracker()
+	start := time.Now()
+
+	result := getHeaderResult{bid: bidResp{blockHash: "0x01", valueWei: big.NewInt(5), relays: []string{"relay"}}, numBids: 2}
+	tracker.headerServed(1, "0xab", result, start)
+	tracker.headerServed(1, "0xab", result, start.Add(time.Second)) // CL retry keeps the start time
+	tracker.headerServed(2, "0xcd", getHeaderResult{}, start)
+
+	require.Nil(t, tracker.payloadServed(3, true, start))
+
+	outcome := tracker.payloadServed(1, true, start.Add(2*time.Second))
+	require.NotNil(t, outcome)
+	require.Equal(t, uint64(1), outcome.Slot)
+	require.Equal(t, "0xab", outcome.Pubkey)
+	require.Equal(t, 2, outcome.NumBids)
+	require.Equal(t, "5", outcome.Value)
+	require.True(t, outcome.PayloadDelivered)
+	require.Equal(t, 2*time.Second, outcome.Latency)
+	require.Nil(t, tracker.payloadServed(1, true, start))
+
+	require.Empty(t, tracker.expire(start.Add(30*time.Second), time.Minute))
+	expired := tracker.expire(start.Add(2*time.Minute), time.Minute)
+	require.Len(t, expired, 1)
+	require.Equal(t, uint64(2), expired[0].Slot)
+	require.False(t, expired[0].PayloadDelivered)
+}
+
+func TestSlotOutcomeMetrics(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	backend := newTestBackend(t, 1, time.Second)
+
+	rr := backend.request(t, http.MethodGet, fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey), nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	payload := types.SignedBlindedBeaconBlock{
+		Message: &types.BlindedBeaconBlock{
+			Slot: 1,
+			Body: &types.BlindedBeaconBlockBody{
+				Eth1Data:      &types.Eth1Data{},
+				SyncAggregate: &types.SyncAggregate{},
+				ExecutionPayloadHeader: &types.ExecutionPayloadHeader{
+					BlockHash: _HexToHash("0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab1"),
+				},
+			},
+		},
+	}
+	rr = backend.request(t, http.MethodPost, pathGetPayload, payload)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	rr = backend.request(t, http.MethodGet, pathMetrics, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Contains(t, rr.Body.String(), `mev_boost_slots_total{payload_delivered="true"} 1`)
+	decisionHash := backend.boost.debugBids().Bids[0].Decision.Decision
+	require.Contains(t, rr.Body.String(), fmt.Sprintf(`mev_boost_slot_outcome_info{block_hash="%s",decision_hash="%s",num_bids="1",payload_delivered="true",pubkey="%s",relays="%s",slot="1",value="12345"} 1`, hash, decisionHash, pubkey, backend.relays[0].RelayEntry.String()))
+}
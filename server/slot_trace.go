@@ -0,0 +1,215 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Categories of the slot trace events
+const (
+	slotTraceCatHandler    = "handler"
+	slotTraceCatRelay      = "relay"
+	slotTraceCatValidation = "validation"
+	slotTraceCatSelection  = "selection"
+
+	// slotTraceMainThread is the trace thread of the builder API handlers. Relay requests have a thread per relay.
+	slotTraceMainThread = "mev-boost"
+)
+
+// slotTraceEvent is an event in the Chrome trace event format, as displayed by Perfetto and chrome://tracing
+type slotTraceEvent struct {
+	Name  string         `json:"name"`
+	Cat   string         `json:"cat,omitempty"`
+	Ph    string         `json:"ph"`
+	Ts    int64          `json:"ts"`            // [us]
+	Dur   int64          `json:"dur,omitempty"` // [us]
+	Pid   int            `json:"pid"`
+	Tid   int            `json:"tid"`
+	Scope string         `json:"s,omitempty"`
+	Args  map[string]any `json:"args,omitempty"`
+}
+
+// slotTraceFile is the content of a slot trace file
+type slotTraceFile struct {
+	TraceEvents     []slotTraceEvent `json:"traceEvents"`
+	DisplayTimeUnit string           `json:"displayTimeUnit"`
+	OtherData       map[string]any   `json:"otherData"`
+}
+
+// slotTrace collects the spans of the requests and processing phases of a slot. All methods are no-ops on a nil
+// trace, which is returned when tracing is disabled.
+type slotTrace struct {
+	mu      sync.Mutex
+	slot    uint64
+	start   time.Time
+	threads map[string]int // trace thread ID per name
+	events  []slotTraceEvent
+}
+
+func newSlotTrace(slot uint64, start time.Time) *slotTrace {
+	t := &slotTrace{slot: slot, start: start, threads: make(map[string]int)}
+	t.thread(slotTraceMainThread)
+	return t
+}
+
+// thread returns the ID of a trace thread, assigning the next one to new threads. The lock must be held.
+func (t *slotTrace) thread(name string) int {
+	if tid, ok := t.threads[name]; ok {
+		return tid
+	}
+	tid := len(t.threads) + 1
+	t.threads[name] = tid
+	return tid
+}
+
+// span records a phase of the slot from start to end
+func (t *slotTrace) span(thread, name, cat string, start, end time.Time, args map[string]any) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, slotTraceEvent{
+		Name: name,
+		Cat:  cat,
+		Ph:   "X",
+		Ts:   start.UnixMicro(),
+		Dur:  end.Sub(start).Microseconds(),
+		Pid:  1,
+		Tid:  t.thread(thread),
+		Args: args,
+	})
+}
+
+// instant records an event of the slot at a point in time, eg. the selection of the best bid
+func (t *slotTrace) instant(thread, name, cat string, at time.Time, args map[string]any) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, slotTraceEvent{
+		Name:  name,
+		Cat:   cat,
+		Ph:    "i",
+		Ts:    at.UnixMicro(),
+		Pid:   1,
+		Tid:   t.thread(thread),
+		Scope: "t",
+		Args:  args,
+	})
+}
+
+// marshal returns the trace in the Chrome trace event format, with the names of the threads as metadata events
+func (t *slotTrace) marshal() ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	events := make([]slotTraceEvent, 0, len(t.threads)+len(t.events)+1)
+	events = append(events, slotTraceEvent{Name: "process_name", Ph: "M", Pid: 1, Args: map[string]any{"name": fmt.Sprintf("slot %d", t.slot)}})
+	for name, tid := range t.threads {
+		events = append(events, slotTraceEvent{Name: "thread_name", Ph: "M", Pid: 1, Tid: tid, Args: map[string]any{"name": name}})
+	}
+	events = append(events, t.events...)
+	return json.Marshal(slotTraceFile{
+		TraceEvents:     events,
+		DisplayTimeUnit: "ms",
+		OtherData:       map[string]any{"slot": t.slot},
+	})
+}
+
+// slotTracer collects a trace per slot, which is written to a file in dir once the outcome of the slot is known
+type slotTracer struct {
+	dir    string
+	key    []byte // encrypting the traces at rest, if set
+	mu     sync.Mutex
+	traces map[uint64]*slotTrace
+}
+
+// newSlotTracer returns a tracer writing to dir, which is created if needed, or nil if dir is empty. The traces are
+// encrypted if an at-rest key is given.
+func newSlotTracer(dir string, key []byte) (*slotTracer, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &slotTracer{dir: dir, key: key, traces: make(map[uint64]*slotTrace)}, nil
+}
+
+// get returns the trace of a slot, starting it at now if needed. It returns nil if tracing is disabled.
+func (t *slotTracer) get(slot uint64, now time.Time) *slotTrace {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	trace, ok := t.traces[slot]
+	if !ok {
+		trace = newSlotTrace(slot, now)
+		t.traces[slot] = trace
+	}
+	return trace
+}
+
+// remove returns the trace of a slot, if any, and stops collecting it
+func (t *slotTracer) remove(slot uint64) *slotTrace {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	trace := t.traces[slot]
+	delete(t.traces, slot)
+	return trace
+}
+
+// prune drops the traces of slots which started more than maxAge before now, without outcome
+func (t *slotTracer) prune(now time.Time, maxAge time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for slot, trace := range t.traces {
+		if now.Sub(trace.start) > maxAge {
+			delete(t.traces, slot)
+		}
+	}
+}
+
+// write writes a trace to the file slot-<slot>.json, and returns its path
+func (t *slotTracer) write(trace *slotTrace) (string, error) {
+	data, err := trace.marshal()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(t.dir, fmt.Sprintf("slot-%d.json", trace.slot))
+	return path, writeFileAtRest(path, t.key, data)
+}
+
+// writeSlotTrace writes the trace of a slot, off the critical path of the builder API
+func (m *BoostService) writeSlotTrace(trace *slotTrace) {
+	path, err := m.slotTracer.write(trace)
+	if err != nil {
+		m.log.WithError(err).WithField("slot", trace.slot).Warn("could not write the slot trace")
+		return
+	}
+	m.log.WithFields(logrus.Fields{"slot": trace.slot, "path": path}).Debug("slot trace written")
+}
+
+// relaySpanArgs returns the arguments of the span of a relay request
+func relaySpanArgs(code int, err error) map[string]any {
+	args := map[string]any{"code": code}
+	if err != nil {
+		args["error"] = err.Error()
+	}
+	return args
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlotTracer(t *testing.T) {
+	var tracer *slotTracer
+	require.Nil(t, tracer.get(1, time.Now()))
+	tracer.get(1, time.Now()).span(slotTraceMainThread, "getHeader", slotTraceCatHandler, time.Now(), time.Now(), nil)
+
+	tracer, err := newSlotTracer(filepath.Join(t.TempDir(), "traces"), nil)
+	require.NoError(t, err)
+	start := time.Now()
+	trace := tracer.get(1, start)
+	require.Same(t, trace, tracer.get(1, start.Add(time.Second)))
+	trace.span("relay-a", "getHeader", slotTraceCatRelay, start, start.Add(150*time.Millisecond), relaySpanArgs(200, nil))
+	trace.instant(slotTraceMainThread, "bid selected", slotTraceCatSelection, start.Add(200*time.Millisecond), nil)
+
+	tracer.get(2, start.Add(-2*time.Minute))
+	tracer.prune(start, time.Minute)
+	require.Nil(t, tracer.remove(2))
+	require.Same(t, trace, tracer.remove(1))
+
+	path, err := tracer.write(trace)
+	require.NoError(t, err)
+	require.Equal(t, "slot-1.json", filepath.Base(path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	file := slotTraceFile{}
+	require.NoError(t, json.Unmarshal(data, &file))
+	threads := map[int]string{}
+	for _, event := range file.TraceEvents {
+		if event.Name == "thread_name" {
+			threads[event.Tid] = event.Args["name"].(string)
+		}
+	}
+	require.Equal(t, map[int]string{1: slotTraceMainThread, 2: "relay-a"}, threads)
+
+	span := file.TraceEvents[len(file.TraceEvents)-2]
+	require.Equal(t, "X", span.Ph)
+	require.Equal(t, 2, span.Tid)
+	require.Equal(t, start.UnixMicro(), span.Ts)
+	require.Equal(t, int64(150000), span.Dur)
+	require.Equal(t, "i", file.TraceEvents[len(file.TraceEvents)-1].Ph)
+}
+
+func TestSlotTraceFile(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	backend := newTestBackend(t, 2, time.Second)
+	tracer, err := newSlotTracer(t.TempDir(), nil)
+	require.NoError(t, err)
+	backend.boost.slotTracer = tracer
+
+	rr := backend.request(t, http.MethodGet, fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey), nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	payload := types.SignedBlindedBeaconBlock{
+		Message: &types.BlindedBeaconBlock{
+			Slot: 1,
+			Body: &types.BlindedBeaconBlockBody{
+				Eth1Data:      &types.Eth1Data{},
+				SyncAggregate: &types.SyncAggregate{},
+				ExecutionPayloadHeader: &types.ExecutionPayloadHeader{
+					BlockHash: _HexToHash("0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab1"),
+				},
+			},
+		},
+	}
+	rr = backend.request(t, http.MethodPost, pathGetPayload, payload)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	// The trace is written once the outcome of the slot is emitted
+	path := filepath.Join(tracer.dir, "slot-1.json")
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	file := slotTraceFile{}
+	require.NoError(t, json.Unmarshal(data, &file))
+	spans := map[string]int{}
+	for _, event := range file.TraceEvents {
+		spans[event.Cat+"/"+event.Name]++
+	}
+	require.Equal(t, 1, spans["handler/getHeader"])
+	require.Equal(t, 2, spans["relay/getHeader"])
+	require.Equal(t, 2, spans["validation/validateBid"])
+	require.Equal(t, 1, spans["selection/bid selected"])
+	require.Equal(t, 1, spans["handler/getPayload"])
+	require.GreaterOrEqual(t, spans["relay/getPayload"], 1)
+	require.Nil(t, tracer.remove(1))
+}
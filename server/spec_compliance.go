@@ -0,0 +1,126 @@
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+)
+
+// specDeviation is a way in which a message deviates from the builder spec
+type specDeviation string
+
+// Spec deviations
+const (
+	specDeviationUnknownField   specDeviation = "unknown_field"
+	specDeviationContentType    specDeviation = "content_type"
+	specDeviationMissingVersion specDeviation = "missing_version"
+)
+
+// peerConsensusClient is the peer label of deviations in messages from the consensus client
+const peerConsensusClient = "consensus_client"
+
+// responseOpts are the checks applied to relay responses
+type responseOpts struct {
+	maxSize int64 // maximum body size [bytes], 0 for no limit
+
+	// stream decodes the body as it is read, see decodeResponseStream. Its size and digest are stored to stats, if
+	// set.
+	stream              bool
+	rejectUnknownFields bool
+	stats               *responseStats
+
+	// specDeviation is called for each deviation of the response from the builder spec, and the response is
+	// rejected if it returns an error. Optional.
+	specDeviation func(specDeviation) error
+
+	// codec encodes the request body and decodes the response body unless streamed, encoding/json if nil
+	codec JSONCodec
+
+	// onTLS is called with the state of the TLS connection of the response, if any. Optional.
+	onTLS func(*tls.ConnectionState)
+}
+
+// checkResponse checks a relay response body for spec deviations
+func (o responseOpts) checkResponse(header http.Header, body []byte, dst any) error {
+	if o.specDeviation == nil {
+		return nil
+	}
+	if !isJSONContentType(header.Get("Content-Type")) {
+		if err := o.specDeviation(specDeviationContentType); err != nil {
+			return err
+		}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if decoder.Decode(reflect.New(reflect.TypeOf(dst).Elem()).Interface()) != nil {
+		return o.specDeviation(specDeviationUnknownField)
+	}
+	return nil
+}
+
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "application/json"
+}
+
+// recordSpecDeviation counts a spec deviation of a peer, and returns an error if deviations are rejected
+func (m *BoostService) recordSpecDeviation(peer string, deviation specDeviation) error {
+	m.metrics.specDeviations.WithLabelValues(peer, string(deviation)).Inc()
+	if m.specStrict {
+		return fmt.Errorf("%w: %s", errSpecDeviation, deviation)
+	}
+	return nil
+}
+
+// recordRelaySpecDeviation counts a deviation of a relay from the builder spec, and returns an error if the
+// response must be rejected: in strict mode, and always for untrusted relays
+func (m *BoostService) recordRelaySpecDeviation(relay RelayEntry, deviation specDeviation) error {
+	err := m.recordSpecDeviation(relay.String(), deviation)
+	if err == nil && relay.Untrusted {
+		return fmt.Errorf("%w: %s", errSpecDeviation, deviation)
+	}
+	return err
+}
+
+// relayResponseOpts returns the checks applied to responses of the relay
+func (m *BoostService) relayResponseOpts(relay RelayEntry) responseOpts {
+	return responseOpts{
+		maxSize: minSizeLimit(m.relayMaxResponseSize, relay.Untrusted, m.untrustedRelayMaxResponseSize),
+		codec:   m.jsonCodec,
+		onTLS:   m.onRelayTLS(relay),
+		specDeviation: func(deviation specDeviation) error {
+			return m.recordRelaySpecDeviation(relay, deviation)
+		},
+	}
+}
+
+// relayPayloadResponseOpts returns the checks applied to getPayload responses of the relay, which are streamed
+func (m *BoostService) relayPayloadResponseOpts(relay RelayEntry, stats *responseStats) responseOpts {
+	opts := m.relayResponseOpts(relay)
+	opts.maxSize = minSizeLimit(m.relayMaxPayloadSize, relay.Untrusted, m.untrustedRelayMaxPayloadSize)
+	opts.stream = true
+	opts.rejectUnknownFields = m.specStrict || relay.Untrusted
+	opts.stats = stats
+	return opts
+}
+
+// minSizeLimit returns the lower of the size limits, if the untrusted limit applies. 0 is no limit.
+func minSizeLimit(limit int64, untrusted bool, untrustedLimit int64) int64 {
+	if !untrusted || untrustedLimit <= 0 || (limit > 0 && limit < untrustedLimit) {
+		return limit
+	}
+	return untrustedLimit
+}
+
+// checkRequestSpec checks a request body of the consensus client for spec deviations. Unknown fields are always
+// rejected when decoding. Bodies are JSON or SSZ encoded.
+func (m *BoostService) checkRequestSpec(req *http.Request) error {
+	if contentType := req.Header.Get("Content-Type"); !isJSONContentType(contentType) && !isSSZContentType(contentType) {
+		return m.recordSpecDeviation(peerConsensusClient, specDeviationContentType)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelaySpecDeviations(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	path := fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey)
+
+	// The relay response has an unknown field, and no content type
+	newBackend := func(t *testing.T) *testBackend {
+		t.Helper()
+		backend := newTestBackend(t, 1, time.Second)
+		response, err := json.Marshal(backend.relays[0].MakeGetHeaderResponse(12345, hash, backend.relays[0].RelayEntry.PublicKey.String()))
+		require.NoError(t, err)
+		response = append(response[:len(response)-1], []byte(`,"extra":1}`)...)
+		backend.relays[0].overrideHandleGetHeader(func(w http.ResponseWriter, req *http.Request) {
+			_, _ = w.Write(response)
+		})
+		return backend
+	}
+
+	t.Run("Lenient mode accepts and counts deviations", func(t *testing.T) {
+		backend := newBackend(t)
+		relay := backend.relays[0].RelayEntry.String()
+
+		rr := backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Equal(t, float64(1), testutil.ToFloat64(backend.boost.metrics.specDeviations.WithLabelValues(relay, string(specDeviationUnknownField))))
+		require.Equal(t, float64(1), testutil.ToFloat64(backend.boost.metrics.specDeviations.WithLabelValues(relay, string(specDeviationContentType))))
+	})
+
+	t.Run("Strict mode rejects deviations", func(t *testing.T) {
+		backend := newBackend(t)
+		backend.boost.specStrict = true
+		relay := backend.relays[0].RelayEntry.String()
+
+		rr := backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+		require.Equal(t, uint64(1), backend.boost.bidRejections.snapshot()[relay][BidRejectionSpecDeviation])
+	})
+}
+
+func TestConsensusClientSpecDeviations(t *testing.T) {
+	payload, err := json.Marshal([]types.SignedValidatorRegistration{newTestRegistration(1, 1)})
+	require.NoError(t, err)
+	register := func(backend *testBackend, contentType string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, pathRegisterValidator, bytes.NewReader(payload))
+		require.NoError(t, err)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		rr := httptest.NewRecorder()
+		backend.boost.getRouter().ServeHTTP(rr, req)
+		return rr
+	}
+
+	backend := newTestBackend(t, 1, time.Second)
+	rr := register(backend, "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.Equal(t, float64(1), testutil.ToFloat64(backend.boost.metrics.specDeviations.WithLabelValues(peerConsensusClient, string(specDeviationContentType))))
+
+	backend.boost.specStrict = true
+	rr = register(backend, "")
+	require.Equal(t, http.StatusUnsupportedMediaType, rr.Code, rr.Body.String())
+	rr = register(backend, "application/json; charset=utf-8")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+}
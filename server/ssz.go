@@ -0,0 +1,352 @@
+package server
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	ssz "github.com/ferranbt/fastssz"
+	"github.com/flashbots/go-boost-utils/types"
+)
+
+// mediaTypeSSZ is the media type of SSZ encoded Builder API requests and responses
+const mediaTypeSSZ = "application/octet-stream"
+
+// HeaderConsensusVersion is the fork of an SSZ encoded response, which the encoding doesn't carry unlike the
+// version field of JSON responses
+const HeaderConsensusVersion = "Eth-Consensus-Version"
+
+// SSZ sizes of the Builder API containers
+const (
+	signatureSize                   = 96
+	signedValidatorRegistrationSize = 84 + signatureSize // message and signature
+	signedBlindedBeaconBlockFixed   = 4 + signatureSize  // message offset and signature
+	signedBuilderBidFixed           = 4 + signatureSize  // message offset and signature
+	builderBidFixed                 = 4 + 32 + 48        // header offset, value and pubkey
+	executionPayloadHeaderFixedSize = 536
+	extraDataOffsetPosition         = 436 // of the extra data offset, in the payload and its header
+	executionPayloadFixedSize       = 508
+	withdrawalSize                  = 44
+	blindedBeaconBlockFixed         = 8 + 8 + 32 + 32 + 4 // slot, proposer index, parent and state roots, body offset
+	blindedBeaconBlockBodyFixed     = 384                 // of Bellatrix, which the Capella body extends by an offset
+	executionPayloadHeaderOffset    = 380                 // in the body
+	blsToExecutionChangeSize        = 8 + 48 + 20 + signatureSize
+	maxBLSToExecutionChanges        = 16
+)
+
+func isSSZContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == mediaTypeSSZ
+}
+
+// acceptsSSZ returns whether the client prefers SSZ over JSON responses, by the quality values of its Accept header.
+// Of equal quality values, the media type listed first is preferred. JSON is the default.
+func acceptsSSZ(req *http.Request) bool {
+	qSSZ, qJSON := -1.0, -1.0 // -1 if not listed
+	sszFirst := false
+	for _, accept := range strings.Split(req.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		switch mediaType {
+		case mediaTypeSSZ:
+			if quality > qSSZ {
+				qSSZ, sszFirst = quality, qJSON < 0
+			}
+		case "application/json", "application/*", "*/*":
+			if quality > qJSON {
+				qJSON = quality
+			}
+		}
+	}
+	return qSSZ > 0 && (qSSZ > qJSON || (qSSZ == qJSON && sszFirst))
+}
+
+// decodeRegistrations decodes the body of a registerValidator request, in SSZ or JSON by its content type
+func decodeRegistrations(req *http.Request) ([]types.SignedValidatorRegistration, error) {
+	payload := []types.SignedValidatorRegistration{}
+	if !isSSZContentType(req.Header.Get("Content-Type")) {
+		err := DecodeJSON(req.Body, &payload)
+		return payload, err
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(body)%signedValidatorRegistrationSize != 0 {
+		return nil, fmt.Errorf("%w: %d bytes is not a list of registrations", ssz.ErrSize, len(body))
+	}
+	for i := 0; i < len(body); i += signedValidatorRegistrationSize {
+		registration := types.SignedValidatorRegistration{Message: new(types.RegisterValidatorRequestMessage)}
+		if err := registration.Message.UnmarshalSSZ(body[i : i+signedValidatorRegistrationSize-signatureSize]); err != nil {
+			return nil, err
+		}
+		copy(registration.Signature[:], body[i+signedValidatorRegistrationSize-signatureSize:i+signedValidatorRegistrationSize])
+		payload = append(payload, registration)
+	}
+	return payload, nil
+}
+
+// decodeBlindedBlock decodes the body of a getPayload request, in SSZ or JSON by its content type. SSZ requests are
+// decoded with the Bellatrix types of go-boost-utils, after converting Capella blocks to the Bellatrix encoding.
+func decodeBlindedBlock(req *http.Request) (*SignedBlindedBeaconBlock, error) {
+	payload := new(SignedBlindedBeaconBlock)
+	if !isSSZContentType(req.Header.Get("Content-Type")) {
+		err := DecodeJSON(req.Body, &payload)
+		return payload, err
+	}
+	version := req.Header.Get(HeaderConsensusVersion)
+	if version != "" && version != versionBellatrix && version != versionCapella {
+		return nil, fmt.Errorf("%w: SSZ blinded block of fork %s", errUnsupportedFork, version)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) < signedBlindedBeaconBlockFixed {
+		return nil, ssz.ErrSize
+	}
+	if offset := binary.LittleEndian.Uint32(body[:4]); offset != signedBlindedBeaconBlockFixed {
+		return nil, ssz.ErrInvalidVariableOffset
+	}
+	copy(payload.Signature[:], body[4:signedBlindedBeaconBlockFixed])
+	message := body[signedBlindedBeaconBlockFixed:]
+	var capella *capellaBlindedBlockFields
+	if version == versionCapella {
+		if message, capella, err = capellaToBellatrixBlindedBlock(message); err != nil {
+			return nil, err
+		}
+	}
+	block := new(types.BlindedBeaconBlock)
+	if err := block.UnmarshalSSZ(message); err != nil {
+		return nil, err
+	}
+	payload.Message = &BlindedBeaconBlock{
+		Slot:          block.Slot,
+		ProposerIndex: block.ProposerIndex,
+		ParentRoot:    block.ParentRoot,
+		StateRoot:     block.StateRoot,
+		Body: &BlindedBeaconBlockBody{
+			BlindedBeaconBlockBody: *block.Body,
+			ExecutionPayloadHeader: &ExecutionPayloadHeader{ExecutionPayloadHeader: *block.Body.ExecutionPayloadHeader},
+		},
+	}
+	payload.Message.Body.BlindedBeaconBlockBody.ExecutionPayloadHeader = nil // superseded by the Capella header
+	if capella != nil {
+		payload.Message.Body.ExecutionPayloadHeader.WithdrawalsRoot = &capella.withdrawalsRoot
+		payload.Message.Body.BLSToExecutionChanges = capella.blsToExecutionChanges
+	}
+	return payload, nil
+}
+
+// capellaBlindedBlockFields are the fields of a Capella blinded block which the Bellatrix encoding has no room for
+type capellaBlindedBlockFields struct {
+	withdrawalsRoot       types.Root
+	blsToExecutionChanges json.RawMessage
+}
+
+// blsToExecutionChange is a signed BLS to execution change, decoded from SSZ to be passed on to the relays in JSON
+type blsToExecutionChange struct {
+	Message struct {
+		ValidatorIndex     uint64          `json:"validator_index,string"`
+		FromBLSPubkey      types.PublicKey `json:"from_bls_pubkey"`
+		ToExecutionAddress types.Address   `json:"to_execution_address"`
+	} `json:"message"`
+	Signature types.Signature `json:"signature"`
+}
+
+// capellaToBellatrixBlindedBlock converts an SSZ encoded Capella blinded block to the Bellatrix encoding, by dropping
+// the BLS to execution changes from the body and the withdrawals root from the payload header, which it returns
+// separately. The other fields of both forks are the same.
+func capellaToBellatrixBlindedBlock(message []byte) ([]byte, *capellaBlindedBlockFields, error) {
+	if len(message) < blindedBeaconBlockFixed {
+		return nil, nil, ssz.ErrSize
+	}
+	if offset := binary.LittleEndian.Uint32(message[blindedBeaconBlockFixed-4:]); offset != blindedBeaconBlockFixed {
+		return nil, nil, ssz.ErrInvalidVariableOffset
+	}
+	body := message[blindedBeaconBlockFixed:]
+	bodyFixed := blindedBeaconBlockBodyFixed + 4 // BLS to execution changes offset
+	if len(body) < bodyFixed {
+		return nil, nil, ssz.ErrSize
+	}
+
+	// The offsets of the variable size fields, which are in order: the slashings, attestations, deposits and
+	// voluntary exits, the payload header and the BLS to execution changes
+	offsetPositions := []int{200, 204, 208, 212, 216, executionPayloadHeaderOffset, blindedBeaconBlockBodyFixed}
+	offsets := make([]int, len(offsetPositions))
+	for i, position := range offsetPositions {
+		offsets[i] = int(binary.LittleEndian.Uint32(body[position:]))
+		if (i == 0 && offsets[i] != bodyFixed) || (i > 0 && offsets[i] < offsets[i-1]) || offsets[i] > len(body) {
+			return nil, nil, ssz.ErrInvalidVariableOffset
+		}
+	}
+	headerOffset, changesOffset := offsets[len(offsets)-2], offsets[len(offsets)-1]
+
+	header := body[headerOffset:changesOffset]
+	if len(header) < executionPayloadHeaderFixedSize+32 {
+		return nil, nil, ssz.ErrSize
+	}
+	if offset := binary.LittleEndian.Uint32(header[extraDataOffsetPosition:]); offset != executionPayloadHeaderFixedSize+32 {
+		return nil, nil, ssz.ErrInvalidVariableOffset
+	}
+	fields := new(capellaBlindedBlockFields)
+	copy(fields.withdrawalsRoot[:], header[executionPayloadHeaderFixedSize:])
+
+	changes := body[changesOffset:]
+	if len(changes)%blsToExecutionChangeSize != 0 {
+		return nil, nil, fmt.Errorf("%w: %d bytes is not a list of BLS to execution changes", ssz.ErrSize, len(changes))
+	}
+	if size := len(changes) / blsToExecutionChangeSize; size > maxBLSToExecutionChanges {
+		return nil, nil, ssz.ErrListTooBigFn("BlindedBeaconBlockBody.BLSToExecutionChanges", size, maxBLSToExecutionChanges)
+	}
+	decodedChanges := make([]blsToExecutionChange, 0, len(changes)/blsToExecutionChangeSize)
+	for i := 0; i < len(changes); i += blsToExecutionChangeSize {
+		change := blsToExecutionChange{}
+		change.Message.ValidatorIndex = binary.LittleEndian.Uint64(changes[i:])
+		copy(change.Message.FromBLSPubkey[:], changes[i+8:])
+		copy(change.Message.ToExecutionAddress[:], changes[i+8+48:])
+		copy(change.Signature[:], changes[i+8+48+20:])
+		decodedChanges = append(decodedChanges, change)
+	}
+	var err error
+	if fields.blsToExecutionChanges, err = json.Marshal(decodedChanges); err != nil {
+		return nil, nil, err
+	}
+
+	bellatrix := make([]byte, 0, len(message)-4-32-len(changes))
+	bellatrix = append(bellatrix, message[:blindedBeaconBlockFixed]...)
+	bellatrix = append(bellatrix, body[:blindedBeaconBlockBodyFixed]...)
+	for _, position := range offsetPositions[:len(offsetPositions)-1] {
+		offset := binary.LittleEndian.Uint32(body[position:])
+		binary.LittleEndian.PutUint32(bellatrix[blindedBeaconBlockFixed+position:], offset-4)
+	}
+	bellatrix = append(bellatrix, body[bodyFixed:headerOffset]...)
+	headerStart := len(bellatrix)
+	bellatrix = append(bellatrix, header[:executionPayloadHeaderFixedSize]...)
+	binary.LittleEndian.PutUint32(bellatrix[headerStart+extraDataOffsetPosition:], executionPayloadHeaderFixedSize)
+	bellatrix = append(bellatrix, header[executionPayloadHeaderFixedSize+32:]...)
+	return bellatrix, fields, nil
+}
+
+// marshalSignedBuilderBidSSZ encodes a bid in SSZ, including the withdrawals root of Capella headers, which the
+// Bellatrix types of go-boost-utils omit
+func marshalSignedBuilderBidSSZ(bid *SignedBuilderBid) ([]byte, error) {
+	if bid.Message == nil || bid.Message.Header == nil {
+		return nil, ssz.ErrSize
+	}
+	header, err := bid.Message.Header.ExecutionPayloadHeader.MarshalSSZ()
+	if err != nil {
+		return nil, err
+	}
+	if root := bid.Message.Header.WithdrawalsRoot; root != nil {
+		// The withdrawals root is the last fixed size field, before the extra data
+		fixed := append(header[:executionPayloadHeaderFixedSize:executionPayloadHeaderFixedSize], root[:]...)
+		binary.LittleEndian.PutUint32(fixed[extraDataOffsetPosition:], uint32(len(fixed)))
+		header = append(fixed, header[executionPayloadHeaderFixedSize:]...)
+	}
+
+	dst := make([]byte, 0, signedBuilderBidFixed+builderBidFixed+len(header))
+	dst = ssz.WriteOffset(dst, signedBuilderBidFixed)
+	dst = append(dst, bid.Signature[:]...)
+	dst = ssz.WriteOffset(dst, builderBidFixed)
+	dst = append(dst, bid.Message.Value[:]...)
+	dst = append(dst, bid.Message.Pubkey[:]...)
+	return append(dst, header...), nil
+}
+
+// marshalExecutionPayloadSSZ encodes an execution payload in SSZ, which go-boost-utils only implements for the
+// payload header. The withdrawals of Capella payloads follow the transactions.
+func marshalExecutionPayloadSSZ(p *ExecutionPayload) ([]byte, error) {
+	if size := len(p.ExtraData); size > 32 {
+		return nil, ssz.ErrBytesLengthFn("ExecutionPayload.ExtraData", size, 32)
+	}
+	if size := len(p.Transactions); size > maxTransactionsPerPayload {
+		return nil, ssz.ErrListTooBigFn("ExecutionPayload.Transactions", size, maxTransactionsPerPayload)
+	}
+	fixedSize := executionPayloadFixedSize
+	if p.Withdrawals != nil {
+		if size := len(*p.Withdrawals); size > maxWithdrawalsPerPayload {
+			return nil, ssz.ErrListTooBigFn("ExecutionPayload.Withdrawals", size, maxWithdrawalsPerPayload)
+		}
+		for _, w := range *p.Withdrawals {
+			if w == nil {
+				return nil, errNilWithdrawal
+			}
+		}
+		fixedSize += 4 // withdrawals offset
+	}
+	transactionsSize := 4 * len(p.Transactions)
+	for _, tx := range p.Transactions {
+		if len(tx) > maxBytesPerTransaction {
+			return nil, ssz.ErrBytesLengthFn("ExecutionPayload.Transactions[i]", len(tx), maxBytesPerTransaction)
+		}
+		transactionsSize += len(tx)
+	}
+	size := fixedSize + len(p.ExtraData) + transactionsSize
+	if p.Withdrawals != nil {
+		size += withdrawalSize * len(*p.Withdrawals)
+	}
+
+	dst := make([]byte, 0, size)
+	dst = append(dst, p.ParentHash[:]...)
+	dst = append(dst, p.FeeRecipient[:]...)
+	dst = append(dst, p.StateRoot[:]...)
+	dst = append(dst, p.ReceiptsRoot[:]...)
+	dst = append(dst, p.LogsBloom[:]...)
+	dst = append(dst, p.Random[:]...)
+	dst = ssz.MarshalUint64(dst, p.BlockNumber)
+	dst = ssz.MarshalUint64(dst, p.GasLimit)
+	dst = ssz.MarshalUint64(dst, p.GasUsed)
+	dst = ssz.MarshalUint64(dst, p.Timestamp)
+	dst = ssz.WriteOffset(dst, fixedSize)
+	dst = append(dst, p.BaseFeePerGas[:]...)
+	dst = append(dst, p.BlockHash[:]...)
+	dst = ssz.WriteOffset(dst, fixedSize+len(p.ExtraData))
+	if p.Withdrawals != nil {
+		dst = ssz.WriteOffset(dst, fixedSize+len(p.ExtraData)+transactionsSize)
+	}
+
+	dst = append(dst, p.ExtraData...)
+	offset := 4 * len(p.Transactions)
+	for _, tx := range p.Transactions {
+		dst = ssz.WriteOffset(dst, offset)
+		offset += len(tx)
+	}
+	for _, tx := range p.Transactions {
+		dst = append(dst, tx...)
+	}
+	if p.Withdrawals != nil {
+		for _, w := range *p.Withdrawals {
+			dst = ssz.MarshalUint64(dst, w.Index)
+			dst = ssz.MarshalUint64(dst, w.ValidatorIndex)
+			dst = append(dst, w.Address[:]...)
+			dst = ssz.MarshalUint64(dst, w.Amount)
+		}
+	}
+	return dst, nil
+}
+
+// respondSSZ writes an SSZ encoded response of the given fork
+func (m *BoostService) respondSSZ(w http.ResponseWriter, body []byte, version types.VersionString) {
+	w.Header().Set("Content-Type", mediaTypeSSZ)
+	w.Header().Set(HeaderConsensusVersion, string(version))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		m.log.WithError(err).Debug("Couldn't write OK response")
+	}
+}
@@ -0,0 +1,245 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ssz "github.com/ferranbt/fastssz"
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptsSSZ(t *testing.T) {
+	for accept, expected := range map[string]bool{
+		"":                             false,
+		"application/json":             false,
+		"application/octet-stream":     true,
+		"application/octet-stream;q=0": false,
+		"application/json, application/octet-stream":       false,
+		"application/octet-stream, application/json":       true,
+		"application/json;q=0.5, application/octet-stream": true,
+		"application/octet-stream;q=0.5, */*":              false,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", accept)
+		require.Equal(t, expected, acceptsSSZ(req), accept)
+	}
+}
+
+func TestMarshalExecutionPayloadSSZ(t *testing.T) {
+	payload := &ExecutionPayload{ExecutionPayload: types.ExecutionPayload{
+		ParentHash:    types.Hash{0x01},
+		FeeRecipient:  types.Address{0x02},
+		BlockNumber:   12345,
+		Timestamp:     1606824023,
+		ExtraData:     hexutil.Bytes{0x03, 0x04},
+		BaseFeePerGas: types.U256Str{0x05},
+		BlockHash:     types.Hash{0x06},
+		Transactions:  []hexutil.Bytes{{0x07}, {0x08, 0x09}},
+	}}
+	data, err := marshalExecutionPayloadSSZ(payload)
+	require.NoError(t, err)
+	require.Len(t, data, executionPayloadFixedSize+2+2*4+3)
+
+	// The fixed part matches the header's, but for the transactions offset in place of the transactions root
+	header := &types.ExecutionPayloadHeader{
+		ParentHash:    payload.ParentHash,
+		FeeRecipient:  payload.FeeRecipient,
+		BlockNumber:   payload.BlockNumber,
+		Timestamp:     payload.Timestamp,
+		ExtraData:     types.ExtraData(payload.ExtraData),
+		BaseFeePerGas: payload.BaseFeePerGas,
+		BlockHash:     payload.BlockHash,
+	}
+	headerData, err := header.MarshalSSZ()
+	require.NoError(t, err)
+	require.Equal(t, headerData[:436], data[:436])
+	require.Equal(t, uint32(executionPayloadFixedSize), binary.LittleEndian.Uint32(data[436:]))
+	require.Equal(t, headerData[440:504], data[440:504])
+	require.Equal(t, uint32(executionPayloadFixedSize+2), binary.LittleEndian.Uint32(data[504:]))
+	require.Equal(t, []byte{0x03, 0x04, 8, 0, 0, 0, 9, 0, 0, 0, 0x07, 0x08, 0x09}, data[executionPayloadFixedSize:])
+
+	payload.ExtraData = make(hexutil.Bytes, 33)
+	_, err = marshalExecutionPayloadSSZ(payload)
+	require.Error(t, err)
+}
+
+func TestBuilderAPISSZ(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	backend := newTestBackend(t, 1, time.Second)
+	backend.relays[0].EchoRequestHashes = true
+	send := func(method, path string, body []byte, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/octet-stream")
+		req.Header.Set("Accept", accept)
+		rr := httptest.NewRecorder()
+		backend.boost.getRouter().ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("registerValidator", func(t *testing.T) {
+		message := &types.RegisterValidatorRequestMessage{Pubkey: _HexToPubkey(pubkey), GasLimit: 30000000}
+		data, err := message.MarshalSSZ()
+		require.NoError(t, err)
+		data = append(data, make([]byte, 96)...)
+		rr := send(http.MethodPost, pathRegisterValidator, append(data, data...), "")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Equal(t, 1, backend.relays[0].GetRequestCount(pathRegisterValidator))
+		require.True(t, backend.boost.isRegisteredValidator(pubkey))
+
+		rr = send(http.MethodPost, pathRegisterValidator, data[:100], "")
+		require.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+	})
+
+	t.Run("getHeader", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/eth/v1/builder/header/1/%s/%s", hash, pubkey), nil)
+		req.Header.Set("Accept", "application/octet-stream")
+		rr := httptest.NewRecorder()
+		backend.boost.getRouter().ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Equal(t, "application/octet-stream", rr.Header().Get("Content-Type"))
+		require.Equal(t, "bellatrix", rr.Header().Get(HeaderConsensusVersion))
+		bid := new(types.SignedBuilderBid)
+		require.NoError(t, bid.UnmarshalSSZ(rr.Body.Bytes()))
+		require.Equal(t, types.IntToU256(12345), bid.Message.Value)
+	})
+
+	t.Run("getPayload", func(t *testing.T) {
+		block := &types.BlindedBeaconBlock{
+			Slot: 1,
+			Body: &types.BlindedBeaconBlockBody{
+				Eth1Data:      &types.Eth1Data{},
+				SyncAggregate: &types.SyncAggregate{},
+				ExecutionPayloadHeader: &types.ExecutionPayloadHeader{
+					BlockHash:   _HexToHash(hash),
+					BlockNumber: 12345,
+				},
+			},
+		}
+		data, err := block.MarshalSSZ()
+		require.NoError(t, err)
+		data = append(append([]byte{100, 0, 0, 0}, make([]byte, 96)...), data...)
+
+		rr := send(http.MethodPost, pathGetPayload, data, "application/octet-stream")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Equal(t, "application/octet-stream", rr.Header().Get("Content-Type"))
+		require.Equal(t, _HexToHash(hash).String(), hexutil.Encode(rr.Body.Bytes()[472:504]))
+
+		// JSON unless SSZ is accepted
+		rr = send(http.MethodPost, pathGetPayload, data, "")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+		rr = send(http.MethodPost, pathGetPayload, data[1:], "")
+		require.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+	})
+}
+
+// marshalCapellaBlindedBlockSSZ encodes a Capella blinded block in SSZ, by extending the Bellatrix encoding of
+// go-boost-utils with the BLS to execution changes offset of the body and the withdrawals root of the payload header
+func marshalCapellaBlindedBlockSSZ(t *testing.T, block *SignedBlindedBeaconBlock, changes []byte) []byte {
+	t.Helper()
+	body := block.Message.Body.BlindedBeaconBlockBody
+	body.ExecutionPayloadHeader = &block.Message.Body.ExecutionPayloadHeader.ExecutionPayloadHeader
+	message, err := (&types.BlindedBeaconBlock{
+		Slot:          block.Message.Slot,
+		ProposerIndex: block.Message.ProposerIndex,
+		ParentRoot:    block.Message.ParentRoot,
+		StateRoot:     block.Message.StateRoot,
+		Body:          &body,
+	}).MarshalSSZ()
+	require.NoError(t, err)
+
+	bellatrixBody := message[blindedBeaconBlockFixed:]
+	headerOffset := binary.LittleEndian.Uint32(bellatrixBody[executionPayloadHeaderOffset:])
+	capellaBody := append([]byte{}, bellatrixBody[:blindedBeaconBlockBodyFixed]...)
+	for _, position := range []int{200, 204, 208, 212, 216, executionPayloadHeaderOffset} {
+		binary.LittleEndian.PutUint32(capellaBody[position:], binary.LittleEndian.Uint32(capellaBody[position:])+4)
+	}
+	capellaBody = ssz.WriteOffset(capellaBody, len(bellatrixBody)+4+32)
+	capellaBody = append(capellaBody, bellatrixBody[blindedBeaconBlockBodyFixed:headerOffset]...)
+	header := bellatrixBody[headerOffset:]
+	capellaBody = append(capellaBody, header[:executionPayloadHeaderFixedSize]...)
+	binary.LittleEndian.PutUint32(capellaBody[int(headerOffset)+4+extraDataOffsetPosition:], executionPayloadHeaderFixedSize+32)
+	capellaBody = append(capellaBody, block.Message.Body.ExecutionPayloadHeader.WithdrawalsRoot[:]...)
+	capellaBody = append(capellaBody, header[executionPayloadHeaderFixedSize:]...)
+	capellaBody = append(capellaBody, changes...)
+
+	data := ssz.WriteOffset(nil, signedBlindedBeaconBlockFixed)
+	data = append(data, block.Signature[:]...)
+	data = append(data, message[:blindedBeaconBlockFixed]...)
+	return append(data, capellaBody...)
+}
+
+func TestDecodeBlindedBlockCapellaSSZ(t *testing.T) {
+	change := blsToExecutionChange{Signature: types.Signature{0x04}}
+	change.Message.ValidatorIndex = 1
+	change.Message.FromBLSPubkey = types.PublicKey{0x02}
+	change.Message.ToExecutionAddress = types.Address{0x03}
+	changes := make([]byte, 0, blsToExecutionChangeSize)
+	changes = ssz.MarshalUint64(changes, change.Message.ValidatorIndex)
+	changes = append(changes, change.Message.FromBLSPubkey[:]...)
+	changes = append(changes, change.Message.ToExecutionAddress[:]...)
+	changes = append(changes, change.Signature[:]...)
+	changesJSON, err := json.Marshal([]blsToExecutionChange{change})
+	require.NoError(t, err)
+
+	header := &ExecutionPayloadHeader{
+		ExecutionPayloadHeader: types.ExecutionPayloadHeader{BlockHash: types.Hash{0x05}, BlockNumber: 12345, ExtraData: types.ExtraData{0x06}},
+		WithdrawalsRoot:        &types.Root{0x07},
+	}
+	blindedBlock := makeTestBlindedBlock(header)
+	blindedBlock.Signature = types.Signature{0x08}
+	blindedBlock.Message.Body.Eth1Data = &types.Eth1Data{}
+	blindedBlock.Message.Body.SyncAggregate = &types.SyncAggregate{}
+	blindedBlock.Message.Body.ProposerSlashings = []*types.ProposerSlashing{}
+	blindedBlock.Message.Body.AttesterSlashings = []*types.AttesterSlashing{}
+	blindedBlock.Message.Body.Attestations = []*types.Attestation{}
+	blindedBlock.Message.Body.Deposits = []*types.Deposit{}
+	blindedBlock.Message.Body.VoluntaryExits = []*types.VoluntaryExit{{Epoch: 9, ValidatorIndex: 10}}
+	blindedBlock.Message.Body.BLSToExecutionChanges = changesJSON
+	data := marshalCapellaBlindedBlockSSZ(t, blindedBlock, changes)
+
+	decode := func(data []byte, version string) (*SignedBlindedBeaconBlock, error) {
+		req := httptest.NewRequest(http.MethodPost, pathGetPayload, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/octet-stream")
+		req.Header.Set(HeaderConsensusVersion, version)
+		return decodeBlindedBlock(req)
+	}
+
+	decoded, err := decode(data, versionCapella)
+	require.NoError(t, err)
+	require.Equal(t, blindedBlock.Signature, decoded.Signature)
+	require.Equal(t, blindedBlock.Message.Slot, decoded.Message.Slot)
+	require.Equal(t, header, decoded.Message.Body.ExecutionPayloadHeader)
+
+	// The block is forwarded to the relays in JSON, with the withdrawals root and BLS to execution changes
+	expected, err := json.Marshal(blindedBlock)
+	require.NoError(t, err)
+	forwarded, err := json.Marshal(decoded)
+	require.NoError(t, err)
+	require.JSONEq(t, string(expected), string(forwarded))
+
+	t.Run("Without BLS to execution changes", func(t *testing.T) {
+		decoded, err := decode(data[:len(data)-len(changes)], versionCapella)
+		require.NoError(t, err)
+		require.JSONEq(t, "[]", string(decoded.Message.Body.BLSToExecutionChanges))
+	})
+
+	t.Run("Invalid blocks", func(t *testing.T) {
+		_, err := decode(data[:len(data)-1], versionCapella)
+		require.Error(t, err)
+		_, err = decode(data, versionBellatrix)
+		require.Error(t, err)
+		_, err = decode(data, "deneb")
+		require.ErrorIs(t, err, errUnsupportedFork)
+	})
+}
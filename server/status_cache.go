@@ -0,0 +1,56 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// Results of status cache lookups
+const (
+	statusCacheHit   = "hit"
+	statusCacheStale = "stale"
+	statusCacheMiss  = "miss"
+)
+
+// statusCache caches the result of the relay status checks of the status endpoint. A result is fresh for ttl, and
+// served stale for staleTTL after, while the relays are checked again in the background.
+type statusCache struct {
+	mu           sync.Mutex
+	ttl          time.Duration
+	staleTTL     time.Duration
+	ok           bool
+	checkedAt    time.Time // zero if the relays were never checked
+	revalidating bool
+}
+
+func newStatusCache(ttl, staleTTL time.Duration) *statusCache {
+	return &statusCache{ttl: ttl, staleTTL: staleTTL}
+}
+
+// get returns the cached result, unless it's a miss, and whether the caller has to revalidate it in the background
+func (c *statusCache) get(now time.Time) (ok bool, result string, revalidate bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	age := now.Sub(c.checkedAt)
+	switch {
+	case c.ttl <= 0 || c.checkedAt.IsZero() || age >= c.ttl+c.staleTTL:
+		return false, statusCacheMiss, false
+	case age < c.ttl:
+		return c.ok, statusCacheHit, false
+	}
+	revalidate = !c.revalidating
+	c.revalidating = true
+	return c.ok, statusCacheStale, revalidate
+}
+
+// set caches the result of checking the relays at t
+func (c *statusCache) set(ok bool, t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t.After(c.checkedAt) {
+		c.ok = ok
+		c.checkedAt = t
+	}
+	c.revalidating = false
+}
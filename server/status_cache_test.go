@@ -0,0 +1,72 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusCache(t *testing.T) {
+	c := newStatusCache(time.Second, 10*time.Second)
+	now := time.Now()
+
+	_, result, _ := c.get(now)
+	require.Equal(t, statusCacheMiss, result)
+	c.set(true, now)
+
+	ok, result, revalidate := c.get(now.Add(500 * time.Millisecond))
+	require.True(t, ok)
+	require.Equal(t, statusCacheHit, result)
+	require.False(t, revalidate)
+
+	// A stale result is revalidated by a single caller
+	ok, result, revalidate = c.get(now.Add(2 * time.Second))
+	require.True(t, ok)
+	require.Equal(t, statusCacheStale, result)
+	require.True(t, revalidate)
+	_, _, revalidate = c.get(now.Add(2 * time.Second))
+	require.False(t, revalidate)
+
+	c.set(false, now.Add(2*time.Second))
+	ok, result, _ = c.get(now.Add(2 * time.Second))
+	require.False(t, ok)
+	require.Equal(t, statusCacheHit, result)
+
+	// Results older than the stale period are not served, nor is a result older than the cached one stored
+	_, result, _ = c.get(now.Add(13 * time.Second))
+	require.Equal(t, statusCacheMiss, result)
+	c.set(true, now)
+	ok, _, _ = c.get(now.Add(2 * time.Second))
+	require.False(t, ok)
+
+	// Caching is disabled without ttl
+	c = newStatusCache(0, 0)
+	c.set(true, now)
+	_, result, _ = c.get(now)
+	require.Equal(t, statusCacheMiss, result)
+}
+
+func TestStatusCached(t *testing.T) {
+	path := "/eth/v1/builder/status"
+	backend := newTestBackend(t, 1, time.Second)
+	backend.boost.statusCache = newStatusCache(time.Hour, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		rr := backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+	}
+	require.Equal(t, 1, backend.relays[0].GetRequestCount(path))
+	require.Equal(t, 2.0, testutil.ToFloat64(backend.boost.metrics.statusCache.WithLabelValues(statusCacheHit)))
+
+	// The stale result is served while the relay is checked again in the background
+	backend.boost.statusCache.checkedAt = time.Now().Add(-90 * time.Minute)
+	backend.relays[0].Server.Close()
+	rr := backend.request(t, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Eventually(t, func() bool {
+		return backend.request(t, http.MethodGet, path, nil).Code == http.StatusServiceUnavailable
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// sdListenFdsStart is the first file descriptor passed by systemd socket activation
+const sdListenFdsStart = 3
+
+// systemdListener returns the listener passed by systemd socket activation (see sd_listen_fds(3)),
+// or nil if the process was not socket activated
+func systemdListener() (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+
+	numFds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || numFds < 1 {
+		return nil, nil
+	}
+
+	// Don't pass the sockets on to child processes
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	f := os.NewFile(sdListenFdsStart, "LISTEN_FD_3")
+	defer f.Close()
+	return net.FileListener(f)
+}
+
+// sdNotify sends a state notification to systemd (see sd_notify(3)). It is a no-op if NOTIFY_SOCKET is not set.
+func sdNotify(state string) error {
+	socketAddr := os.Getenv("NOTIFY_SOCKET")
+	if socketAddr == "" {
+		return nil
+	}
+
+	addr := &net.UnixAddr{Name: socketAddr, Net: "unixgram"}
+	if socketAddr[0] == '@' { // abstract namespace socket
+		addr.Name = "\x00" + socketAddr[1:]
+	}
+
+	conn, err := net.DialUnix(addr.Net, nil, addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// sdWatchdogInterval returns the interval at which systemd expects watchdog notifications, or 0 if the
+// watchdog is not enabled for this process
+func sdWatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+
+	if pidStr := os.Getenv("WATCHDOG_PID"); pidStr != "" {
+		pid, err := strconv.Atoi(pidStr)
+		if err != nil || pid != os.Getpid() {
+			return 0
+		}
+	}
+
+	// Notify at half the timeout, as recommended by sd_watchdog_enabled(3)
+	return time.Duration(usec) * time.Microsecond / 2
+}
@@ -0,0 +1,67 @@
+//go:build !windows
+
+package server
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemdListener(t *testing.T) {
+	t.Run("Not socket activated", func(t *testing.T) {
+		t.Setenv("LISTEN_PID", "")
+		t.Setenv("LISTEN_FDS", "")
+		listener, err := systemdListener()
+		require.NoError(t, err)
+		require.Nil(t, listener)
+	})
+
+	t.Run("Sockets passed to another process", func(t *testing.T) {
+		t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+		t.Setenv("LISTEN_FDS", "1")
+		listener, err := systemdListener()
+		require.NoError(t, err)
+		require.Nil(t, listener)
+	})
+}
+
+func TestSdNotify(t *testing.T) {
+	t.Run("No notify socket", func(t *testing.T) {
+		t.Setenv("NOTIFY_SOCKET", "")
+		require.NoError(t, sdNotify("READY=1"))
+	})
+
+	t.Run("Sends state to notify socket", func(t *testing.T) {
+		socketPath := filepath.Join(t.TempDir(), "notify.sock")
+		conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+		require.NoError(t, err)
+		defer conn.Close()
+
+		t.Setenv("NOTIFY_SOCKET", socketPath)
+		require.NoError(t, sdNotify("READY=1"))
+
+		buf := make([]byte, 64)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		n, err := conn.Read(buf)
+		require.NoError(t, err)
+		require.Equal(t, "READY=1", string(buf[:n]))
+	})
+}
+
+func TestSdWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "")
+	require.Equal(t, time.Duration(0), sdWatchdogInterval())
+
+	t.Setenv("WATCHDOG_USEC", "10000000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	require.Equal(t, 5*time.Second, sdWatchdogInterval())
+
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()+1))
+	require.Equal(t, time.Duration(0), sdWatchdogInterval())
+}
@@ -0,0 +1,297 @@
+package server
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flashbots/mev-boost/config"
+)
+
+const (
+	// telemetryTimeout is the timeout of telemetry submissions
+	telemetryTimeout = 10 * time.Second
+
+	// telemetryClientOther counts the getHeader calls of consensus clients other than telemetryClients
+	telemetryClientOther = "other"
+)
+
+// telemetryClients are the consensus clients whose getHeader calls are counted separately. The set of clients in a
+// report is fixed, as reporting the clients seen, or their versions, would reveal a single call of a rare client.
+var telemetryClients = []string{"grandine", "lighthouse", "lodestar", "nimbus", "prysm", "teku"}
+
+// telemetryLatencyBucketsMs are the upper bounds of the buckets of the latency histogram of a relay, whose noisy
+// counts give the reported percentiles. The last bucket, above the last bound, is unbounded.
+var telemetryLatencyBucketsMs = []float64{25, 50, 100, 200, 300, 400, 500, 750, 1000, 1500, 2000, 3000}
+
+// TelemetryRelayStats are the anonymous statistics of the getHeader calls to a relay
+type TelemetryRelayStats struct {
+	Relay        string  `json:"relay"` // host of the relay, without its pubkey
+	Bids         uint64  `json:"bids"`
+	NoBids       uint64  `json:"no_bids"`
+	Rejected     uint64  `json:"rejected"`
+	Errors       uint64  `json:"errors"`
+	LatencyP50Ms float64 `json:"latency_p50_ms"` // of the noisy latency histogram
+	LatencyP90Ms float64 `json:"latency_p90_ms"`
+
+	latencies []uint64 // histogram of telemetryLatencyBucketsMs
+}
+
+// TelemetryReport is the anonymous telemetry submitted to the community endpoint. It holds no proposer or validator
+// pubkeys, slots, block hashes or bid values, and is Epsilon-differentially private for a single getHeader call.
+//
+// A getHeader call adds one to a count and to a latency bucket of every relay, and to the count of one client, so
+// the Laplace noise added to every count and latency bucket has a scale of (2 × relays + 1) / Epsilon, given as
+// NoiseScale. The latency percentiles are computed from the noisy histograms. The relays and clients of a report are
+// those configured and telemetryClients, whether they were called or not.
+type TelemetryReport struct {
+	Version    string                `json:"version"` // of mev-boost
+	Network    string                `json:"network"` // genesis fork version
+	From       time.Time             `json:"from"`
+	To         time.Time             `json:"to"`
+	Epsilon    float64               `json:"epsilon"` // privacy budget of the report
+	NoiseScale float64               `json:"noise_scale"`
+	Relays     []TelemetryRelayStats `json:"relays"`
+	Clients    map[string]uint64     `json:"clients"` // getHeader calls per consensus client
+}
+
+// telemetryStatus is the telemetry section of the status API, showing what is submitted
+type telemetryStatus struct {
+	Enabled       bool             `json:"enabled"`
+	Endpoint      string           `json:"endpoint,omitempty"`
+	Epsilon       float64          `json:"epsilon,omitempty"` // privacy budget of each report
+	LastSubmitted *time.Time       `json:"last_submitted,omitempty"`
+	LastError     string           `json:"last_error,omitempty"`
+	LastReport    *TelemetryReport `json:"last_report,omitempty"`
+}
+
+// telemetry aggregates anonymous statistics of the relays, submitted to a community endpoint to help analyze relay
+// performance. It is opt-in, and nil when disabled.
+type telemetry struct {
+	endpoint string
+	epsilon  float64 // privacy budget of a report, 0 disables the noise
+	network  string
+	client   *http.Client
+
+	mu            sync.Mutex
+	rand          *rand.Rand
+	from          time.Time
+	relays        map[string]*TelemetryRelayStats
+	clients       map[string]uint64
+	lastReport    *TelemetryReport
+	lastSubmitted time.Time
+	lastError     string
+}
+
+// newTelemetry returns the telemetry of the relays submitted to endpoint, or nil without endpoint
+func newTelemetry(endpoint string, epsilon float64, network string, relays []RelayEntry, now time.Time) *telemetry {
+	if endpoint == "" {
+		return nil
+	}
+
+	// The noise must not be predictable, so the generator is seeded from crypto/rand
+	var seed [8]byte
+	_, _ = crand.Read(seed[:])
+	t := &telemetry{
+		endpoint: endpoint,
+		epsilon:  epsilon,
+		network:  network,
+		client:   &http.Client{Timeout: telemetryTimeout},
+		rand:     rand.New(rand.NewSource(int64(binary.LittleEndian.Uint64(seed[:])))),
+		relays:   make(map[string]*TelemetryRelayStats),
+	}
+	for _, relay := range relays {
+		t.relays[relay.URL.Host] = nil
+	}
+	t.reset(now)
+	return t
+}
+
+// reset starts a new report, with no calls of the relays and clients
+func (t *telemetry) reset(now time.Time) {
+	t.from = now
+	for host := range t.relays {
+		t.relays[host] = &TelemetryRelayStats{Relay: host, latencies: make([]uint64, len(telemetryLatencyBucketsMs)+1)}
+	}
+	t.clients = make(map[string]uint64, len(telemetryClients)+1)
+	for _, client := range telemetryClients {
+		t.clients[client] = 0
+	}
+	t.clients[telemetryClientOther] = 0
+}
+
+// recordRelayBid records the response of a relay, given by its host, to a getHeader call, with its status of the
+// relay bid events. Relays which are not configured, eg. those of a proposer config, are not reported.
+func (t *telemetry) recordRelayBid(relayHost, status string, latency time.Duration) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stats, ok := t.relays[relayHost]
+	if !ok {
+		return
+	}
+	switch status {
+	case relayBidValid:
+		stats.Bids++
+	case relayBidNone:
+		stats.NoBids++
+	case relayBidRejected:
+		stats.Rejected++
+	case relayBidError:
+		stats.Errors++
+	}
+	bucket := sort.SearchFloat64s(telemetryLatencyBucketsMs, float64(latency.Milliseconds())+1)
+	stats.latencies[bucket]++
+}
+
+// recordClient records a getHeader call of a consensus client, by the product of its user agent
+func (t *telemetry) recordClient(ua UserAgent) {
+	if t == nil {
+		return
+	}
+
+	client := telemetryClientOther
+	if fields := strings.Fields(string(ua)); len(fields) > 0 {
+		product := strings.ToLower(strings.SplitN(fields[0], "/", 2)[0])
+		for _, known := range telemetryClients {
+			if product == known {
+				client = known
+			}
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.clients[client]++
+}
+
+// noiseScale returns the scale of the Laplace noise of the counts, for the budget of a report to cover the counts a
+// single getHeader call adds to
+func (t *telemetry) noiseScale() float64 {
+	if t.epsilon <= 0 {
+		return 0
+	}
+	return float64(2*len(t.relays)+1) / t.epsilon
+}
+
+// noisy returns a count with Laplace noise of the given scale, rounded and at least 0
+func (t *telemetry) noisy(count uint64, scale float64) uint64 {
+	if scale <= 0 {
+		return count
+	}
+	u := t.rand.Float64() - 0.5
+	noise := -math.Copysign(1, u) * math.Log(1-2*math.Abs(u)) * scale
+	return uint64(math.Max(0, math.Round(float64(count)+noise)))
+}
+
+// report returns the report of the statistics recorded since the last one, and starts a new one. A report is made
+// even if nothing was recorded, as its absence would reveal that there was no getHeader call.
+func (t *telemetry) report(now time.Time) *TelemetryReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	scale := t.noiseScale()
+	report := &TelemetryReport{
+		Version:    config.Version,
+		Network:    t.network,
+		From:       t.from,
+		To:         now,
+		Epsilon:    t.epsilon,
+		NoiseScale: scale,
+		Relays:     make([]TelemetryRelayStats, 0, len(t.relays)),
+		Clients:    make(map[string]uint64, len(t.clients)),
+	}
+	for _, stats := range t.relays {
+		latencies := make([]uint64, len(stats.latencies))
+		for i, count := range stats.latencies {
+			latencies[i] = t.noisy(count, scale)
+		}
+		report.Relays = append(report.Relays, TelemetryRelayStats{
+			Relay:        stats.Relay,
+			Bids:         t.noisy(stats.Bids, scale),
+			NoBids:       t.noisy(stats.NoBids, scale),
+			Rejected:     t.noisy(stats.Rejected, scale),
+			Errors:       t.noisy(stats.Errors, scale),
+			LatencyP50Ms: latencyHistogramPercentileMs(latencies, 50),
+			LatencyP90Ms: latencyHistogramPercentileMs(latencies, 90),
+		})
+	}
+	sort.Slice(report.Relays, func(i, j int) bool { return report.Relays[i].Relay < report.Relays[j].Relay })
+	for client, count := range t.clients {
+		report.Clients[client] = t.noisy(count, scale)
+	}
+
+	t.reset(now)
+	return report
+}
+
+// latencyHistogramPercentileMs returns the percentile p of a histogram of telemetryLatencyBucketsMs, as the upper
+// bound of the bucket it falls in, or the last bound for the unbounded bucket. It is 0 for an empty histogram.
+func latencyHistogramPercentileMs(counts []uint64, p float64) float64 {
+	var total uint64
+	for _, count := range counts {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(p / 100 * float64(total)))
+	var cumulative uint64
+	for i, count := range counts {
+		cumulative += count
+		if cumulative >= rank && i < len(telemetryLatencyBucketsMs) {
+			return telemetryLatencyBucketsMs[i]
+		}
+	}
+	return telemetryLatencyBucketsMs[len(telemetryLatencyBucketsMs)-1]
+}
+
+// status returns the telemetry section of the status API
+func (t *telemetry) status() telemetryStatus {
+	if t == nil {
+		return telemetryStatus{}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	status := telemetryStatus{
+		Enabled:    true,
+		Endpoint:   t.endpoint,
+		Epsilon:    t.epsilon,
+		LastError:  t.lastError,
+		LastReport: t.lastReport,
+	}
+	if !t.lastSubmitted.IsZero() {
+		lastSubmitted := t.lastSubmitted
+		status.LastSubmitted = &lastSubmitted
+	}
+	return status
+}
+
+// submitTelemetry submits the statistics recorded since the last submission. Failed submissions are not retried.
+func (m *BoostService) submitTelemetry(ctx context.Context) error {
+	report := m.telemetry.report(m.clock.Now())
+	_, _, err := sendHTTPRequest(ctx, m.telemetry.client, http.MethodPost, m.telemetry.endpoint, "", report, nil, responseOpts{})
+
+	m.telemetry.mu.Lock()
+	defer m.telemetry.mu.Unlock()
+	m.telemetry.lastReport = report
+	if err != nil {
+		m.telemetry.lastError = err.Error()
+		m.log.WithError(err).Debug("failed to submit telemetry")
+		return err
+	}
+	m.telemetry.lastSubmitted = m.clock.Now()
+	m.telemetry.lastError = ""
+	return nil
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelemetryReport(t *testing.T) {
+	relay := newMockRelay(t).RelayEntry
+	require.Nil(t, newTelemetry("", 1, "0x00000000", []RelayEntry{relay}, time.Now()))
+
+	start := time.Unix(1663000000, 0)
+	telemetry := newTelemetry("http://localhost", 0, "0x00000000", []RelayEntry{relay}, start)
+
+	// Reports list the configured relays and all clients, even without calls
+	clients := map[string]uint64{"grandine": 0, "lighthouse": 0, "lodestar": 0, "nimbus": 0, "prysm": 0, "teku": 0, "other": 0}
+	report := telemetry.report(start)
+	require.Equal(t, []TelemetryRelayStats{{Relay: relay.URL.Host}}, report.Relays)
+	require.Equal(t, clients, report.Clients)
+
+	for i := 1; i <= 10; i++ {
+		telemetry.recordRelayBid(relay.URL.Host, relayBidValid, time.Duration(i)*10*time.Millisecond)
+	}
+	telemetry.recordRelayBid(relay.URL.Host, relayBidNone, 0)
+	telemetry.recordRelayBid(relay.URL.Host, relayBidError, 0)
+	telemetry.recordRelayBid("relay.example.com", relayBidValid, 0) // not configured
+	telemetry.recordClient("Lighthouse/v3.1.0-aa022f4 (linux x86_64)")
+	telemetry.recordClient("RareClient/v0.0.1")
+	telemetry.recordClient("")
+
+	report = telemetry.report(start.Add(time.Hour))
+	require.Equal(t, "0x00000000", report.Network)
+	require.Equal(t, start, report.From)
+	require.Zero(t, report.NoiseScale)
+	// The latencies of the responses without bid count too, the percentiles are the bounds of the latency buckets
+	require.Equal(t, []TelemetryRelayStats{{
+		Relay:        relay.URL.Host,
+		Bids:         10,
+		NoBids:       1,
+		Errors:       1,
+		LatencyP50Ms: 50,
+		LatencyP90Ms: 100,
+	}}, report.Relays)
+	clients["lighthouse"] = 1
+	clients["other"] = 2
+	require.Equal(t, clients, report.Clients)
+
+	// The pubkey of the relay is not reported
+	body, err := json.Marshal(report)
+	require.NoError(t, err)
+	require.NotContains(t, string(body), relay.PublicKey.String())
+
+	// A new report is started
+	report = telemetry.report(start.Add(2 * time.Hour))
+	require.Equal(t, start.Add(time.Hour), report.From)
+	require.Equal(t, []TelemetryRelayStats{{Relay: relay.URL.Host}}, report.Relays)
+}
+
+func TestTelemetryNoise(t *testing.T) {
+	relays := []RelayEntry{newMockRelay(t).RelayEntry, newMockRelay(t).RelayEntry}
+	telemetry := newTelemetry("http://localhost", 0.5, "0x00000000", relays, time.Now())
+
+	// The budget covers the counts of every relay and one client which a getHeader call adds to
+	require.Equal(t, 10.0, telemetry.noiseScale())
+
+	var sum, changed uint64
+	for i := 0; i < 1000; i++ {
+		count := telemetry.noisy(100, 2)
+		sum += count
+		if count != 100 {
+			changed++
+		}
+	}
+	require.Greater(t, changed, uint64(500))
+	require.InDelta(t, 100, float64(sum)/1000, 1)
+
+	report := telemetry.report(time.Now())
+	require.Equal(t, 10.0, report.NoiseScale)
+}
+
+func TestLatencyHistogramPercentileMs(t *testing.T) {
+	buckets := make([]uint64, len(telemetryLatencyBucketsMs)+1)
+	require.Zero(t, latencyHistogramPercentileMs(buckets, 50))
+	buckets[0] = 1
+	buckets[len(buckets)-1] = 1
+	require.Equal(t, 25.0, latencyHistogramPercentileMs(buckets, 50))
+	require.Equal(t, 3000.0, latencyHistogramPercentileMs(buckets, 90))
+}
+
+func TestSubmitTelemetry(t *testing.T) {
+	var received []TelemetryReport
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := TelemetryReport{}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&report))
+		received = append(received, report)
+	}))
+	defer endpoint.Close()
+
+	backend := newTestBackend(t, 1, time.Second)
+	status := func() telemetryStatus {
+		rr := backend.request(t, http.MethodGet, pathMevBoostStatus, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		status := mevBoostStatusResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &status))
+		return status.Telemetry
+	}
+	require.Equal(t, telemetryStatus{}, status())
+
+	backend.boost.telemetry = newTelemetry(endpoint.URL, 1, "0x00000000", backend.boost.relays, time.Now())
+
+	// Pubkeys, slots and hashes of getHeader calls are not submitted
+	path := "/eth/v1/builder/header/1/0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7/0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	require.Equal(t, http.StatusOK, backend.request(t, http.MethodGet, path, nil).Code)
+	require.NoError(t, backend.boost.submitTelemetry(context.Background()))
+	require.Len(t, received, 1)
+	require.Equal(t, 3.0, received[0].NoiseScale)
+	require.Len(t, received[0].Relays, 1)
+	body, err := json.Marshal(received[0])
+	require.NoError(t, err)
+	require.False(t, strings.Contains(string(body), "0x8a1d7b8d"))
+
+	submitted := status()
+	require.True(t, submitted.Enabled)
+	require.NotNil(t, submitted.LastSubmitted)
+	require.Equal(t, received[0].Relays[0].Relay, submitted.LastReport.Relays[0].Relay)
+}
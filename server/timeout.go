@@ -0,0 +1,106 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RequestTimeouts are the per-endpoint deadlines for handling incoming requests. 0 disables a deadline.
+type RequestTimeouts struct {
+	GetHeader         time.Duration
+	GetPayload        time.Duration
+	RegisterValidator time.Duration
+}
+
+// timeoutWriter buffers the response of a handler, so it can be discarded if the deadline is exceeded
+type timeoutWriter struct {
+	mu          sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	code        int
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	return tw.buf.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.writeHeaderLocked(code)
+}
+
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	tw.wroteHeader = true
+	tw.code = code
+}
+
+// withTimeout runs the handler with a deadline. If the deadline is exceeded, a JSON error response is sent
+// instead of whatever the handler writes afterwards.
+func (m *BoostService) withTimeout(timeout time.Duration, handler http.HandlerFunc) http.HandlerFunc {
+	if timeout <= 0 {
+		return handler
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), timeout)
+		defer cancel()
+		req = req.WithContext(ctx)
+
+		tw := &timeoutWriter{header: make(http.Header)}
+		done := make(chan struct{})
+		panicCh := make(chan any, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicCh <- p
+				}
+			}()
+			handler(tw, req)
+			close(done)
+		}()
+
+		select {
+		case p := <-panicCh:
+			panic(p)
+		case <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			dst := w.Header()
+			for k, v := range tw.header {
+				dst[k] = v
+			}
+			if !tw.wroteHeader {
+				tw.code = http.StatusOK
+			}
+			w.WriteHeader(tw.code)
+			_, _ = w.Write(tw.buf.Bytes())
+		case <-ctx.Done():
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			tw.timedOut = true
+			m.requestLog(req).WithField("path", req.URL.Path).Warnf("request exceeded the deadline of %s", timeout.String())
+			m.respondError(w, http.StatusGatewayTimeout, errRequestTimeout.Error())
+		}
+	}
+}
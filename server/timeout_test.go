@@ -0,0 +1,52 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTimeout(t *testing.T) {
+	backend := newTestBackend(t, 1, time.Second)
+
+	t.Run("Response within deadline", func(t *testing.T) {
+		handler := backend.boost.withTimeout(100*time.Millisecond, func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("X-Test", "1")
+			backend.boost.respondError(w, http.StatusBadRequest, "bad request")
+		})
+
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Equal(t, "1", rr.Header().Get("X-Test"))
+		require.Equal(t, `{"code":400,"message":"bad request"}`+"\n", rr.Body.String())
+	})
+
+	t.Run("Deadline exceeded", func(t *testing.T) {
+		handler := backend.boost.withTimeout(10*time.Millisecond, func(w http.ResponseWriter, req *http.Request) {
+			<-req.Context().Done()
+			backend.boost.respondOK(w, nilResponse)
+		})
+
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusGatewayTimeout, rr.Code)
+		require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+		require.Equal(t, `{"code":504,"message":"request timeout"}`+"\n", rr.Body.String())
+	})
+
+	t.Run("Disabled deadline", func(t *testing.T) {
+		handler := backend.boost.withTimeout(0, func(w http.ResponseWriter, req *http.Request) {
+			_, hasDeadline := req.Context().Deadline()
+			require.False(t, hasDeadline)
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusNoContent, rr.Code)
+	})
+}
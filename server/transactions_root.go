@@ -0,0 +1,78 @@
+package server
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ssz "github.com/ferranbt/fastssz"
+	"github.com/flashbots/go-boost-utils/types"
+)
+
+// SSZ limits of the transactions of an execution payload
+const (
+	maxTransactionsPerPayload = 1048576
+	maxBytesPerTransaction    = 1073741824
+)
+
+// minParallelTransactions is the number of transactions from which their roots are hashed in parallel. The roots of
+// fewer transactions are hashed faster than the goroutines are started.
+const minParallelTransactions = 64
+
+// transactionsRoot returns the hash tree root of the transactions of a payload, which is the transactions root of
+// its header. The roots of the individual transactions, which make up most of the work, are hashed in parallel
+// across chunks of transactions. Bellatrix payloads carry no blobs, so there are no blob roots to hash.
+func transactionsRoot(txs []hexutil.Bytes) (types.Root, error) {
+	if len(txs) > maxTransactionsPerPayload {
+		return types.Root{}, ssz.ErrIncorrectListSize
+	}
+	for _, tx := range txs {
+		if len(tx) > maxBytesPerTransaction {
+			return types.Root{}, ssz.ErrIncorrectListSize
+		}
+	}
+
+	roots := make([][32]byte, len(txs))
+	workers := runtime.GOMAXPROCS(0)
+	if len(txs) < minParallelTransactions || workers == 1 {
+		hashTransactionRoots(txs, roots)
+	} else {
+		chunkSize := (len(txs) + workers - 1) / workers
+		var wg sync.WaitGroup
+		for start := 0; start < len(txs); start += chunkSize {
+			end := start + chunkSize
+			if end > len(txs) {
+				end = len(txs)
+			}
+			wg.Add(1)
+			go func(start, end int) {
+				defer wg.Done()
+				hashTransactionRoots(txs[start:end], roots[start:end])
+			}(start, end)
+		}
+		wg.Wait()
+	}
+
+	hh := ssz.DefaultHasherPool.Get()
+	defer ssz.DefaultHasherPool.Put(hh)
+	indx := hh.Index()
+	for _, root := range roots {
+		hh.Append(root[:])
+	}
+	hh.MerkleizeWithMixin(indx, uint64(len(txs)), maxTransactionsPerPayload)
+	root, err := hh.HashRoot()
+	return types.Root(root), err
+}
+
+// hashTransactionRoots hashes the root of each transaction into roots
+func hashTransactionRoots(txs []hexutil.Bytes, roots [][32]byte) {
+	hh := ssz.DefaultHasherPool.Get()
+	defer ssz.DefaultHasherPool.Put(hh)
+	for i, tx := range txs {
+		hh.Reset()
+		indx := hh.Index()
+		hh.AppendBytes32(tx)
+		hh.MerkleizeWithMixin(indx, uint64(len(tx)), (maxBytesPerTransaction+31)/32)
+		copy(roots[i][:], hh.Hash())
+	}
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionsRoot(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4)) // hash in parallel on any machine
+
+	for _, numTxs := range []int{0, 1, minParallelTransactions - 1, minParallelTransactions, 1000} {
+		payload := makeTestPayload(t, numTxs, 150)
+		payload.Transactions = append(payload.Transactions, []byte{}, make([]byte, 32), make([]byte, 33))
+
+		expected := makeTestHeader(t, payload)
+		root, err := transactionsRoot(payload.Transactions)
+		require.NoError(t, err)
+		require.Equal(t, expected.TransactionsRoot, root, "%d txs", numTxs)
+
+		header, err := payloadToHeader(payload)
+		require.NoError(t, err)
+		require.Equal(t, expected, header)
+	}
+}
+
+// BenchmarkTransactionsRootSerial is the baseline for BenchmarkTransactionsRoot, hashing the transactions on a
+// single goroutine
+func BenchmarkTransactionsRootSerial(b *testing.B) {
+	for _, numTxs := range []int{100, 500, 2000} {
+		payload := makeTestPayload(b, numTxs, 300)
+		b.Run(fmt.Sprintf("%d txs", numTxs), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := types.PayloadToPayloadHeader(&payload.ExecutionPayload); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
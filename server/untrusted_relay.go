@@ -0,0 +1,17 @@
+package server
+
+import (
+	"math"
+	"math/big"
+)
+
+// effectiveBidValue returns the value a bid of the relay is compared with in the bid selection: the bids of untrusted
+// relays are reduced by the margin they must exceed the trusted bids by
+func (m *BoostService) effectiveBidValue(valueWei *big.Int, relay RelayEntry) *big.Int {
+	if !relay.Untrusted || m.untrustedRelayBidMargin <= 0 {
+		return valueWei
+	}
+	bps := int64(math.Round(m.untrustedRelayBidMargin * 100)) // [basis points]
+	value := new(big.Int).Mul(valueWei, big.NewInt(10000))
+	return value.Quo(value, big.NewInt(10000+bps))
+}
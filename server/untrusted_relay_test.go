@@ -0,0 +1,61 @@
+package server
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEffectiveBidValue(t *testing.T) {
+	backend := newTestBackend(t, 1, time.Second)
+	backend.boost.untrustedRelayBidMargin = 5
+	relay := backend.relays[0].RelayEntry
+
+	require.Equal(t, big.NewInt(105), backend.boost.effectiveBidValue(big.NewInt(105), relay))
+	relay.Untrusted = true
+	require.Equal(t, big.NewInt(100), backend.boost.effectiveBidValue(big.NewInt(105), relay))
+}
+
+func TestMinSizeLimit(t *testing.T) {
+	require.Equal(t, int64(100), minSizeLimit(100, false, 10))
+	require.Equal(t, int64(10), minSizeLimit(100, true, 10))
+	require.Equal(t, int64(10), minSizeLimit(0, true, 10))
+	require.Equal(t, int64(5), minSizeLimit(5, true, 10))
+	require.Equal(t, int64(100), minSizeLimit(100, true, 0))
+}
+
+func TestUntrustedRelayBidMargin(t *testing.T) {
+	path := "/eth/v1/builder/header/1/0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7/0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	untrustedHash := "0xa18385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	trustedHash := "0xa28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+
+	backend := newTestBackend(t, 2, time.Second)
+	backend.boost.relays[0].Untrusted = true
+	backend.relays[0].GetHeaderResponse = backend.relays[0].MakeGetHeaderResponse(10400, untrustedHash, pubkey)
+	backend.relays[1].GetHeaderResponse = backend.relays[1].MakeGetHeaderResponse(10000, trustedHash, pubkey)
+
+	servedHash := func() string {
+		rr := backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		resp := new(types.GetHeaderResponse)
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		return resp.Data.Message.Header.BlockHash.String()
+	}
+
+	// The untrusted bid is 4% higher, which wins with a 3% margin but not with a 5% margin
+	backend.boost.untrustedRelayBidMargin = 5
+	require.Equal(t, trustedHash, servedHash())
+	backend.boost.untrustedRelayBidMargin = 3
+	require.Equal(t, untrustedHash, servedHash())
+
+	// Untrusted relays must follow the builder spec
+	backend.relays[0].GetHeaderResponse.Version = ""
+	backend.boost.untrustedRelayBidMargin = 0
+	require.Equal(t, trustedHash, servedHash())
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"net/http"
 	"strings"
 	"time"
@@ -22,58 +23,96 @@ type UserAgent string
 
 // SendHTTPRequest - prepare and send HTTP request, marshaling the payload if any, and decoding the response if dst is set
 func SendHTTPRequest(ctx context.Context, client http.Client, method, url string, userAgent UserAgent, payload any, dst any) (code int, err error) {
+	code, _, err = sendHTTPRequest(ctx, &client, method, url, userAgent, payload, dst, responseOpts{})
+	return code, err
+}
+
+// sendHTTPRequest is SendHTTPRequest, additionally returning the response headers, and applying the checks of opts
+// to the response. Response bodies larger than opts.maxSize are rejected with errResponseTooLarge.
+func sendHTTPRequest(ctx context.Context, client RelayClient, method, url string, userAgent UserAgent, payload any, dst any, opts responseOpts) (code int, header http.Header, err error) {
 	var req *http.Request
 
 	if payload == nil {
 		req, err = http.NewRequestWithContext(ctx, method, url, nil)
 	} else {
-		payloadBytes, err2 := json.Marshal(payload)
-		if err2 != nil {
-			return 0, fmt.Errorf("could not marshal request: %w", err2)
+		payloadBytes, encoded := payload.(encodedPayload)
+		if !encoded {
+			var err2 error
+			if payloadBytes, err2 = marshalJSON(opts.codec, payload); err2 != nil {
+				return 0, nil, fmt.Errorf("could not marshal request: %w", err2)
+			}
 		}
 		req, err = http.NewRequestWithContext(ctx, method, url, bytes.NewReader(payloadBytes))
+		if err != nil {
+			return 0, nil, fmt.Errorf("could not prepare request: %w", err)
+		}
 
-		// Set content-type
+		// Set content-type, and the checksum and idempotency key for deduping retries
 		req.Header.Add("Content-Type", "application/json")
+		setIdempotencyHeaders(req, payloadBytes)
 	}
 	if err != nil {
-		return 0, fmt.Errorf("could not prepare request: %w", err)
+		return 0, nil, fmt.Errorf("could not prepare request: %w", err)
 	}
 
 	// Set user agent
 	req.Header.Set("User-Agent", strings.TrimSpace(fmt.Sprintf("mev-boost/%s %s", config.Version, userAgent)))
+	if id := requestIDFromContext(ctx); id != "" {
+		req.Header.Set(HeaderRequestID, id)
+	}
 
 	// Execute request
 	resp, err := client.Do(req)
 	if err != nil {
-		return 0, err
+		return 0, nil, err
 	}
 	defer resp.Body.Close()
+	if opts.onTLS != nil && resp.TLS != nil {
+		opts.onTLS(resp.TLS)
+	}
 
 	if resp.StatusCode == http.StatusNoContent {
-		return resp.StatusCode, nil
+		return resp.StatusCode, resp.Header, nil
 	}
 
 	if resp.StatusCode > 299 {
 		bodyBytes, err := io.ReadAll(resp.Body)
 		if err != nil {
-			return resp.StatusCode, fmt.Errorf("could not read error response body for status code %d: %w", resp.StatusCode, err)
+			return resp.StatusCode, resp.Header, fmt.Errorf("could not read error response body for status code %d: %w", resp.StatusCode, err)
+		}
+		return resp.StatusCode, resp.Header, parseRelayError(resp.StatusCode, bodyBytes)
+	}
+
+	if dst != nil && opts.stream {
+		stats, err := decodeResponseStream(resp, dst, opts)
+		if opts.stats != nil {
+			*opts.stats = stats
 		}
-		return resp.StatusCode, fmt.Errorf("HTTP error response: %d / %s", resp.StatusCode, string(bodyBytes))
+		return resp.StatusCode, resp.Header, err
 	}
 
 	if dst != nil {
-		bodyBytes, err := io.ReadAll(resp.Body)
+		body := io.Reader(resp.Body)
+		if opts.maxSize > 0 {
+			body = io.LimitReader(resp.Body, opts.maxSize+1)
+		}
+		bodyBytes, err := io.ReadAll(body)
 		if err != nil {
-			return resp.StatusCode, fmt.Errorf("could not read response body: %w", err)
+			return resp.StatusCode, resp.Header, fmt.Errorf("could not read response body: %w", err)
+		}
+		if opts.maxSize > 0 && int64(len(bodyBytes)) > opts.maxSize {
+			return resp.StatusCode, resp.Header, fmt.Errorf("%w: more than %d bytes", errResponseTooLarge, opts.maxSize)
 		}
 
-		if err := json.Unmarshal(bodyBytes, dst); err != nil {
-			return resp.StatusCode, fmt.Errorf("could not unmarshal response %s: %w", string(bodyBytes), err)
+		if err := unmarshalJSON(opts.codec, bodyBytes, dst); err != nil {
+			return resp.StatusCode, resp.Header, fmt.Errorf("could not unmarshal response %s: %w", string(bodyBytes), err)
+		}
+		if err := opts.checkResponse(resp.Header, bodyBytes, dst); err != nil {
+			return resp.StatusCode, resp.Header, err
 		}
 	}
 
-	return resp.StatusCode, nil
+	return resp.StatusCode, resp.Header, nil
 }
 
 // ComputeDomain computes the signing domain
@@ -103,9 +142,26 @@ func DecodeJSON(r io.Reader, dst any) error {
 // bidResp are entries in the bids cache
 type bidResp struct {
 	t         time.Time
-	response  types.GetHeaderResponse
+	response  GetHeaderResponse
 	blockHash string
+	valueWei  *big.Int // bid value normalized to wei
 	relays    []string
+	pubkey    string // proposer pubkey of the getHeader request
+	servedAt  time.Time
+
+	rejections  []bidRejection // bids of the same request which were not selected
+	decision    decisionHashes
+	provenance  []bidProvenance // per relay which delivered the bid
+	attestation *signedBidAttestation
+}
+
+// getHeaderResult is the outcome of requesting bids from the relays
+type getHeaderResult struct {
+	bid       bidResp
+	isPartial bool   // the partial deadline was reached before all relays responded
+	coverage  string // number of relays which responded, out of the relays called
+	numBids   int    // number of valid bids received
+	cohort    string // experiment cohort of the request, if any
 }
 
 // bidRespKey is used as key for the bids cache
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// validationCostWeight is the weight of a new sample in the moving average of the bid validation cost
+const validationCostWeight = 8
+
+// validationCost is the moving average of the time it takes to validate a bid
+type validationCost struct {
+	avg int64 // [ns]
+}
+
+// add adds a validation time to the moving average
+func (c *validationCost) add(d time.Duration) {
+	for {
+		old := atomic.LoadInt64(&c.avg)
+		avg := int64(d)
+		if old > 0 {
+			avg = old + (int64(d)-old)/validationCostWeight
+		}
+		if atomic.CompareAndSwapInt64(&c.avg, old, avg) {
+			return
+		}
+	}
+}
+
+// get returns the moving average of the validation time
+func (c *validationCost) get() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.avg))
+}
+
+// newValidationCostMetric returns a gauge of the moving average of the bid validation cost
+func newValidationCostMetric(cost *validationCost) prometheus.GaugeFunc {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "mev_boost_bid_validation_cost_seconds",
+		Help: "Moving average of the time it takes to validate a bid",
+	}, func() float64 {
+		return cost.get().Seconds()
+	})
+}
+
+// validationBudget is the time left for validating the bids of a getHeader request
+type validationBudget struct {
+	deadline time.Time // zero without a deadline
+	cost     *validationCost
+	inFlight int32 // validations in progress
+}
+
+// newValidationBudget returns the validation budget of a getHeader request started at start, which ends at the
+// partial deadline or the getHeader timeout, whichever comes first
+func (m *BoostService) newValidationBudget(start time.Time) *validationBudget {
+	budget := &validationBudget{cost: m.validationCost}
+	for _, d := range []time.Duration{m.getHeaderPartialDeadline, m.requestTimeouts.GetHeader} {
+		if d > 0 && (budget.deadline.IsZero() || start.Add(d).Before(budget.deadline)) {
+			budget.deadline = start.Add(d)
+		}
+	}
+	return budget
+}
+
+// exhausted returns whether the time left at t is insufficient to validate another bid, next to the validations
+// in progress
+func (b *validationBudget) exhausted(t time.Time) bool {
+	if b.deadline.IsZero() || b.cost.get() <= 0 {
+		return false
+	}
+	pending := time.Duration(atomic.LoadInt32(&b.inFlight) + 1)
+	return b.deadline.Sub(t) < pending*b.cost.get()
+}
+
+// start marks the start of a validation, and returns the function to call at its end
+func (b *validationBudget) start() func(time.Duration) {
+	atomic.AddInt32(&b.inFlight, 1)
+	return func(d time.Duration) {
+		atomic.AddInt32(&b.inFlight, -1)
+		b.cost.add(d)
+	}
+}
+
+// canSkipValidation returns whether validating a bid can be skipped to stay within the budget, which is only the
+// case for bids losing to the best bid validated so far. Such bids would be rejected for lower value anyway.
+func canSkipValidation(value, bestValue *big.Int, blockHash, bestBlockHash string) bool {
+	if bestValue == nil || blockHash == bestBlockHash {
+		return false
+	}
+	diff := value.Cmp(bestValue)
+	return diff < 0 || (diff == 0 && blockHash >= bestBlockHash)
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidationCost(t *testing.T) {
+	cost := new(validationCost)
+	cost.add(8 * time.Millisecond)
+	require.Equal(t, 8*time.Millisecond, cost.get())
+	cost.add(16 * time.Millisecond)
+	require.Equal(t, 9*time.Millisecond, cost.get())
+}
+
+func TestValidationBudget(t *testing.T) {
+	start := time.Now()
+	budget := &validationBudget{deadline: start.Add(10 * time.Millisecond), cost: new(validationCost)}
+	require.False(t, budget.exhausted(start), "no exhausted budget without validation cost samples")
+
+	budget.cost.add(4 * time.Millisecond)
+	require.False(t, budget.exhausted(start))
+	done := budget.start()
+	done2 := budget.start()
+	require.True(t, budget.exhausted(start), "two validations in progress and another one exceed the budget")
+	done(4 * time.Millisecond)
+	done2(4 * time.Millisecond)
+	require.True(t, budget.exhausted(start.Add(7*time.Millisecond)))
+
+	require.False(t, (&validationBudget{cost: budget.cost}).exhausted(start), "no exhausted budget without deadline")
+}
+
+func TestCanSkipValidation(t *testing.T) {
+	require.False(t, canSkipValidation(big.NewInt(1), nil, "0xa", ""))
+	require.True(t, canSkipValidation(big.NewInt(1), big.NewInt(2), "0xa", "0xb"))
+	require.False(t, canSkipValidation(big.NewInt(3), big.NewInt(2), "0xa", "0xb"))
+	require.False(t, canSkipValidation(big.NewInt(1), big.NewInt(2), "0xb", "0xb"), "relays delivering the best bid are kept")
+	require.True(t, canSkipValidation(big.NewInt(2), big.NewInt(2), "0xc", "0xb"))
+	require.False(t, canSkipValidation(big.NewInt(2), big.NewInt(2), "0xa", "0xb"), "ties are decided by the block hash")
+}
+
+func TestSkipValidationOutOfBudget(t *testing.T) {
+	hash := "0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"
+	pubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	backend := newTestBackend(t, 3, time.Second)
+	backend.boost.requestTimeouts.GetHeader = time.Second
+	backend.boost.validationCost.add(time.Hour)
+
+	// The best bid is validated even without budget, as are higher bids arriving later
+	backend.relays[1].GetHeaderResponse = backend.relays[1].MakeGetHeaderResponse(1, "0xb38385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7", pubkey)
+	backend.relays[1].ResponseDelay = 100 * time.Millisecond
+	backend.relays[2].GetHeaderResponse = backend.relays[2].MakeGetHeaderResponse(20000, "0xc38385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7", pubkey)
+	backend.relays[2].ResponseDelay = 200 * time.Millisecond
+
+	result := backend.boost.requestBids(context.Background(), testLog, 1, hash, pubkey, "")
+	require.Equal(t, "0xc38385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7", result.bid.blockHash)
+
+	relay := backend.relays[1].RelayEntry.String()
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.boost.metrics.relayDroppedBids.WithLabelValues(relay, string(BidRejectionValidationSkipped))))
+	require.Equal(t, uint64(1), backend.boost.bidRejections.snapshot()[relay][BidRejectionValidationSkipped])
+	require.Equal(t, 2, result.numBids)
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/flashbots/go-boost-utils/types"
+)
+
+// ValueUnit is the unit in which a relay reports bid values
+type ValueUnit string
+
+// Supported value units
+const (
+	ValueUnitWei  ValueUnit = "wei"
+	ValueUnitGwei ValueUnit = "gwei"
+)
+
+var (
+	weiPerGwei = big.NewInt(1_000_000_000)
+
+	// minPlausibleBidValueWei is the lowest plausible bid value in wei. Lower values are most likely
+	// reported in gwei by a misconfigured relay.
+	minPlausibleBidValueWei = big.NewInt(1_000_000_000)
+)
+
+// ParseRelayValueUnits parses a comma-separated list of HOST=UNIT entries into value units per relay host
+func ParseRelayValueUnits(s string) (map[string]ValueUnit, error) {
+	ret := make(map[string]ValueUnit)
+	if strings.TrimSpace(s) == "" {
+		return ret, nil
+	}
+
+	for _, entry := range strings.Split(s, ",") {
+		host, unit, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || host == "" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidValueUnit, entry)
+		}
+
+		switch ValueUnit(strings.ToLower(unit)) {
+		case ValueUnitWei:
+			ret[host] = ValueUnitWei
+		case ValueUnitGwei:
+			ret[host] = ValueUnitGwei
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrInvalidValueUnit, unit)
+		}
+	}
+	return ret, nil
+}
+
+// normalizeBidValue returns the bid value in wei, given the unit the relay reports values in
+func normalizeBidValue(value *types.U256Str, unit ValueUnit) *big.Int {
+	v := value.BigInt()
+	if unit == ValueUnitGwei {
+		v.Mul(v, weiPerGwei)
+	}
+	return v
+}
+
+// isImplausibleBidValue returns whether a non-zero bid value in wei is so low that the relay most likely
+// reports values in a different unit. Such values are only alarmed and never normalized heuristically,
+// since a relay could otherwise inflate its bids.
+func isImplausibleBidValue(valueWei *big.Int) bool {
+	return valueWei.Sign() > 0 && valueWei.Cmp(minPlausibleBidValueWei) < 0
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRelayValueUnits(t *testing.T) {
+	units, err := ParseRelayValueUnits("")
+	require.NoError(t, err)
+	require.Len(t, units, 0)
+
+	units, err = ParseRelayValueUnits("foo.com=GWEI, bar.com:9000=wei")
+	require.NoError(t, err)
+	require.Equal(t, map[string]ValueUnit{"foo.com": ValueUnitGwei, "bar.com:9000": ValueUnitWei}, units)
+
+	_, err = ParseRelayValueUnits("foo.com=eth")
+	require.True(t, errors.Is(err, ErrInvalidValueUnit), err)
+
+	_, err = ParseRelayValueUnits("gwei")
+	require.True(t, errors.Is(err, ErrInvalidValueUnit), err)
+}
+
+func TestNormalizeBidValue(t *testing.T) {
+	value := types.IntToU256(12345)
+	require.Equal(t, big.NewInt(12345), normalizeBidValue(&value, ""))
+	require.Equal(t, big.NewInt(12345), normalizeBidValue(&value, ValueUnitWei))
+	require.Equal(t, big.NewInt(12345_000_000_000), normalizeBidValue(&value, ValueUnitGwei))
+
+	// The original value is not modified
+	require.Equal(t, "12345", value.String())
+}
+
+func TestIsImplausibleBidValue(t *testing.T) {
+	require.False(t, isImplausibleBidValue(big.NewInt(0)))
+	require.True(t, isImplausibleBidValue(big.NewInt(50_000_000)))     // 0.05 ETH in gwei
+	require.False(t, isImplausibleBidValue(big.NewInt(1_000_000_000))) // 1 gwei in wei
+}